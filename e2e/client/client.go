@@ -88,6 +88,8 @@ type TestClient struct {
 	datasetService       asset.DatasetServiceClient
 	eventService         asset.EventServiceClient
 	failureReportService asset.FailureReportServiceClient
+	archiveService       asset.ArchiveServiceClient
+	webhookService       asset.WebhookServiceClient
 }
 
 type TestClientFactory struct {
@@ -136,6 +138,8 @@ func (f *TestClientFactory) NewTestClient() *TestClient {
 		datasetService:       asset.NewDatasetServiceClient(f.conn),
 		eventService:         asset.NewEventServiceClient(f.conn),
 		failureReportService: asset.NewFailureReportServiceClient(f.conn),
+		archiveService:       asset.NewArchiveServiceClient(f.conn),
+		webhookService:       asset.NewWebhookServiceClient(f.conn),
 	}
 
 	client.EnsureOrganization()
@@ -309,12 +313,25 @@ func (c *TestClient) RegisterTasks(optList ...Taskable) []*asset.ComputeTask {
 }
 
 func (c *TestClient) FailableRegisterTasks(optList ...Taskable) (*asset.RegisterTasksResponse, error) {
+	return c.registerTasks(false, optList...)
+}
+
+// ValidateTasks registers the tasks in validate only mode: tasks are returned but not persisted.
+func (c *TestClient) ValidateTasks(optList ...Taskable) []*asset.ComputeTask {
+	res, err := c.registerTasks(true, optList...)
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("ValidateTasks failed")
+	}
+	return res.Tasks
+}
+
+func (c *TestClient) registerTasks(validateOnly bool, optList ...Taskable) (*asset.RegisterTasksResponse, error) {
 	newTasks := make([]*asset.NewComputeTask, len(optList))
 	for i, o := range optList {
 		newTasks[i] = o.GetNewTask(c.ks)
 	}
-	c.logger.Debug().Int("nbTasks", len(newTasks)).Msg("registering tasks")
-	return c.computeTaskService.RegisterTasks(c.ctx, &asset.RegisterTasksParam{Tasks: newTasks})
+	c.logger.Debug().Int("nbTasks", len(newTasks)).Bool("validateOnly", validateOnly).Msg("registering tasks")
+	return c.computeTaskService.RegisterTasks(c.ctx, &asset.RegisterTasksParam{Tasks: newTasks, ValidateOnly: validateOnly})
 }
 
 func (c *TestClient) StartTask(keyRef string) {
@@ -333,16 +350,24 @@ func (c *TestClient) DoneTask(keyRef string) {
 	c.applyTaskTransition(keyRef, asset.ComputeTaskAction_TASK_ACTION_DONE)
 }
 
+func (c *TestClient) RetryTask(keyRef string) {
+	c.applyTaskTransition(keyRef, asset.ComputeTaskAction_TASK_ACTION_RETRY)
+}
+
 func (c *TestClient) applyTaskTransition(keyRef string, action asset.ComputeTaskAction) {
+	_, err := c.FailableApplyTaskAction(keyRef, action)
+	if err != nil {
+		c.logger.Fatal().Err(err).Msgf("failed to mark task as %v", action)
+	}
+}
+
+func (c *TestClient) FailableApplyTaskAction(keyRef string, action asset.ComputeTaskAction) (*asset.ApplyTaskActionResponse, error) {
 	taskKey := c.ks.GetKey(keyRef)
 	c.logger.Debug().Str("taskKey", taskKey).Str("action", action.String()).Msg("applying task action")
-	_, err := c.computeTaskService.ApplyTaskAction(c.ctx, &asset.ApplyTaskActionParam{
+	return c.computeTaskService.ApplyTaskAction(c.ctx, &asset.ApplyTaskActionParam{
 		ComputeTaskKey: taskKey,
 		Action:         action,
 	})
-	if err != nil {
-		c.logger.Fatal().Err(err).Msgf("failed to mark task as %v", action)
-	}
 }
 
 func (c *TestClient) RegisterModel(o *ModelOptions) *asset.Model {
@@ -406,8 +431,9 @@ func (c *TestClient) DisableOutput(taskRef string, identifier string) {
 
 func (c *TestClient) RegisterComputePlan(o *ComputePlanOptions) *asset.ComputePlan {
 	newCp := &asset.NewComputePlan{
-		Key:  c.ks.GetKey(o.KeyRef),
-		Name: "Compute plan test",
+		Key:             c.ks.GetKey(o.KeyRef),
+		Name:            "Compute plan test",
+		MaxTaskAttempts: o.MaxTaskAttempts,
 	}
 	c.logger.Debug().Interface("plan", newCp).Msg("registering compute plan")
 	plan, err := c.computePlanService.RegisterPlan(c.ctx, newCp)
@@ -436,7 +462,18 @@ func (c *TestClient) GetComputeTask(keyRef string) *asset.ComputeTask {
 }
 
 func (c *TestClient) QueryTasks(filter *asset.TaskQueryFilter, pageToken string, pageSize int) *asset.QueryTasksResponse {
-	resp, err := c.computeTaskService.QueryTasks(c.ctx, &asset.QueryTasksParam{Filter: filter, PageToken: pageToken, PageSize: uint32(pageSize)})
+	return c.QuerySortedTasks(filter, asset.TaskSortField_TASK_SORT_CREATION_DATE, asset.SortOrder_ASCENDING, pageToken, pageSize)
+}
+
+func (c *TestClient) QuerySortedTasks(filter *asset.TaskQueryFilter, sortBy asset.TaskSortField, sortOrder asset.SortOrder, pageToken string, pageSize int) *asset.QueryTasksResponse {
+	param := &asset.QueryTasksParam{
+		Filter:    filter,
+		PageToken: pageToken,
+		PageSize:  uint32(pageSize),
+		SortBy:    sortBy,
+		Sort:      sortOrder,
+	}
+	resp, err := c.computeTaskService.QueryTasks(c.ctx, param)
 	if err != nil {
 		c.logger.Fatal().Err(err).Msg("QueryTasks failed")
 	}
@@ -592,6 +629,16 @@ func (c *TestClient) SubscribeToEvents(startEventID string) (asset.EventService_
 	return stream, cancel
 }
 
+func (c *TestClient) SubscribeToFilteredEvents(startEventID string, filter *asset.EventQueryFilter) (asset.EventService_SubscribeToEventsClient, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(c.ctx)
+
+	stream, err := c.eventService.SubscribeToEvents(ctx, &asset.SubscribeToEventsParam{StartEventId: startEventID, Filter: filter})
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("SubscribeToEvents failed")
+	}
+	return stream, cancel
+}
+
 func (c *TestClient) QueryPlans(filter *asset.PlanQueryFilter, pageToken string, pageSize int) *asset.QueryPlansResponse {
 	resp, err := c.computePlanService.QueryPlans(c.ctx, &asset.QueryPlansParam{Filter: filter, PageToken: pageToken, PageSize: uint32(pageSize)})
 	if err != nil {
@@ -645,6 +692,26 @@ func (c *TestClient) CancelComputePlan(computePlanRef string) (*asset.ApplyPlanA
 	return c.computePlanService.ApplyPlanAction(c.ctx, param)
 }
 
+func (c *TestClient) PauseComputePlan(computePlanRef string) (*asset.ApplyPlanActionResponse, error) {
+	param := &asset.ApplyPlanActionParam{
+		Key:    c.ks.GetKey(computePlanRef),
+		Action: asset.ComputePlanAction_PLAN_ACTION_PAUSED,
+	}
+
+	c.logger.Debug().Str("compute plan key", computePlanRef).Msg("pausing compute plan")
+	return c.computePlanService.ApplyPlanAction(c.ctx, param)
+}
+
+func (c *TestClient) ResumeComputePlan(computePlanRef string) (*asset.ApplyPlanActionResponse, error) {
+	param := &asset.ApplyPlanActionParam{
+		Key:    c.ks.GetKey(computePlanRef),
+		Action: asset.ComputePlanAction_PLAN_ACTION_RESUMED,
+	}
+
+	c.logger.Debug().Str("compute plan key", computePlanRef).Msg("resuming compute plan")
+	return c.computePlanService.ApplyPlanAction(c.ctx, param)
+}
+
 func (c *TestClient) UpdateComputePlan(computePlanRef string, name string) *asset.UpdateComputePlanResponse {
 	param := &asset.UpdateComputePlanParam{
 		Key:  c.ks.GetKey(computePlanRef),
@@ -672,6 +739,20 @@ func (c *TestClient) IsPlanRunning(computePlanRef string) *asset.IsPlanRunningRe
 	return resp
 }
 
+func (c *TestClient) GetPlanStatistics(computePlanRef string) *asset.ComputePlanStatistics {
+	param := &asset.GetPlanStatisticsParam{
+		Key: c.ks.GetKey(computePlanRef),
+	}
+
+	c.logger.Debug().Str("compute plan key", computePlanRef).Msg("getting compute plan statistics")
+
+	resp, err := c.computePlanService.GetPlanStatistics(c.ctx, param)
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("GetPlanStatistics failed")
+	}
+	return resp
+}
+
 func (c *TestClient) makeNewModel(o *ModelOptions) *asset.NewModel {
 	return &asset.NewModel{
 		ComputeTaskKey:              c.ks.GetKey(o.TaskRef),
@@ -683,3 +764,184 @@ func (c *TestClient) makeNewModel(o *ModelOptions) *asset.NewModel {
 		},
 	}
 }
+
+func (c *TestClient) ArchivePlan(computePlanRef string) *asset.ComputePlanArchive {
+	param := &asset.ArchivePlanParam{
+		Key: c.ks.GetKey(computePlanRef),
+	}
+
+	c.logger.Debug().Str("compute plan key", computePlanRef).Msg("archiving compute plan")
+	archive, err := c.archiveService.ArchivePlan(c.ctx, param)
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("ArchivePlan failed")
+	}
+	return archive
+}
+
+func (c *TestClient) PurgePlan(computePlanRef string) (*asset.PurgePlanResponse, error) {
+	param := &asset.PurgePlanParam{
+		Key: c.ks.GetKey(computePlanRef),
+	}
+
+	c.logger.Debug().Str("compute plan key", computePlanRef).Msg("purging compute plan")
+	return c.archiveService.PurgePlan(c.ctx, param)
+}
+
+func (c *TestClient) GetPlanGraph(computePlanRef string) *asset.ComputePlanGraph {
+	param := &asset.GetPlanGraphParam{
+		Key: c.ks.GetKey(computePlanRef),
+	}
+
+	c.logger.Debug().Str("compute plan key", computePlanRef).Msg("getting compute plan graph")
+
+	resp, err := c.computePlanService.GetPlanGraph(c.ctx, param)
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("GetPlanGraph failed")
+	}
+	return resp
+}
+
+func (c *TestClient) RegisterWebhook(webhookRef string, url string, filter *asset.EventQueryFilter) *asset.Webhook {
+	newWebhook := &asset.NewWebhook{
+		Key:    c.ks.GetKey(webhookRef),
+		Url:    url,
+		Filter: filter,
+		Secret: "0123456789abcdef",
+	}
+
+	c.logger.Debug().Interface("webhook", newWebhook).Msg("registering webhook")
+	webhook, err := c.webhookService.RegisterWebhook(c.ctx, newWebhook)
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("RegisterWebhook failed")
+	}
+	return webhook
+}
+
+func (c *TestClient) GetWebhook(webhookRef string) *asset.Webhook {
+	param := &asset.GetWebhookParam{
+		Key: c.ks.GetKey(webhookRef),
+	}
+
+	c.logger.Debug().Str("webhook key", param.Key).Msg("getting webhook")
+	webhook, err := c.webhookService.GetWebhook(c.ctx, param)
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("GetWebhook failed")
+	}
+	return webhook
+}
+
+func (c *TestClient) FailableGetWebhook(webhookRef string) (*asset.Webhook, error) {
+	param := &asset.GetWebhookParam{
+		Key: c.ks.GetKey(webhookRef),
+	}
+
+	c.logger.Debug().Str("webhook key", param.Key).Msg("getting webhook")
+	return c.webhookService.GetWebhook(c.ctx, param)
+}
+
+func (c *TestClient) QueryWebhooks(pageToken string, pageSize int) *asset.QueryWebhooksResponse {
+	resp, err := c.webhookService.QueryWebhooks(c.ctx, &asset.QueryWebhooksParam{PageToken: pageToken, PageSize: uint32(pageSize)})
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("QueryWebhooks failed")
+	}
+	return resp
+}
+
+func (c *TestClient) UpdateWebhook(webhookRef string, url string, filter *asset.EventQueryFilter) *asset.Webhook {
+	param := &asset.UpdateWebhookParam{
+		Key:    c.ks.GetKey(webhookRef),
+		Url:    url,
+		Filter: filter,
+	}
+
+	c.logger.Debug().Str("webhook key", param.Key).Msg("updating webhook")
+	webhook, err := c.webhookService.UpdateWebhook(c.ctx, param)
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("UpdateWebhook failed")
+	}
+	return webhook
+}
+
+func (c *TestClient) DeleteWebhook(webhookRef string) {
+	param := &asset.DeleteWebhookParam{
+		Key: c.ks.GetKey(webhookRef),
+	}
+
+	c.logger.Debug().Str("webhook key", param.Key).Msg("deleting webhook")
+	_, err := c.webhookService.DeleteWebhook(c.ctx, param)
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("DeleteWebhook failed")
+	}
+}
+
+func (c *TestClient) QueryWebhookDeliveries(webhookRef string, status asset.WebhookDeliveryStatus, pageToken string, pageSize int) *asset.QueryWebhookDeliveriesResponse {
+	param := &asset.QueryWebhookDeliveriesParam{
+		WebhookKey: c.ks.GetKey(webhookRef),
+		Status:     status,
+		PageToken:  pageToken,
+		PageSize:   uint32(pageSize),
+	}
+
+	resp, err := c.webhookService.QueryWebhookDeliveries(c.ctx, param)
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("QueryWebhookDeliveries failed")
+	}
+	return resp
+}
+
+func (c *TestClient) VerifyEventChain() *asset.EventChainStatus {
+	status, err := c.eventService.VerifyEventChain(c.ctx, &asset.VerifyEventChainParam{})
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("VerifyEventChain failed")
+	}
+	return status
+}
+
+func (c *TestClient) SubscribeAsConsumer(consumer string) (asset.EventService_SubscribeToEventsClient, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(c.ctx)
+
+	stream, err := c.eventService.SubscribeToEvents(ctx, &asset.SubscribeToEventsParam{Consumer: consumer})
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("SubscribeToEvents failed")
+	}
+	return stream, cancel
+}
+
+// SubscribeToChannels streams the events of the client channel along with the events of the other given channels.
+func (c *TestClient) SubscribeToChannels(otherChannels []string, cursors []*asset.ChannelCursor) (asset.EventService_SubscribeToChannelsClient, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(c.ctx)
+	for _, channel := range otherChannels {
+		ctx = metadata.AppendToOutgoingContext(ctx, "channel", channel)
+	}
+
+	stream, err := c.eventService.SubscribeToChannels(ctx, &asset.SubscribeToChannelsParam{Cursors: cursors})
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("SubscribeToChannels failed")
+	}
+	return stream, cancel
+}
+
+func (c *TestClient) WatchComputePlan(planRef string) (asset.EventService_WatchComputePlanClient, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(c.ctx)
+
+	stream, err := c.eventService.WatchComputePlan(ctx, &asset.WatchComputePlanParam{Key: c.ks.GetKey(planRef)})
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("WatchComputePlan failed")
+	}
+	return stream, cancel
+}
+
+func (c *TestClient) AckEvents(consumer string, eventID string) {
+	_, err := c.eventService.AckEvents(c.ctx, &asset.AckEventsParam{Consumer: consumer, EventId: eventID})
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("AckEvents failed")
+	}
+}
+
+func (c *TestClient) QueryEventConsumers(pageToken string, pageSize int) *asset.QueryEventConsumersResponse {
+	resp, err := c.eventService.QueryEventConsumers(c.ctx, &asset.QueryEventConsumersParam{PageToken: pageToken, PageSize: uint32(pageSize)})
+	if err != nil {
+		c.logger.Fatal().Err(err).Msg("QueryEventConsumers failed")
+	}
+	return resp
+}
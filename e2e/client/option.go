@@ -9,7 +9,8 @@ import (
 )
 
 type ComputePlanOptions struct {
-	KeyRef string
+	KeyRef          string
+	MaxTaskAttempts uint32
 }
 
 type FunctionOptions struct {
@@ -448,6 +449,11 @@ func (o *ComputePlanOptions) WithKeyRef(ref string) *ComputePlanOptions {
 	return o
 }
 
+func (o *ComputePlanOptions) WithMaxTaskAttempts(attempts uint32) *ComputePlanOptions {
+	o.MaxTaskAttempts = attempts
+	return o
+}
+
 func DefaultModelOptions() *ModelOptions {
 	return &ModelOptions{
 		KeyRef:     DefaultModelRef,
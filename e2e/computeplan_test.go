@@ -714,3 +714,148 @@ func TestIsPlanRunning(t *testing.T) {
 	resp = appClient.IsPlanRunning("cp3")
 	require.False(t, resp.IsRunning)
 }
+
+// TestGetPlanStatistics ensures that task counts, timings and the derived status
+// of a compute plan are consistent with its tasks.
+func TestGetPlanStatistics(t *testing.T) {
+	appClient := factory.NewTestClient()
+	appClient.RegisterFunction(client.DefaultSimpleFunctionOptions())
+	appClient.RegisterDataManager(client.DefaultDataManagerOptions())
+	appClient.RegisterDataSample(client.DefaultDataSampleOptions())
+	appClient.RegisterComputePlan(client.DefaultComputePlanOptions())
+
+	stats := appClient.GetPlanStatistics(client.DefaultPlanRef)
+	require.Equal(t, asset.ComputePlanStatus_PLAN_STATUS_EMPTY, stats.Status)
+	require.Equal(t, uint32(0), stats.TaskCount)
+
+	appClient.RegisterTasks(client.DefaultTrainTaskOptions())
+	appClient.RegisterTasks(client.DefaultTrainTaskOptions().WithKeyRef("task2"))
+	appClient.SetReadyFromWaitingFunction(client.DefaultSimpleFunctionRef)
+	appClient.StartTask(client.DefaultTrainTaskRef)
+
+	stats = appClient.GetPlanStatistics(client.DefaultPlanRef)
+	require.Equal(t, asset.ComputePlanStatus_PLAN_STATUS_RUNNING, stats.Status)
+	require.Equal(t, uint32(2), stats.TaskCount)
+	require.NotNil(t, stats.FirstTaskStartDate)
+	require.Nil(t, stats.LastTaskCompletionDate)
+
+	appClient.RegisterModel(client.DefaultModelOptions())
+	appClient.DoneTask(client.DefaultTrainTaskRef)
+	appClient.StartTask("task2")
+	appClient.RegisterModel(client.DefaultModelOptions().WithTaskRef("task2").WithKeyRef("model2"))
+	appClient.DoneTask("task2")
+
+	stats = appClient.GetPlanStatistics(client.DefaultPlanRef)
+	require.Equal(t, asset.ComputePlanStatus_PLAN_STATUS_DONE, stats.Status)
+	require.Len(t, stats.TaskCounts, 1)
+	require.Equal(t, asset.ComputeTaskStatus_STATUS_DONE, stats.TaskCounts[0].Status)
+	require.Equal(t, uint32(2), stats.TaskCounts[0].Count)
+	require.NotNil(t, stats.LastTaskCompletionDate)
+	require.False(t, stats.LastTaskCompletionDate.AsTime().Before(stats.FirstTaskStartDate.AsTime()))
+}
+
+func TestPauseComputePlan(t *testing.T) {
+	appClient := factory.NewTestClient()
+	appClient.RegisterFunction(client.DefaultSimpleFunctionOptions())
+	appClient.RegisterDataManager(client.DefaultDataManagerOptions())
+	appClient.RegisterDataSample(client.DefaultDataSampleOptions())
+	appClient.RegisterComputePlan(client.DefaultComputePlanOptions())
+
+	appClient.RegisterTasks(client.DefaultTrainTaskOptions())
+	appClient.RegisterTasks(client.DefaultTrainTaskOptions().
+		WithKeyRef("child").
+		WithInput("model", &client.TaskOutputRef{TaskRef: client.DefaultTrainTaskRef, Identifier: "model"}))
+	appClient.SetReadyFromWaitingFunction(client.DefaultSimpleFunctionRef)
+	appClient.StartTask(client.DefaultTrainTaskRef)
+
+	_, err := appClient.PauseComputePlan(client.DefaultPlanRef)
+	require.NoError(t, err)
+
+	_, err = appClient.PauseComputePlan(client.DefaultPlanRef)
+	require.Error(t, err, "pausing an already paused plan should fail")
+
+	stats := appClient.GetPlanStatistics(client.DefaultPlanRef)
+	require.Equal(t, asset.ComputePlanStatus_PLAN_STATUS_PAUSED, stats.Status)
+
+	// The running task can complete, but its child is held
+	appClient.RegisterModel(client.DefaultModelOptions())
+	appClient.DoneTask(client.DefaultTrainTaskRef)
+
+	child := appClient.GetComputeTask("child")
+	require.Equal(t, asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS, child.Status)
+
+	_, err = appClient.ResumeComputePlan(client.DefaultPlanRef)
+	require.NoError(t, err)
+
+	child = appClient.GetComputeTask("child")
+	require.Equal(t, asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT, child.Status)
+
+	plan := appClient.GetComputePlan(client.DefaultPlanRef)
+	require.NotNil(t, plan.PauseDate)
+	require.NotNil(t, plan.ResumeDate)
+}
+
+func TestGetPlanGraph(t *testing.T) {
+	appClient := factory.NewTestClient()
+	appClient.RegisterFunction(client.DefaultSimpleFunctionOptions())
+	appClient.RegisterFunction(client.DefaultPredictFunctionOptions())
+	appClient.RegisterDataManager(client.DefaultDataManagerOptions())
+	appClient.RegisterDataSample(client.DefaultDataSampleOptions())
+	appClient.RegisterComputePlan(client.DefaultComputePlanOptions())
+
+	appClient.RegisterTasks(client.DefaultTrainTaskOptions())
+	appClient.RegisterTasks(client.DefaultPredictTaskOptions().WithInput("model", &client.TaskOutputRef{TaskRef: client.DefaultTrainTaskRef, Identifier: "model"}))
+
+	graph := appClient.GetPlanGraph(client.DefaultPlanRef)
+	ks := appClient.GetKeyStore()
+
+	require.Len(t, graph.Nodes, 2)
+	require.Len(t, graph.Edges, 1)
+	require.Equal(t, ks.GetKey(client.DefaultTrainTaskRef), graph.Edges[0].ParentTaskKey)
+	require.Equal(t, "model", graph.Edges[0].ParentTaskOutputIdentifier)
+	require.Equal(t, ks.GetKey(client.DefaultPredictTaskRef), graph.Edges[0].ChildTaskKey)
+	require.Equal(t, "model", graph.Edges[0].ChildTaskInputIdentifier)
+
+	require.Contains(t, graph.ToDOT(), ks.GetKey(client.DefaultPredictTaskRef))
+	require.Contains(t, graph.ToMermaid(), "flowchart TD")
+}
+
+func TestPurgeComputePlan(t *testing.T) {
+	appClient := factory.NewTestClient()
+	appClient.RegisterFunction(client.DefaultSimpleFunctionOptions())
+	appClient.RegisterDataManager(client.DefaultDataManagerOptions())
+	appClient.RegisterDataSample(client.DefaultDataSampleOptions())
+	appClient.RegisterComputePlan(client.DefaultComputePlanOptions())
+
+	appClient.RegisterTasks(client.DefaultTrainTaskOptions())
+	appClient.SetReadyFromWaitingFunction(client.DefaultSimpleFunctionRef)
+	appClient.StartTask(client.DefaultTrainTaskRef)
+
+	_, err := appClient.PurgePlan(client.DefaultPlanRef)
+	require.Error(t, err, "purging a running plan should fail")
+
+	appClient.RegisterModel(client.DefaultModelOptions())
+	appClient.DoneTask(client.DefaultTrainTaskRef)
+
+	archive := appClient.ArchivePlan(client.DefaultPlanRef)
+	require.Len(t, archive.Tasks, 1)
+	require.Len(t, archive.Models, 1)
+	require.Len(t, archive.OutputAssets, 1)
+	require.NotEmpty(t, archive.Events)
+
+	resp, err := appClient.PurgePlan(client.DefaultPlanRef)
+	require.NoError(t, err)
+	require.NotNil(t, resp.ComputePlan.PurgeDate)
+	require.Equal(t, uint32(1), resp.ComputePlan.PurgeSummary.TaskCount)
+	require.Equal(t, uint32(1), resp.ComputePlan.PurgeSummary.ModelCount)
+
+	plan := appClient.GetComputePlan(client.DefaultPlanRef)
+	require.NotNil(t, plan.PurgeDate)
+
+	stats := appClient.GetPlanStatistics(client.DefaultPlanRef)
+	require.Equal(t, asset.ComputePlanStatus_PLAN_STATUS_PURGED, stats.Status)
+	require.Equal(t, uint32(0), stats.TaskCount)
+
+	_, err = appClient.PurgePlan(client.DefaultPlanRef)
+	require.Error(t, err, "purging an already purged plan should fail")
+}
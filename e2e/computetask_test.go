@@ -37,6 +37,76 @@ func TestRegisterComputeTask(t *testing.T) {
 	e2erequire.ProtoEqual(t, registeredTask, retrievedTask)
 }
 
+func TestValidateComputeTasks(t *testing.T) {
+	appClient := factory.NewTestClient()
+	ks := appClient.GetKeyStore()
+
+	appClient.RegisterFunction(client.DefaultSimpleFunctionOptions())
+	appClient.RegisterDataManager(client.DefaultDataManagerOptions())
+	appClient.RegisterDataSample(client.DefaultDataSampleOptions())
+	appClient.RegisterComputePlan(client.DefaultComputePlanOptions())
+
+	// Child is given first to check that tasks are sorted as they would be on registration
+	validated := appClient.ValidateTasks(
+		client.DefaultTrainTaskOptions().
+			WithKeyRef("child").
+			WithInput("model", &client.TaskOutputRef{TaskRef: client.DefaultTrainTaskRef, Identifier: "model"}),
+		client.DefaultTrainTaskOptions(),
+	)
+	require.Len(t, validated, 2)
+	require.Equal(t, ks.GetKey(client.DefaultTrainTaskRef), validated[0].Key)
+	require.Equal(t, int32(0), validated[0].Rank)
+	require.Equal(t, ks.GetKey("child"), validated[1].Key)
+	require.Equal(t, int32(1), validated[1].Rank)
+	require.NotEmpty(t, validated[1].Worker)
+	require.NotNil(t, validated[1].LogsPermission)
+
+	// Nothing has been persisted
+	resp := appClient.QueryTasks(&asset.TaskQueryFilter{ComputePlanKey: ks.GetKey(client.DefaultPlanRef)}, "", 10)
+	require.Len(t, resp.Tasks, 0)
+
+	// Tasks can be registered afterwards
+	registered := appClient.RegisterTasks(client.DefaultTrainTaskOptions())
+	require.Equal(t, validated[0].Worker, registered[0].Worker)
+}
+
+func TestQueryTasksFilteredAndSorted(t *testing.T) {
+	appClient := factory.NewTestClient()
+	ks := appClient.GetKeyStore()
+
+	appClient.RegisterFunction(client.DefaultSimpleFunctionOptions())
+	appClient.RegisterDataManager(client.DefaultDataManagerOptions())
+	appClient.RegisterDataSample(client.DefaultDataSampleOptions())
+	appClient.RegisterComputePlan(client.DefaultComputePlanOptions())
+
+	appClient.RegisterTasks(
+		client.DefaultTrainTaskOptions(),
+		client.DefaultTrainTaskOptions().
+			WithKeyRef("child").
+			WithInput("model", &client.TaskOutputRef{TaskRef: client.DefaultTrainTaskRef, Identifier: "model"}),
+	)
+
+	rankMin := int32(0)
+	filter := &asset.TaskQueryFilter{
+		ComputePlanKey: ks.GetKey(client.DefaultPlanRef),
+		Statuses: []asset.ComputeTaskStatus{
+			asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS,
+			asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT,
+			asset.ComputeTaskStatus_STATUS_BUILDING,
+		},
+		RankMin: &rankMin,
+	}
+
+	resp := appClient.QuerySortedTasks(filter, asset.TaskSortField_TASK_SORT_RANK, asset.SortOrder_DESCENDING, "", 10)
+	require.Len(t, resp.Tasks, 2)
+	require.Equal(t, ks.GetKey("child"), resp.Tasks[0].Key)
+	require.Equal(t, ks.GetKey(client.DefaultTrainTaskRef), resp.Tasks[1].Key)
+
+	filter.Keys = []string{ks.GetKey("child")}
+	resp = appClient.QuerySortedTasks(filter, asset.TaskSortField_TASK_SORT_RANK, asset.SortOrder_DESCENDING, "", 10)
+	require.Len(t, resp.Tasks, 1)
+}
+
 func TestRegisterTaskWithTransientOutput(t *testing.T) {
 	appClient := factory.NewTestClient()
 
@@ -204,6 +274,49 @@ func TestCascadeFailure(t *testing.T) {
 	require.Nil(t, plan.CancelationDate)
 }
 
+// TestRetryFailedTask fails a task, retries it and makes sure the plan is no longer failed,
+// while each attempt keeps its own failure report.
+func TestRetryFailedTask(t *testing.T) {
+	appClient := factory.NewTestClient()
+
+	appClient.RegisterFunction(client.DefaultSimpleFunctionOptions())
+	appClient.RegisterDataManager(client.DefaultDataManagerOptions())
+	appClient.RegisterDataSample(client.DefaultDataSampleOptions())
+	appClient.RegisterComputePlan(client.DefaultComputePlanOptions().WithMaxTaskAttempts(2))
+	appClient.RegisterTasks(client.DefaultTrainTaskOptions())
+	appClient.RegisterTasks(client.DefaultTrainTaskOptions().WithKeyRef("child").
+		WithInput("model", &client.TaskOutputRef{TaskRef: client.DefaultTrainTaskRef, Identifier: "model"}))
+
+	appClient.SetReadyFromWaitingFunction(client.DefaultSimpleFunctionRef)
+	appClient.StartTask(client.DefaultTrainTaskRef)
+	appClient.RegisterTaskFailureReport(client.DefaultTrainTaskRef)
+
+	plan := appClient.GetComputePlan(client.DefaultPlanRef)
+	require.NotNil(t, plan.FailureDate)
+
+	appClient.RetryTask(client.DefaultTrainTaskRef)
+
+	task := appClient.GetComputeTask(client.DefaultTrainTaskRef)
+	require.Equal(t, asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT, task.Status)
+	require.Equal(t, uint32(2), task.Attempt)
+
+	plan = appClient.GetComputePlan(client.DefaultPlanRef)
+	require.Nil(t, plan.FailureDate)
+
+	appClient.StartTask(client.DefaultTrainTaskRef)
+	appClient.RegisterTaskFailureReport(client.DefaultTrainTaskRef)
+
+	failureReport := appClient.GetFailureReport(client.DefaultTrainTaskRef)
+	require.Equal(t, uint32(2), failureReport.Attempt)
+
+	// the maximum number of attempts is reached
+	_, err := appClient.FailableApplyTaskAction(client.DefaultTrainTaskRef, asset.ComputeTaskAction_TASK_ACTION_RETRY)
+	require.Error(t, err)
+
+	child := appClient.GetComputeTask("child")
+	require.Equal(t, asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS, child.Status)
+}
+
 func TestPropagateLogsPermission(t *testing.T) {
 	appClient := factory.NewTestClient()
 
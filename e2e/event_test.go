@@ -112,6 +112,40 @@ func TestSubscribeEventsEmittedWhileSubscribed(t *testing.T) {
 	}
 }
 
+// TestSubscribeFilteredEvents ensures that only the events matching the filter
+// are replayed and forwarded.
+func TestSubscribeFilteredEvents(t *testing.T) {
+	appClient := factory.NewTestClient()
+
+	appClient.RegisterFunction(client.DefaultSimpleFunctionOptions())
+	appClient.SetReadyFromWaitingFunction(client.DefaultSimpleFunctionRef)
+	manager := appClient.RegisterDataManager(client.DefaultDataManagerOptions())
+	appClient.RegisterDataSample(client.DefaultDataSampleOptions())
+	plan := appClient.RegisterComputePlan(client.DefaultComputePlanOptions())
+
+	replayedTask := appClient.RegisterTasks(client.DefaultTrainTaskOptions().WithKeyRef("replayedTask"))[0]
+	appClient.RegisterDataSample(client.DefaultDataSampleOptions().WithKeyRef("ignoredSample"))
+
+	startEvent := appClient.GetAssetCreationEvent(manager.Key)
+	stream, cancel := appClient.SubscribeToFilteredEvents(startEvent.Id, &asset.EventQueryFilter{
+		ComputePlanKey: plan.Key,
+		AssetKinds:     []asset.AssetKind{asset.AssetKind_ASSET_COMPUTE_TASK},
+		EventKinds:     []asset.EventKind{asset.EventKind_EVENT_ASSET_CREATED},
+	})
+	defer cancel()
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	e2erequire.ProtoEqual(t, replayedTask, event.GetComputeTask())
+
+	appClient.RegisterDataSample(client.DefaultDataSampleOptions().WithKeyRef("otherIgnoredSample"))
+	listenedTask := appClient.RegisterTasks(client.DefaultTrainTaskOptions().WithKeyRef("listenedTask"))[0]
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	e2erequire.ProtoEqual(t, listenedTask, event.GetComputeTask())
+}
+
 // TestSubscribeReplayThenListen ensures that after previous events have been replayed,
 // it is possible to listen to new events.
 func TestSubscribeReplayThenListen(t *testing.T) {
@@ -163,6 +197,85 @@ func TestSubscribeWithoutStartEventID(t *testing.T) {
 	}
 }
 
+// TestVerifyEventChain registers assets and makes sure the hash chain of the events is valid.
+func TestVerifyEventChain(t *testing.T) {
+	appClient := factory.NewTestClient()
+	appClient.RegisterFunction(client.DefaultSimpleFunctionOptions())
+	appClient.RegisterDataManager(client.DefaultDataManagerOptions())
+
+	status := appClient.VerifyEventChain()
+	require.True(t, status.Valid)
+	require.Nil(t, status.FirstBrokenLink)
+	require.GreaterOrEqual(t, status.VerifiedEvents, uint64(2))
+	require.NotEmpty(t, status.LastHash)
+}
+
+// TestSubscribeAsDurableConsumer ensures that a durable consumer resumes after its last acknowledged event.
+func TestSubscribeAsDurableConsumer(t *testing.T) {
+	appClient := factory.NewTestClient()
+	ks := appClient.GetKeyStore()
+	consumer := ks.GetKey("consumer")
+
+	appClient.RegisterFunction(client.DefaultSimpleFunctionOptions())
+	appClient.RegisterDataManager(client.DefaultDataManagerOptions())
+
+	ackedEvent := appClient.GetAssetCreationEvent(ks.GetKey(client.DefaultDataManagerRef))
+	appClient.AckEvents(consumer, ackedEvent.Id)
+
+	sample := appClient.RegisterDataSample(client.DefaultDataSampleOptions())
+
+	stream, cancel := appClient.SubscribeAsConsumer(consumer)
+	defer cancel()
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, sample.Key, event.AssetKey, "streaming should resume after the acknowledged event")
+
+	resp := appClient.QueryEventConsumers("", 1000)
+	var found *asset.EventConsumer
+	for _, c := range resp.Consumers {
+		if c.Name == consumer {
+			found = c
+		}
+	}
+	require.NotNil(t, found)
+	require.Equal(t, ackedEvent.Id, found.LastAckedEventId)
+	require.GreaterOrEqual(t, found.Lag, uint64(1))
+}
+
+// TestWatchComputePlan ensures that watching a plan sends its snapshot, then only the events related to the plan.
+func TestWatchComputePlan(t *testing.T) {
+	appClient := factory.NewTestClient()
+
+	appClient.RegisterFunction(client.DefaultSimpleFunctionOptions())
+	appClient.SetReadyFromWaitingFunction(client.DefaultSimpleFunctionRef)
+	appClient.RegisterDataManager(client.DefaultDataManagerOptions())
+	appClient.RegisterDataSample(client.DefaultDataSampleOptions())
+	plan := appClient.RegisterComputePlan(client.DefaultComputePlanOptions())
+	task := appClient.RegisterTasks(client.DefaultTrainTaskOptions())[0]
+
+	stream, cancel := appClient.WatchComputePlan(client.DefaultPlanRef)
+	defer cancel()
+
+	update, err := stream.Recv()
+	require.NoError(t, err)
+	snapshot := update.GetSnapshot()
+	require.NotNil(t, snapshot)
+	e2erequire.ProtoEqual(t, plan, snapshot.ComputePlan)
+	require.Len(t, snapshot.Tasks, 1)
+	require.Equal(t, task.Key, snapshot.Tasks[0].Key)
+	require.NotZero(t, snapshot.Position)
+
+	appClient.RegisterDataSample(client.DefaultDataSampleOptions().WithKeyRef("ignoredSample"))
+	newTask := appClient.RegisterTasks(client.DefaultTrainTaskOptions().WithKeyRef("newTask"))[0]
+
+	update, err = stream.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, update.GetEvent())
+	require.Equal(t, asset.EventKind_EVENT_ASSET_CREATED, update.GetEvent().EventKind)
+	e2erequire.ProtoEqual(t, newTask, update.GetEvent().GetComputeTask())
+}
+
 // TestSubscribeCheckEventStreamOrder ensures that events in the stream are
 // ordered by timestamp and that they belong to the client channel.
 // This check is made on a stream of events containing replayed events but also
@@ -211,6 +324,35 @@ func TestSubscribeCheckEventStreamConsistency(t *testing.T) {
 	}
 }
 
+// TestSubscribeToChannels ensures that a single stream receives the events of several channels,
+// each one resuming after its own cursor.
+func TestSubscribeToChannels(t *testing.T) {
+	client1 := factory.WithChannel("mychannel").NewTestClient()
+	client2 := factory.WithChannel("yourchannel").NewTestClient()
+
+	function1 := client1.RegisterFunction(client.DefaultSimpleFunctionOptions())
+	function2 := client2.RegisterFunction(client.DefaultSimpleFunctionOptions())
+
+	stream, cancel := client1.SubscribeToChannels([]string{client2.Channel}, []*asset.ChannelCursor{
+		{Channel: client1.Channel, StartEventId: client1.GetAssetCreationEvent(function1.Key).Id},
+		{Channel: client2.Channel, StartEventId: client2.GetAssetCreationEvent(function2.Key).Id},
+	})
+	defer cancel()
+
+	manager1 := client1.RegisterDataManager(client.DefaultDataManagerOptions())
+	manager2 := client2.RegisterDataManager(client.DefaultDataManagerOptions())
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, client1.Channel, event.Channel)
+	require.Equal(t, manager1.Key, event.AssetKey)
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, client2.Channel, event.Channel)
+	require.Equal(t, manager2.Key, event.AssetKey)
+}
+
 // TestSubscribeCheckEventStreamOrder ensures that two clients subscribed to the same the stream
 // receive the same events in parallel.
 func TestSubscribeParallel(t *testing.T) {
@@ -0,0 +1,43 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// TestWebhookLifecycle registers, updates and deletes a webhook.
+func TestWebhookLifecycle(t *testing.T) {
+	appClient := factory.NewTestClient()
+	ks := appClient.GetKeyStore()
+
+	filter := &asset.EventQueryFilter{AssetKind: asset.AssetKind_ASSET_COMPUTE_TASK}
+	webhook := appClient.RegisterWebhook("webhook", "https://example.com/hook", filter)
+	require.Equal(t, ks.GetKey("webhook"), webhook.Key)
+
+	retrieved := appClient.GetWebhook("webhook")
+	require.Equal(t, "https://example.com/hook", retrieved.Url)
+	require.Equal(t, asset.AssetKind_ASSET_COMPUTE_TASK, retrieved.Filter.AssetKind)
+
+	resp := appClient.QueryWebhooks("", 100)
+	keys := make([]string, 0, len(resp.Webhooks))
+	for _, w := range resp.Webhooks {
+		keys = append(keys, w.Key)
+	}
+	require.Contains(t, keys, webhook.Key)
+
+	updated := appClient.UpdateWebhook("webhook", "https://example.com/other", nil)
+	require.Equal(t, "https://example.com/other", updated.Url)
+
+	deliveries := appClient.QueryWebhookDeliveries("webhook", asset.WebhookDeliveryStatus_DELIVERY_UNKNOWN, "", 100)
+	require.Empty(t, deliveries.NextPageToken)
+
+	appClient.DeleteWebhook("webhook")
+
+	_, err := appClient.FailableGetWebhook("webhook")
+	require.Error(t, err, "a deleted webhook should not be found")
+}
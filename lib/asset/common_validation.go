@@ -13,6 +13,13 @@ var nameValidationRules = []validation.Rule{
 	validation.Length(1, 100),
 }
 
+// MaxTaskAttempts is the upper bound of the configurable number of execution attempts of a task.
+const MaxTaskAttempts = 10
+
+var maxTaskAttemptsValidationRules = []validation.Rule{
+	validation.Max(uint32(MaxTaskAttempts)),
+}
+
 // Validate makes sure the Addressable object is valid
 func (a *Addressable) Validate() error {
 	return validation.ValidateStruct(a,
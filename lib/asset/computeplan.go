@@ -1,5 +1,18 @@
 package asset
 
 func (c *ComputePlan) IsTerminated() bool {
-	return c.CancelationDate != nil || c.FailureDate != nil
+	return c.CancelationDate != nil || c.FailureDate != nil || c.IsPurged()
+}
+
+// IsPurged returns true if the tasks of the compute plan have been purged.
+func (c *ComputePlan) IsPurged() bool {
+	return c.PurgeDate != nil
+}
+
+// IsPaused returns true if the compute plan has been paused and not resumed since.
+func (c *ComputePlan) IsPaused() bool {
+	if c.PauseDate == nil {
+		return false
+	}
+	return c.ResumeDate == nil || c.ResumeDate.AsTime().Before(c.PauseDate.AsTime())
 }
@@ -0,0 +1,120 @@
+package asset
+
+import (
+	"fmt"
+	"strings"
+)
+
+// graphStatusColors lists the fill color of nodes by task status.
+// Statuses without color are rendered with the default style.
+var graphStatusColors = []struct {
+	status ComputeTaskStatus
+	color  string
+}{
+	{ComputeTaskStatus_STATUS_EXECUTING, "#9ecae1"},
+	{ComputeTaskStatus_STATUS_DONE, "#a1d99b"},
+	{ComputeTaskStatus_STATUS_FAILED, "#fc9272"},
+	{ComputeTaskStatus_STATUS_CANCELED, "#d9d9d9"},
+}
+
+func statusColor(status ComputeTaskStatus) (string, bool) {
+	for _, c := range graphStatusColors {
+		if c.status == status {
+			return c.color, true
+		}
+	}
+	return "", false
+}
+
+// shortStatus returns the task status without its common prefix.
+func shortStatus(status ComputeTaskStatus) string {
+	return strings.TrimPrefix(status.String(), "STATUS_")
+}
+
+func (n *ComputePlanGraphNode) labelLines() []string {
+	return []string{
+		n.TaskKey,
+		shortStatus(n.Status),
+		fmt.Sprintf("rank %d on %s", n.Rank, n.Worker),
+	}
+}
+
+func (e *ComputePlanGraphEdge) label() string {
+	return e.ParentTaskOutputIdentifier + " → " + e.ChildTaskInputIdentifier
+}
+
+// ToDOT renders the graph in the Graphviz DOT language.
+func (g *ComputePlanGraph) ToDOT() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(g.ComputePlanKey))
+	b.WriteString("  node [shape=box, style=filled, fillcolor=\"#ffffff\"];\n")
+
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s", dotQuote(n.TaskKey), dotQuote(strings.Join(n.labelLines(), "\n")))
+		if color, ok := statusColor(n.Status); ok {
+			fmt.Fprintf(&b, ", fillcolor=%s", dotQuote(color))
+		}
+		if n.ComputePlanKey != g.ComputePlanKey {
+			b.WriteString(", style=\"filled,dashed\"")
+		}
+		b.WriteString("];\n")
+	}
+
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", dotQuote(e.ParentTaskKey), dotQuote(e.ChildTaskKey), dotQuote(e.label()))
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// ToMermaid renders the graph as a Mermaid flowchart.
+func (g *ComputePlanGraph) ToMermaid() string {
+	var b strings.Builder
+
+	b.WriteString("flowchart TD\n")
+
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", mermaidID(n.TaskKey), mermaidEscape(strings.Join(n.labelLines(), "<br/>")))
+	}
+
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %s -->|\"%s\"| %s\n", mermaidID(e.ParentTaskKey), mermaidEscape(e.label()), mermaidID(e.ChildTaskKey))
+	}
+
+	for _, c := range graphStatusColors {
+		keys := []string{}
+		for _, n := range g.Nodes {
+			if n.Status == c.status {
+				keys = append(keys, mermaidID(n.TaskKey))
+			}
+		}
+		if len(keys) > 0 {
+			class := strings.ToLower(shortStatus(c.status))
+			fmt.Fprintf(&b, "  classDef %s fill:%s\n", class, c.color)
+			fmt.Fprintf(&b, "  class %s %s\n", strings.Join(keys, ","), class)
+		}
+	}
+
+	return b.String()
+}
+
+// dotQuote returns the string as a DOT quoted identifier.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// mermaidID returns a node identifier which cannot be mistaken for mermaid syntax.
+func mermaidID(taskKey string) string {
+	return "t" + strings.ReplaceAll(taskKey, "-", "")
+}
+
+// mermaidEscape escapes the characters which would end a quoted mermaid label.
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
@@ -0,0 +1,54 @@
+package asset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestGraph() *ComputePlanGraph {
+	return &ComputePlanGraph{
+		ComputePlanKey: "cp",
+		Nodes: []*ComputePlanGraphNode{
+			{TaskKey: "3c1f5b6c-6e8b-4b33-b5d5-5d0e8d0e6a51", ComputePlanKey: "cp", Status: ComputeTaskStatus_STATUS_DONE, Worker: "org1"},
+			{TaskKey: "9f4d7c2a-1e5b-4b6f-8a2d-0c3e7f1b2a94", ComputePlanKey: "cp", Status: ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS, Rank: 1, Worker: "org2"},
+			{TaskKey: "5e2a9d41-7b3c-4f8e-9a6d-2b1c0e8f7d63", ComputePlanKey: "other", Status: ComputeTaskStatus_STATUS_DONE, Worker: "org1"},
+		},
+		Edges: []*ComputePlanGraphEdge{
+			{
+				ParentTaskKey:              "3c1f5b6c-6e8b-4b33-b5d5-5d0e8d0e6a51",
+				ParentTaskOutputIdentifier: "model",
+				ChildTaskKey:               "9f4d7c2a-1e5b-4b6f-8a2d-0c3e7f1b2a94",
+				ChildTaskInputIdentifier:   "shared",
+			},
+		},
+	}
+}
+
+func TestComputePlanGraphToDOT(t *testing.T) {
+	expected := `digraph "cp" {
+  node [shape=box, style=filled, fillcolor="#ffffff"];
+  "3c1f5b6c-6e8b-4b33-b5d5-5d0e8d0e6a51" [label="3c1f5b6c-6e8b-4b33-b5d5-5d0e8d0e6a51\nDONE\nrank 0 on org1", fillcolor="#a1d99b"];
+  "9f4d7c2a-1e5b-4b6f-8a2d-0c3e7f1b2a94" [label="9f4d7c2a-1e5b-4b6f-8a2d-0c3e7f1b2a94\nWAITING_FOR_PARENT_TASKS\nrank 1 on org2"];
+  "5e2a9d41-7b3c-4f8e-9a6d-2b1c0e8f7d63" [label="5e2a9d41-7b3c-4f8e-9a6d-2b1c0e8f7d63\nDONE\nrank 0 on org1", fillcolor="#a1d99b", style="filled,dashed"];
+  "3c1f5b6c-6e8b-4b33-b5d5-5d0e8d0e6a51" -> "9f4d7c2a-1e5b-4b6f-8a2d-0c3e7f1b2a94" [label="model → shared"];
+}
+`
+	assert.Equal(t, expected, getTestGraph().ToDOT())
+}
+
+func TestComputePlanGraphToMermaid(t *testing.T) {
+	expected := `flowchart TD
+  t3c1f5b6c6e8b4b33b5d55d0e8d0e6a51["3c1f5b6c-6e8b-4b33-b5d5-5d0e8d0e6a51<br/>DONE<br/>rank 0 on org1"]
+  t9f4d7c2a1e5b4b6f8a2d0c3e7f1b2a94["9f4d7c2a-1e5b-4b6f-8a2d-0c3e7f1b2a94<br/>WAITING_FOR_PARENT_TASKS<br/>rank 1 on org2"]
+  t5e2a9d417b3c4f8e9a6d2b1c0e8f7d63["5e2a9d41-7b3c-4f8e-9a6d-2b1c0e8f7d63<br/>DONE<br/>rank 0 on org1"]
+  t3c1f5b6c6e8b4b33b5d55d0e8d0e6a51 -->|"model → shared"| t9f4d7c2a1e5b4b6f8a2d0c3e7f1b2a94
+  classDef done fill:#a1d99b
+  class t3c1f5b6c6e8b4b33b5d55d0e8d0e6a51,t5e2a9d417b3c4f8e9a6d2b1c0e8f7d63 done
+`
+	assert.Equal(t, expected, getTestGraph().ToMermaid())
+}
+
+func TestDOTQuote(t *testing.T) {
+	assert.Equal(t, `"a \"quoted\" \\ label\nnext"`, dotQuote("a \"quoted\" \\ label\nnext"))
+}
@@ -13,6 +13,7 @@ func (t *NewComputePlan) Validate() error {
 		validation.Field(&t.Tag, validation.Length(0, 100)),
 		validation.Field(&t.Name, nameValidationRules...),
 		validation.Field(&t.Metadata, validation.By(validateMetadata)),
+		validation.Field(&t.MaxTaskAttempts, maxTaskAttemptsValidationRules...),
 	)
 }
 
@@ -25,6 +25,16 @@ func TestValidateNewComputePlan(t *testing.T) {
 			Key:  "08680966-97ae-4573-8b2d-6c4db2b3c532",
 			Name: "The name of my compute plan",
 		}, true},
+		"maxTaskAttempts": {&NewComputePlan{
+			Key:             "08680966-97ae-4573-8b2d-6c4db2b3c532",
+			Name:            "The name of my compute plan",
+			MaxTaskAttempts: 3,
+		}, true},
+		"tooManyTaskAttempts": {&NewComputePlan{
+			Key:             "08680966-97ae-4573-8b2d-6c4db2b3c532",
+			Name:            "The name of my compute plan",
+			MaxTaskAttempts: MaxTaskAttempts + 1,
+		}, false},
 	}
 
 	for name, tc := range cases {
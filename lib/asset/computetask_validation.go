@@ -21,6 +21,30 @@ func (t *NewComputeTask) Validate() error {
 	)
 }
 
+// Validate returns an error if the TaskQueryFilter cannot be turned into a query:
+// malformed keys or inverted ranges.
+func (f *TaskQueryFilter) Validate() error {
+	return validation.ValidateStruct(f,
+		validation.Field(&f.Keys, validation.Each(is.UUID)),
+		validation.Field(&f.RankMax, validation.When(f.RankMin != nil && f.RankMax != nil, validation.By(f.validateRankRange))),
+		validation.Field(&f.CreationDateEnd, validation.When(f.CreationDateStart != nil && f.CreationDateEnd != nil, validation.By(f.validateCreationDateRange))),
+	)
+}
+
+func (f *TaskQueryFilter) validateRankRange(interface{}) error {
+	if *f.RankMax < *f.RankMin {
+		return errors.NewInvalidAsset("rank_max must be greater than or equal to rank_min")
+	}
+	return nil
+}
+
+func (f *TaskQueryFilter) validateCreationDateRange(interface{}) error {
+	if f.CreationDateEnd.AsTime().Before(f.CreationDateStart.AsTime()) {
+		return errors.NewInvalidAsset("creation_date_end must not be before creation_date_start")
+	}
+	return nil
+}
+
 func (p *ApplyTaskActionParam) Validate() error {
 	return validation.ValidateStruct(p,
 		validation.Field(&p.ComputeTaskKey, validation.Required, is.UUID),
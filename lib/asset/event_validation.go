@@ -0,0 +1,72 @@
+package asset
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+var eventConsumerNameValidationRules = []validation.Rule{
+	validation.Length(1, 100),
+	validation.Match(regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)).Error("must only contain letters, digits, dots, dashes and underscores"),
+}
+
+// Validate returns an error if the subscription parameters are not valid.
+func (p *SubscribeToEventsParam) Validate() error {
+	return validation.ValidateStruct(p,
+		validation.Field(&p.StartEventId, is.UUID),
+		validation.Field(&p.Consumer, eventConsumerNameValidationRules...),
+	)
+}
+
+// Validate returns an error if the subscription parameters are not valid.
+// Each element of Cursors is validated, and a channel can't have several cursors.
+func (p *SubscribeToChannelsParam) Validate() error {
+	return validation.ValidateStruct(p,
+		validation.Field(&p.Cursors, validation.By(validateUniqueChannelCursors)),
+	)
+}
+
+// Validate returns an error if the cursor is not valid.
+func (c *ChannelCursor) Validate() error {
+	return validation.ValidateStruct(c,
+		validation.Field(&c.Channel, validation.Required),
+		validation.Field(&c.StartEventId, is.UUID),
+	)
+}
+
+func validateUniqueChannelCursors(input interface{}) error {
+	cursors, ok := input.([]*ChannelCursor)
+	if !ok {
+		return errors.New("cursors is not a proper list")
+	}
+
+	channels := make(map[string]bool, len(cursors))
+	for _, cursor := range cursors {
+		if channels[cursor.GetChannel()] {
+			return fmt.Errorf("duplicate cursor for channel %q", cursor.GetChannel())
+		}
+		channels[cursor.GetChannel()] = true
+	}
+
+	return nil
+}
+
+// Validate returns an error if the acknowledgement is not valid:
+// missing required data, incompatible values, etc.
+func (p *AckEventsParam) Validate() error {
+	return validation.ValidateStruct(p,
+		validation.Field(&p.Consumer, append([]validation.Rule{validation.Required}, eventConsumerNameValidationRules...)...),
+		validation.Field(&p.EventId, validation.Required, is.UUID),
+	)
+}
+
+// Validate returns an error if the watched compute plan is not valid.
+func (p *WatchComputePlanParam) Validate() error {
+	return validation.ValidateStruct(p,
+		validation.Field(&p.Key, validation.Required, is.UUID),
+	)
+}
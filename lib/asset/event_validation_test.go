@@ -0,0 +1,90 @@
+package asset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSubscribeToEventsParam(t *testing.T) {
+	cases := map[string]struct {
+		param *SubscribeToEventsParam
+		valid bool
+	}{
+		"empty":              {&SubscribeToEventsParam{}, true},
+		"invalidStartEvent":  {&SubscribeToEventsParam{StartEventId: "not36chars"}, false},
+		"invalidConsumer":    {&SubscribeToEventsParam{Consumer: "my consumer"}, false},
+		"startEventConsumer": {&SubscribeToEventsParam{StartEventId: "08680966-97ae-4573-8b2d-6c4db2b3c532", Consumer: "backend-1"}, true},
+	}
+
+	for name, tc := range cases {
+		if tc.valid {
+			assert.NoError(t, tc.param.Validate(), name+" should be valid")
+		} else {
+			assert.Error(t, tc.param.Validate(), name+" should be invalid")
+		}
+	}
+}
+
+func TestValidateAckEventsParam(t *testing.T) {
+	cases := map[string]struct {
+		param *AckEventsParam
+		valid bool
+	}{
+		"empty":           {&AckEventsParam{}, false},
+		"missingConsumer": {&AckEventsParam{EventId: "08680966-97ae-4573-8b2d-6c4db2b3c532"}, false},
+		"invalidEvent":    {&AckEventsParam{Consumer: "backend", EventId: "not36chars"}, false},
+		"valid":           {&AckEventsParam{Consumer: "backend_1.v2", EventId: "08680966-97ae-4573-8b2d-6c4db2b3c532"}, true},
+	}
+
+	for name, tc := range cases {
+		if tc.valid {
+			assert.NoError(t, tc.param.Validate(), name+" should be valid")
+		} else {
+			assert.Error(t, tc.param.Validate(), name+" should be invalid")
+		}
+	}
+}
+
+func TestValidateWatchComputePlanParam(t *testing.T) {
+	cases := map[string]struct {
+		param *WatchComputePlanParam
+		valid bool
+	}{
+		"empty":      {&WatchComputePlanParam{}, false},
+		"invalidKey": {&WatchComputePlanParam{Key: "not36chars"}, false},
+		"valid":      {&WatchComputePlanParam{Key: "08680966-97ae-4573-8b2d-6c4db2b3c532"}, true},
+	}
+
+	for name, tc := range cases {
+		if tc.valid {
+			assert.NoError(t, tc.param.Validate(), name+" should be valid")
+		} else {
+			assert.Error(t, tc.param.Validate(), name+" should be invalid")
+		}
+	}
+}
+
+func TestValidateSubscribeToChannelsParam(t *testing.T) {
+	cases := map[string]struct {
+		param *SubscribeToChannelsParam
+		valid bool
+	}{
+		"empty":            {&SubscribeToChannelsParam{}, true},
+		"missingChannel":   {&SubscribeToChannelsParam{Cursors: []*ChannelCursor{{StartEventId: "08680966-97ae-4573-8b2d-6c4db2b3c532"}}}, false},
+		"invalidEvent":     {&SubscribeToChannelsParam{Cursors: []*ChannelCursor{{Channel: "mychannel", StartEventId: "not36chars"}}}, false},
+		"duplicateChannel": {&SubscribeToChannelsParam{Cursors: []*ChannelCursor{{Channel: "mychannel"}, {Channel: "mychannel"}}}, false},
+		"valid": {&SubscribeToChannelsParam{Cursors: []*ChannelCursor{
+			{Channel: "mychannel", StartEventId: "08680966-97ae-4573-8b2d-6c4db2b3c532"},
+			{Channel: "yourchannel"},
+		}}, true},
+	}
+
+	for name, tc := range cases {
+		if tc.valid {
+			assert.NoError(t, tc.param.Validate(), name+" should be valid")
+		} else {
+			assert.Error(t, tc.param.Validate(), name+" should be invalid")
+		}
+	}
+}
@@ -20,6 +20,7 @@ func (a *NewFunction) Validate() error {
 		validation.Field(&a.NewPermissions, validation.Required),
 		validation.Field(&a.Inputs, validation.By(validateInputs)),
 		validation.Field(&a.Outputs, validation.By(validateOutputs)),
+		validation.Field(&a.MaxTaskAttempts, maxTaskAttemptsValidationRules...),
 	)
 }
 
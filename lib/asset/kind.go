@@ -27,4 +27,8 @@ var (
 	// FailureReportKind is the type of FailureReport assets
 	FailureReportKind          = "failurereport"
 	ComputeTaskOutputAssetKind = "computetask_output_asset"
+	// WebhookKind is the type of Webhook objects
+	WebhookKind = "webhook"
+	// EventConsumerKind is the type of durable event consumers
+	EventConsumerKind = "event_consumer"
 )
@@ -178,3 +178,43 @@ func (fak *FailedAssetKind) Scan(value interface{}) error {
 
 	return nil
 }
+
+// Value implements the driver.Valuer interface.
+// Simply returns the JSON-encoded representation of the EventQueryFilter.
+func (f *EventQueryFilter) Value() (driver.Value, error) {
+	return protojson.Marshal(f)
+}
+
+// Scan implements the sql.Scanner interface.
+// Simply decodes JSON into the EventQueryFilter.
+func (f *EventQueryFilter) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.NewError(errors.ErrByteArray, "cannot scan event filter")
+	}
+
+	return protojson.Unmarshal(b, f)
+}
+
+// Value implements the driver.Valuer interface.
+// Simply returns the string representation of the WebhookDeliveryStatus.
+func (s *WebhookDeliveryStatus) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+// Scan implements the sql.Scanner interface.
+// Simply decodes a string into the WebhookDeliveryStatus.
+func (s *WebhookDeliveryStatus) Scan(value interface{}) error {
+	str, ok := value.(string)
+	if !ok {
+		return errors.NewInternal("cannot scan webhook delivery status: invalid string")
+	}
+
+	v, ok := WebhookDeliveryStatus_value[str]
+	if !ok {
+		return errors.NewInternal("cannot scan webhook delivery status: unknown value")
+	}
+	*s = WebhookDeliveryStatus(v)
+
+	return nil
+}
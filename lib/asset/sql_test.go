@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestPermissionsValue(t *testing.T) {
@@ -104,3 +105,20 @@ func TestFailedAssetKindKindValue(t *testing.T) {
 
 	assert.Equal(t, kind, scanned)
 }
+
+func TestEventQueryFilterValue(t *testing.T) {
+	filter := &EventQueryFilter{
+		AssetKinds:     []AssetKind{AssetKind_ASSET_COMPUTE_TASK, AssetKind_ASSET_MODEL},
+		EventKind:      EventKind_EVENT_ASSET_CREATED,
+		ComputePlanKey: "08680966-97ae-4573-8b2d-6c4db2b3c532",
+	}
+
+	value, err := filter.Value()
+	assert.NoError(t, err, "event filter serialization should not fail")
+
+	scanned := new(EventQueryFilter)
+	err = scanned.Scan(value)
+	assert.NoError(t, err, "event filter scan should not fail")
+
+	assert.True(t, proto.Equal(filter, scanned))
+}
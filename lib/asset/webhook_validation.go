@@ -0,0 +1,38 @@
+package asset
+
+import (
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+var webhookURLValidationRules = []validation.Rule{
+	validation.Required,
+	is.RequestURL,
+	validation.Match(regexp.MustCompile(`^https?://`)).Error("must be an http or https URL"),
+}
+
+var webhookSecretValidationRules = []validation.Rule{
+	validation.Length(16, 256),
+}
+
+// Validate returns an error if the NewWebhook is not valid:
+// missing required data, incompatible values, etc.
+func (w *NewWebhook) Validate() error {
+	return validation.ValidateStruct(w,
+		validation.Field(&w.Key, validation.Required, is.UUID),
+		validation.Field(&w.Url, webhookURLValidationRules...),
+		validation.Field(&w.Secret, append([]validation.Rule{validation.Required}, webhookSecretValidationRules...)...),
+	)
+}
+
+// Validate returns an error if the updated webhook is not valid:
+// missing required data, incompatible values, etc.
+func (w *UpdateWebhookParam) Validate() error {
+	return validation.ValidateStruct(w,
+		validation.Field(&w.Key, validation.Required, is.UUID),
+		validation.Field(&w.Url, webhookURLValidationRules...),
+		validation.Field(&w.Secret, webhookSecretValidationRules...),
+	)
+}
@@ -0,0 +1,85 @@
+package asset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateNewWebhook(t *testing.T) {
+	cases := map[string]struct {
+		newWebhook *NewWebhook
+		valid      bool
+	}{
+		"empty": {&NewWebhook{}, false},
+		"invalidKey": {&NewWebhook{
+			Key:    "not36chars",
+			Url:    "https://example.com/hook",
+			Secret: "0123456789abcdef",
+		}, false},
+		"invalidURL": {&NewWebhook{
+			Key:    "08680966-97ae-4573-8b2d-6c4db2b3c532",
+			Url:    "not a url",
+			Secret: "0123456789abcdef",
+		}, false},
+		"unsupportedScheme": {&NewWebhook{
+			Key:    "08680966-97ae-4573-8b2d-6c4db2b3c532",
+			Url:    "ftp://example.com/hook",
+			Secret: "0123456789abcdef",
+		}, false},
+		"missingSecret": {&NewWebhook{
+			Key: "08680966-97ae-4573-8b2d-6c4db2b3c532",
+			Url: "https://example.com/hook",
+		}, false},
+		"shortSecret": {&NewWebhook{
+			Key:    "08680966-97ae-4573-8b2d-6c4db2b3c532",
+			Url:    "https://example.com/hook",
+			Secret: "secret",
+		}, false},
+		"valid": {&NewWebhook{
+			Key:    "08680966-97ae-4573-8b2d-6c4db2b3c532",
+			Url:    "https://example.com/hook",
+			Secret: "0123456789abcdef",
+			Filter: &EventQueryFilter{AssetKind: AssetKind_ASSET_COMPUTE_TASK},
+		}, true},
+	}
+
+	for name, tc := range cases {
+		if tc.valid {
+			assert.NoError(t, tc.newWebhook.Validate(), name+" should be valid")
+		} else {
+			assert.Error(t, tc.newWebhook.Validate(), name+" should be invalid")
+		}
+	}
+}
+
+func TestValidateUpdateWebhookParam(t *testing.T) {
+	cases := map[string]struct {
+		param *UpdateWebhookParam
+		valid bool
+	}{
+		"empty": {&UpdateWebhookParam{}, false},
+		"shortSecret": {&UpdateWebhookParam{
+			Key:    "08680966-97ae-4573-8b2d-6c4db2b3c532",
+			Url:    "https://example.com/hook",
+			Secret: "secret",
+		}, false},
+		"keepSecret": {&UpdateWebhookParam{
+			Key: "08680966-97ae-4573-8b2d-6c4db2b3c532",
+			Url: "http://example.com/hook",
+		}, true},
+		"newSecret": {&UpdateWebhookParam{
+			Key:    "08680966-97ae-4573-8b2d-6c4db2b3c532",
+			Url:    "https://example.com/hook",
+			Secret: "0123456789abcdef",
+		}, true},
+	}
+
+	for name, tc := range cases {
+		if tc.valid {
+			assert.NoError(t, tc.param.Validate(), name+" should be valid")
+		} else {
+			assert.Error(t, tc.param.Validate(), name+" should be invalid")
+		}
+	}
+}
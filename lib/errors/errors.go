@@ -8,8 +8,12 @@
 package errors
 
 import (
+	"errors"
 	"fmt"
 	"runtime"
+	"sort"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
 
 // ErrorKind is unique per kind of orchestration error.
@@ -70,6 +74,9 @@ var (
 
 	// ErrTerminatedComputeTask occurs when attempting to cancel or fail an already terminated compute plan
 	ErrTerminatedComputeTask = "OE0110"
+
+	// ErrPausedComputePlan occurs when attempting to start a task of a paused compute plan
+	ErrPausedComputePlan = "OE0111"
 )
 
 // OrcError represents an orchestration error.
@@ -79,6 +86,19 @@ type OrcError struct {
 	msg      string
 	internal error
 	source   string
+	details  []*FieldError
+}
+
+// FieldError describes a single invalid field of an item submitted in a batch.
+type FieldError struct {
+	// Index is the position of the item in the batch
+	Index int
+	// AssetKey is the key of the invalid item, it may be empty if the key itself is invalid
+	AssetKey string
+	// Field is the path of the invalid field, eg: "inputs.identifier"
+	Field string
+	Kind  ErrorKind
+	Msg   string
 }
 
 // Error returns the error message
@@ -103,6 +123,18 @@ func (e *OrcError) Wrap(err error) *OrcError {
 	return e
 }
 
+// Details returns the per-field errors attached to the error, if any
+func (e *OrcError) Details() []*FieldError {
+	return e.details
+}
+
+// WithDetails attaches per-field errors to the error.
+// It returns the OrcError for a convenient fluent interface.
+func (e *OrcError) WithDetails(details ...*FieldError) *OrcError {
+	e.details = append(e.details, details...)
+	return e
+}
+
 // Source will return error's source as file:line
 func (e *OrcError) Source() string {
 	return e.source
@@ -193,6 +225,12 @@ func NewTerminatedComputeTask(taskKey string) *OrcError {
 	return newErrorWithSource(ErrTerminatedComputeTask, msg)
 }
 
+// NewPausedComputePlan returns an ErrPausedComputePlan kind of OrcError with given message
+func NewPausedComputePlan(planKey string) *OrcError {
+	msg := fmt.Sprintf("compute plan %s is paused", planKey)
+	return newErrorWithSource(ErrPausedComputePlan, msg)
+}
+
 func NewIncompatibleTaskOutput(taskKey, identifier, expected, actual string) *OrcError {
 	return newErrorWithSource(
 		ErrIncompatibleKind,
@@ -210,3 +248,79 @@ func NewIncompatibleTaskOutput(taskKey, identifier, expected, actual string) *Or
 func FromValidationError(resource string, err error) *OrcError {
 	return newErrorWithSource(ErrInvalidAsset, fmt.Sprintf("%s is not valid", resource)).Wrap(err)
 }
+
+// FromValidationErrors returns an OrcError detailing every invalid item of a batch.
+// Its kind is the kind shared by every detail, so that a batch rejected for a single reason
+// (eg: permission denied) keeps the matching status code, and ErrInvalidAsset otherwise.
+func FromValidationErrors(resource string, details []*FieldError) *OrcError {
+	msg := fmt.Sprintf("%s is not valid: %d invalid field(s), see error details", resource, len(details))
+	kind := ErrInvalidAsset
+	if len(details) > 0 {
+		first := details[0]
+		if first.Field == "" {
+			msg = fmt.Sprintf("%s is not valid: %d invalid field(s), first one is item %d: %s", resource, len(details), first.Index, first.Msg)
+		} else {
+			msg = fmt.Sprintf("%s is not valid: %d invalid field(s), first one is %q of item %d: %s", resource, len(details), first.Field, first.Index, first.Msg)
+		}
+
+		kind = first.Kind
+		for _, d := range details[1:] {
+			if d.Kind != kind {
+				kind = ErrInvalidAsset
+				break
+			}
+		}
+	}
+
+	return newErrorWithSource(kind, msg).WithDetails(details...)
+}
+
+// IsAssetError returns true if the error is caused by the submitted asset itself,
+// as opposed to internal failures which should abort a batch right away rather than being reported per item.
+func IsAssetError(err error) bool {
+	orcError := new(OrcError)
+	return errors.As(err, &orcError) && orcError.Kind != ErrInternal
+}
+
+// NewFieldErrors flattens a validation error into per-field errors of the item at the given index.
+// Nested fields are joined with a dot and sorted by path.
+func NewFieldErrors(index int, assetKey string, err error) []*FieldError {
+	details := []*FieldError{}
+	appendFieldErrors(&details, index, assetKey, "", err)
+
+	return details
+}
+
+func appendFieldErrors(details *[]*FieldError, index int, assetKey, path string, err error) {
+	var fieldErrors validation.Errors
+	if errors.As(err, &fieldErrors) {
+		fields := make([]string, 0, len(fieldErrors))
+		for field := range fieldErrors {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		for _, field := range fields {
+			fieldPath := field
+			if path != "" {
+				fieldPath = path + "." + field
+			}
+			appendFieldErrors(details, index, assetKey, fieldPath, fieldErrors[field])
+		}
+		return
+	}
+
+	kind := ErrInvalidAsset
+	orcError := new(OrcError)
+	if errors.As(err, &orcError) {
+		kind = orcError.Kind
+	}
+
+	*details = append(*details, &FieldError{
+		Index:    index,
+		AssetKey: assetKey,
+		Field:    path,
+		Kind:     kind,
+		Msg:      err.Error(),
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,3 +31,48 @@ func TestErrorWrapping(t *testing.T) {
 
 	assert.Equal(t, ErrInternal, outErr.Kind)
 }
+
+func TestNewFieldErrors(t *testing.T) {
+	err := validation.Errors{
+		"key":     errors.New("must be a valid UUID"),
+		"inputs":  validation.Errors{"identifier": errors.New("cannot be blank")},
+		"outputs": NewError(ErrMissingTaskOutput, "missing output"),
+	}
+
+	expected := []*FieldError{
+		{Index: 3, AssetKey: "uuid", Field: "inputs.identifier", Kind: ErrInvalidAsset, Msg: "cannot be blank"},
+		{Index: 3, AssetKey: "uuid", Field: "key", Kind: ErrInvalidAsset, Msg: "must be a valid UUID"},
+		{Index: 3, AssetKey: "uuid", Field: "outputs", Kind: ErrMissingTaskOutput, Msg: "OE0106: missing output"},
+	}
+
+	assert.Equal(t, expected, NewFieldErrors(3, "uuid", err))
+}
+
+func TestFromValidationErrors(t *testing.T) {
+	details := NewFieldErrors(1, "uuid", validation.Errors{"key": errors.New("must be a valid UUID")})
+	err := FromValidationErrors("computetask", details)
+
+	assert.Equal(t, ErrInvalidAsset, err.Kind)
+	assert.Equal(t, details, err.Details())
+	assert.Equal(t, `OE0101: computetask is not valid: 1 invalid field(s), first one is "key" of item 1: must be a valid UUID`, err.Error())
+}
+
+func TestFromValidationErrorsKind(t *testing.T) {
+	denied := NewFieldErrors(0, "uuid1", NewPermissionDenied("not allowed"))
+	notFound := NewFieldErrors(2, "uuid2", NewNotFound("function", "uuid"))
+
+	err := FromValidationErrors("computetask", denied)
+	assert.Equal(t, ErrPermissionDenied, err.Kind, "a single reason should keep its kind")
+	assert.Equal(t, `OE0102: computetask is not valid: 1 invalid field(s), first one is item 0: OE0102: not allowed`, err.Error())
+
+	err = FromValidationErrors("computetask", append(denied, notFound...))
+	assert.Equal(t, ErrInvalidAsset, err.Kind)
+	assert.Len(t, err.Details(), 2)
+}
+
+func TestIsAssetError(t *testing.T) {
+	assert.True(t, IsAssetError(NewPermissionDenied("test")))
+	assert.True(t, IsAssetError(fmt.Errorf("wrapped: %w", NewNotFound("function", "uuid"))))
+	assert.False(t, IsAssetError(NewInternal("test")))
+	assert.False(t, IsAssetError(errors.New("connection reset")))
+}
@@ -15,7 +15,23 @@ type ComputePlanDBAL interface {
 	SetComputePlanName(plan *asset.ComputePlan, name string) error
 	CancelComputePlan(plan *asset.ComputePlan, cancelationDate time.Time) error
 	FailComputePlan(plan *asset.ComputePlan, failureDate time.Time) error
+	// RestoreComputePlan clears the failure date of a compute plan.
+	RestoreComputePlan(plan *asset.ComputePlan) error
+	PauseComputePlan(plan *asset.ComputePlan, pauseDate time.Time) error
+	ResumeComputePlan(plan *asset.ComputePlan, resumeDate time.Time) error
 	ArePlanTasksRunning(key string) (bool, error)
+	// GetComputePlanStatistics returns the task counts by status and the task timings of a compute plan.
+	// The returned statistics do not hold the plan status, which is left to the caller.
+	GetComputePlanStatistics(key string) (*asset.ComputePlanStatistics, error)
+	// GetComputePlanArchive returns the compute plan along with its tasks and every related asset.
+	GetComputePlanArchive(key string) (*asset.ComputePlanArchive, error)
+	// IsComputePlanReferenced returns true if a task of another compute plan depends on the given compute plan.
+	IsComputePlanReferenced(key string) (bool, error)
+	// PurgeComputePlan deletes the tasks of a compute plan and every related row, keeping the plan as a tombstone.
+	PurgeComputePlan(key string, purgeDate time.Time) (*asset.ComputePlanPurgeSummary, error)
+	// GetPurgeableComputePlanKeys returns the keys of the compute plans terminated before the given date
+	// which have not been purged yet.
+	GetPurgeableComputePlanKeys(terminatedBefore time.Time) ([]string, error)
 }
 
 type ComputePlanDBALProvider interface {
@@ -1,6 +1,8 @@
 package persistence
 
 import (
+	"time"
+
 	"github.com/substra/orchestrator/lib/asset"
 	"github.com/substra/orchestrator/lib/common"
 )
@@ -15,7 +17,16 @@ type ComputeTaskDBAL interface {
 	GetComputeTasks(keys []string) ([]*asset.ComputeTask, error)
 	AddComputeTasks(task ...*asset.ComputeTask) error
 	UpdateComputeTaskStatus(taskKey string, taskStatus asset.ComputeTaskStatus) error
-	QueryComputeTasks(p *common.Pagination, filter *asset.TaskQueryFilter) ([]*asset.ComputeTask, common.PaginationToken, error)
+	UpdateComputeTaskAttempt(taskKey string, attempt uint32) error
+	// UpdateComputeTaskLease sets the date until which an executing task is considered alive.
+	UpdateComputeTaskLease(taskKey string, expiration time.Time) error
+	// GetExpiredComputeTasks returns the executing tasks whose lease expired before the given date.
+	GetExpiredComputeTasks(expiredBefore time.Time) ([]*asset.ComputeTask, error)
+	// IsComputeTaskLeaseExpired returns true if the lease of the task expired before the given date.
+	// The lease of a task without lease never expires.
+	IsComputeTaskLeaseExpired(taskKey string, expiredBefore time.Time) (bool, error)
+	// QueryComputeTasks returns the tasks matching the filter, sorted by the given field.
+	QueryComputeTasks(p *common.Pagination, filter *asset.TaskQueryFilter, sortBy asset.TaskSortField, sortOrder asset.SortOrder) ([]*asset.ComputeTask, common.PaginationToken, error)
 	GetComputeTaskChildren(key string) ([]*asset.ComputeTask, error)
 	GetComputeTaskParents(key string) ([]*asset.ComputeTask, error)
 	// GetComputePlanTasks returns the tasks of the compute plan identified by the given key
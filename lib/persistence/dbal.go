@@ -80,6 +80,7 @@ type DBAL interface {
 	PerformanceDBAL
 	EventDBAL
 	FailureReportDBAL
+	WebhookDBAL
 }
 
 // DBALProvider exposes all available DBAL.
@@ -94,4 +95,5 @@ type DBALProvider interface {
 	PerformanceDBALProvider
 	EventDBALProvider
 	FailureReportDBALProvider
+	WebhookDBALProvider
 }
@@ -1,6 +1,8 @@
 package persistence
 
 import (
+	"time"
+
 	"github.com/substra/orchestrator/lib/asset"
 	"github.com/substra/orchestrator/lib/common"
 )
@@ -9,6 +11,9 @@ type EventDBAL interface {
 	NewEventID() string
 	AddEvents(events ...*asset.Event) error
 	QueryEvents(p *common.Pagination, filter *asset.EventQueryFilter, sortOrder asset.SortOrder) ([]*asset.Event, common.PaginationToken, error)
+	VerifyEventChain() (*asset.EventChainStatus, error)
+	AckEvents(owner string, consumer string, eventID string, ackDate time.Time) error
+	QueryEventConsumers(p *common.Pagination, owner string) ([]*asset.EventConsumer, common.PaginationToken, error)
 }
 
 type EventDBALProvider interface {
@@ -5,7 +5,8 @@ import (
 )
 
 type FailureReportDBAL interface {
-	GetFailureReport(assetKey string) (*asset.FailureReport, error)
+	// GetFailureReport returns the failure report of the given attempt, or the latest one if attempt is 0.
+	GetFailureReport(assetKey string, attempt uint32) (*asset.FailureReport, error)
 	AddFailureReport(f *asset.FailureReport) error
 }
 
@@ -0,0 +1,243 @@
+package memory
+
+import (
+	"maps"
+	"time"
+
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// planKeyset sorts compute plans by creation date.
+var planKeyset = dateKeyset(false, "creation_date", "key",
+	func(plan *asset.ComputePlan) time.Time { return plan.CreationDate.AsTime() },
+	func(plan *asset.ComputePlan) string { return plan.Key },
+	func(date time.Time, key string) *asset.ComputePlan {
+		return &asset.ComputePlan{CreationDate: timestamppb.New(date), Key: key}
+	},
+)
+
+// toComputePlan returns a copy of a stored compute plan.
+// Like in the standalone orchestrator, the failure date of a canceled plan is not returned.
+func toComputePlan(stored *asset.ComputePlan) *asset.ComputePlan {
+	plan := clone(stored)
+	if plan.CancelationDate != nil {
+		plan.FailureDate = nil
+	}
+
+	return plan
+}
+
+// ComputePlanExists implements persistence.ComputePlanDBAL
+func (d *DBAL) ComputePlanExists(key string) (bool, error) {
+	state, err := d.read()
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := state.computePlans[key]
+
+	return ok, nil
+}
+
+// AddComputePlan implements persistence.ComputePlanDBAL
+func (d *DBAL) AddComputePlan(plan *asset.ComputePlan) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := state.computePlans[plan.Key]; ok {
+		return orcerrors.NewConflict("computeplan", plan.Key)
+	}
+
+	// Only the immutable fields are stored, dates are set by the update methods
+	stored := &asset.ComputePlan{
+		Key:             plan.Key,
+		Owner:           plan.Owner,
+		CreationDate:    clone(plan.CreationDate),
+		Tag:             plan.Tag,
+		Name:            plan.Name,
+		Metadata:        maps.Clone(plan.Metadata),
+		MaxTaskAttempts: plan.MaxTaskAttempts,
+	}
+	put(d.tx, state.computePlans, plan.Key, stored)
+
+	return nil
+}
+
+// GetComputePlan implements persistence.ComputePlanDBAL
+func (d *DBAL) GetComputePlan(key string) (*asset.ComputePlan, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	plan, ok := state.computePlans[key]
+	if !ok {
+		return nil, orcerrors.NewNotFound("computeplan", key)
+	}
+
+	return toComputePlan(plan), nil
+}
+
+// QueryComputePlans implements persistence.ComputePlanDBAL
+func (d *DBAL) QueryComputePlans(p *common.Pagination, filter *asset.PlanQueryFilter) ([]*asset.ComputePlan, common.PaginationToken, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, "", err
+	}
+
+	plans := values(state.computePlans, planKeyset.less)
+	if filter != nil && filter.Owner != "" {
+		plans = keep(plans, func(plan *asset.ComputePlan) bool {
+			return plan.Owner == filter.Owner
+		})
+	}
+
+	page, token, err := paginate(plans, p, planKeyset)
+	if err != nil {
+		return nil, "", err
+	}
+
+	res := make([]*asset.ComputePlan, 0, len(page))
+	for _, plan := range page {
+		res = append(res, toComputePlan(plan))
+	}
+
+	return res, token, nil
+}
+
+// updateComputePlan replaces a plan by a copy modified by update, unknown plans are ignored.
+func (d *DBAL) updateComputePlan(key string, update func(*asset.ComputePlan)) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	stored, ok := state.computePlans[key]
+	if !ok {
+		return nil
+	}
+
+	updated := clone(stored)
+	update(updated)
+	put(d.tx, state.computePlans, key, updated)
+
+	return nil
+}
+
+// SetComputePlanName implements persistence.ComputePlanDBAL
+func (d *DBAL) SetComputePlanName(plan *asset.ComputePlan, name string) error {
+	return d.updateComputePlan(plan.Key, func(p *asset.ComputePlan) {
+		p.Name = name
+	})
+}
+
+// CancelComputePlan implements persistence.ComputePlanDBAL
+func (d *DBAL) CancelComputePlan(plan *asset.ComputePlan, cancelationDate time.Time) error {
+	return d.updateComputePlan(plan.Key, func(p *asset.ComputePlan) {
+		p.CancelationDate = timestamppb.New(cancelationDate)
+	})
+}
+
+// FailComputePlan implements persistence.ComputePlanDBAL
+func (d *DBAL) FailComputePlan(plan *asset.ComputePlan, failureDate time.Time) error {
+	return d.updateComputePlan(plan.Key, func(p *asset.ComputePlan) {
+		p.FailureDate = timestamppb.New(failureDate)
+	})
+}
+
+// RestoreComputePlan implements persistence.ComputePlanDBAL
+func (d *DBAL) RestoreComputePlan(plan *asset.ComputePlan) error {
+	return d.updateComputePlan(plan.Key, func(p *asset.ComputePlan) {
+		p.FailureDate = nil
+	})
+}
+
+// PauseComputePlan implements persistence.ComputePlanDBAL
+func (d *DBAL) PauseComputePlan(plan *asset.ComputePlan, pauseDate time.Time) error {
+	return d.updateComputePlan(plan.Key, func(p *asset.ComputePlan) {
+		p.PauseDate = timestamppb.New(pauseDate)
+	})
+}
+
+// ResumeComputePlan implements persistence.ComputePlanDBAL
+func (d *DBAL) ResumeComputePlan(plan *asset.ComputePlan, resumeDate time.Time) error {
+	return d.updateComputePlan(plan.Key, func(p *asset.ComputePlan) {
+		p.ResumeDate = timestamppb.New(resumeDate)
+	})
+}
+
+// ArePlanTasksRunning implements persistence.ComputePlanDBAL
+// A plan having a canceled or failed task is not running.
+func (d *DBAL) ArePlanTasksRunning(key string) (bool, error) {
+	tasks, err := d.GetComputePlanTasks(key)
+	if err != nil {
+		return false, err
+	}
+
+	running := false
+	for _, task := range tasks {
+		switch task.Status {
+		case asset.ComputeTaskStatus_STATUS_CANCELED, asset.ComputeTaskStatus_STATUS_FAILED:
+			return false, nil
+		case asset.ComputeTaskStatus_STATUS_DONE:
+		default:
+			running = true
+		}
+	}
+
+	return running, nil
+}
+
+// GetComputePlanStatistics implements persistence.ComputePlanDBAL
+// The first task start and the last task completion come from the task status update events.
+func (d *DBAL) GetComputePlanStatistics(key string) (*asset.ComputePlanStatistics, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &asset.ComputePlanStatistics{
+		ComputePlanKey: key,
+		TaskCounts:     []*asset.TaskStatusCount{},
+	}
+
+	counts := make(map[asset.ComputeTaskStatus]uint32)
+	for _, task := range state.computeTasks {
+		if task.ComputePlanKey == key {
+			counts[task.Status]++
+			stats.TaskCount++
+		}
+	}
+	for status, count := range counts {
+		stats.TaskCounts = append(stats.TaskCounts, &asset.TaskStatusCount{Status: status, Count: count})
+	}
+	// Counts are sorted by status name, like the standalone orchestrator
+	sortItems(stats.TaskCounts, func(a, b *asset.TaskStatusCount) bool {
+		return a.Status.String() < b.Status.String()
+	})
+
+	for _, e := range state.events {
+		task := e.event.GetComputeTask()
+		if task == nil || task.ComputePlanKey != key || e.event.EventKind != asset.EventKind_EVENT_ASSET_UPDATED {
+			continue
+		}
+
+		switch task.Status {
+		case asset.ComputeTaskStatus_STATUS_EXECUTING:
+			if stats.FirstTaskStartDate == nil || e.event.Timestamp.AsTime().Before(stats.FirstTaskStartDate.AsTime()) {
+				stats.FirstTaskStartDate = clone(e.event.Timestamp)
+			}
+		case asset.ComputeTaskStatus_STATUS_DONE:
+			if stats.LastTaskCompletionDate == nil || e.event.Timestamp.AsTime().After(stats.LastTaskCompletionDate.AsTime()) {
+				stats.LastTaskCompletionDate = clone(e.event.Timestamp)
+			}
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,252 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/substra/orchestrator/lib/asset"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GetComputePlanArchive implements persistence.ComputePlanDBAL
+func (d *DBAL) GetComputePlanArchive(key string) (*asset.ComputePlanArchive, error) {
+	archive, err := d.GetComputePlanAssets(key)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	planEvent := state.planEventMatcher(key)
+	archive.Events = []*asset.Event{}
+	for _, e := range state.events {
+		if e.event.AssetKey == key || planEvent(e) {
+			archive.Events = append(archive.Events, clone(e.event))
+		}
+	}
+
+	return archive, nil
+}
+
+// GetComputePlanAssets returns the compute plan along with its tasks and their outputs, without the events.
+func (d *DBAL) GetComputePlanAssets(key string) (*asset.ComputePlanArchive, error) {
+	plan, err := d.GetComputePlan(key)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := d.GetComputePlanTasks(key)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+	taskKeys := state.planTaskKeys(key)
+
+	archive := &asset.ComputePlanArchive{
+		ComputePlan: plan,
+		Tasks:       tasks,
+	}
+
+	archive.Models = cloneAll(keep(values(state.models, modelCreatedBefore), func(m *asset.Model) bool {
+		return taskKeys[m.ComputeTaskKey]
+	}))
+
+	archive.Performances = cloneAll(keep(values(state.performances, func(a, b *asset.Performance) bool {
+		if !a.CreationDate.AsTime().Equal(b.CreationDate.AsTime()) {
+			return a.CreationDate.AsTime().Before(b.CreationDate.AsTime())
+		}
+		if a.ComputeTaskKey != b.ComputeTaskKey {
+			return a.ComputeTaskKey < b.ComputeTaskKey
+		}
+		return a.ComputeTaskOutputIdentifier < b.ComputeTaskOutputIdentifier
+	}), func(perf *asset.Performance) bool {
+		return taskKeys[perf.ComputeTaskKey]
+	}))
+
+	archive.FailureReports = cloneAll(keep(values(state.failureReports, func(a, b *asset.FailureReport) bool {
+		if a.AssetKey != b.AssetKey {
+			return a.AssetKey < b.AssetKey
+		}
+		return a.Attempt < b.Attempt
+	}), func(r *asset.FailureReport) bool {
+		return taskKeys[r.AssetKey]
+	}))
+
+	outputs := make([]outputKey, 0)
+	for output := range state.outputAssets {
+		if taskKeys[output.taskKey] {
+			outputs = append(outputs, output)
+		}
+	}
+	sortItems(outputs, func(a, b outputKey) bool {
+		if a.taskKey != b.taskKey {
+			return a.taskKey < b.taskKey
+		}
+		return a.identifier < b.identifier
+	})
+	archive.OutputAssets = []*asset.ComputeTaskOutputAsset{}
+	for _, output := range outputs {
+		archive.OutputAssets = append(archive.OutputAssets, cloneAll(state.outputAssets[output])...)
+	}
+
+	return archive, nil
+}
+
+// IsComputePlanReferenced implements persistence.ComputePlanDBAL
+// A plan is referenced when a task of another compute plan depends on one of its tasks or on one of its models.
+func (d *DBAL) IsComputePlanReferenced(key string) (bool, error) {
+	state, err := d.read()
+	if err != nil {
+		return false, err
+	}
+
+	taskKeys := state.planTaskKeys(key)
+	modelKeys := make(map[string]bool)
+	for _, model := range state.models {
+		if taskKeys[model.ComputeTaskKey] {
+			modelKeys[model.Key] = true
+		}
+	}
+
+	for _, task := range state.computeTasks {
+		if task.ComputePlanKey == key {
+			continue
+		}
+		for _, input := range task.Inputs {
+			if taskKeys[input.GetParentTaskOutput().GetParentTaskKey()] || modelKeys[input.GetAssetKey()] {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// PurgeComputePlan implements persistence.ComputePlanDBAL
+// The compute plan is kept as a tombstone recording the purge date and what has been removed.
+func (d *DBAL) PurgeComputePlan(key string, purgeDate time.Time) (*asset.ComputePlanPurgeSummary, error) {
+	state, err := d.write()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := new(asset.ComputePlanPurgeSummary)
+	taskKeys := state.planTaskKeys(key)
+
+	// Purged events are replaced by their hash, so that the remaining events stay chained
+	planEvent := state.planEventMatcher(key)
+	events := state.events
+	tombstones := state.tombstones
+	kept := make([]*storedEvent, 0, len(events))
+	purged := []*storedEvent{}
+	for _, e := range events {
+		if planEvent(e) {
+			remove(d.tx, state.eventsByID, e.event.Id)
+			purged = append(purged, &storedEvent{
+				position:     e.position,
+				event:        &asset.Event{Id: e.event.Id},
+				hash:         e.hash,
+				previousHash: e.previousHash,
+			})
+			summary.EventCount++
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	state.events = kept
+	state.tombstones = mergeEvents(tombstones, purged)
+	d.tx.onRollback(func() {
+		state.events = events
+		state.tombstones = tombstones
+	})
+
+	for reportKey := range state.failureReports {
+		if taskKeys[reportKey.assetKey] {
+			remove(d.tx, state.failureReports, reportKey)
+			summary.FailureReportCount++
+		}
+	}
+	for perfKey := range state.performances {
+		if taskKeys[perfKey.taskKey] {
+			remove(d.tx, state.performances, perfKey)
+			summary.PerformanceCount++
+		}
+	}
+	for output, assets := range state.outputAssets {
+		if taskKeys[output.taskKey] {
+			remove(d.tx, state.outputAssets, output)
+			summary.OutputAssetCount += uint32(len(assets))
+		}
+	}
+	for modelKey, model := range state.models {
+		if taskKeys[model.ComputeTaskKey] {
+			remove(d.tx, state.models, modelKey)
+			summary.ModelCount++
+		}
+	}
+	for taskKey := range taskKeys {
+		remove(d.tx, state.computeTasks, taskKey)
+		remove(d.tx, state.taskLeases, taskKey)
+		summary.TaskCount++
+	}
+
+	err = d.updateComputePlan(key, func(plan *asset.ComputePlan) {
+		plan.PurgeDate = timestamppb.New(purgeDate)
+		plan.PurgeSummary = clone(summary)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// GetPurgeableComputePlanKeys implements persistence.ComputePlanDBAL
+// A plan is terminated when it has been canceled or has failed, or when all its tasks are done.
+func (d *DBAL) GetPurgeableComputePlanKeys(terminatedBefore time.Time) ([]string, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	// Date of the last task event and whether every task is done, by plan
+	lastTaskUpdates := make(map[string]time.Time)
+	for _, e := range state.events {
+		task := e.event.GetComputeTask()
+		if task == nil {
+			continue
+		}
+		if timestamp := e.event.Timestamp.AsTime(); timestamp.After(lastTaskUpdates[task.ComputePlanKey]) {
+			lastTaskUpdates[task.ComputePlanKey] = timestamp
+		}
+	}
+	allDone := make(map[string]bool)
+	for _, task := range state.computeTasks {
+		done, ok := allDone[task.ComputePlanKey]
+		allDone[task.ComputePlanKey] = (done || !ok) && task.Status == asset.ComputeTaskStatus_STATUS_DONE
+	}
+
+	keys := []string{}
+	for _, plan := range values(state.computePlans, planKeyset.less) {
+		if plan.PurgeDate != nil {
+			continue
+		}
+
+		lastTaskUpdate, updated := lastTaskUpdates[plan.Key]
+		terminated := (plan.CancelationDate != nil && plan.CancelationDate.AsTime().Before(terminatedBefore)) ||
+			(plan.FailureDate != nil && plan.FailureDate.AsTime().Before(terminatedBefore)) ||
+			(allDone[plan.Key] && updated && lastTaskUpdate.Before(terminatedBefore))
+
+		if terminated {
+			keys = append(keys, plan.Key)
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,391 @@
+package memory
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"github.com/substra/orchestrator/lib/persistence"
+	"github.com/substra/orchestrator/utils"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func taskCreatedBefore(a, b *asset.ComputeTask) bool {
+	return createdBefore(a.CreationDate.AsTime(), a.Key, b.CreationDate.AsTime(), b.Key)
+}
+
+// parentTaskKeys returns the unique keys of the parents of a task, in the order of its inputs.
+func parentTaskKeys(task *asset.ComputeTask) []string {
+	keys := []string{}
+	for _, input := range task.Inputs {
+		if ref := input.GetParentTaskOutput(); ref != nil && !utils.SliceContains(keys, ref.ParentTaskKey) {
+			keys = append(keys, ref.ParentTaskKey)
+		}
+	}
+
+	return keys
+}
+
+// containsMetadata returns whether every entry of subset is in metadata.
+func containsMetadata(metadata map[string]string, subset map[string]string) bool {
+	for k, v := range subset {
+		if value, ok := metadata[k]; !ok || value != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AddComputeTasks implements persistence.ComputeTaskDBAL
+func (d *DBAL) AddComputeTasks(tasks ...*asset.ComputeTask) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if _, ok := state.computeTasks[task.Key]; ok {
+			return orcerrors.NewConflict("computetask", task.Key)
+		}
+		put(d.tx, state.computeTasks, task.Key, clone(task))
+	}
+
+	return nil
+}
+
+// updateComputeTask replaces a task by a copy modified by update, unknown tasks are ignored.
+func (d *DBAL) updateComputeTask(key string, update func(*asset.ComputeTask)) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	stored, ok := state.computeTasks[key]
+	if !ok {
+		return nil
+	}
+
+	updated := clone(stored)
+	update(updated)
+	put(d.tx, state.computeTasks, key, updated)
+
+	return nil
+}
+
+// UpdateComputeTaskStatus implements persistence.ComputeTaskDBAL
+func (d *DBAL) UpdateComputeTaskStatus(taskKey string, taskStatus asset.ComputeTaskStatus) error {
+	return d.updateComputeTask(taskKey, func(task *asset.ComputeTask) {
+		task.Status = taskStatus
+	})
+}
+
+// UpdateComputeTaskAttempt implements persistence.ComputeTaskDBAL
+func (d *DBAL) UpdateComputeTaskAttempt(taskKey string, attempt uint32) error {
+	return d.updateComputeTask(taskKey, func(task *asset.ComputeTask) {
+		task.Attempt = attempt
+	})
+}
+
+// UpdateComputeTaskLease implements persistence.ComputeTaskDBAL
+func (d *DBAL) UpdateComputeTaskLease(taskKey string, expiration time.Time) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := state.computeTasks[taskKey]; ok {
+		put(d.tx, state.taskLeases, taskKey, expiration)
+	}
+
+	return nil
+}
+
+// GetExistingComputeTaskKeys implements persistence.ComputeTaskDBAL
+func (d *DBAL) GetExistingComputeTaskKeys(keys []string) ([]string, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	existingKeys := []string{}
+	for _, key := range utils.Unique(keys) {
+		if _, ok := state.computeTasks[key]; ok {
+			existingKeys = append(existingKeys, key)
+		}
+	}
+
+	return existingKeys, nil
+}
+
+// GetComputeTask implements persistence.ComputeTaskDBAL
+func (d *DBAL) GetComputeTask(key string) (*asset.ComputeTask, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	task, ok := state.computeTasks[key]
+	if !ok {
+		return nil, orcerrors.NewNotFound("computetask", key)
+	}
+
+	return clone(task), nil
+}
+
+// GetComputeTasks implements persistence.ComputeTaskDBAL
+func (d *DBAL) GetComputeTasks(keys []string) ([]*asset.ComputeTask, error) {
+	return d.getComputeTasks(func(task *asset.ComputeTask) bool {
+		return utils.SliceContains(keys, task.Key)
+	})
+}
+
+// getComputeTasks returns the tasks matching the predicate, sorted by creation date.
+func (d *DBAL) getComputeTasks(match func(*asset.ComputeTask) bool) ([]*asset.ComputeTask, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return cloneAll(keep(values(state.computeTasks, taskCreatedBefore), match)), nil
+}
+
+// GetComputeTaskChildren implements persistence.ComputeTaskDBAL
+func (d *DBAL) GetComputeTaskChildren(key string) ([]*asset.ComputeTask, error) {
+	return d.getComputeTasks(func(task *asset.ComputeTask) bool {
+		return utils.SliceContains(parentTaskKeys(task), key)
+	})
+}
+
+// GetComputeTaskParents implements persistence.ComputeTaskDBAL
+// Parents are returned in the order of the task inputs.
+func (d *DBAL) GetComputeTaskParents(key string) ([]*asset.ComputeTask, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	task, ok := state.computeTasks[key]
+	if !ok {
+		return []*asset.ComputeTask{}, nil
+	}
+
+	parents := []*asset.ComputeTask{}
+	for _, parentKey := range parentTaskKeys(task) {
+		if parent, ok := state.computeTasks[parentKey]; ok {
+			parents = append(parents, clone(parent))
+		}
+	}
+
+	return parents, nil
+}
+
+// GetComputePlanTasks implements persistence.ComputeTaskDBAL
+func (d *DBAL) GetComputePlanTasks(key string) ([]*asset.ComputeTask, error) {
+	return d.getComputeTasks(func(task *asset.ComputeTask) bool {
+		return task.ComputePlanKey == key
+	})
+}
+
+// GetComputePlanTasksKeys implements persistence.ComputeTaskDBAL
+func (d *DBAL) GetComputePlanTasksKeys(key string) ([]string, error) {
+	tasks, err := d.GetComputePlanTasks(key)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		keys = append(keys, task.Key)
+	}
+
+	return keys, nil
+}
+
+// GetFunctionFromTasksWithStatus implements persistence.ComputeTaskDBAL
+func (d *DBAL) GetFunctionFromTasksWithStatus(key string, statuses []asset.ComputeTaskStatus) ([]*asset.ComputeTask, error) {
+	return d.getComputeTasks(func(task *asset.ComputeTask) bool {
+		return task.FunctionKey == key && utils.SliceContains(statuses, task.Status)
+	})
+}
+
+// GetExpiredComputeTasks implements persistence.ComputeTaskDBAL
+// Tasks without lease are never returned.
+func (d *DBAL) GetExpiredComputeTasks(expiredBefore time.Time) ([]*asset.ComputeTask, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.getComputeTasks(func(task *asset.ComputeTask) bool {
+		lease, ok := state.taskLeases[task.Key]
+		return ok && task.Status == asset.ComputeTaskStatus_STATUS_EXECUTING && lease.Before(expiredBefore)
+	})
+}
+
+// IsComputeTaskLeaseExpired implements persistence.ComputeTaskDBAL
+func (d *DBAL) IsComputeTaskLeaseExpired(taskKey string, expiredBefore time.Time) (bool, error) {
+	state, err := d.read()
+	if err != nil {
+		return false, err
+	}
+
+	lease, ok := state.taskLeases[taskKey]
+
+	return ok && lease.Before(expiredBefore), nil
+}
+
+// QueryComputeTasks implements persistence.ComputeTaskDBAL
+func (d *DBAL) QueryComputeTasks(p *common.Pagination, filter *asset.TaskQueryFilter, sortBy asset.TaskSortField, sortOrder asset.SortOrder) ([]*asset.ComputeTask, common.PaginationToken, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, "", err
+	}
+
+	ks := taskKeyset(sortBy, sortOrder)
+	tasks := values(state.computeTasks, ks.less)
+	if filter != nil {
+		tasks = keep(tasks, func(task *asset.ComputeTask) bool {
+			return matchTaskFilter(task, filter)
+		})
+	}
+
+	page, token, err := paginate(tasks, p, ks)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return cloneAll(page), token, nil
+}
+
+// taskKeyset returns the keyset sorting tasks by the given field.
+// The key is always the last criterion so that pagination is stable.
+func taskKeyset(sortBy asset.TaskSortField, sortOrder asset.SortOrder) keyset[*asset.ComputeTask] {
+	desc := sortOrder == asset.SortOrder_DESCENDING
+
+	var ks keyset[*asset.ComputeTask]
+
+	switch sortBy {
+	case asset.TaskSortField_TASK_SORT_RANK:
+		ks = keyset[*asset.ComputeTask]{
+			orderBy: orderBy(desc, "rank", "key"),
+			less: func(a, b *asset.ComputeTask) bool {
+				if a.Rank != b.Rank {
+					return a.Rank < b.Rank
+				}
+				return a.Key < b.Key
+			},
+			values: func(task *asset.ComputeTask) []string {
+				return []string{strconv.Itoa(int(task.Rank)), task.Key}
+			},
+			pivot: func(values []string) (*asset.ComputeTask, error) {
+				if len(values) != 2 {
+					return nil, fmt.Errorf("expected 2 values, got %d", len(values))
+				}
+				rank, err := strconv.ParseInt(values[0], 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				return &asset.ComputeTask{Rank: int32(rank), Key: values[1]}, nil
+			},
+		}
+	case asset.TaskSortField_TASK_SORT_KEY:
+		ks = keyset[*asset.ComputeTask]{
+			orderBy: orderBy(desc, "key"),
+			less: func(a, b *asset.ComputeTask) bool {
+				return a.Key < b.Key
+			},
+			values: func(task *asset.ComputeTask) []string {
+				return []string{task.Key}
+			},
+			pivot: func(values []string) (*asset.ComputeTask, error) {
+				if len(values) != 1 {
+					return nil, fmt.Errorf("expected 1 value, got %d", len(values))
+				}
+				return &asset.ComputeTask{Key: values[0]}, nil
+			},
+		}
+	default:
+		// dateKeyset handles the sort order by itself
+		return dateKeyset(desc, "creation_date", "key",
+			func(task *asset.ComputeTask) time.Time { return task.CreationDate.AsTime() },
+			func(task *asset.ComputeTask) string { return task.Key },
+			func(date time.Time, key string) *asset.ComputeTask {
+				return &asset.ComputeTask{CreationDate: timestamppb.New(date), Key: key}
+			},
+		)
+	}
+
+	if desc {
+		less := ks.less
+		ks.less = func(a, b *asset.ComputeTask) bool { return less(b, a) }
+	}
+
+	return ks
+}
+
+// matchTaskFilter returns whether a task matches every criterion of the filter.
+func matchTaskFilter(task *asset.ComputeTask, filter *asset.TaskQueryFilter) bool {
+	statuses := append([]asset.ComputeTaskStatus{}, filter.Statuses...)
+	if filter.Status != asset.ComputeTaskStatus_STATUS_UNKNOWN {
+		statuses = append(statuses, filter.Status)
+	}
+	creationDate := task.CreationDate.AsTime()
+
+	return (filter.Worker == "" || task.Worker == filter.Worker) &&
+		(len(statuses) == 0 || utils.SliceContains(statuses, task.Status)) &&
+		(filter.ComputePlanKey == "" || task.ComputePlanKey == filter.ComputePlanKey) &&
+		(filter.FunctionKey == "" || task.FunctionKey == filter.FunctionKey) &&
+		(filter.Owner == "" || task.Owner == filter.Owner) &&
+		(len(filter.Keys) == 0 || utils.SliceContains(filter.Keys, task.Key)) &&
+		(filter.RankMin == nil || task.Rank >= filter.GetRankMin()) &&
+		(filter.RankMax == nil || task.Rank <= filter.GetRankMax()) &&
+		(filter.CreationDateStart == nil || !creationDate.Before(filter.CreationDateStart.AsTime())) &&
+		(filter.CreationDateEnd == nil || !creationDate.After(filter.CreationDateEnd.AsTime())) &&
+		containsMetadata(task.Metadata, filter.Metadata)
+}
+
+// AddComputeTaskOutputAsset implements persistence.ComputeTaskDBAL
+func (d *DBAL) AddComputeTaskOutputAsset(output *asset.ComputeTaskOutputAsset) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	key := outputKey{taskKey: output.ComputeTaskKey, identifier: output.ComputeTaskOutputIdentifier}
+	outputs := append(append([]*asset.ComputeTaskOutputAsset{}, state.outputAssets[key]...), clone(output))
+	put(d.tx, state.outputAssets, key, outputs)
+
+	return nil
+}
+
+// CountComputeTaskRegisteredOutputs implements persistence.ComputeTaskDBAL
+func (d *DBAL) CountComputeTaskRegisteredOutputs(key string) (persistence.ComputeTaskOutputCounter, error) {
+	counter := make(persistence.ComputeTaskOutputCounter)
+
+	state, err := d.read()
+	if err != nil {
+		return counter, err
+	}
+
+	for output, assets := range state.outputAssets {
+		if output.taskKey == key && len(assets) > 0 {
+			counter[output.identifier] = len(assets)
+		}
+	}
+
+	return counter, nil
+}
+
+// GetComputeTaskOutputAssets implements persistence.ComputeTaskDBAL
+func (d *DBAL) GetComputeTaskOutputAssets(taskKey, identifier string) ([]*asset.ComputeTaskOutputAsset, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return cloneAll(state.outputAssets[outputKey{taskKey: taskKey, identifier: identifier}]), nil
+}
@@ -0,0 +1,164 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newTestTask(key string, rank int32, parents ...string) *asset.ComputeTask {
+	task := &asset.ComputeTask{
+		Key:            key,
+		ComputePlanKey: "cp",
+		Rank:           rank,
+		Status:         asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT,
+		CreationDate:   timestamppb.New(testTime(int64(rank))),
+	}
+	for _, parent := range parents {
+		task.Inputs = append(task.Inputs, &asset.ComputeTaskInput{
+			Identifier: "model",
+			Ref: &asset.ComputeTaskInput_ParentTaskOutput{
+				ParentTaskOutput: &asset.ParentTaskOutputRef{ParentTaskKey: parent, OutputIdentifier: "model"},
+			},
+		})
+	}
+
+	return task
+}
+
+func TestComputeTaskRelatives(t *testing.T) {
+	tx := NewStore().Begin(false)
+	defer tx.Rollback() //nolint:errcheck
+	dbal := New(tx, testChannel)
+
+	require.NoError(t, dbal.AddComputeTasks(
+		newTestTask("parent1", 0),
+		newTestTask("parent2", 0),
+		newTestTask("child", 1, "parent2", "parent1", "parent2"),
+	))
+
+	parents, err := dbal.GetComputeTaskParents("child")
+	assert.NoError(t, err)
+	require.Len(t, parents, 2)
+	assert.Equal(t, "parent2", parents[0].Key)
+	assert.Equal(t, "parent1", parents[1].Key)
+
+	children, err := dbal.GetComputeTaskChildren("parent1")
+	assert.NoError(t, err)
+	require.Len(t, children, 1)
+	assert.Equal(t, "child", children[0].Key)
+}
+
+func TestQueryComputeTasks(t *testing.T) {
+	tx := NewStore().Begin(false)
+	defer tx.Rollback() //nolint:errcheck
+	dbal := New(tx, testChannel)
+
+	require.NoError(t, dbal.AddComputeTasks(newTestTask("a", 2), newTestTask("b", 0), newTestTask("c", 1)))
+	require.NoError(t, dbal.UpdateComputeTaskStatus("c", asset.ComputeTaskStatus_STATUS_DONE))
+
+	tasks, _, err := dbal.QueryComputeTasks(common.NewPagination("", 10), nil, asset.TaskSortField_TASK_SORT_RANK, asset.SortOrder_DESCENDING)
+	assert.NoError(t, err)
+	require.Len(t, tasks, 3)
+	assert.Equal(t, []string{"a", "c", "b"}, []string{tasks[0].Key, tasks[1].Key, tasks[2].Key})
+
+	rankMin := int32(1)
+	tasks, _, err = dbal.QueryComputeTasks(
+		common.NewPagination("", 10),
+		&asset.TaskQueryFilter{RankMin: &rankMin, Statuses: []asset.ComputeTaskStatus{asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT}},
+		asset.TaskSortField_TASK_SORT_CREATION_DATE,
+		asset.SortOrder_ASCENDING,
+	)
+	assert.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "a", tasks[0].Key)
+}
+
+func TestGetExpiredComputeTasks(t *testing.T) {
+	tx := NewStore().Begin(false)
+	defer tx.Rollback() //nolint:errcheck
+	dbal := New(tx, testChannel)
+
+	require.NoError(t, dbal.AddComputeTasks(newTestTask("expired", 0), newTestTask("alive", 0), newTestTask("noLease", 0)))
+	for _, key := range []string{"expired", "alive", "noLease"} {
+		require.NoError(t, dbal.UpdateComputeTaskStatus(key, asset.ComputeTaskStatus_STATUS_EXECUTING))
+	}
+	require.NoError(t, dbal.UpdateComputeTaskLease("expired", testTime(1)))
+	require.NoError(t, dbal.UpdateComputeTaskLease("alive", testTime(10)))
+
+	tasks, err := dbal.GetExpiredComputeTasks(testTime(5))
+	assert.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "expired", tasks[0].Key)
+}
+
+func TestIsComputeTaskLeaseExpired(t *testing.T) {
+	tx := NewStore().Begin(false)
+	defer tx.Rollback() //nolint:errcheck
+	dbal := New(tx, testChannel)
+
+	require.NoError(t, dbal.AddComputeTasks(newTestTask("task", 0), newTestTask("noLease", 0)))
+	require.NoError(t, dbal.UpdateComputeTaskLease("task", testTime(5)))
+
+	expired, err := dbal.IsComputeTaskLeaseExpired("task", testTime(10))
+	assert.NoError(t, err)
+	assert.True(t, expired)
+
+	expired, err = dbal.IsComputeTaskLeaseExpired("task", testTime(1))
+	assert.NoError(t, err)
+	assert.False(t, expired)
+
+	expired, err = dbal.IsComputeTaskLeaseExpired("noLease", testTime(10))
+	assert.NoError(t, err)
+	assert.False(t, expired)
+}
+
+func TestPurgeComputePlan(t *testing.T) {
+	store := NewStore()
+	tx := store.Begin(false)
+	dbal := New(tx, testChannel)
+
+	require.NoError(t, dbal.AddComputePlan(&asset.ComputePlan{Key: "cp"}))
+	require.NoError(t, dbal.AddComputeTasks(newTestTask("task", 0)))
+	require.NoError(t, dbal.AddModel(&asset.Model{Key: "model", ComputeTaskKey: "task"}, "model"))
+	require.NoError(t, dbal.AddComputeTaskOutputAsset(&asset.ComputeTaskOutputAsset{ComputeTaskKey: "task", ComputeTaskOutputIdentifier: "model", AssetKey: "model"}))
+	require.NoError(t, dbal.AddEvents(
+		&asset.Event{Id: "e1", AssetKey: "cp", Asset: &asset.Event_ComputePlan{ComputePlan: &asset.ComputePlan{Key: "cp"}}},
+		newTestEvent("e2", 1, newTestTask("task", 0)),
+	))
+	require.NoError(t, tx.Commit())
+
+	tx = store.Begin(false)
+	dbal = New(tx, testChannel)
+
+	summary, err := dbal.PurgeComputePlan("cp", testTime(2))
+	require.NoError(t, err)
+	expected := &asset.ComputePlanPurgeSummary{TaskCount: 1, ModelCount: 1, OutputAssetCount: 1, EventCount: 1}
+	assert.True(t, proto.Equal(expected, summary))
+
+	plan, err := dbal.GetComputePlan("cp")
+	assert.NoError(t, err)
+	assert.Equal(t, testTime(2), plan.PurgeDate.AsTime())
+
+	archive, err := dbal.GetComputePlanArchive("cp")
+	assert.NoError(t, err)
+	assert.Empty(t, archive.Tasks)
+	require.Len(t, archive.Events, 1, "events of the plan itself should be kept")
+	require.NoError(t, tx.Rollback())
+
+	tx = store.Begin(true)
+	defer tx.Rollback() //nolint:errcheck
+
+	archive, err = New(tx, testChannel).GetComputePlanArchive("cp")
+	assert.NoError(t, err)
+	assert.Nil(t, archive.ComputePlan.PurgeDate)
+	assert.Len(t, archive.Tasks, 1)
+	assert.Len(t, archive.Models, 1)
+	assert.Len(t, archive.OutputAssets, 1)
+	assert.Len(t, archive.Events, 2)
+}
@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+)
+
+// cursorVersion is the version of the pagination tokens, they follow the tokens of the standalone orchestrator.
+const cursorVersion = 1
+
+// cursor is the content of a pagination token: the sort values of the last item of the previous page.
+type cursor struct {
+	Version int      `json:"v"`
+	OrderBy string   `json:"o"`
+	Values  []string `json:"k"`
+}
+
+// keyset describes the sort order of a paginated list.
+// The sort values of an item must be unique so that the item can be used as a cursor.
+type keyset[T any] struct {
+	// orderBy identifies the sort order in page tokens
+	orderBy string
+	less    func(a, b T) bool
+	// values returns the sort values of an item
+	values func(T) []string
+	// pivot returns an item holding the given sort values, which is sorted like the item they were taken from
+	pivot func(values []string) (T, error)
+}
+
+// orderBy returns the description of a sort order by the given columns.
+func orderBy(desc bool, columns ...string) string {
+	order := "ASC"
+	if desc {
+		order = "DESC"
+	}
+
+	clauses := make([]string, 0, len(columns))
+	for _, c := range columns {
+		clauses = append(clauses, c+" "+order)
+	}
+
+	return strings.Join(clauses, ", ")
+}
+
+// dateKeyset sorts items by date then key, which is how assets are sorted by creation date.
+func dateKeyset[T any](desc bool, dateColumn string, keyColumn string, date func(T) time.Time, key func(T) string, pivot func(time.Time, string) T) keyset[T] {
+	return keyset[T]{
+		orderBy: orderBy(desc, dateColumn, keyColumn),
+		less: func(a, b T) bool {
+			if desc {
+				a, b = b, a
+			}
+			return createdBefore(date(a), key(a), date(b), key(b))
+		},
+		values: func(item T) []string {
+			return []string{cursorTime(date(item)), key(item)}
+		},
+		pivot: func(values []string) (T, error) {
+			var item T
+			if len(values) != 2 {
+				return item, fmt.Errorf("expected 2 values, got %d", len(values))
+			}
+			date, err := time.Parse(time.RFC3339Nano, values[0])
+			if err != nil {
+				return item, err
+			}
+			return pivot(date, values[1]), nil
+		},
+	}
+}
+
+// paginate returns the page of items starting after the cursor of the token, a nil pagination returns every item.
+// Items must be sorted with the keyset: unlike offsets, cursors are not shifted
+// by items added or removed before them between two pages.
+func paginate[T any](items []T, p *common.Pagination, ks keyset[T]) ([]T, common.PaginationToken, error) {
+	if p == nil {
+		return items, "", nil
+	}
+
+	if p.Token != "" {
+		pivot, err := parseCursor(p.Token, ks)
+		if err != nil {
+			return nil, "", err
+		}
+		start := sort.Search(len(items), func(i int) bool {
+			return ks.less(pivot, items[i])
+		})
+		items = items[start:]
+	}
+
+	if len(items) <= int(p.Size) {
+		return items, "", nil
+	}
+
+	page := items[:p.Size]
+	token, err := newCursorToken(ks.orderBy, ks.values(page[len(page)-1]))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return page, token, nil
+}
+
+// parseCursor returns an item located at the cursor of a token.
+func parseCursor[T any](token common.PaginationToken, ks keyset[T]) (T, error) {
+	var pivot T
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return pivot, orcerrors.NewBadRequest("invalid page token").Wrap(err)
+	}
+
+	c := new(cursor)
+	if err := json.Unmarshal(data, c); err != nil {
+		return pivot, orcerrors.NewBadRequest("invalid page token").Wrap(err)
+	}
+	if c.Version != cursorVersion {
+		return pivot, orcerrors.NewBadRequest(fmt.Sprintf("unsupported page token version %d", c.Version))
+	}
+	if c.OrderBy != ks.orderBy {
+		return pivot, orcerrors.NewBadRequest("page token does not match the query sort order")
+	}
+
+	pivot, err = ks.pivot(c.Values)
+	if err != nil {
+		return pivot, orcerrors.NewBadRequest("invalid page token").Wrap(err)
+	}
+
+	return pivot, nil
+}
+
+// newCursorToken returns the token of the page starting after the item with the given sort values.
+func newCursorToken(orderBy string, values []string) (common.PaginationToken, error) {
+	data, err := json.Marshal(cursor{Version: cursorVersion, OrderBy: orderBy, Values: values})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// cursorTime formats a timestamp as a cursor value without losing precision.
+func cursorTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+)
+
+var intKeyset = keyset[int]{
+	orderBy: orderBy(false, "value"),
+	less:    func(a, b int) bool { return a < b },
+	values:  func(i int) []string { return []string{strconv.Itoa(i)} },
+	pivot: func(values []string) (int, error) {
+		return strconv.Atoi(values[0])
+	},
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	page, token, err := paginate(items, common.NewPagination("", 2), intKeyset)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, page)
+	assert.NotEmpty(t, token)
+
+	// Removing an item of a previous page doesn't shift the next one
+	page, token, err = paginate([]int{2, 3, 4, 5}, common.NewPagination(token, 2), intKeyset)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 4}, page)
+
+	// The next page starts after the cursor even if its item has been removed
+	page, token, err = paginate([]int{1, 2, 3, 5}, common.NewPagination(token, 2), intKeyset)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{5}, page)
+	assert.Equal(t, "", token)
+
+	page, _, err = paginate(items, nil, intKeyset)
+	assert.NoError(t, err)
+	assert.Equal(t, items, page)
+}
+
+func TestPaginateInvalidToken(t *testing.T) {
+	items := []int{1, 2, 3}
+
+	_, token, err := paginate(items, common.NewPagination("", 1), intKeyset)
+	assert.NoError(t, err)
+
+	otherOrder := intKeyset
+	otherOrder.orderBy = orderBy(true, "value")
+
+	cases := map[string]struct {
+		token common.PaginationToken
+		ks    keyset[int]
+	}{
+		"not a cursor":         {token: "invalid", ks: intKeyset},
+		"offset":               {token: "2", ks: intKeyset},
+		"different sort order": {token: token, ks: otherOrder},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := paginate(items, common.NewPagination(c.token, 1), c.ks)
+			orcErr := new(orcerrors.OrcError)
+			assert.ErrorAs(t, err, &orcErr)
+			assert.Equal(t, orcerrors.ErrBadRequest, orcErr.Kind)
+		})
+	}
+}
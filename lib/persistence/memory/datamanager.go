@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// dataManagerKeyset sorts data managers by creation date.
+var dataManagerKeyset = dateKeyset(false, "creation_date", "key",
+	func(dm *asset.DataManager) time.Time { return dm.CreationDate.AsTime() },
+	func(dm *asset.DataManager) string { return dm.Key },
+	func(date time.Time, key string) *asset.DataManager {
+		return &asset.DataManager{CreationDate: timestamppb.New(date), Key: key}
+	},
+)
+
+// AddDataManager implements persistence.DataManagerDBAL
+func (d *DBAL) AddDataManager(dataManager *asset.DataManager) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := state.dataManagers[dataManager.Key]; ok {
+		return orcerrors.NewConflict("datamanager", dataManager.Key)
+	}
+	put(d.tx, state.dataManagers, dataManager.Key, clone(dataManager))
+
+	return nil
+}
+
+// DataManagerExists implements persistence.DataManagerDBAL
+func (d *DBAL) DataManagerExists(key string) (bool, error) {
+	state, err := d.read()
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := state.dataManagers[key]
+
+	return ok, nil
+}
+
+// GetDataManager implements persistence.DataManagerDBAL
+func (d *DBAL) GetDataManager(key string) (*asset.DataManager, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	dataManager, ok := state.dataManagers[key]
+	if !ok {
+		return nil, orcerrors.NewNotFound("datamanager", key)
+	}
+
+	return clone(dataManager), nil
+}
+
+// QueryDataManagers implements persistence.DataManagerDBAL
+func (d *DBAL) QueryDataManagers(p *common.Pagination) ([]*asset.DataManager, common.PaginationToken, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, "", err
+	}
+
+	dataManagers := values(state.dataManagers, dataManagerKeyset.less)
+
+	page, token, err := paginate(dataManagers, p, dataManagerKeyset)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return cloneAll(page), token, nil
+}
+
+// UpdateDataManager implements persistence.DataManagerDBAL
+// Only the name of the data manager is updated.
+func (d *DBAL) UpdateDataManager(dataManager *asset.DataManager) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	stored, ok := state.dataManagers[dataManager.Key]
+	if !ok {
+		return nil
+	}
+
+	updated := clone(stored)
+	updated.Name = dataManager.Name
+	put(d.tx, state.dataManagers, dataManager.Key, updated)
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"github.com/substra/orchestrator/utils"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// dataSampleKeyset sorts data samples by creation date.
+var dataSampleKeyset = dateKeyset(false, "creation_date", "key",
+	func(ds *asset.DataSample) time.Time { return ds.CreationDate.AsTime() },
+	func(ds *asset.DataSample) string { return ds.Key },
+	func(date time.Time, key string) *asset.DataSample {
+		return &asset.DataSample{CreationDate: timestamppb.New(date), Key: key}
+	},
+)
+
+// DataSampleExists implements persistence.DataSampleDBAL
+func (d *DBAL) DataSampleExists(key string) (bool, error) {
+	state, err := d.read()
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := state.dataSamples[key]
+
+	return ok, nil
+}
+
+// AddDataSamples implements persistence.DataSampleDBAL
+func (d *DBAL) AddDataSamples(dataSamples ...*asset.DataSample) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	for _, ds := range dataSamples {
+		if _, ok := state.dataSamples[ds.Key]; ok {
+			return orcerrors.NewConflict("datasample", ds.Key)
+		}
+		put(d.tx, state.dataSamples, ds.Key, clone(ds))
+	}
+
+	return nil
+}
+
+// UpdateDataSample implements persistence.DataSampleDBAL
+func (d *DBAL) UpdateDataSample(dataSample *asset.DataSample) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	stored, ok := state.dataSamples[dataSample.Key]
+	if !ok {
+		return nil
+	}
+
+	updated := clone(stored)
+	updated.Owner = dataSample.Owner
+	updated.Checksum = dataSample.Checksum
+	updated.DataManagerKeys = append([]string{}, dataSample.DataManagerKeys...)
+	put(d.tx, state.dataSamples, dataSample.Key, updated)
+
+	return nil
+}
+
+// GetDataSample implements persistence.DataSampleDBAL
+func (d *DBAL) GetDataSample(key string) (*asset.DataSample, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	ds, ok := state.dataSamples[key]
+	if !ok {
+		return nil, orcerrors.NewNotFound("datasample", key)
+	}
+
+	return clone(ds), nil
+}
+
+// QueryDataSamples implements persistence.DataSampleDBAL
+func (d *DBAL) QueryDataSamples(p *common.Pagination, filter *asset.DataSampleQueryFilter) ([]*asset.DataSample, common.PaginationToken, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, "", err
+	}
+
+	dataSamples := values(state.dataSamples, dataSampleKeyset.less)
+	if filter != nil && len(filter.Keys) > 0 {
+		dataSamples = keep(dataSamples, func(ds *asset.DataSample) bool {
+			return utils.SliceContains(filter.Keys, ds.Key)
+		})
+	}
+
+	page, token, err := paginate(dataSamples, p, dataSampleKeyset)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return cloneAll(page), token, nil
+}
+
+// GetDataSampleKeysByManager implements persistence.DataSampleDBAL
+func (d *DBAL) GetDataSampleKeysByManager(dataManagerKey string) ([]string, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for _, ds := range values(state.dataSamples, dataSampleKeyset.less) {
+		if utils.SliceContains(ds.DataManagerKeys, dataManagerKey) {
+			keys = append(keys, ds.Key)
+		}
+	}
+
+	return keys, nil
+}
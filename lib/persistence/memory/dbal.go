@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/persistence"
+)
+
+var _ persistence.DBAL = (*DBAL)(nil)
+
+// channelState holds the assets of a channel.
+// Stored assets are never modified in place: updates replace them with a modified copy,
+// so that rollbacks only have to restore the previous values.
+type channelState struct {
+	organizations  map[string]*asset.Organization
+	dataSamples    map[string]*asset.DataSample
+	functions      map[string]*asset.Function
+	dataManagers   map[string]*asset.DataManager
+	computePlans   map[string]*asset.ComputePlan
+	computeTasks   map[string]*asset.ComputeTask
+	taskLeases     map[string]time.Time
+	outputAssets   map[outputKey][]*asset.ComputeTaskOutputAsset
+	models         map[string]*asset.Model
+	performances   map[outputKey]*asset.Performance
+	failureReports map[failureReportKey]*asset.FailureReport
+	// events are sorted by position
+	events     []*storedEvent
+	eventsByID map[string]*storedEvent
+	// tombstones keep the position, ID and hash of purged events, sorted by position
+	tombstones     []*storedEvent
+	eventConsumers map[eventConsumerKey]*eventConsumer
+	webhooks       map[string]*storedWebhook
+	deliveries     map[string]*asset.WebhookDelivery
+}
+
+func newChannelState() *channelState {
+	return &channelState{
+		organizations:  make(map[string]*asset.Organization),
+		dataSamples:    make(map[string]*asset.DataSample),
+		functions:      make(map[string]*asset.Function),
+		dataManagers:   make(map[string]*asset.DataManager),
+		computePlans:   make(map[string]*asset.ComputePlan),
+		computeTasks:   make(map[string]*asset.ComputeTask),
+		taskLeases:     make(map[string]time.Time),
+		outputAssets:   make(map[outputKey][]*asset.ComputeTaskOutputAsset),
+		models:         make(map[string]*asset.Model),
+		performances:   make(map[outputKey]*asset.Performance),
+		failureReports: make(map[failureReportKey]*asset.FailureReport),
+		eventsByID:     make(map[string]*storedEvent),
+		eventConsumers: make(map[eventConsumerKey]*eventConsumer),
+		webhooks:       make(map[string]*storedWebhook),
+		deliveries:     make(map[string]*asset.WebhookDelivery),
+	}
+}
+
+// outputKey identifies an output of a compute task.
+type outputKey struct {
+	taskKey    string
+	identifier string
+}
+
+// DBAL is the in-memory Database Abstraction Layer of a channel, bound to a transaction.
+type DBAL struct {
+	tx      *Tx
+	channel string
+}
+
+// New returns a DBAL of the given channel operating in the transaction tx.
+func New(tx *Tx, channel string) *DBAL {
+	return &DBAL{tx: tx, channel: channel}
+}
+
+// read returns the state of the channel.
+func (d *DBAL) read() (*channelState, error) {
+	return d.tx.read(d.channel)
+}
+
+// write returns the state of the channel to modify.
+func (d *DBAL) write() (*channelState, error) {
+	return d.tx.write(d.channel)
+}
@@ -0,0 +1,355 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"github.com/substra/orchestrator/utils"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// storedEvent holds an event along with its position and its hash in the event chain.
+type storedEvent struct {
+	position int64
+	event    *asset.Event
+	// asset and metadata are the JSON representations of the event, computed once to be hashed
+	asset    string
+	metadata string
+	hash     []byte
+	// previousHash is the hash the event has been chained to when it was inserted
+	previousHash []byte
+}
+
+// eventConsumerKey identifies a durable consumer.
+type eventConsumerKey struct {
+	owner string
+	name  string
+}
+
+// eventConsumerKeyset sorts consumers by owner and name.
+var eventConsumerKeyset = keyset[eventConsumerKey]{
+	orderBy: orderBy(false, "owner", "name"),
+	less: func(a, b eventConsumerKey) bool {
+		if a.owner != b.owner {
+			return a.owner < b.owner
+		}
+		return a.name < b.name
+	},
+	values: func(key eventConsumerKey) []string {
+		return []string{key.owner, key.name}
+	},
+	pivot: func(values []string) (eventConsumerKey, error) {
+		if len(values) != 2 {
+			return eventConsumerKey{}, fmt.Errorf("expected 2 values, got %d", len(values))
+		}
+		return eventConsumerKey{owner: values[0], name: values[1]}, nil
+	},
+}
+
+type eventConsumer struct {
+	eventID  string
+	position int64
+	ackDate  time.Time
+}
+
+// NewEventID implements persistence.EventDBAL
+func (d *DBAL) NewEventID() string {
+	return uuid.NewString()
+}
+
+// AddEvents implements persistence.EventDBAL
+// Events are positioned after every existing event of every channel.
+func (d *DBAL) AddEvents(events ...*asset.Event) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	store := d.tx.store
+	lastPosition := store.lastEventPosition
+	d.tx.onRollback(func() {
+		store.lastEventPosition = lastPosition
+	})
+
+	for _, event := range events {
+		if _, ok := state.eventsByID[event.Id]; ok {
+			return orcerrors.NewConflict("event", event.Id)
+		}
+
+		eventAsset, err := asset.MarshalEventAsset(event)
+		if err != nil {
+			return err
+		}
+		metadata, err := json.Marshal(event.Metadata)
+		if err != nil {
+			return err
+		}
+
+		store.lastEventPosition++
+		stored := &storedEvent{
+			position: store.lastEventPosition,
+			event:    clone(event),
+			asset:    string(eventAsset),
+			metadata: string(metadata),
+		}
+		stored.event.Channel = d.channel
+
+		stored.previousHash = state.lastHash()
+		stored.hash = chainEventHash(stored.previousHash, stored.canonicalForm(d.channel))
+
+		d.appendEvent(state, stored)
+	}
+
+	return nil
+}
+
+// lastHash returns the hash of the last event of the channel, which may have been purged.
+func (s *channelState) lastHash() []byte {
+	var last *storedEvent
+	if n := len(s.events); n > 0 {
+		last = s.events[n-1]
+	}
+	if n := len(s.tombstones); n > 0 && (last == nil || s.tombstones[n-1].position > last.position) {
+		last = s.tombstones[n-1]
+	}
+	if last == nil {
+		return nil
+	}
+	return last.hash
+}
+
+// mergeEvents returns the events of both lists sorted by position, the lists being sorted by position.
+func mergeEvents(a, b []*storedEvent) []*storedEvent {
+	merged := make([]*storedEvent, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if a[0].position < b[0].position {
+			merged = append(merged, a[0])
+			a = a[1:]
+		} else {
+			merged = append(merged, b[0])
+			b = b[1:]
+		}
+	}
+	merged = append(merged, a...)
+	return append(merged, b...)
+}
+
+// appendEvent adds an event at the end of the channel events, it is removed on rollback.
+func (d *DBAL) appendEvent(state *channelState, event *storedEvent) {
+	count := len(state.events)
+	state.events = append(state.events, event)
+	put(d.tx, state.eventsByID, event.event.Id, event)
+
+	d.tx.onRollback(func() {
+		state.events = state.events[:count]
+	})
+}
+
+// QueryEvents implements persistence.EventDBAL
+func (d *DBAL) QueryEvents(p *common.Pagination, filter *asset.EventQueryFilter, sortOrder asset.SortOrder) ([]*asset.Event, common.PaginationToken, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, "", err
+	}
+
+	match := state.eventMatcher(filter)
+	matching := []*storedEvent{}
+	for _, e := range state.events {
+		if match(e) {
+			matching = append(matching, e)
+		}
+	}
+
+	ks := eventKeyset(sortOrder == asset.SortOrder_DESCENDING)
+	sortItems(matching, ks.less)
+
+	page, token, err := paginate(matching, p, ks)
+	if err != nil {
+		return nil, "", err
+	}
+
+	events := make([]*asset.Event, 0, len(page))
+	for _, e := range page {
+		events = append(events, e.event)
+	}
+
+	return cloneAll(events), token, nil
+}
+
+// eventKeyset sorts events by position, which is the order in which they were stored.
+// Unlike timestamps, positions are never assigned behind a cursor already handed out.
+func eventKeyset(desc bool) keyset[*storedEvent] {
+	return keyset[*storedEvent]{
+		orderBy: orderBy(desc, "position"),
+		less: func(a, b *storedEvent) bool {
+			if desc {
+				return a.position > b.position
+			}
+			return a.position < b.position
+		},
+		values: func(e *storedEvent) []string {
+			return []string{strconv.FormatInt(e.position, 10)}
+		},
+		pivot: func(values []string) (*storedEvent, error) {
+			if len(values) != 1 {
+				return nil, fmt.Errorf("expected 1 value, got %d", len(values))
+			}
+			position, err := strconv.ParseInt(values[0], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			return &storedEvent{position: position}, nil
+		},
+	}
+}
+
+// eventMatcher returns a function matching the events of the channel selected by the filter.
+func (s *channelState) eventMatcher(filter *asset.EventQueryFilter) func(*storedEvent) bool {
+	if filter == nil {
+		return func(*storedEvent) bool { return true }
+	}
+
+	assetKinds := append([]asset.AssetKind{}, filter.AssetKinds...)
+	if filter.AssetKind != asset.AssetKind_ASSET_UNKNOWN {
+		assetKinds = append(assetKinds, filter.AssetKind)
+	}
+	eventKinds := append([]asset.EventKind{}, filter.EventKinds...)
+	if filter.EventKind != asset.EventKind_EVENT_UNKNOWN {
+		eventKinds = append(eventKinds, filter.EventKind)
+	}
+	var inPlan func(*storedEvent) bool
+	if filter.ComputePlanKey != "" {
+		planEvent := s.planEventMatcher(filter.ComputePlanKey)
+		inPlan = func(e *storedEvent) bool {
+			return e.event.AssetKey == filter.ComputePlanKey || planEvent(e)
+		}
+	}
+
+	return func(e *storedEvent) bool {
+		timestamp := e.event.Timestamp.AsTime()
+
+		return (filter.AssetKey == "" || e.event.AssetKey == filter.AssetKey) &&
+			(len(assetKinds) == 0 || utils.SliceContains(assetKinds, e.event.AssetKind)) &&
+			(len(eventKinds) == 0 || utils.SliceContains(eventKinds, e.event.EventKind)) &&
+			(inPlan == nil || inPlan(e)) &&
+			containsMetadata(e.event.Metadata, filter.Metadata) &&
+			(filter.Start == nil || !timestamp.Before(filter.Start.AsTime())) &&
+			(filter.End == nil || !timestamp.After(filter.End.AsTime()))
+	}
+}
+
+// planEventMatcher returns a function matching the events related to the tasks of a compute plan and to their outputs.
+// Events of the compute plan itself are not matched.
+func (s *channelState) planEventMatcher(key string) func(*storedEvent) bool {
+	taskKeys := s.planTaskKeys(key)
+
+	return func(e *storedEvent) bool {
+		return taskKeys[e.event.AssetKey] || taskKeys[eventComputeTaskKey(e.event)]
+	}
+}
+
+// planTaskKeys returns the set of keys of the tasks of a compute plan.
+func (s *channelState) planTaskKeys(key string) map[string]bool {
+	keys := make(map[string]bool)
+	for _, task := range s.computeTasks {
+		if task.ComputePlanKey == key {
+			keys[task.Key] = true
+		}
+	}
+
+	return keys
+}
+
+// eventComputeTaskKey returns the key of the task the asset of an event belongs to, if any.
+func eventComputeTaskKey(event *asset.Event) string {
+	switch {
+	case event.GetModel() != nil:
+		return event.GetModel().ComputeTaskKey
+	case event.GetPerformance() != nil:
+		return event.GetPerformance().ComputeTaskKey
+	case event.GetComputeTaskOutputAsset() != nil:
+		return event.GetComputeTaskOutputAsset().ComputeTaskKey
+	default:
+		return ""
+	}
+}
+
+// AckEvents implements persistence.EventDBAL
+// The acknowledged position never moves backward: acknowledging an older event has no effect.
+func (d *DBAL) AckEvents(owner string, consumer string, eventID string, ackDate time.Time) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	event, ok := state.eventsByID[eventID]
+	if !ok {
+		return orcerrors.NewNotFound("event", eventID)
+	}
+
+	key := eventConsumerKey{owner: owner, name: consumer}
+	acked := &eventConsumer{eventID: eventID, position: event.position, ackDate: ackDate}
+	if previous, ok := state.eventConsumers[key]; ok && previous.position >= event.position {
+		acked.eventID = previous.eventID
+		acked.position = previous.position
+	}
+	put(d.tx, state.eventConsumers, key, acked)
+
+	return nil
+}
+
+// QueryEventConsumers implements persistence.EventDBAL
+// The lag of a consumer is the number of events of the channel emitted after its last acknowledged event,
+// regardless of its subscription filter.
+func (d *DBAL) QueryEventConsumers(p *common.Pagination, owner string) ([]*asset.EventConsumer, common.PaginationToken, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, "", err
+	}
+
+	keys := make([]eventConsumerKey, 0, len(state.eventConsumers))
+	for key := range state.eventConsumers {
+		if key.owner == owner {
+			keys = append(keys, key)
+		}
+	}
+	sortItems(keys, eventConsumerKeyset.less)
+
+	page, token, err := paginate(keys, p, eventConsumerKeyset)
+	if err != nil {
+		return nil, "", err
+	}
+
+	consumers := make([]*asset.EventConsumer, 0, len(page))
+	for _, key := range page {
+		c := state.eventConsumers[key]
+		consumers = append(consumers, &asset.EventConsumer{
+			Name:             key.name,
+			Owner:            key.owner,
+			LastAckedEventId: c.eventID,
+			LastAckDate:      timestamppb.New(c.ackDate),
+			Lag:              uint64(state.countEventsAfter(c.position)),
+		})
+	}
+
+	return consumers, token, nil
+}
+
+// countEventsAfter returns the number of events of the channel positioned after the given position.
+func (s *channelState) countEventsAfter(position int64) int {
+	return len(s.events) - s.eventIndexAfter(position)
+}
+
+// eventIndexAfter returns the index of the first event positioned after the given position.
+func (s *channelState) eventIndexAfter(position int64) int {
+	return sort.Search(len(s.events), func(i int) bool {
+		return s.events[i].position > position
+	})
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// canonicalForm returns the serialized form of the event which is hashed.
+// It follows the canonical form of the standalone orchestrator.
+func (e *storedEvent) canonicalForm(channel string) []byte {
+	return []byte(strings.Join([]string{
+		channel,
+		strconv.FormatInt(e.position, 10),
+		e.event.Id,
+		e.event.AssetKey,
+		e.event.AssetKind.String(),
+		e.event.EventKind.String(),
+		e.event.Timestamp.AsTime().UTC().Format("2006-01-02T15:04:05.000000Z"),
+		e.asset,
+		e.metadata,
+	}, "\n"))
+}
+
+// chainEventHash returns the hash of an event chained to the hash of the previous event.
+func chainEventHash(previous []byte, canonicalForm []byte) []byte {
+	h := sha256.New()
+	h.Write(previous)
+	h.Write(canonicalForm)
+	return h.Sum(nil)
+}
+
+// VerifyEventChain implements persistence.EventDBAL
+// It recomputes the hash of every event of the channel in position order,
+// and reports the first event whose stored hash does not match.
+// The hash of a purged event cannot be recomputed: only its link to the previous event is checked.
+func (d *DBAL) VerifyEventChain() (*asset.EventChainStatus, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &asset.EventChainStatus{Valid: true}
+	var previous []byte
+	purged := state.tombstones
+
+	for _, e := range state.events {
+		for len(purged) > 0 && purged[0].position < e.position {
+			if !verifyPurgedEvent(status, purged[0], &previous) {
+				return status, nil
+			}
+			purged = purged[1:]
+		}
+
+		expected := chainEventHash(previous, e.canonicalForm(d.channel))
+		if !bytes.Equal(expected, e.hash) {
+			setBrokenLink(status, e, expected, e.hash)
+			return status, nil
+		}
+
+		status.VerifiedEvents++
+		status.LastEventId = e.event.Id
+		status.LastHash = hex.EncodeToString(e.hash)
+		previous = e.hash
+	}
+
+	for _, e := range purged {
+		if !verifyPurgedEvent(status, e, &previous) {
+			return status, nil
+		}
+	}
+
+	return status, nil
+}
+
+// verifyPurgedEvent checks that a purged event is linked to the previous event of the chain.
+func verifyPurgedEvent(status *asset.EventChainStatus, e *storedEvent, previous *[]byte) bool {
+	if !bytes.Equal(*previous, e.previousHash) {
+		setBrokenLink(status, e, *previous, e.previousHash)
+		return false
+	}
+	status.PurgedEvents++
+	*previous = e.hash
+	return true
+}
+
+func setBrokenLink(status *asset.EventChainStatus, e *storedEvent, expected, actual []byte) {
+	status.Valid = false
+	status.FirstBrokenLink = &asset.BrokenEventLink{
+		EventId:      e.event.Id,
+		Position:     uint64(e.position),
+		ExpectedHash: hex.EncodeToString(expected),
+		ActualHash:   hex.EncodeToString(actual),
+	}
+}
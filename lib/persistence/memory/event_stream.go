@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"github.com/substra/orchestrator/lib/asset"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+)
+
+// PositionedEvent is an event along with its position, positions are shared by every channel.
+type PositionedEvent struct {
+	Position int64
+	Event    *asset.Event
+}
+
+// GetEventsAfter returns at most limit events of the channel positioned after the given position and matching the filter,
+// in position order. Along with the following methods, it allows streaming the events of the store.
+func (d *DBAL) GetEventsAfter(position int64, filter *asset.EventQueryFilter, limit int) ([]PositionedEvent, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	match := state.eventMatcher(filter)
+	events := []PositionedEvent{}
+	for _, e := range state.events[state.eventIndexAfter(position):] {
+		if len(events) == limit {
+			break
+		}
+		if match(e) {
+			events = append(events, PositionedEvent{Position: e.position, Event: clone(e.event)})
+		}
+	}
+
+	return events, nil
+}
+
+// GetEventPosition returns the position of an event of the channel, or a not found error.
+func (d *DBAL) GetEventPosition(eventID string) (int64, error) {
+	state, err := d.read()
+	if err != nil {
+		return 0, err
+	}
+
+	e, ok := state.eventsByID[eventID]
+	if !ok {
+		return 0, orcerrors.NewNotFound("event", eventID)
+	}
+
+	return e.position, nil
+}
+
+// GetEventConsumerPosition returns the position of the last event acknowledged by a consumer,
+// or 0 if the consumer has not acknowledged any event yet.
+func (d *DBAL) GetEventConsumerPosition(owner string, consumer string) (int64, error) {
+	state, err := d.read()
+	if err != nil {
+		return 0, err
+	}
+
+	c, ok := state.eventConsumers[eventConsumerKey{owner: owner, name: consumer}]
+	if !ok {
+		return 0, nil
+	}
+
+	return c.position, nil
+}
+
+// GetLastEventPosition returns the position of the last event of the channel, or 0 if there is none.
+func (d *DBAL) GetLastEventPosition() (int64, error) {
+	state, err := d.read()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(state.events) == 0 {
+		return 0, nil
+	}
+
+	return state.events[len(state.events)-1].position, nil
+}
+
+// LastEventPosition returns the position of the last committed event of any channel, or 0 if there is none.
+func (s *Store) LastEventPosition() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lastEventPosition
+}
+
+// LastChannelEvents returns the position of the last committed event of each channel,
+// for the channels having events positioned after the given position.
+func (s *Store) LastChannelEvents(afterPosition int64) map[string]int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	positions := make(map[string]int64)
+	for channel, state := range s.channels {
+		if n := len(state.events); n > 0 && state.events[n-1].position > afterPosition {
+			positions[channel] = state.events[n-1].position
+		}
+	}
+
+	return positions
+}
@@ -0,0 +1,288 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func testTime(seconds int64) time.Time {
+	return time.Unix(1700000000+seconds, 0).UTC()
+}
+
+func newTestEvent(id string, seconds int64, task *asset.ComputeTask) *asset.Event {
+	return &asset.Event{
+		Id:        id,
+		AssetKey:  task.Key,
+		AssetKind: asset.AssetKind_ASSET_COMPUTE_TASK,
+		EventKind: asset.EventKind_EVENT_ASSET_UPDATED,
+		Timestamp: timestamppb.New(testTime(seconds)),
+		Asset:     &asset.Event_ComputeTask{ComputeTask: task},
+	}
+}
+
+func TestEventPositionsAreShared(t *testing.T) {
+	store := NewStore()
+	tx := store.Begin(false)
+	defer tx.Rollback() //nolint:errcheck
+
+	task := &asset.ComputeTask{Key: "task"}
+	require.NoError(t, New(tx, testChannel).AddEvents(newTestEvent("e1", 1, task)))
+	require.NoError(t, New(tx, "otherchannel").AddEvents(newTestEvent("e2", 2, task)))
+	require.NoError(t, New(tx, testChannel).AddEvents(newTestEvent("e3", 3, task)))
+
+	state, err := tx.read(testChannel)
+	require.NoError(t, err)
+	require.Len(t, state.events, 2)
+	assert.Equal(t, int64(1), state.events[0].position)
+	assert.Equal(t, int64(3), state.events[1].position)
+	assert.Equal(t, testChannel, state.events[1].event.Channel)
+}
+
+func TestQueryEvents(t *testing.T) {
+	tx := NewStore().Begin(false)
+	defer tx.Rollback() //nolint:errcheck
+	dbal := New(tx, testChannel)
+
+	require.NoError(t, dbal.AddComputeTasks(&asset.ComputeTask{Key: "task", ComputePlanKey: "cp"}))
+	require.NoError(t, dbal.AddEvents(
+		newTestEvent("e1", 3, &asset.ComputeTask{Key: "task", ComputePlanKey: "cp"}),
+		newTestEvent("e2", 1, &asset.ComputeTask{Key: "other"}),
+		&asset.Event{
+			Id:        "e3",
+			AssetKey:  "model",
+			AssetKind: asset.AssetKind_ASSET_MODEL,
+			EventKind: asset.EventKind_EVENT_ASSET_CREATED,
+			Timestamp: timestamppb.New(testTime(2)),
+			Asset:     &asset.Event_Model{Model: &asset.Model{Key: "model", ComputeTaskKey: "task"}},
+		},
+	))
+
+	// Events are sorted by position, whatever their timestamp
+	events, token, err := dbal.QueryEvents(common.NewPagination("", 2), nil, asset.SortOrder_DESCENDING)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	require.Len(t, events, 2)
+	assert.Equal(t, "e3", events[0].Id)
+	assert.Equal(t, "e2", events[1].Id)
+
+	events, token, err = dbal.QueryEvents(common.NewPagination(token, 2), nil, asset.SortOrder_DESCENDING)
+	assert.NoError(t, err)
+	assert.Empty(t, token)
+	require.Len(t, events, 1)
+	assert.Equal(t, "e1", events[0].Id)
+
+	events, _, err = dbal.QueryEvents(common.NewPagination("", 10), &asset.EventQueryFilter{ComputePlanKey: "cp"}, asset.SortOrder_ASCENDING)
+	assert.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "e1", events[0].Id)
+	assert.Equal(t, "e3", events[1].Id)
+
+	events, _, err = dbal.QueryEvents(common.NewPagination("", 10), &asset.EventQueryFilter{
+		AssetKinds: []asset.AssetKind{asset.AssetKind_ASSET_COMPUTE_TASK},
+		End:        timestamppb.New(testTime(1)),
+	}, asset.SortOrder_ASCENDING)
+	assert.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "e2", events[0].Id)
+}
+
+func TestQueryEventsInsertedBehindCursor(t *testing.T) {
+	store := NewStore()
+	task := &asset.ComputeTask{Key: "task"}
+
+	tx := store.Begin(false)
+	require.NoError(t, New(tx, testChannel).AddEvents(newTestEvent("e1", 2, task), newTestEvent("e2", 3, task)))
+	require.NoError(t, tx.Commit())
+
+	tx = store.Begin(true)
+	events, token, err := New(tx, testChannel).QueryEvents(common.NewPagination("", 1), nil, asset.SortOrder_ASCENDING)
+	require.NoError(t, tx.Rollback())
+	assert.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "e1", events[0].Id)
+
+	// A transaction which started before the first page was read commits an older timestamp
+	tx = store.Begin(false)
+	require.NoError(t, New(tx, testChannel).AddEvents(newTestEvent("e3", 1, task)))
+	require.NoError(t, tx.Commit())
+
+	tx = store.Begin(true)
+	defer tx.Rollback() //nolint:errcheck
+	events, _, err = New(tx, testChannel).QueryEvents(common.NewPagination(token, 10), nil, asset.SortOrder_ASCENDING)
+	assert.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "e2", events[0].Id)
+	assert.Equal(t, "e3", events[1].Id, "an event stored after the cursor should be returned")
+}
+
+func TestAckEvents(t *testing.T) {
+	tx := NewStore().Begin(false)
+	defer tx.Rollback() //nolint:errcheck
+	dbal := New(tx, testChannel)
+
+	task := &asset.ComputeTask{Key: "task"}
+	require.NoError(t, dbal.AddEvents(newTestEvent("e1", 1, task), newTestEvent("e2", 2, task), newTestEvent("e3", 3, task)))
+
+	require.NoError(t, dbal.AckEvents("owner", "consumer", "e2", testTime(10)))
+	// Acknowledging an older event doesn't move the position backward
+	require.NoError(t, dbal.AckEvents("owner", "consumer", "e1", testTime(11)))
+	require.NoError(t, dbal.AckEvents("other", "consumer", "e3", testTime(12)))
+
+	consumers, _, err := dbal.QueryEventConsumers(common.NewPagination("", 10), "owner")
+	assert.NoError(t, err)
+	require.Len(t, consumers, 1)
+	assert.Equal(t, "e2", consumers[0].LastAckedEventId)
+	assert.Equal(t, uint64(1), consumers[0].Lag)
+	assert.Equal(t, testTime(11), consumers[0].LastAckDate.AsTime())
+
+	assert.Error(t, dbal.AckEvents("owner", "consumer", "unknown", testTime(12)))
+}
+
+func TestVerifyEventChain(t *testing.T) {
+	tx := NewStore().Begin(false)
+	defer tx.Rollback() //nolint:errcheck
+	dbal := New(tx, testChannel)
+
+	task := &asset.ComputeTask{Key: "task"}
+	require.NoError(t, dbal.AddEvents(newTestEvent("e1", 1, task), newTestEvent("e2", 2, task)))
+
+	status, err := dbal.VerifyEventChain()
+	assert.NoError(t, err)
+	assert.True(t, status.Valid)
+	assert.Equal(t, uint64(2), status.VerifiedEvents)
+	assert.Equal(t, "e2", status.LastEventId)
+
+	state, err := tx.read(testChannel)
+	require.NoError(t, err)
+	state.events[0].event.AssetKey = "tampered"
+
+	status, err = dbal.VerifyEventChain()
+	assert.NoError(t, err)
+	assert.False(t, status.Valid)
+	assert.Equal(t, "e1", status.FirstBrokenLink.EventId)
+}
+
+func TestVerifyEventChainAfterPurge(t *testing.T) {
+	tx := NewStore().Begin(false)
+	defer tx.Rollback() //nolint:errcheck
+	dbal := New(tx, testChannel)
+
+	task := newTestTask("task", 0)
+	other := &asset.ComputeTask{Key: "other"}
+	require.NoError(t, dbal.AddComputePlan(&asset.ComputePlan{Key: "cp"}))
+	require.NoError(t, dbal.AddComputeTasks(task))
+	require.NoError(t, dbal.AddEvents(newTestEvent("e1", 1, task), newTestEvent("e2", 2, other), newTestEvent("e3", 3, task)))
+
+	_, err := dbal.PurgeComputePlan("cp", testTime(4))
+	require.NoError(t, err)
+	// The new event is chained to the last event of the channel, which has been purged
+	require.NoError(t, dbal.AddEvents(newTestEvent("e4", 5, other)))
+
+	status, err := dbal.VerifyEventChain()
+	assert.NoError(t, err)
+	assert.True(t, status.Valid)
+	assert.Equal(t, uint64(2), status.VerifiedEvents)
+	assert.Equal(t, uint64(2), status.PurgedEvents)
+	assert.Equal(t, "e4", status.LastEventId)
+
+	// Deleting an event without keeping its hash still breaks the chain
+	state, err := tx.read(testChannel)
+	require.NoError(t, err)
+	state.events = state.events[1:]
+
+	status, err = dbal.VerifyEventChain()
+	assert.NoError(t, err)
+	assert.False(t, status.Valid)
+	assert.Equal(t, "e3", status.FirstBrokenLink.EventId, "the link of the purged event should be checked")
+}
+
+func TestWebhookEvents(t *testing.T) {
+	tx := NewStore().Begin(false)
+	defer tx.Rollback() //nolint:errcheck
+	dbal := New(tx, testChannel)
+
+	task := &asset.ComputeTask{Key: "task"}
+	require.NoError(t, dbal.AddEvents(newTestEvent("e1", 1, task)))
+
+	webhook := &asset.Webhook{Key: "webhook", Owner: "owner"}
+	require.NoError(t, dbal.AddWebhook(webhook, "secret"))
+
+	keys, err := dbal.GetDueWebhookKeys(testTime(10))
+	assert.NoError(t, err)
+	assert.Empty(t, keys, "events emitted before the registration should not be pushed")
+
+	require.NoError(t, dbal.AddEvents(newTestEvent("e2", 2, task), newTestEvent("e3", 3, task)))
+
+	keys, err = dbal.GetDueWebhookKeys(testTime(10))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"webhook"}, keys)
+
+	events, lastScanned, err := dbal.GetWebhookEvents(webhook, 1)
+	assert.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "e2", events[0].Id)
+	assert.Equal(t, "e2", lastScanned)
+
+	// Events not matching the filter are scanned as well
+	filtered := &asset.Webhook{Key: "webhook", Filter: &asset.EventQueryFilter{AssetKind: asset.AssetKind_ASSET_COMPUTE_PLAN}}
+	events, lastScanned, err = dbal.GetWebhookEvents(filtered, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Equal(t, "e3", lastScanned)
+
+	require.NoError(t, dbal.SetWebhookCursor("webhook", "e3"))
+	keys, err = dbal.GetDueWebhookKeys(testTime(10))
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, lastScanned, err = dbal.GetWebhookEvents(webhook, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, lastScanned)
+}
+
+func TestWebhookClaims(t *testing.T) {
+	tx := NewStore().Begin(false)
+	defer tx.Rollback() //nolint:errcheck
+	dbal := New(tx, testChannel)
+
+	task := &asset.ComputeTask{Key: "task"}
+	webhook := &asset.Webhook{Key: "webhook", Owner: "owner"}
+	require.NoError(t, dbal.AddWebhook(webhook, "secret"))
+	require.NoError(t, dbal.AddEvents(newTestEvent("e1", 1, task)))
+
+	secret, claimed, err := dbal.ClaimWebhook("webhook", "claim1", testTime(10), testTime(20))
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Equal(t, "secret", secret)
+
+	keys, err := dbal.GetDueWebhookKeys(testTime(10))
+	assert.NoError(t, err)
+	assert.Empty(t, keys, "claimed webhooks should not be due")
+
+	_, claimed, err = dbal.ClaimWebhook("webhook", "claim2", testTime(15), testTime(25))
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	// The claim expired, another dispatch takes over
+	_, claimed, err = dbal.ClaimWebhook("webhook", "claim2", testTime(21), testTime(30))
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	released, err := dbal.ReleaseWebhook("webhook", "claim1")
+	assert.NoError(t, err)
+	assert.False(t, released)
+
+	released, err = dbal.ReleaseWebhook("webhook", "claim2")
+	assert.NoError(t, err)
+	assert.True(t, released)
+
+	keys, err = dbal.GetDueWebhookKeys(testTime(22))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"webhook"}, keys)
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"github.com/substra/orchestrator/lib/asset"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+)
+
+// failureReportKey identifies the failure report of an attempt.
+type failureReportKey struct {
+	assetKey string
+	attempt  uint32
+}
+
+// GetFailureReport returns the failure report of the given attempt, or the latest one if attempt is 0.
+func (d *DBAL) GetFailureReport(assetKey string, attempt uint32) (*asset.FailureReport, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var report *asset.FailureReport
+	for key, r := range state.failureReports {
+		if key.assetKey != assetKey || (attempt > 0 && key.attempt != attempt) {
+			continue
+		}
+		if report == nil || r.Attempt > report.Attempt {
+			report = r
+		}
+	}
+
+	if report == nil {
+		return nil, orcerrors.NewNotFound("failure report", assetKey)
+	}
+
+	return clone(report), nil
+}
+
+// AddFailureReport implements persistence.FailureReportDBAL
+func (d *DBAL) AddFailureReport(failureReport *asset.FailureReport) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	key := failureReportKey{assetKey: failureReport.AssetKey, attempt: failureReport.Attempt}
+	if _, ok := state.failureReports[key]; ok {
+		return orcerrors.NewConflict("failure report", failureReport.AssetKey)
+	}
+	put(d.tx, state.failureReports, key, clone(failureReport))
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// functionKeyset sorts functions by creation date.
+var functionKeyset = dateKeyset(false, "creation_date", "key",
+	func(f *asset.Function) time.Time { return f.CreationDate.AsTime() },
+	func(f *asset.Function) string { return f.Key },
+	func(date time.Time, key string) *asset.Function {
+		return &asset.Function{CreationDate: timestamppb.New(date), Key: key}
+	},
+)
+
+// AddFunction implements persistence.FunctionDBAL
+func (d *DBAL) AddFunction(function *asset.Function) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := state.functions[function.Key]; ok {
+		return orcerrors.NewConflict(asset.FunctionKind, function.Key)
+	}
+	put(d.tx, state.functions, function.Key, clone(function))
+
+	return nil
+}
+
+// GetFunction implements persistence.FunctionDBAL
+func (d *DBAL) GetFunction(key string) (*asset.Function, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	function, ok := state.functions[key]
+	if !ok {
+		return nil, orcerrors.NewNotFound(asset.FunctionKind, key)
+	}
+
+	return clone(function), nil
+}
+
+// QueryFunctions implements persistence.FunctionDBAL
+func (d *DBAL) QueryFunctions(p *common.Pagination, filter *asset.FunctionQueryFilter) ([]*asset.Function, common.PaginationToken, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, "", err
+	}
+
+	functions := values(state.functions, functionKeyset.less)
+
+	if filter != nil && filter.ComputePlanKey != "" {
+		planFunctions := make(map[string]bool)
+		for _, task := range state.computeTasks {
+			if task.ComputePlanKey == filter.ComputePlanKey {
+				planFunctions[task.FunctionKey] = true
+			}
+		}
+		functions = keep(functions, func(f *asset.Function) bool {
+			return planFunctions[f.Key]
+		})
+	}
+
+	page, token, err := paginate(functions, p, functionKeyset)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return cloneAll(page), token, nil
+}
+
+// FunctionExists implements persistence.FunctionDBAL
+func (d *DBAL) FunctionExists(key string) (bool, error) {
+	state, err := d.read()
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := state.functions[key]
+
+	return ok, nil
+}
+
+// UpdateFunction implements persistence.FunctionDBAL
+// Only the name, the status and the image of the function are updated.
+func (d *DBAL) UpdateFunction(function *asset.Function) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	stored, ok := state.functions[function.Key]
+	if !ok {
+		return nil
+	}
+
+	updated := clone(stored)
+	updated.Name = function.Name
+	updated.Status = function.Status
+	if function.GetImage().GetStorageAddress() != "" {
+		updated.Image = clone(function.Image)
+	}
+	put(d.tx, state.functions, function.Key, updated)
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"github.com/substra/orchestrator/lib/asset"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+)
+
+func modelCreatedBefore(a, b *asset.Model) bool {
+	return createdBefore(a.CreationDate.AsTime(), a.Key, b.CreationDate.AsTime(), b.Key)
+}
+
+// GetModel implements persistence.ModelDBAL
+func (d *DBAL) GetModel(key string) (*asset.Model, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	model, ok := state.models[key]
+	if !ok {
+		return nil, orcerrors.NewNotFound("model", key)
+	}
+
+	return clone(model), nil
+}
+
+// ModelExists implements persistence.ModelDBAL
+func (d *DBAL) ModelExists(key string) (bool, error) {
+	state, err := d.read()
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := state.models[key]
+
+	return ok, nil
+}
+
+// GetComputeTaskOutputModels implements persistence.ModelDBAL
+func (d *DBAL) GetComputeTaskOutputModels(key string) ([]*asset.Model, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	models := keep(values(state.models, modelCreatedBefore), func(m *asset.Model) bool {
+		return m.ComputeTaskKey == key
+	})
+
+	return cloneAll(models), nil
+}
+
+// AddModel implements persistence.ModelDBAL
+func (d *DBAL) AddModel(model *asset.Model, identifier string) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := state.models[model.Key]; ok {
+		return orcerrors.NewConflict("model", model.Key)
+	}
+	put(d.tx, state.models, model.Key, clone(model))
+
+	return nil
+}
+
+// UpdateModel implements persistence.ModelDBAL
+// The task, permissions, owner and address of the model are updated, a nil address disables the model.
+func (d *DBAL) UpdateModel(model *asset.Model) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	stored, ok := state.models[model.Key]
+	if !ok {
+		return orcerrors.NewNotFound("model", model.Key)
+	}
+
+	updated := clone(stored)
+	updated.ComputeTaskKey = model.ComputeTaskKey
+	updated.Permissions = clone(model.Permissions)
+	updated.Owner = model.Owner
+	updated.Address = nil
+	if model.Address != nil {
+		updated.Address = clone(model.Address)
+	}
+	put(d.tx, state.models, model.Key, updated)
+
+	return nil
+}
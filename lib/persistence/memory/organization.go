@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"github.com/substra/orchestrator/lib/asset"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+)
+
+// AddOrganization implements persistence.OrganizationDBAL
+func (d *DBAL) AddOrganization(organization *asset.Organization) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := state.organizations[organization.Id]; ok {
+		return orcerrors.NewConflict("organization", organization.Id)
+	}
+	put(d.tx, state.organizations, organization.Id, clone(organization))
+
+	return nil
+}
+
+// OrganizationExists implements persistence.OrganizationDBAL
+func (d *DBAL) OrganizationExists(id string) (bool, error) {
+	state, err := d.read()
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := state.organizations[id]
+
+	return ok, nil
+}
+
+// GetAllOrganizations implements persistence.OrganizationDBAL
+func (d *DBAL) GetAllOrganizations() ([]*asset.Organization, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	organizations := values(state.organizations, func(a, b *asset.Organization) bool {
+		return createdBefore(a.CreationDate.AsTime(), a.Id, b.CreationDate.AsTime(), b.Id)
+	})
+
+	return cloneAll(organizations), nil
+}
+
+// GetOrganization implements persistence.OrganizationDBAL
+func (d *DBAL) GetOrganization(id string) (*asset.Organization, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	organization, ok := state.organizations[id]
+	if !ok {
+		return nil, orcerrors.NewNotFound("organization", id)
+	}
+
+	return clone(organization), nil
+}
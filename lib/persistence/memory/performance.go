@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// performanceKeyset sorts performances by creation date, then by descending task key and by output identifier.
+var performanceKeyset = keyset[*asset.Performance]{
+	orderBy: "creation_date ASC, compute_task_key DESC, compute_task_output_identifier ASC",
+	less:    performanceBefore,
+	values: func(perf *asset.Performance) []string {
+		return []string{cursorTime(perf.CreationDate.AsTime()), perf.ComputeTaskKey, perf.ComputeTaskOutputIdentifier}
+	},
+	pivot: func(values []string) (*asset.Performance, error) {
+		if len(values) != 3 {
+			return nil, fmt.Errorf("expected 3 values, got %d", len(values))
+		}
+		date, err := time.Parse(time.RFC3339Nano, values[0])
+		if err != nil {
+			return nil, err
+		}
+		return &asset.Performance{CreationDate: timestamppb.New(date), ComputeTaskKey: values[1], ComputeTaskOutputIdentifier: values[2]}, nil
+	},
+}
+
+func performanceBefore(a, b *asset.Performance) bool {
+	if !a.CreationDate.AsTime().Equal(b.CreationDate.AsTime()) {
+		return a.CreationDate.AsTime().Before(b.CreationDate.AsTime())
+	}
+	if a.ComputeTaskKey != b.ComputeTaskKey {
+		return a.ComputeTaskKey > b.ComputeTaskKey
+	}
+
+	return a.ComputeTaskOutputIdentifier < b.ComputeTaskOutputIdentifier
+}
+
+// AddPerformance implements persistence.PerformanceDBAL
+func (d *DBAL) AddPerformance(perf *asset.Performance, identifier string) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	key := outputKey{taskKey: perf.ComputeTaskKey, identifier: perf.ComputeTaskOutputIdentifier}
+	if _, ok := state.performances[key]; ok {
+		return orcerrors.NewConflict("performance", perf.ComputeTaskKey)
+	}
+	put(d.tx, state.performances, key, clone(perf))
+
+	return nil
+}
+
+// PerformanceExists implements persistence.PerformanceDBAL
+func (d *DBAL) PerformanceExists(perf *asset.Performance) (bool, error) {
+	state, err := d.read()
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := state.performances[outputKey{taskKey: perf.ComputeTaskKey, identifier: perf.ComputeTaskOutputIdentifier}]
+
+	return ok, nil
+}
+
+// QueryPerformances implements persistence.PerformanceDBAL
+func (d *DBAL) QueryPerformances(p *common.Pagination, filter *asset.PerformanceQueryFilter) ([]*asset.Performance, common.PaginationToken, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, "", err
+	}
+
+	performances := values(state.performances, performanceKeyset.less)
+	if filter != nil {
+		performances = keep(performances, func(perf *asset.Performance) bool {
+			return (filter.ComputeTaskKey == "" || perf.ComputeTaskKey == filter.ComputeTaskKey) &&
+				(filter.ComputeTaskOutputIdentifier == "" || perf.ComputeTaskOutputIdentifier == filter.ComputeTaskOutputIdentifier)
+		})
+	}
+
+	page, token, err := paginate(performances, p, performanceKeyset)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return cloneAll(page), token, nil
+}
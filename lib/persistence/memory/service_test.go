@@ -0,0 +1,80 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	"github.com/substra/orchestrator/lib/persistence/memory"
+	"github.com/substra/orchestrator/lib/service"
+)
+
+func TestEmbeddedService(t *testing.T) {
+	store := memory.NewStore()
+	newProvider := func(tx *memory.Tx) *service.Provider {
+		return service.NewProvider(context.Background(), memory.New(tx, "testchannel"), service.NewTimeService(time.Now()), "testchannel", 0)
+	}
+
+	tx := store.Begin(false)
+	provider := newProvider(tx)
+	_, err := provider.GetOrganizationService().RegisterOrganization("org1", &asset.RegisterOrganizationParam{})
+	require.NoError(t, err)
+	_, err = provider.GetComputePlanService().RegisterPlan(&asset.NewComputePlan{Key: "d7b6fa2b-4c4a-4ae0-8a1f-8c8c7b2a6a21", Name: "plan"}, "org1")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	tx = store.Begin(true)
+	defer tx.Rollback() //nolint:errcheck
+	provider = newProvider(tx)
+
+	plan, err := provider.GetComputePlanService().GetPlan("d7b6fa2b-4c4a-4ae0-8a1f-8c8c7b2a6a21")
+	require.NoError(t, err)
+	assert.Equal(t, "org1", plan.Owner)
+
+	events, _, err := provider.GetEventService().QueryEvents(common.NewPagination("", 10), nil, asset.SortOrder_ASCENDING)
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+func TestFailExpiredTaskAfterHeartbeat(t *testing.T) {
+	store := memory.NewStore()
+	now := time.Unix(1337, 0)
+	newProvider := func(tx *memory.Tx) *service.Provider {
+		return service.NewProvider(context.Background(), memory.New(tx, "testchannel"), service.NewTimeService(now), "testchannel", time.Minute)
+	}
+
+	tx := store.Begin(false)
+	dbal := memory.New(tx, "testchannel")
+	require.NoError(t, dbal.AddComputeTasks(&asset.ComputeTask{Key: "task", Worker: "org1"}))
+	require.NoError(t, dbal.UpdateComputeTaskStatus("task", asset.ComputeTaskStatus_STATUS_EXECUTING))
+	require.NoError(t, dbal.UpdateComputeTaskLease("task", now.Add(-time.Second)))
+	require.NoError(t, tx.Commit())
+
+	tx = store.Begin(true)
+	keys, err := newProvider(tx).GetComputeTaskService().GetExpiredTaskKeys()
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+	assert.Equal(t, []string{"task"}, keys)
+
+	// The worker renews the lease after the task has been found expired
+	tx = store.Begin(false)
+	_, err = newProvider(tx).GetComputeTaskService().Heartbeat("task", "org1")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	tx = store.Begin(false)
+	defer tx.Rollback() //nolint:errcheck
+	provider := newProvider(tx)
+
+	failed, err := provider.GetComputeTaskService().FailExpiredTask("task")
+	assert.NoError(t, err)
+	assert.False(t, failed, "a task whose lease has been renewed should not be failed")
+
+	task, err := provider.GetComputeTaskService().GetTask("task")
+	require.NoError(t, err)
+	assert.Equal(t, asset.ComputeTaskStatus_STATUS_EXECUTING, task.Status)
+}
@@ -0,0 +1,210 @@
+// Package memory implements persistence.DBAL without any database.
+// It allows embedding the orchestrator services in another process, or running them in tests,
+// while keeping the transactional behavior of the standalone orchestrator.
+//
+// Assets are only kept in memory: they are lost when the store is discarded.
+package memory
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrTxDone is returned when using a transaction which has already been committed or rolled back.
+var ErrTxDone = errors.New("transaction has already been committed or rolled back")
+
+// ErrReadOnlyTx is returned when writing in a read-only transaction.
+var ErrReadOnlyTx = errors.New("cannot write in a read-only transaction")
+
+// Store holds the assets of every channel.
+// Write transactions are serialized, while read-only transactions run concurrently.
+type Store struct {
+	mu       sync.RWMutex
+	channels map[string]*channelState
+	// lastEventPosition is the position of the last event, positions are shared by every channel
+	lastEventPosition int64
+}
+
+// NewStore returns an empty store.
+func NewStore() *Store {
+	return &Store{channels: make(map[string]*channelState)}
+}
+
+// Begin starts a transaction, it blocks until it can run alongside the pending transactions.
+// The transaction must be committed or rolled back to release the store.
+func (s *Store) Begin(readOnly bool) *Tx {
+	if readOnly {
+		s.mu.RLock()
+	} else {
+		s.mu.Lock()
+	}
+
+	return &Tx{store: s, readOnly: readOnly}
+}
+
+// Tx is a transaction on a Store.
+// Changes are applied immediately and reverted on rollback, they are only visible to other transactions once committed.
+// A Tx must not be used concurrently.
+type Tx struct {
+	store    *Store
+	readOnly bool
+	done     bool
+	// undo holds the functions reverting the changes of the transaction, in the order they were made
+	undo []func()
+}
+
+// Commit makes the changes of the transaction visible to the next transactions.
+func (t *Tx) Commit() error {
+	if t.done {
+		return ErrTxDone
+	}
+
+	t.undo = nil
+	t.release()
+
+	return nil
+}
+
+// Rollback reverts the changes of the transaction.
+func (t *Tx) Rollback() error {
+	if t.done {
+		return ErrTxDone
+	}
+
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		t.undo[i]()
+	}
+	t.undo = nil
+	t.release()
+
+	return nil
+}
+
+func (t *Tx) release() {
+	t.done = true
+	if t.readOnly {
+		t.store.mu.RUnlock()
+	} else {
+		t.store.mu.Unlock()
+	}
+}
+
+// read returns the state of a channel, an unknown channel is empty.
+func (t *Tx) read(channel string) (*channelState, error) {
+	if t.done {
+		return nil, ErrTxDone
+	}
+
+	state, ok := t.store.channels[channel]
+	if !ok {
+		return newChannelState(), nil
+	}
+
+	return state, nil
+}
+
+// write returns the state of a channel to modify, it is created if needed.
+func (t *Tx) write(channel string) (*channelState, error) {
+	if t.done {
+		return nil, ErrTxDone
+	}
+	if t.readOnly {
+		return nil, ErrReadOnlyTx
+	}
+
+	state, ok := t.store.channels[channel]
+	if !ok {
+		state = newChannelState()
+		put(t, t.store.channels, channel, state)
+	}
+
+	return state, nil
+}
+
+// onRollback registers a function reverting a change.
+func (t *Tx) onRollback(f func()) {
+	t.undo = append(t.undo, f)
+}
+
+// put sets the value of a key, the previous value is restored on rollback.
+func put[K comparable, V any](t *Tx, m map[K]V, key K, value V) {
+	previous, existed := m[key]
+	m[key] = value
+
+	t.onRollback(func() {
+		if existed {
+			m[key] = previous
+		} else {
+			delete(m, key)
+		}
+	})
+}
+
+// remove deletes a key, it is restored on rollback.
+func remove[K comparable, V any](t *Tx, m map[K]V, key K) {
+	previous, existed := m[key]
+	if !existed {
+		return
+	}
+	delete(m, key)
+
+	t.onRollback(func() {
+		m[key] = previous
+	})
+}
+
+// clone returns a deep copy of a message, so that stored assets can't be modified by callers.
+func clone[T proto.Message](m T) T {
+	return proto.Clone(m).(T)
+}
+
+// cloneAll returns a deep copy of every message.
+func cloneAll[T proto.Message](messages []T) []T {
+	res := make([]T, 0, len(messages))
+	for _, m := range messages {
+		res = append(res, clone(m))
+	}
+
+	return res
+}
+
+// values returns the values of a map sorted with less.
+func values[K comparable, V any](m map[K]V, less func(a, b V) bool) []V {
+	res := make([]V, 0, len(m))
+	for _, v := range m {
+		res = append(res, v)
+	}
+	sortItems(res, less)
+
+	return res
+}
+
+// sortItems sorts items in place with less.
+func sortItems[T any](items []T, less func(a, b T) bool) {
+	sort.Slice(items, func(i, j int) bool { return less(items[i], items[j]) })
+}
+
+// keep returns the elements matching the predicate.
+func keep[T any](items []T, match func(T) bool) []T {
+	res := make([]T, 0, len(items))
+	for _, item := range items {
+		if match(item) {
+			res = append(res, item)
+		}
+	}
+
+	return res
+}
+
+// createdBefore sorts assets by creation date then key, like the standalone orchestrator.
+func createdBefore(aDate time.Time, aKey string, bDate time.Time, bKey string) bool {
+	if !aDate.Equal(bDate) {
+		return aDate.Before(bDate)
+	}
+
+	return aKey < bKey
+}
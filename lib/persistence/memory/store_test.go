@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const testChannel = "testchannel"
+
+func TestCommit(t *testing.T) {
+	store := NewStore()
+
+	tx := store.Begin(false)
+	err := New(tx, testChannel).AddOrganization(&asset.Organization{Id: "org1"})
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	tx = store.Begin(true)
+	defer tx.Rollback() //nolint:errcheck
+
+	exists, err := New(tx, testChannel).OrganizationExists("org1")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = New(tx, "otherchannel").OrganizationExists("org1")
+	assert.NoError(t, err)
+	assert.False(t, exists, "assets should be isolated by channel")
+}
+
+func TestRollback(t *testing.T) {
+	store := NewStore()
+
+	tx := store.Begin(false)
+	dbal := New(tx, testChannel)
+	require.NoError(t, dbal.AddOrganization(&asset.Organization{Id: "org1"}))
+	require.NoError(t, tx.Commit())
+
+	tx = store.Begin(false)
+	dbal = New(tx, testChannel)
+	require.NoError(t, dbal.AddOrganization(&asset.Organization{Id: "org2"}))
+	require.NoError(t, dbal.AddComputePlan(&asset.ComputePlan{Key: "cp", Name: "before"}))
+	require.NoError(t, dbal.SetComputePlanName(&asset.ComputePlan{Key: "cp"}, "after"))
+	require.NoError(t, dbal.AddEvents(&asset.Event{Id: "event", Asset: &asset.Event_Organization{Organization: &asset.Organization{Id: "org2"}}}))
+	require.NoError(t, tx.Rollback())
+
+	tx = store.Begin(true)
+	defer tx.Rollback() //nolint:errcheck
+	dbal = New(tx, testChannel)
+
+	organizations, err := dbal.GetAllOrganizations()
+	assert.NoError(t, err)
+	assert.Len(t, organizations, 1)
+
+	exists, err := dbal.ComputePlanExists("cp")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	events, _, err := dbal.QueryEvents(common.NewPagination("", 10), nil, asset.SortOrder_ASCENDING)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Equal(t, int64(0), store.lastEventPosition)
+}
+
+func TestTxDone(t *testing.T) {
+	tx := NewStore().Begin(false)
+	require.NoError(t, tx.Commit())
+
+	assert.ErrorIs(t, tx.Commit(), ErrTxDone)
+	assert.ErrorIs(t, tx.Rollback(), ErrTxDone)
+
+	_, err := New(tx, testChannel).GetAllOrganizations()
+	assert.ErrorIs(t, err, ErrTxDone)
+}
+
+func TestReadOnlyTx(t *testing.T) {
+	tx := NewStore().Begin(true)
+	defer tx.Rollback() //nolint:errcheck
+
+	err := New(tx, testChannel).AddOrganization(&asset.Organization{Id: "org1"})
+	assert.ErrorIs(t, err, ErrReadOnlyTx)
+}
+
+func TestStoredAssetsAreCopies(t *testing.T) {
+	store := NewStore()
+	tx := store.Begin(false)
+	defer tx.Rollback() //nolint:errcheck
+	dbal := New(tx, testChannel)
+
+	organization := &asset.Organization{Id: "org1", Address: "initial"}
+	require.NoError(t, dbal.AddOrganization(organization))
+	organization.Address = "modified"
+
+	stored, err := dbal.GetOrganization("org1")
+	require.NoError(t, err)
+	assert.Equal(t, "initial", stored.Address)
+
+	stored.Address = "modified"
+	stored, err = dbal.GetOrganization("org1")
+	require.NoError(t, err)
+	assert.Equal(t, "initial", stored.Address)
+}
+
+func TestQueryDataSamplesSortedByCreation(t *testing.T) {
+	tx := NewStore().Begin(false)
+	defer tx.Rollback() //nolint:errcheck
+	dbal := New(tx, testChannel)
+
+	err := dbal.AddDataSamples(
+		&asset.DataSample{Key: "b", CreationDate: timestamppb.New(testTime(2))},
+		&asset.DataSample{Key: "a", CreationDate: timestamppb.New(testTime(2))},
+		&asset.DataSample{Key: "c", CreationDate: timestamppb.New(testTime(1))},
+	)
+	require.NoError(t, err)
+
+	samples, token, err := dbal.QueryDataSamples(common.NewPagination("", 10), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "", token)
+	require.Len(t, samples, 3)
+	assert.Equal(t, []string{"c", "a", "b"}, []string{samples[0].Key, samples[1].Key, samples[2].Key})
+
+	err = dbal.AddDataSamples(&asset.DataSample{Key: "a"})
+	orcErr := new(orcerrors.OrcError)
+	assert.ErrorAs(t, err, &orcErr)
+	assert.Equal(t, orcerrors.ErrConflict, orcErr.Kind)
+}
@@ -0,0 +1,375 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// storedWebhook holds a webhook along with its secret, the position of the last event pushed to it
+// and the claim of the running dispatch.
+type storedWebhook struct {
+	webhook      *asset.Webhook
+	secret       string
+	position     int64
+	claimID      string
+	claimedUntil time.Time
+}
+
+// copy returns a shallow copy of the stored webhook, to be updated and put back in the state.
+func (w *storedWebhook) copy() *storedWebhook {
+	c := *w
+	return &c
+}
+
+// webhookFilter returns the filter to store, an empty filter matches every event.
+func webhookFilter(webhook *asset.Webhook) *asset.EventQueryFilter {
+	if webhook.Filter == nil {
+		return &asset.EventQueryFilter{}
+	}
+	return clone(webhook.Filter)
+}
+
+// webhookKeyset sorts webhooks by creation date.
+var webhookKeyset = dateKeyset(false, "creation_date", "key",
+	func(w *storedWebhook) time.Time { return w.webhook.CreationDate.AsTime() },
+	func(w *storedWebhook) string { return w.webhook.Key },
+	func(date time.Time, key string) *storedWebhook {
+		return &storedWebhook{webhook: &asset.Webhook{CreationDate: timestamppb.New(date), Key: key}}
+	},
+)
+
+// deliveryKeyset sorts webhook deliveries most recent first.
+var deliveryKeyset = dateKeyset(true, "creation_date", "id",
+	func(delivery *asset.WebhookDelivery) time.Time { return delivery.CreationDate.AsTime() },
+	func(delivery *asset.WebhookDelivery) string { return delivery.Id },
+	func(date time.Time, id string) *asset.WebhookDelivery {
+		return &asset.WebhookDelivery{CreationDate: timestamppb.New(date), Id: id}
+	},
+)
+
+// AddWebhook implements persistence.WebhookDBAL
+// Its cursor is set to the last event so that only new events are pushed.
+func (d *DBAL) AddWebhook(webhook *asset.Webhook, secret string) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := state.webhooks[webhook.Key]; ok {
+		return orcerrors.NewConflict(asset.WebhookKind, webhook.Key)
+	}
+
+	stored := &storedWebhook{
+		webhook:  clone(webhook),
+		secret:   secret,
+		position: d.tx.store.lastEventPosition,
+	}
+	stored.webhook.Filter = webhookFilter(webhook)
+	put(d.tx, state.webhooks, webhook.Key, stored)
+
+	return nil
+}
+
+// UpdateWebhook implements persistence.WebhookDBAL
+func (d *DBAL) UpdateWebhook(webhook *asset.Webhook, secret string) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	stored, ok := state.webhooks[webhook.Key]
+	if !ok {
+		return nil
+	}
+
+	updated := stored.copy()
+	updated.webhook = clone(stored.webhook)
+	updated.webhook.Url = webhook.Url
+	updated.webhook.Filter = webhookFilter(webhook)
+	if secret != "" {
+		updated.secret = secret
+	}
+	put(d.tx, state.webhooks, webhook.Key, updated)
+
+	return nil
+}
+
+// DeleteWebhook implements persistence.WebhookDBAL
+func (d *DBAL) DeleteWebhook(key string) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	remove(d.tx, state.webhooks, key)
+	for id, delivery := range state.deliveries {
+		if delivery.WebhookKey == key {
+			remove(d.tx, state.deliveries, id)
+		}
+	}
+
+	return nil
+}
+
+// GetWebhook implements persistence.WebhookDBAL
+func (d *DBAL) GetWebhook(key string) (*asset.Webhook, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	stored, ok := state.webhooks[key]
+	if !ok {
+		return nil, orcerrors.NewNotFound(asset.WebhookKind, key)
+	}
+
+	return clone(stored.webhook), nil
+}
+
+// WebhookExists implements persistence.WebhookDBAL
+func (d *DBAL) WebhookExists(key string) (bool, error) {
+	state, err := d.read()
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := state.webhooks[key]
+
+	return ok, nil
+}
+
+// QueryWebhooks implements persistence.WebhookDBAL
+func (d *DBAL) QueryWebhooks(p *common.Pagination, owner string) ([]*asset.Webhook, common.PaginationToken, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, "", err
+	}
+
+	webhooks := keep(values(state.webhooks, webhookKeyset.less), func(w *storedWebhook) bool {
+		return w.webhook.Owner == owner
+	})
+
+	page, token, err := paginate(webhooks, p, webhookKeyset)
+	if err != nil {
+		return nil, "", err
+	}
+
+	res := make([]*asset.Webhook, 0, len(page))
+	for _, w := range page {
+		res = append(res, clone(w.webhook))
+	}
+
+	return res, token, nil
+}
+
+// ClaimWebhook implements persistence.WebhookDBAL
+func (d *DBAL) ClaimWebhook(key string, claimID string, now time.Time, until time.Time) (string, bool, error) {
+	state, err := d.write()
+	if err != nil {
+		return "", false, err
+	}
+
+	stored, ok := state.webhooks[key]
+	if !ok {
+		return "", false, orcerrors.NewNotFound(asset.WebhookKind, key)
+	}
+	if stored.claimedUntil.After(now) {
+		return "", false, nil
+	}
+
+	claimed := stored.copy()
+	claimed.claimID = claimID
+	claimed.claimedUntil = until
+	put(d.tx, state.webhooks, key, claimed)
+
+	return stored.secret, true, nil
+}
+
+// ReleaseWebhook implements persistence.WebhookDBAL
+func (d *DBAL) ReleaseWebhook(key string, claimID string) (bool, error) {
+	state, err := d.write()
+	if err != nil {
+		return false, err
+	}
+
+	stored, ok := state.webhooks[key]
+	if !ok || stored.claimID != claimID {
+		return false, nil
+	}
+
+	released := stored.copy()
+	released.claimID = ""
+	released.claimedUntil = time.Time{}
+	put(d.tx, state.webhooks, key, released)
+
+	return true, nil
+}
+
+// GetDueWebhookKeys implements persistence.WebhookDBAL
+func (d *DBAL) GetDueWebhookKeys(now time.Time) ([]string, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	backingOff := make(map[string]bool)
+	for _, delivery := range state.deliveries {
+		if delivery.Status == asset.WebhookDeliveryStatus_DELIVERY_PENDING &&
+			delivery.NextAttemptDate != nil && delivery.NextAttemptDate.AsTime().After(now) {
+			backingOff[delivery.WebhookKey] = true
+		}
+	}
+
+	keys := []string{}
+	for _, w := range values(state.webhooks, webhookKeyset.less) {
+		if state.countEventsAfter(w.position) > 0 && !backingOff[w.webhook.Key] && !w.claimedUntil.After(now) {
+			keys = append(keys, w.webhook.Key)
+		}
+	}
+
+	return keys, nil
+}
+
+// GetWebhookEvents implements persistence.WebhookDBAL
+func (d *DBAL) GetWebhookEvents(webhook *asset.Webhook, limit uint32) ([]*asset.Event, string, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, "", err
+	}
+
+	events := []*asset.Event{}
+
+	stored, ok := state.webhooks[webhook.Key]
+	if !ok {
+		return events, "", nil
+	}
+
+	lastScanned := ""
+	match := state.eventMatcher(webhook.Filter)
+	for _, e := range state.events[state.eventIndexAfter(stored.position):] {
+		if len(events) == int(limit) {
+			break
+		}
+		lastScanned = e.event.Id
+		if match(e) {
+			events = append(events, clone(e.event))
+		}
+	}
+
+	return events, lastScanned, nil
+}
+
+// SetWebhookCursor implements persistence.WebhookDBAL
+func (d *DBAL) SetWebhookCursor(webhookKey string, eventID string) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	stored, ok := state.webhooks[webhookKey]
+	if !ok {
+		return nil
+	}
+	event, ok := state.eventsByID[eventID]
+	if !ok {
+		return orcerrors.NewNotFound("event", eventID)
+	}
+
+	updated := stored.copy()
+	updated.position = event.position
+	put(d.tx, state.webhooks, webhookKey, updated)
+
+	return nil
+}
+
+// NewWebhookClaimID implements persistence.WebhookDBAL
+func (d *DBAL) NewWebhookClaimID() string {
+	return uuid.NewString()
+}
+
+// NewWebhookDeliveryID implements persistence.WebhookDBAL
+func (d *DBAL) NewWebhookDeliveryID() string {
+	return uuid.NewString()
+}
+
+// GetWebhookDelivery implements persistence.WebhookDBAL
+func (d *DBAL) GetWebhookDelivery(webhookKey string, eventID string) (*asset.WebhookDelivery, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, delivery := range state.deliveries {
+		if delivery.WebhookKey == webhookKey && delivery.EventId == eventID {
+			return clone(delivery), nil
+		}
+	}
+
+	return nil, orcerrors.NewNotFound("webhook delivery", eventID)
+}
+
+// AddWebhookDelivery implements persistence.WebhookDBAL
+func (d *DBAL) AddWebhookDelivery(delivery *asset.WebhookDelivery) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := state.deliveries[delivery.Id]; ok {
+		return orcerrors.NewConflict("webhook delivery", delivery.Id)
+	}
+	put(d.tx, state.deliveries, delivery.Id, clone(delivery))
+
+	return nil
+}
+
+// UpdateWebhookDelivery implements persistence.WebhookDBAL
+func (d *DBAL) UpdateWebhookDelivery(delivery *asset.WebhookDelivery) error {
+	state, err := d.write()
+	if err != nil {
+		return err
+	}
+
+	stored, ok := state.deliveries[delivery.Id]
+	if !ok {
+		return nil
+	}
+
+	updated := clone(stored)
+	updated.Status = delivery.Status
+	updated.Attempts = delivery.Attempts
+	updated.LastAttemptDate = clone(delivery.LastAttemptDate)
+	updated.NextAttemptDate = clone(delivery.NextAttemptDate)
+	updated.ResponseCode = delivery.ResponseCode
+	updated.Error = delivery.Error
+	put(d.tx, state.deliveries, delivery.Id, updated)
+
+	return nil
+}
+
+// QueryWebhookDeliveries implements persistence.WebhookDBAL
+// Deliveries are returned most recent first.
+func (d *DBAL) QueryWebhookDeliveries(p *common.Pagination, webhookKey string, status asset.WebhookDeliveryStatus) ([]*asset.WebhookDelivery, common.PaginationToken, error) {
+	state, err := d.read()
+	if err != nil {
+		return nil, "", err
+	}
+
+	deliveries := values(state.deliveries, deliveryKeyset.less)
+	deliveries = keep(deliveries, func(delivery *asset.WebhookDelivery) bool {
+		return delivery.WebhookKey == webhookKey &&
+			(status == asset.WebhookDeliveryStatus_DELIVERY_UNKNOWN || delivery.Status == status)
+	})
+
+	page, token, err := paginate(deliveries, p, deliveryKeyset)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return cloneAll(page), token, nil
+}
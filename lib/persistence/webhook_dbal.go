@@ -0,0 +1,48 @@
+package persistence
+
+import (
+	"time"
+
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+)
+
+// WebhookDBAL is the database abstraction layer for webhooks and their deliveries
+type WebhookDBAL interface {
+	// AddWebhook stores a new webhook, it will receive the events emitted after its registration.
+	AddWebhook(webhook *asset.Webhook, secret string) error
+	// UpdateWebhook updates the url and filter of a webhook, the secret is only updated when not empty.
+	UpdateWebhook(webhook *asset.Webhook, secret string) error
+	// DeleteWebhook removes a webhook along with its deliveries.
+	DeleteWebhook(key string) error
+	GetWebhook(key string) (*asset.Webhook, error)
+	WebhookExists(key string) (bool, error)
+	QueryWebhooks(p *common.Pagination, owner string) ([]*asset.Webhook, common.PaginationToken, error)
+	// ClaimWebhook marks the webhook as being dispatched by claimID until the given date, and returns its secret.
+	// It returns false if the webhook is already claimed by another dispatcher and its claim has not expired.
+	ClaimWebhook(key string, claimID string, now time.Time, until time.Time) (string, bool, error)
+	// ReleaseWebhook clears the claim of the webhook.
+	// It returns false if the webhook is no longer claimed by claimID, eg: because the claim expired in between.
+	ReleaseWebhook(key string, claimID string) (bool, error)
+	// GetDueWebhookKeys returns the keys of the webhooks having events to deliver,
+	// skipping webhooks waiting for the next attempt of a pending delivery or claimed by a dispatcher.
+	GetDueWebhookKeys(now time.Time) ([]string, error)
+	// GetWebhookEvents returns the events after the cursor of the webhook matching its filter, ordered by position.
+	// It also returns the ID of the last event scanned, matching the filter or not,
+	// which is empty when there is no event after the cursor.
+	GetWebhookEvents(webhook *asset.Webhook, limit uint32) ([]*asset.Event, string, error)
+	// SetWebhookCursor moves the cursor of the webhook to the position of the given event.
+	SetWebhookCursor(webhookKey string, eventID string) error
+	NewWebhookClaimID() string
+	NewWebhookDeliveryID() string
+	// GetWebhookDelivery returns the delivery of an event to a webhook.
+	GetWebhookDelivery(webhookKey string, eventID string) (*asset.WebhookDelivery, error)
+	AddWebhookDelivery(delivery *asset.WebhookDelivery) error
+	UpdateWebhookDelivery(delivery *asset.WebhookDelivery) error
+	QueryWebhookDeliveries(p *common.Pagination, webhookKey string, status asset.WebhookDeliveryStatus) ([]*asset.WebhookDelivery, common.PaginationToken, error)
+}
+
+// WebhookDBALProvider represents an object capable of providing a WebhookDBAL
+type WebhookDBALProvider interface {
+	GetWebhookDBAL() WebhookDBAL
+}
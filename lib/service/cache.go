@@ -0,0 +1,132 @@
+package service
+
+import (
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// AssetCacheAPI gives access to assets which are read repeatedly while processing a request.
+// Assets are fetched once and reused until the end of the transaction,
+// unless they are updated by their service in the meantime.
+// Returned assets are shared by every caller: they must not be modified.
+// Services updating an asset load it from the DBAL instead.
+type AssetCacheAPI interface {
+	GetFunction(key string) (*asset.Function, error)
+	GetDataManager(key string) (*asset.DataManager, error)
+	GetOrganization(id string) (*asset.Organization, error)
+	GetAllOrganizations() ([]*asset.Organization, error)
+	GetPlan(key string) (*asset.ComputePlan, error)
+	forgetFunction(key string)
+	forgetDataManager(key string)
+	forgetOrganizations()
+	forgetPlan(key string)
+}
+
+// AssetCacheProvider defines an object able to provide an AssetCacheAPI instance
+type AssetCacheProvider interface {
+	GetAssetCache() AssetCacheAPI
+}
+
+// AssetCacheDependencyProvider defines what the AssetCache needs to perform its duty
+type AssetCacheDependencyProvider interface {
+	FunctionServiceProvider
+	DataManagerServiceProvider
+	OrganizationServiceProvider
+	ComputePlanServiceProvider
+}
+
+// AssetCache keeps the assets fetched during a transaction.
+// It is not safe for concurrent use, like the Provider which owns it.
+type AssetCache struct {
+	AssetCacheDependencyProvider
+	functions     map[string]*asset.Function
+	dataManagers  map[string]*asset.DataManager
+	organizations map[string]*asset.Organization
+	// allOrganizations is nil until GetAllOrganizations is called
+	allOrganizations []*asset.Organization
+	// plans may be running: this is safe as long as every ComputePlanDBAL write is followed by forgetPlan,
+	// which ComputePlanService does for each of them (name, cancelation, failure, restoration, pause, resumption and purge).
+	// The plan status is not stored but derived from task counts, so task updates don't make cached plans stale.
+	plans map[string]*asset.ComputePlan
+}
+
+// NewAssetCache creates an empty cache
+func NewAssetCache(provider AssetCacheDependencyProvider) *AssetCache {
+	return &AssetCache{
+		AssetCacheDependencyProvider: provider,
+		functions:                    make(map[string]*asset.Function),
+		dataManagers:                 make(map[string]*asset.DataManager),
+		organizations:                make(map[string]*asset.Organization),
+		plans:                        make(map[string]*asset.ComputePlan),
+	}
+}
+
+// getCached returns the value stored under key, or loads and stores it if it is not there.
+func getCached[T any](store map[string]T, key string, load func(string) (T, error)) (T, error) {
+	if value, ok := store[key]; ok {
+		return value, nil
+	}
+
+	value, err := load(key)
+	if err != nil {
+		return value, err
+	}
+	store[key] = value
+
+	return value, nil
+}
+
+// GetFunction returns the function from the cache, fetching it on first access.
+func (c *AssetCache) GetFunction(key string) (*asset.Function, error) {
+	return getCached(c.functions, key, c.GetFunctionService().GetFunction)
+}
+
+// GetDataManager returns the data manager from the cache, fetching it on first access.
+func (c *AssetCache) GetDataManager(key string) (*asset.DataManager, error) {
+	return getCached(c.dataManagers, key, c.GetDataManagerService().GetDataManager)
+}
+
+// GetOrganization returns the organization from the cache, fetching it on first access.
+func (c *AssetCache) GetOrganization(id string) (*asset.Organization, error) {
+	return getCached(c.organizations, id, c.GetOrganizationService().GetOrganization)
+}
+
+// GetAllOrganizations returns every organization of the channel, they are fetched on first access.
+func (c *AssetCache) GetAllOrganizations() ([]*asset.Organization, error) {
+	if c.allOrganizations == nil {
+		organizations, err := c.GetOrganizationService().GetAllOrganizations()
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range organizations {
+			c.organizations[org.Id] = org
+		}
+		c.allOrganizations = organizations
+	}
+
+	return c.allOrganizations, nil
+}
+
+// GetPlan returns the compute plan from the cache, fetching it on first access.
+func (c *AssetCache) GetPlan(key string) (*asset.ComputePlan, error) {
+	return getCached(c.plans, key, c.GetComputePlanService().GetPlan)
+}
+
+// forgetFunction should be called when the function is updated.
+func (c *AssetCache) forgetFunction(key string) {
+	delete(c.functions, key)
+}
+
+// forgetDataManager should be called when the data manager is updated.
+func (c *AssetCache) forgetDataManager(key string) {
+	delete(c.dataManagers, key)
+}
+
+// forgetOrganizations should be called when an organization is registered.
+func (c *AssetCache) forgetOrganizations() {
+	c.allOrganizations = nil
+}
+
+// forgetPlan should be called when the compute plan is updated.
+func (c *AssetCache) forgetPlan(key string) {
+	delete(c.plans, key)
+}
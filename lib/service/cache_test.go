@@ -0,0 +1,153 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/substra/orchestrator/lib/asset"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"github.com/substra/orchestrator/lib/persistence"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestAssetCacheGetPlan(t *testing.T) {
+	provider := newMockedProvider()
+	cps := new(MockComputePlanAPI)
+	provider.On("GetComputePlanService").Return(cps)
+
+	computePlan := &asset.ComputePlan{
+		Key: "uuid1",
+	}
+
+	cps.On("GetPlan", "uuid1").Return(computePlan, nil).Once()
+
+	cache := NewAssetCache(provider)
+
+	cp, err := cache.GetPlan("uuid1")
+	assert.NoError(t, err)
+	assert.Equal(t, computePlan.Key, cp.Key)
+
+	cp, err = cache.GetPlan("uuid1")
+	assert.NoError(t, err)
+	assert.Equal(t, computePlan.Key, cp.Key)
+
+	cps.AssertExpectations(t)
+}
+
+func TestAssetCacheForgetsUpdatedPlan(t *testing.T) {
+	ts := new(MockTimeAPI)
+	dbal := new(persistence.MockDBAL)
+	es := new(MockEventAPI)
+	provider := newMockedProvider()
+
+	provider.On("GetTimeService").Return(ts)
+	provider.On("GetComputePlanDBAL").Return(dbal)
+	provider.On("GetEventService").Return(es)
+	provider.On("GetComputePlanService").Return(NewComputePlanService(provider))
+
+	cached := &asset.ComputePlan{Key: "uuid1", Owner: "owner"}
+	dbal.On("GetComputePlan", "uuid1").Once().Return(cached, nil)
+	dbal.On("GetComputePlan", "uuid1").Once().Return(&asset.ComputePlan{Key: "uuid1", Owner: "owner"}, nil)
+	ts.On("GetTransactionTime").Once().Return(time.Unix(1337, 0))
+	dbal.On("PauseComputePlan", mock.Anything, time.Unix(1337, 0)).Once().Return(nil)
+	es.On("RegisterEvents", mock.Anything).Once().Return(nil)
+	dbal.On("GetComputePlan", "uuid1").Once().Return(&asset.ComputePlan{Key: "uuid1", Owner: "owner", PauseDate: timestamppb.New(time.Unix(1337, 0))}, nil)
+
+	cache := provider.GetAssetCache()
+
+	plan, err := cache.GetPlan("uuid1")
+	assert.NoError(t, err)
+	assert.False(t, plan.IsPaused())
+
+	err = provider.GetComputePlanService().ApplyPlanAction("uuid1", asset.ComputePlanAction_PLAN_ACTION_PAUSED, "owner")
+	assert.NoError(t, err)
+
+	plan, err = cache.GetPlan("uuid1")
+	assert.NoError(t, err)
+	assert.True(t, plan.IsPaused())
+	assert.False(t, cached.IsPaused(), "the cached plan should not be modified")
+
+	ts.AssertExpectations(t)
+	dbal.AssertExpectations(t)
+	es.AssertExpectations(t)
+}
+
+func TestAssetCacheForgetFunction(t *testing.T) {
+	provider := newMockedProvider()
+	fs := new(MockFunctionAPI)
+	provider.On("GetFunctionService").Return(fs)
+
+	fs.On("GetFunction", "uuid1").Return(&asset.Function{Key: "uuid1", Name: "before"}, nil).Once()
+	fs.On("GetFunction", "uuid1").Return(&asset.Function{Key: "uuid1", Name: "after"}, nil).Once()
+
+	cache := NewAssetCache(provider)
+
+	function, err := cache.GetFunction("uuid1")
+	assert.NoError(t, err)
+	assert.Equal(t, "before", function.Name)
+
+	cache.forgetFunction("uuid1")
+
+	function, err = cache.GetFunction("uuid1")
+	assert.NoError(t, err)
+	assert.Equal(t, "after", function.Name)
+
+	fs.AssertExpectations(t)
+}
+
+func TestAssetCacheDoesNotKeepErrors(t *testing.T) {
+	provider := newMockedProvider()
+	dms := new(MockDataManagerAPI)
+	provider.On("GetDataManagerService").Return(dms)
+
+	dms.On("GetDataManager", "uuid1").Return(nil, orcerrors.NewNotFound(asset.DataManagerKind, "uuid1")).Once()
+	dms.On("GetDataManager", "uuid1").Return(&asset.DataManager{Key: "uuid1"}, nil).Once()
+
+	cache := NewAssetCache(provider)
+
+	_, err := cache.GetDataManager("uuid1")
+	assert.Error(t, err)
+
+	dm, err := cache.GetDataManager("uuid1")
+	assert.NoError(t, err)
+	assert.Equal(t, "uuid1", dm.Key)
+
+	dms.AssertExpectations(t)
+}
+
+func TestAssetCacheGetAllOrganizations(t *testing.T) {
+	provider := newMockedProvider()
+	os := new(MockOrganizationAPI)
+	provider.On("GetOrganizationService").Return(os)
+
+	organizations := []*asset.Organization{{Id: "org1"}, {Id: "org2"}}
+	os.On("GetAllOrganizations").Return(organizations, nil).Once()
+
+	cache := NewAssetCache(provider)
+
+	res, err := cache.GetAllOrganizations()
+	assert.NoError(t, err)
+	assert.Equal(t, organizations, res)
+
+	res, err = cache.GetAllOrganizations()
+	assert.NoError(t, err)
+	assert.Equal(t, organizations, res)
+
+	// Organizations listed are cached individually
+	org, err := cache.GetOrganization("org2")
+	assert.NoError(t, err)
+	assert.Equal(t, "org2", org.Id)
+
+	os.AssertExpectations(t)
+
+	cache.forgetOrganizations()
+	os.On("GetAllOrganizations").Return([]*asset.Organization{{Id: "org1"}, {Id: "org2"}, {Id: "org3"}}, nil).Once()
+
+	res, err = cache.GetAllOrganizations()
+	assert.NoError(t, err)
+	assert.Len(t, res, 3)
+
+	os.AssertExpectations(t)
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/substra/orchestrator/lib/asset"
 	"github.com/substra/orchestrator/lib/common"
 	orcerrors "github.com/substra/orchestrator/lib/errors"
@@ -16,8 +19,15 @@ type ComputePlanAPI interface {
 	ApplyPlanAction(key string, action asset.ComputePlanAction, requester string) error
 	UpdatePlan(computePlan *asset.UpdateComputePlanParam, requester string) error
 	failPlan(key string) error
+	restorePlan(key string) error
 	computePlanExists(key string) (bool, error)
 	IsPlanRunning(key string) (bool, error)
+	GetPlanStatistics(key string) (*asset.ComputePlanStatistics, error)
+	GetPlanGraph(key string) (*asset.ComputePlanGraph, error)
+	ArchivePlan(key string, requester string) (*asset.ComputePlanArchive, error)
+	PurgePlan(key string, requester string) (*asset.ComputePlan, error)
+	GetExpiredPlanKeys(retention time.Duration) ([]string, error)
+	PurgeExpiredPlan(key string, archiver PlanArchiver) (bool, error)
 }
 
 // ComputePlanServiceProvider defines an object able to provide a ComputePlanAPI instance
@@ -32,6 +42,7 @@ type ComputePlanDependencyProvider interface {
 	EventServiceProvider
 	ComputeTaskServiceProvider
 	TimeServiceProvider
+	AssetCacheProvider
 }
 
 // ComputePlanService is the compute plan manipulation entry point
@@ -60,12 +71,13 @@ func (s *ComputePlanService) RegisterPlan(input *asset.NewComputePlan, owner str
 	}
 
 	plan := &asset.ComputePlan{
-		Key:          input.Key,
-		Owner:        owner,
-		Tag:          input.Tag,
-		Name:         input.Name,
-		Metadata:     input.Metadata,
-		CreationDate: timestamppb.New(s.GetTimeService().GetTransactionTime()),
+		Key:             input.Key,
+		Owner:           owner,
+		Tag:             input.Tag,
+		Name:            input.Name,
+		Metadata:        input.Metadata,
+		CreationDate:    timestamppb.New(s.GetTimeService().GetTransactionTime()),
+		MaxTaskAttempts: input.MaxTaskAttempts,
 	}
 
 	err = s.GetComputePlanDBAL().AddComputePlan(plan)
@@ -99,6 +111,10 @@ func (s *ComputePlanService) ApplyPlanAction(key string, action asset.ComputePla
 	switch action {
 	case asset.ComputePlanAction_PLAN_ACTION_CANCELED:
 		return s.cancelPlan(plan)
+	case asset.ComputePlanAction_PLAN_ACTION_PAUSED:
+		return s.pausePlan(plan)
+	case asset.ComputePlanAction_PLAN_ACTION_RESUMED:
+		return s.resumePlan(plan)
 	default:
 		return orcerrors.NewUnimplemented("plan action unimplemented")
 	}
@@ -136,6 +152,7 @@ func (s *ComputePlanService) UpdatePlan(a *asset.UpdateComputePlanParam, request
 	if err != nil {
 		return err
 	}
+	s.GetAssetCache().forgetPlan(plan.Key)
 
 	event := &asset.Event{
 		EventKind: asset.EventKind_EVENT_ASSET_UPDATED,
@@ -157,7 +174,37 @@ func (s *ComputePlanService) failPlan(key string) error {
 	}
 
 	failureDate := s.GetTimeService().GetTransactionTime()
-	return s.GetComputePlanDBAL().FailComputePlan(plan, failureDate)
+	err = s.GetComputePlanDBAL().FailComputePlan(plan, failureDate)
+	if err != nil {
+		return err
+	}
+	s.GetAssetCache().forgetPlan(plan.Key)
+
+	return nil
+}
+
+// restorePlan clears the failure of a compute plan, so that its tasks can be executed again.
+func (s *ComputePlanService) restorePlan(key string) error {
+	plan, err := s.GetPlan(key)
+	if err != nil {
+		return err
+	}
+
+	if plan.FailureDate == nil {
+		return nil
+	}
+
+	if plan.CancelationDate != nil {
+		return orcerrors.NewTerminatedComputePlan(plan.Key)
+	}
+
+	err = s.GetComputePlanDBAL().RestoreComputePlan(plan)
+	if err != nil {
+		return err
+	}
+	s.GetAssetCache().forgetPlan(plan.Key)
+
+	return nil
 }
 
 func (s *ComputePlanService) cancelPlan(plan *asset.ComputePlan) error {
@@ -170,6 +217,7 @@ func (s *ComputePlanService) cancelPlan(plan *asset.ComputePlan) error {
 	if err != nil {
 		return err
 	}
+	s.GetAssetCache().forgetPlan(plan.Key)
 
 	plan.CancelationDate = timestamppb.New(cancelationDate)
 
@@ -183,6 +231,67 @@ func (s *ComputePlanService) cancelPlan(plan *asset.ComputePlan) error {
 	return s.GetEventService().RegisterEvents(event)
 }
 
+// pausePlan prevents the tasks of the compute plan from being started until the plan is resumed.
+func (s *ComputePlanService) pausePlan(plan *asset.ComputePlan) error {
+	if plan.IsTerminated() {
+		return orcerrors.NewTerminatedComputePlan(plan.Key)
+	}
+	if plan.IsPaused() {
+		return orcerrors.NewBadRequest(fmt.Sprintf("compute plan %s is already paused", plan.Key))
+	}
+
+	pauseDate := s.GetTimeService().GetTransactionTime()
+	err := s.GetComputePlanDBAL().PauseComputePlan(plan, pauseDate)
+	if err != nil {
+		return err
+	}
+	s.GetAssetCache().forgetPlan(plan.Key)
+
+	plan.PauseDate = timestamppb.New(pauseDate)
+
+	event := &asset.Event{
+		AssetKey:  plan.Key,
+		EventKind: asset.EventKind_EVENT_ASSET_UPDATED,
+		AssetKind: asset.AssetKind_ASSET_COMPUTE_PLAN,
+		Asset:     &asset.Event_ComputePlan{ComputePlan: plan},
+	}
+
+	return s.GetEventService().RegisterEvents(event)
+}
+
+// resumePlan allows the tasks of a paused compute plan to be started again,
+// and updates the tasks which have been held while the plan was paused.
+func (s *ComputePlanService) resumePlan(plan *asset.ComputePlan) error {
+	if plan.IsTerminated() {
+		return orcerrors.NewTerminatedComputePlan(plan.Key)
+	}
+	if !plan.IsPaused() {
+		return orcerrors.NewBadRequest(fmt.Sprintf("compute plan %s is not paused", plan.Key))
+	}
+
+	resumeDate := s.GetTimeService().GetTransactionTime()
+	err := s.GetComputePlanDBAL().ResumeComputePlan(plan, resumeDate)
+	if err != nil {
+		return err
+	}
+	s.GetAssetCache().forgetPlan(plan.Key)
+
+	plan.ResumeDate = timestamppb.New(resumeDate)
+
+	event := &asset.Event{
+		AssetKey:  plan.Key,
+		EventKind: asset.EventKind_EVENT_ASSET_UPDATED,
+		AssetKind: asset.AssetKind_ASSET_COMPUTE_PLAN,
+		Asset:     &asset.Event_ComputePlan{ComputePlan: plan},
+	}
+	err = s.GetEventService().RegisterEvents(event)
+	if err != nil {
+		return err
+	}
+
+	return s.GetComputeTaskService().resumePlanTasks(plan.Key)
+}
+
 func (s *ComputePlanService) computePlanExists(key string) (bool, error) {
 	return s.GetComputePlanDBAL().ComputePlanExists(key)
 }
@@ -197,3 +306,137 @@ func (s *ComputePlanService) IsPlanRunning(key string) (bool, error) {
 		return s.GetComputePlanDBAL().ArePlanTasksRunning(key)
 	}
 }
+
+// GetPlanStatistics returns the task counts by status and the timings of the compute plan,
+// along with a status derived from its termination dates and task counts.
+func (s *ComputePlanService) GetPlanStatistics(key string) (*asset.ComputePlanStatistics, error) {
+	s.GetLogger().Debug().Str("key", key).Msg("Get compute plan statistics")
+
+	plan, err := s.GetPlan(key)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.GetComputePlanDBAL().GetComputePlanStatistics(key)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Status = getPlanStatus(plan, stats)
+
+	return stats, nil
+}
+
+// GetPlanGraph returns the tasks of the compute plan as nodes,
+// and the links between their outputs and inputs as edges.
+// Parents belonging to another compute plan are included in the nodes.
+func (s *ComputePlanService) GetPlanGraph(key string) (*asset.ComputePlanGraph, error) {
+	s.GetLogger().Debug().Str("key", key).Msg("Get compute plan graph")
+
+	exists, err := s.computePlanExists(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, orcerrors.NewNotFound(asset.ComputePlanKind, key)
+	}
+
+	tasks, err := s.GetComputeTaskService().getPlanTasks(key)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &asset.ComputePlanGraph{
+		ComputePlanKey: key,
+		Nodes:          make([]*asset.ComputePlanGraphNode, 0, len(tasks)),
+		Edges:          []*asset.ComputePlanGraphEdge{},
+	}
+
+	inGraph := make(map[string]struct{}, len(tasks))
+	for _, task := range tasks {
+		graph.Nodes = append(graph.Nodes, newGraphNode(task))
+		inGraph[task.Key] = struct{}{}
+	}
+
+	// Parents from other compute plans are fetched at once
+	externalParents := []string{}
+	for _, task := range tasks {
+		for _, parentKey := range GetParentTaskKeys(task.Inputs) {
+			if _, ok := inGraph[parentKey]; !ok {
+				externalParents = append(externalParents, parentKey)
+				inGraph[parentKey] = struct{}{}
+			}
+		}
+	}
+
+	if len(externalParents) > 0 {
+		parents, err := s.GetComputeTaskService().getTasks(externalParents)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range parents {
+			graph.Nodes = append(graph.Nodes, newGraphNode(parent))
+		}
+	}
+
+	for _, task := range tasks {
+		for _, input := range task.Inputs {
+			ref, ok := input.Ref.(*asset.ComputeTaskInput_ParentTaskOutput)
+			if !ok {
+				continue
+			}
+			graph.Edges = append(graph.Edges, &asset.ComputePlanGraphEdge{
+				ParentTaskKey:              ref.ParentTaskOutput.ParentTaskKey,
+				ParentTaskOutputIdentifier: ref.ParentTaskOutput.OutputIdentifier,
+				ChildTaskKey:               task.Key,
+				ChildTaskInputIdentifier:   input.Identifier,
+			})
+		}
+	}
+
+	return graph, nil
+}
+
+func newGraphNode(task *asset.ComputeTask) *asset.ComputePlanGraphNode {
+	return &asset.ComputePlanGraphNode{
+		TaskKey:        task.Key,
+		Status:         task.Status,
+		Rank:           task.Rank,
+		Worker:         task.Worker,
+		FunctionKey:    task.FunctionKey,
+		ComputePlanKey: task.ComputePlanKey,
+	}
+}
+
+// getPlanStatus infers the status of a compute plan.
+// Termination dates take precedence over task counts.
+func getPlanStatus(plan *asset.ComputePlan, stats *asset.ComputePlanStatistics) asset.ComputePlanStatus {
+	switch {
+	case plan.IsPurged():
+		return asset.ComputePlanStatus_PLAN_STATUS_PURGED
+	case plan.CancelationDate != nil:
+		return asset.ComputePlanStatus_PLAN_STATUS_CANCELED
+	case plan.FailureDate != nil:
+		return asset.ComputePlanStatus_PLAN_STATUS_FAILED
+	case plan.IsPaused():
+		return asset.ComputePlanStatus_PLAN_STATUS_PAUSED
+	case stats.TaskCount == 0:
+		return asset.ComputePlanStatus_PLAN_STATUS_EMPTY
+	}
+
+	counts := make(map[asset.ComputeTaskStatus]uint32, len(stats.TaskCounts))
+	for _, c := range stats.TaskCounts {
+		counts[c.Status] = c.Count
+	}
+
+	switch {
+	case counts[asset.ComputeTaskStatus_STATUS_FAILED] > 0:
+		return asset.ComputePlanStatus_PLAN_STATUS_FAILED
+	case counts[asset.ComputeTaskStatus_STATUS_CANCELED] > 0:
+		return asset.ComputePlanStatus_PLAN_STATUS_CANCELED
+	case counts[asset.ComputeTaskStatus_STATUS_DONE] == stats.TaskCount:
+		return asset.ComputePlanStatus_PLAN_STATUS_DONE
+	default:
+		return asset.ComputePlanStatus_PLAN_STATUS_RUNNING
+	}
+}
@@ -1,11 +1,13 @@
 package service
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/substra/orchestrator/lib/asset"
 	"github.com/substra/orchestrator/lib/common"
 	orcerrors "github.com/substra/orchestrator/lib/errors"
@@ -126,6 +128,116 @@ func TestCancelPlan(t *testing.T) {
 	es.AssertExpectations(t)
 }
 
+func TestPausePlan(t *testing.T) {
+	ts := new(MockTimeAPI)
+	dbal := new(persistence.MockDBAL)
+	es := new(MockEventAPI)
+	provider := newMockedProvider()
+
+	provider.On("GetTimeService").Return(ts)
+	provider.On("GetComputePlanDBAL").Return(dbal)
+	provider.On("GetEventService").Return(es)
+
+	service := NewComputePlanService(provider)
+
+	plan := &asset.ComputePlan{Key: "b9b3ecda-0a90-41da-a2e3-945eeafb06d8", Owner: "owner"}
+
+	ts.On("GetTransactionTime").Once().Return(time.Unix(1337, 0))
+	dbal.On("GetComputePlan", plan.Key).Once().Return(plan, nil)
+	dbal.On("PauseComputePlan", plan, time.Unix(1337, 0)).Once().Return(nil)
+
+	expectedEvent := &asset.Event{
+		AssetKind: asset.AssetKind_ASSET_COMPUTE_PLAN,
+		AssetKey:  plan.Key,
+		EventKind: asset.EventKind_EVENT_ASSET_UPDATED,
+		Asset: &asset.Event_ComputePlan{ComputePlan: &asset.ComputePlan{
+			Key:       plan.Key,
+			Owner:     plan.Owner,
+			PauseDate: timestamppb.New(time.Unix(1337, 0)),
+		}},
+	}
+	es.On("RegisterEvents", expectedEvent).Once().Return(nil)
+
+	err := service.ApplyPlanAction(plan.Key, asset.ComputePlanAction_PLAN_ACTION_PAUSED, "owner")
+	assert.NoError(t, err)
+
+	// A paused plan cannot be paused again
+	err = service.pausePlan(plan)
+	orcError := new(orcerrors.OrcError)
+	assert.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrBadRequest, orcError.Kind)
+
+	err = service.pausePlan(&asset.ComputePlan{Key: plan.Key, CancelationDate: timestamppb.Now()})
+	assert.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrTerminatedComputePlan, orcError.Kind)
+
+	ts.AssertExpectations(t)
+	dbal.AssertExpectations(t)
+	es.AssertExpectations(t)
+}
+
+func TestResumePlan(t *testing.T) {
+	ts := new(MockTimeAPI)
+	dbal := new(persistence.MockDBAL)
+	es := new(MockEventAPI)
+	cts := new(MockComputeTaskAPI)
+	provider := newMockedProvider()
+
+	provider.On("GetTimeService").Return(ts)
+	provider.On("GetComputePlanDBAL").Return(dbal)
+	provider.On("GetEventService").Return(es)
+	provider.On("GetComputeTaskService").Return(cts)
+
+	service := NewComputePlanService(provider)
+
+	plan := &asset.ComputePlan{Key: "b9b3ecda-0a90-41da-a2e3-945eeafb06d8", Owner: "owner", PauseDate: timestamppb.New(time.Unix(1337, 0))}
+
+	ts.On("GetTransactionTime").Once().Return(time.Unix(1338, 0))
+	dbal.On("ResumeComputePlan", plan, time.Unix(1338, 0)).Once().Return(nil)
+	es.On("RegisterEvents", mock.AnythingOfType("*asset.Event")).Once().Return(nil)
+	cts.On("resumePlanTasks", plan.Key).Once().Return(nil)
+
+	err := service.resumePlan(plan)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1338), plan.ResumeDate.GetSeconds())
+	assert.False(t, plan.IsPaused())
+
+	// A running plan cannot be resumed
+	err = service.resumePlan(plan)
+	orcError := new(orcerrors.OrcError)
+	assert.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrBadRequest, orcError.Kind)
+
+	ts.AssertExpectations(t)
+	dbal.AssertExpectations(t)
+	es.AssertExpectations(t)
+	cts.AssertExpectations(t)
+}
+
+func TestRestorePlan(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	provider := newMockedProvider()
+	provider.On("GetComputePlanDBAL").Return(dbal)
+	service := NewComputePlanService(provider)
+
+	plan := &asset.ComputePlan{Key: "failed", FailureDate: timestamppb.Now()}
+	dbal.On("GetComputePlan", "failed").Once().Return(plan, nil)
+	dbal.On("RestoreComputePlan", plan).Once().Return(nil)
+	assert.NoError(t, service.restorePlan("failed"))
+
+	// Nothing to do on a plan which is not failed
+	dbal.On("GetComputePlan", "running").Once().Return(&asset.ComputePlan{Key: "running"}, nil)
+	assert.NoError(t, service.restorePlan("running"))
+
+	dbal.On("GetComputePlan", "canceled").Once().Return(&asset.ComputePlan{Key: "canceled", FailureDate: timestamppb.Now(), CancelationDate: timestamppb.Now()}, nil)
+	err := service.restorePlan("canceled")
+	orcError := new(orcerrors.OrcError)
+	assert.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrTerminatedComputePlan, orcError.Kind)
+
+	dbal.AssertExpectations(t)
+}
+
 func TestQueryPlans(t *testing.T) {
 	dbal := new(persistence.MockDBAL)
 	provider := newMockedProvider()
@@ -233,3 +345,210 @@ func TestUpdateSingleExistingComputePlan(t *testing.T) {
 	}
 
 }
+
+func TestGetPlanStatistics(t *testing.T) {
+	cases := map[string]struct {
+		plan     *asset.ComputePlan
+		counts   []*asset.TaskStatusCount
+		expected asset.ComputePlanStatus
+	}{
+		"empty": {
+			plan:     &asset.ComputePlan{Key: "uuid"},
+			counts:   []*asset.TaskStatusCount{},
+			expected: asset.ComputePlanStatus_PLAN_STATUS_EMPTY,
+		},
+		"running": {
+			plan: &asset.ComputePlan{Key: "uuid"},
+			counts: []*asset.TaskStatusCount{
+				{Status: asset.ComputeTaskStatus_STATUS_DONE, Count: 2},
+				{Status: asset.ComputeTaskStatus_STATUS_EXECUTING, Count: 1},
+			},
+			expected: asset.ComputePlanStatus_PLAN_STATUS_RUNNING,
+		},
+		"done": {
+			plan: &asset.ComputePlan{Key: "uuid"},
+			counts: []*asset.TaskStatusCount{
+				{Status: asset.ComputeTaskStatus_STATUS_DONE, Count: 3},
+			},
+			expected: asset.ComputePlanStatus_PLAN_STATUS_DONE,
+		},
+		"failed task": {
+			plan: &asset.ComputePlan{Key: "uuid"},
+			counts: []*asset.TaskStatusCount{
+				{Status: asset.ComputeTaskStatus_STATUS_DONE, Count: 2},
+				{Status: asset.ComputeTaskStatus_STATUS_FAILED, Count: 1},
+			},
+			expected: asset.ComputePlanStatus_PLAN_STATUS_FAILED,
+		},
+		"paused": {
+			plan: &asset.ComputePlan{Key: "uuid", PauseDate: timestamppb.New(time.Unix(1337, 0))},
+			counts: []*asset.TaskStatusCount{
+				{Status: asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT, Count: 1},
+			},
+			expected: asset.ComputePlanStatus_PLAN_STATUS_PAUSED,
+		},
+		"resumed": {
+			plan: &asset.ComputePlan{Key: "uuid", PauseDate: timestamppb.New(time.Unix(1337, 0)), ResumeDate: timestamppb.New(time.Unix(1338, 0))},
+			counts: []*asset.TaskStatusCount{
+				{Status: asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT, Count: 1},
+			},
+			expected: asset.ComputePlanStatus_PLAN_STATUS_RUNNING,
+		},
+		"failed plan": {
+			plan: &asset.ComputePlan{Key: "uuid", FailureDate: timestamppb.New(time.Unix(1337, 0))},
+			counts: []*asset.TaskStatusCount{
+				{Status: asset.ComputeTaskStatus_STATUS_EXECUTING, Count: 1},
+			},
+			expected: asset.ComputePlanStatus_PLAN_STATUS_FAILED,
+		},
+		"canceled plan": {
+			plan: &asset.ComputePlan{Key: "uuid", CancelationDate: timestamppb.New(time.Unix(1337, 0))},
+			counts: []*asset.TaskStatusCount{
+				{Status: asset.ComputeTaskStatus_STATUS_DONE, Count: 1},
+				{Status: asset.ComputeTaskStatus_STATUS_CANCELED, Count: 2},
+			},
+			expected: asset.ComputePlanStatus_PLAN_STATUS_CANCELED,
+		},
+		"purged plan": {
+			plan:     &asset.ComputePlan{Key: "uuid", CancelationDate: timestamppb.New(time.Unix(1337, 0)), PurgeDate: timestamppb.New(time.Unix(1338, 0))},
+			counts:   []*asset.TaskStatusCount{},
+			expected: asset.ComputePlanStatus_PLAN_STATUS_PURGED,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dbal := new(persistence.MockDBAL)
+			provider := newMockedProvider()
+			provider.On("GetComputePlanDBAL").Return(dbal)
+
+			service := NewComputePlanService(provider)
+
+			var total uint32
+			for _, c := range tc.counts {
+				total += c.Count
+			}
+			stats := &asset.ComputePlanStatistics{
+				ComputePlanKey: "uuid",
+				TaskCount:      total,
+				TaskCounts:     tc.counts,
+			}
+
+			dbal.On("GetComputePlan", "uuid").Once().Return(tc.plan, nil)
+			dbal.On("GetComputePlanStatistics", "uuid").Once().Return(stats, nil)
+
+			res, err := service.GetPlanStatistics("uuid")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, res.Status)
+			assert.Equal(t, total, res.TaskCount)
+
+			dbal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetPlanStatisticsUnknownPlan(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	provider := newMockedProvider()
+	provider.On("GetComputePlanDBAL").Return(dbal)
+
+	service := NewComputePlanService(provider)
+
+	dbal.On("GetComputePlan", "uuid").Once().Return(nil, orcerrors.NewNotFound(asset.ComputePlanKind, "uuid"))
+
+	_, err := service.GetPlanStatistics("uuid")
+	assert.Error(t, err)
+
+	dbal.AssertExpectations(t)
+}
+
+func TestGetPlanGraph(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	cts := new(MockComputeTaskAPI)
+	provider := newMockedProvider()
+	provider.On("GetComputePlanDBAL").Return(dbal)
+	provider.On("GetComputeTaskService").Return(cts)
+
+	service := NewComputePlanService(provider)
+
+	parentRef := func(key, identifier string) *asset.ComputeTaskInput_ParentTaskOutput {
+		return &asset.ComputeTaskInput_ParentTaskOutput{
+			ParentTaskOutput: &asset.ParentTaskOutputRef{ParentTaskKey: key, OutputIdentifier: identifier},
+		}
+	}
+
+	tasks := []*asset.ComputeTask{
+		{
+			Key:            "train",
+			ComputePlanKey: "cp",
+			Status:         asset.ComputeTaskStatus_STATUS_DONE,
+			Worker:         "org1",
+			FunctionKey:    "trainFunction",
+			Inputs: []*asset.ComputeTaskInput{
+				{Identifier: "opener", Ref: &asset.ComputeTaskInput_AssetKey{AssetKey: "dm"}},
+				{Identifier: "model", Ref: parentRef("external", "model")},
+			},
+		},
+		{
+			Key:            "predict",
+			ComputePlanKey: "cp",
+			Status:         asset.ComputeTaskStatus_STATUS_EXECUTING,
+			Rank:           1,
+			Worker:         "org1",
+			FunctionKey:    "predictFunction",
+			Inputs: []*asset.ComputeTaskInput{
+				{Identifier: "model", Ref: parentRef("train", "model")},
+				{Identifier: "shared", Ref: parentRef("train", "shared")},
+				{Identifier: "metrics", Ref: parentRef("otherExternal", "metrics")},
+			},
+		},
+	}
+
+	dbal.On("ComputePlanExists", "cp").Once().Return(true, nil)
+	cts.On("getPlanTasks", "cp").Once().Return(tasks, nil)
+	// parents from other plans are fetched at once to be part of the graph
+	cts.On("getTasks", []string{"external", "otherExternal"}).Once().Return([]*asset.ComputeTask{
+		{Key: "external", ComputePlanKey: "otherCp", Status: asset.ComputeTaskStatus_STATUS_DONE},
+		{Key: "otherExternal", ComputePlanKey: "otherCp", Status: asset.ComputeTaskStatus_STATUS_EXECUTING},
+	}, nil)
+
+	graph, err := service.GetPlanGraph("cp")
+	require.NoError(t, err)
+
+	expected := &asset.ComputePlanGraph{
+		ComputePlanKey: "cp",
+		Nodes: []*asset.ComputePlanGraphNode{
+			{TaskKey: "train", ComputePlanKey: "cp", Status: asset.ComputeTaskStatus_STATUS_DONE, Worker: "org1", FunctionKey: "trainFunction"},
+			{TaskKey: "predict", ComputePlanKey: "cp", Status: asset.ComputeTaskStatus_STATUS_EXECUTING, Rank: 1, Worker: "org1", FunctionKey: "predictFunction"},
+			{TaskKey: "external", ComputePlanKey: "otherCp", Status: asset.ComputeTaskStatus_STATUS_DONE},
+			{TaskKey: "otherExternal", ComputePlanKey: "otherCp", Status: asset.ComputeTaskStatus_STATUS_EXECUTING},
+		},
+		Edges: []*asset.ComputePlanGraphEdge{
+			{ParentTaskKey: "external", ParentTaskOutputIdentifier: "model", ChildTaskKey: "train", ChildTaskInputIdentifier: "model"},
+			{ParentTaskKey: "train", ParentTaskOutputIdentifier: "model", ChildTaskKey: "predict", ChildTaskInputIdentifier: "model"},
+			{ParentTaskKey: "train", ParentTaskOutputIdentifier: "shared", ChildTaskKey: "predict", ChildTaskInputIdentifier: "shared"},
+			{ParentTaskKey: "otherExternal", ParentTaskOutputIdentifier: "metrics", ChildTaskKey: "predict", ChildTaskInputIdentifier: "metrics"},
+		},
+	}
+	assert.Equal(t, expected, graph)
+
+	dbal.AssertExpectations(t)
+	cts.AssertExpectations(t)
+}
+
+func TestGetPlanGraphUnknownPlan(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	provider := newMockedProvider()
+	provider.On("GetComputePlanDBAL").Return(dbal)
+
+	service := NewComputePlanService(provider)
+
+	dbal.On("ComputePlanExists", "uuid").Once().Return(false, nil)
+
+	_, err := service.GetPlanGraph("uuid")
+	orcError := new(orcerrors.OrcError)
+	assert.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrNotFound, orcError.Kind)
+
+	dbal.AssertExpectations(t)
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/substra/orchestrator/lib/asset"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// finalTaskStatuses lists the statuses of the tasks which can be purged.
+var finalTaskStatuses = []asset.ComputeTaskStatus{
+	asset.ComputeTaskStatus_STATUS_DONE,
+	asset.ComputeTaskStatus_STATUS_FAILED,
+	asset.ComputeTaskStatus_STATUS_CANCELED,
+}
+
+// PlanArchiver receives the archive of a compute plan before it is purged, in the same transaction.
+type PlanArchiver func(archive *asset.ComputePlanArchive) error
+
+// ArchivePlan exports the compute plan along with its tasks and every related asset.
+// It is meant to be called before purging the plan, to keep a copy of what will be removed.
+func (s *ComputePlanService) ArchivePlan(key string, requester string) (*asset.ComputePlanArchive, error) {
+	s.GetLogger().Debug().Str("key", key).Str("requester", requester).Msg("Archiving compute plan")
+
+	plan, err := s.GetPlan(key)
+	if err != nil {
+		return nil, err
+	}
+	if requester != plan.Owner {
+		return nil, orcerrors.NewPermissionDenied("only plan owner can archive it")
+	}
+
+	return s.GetComputePlanDBAL().GetComputePlanArchive(key)
+}
+
+// PurgePlan removes the tasks of a terminated compute plan and every related asset.
+// The compute plan is kept as a tombstone recording what has been removed.
+func (s *ComputePlanService) PurgePlan(key string, requester string) (*asset.ComputePlan, error) {
+	s.GetLogger().Debug().Str("key", key).Str("requester", requester).Msg("Purging compute plan")
+
+	plan, err := s.GetPlan(key)
+	if err != nil {
+		return nil, err
+	}
+	if requester != plan.Owner {
+		return nil, orcerrors.NewPermissionDenied("only plan owner can purge it")
+	}
+
+	err = s.purgePlan(plan, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// GetExpiredPlanKeys returns the keys of the compute plans terminated for longer than the retention duration.
+func (s *ComputePlanService) GetExpiredPlanKeys(retention time.Duration) ([]string, error) {
+	if retention <= 0 {
+		return []string{}, nil
+	}
+
+	terminatedBefore := s.GetTimeService().GetTransactionTime().Add(-retention)
+	return s.GetComputePlanDBAL().GetPurgeableComputePlanKeys(terminatedBefore)
+}
+
+// PurgeExpiredPlan purges an expired compute plan, see GetExpiredPlanKeys.
+// Plans which cannot be purged, eg: because other plans depend on them, are skipped.
+// When an archiver is given, the archive of the plan is passed to it before the plan is purged.
+// It returns whether the plan has been purged.
+func (s *ComputePlanService) PurgeExpiredPlan(key string, archiver PlanArchiver) (bool, error) {
+	plan, err := s.GetPlan(key)
+	if err != nil {
+		return false, err
+	}
+
+	err = s.purgePlan(plan, archiver)
+	orcErr := new(orcerrors.OrcError)
+	if errors.As(err, &orcErr) && orcErr.Kind == orcerrors.ErrBadRequest {
+		s.GetLogger().Info().Err(err).Str("computePlanKey", key).Msg("skipping expired compute plan")
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// purgePlan checks that the compute plan can be purged, deletes its tasks and dispatches the tombstone.
+// The plan is updated in place with its purge date and summary.
+func (s *ComputePlanService) purgePlan(plan *asset.ComputePlan, archiver PlanArchiver) error {
+	if plan.IsPurged() {
+		return orcerrors.NewBadRequest(fmt.Sprintf("compute plan %s is already purged", plan.Key))
+	}
+
+	stats, err := s.GetComputePlanDBAL().GetComputePlanStatistics(plan.Key)
+	if err != nil {
+		return err
+	}
+
+	status := getPlanStatus(plan, stats)
+	if !isPlanPurgeable(status, stats) {
+		return orcerrors.NewBadRequest(fmt.Sprintf("cannot purge compute plan %s before all its tasks are terminated, status is %s", plan.Key, status.String()))
+	}
+
+	referenced, err := s.GetComputePlanDBAL().IsComputePlanReferenced(plan.Key)
+	if err != nil {
+		return err
+	}
+	if referenced {
+		return orcerrors.NewBadRequest(fmt.Sprintf("cannot purge compute plan %s: tasks of other compute plans depend on it", plan.Key))
+	}
+
+	if archiver != nil {
+		archive, err := s.GetComputePlanDBAL().GetComputePlanArchive(plan.Key)
+		if err != nil {
+			return err
+		}
+		err = archiver(archive)
+		if err != nil {
+			return err
+		}
+	}
+
+	purgeDate := s.GetTimeService().GetTransactionTime()
+	summary, err := s.GetComputePlanDBAL().PurgeComputePlan(plan.Key, purgeDate)
+	if err != nil {
+		return err
+	}
+	s.GetAssetCache().forgetPlan(plan.Key)
+
+	plan.PurgeDate = timestamppb.New(purgeDate)
+	plan.PurgeSummary = summary
+
+	event := &asset.Event{
+		AssetKey:  plan.Key,
+		EventKind: asset.EventKind_EVENT_ASSET_UPDATED,
+		AssetKind: asset.AssetKind_ASSET_COMPUTE_PLAN,
+		Asset:     &asset.Event_ComputePlan{ComputePlan: plan},
+	}
+
+	return s.GetEventService().RegisterEvents(event)
+}
+
+// isPlanPurgeable returns true if the plan is terminated and none of its tasks may still be executed.
+func isPlanPurgeable(status asset.ComputePlanStatus, stats *asset.ComputePlanStatistics) bool {
+	switch status {
+	case asset.ComputePlanStatus_PLAN_STATUS_DONE,
+		asset.ComputePlanStatus_PLAN_STATUS_FAILED,
+		asset.ComputePlanStatus_PLAN_STATUS_CANCELED:
+	default:
+		return false
+	}
+
+	var finalCount uint32
+	for _, c := range stats.TaskCounts {
+		for _, final := range finalTaskStatuses {
+			if c.Status == final {
+				finalCount += c.Count
+			}
+		}
+	}
+
+	return finalCount == stats.TaskCount
+}
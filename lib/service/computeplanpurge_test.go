@@ -0,0 +1,248 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/substra/orchestrator/lib/asset"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"github.com/substra/orchestrator/lib/persistence"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestArchivePlan(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	provider := newMockedProvider()
+	provider.On("GetComputePlanDBAL").Return(dbal)
+
+	archive := &asset.ComputePlanArchive{ComputePlan: &asset.ComputePlan{Key: "uuid", Owner: "owner"}}
+
+	dbal.On("GetComputePlan", "uuid").Twice().Return(&asset.ComputePlan{Key: "uuid", Owner: "owner"}, nil)
+	dbal.On("GetComputePlanArchive", "uuid").Once().Return(archive, nil)
+
+	service := NewComputePlanService(provider)
+
+	res, err := service.ArchivePlan("uuid", "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, archive, res)
+
+	_, err = service.ArchivePlan("uuid", "other")
+	orcError := new(orcerrors.OrcError)
+	assert.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrPermissionDenied, orcError.Kind)
+
+	dbal.AssertExpectations(t)
+}
+
+func TestPurgePlan(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	es := new(MockEventAPI)
+	ts := new(MockTimeAPI)
+	provider := newMockedProvider()
+	provider.On("GetComputePlanDBAL").Return(dbal)
+	provider.On("GetEventService").Return(es)
+	provider.On("GetTimeService").Return(ts)
+
+	plan := &asset.ComputePlan{Key: "uuid", Owner: "owner"}
+	stats := &asset.ComputePlanStatistics{
+		TaskCount:  2,
+		TaskCounts: []*asset.TaskStatusCount{{Status: asset.ComputeTaskStatus_STATUS_DONE, Count: 2}},
+	}
+	summary := &asset.ComputePlanPurgeSummary{TaskCount: 2, EventCount: 6}
+
+	dbal.On("GetComputePlan", "uuid").Once().Return(plan, nil)
+	dbal.On("GetComputePlanStatistics", "uuid").Once().Return(stats, nil)
+	dbal.On("IsComputePlanReferenced", "uuid").Once().Return(false, nil)
+	ts.On("GetTransactionTime").Once().Return(time.Unix(1337, 0))
+	dbal.On("PurgeComputePlan", "uuid", time.Unix(1337, 0)).Once().Return(summary, nil)
+
+	expectedEvent := &asset.Event{
+		AssetKey:  "uuid",
+		EventKind: asset.EventKind_EVENT_ASSET_UPDATED,
+		AssetKind: asset.AssetKind_ASSET_COMPUTE_PLAN,
+		Asset: &asset.Event_ComputePlan{ComputePlan: &asset.ComputePlan{
+			Key:          "uuid",
+			Owner:        "owner",
+			PurgeDate:    timestamppb.New(time.Unix(1337, 0)),
+			PurgeSummary: summary,
+		}},
+	}
+	es.On("RegisterEvents", expectedEvent).Once().Return(nil)
+
+	service := NewComputePlanService(provider)
+
+	res, err := service.PurgePlan("uuid", "owner")
+	assert.NoError(t, err)
+	assert.True(t, res.IsPurged())
+	assert.Equal(t, summary, res.PurgeSummary)
+
+	dbal.AssertExpectations(t)
+	es.AssertExpectations(t)
+	ts.AssertExpectations(t)
+}
+
+func TestPurgePlanRejected(t *testing.T) {
+	doneStats := &asset.ComputePlanStatistics{
+		TaskCount:  1,
+		TaskCounts: []*asset.TaskStatusCount{{Status: asset.ComputeTaskStatus_STATUS_DONE, Count: 1}},
+	}
+
+	cases := map[string]struct {
+		plan       *asset.ComputePlan
+		requester  string
+		stats      *asset.ComputePlanStatistics
+		referenced bool
+		errorKind  string
+	}{
+		"not the owner": {
+			plan:      &asset.ComputePlan{Key: "uuid", Owner: "owner"},
+			requester: "other",
+			errorKind: orcerrors.ErrPermissionDenied,
+		},
+		"already purged": {
+			plan:      &asset.ComputePlan{Key: "uuid", Owner: "owner", PurgeDate: timestamppb.New(time.Unix(1337, 0))},
+			requester: "owner",
+			errorKind: orcerrors.ErrBadRequest,
+		},
+		"running": {
+			plan:      &asset.ComputePlan{Key: "uuid", Owner: "owner"},
+			requester: "owner",
+			stats: &asset.ComputePlanStatistics{
+				TaskCount:  1,
+				TaskCounts: []*asset.TaskStatusCount{{Status: asset.ComputeTaskStatus_STATUS_EXECUTING, Count: 1}},
+			},
+			errorKind: orcerrors.ErrBadRequest,
+		},
+		"failed with executing tasks": {
+			plan:      &asset.ComputePlan{Key: "uuid", Owner: "owner", FailureDate: timestamppb.New(time.Unix(1337, 0))},
+			requester: "owner",
+			stats: &asset.ComputePlanStatistics{
+				TaskCount: 2,
+				TaskCounts: []*asset.TaskStatusCount{
+					{Status: asset.ComputeTaskStatus_STATUS_FAILED, Count: 1},
+					{Status: asset.ComputeTaskStatus_STATUS_EXECUTING, Count: 1},
+				},
+			},
+			errorKind: orcerrors.ErrBadRequest,
+		},
+		"referenced": {
+			plan:       &asset.ComputePlan{Key: "uuid", Owner: "owner"},
+			requester:  "owner",
+			stats:      doneStats,
+			referenced: true,
+			errorKind:  orcerrors.ErrBadRequest,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			dbal := new(persistence.MockDBAL)
+			provider := newMockedProvider()
+			provider.On("GetComputePlanDBAL").Return(dbal)
+
+			dbal.On("GetComputePlan", "uuid").Once().Return(c.plan, nil)
+			if c.stats != nil {
+				dbal.On("GetComputePlanStatistics", "uuid").Once().Return(c.stats, nil)
+			}
+			if c.referenced {
+				dbal.On("IsComputePlanReferenced", "uuid").Once().Return(true, nil)
+			}
+
+			service := NewComputePlanService(provider)
+
+			_, err := service.PurgePlan("uuid", c.requester)
+			orcError := new(orcerrors.OrcError)
+			assert.True(t, errors.As(err, &orcError))
+			assert.Equal(t, c.errorKind, orcError.Kind)
+
+			dbal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetExpiredPlanKeys(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	ts := new(MockTimeAPI)
+	provider := newMockedProvider()
+	provider.On("GetComputePlanDBAL").Return(dbal)
+	provider.On("GetTimeService").Return(ts)
+
+	now := time.Unix(100*24*3600, 0)
+	retention := 30 * 24 * time.Hour
+
+	ts.On("GetTransactionTime").Return(now)
+	dbal.On("GetPurgeableComputePlanKeys", now.Add(-retention)).Once().Return([]string{"cp1", "cp2"}, nil)
+
+	service := NewComputePlanService(provider)
+
+	keys, err := service.GetExpiredPlanKeys(retention)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cp1", "cp2"}, keys)
+
+	dbal.AssertExpectations(t)
+}
+
+func TestGetExpiredPlanKeysWithoutRetention(t *testing.T) {
+	provider := newMockedProvider()
+	service := NewComputePlanService(provider)
+
+	keys, err := service.GetExpiredPlanKeys(0)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	provider.AssertExpectations(t)
+}
+
+func TestPurgeExpiredPlan(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	es := new(MockEventAPI)
+	ts := new(MockTimeAPI)
+	provider := newMockedProvider()
+	provider.On("GetComputePlanDBAL").Return(dbal)
+	provider.On("GetEventService").Return(es)
+	provider.On("GetTimeService").Return(ts)
+
+	now := time.Unix(100*24*3600, 0)
+	doneStats := &asset.ComputePlanStatistics{
+		TaskCount:  1,
+		TaskCounts: []*asset.TaskStatusCount{{Status: asset.ComputeTaskStatus_STATUS_DONE, Count: 1}},
+	}
+
+	ts.On("GetTransactionTime").Return(now)
+
+	// cp1 is a dependency of another plan and is kept
+	dbal.On("GetComputePlan", "cp1").Once().Return(&asset.ComputePlan{Key: "cp1"}, nil)
+	dbal.On("GetComputePlanStatistics", "cp1").Once().Return(doneStats, nil)
+	dbal.On("IsComputePlanReferenced", "cp1").Once().Return(true, nil)
+
+	archive := &asset.ComputePlanArchive{ComputePlan: &asset.ComputePlan{Key: "cp2"}}
+	dbal.On("GetComputePlan", "cp2").Once().Return(&asset.ComputePlan{Key: "cp2"}, nil)
+	dbal.On("GetComputePlanStatistics", "cp2").Once().Return(doneStats, nil)
+	dbal.On("IsComputePlanReferenced", "cp2").Once().Return(false, nil)
+	dbal.On("GetComputePlanArchive", "cp2").Once().Return(archive, nil)
+	dbal.On("PurgeComputePlan", "cp2", now).Once().Return(&asset.ComputePlanPurgeSummary{TaskCount: 1}, nil)
+	es.On("RegisterEvents", mock.Anything).Once().Return(nil)
+
+	archived := []*asset.ComputePlanArchive{}
+	archiver := func(a *asset.ComputePlanArchive) error {
+		archived = append(archived, a)
+		return nil
+	}
+
+	service := NewComputePlanService(provider)
+
+	purged, err := service.PurgeExpiredPlan("cp1", archiver)
+	assert.NoError(t, err)
+	assert.False(t, purged)
+
+	purged, err = service.PurgeExpiredPlan("cp2", archiver)
+	assert.NoError(t, err)
+	assert.True(t, purged)
+	assert.Equal(t, []*asset.ComputePlanArchive{archive}, archived)
+
+	dbal.AssertExpectations(t)
+	es.AssertExpectations(t)
+}
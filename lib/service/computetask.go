@@ -2,6 +2,8 @@ package service
 
 import (
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/substra/orchestrator/lib/asset"
 	"github.com/substra/orchestrator/lib/common"
@@ -29,7 +31,7 @@ type namedFunctionOutputs = map[string]*asset.FunctionOutput
 type ComputeTaskAPI interface {
 	RegisterTasks(tasks []*asset.NewComputeTask, owner string) ([]*asset.ComputeTask, error)
 	GetTask(key string) (*asset.ComputeTask, error)
-	QueryTasks(p *common.Pagination, filter *asset.TaskQueryFilter) ([]*asset.ComputeTask, common.PaginationToken, error)
+	QueryTasks(p *common.Pagination, filter *asset.TaskQueryFilter, sortBy asset.TaskSortField, sortOrder asset.SortOrder) ([]*asset.ComputeTask, common.PaginationToken, error)
 	ApplyTaskAction(key string, action asset.ComputeTaskAction, reason string, requester string) error
 	GetInputAssets(key string) ([]*asset.ComputeTaskInputAsset, error)
 	DisableOutput(taskKey string, identifier string, requester string) error
@@ -40,6 +42,12 @@ type ComputeTaskAPI interface {
 	PropagateActionFromFunction(functionKey string, action asset.ComputeTaskAction, reason string, requester string) error
 	GetTasksByFunction(functionKey string, statuses []asset.ComputeTaskStatus) ([]*asset.ComputeTask, error)
 	StartDependentTask(child *asset.ComputeTask, reason string) error
+	resumePlanTasks(planKey string) error
+	getPlanTasks(planKey string) ([]*asset.ComputeTask, error)
+	getTasks(keys []string) ([]*asset.ComputeTask, error)
+	Heartbeat(key string, requester string) (time.Time, error)
+	GetExpiredTaskKeys() ([]string, error)
+	FailExpiredTask(key string) (bool, error)
 }
 
 // ComputeTaskServiceProvider defines an object able to provide a ComputeTaskAPI instance
@@ -61,36 +69,38 @@ type ComputeTaskDependencyProvider interface {
 	ComputePlanServiceProvider
 	ModelServiceProvider
 	TimeServiceProvider
+	FailureReportServiceProvider
+	TaskLeaseProvider
+	AssetCacheProvider
 }
 
 // ComputeTaskService is the compute task manipulation entry point
 type ComputeTaskService struct {
 	ComputeTaskDependencyProvider
-	// Keep a local cache of functions, plans and tasks to be used in batch import
-	functionStore    map[string]*asset.Function
-	taskStore        map[string]*asset.ComputeTask
-	planStore        map[string]*asset.ComputePlan
-	dataManagerStore map[string]*asset.DataManager
-	orgStore         map[string]*asset.Organization
+	// Keep a local cache of tasks to be used in batch import,
+	// other assets are cached by the provider, see AssetCacheAPI.
+	taskStore map[string]*asset.ComputeTask
 }
 
 // NewComputeTaskService creates a new service
 func NewComputeTaskService(provider ComputeTaskDependencyProvider) *ComputeTaskService {
 	return &ComputeTaskService{
 		ComputeTaskDependencyProvider: provider,
-		functionStore:                 make(map[string]*asset.Function),
 		taskStore:                     make(map[string]*asset.ComputeTask),
-		planStore:                     make(map[string]*asset.ComputePlan),
-		dataManagerStore:              make(map[string]*asset.DataManager),
-		orgStore:                      make(map[string]*asset.Organization),
 	}
 }
 
 // QueryTasks returns tasks matching filter
-func (s *ComputeTaskService) QueryTasks(p *common.Pagination, filter *asset.TaskQueryFilter) ([]*asset.ComputeTask, common.PaginationToken, error) {
-	s.GetLogger().Debug().Interface("pagination", p).Interface("filter", filter).Msg("Querying ComputeTasks")
+func (s *ComputeTaskService) QueryTasks(p *common.Pagination, filter *asset.TaskQueryFilter, sortBy asset.TaskSortField, sortOrder asset.SortOrder) ([]*asset.ComputeTask, common.PaginationToken, error) {
+	s.GetLogger().Debug().Interface("pagination", p).Interface("filter", filter).Str("sortBy", sortBy.String()).Msg("Querying ComputeTasks")
 
-	return s.GetComputeTaskDBAL().QueryComputeTasks(p, filter)
+	if filter != nil {
+		if err := filter.Validate(); err != nil {
+			return nil, "", orcerrors.FromValidationError("task filter", err)
+		}
+	}
+
+	return s.GetComputeTaskDBAL().QueryComputeTasks(p, filter, sortBy, sortOrder)
 }
 
 // GetTask return a single task
@@ -107,12 +117,17 @@ func (s *ComputeTaskService) RegisterTasks(tasks []*asset.NewComputeTask, owner
 		return nil, orcerrors.NewBadRequest("no task to register")
 	}
 
-	for _, newTask := range tasks {
+	// Collect every invalid task so that they can be fixed at once
+	invalidFields := []*orcerrors.FieldError{}
+	for i, newTask := range tasks {
 		err := newTask.Validate()
 		if err != nil {
-			return nil, orcerrors.FromValidationError(asset.ComputeTaskKind, err)
+			invalidFields = append(invalidFields, orcerrors.NewFieldErrors(i, newTask.Key, err)...)
 		}
 	}
+	if len(invalidFields) > 0 {
+		return nil, orcerrors.FromValidationErrors(asset.ComputeTaskKind, invalidFields)
+	}
 
 	existingKeys, err := s.getExistingKeys(tasks)
 	if err != nil {
@@ -127,18 +142,48 @@ func (s *ComputeTaskService) RegisterTasks(tasks []*asset.NewComputeTask, owner
 		return nil, err
 	}
 
-	sortedTasks, err := s.sortTasks(tasks, existingParentKeys)
-	if err != nil {
-		return nil, err
+	indexes := make(map[string]int, len(tasks))
+	for i, newTask := range tasks {
+		indexes[newTask.Key] = i
+	}
+
+	sortedTasks, unsortedTasks := s.sortTasks(tasks, existingParentKeys)
+	for _, newTask := range unsortedTasks {
+		invalidFields = append(invalidFields, &orcerrors.FieldError{
+			Index:    indexes[newTask.Key],
+			AssetKey: newTask.Key,
+			Field:    "inputs",
+			Kind:     orcerrors.ErrInvalidAsset,
+			Msg:      "cyclic dependency in compute plan graph or unknown task parent",
+		})
 	}
 
 	registeredTasks := []*asset.ComputeTask{}
 	events := []*asset.Event{}
+	invalidTasks := make(map[string]struct{})
 
 	for _, newTask := range sortedTasks {
+		if parent, ok := firstInvalidParent(newTask, invalidTasks); ok {
+			// The task cannot be checked without its parent, report it instead of a misleading error
+			invalidTasks[newTask.Key] = struct{}{}
+			invalidFields = append(invalidFields, &orcerrors.FieldError{
+				Index:    indexes[newTask.Key],
+				AssetKey: newTask.Key,
+				Field:    "inputs",
+				Kind:     orcerrors.ErrInvalidAsset,
+				Msg:      fmt.Sprintf("parent task %q is not valid", parent),
+			})
+			continue
+		}
+
 		task, err := s.createTask(newTask, owner)
 		if err != nil {
-			return nil, err
+			if !orcerrors.IsAssetError(err) {
+				return nil, err
+			}
+			invalidTasks[newTask.Key] = struct{}{}
+			invalidFields = append(invalidFields, orcerrors.NewFieldErrors(indexes[newTask.Key], newTask.Key, err)...)
+			continue
 		}
 		metrics.TaskRegisteredTotal.WithLabelValues(s.GetChannel()).Inc()
 		registeredTasks = append(registeredTasks, task)
@@ -153,6 +198,11 @@ func (s *ComputeTaskService) RegisterTasks(tasks []*asset.NewComputeTask, owner
 
 	}
 
+	if len(invalidFields) > 0 {
+		sort.SliceStable(invalidFields, func(i, j int) bool { return invalidFields[i].Index < invalidFields[j].Index })
+		return nil, orcerrors.FromValidationErrors(asset.ComputeTaskKind, invalidFields)
+	}
+
 	err = s.GetComputeTaskDBAL().AddComputeTasks(registeredTasks...)
 	if err != nil {
 		return nil, err
@@ -181,7 +231,7 @@ func (s *ComputeTaskService) GetInputAssets(key string) ([]*asset.ComputeTaskInp
 	}
 
 	inputAssets := make([]*asset.ComputeTaskInputAsset, 0, len(task.Inputs))
-	function, err := s.GetFunctionService().GetFunction(task.FunctionKey)
+	function, err := s.GetAssetCache().GetFunction(task.FunctionKey)
 	if err != nil {
 		return nil, err
 	}
@@ -235,7 +285,7 @@ func (s *ComputeTaskService) DisableOutput(taskKey string, identifier string, re
 	}
 
 	state := newState(&dumbUpdater, task)
-	if len(state.AvailableTransitions()) > 0 {
+	if !isFinalState(state) {
 		return orcerrors.NewCannotDisableAsset("cannot disable asset: task not in final state")
 	}
 
@@ -280,7 +330,7 @@ func (s *ComputeTaskService) DisableOutput(taskKey string, identifier string, re
 
 	for _, child := range children {
 		state := newState(&dumbUpdater, child)
-		if len(state.AvailableTransitions()) > 0 {
+		if !isFinalState(state) {
 			return orcerrors.NewCannotDisableAsset("cannot disable asset: child not in final state")
 		}
 	}
@@ -297,10 +347,10 @@ func (s *ComputeTaskService) DisableOutput(taskKey string, identifier string, re
 // sortTasks is a function to sort a list of tasks in a valid order for their registration.
 // It performs a topological sort of the tasks such that for every dependency from task A to B
 // A comes before B in the resulting list of tasks.
-// A topological ordering is possible only if the graph is a DAG and has no cycles. This function will
-// raise an error if there is a cycle in the list of tasks.
+// A topological ordering is possible only if the graph is a DAG and has no cycles. Tasks which cannot be
+// sorted, because they belong to a cycle or depend on an unknown parent, are returned in the unsorted list.
 // This sorting function is based on Kahn's algorithm.
-func (s *ComputeTaskService) sortTasks(newTasks []*asset.NewComputeTask, existingTasks []string) ([]*asset.NewComputeTask, error) {
+func (s *ComputeTaskService) sortTasks(newTasks []*asset.NewComputeTask, existingTasks []string) (sorted []*asset.NewComputeTask, unsorted []*asset.NewComputeTask) {
 	sortedTasks := make([]*asset.NewComputeTask, len(newTasks))
 	unsortedTasks := make([]*asset.NewComputeTask, len(newTasks))
 	copy(unsortedTasks, newTasks)
@@ -355,16 +405,25 @@ func (s *ComputeTaskService) sortTasks(newTasks []*asset.NewComputeTask, existin
 			Int("unsortedTasks", len(unsortedTasks)).
 			Int("existingTasks", len(existingTasks)).
 			Msg("Failed to sort tasks, cyclic dependency in compute plan graph or unknown parent")
-		return nil, orcerrors.NewInvalidAsset(fmt.Sprintf("cyclic dependency in compute plan graph or unknown task parent, unsorted_tasks_count: %d", len(unsortedTasks)))
 	}
 
-	return sortedTasks, nil
+	return sortedTasks[:sortedTasksCount], unsortedTasks
+}
+
+// firstInvalidParent returns the first parent of the task which belongs to the given invalid tasks.
+func firstInvalidParent(task *asset.NewComputeTask, invalidTasks map[string]struct{}) (string, bool) {
+	for _, parent := range GetParentTaskKeys(task.Inputs) {
+		if _, ok := invalidTasks[parent]; ok {
+			return parent, true
+		}
+	}
+	return "", false
 }
 
 // createTask converts a NewComputeTask into a ComputeTask.
 // It does not persist nor dispatch events.
 func (s *ComputeTaskService) createTask(input *asset.NewComputeTask, owner string) (*asset.ComputeTask, error) {
-	computePlan, err := s.getCachedCP(input.ComputePlanKey)
+	computePlan, err := s.GetAssetCache().GetPlan(input.ComputePlanKey)
 	if err != nil {
 		return nil, err
 	}
@@ -373,6 +432,10 @@ func (s *ComputeTaskService) createTask(input *asset.NewComputeTask, owner strin
 		return nil, orcerrors.NewPermissionDenied("Cannot register tasks to a compute plan you don't own")
 	}
 
+	if computePlan.IsPurged() {
+		return nil, orcerrors.NewTerminatedComputePlan(computePlan.Key)
+	}
+
 	parentTasks, err := s.getRegisteredTasks(GetParentTaskKeys(input.Inputs)...)
 	if err != nil {
 		return nil, err
@@ -385,6 +448,16 @@ func (s *ComputeTaskService) createTask(input *asset.NewComputeTask, owner strin
 
 	status := getInitialStatus(parentTasks, function)
 
+	// Tasks of a paused compute plan are held until the plan is resumed
+	if computePlan.IsPaused() {
+		switch status {
+		case asset.ComputeTaskStatus_STATUS_BUILDING:
+			status = asset.ComputeTaskStatus_STATUS_WAITING_FOR_BUILDER_SLOT
+		case asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT:
+			status = asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS
+		}
+	}
+
 	if err := s.allModelsAvailable(parentTasks); err != nil {
 		return nil, err
 	}
@@ -406,7 +479,7 @@ func (s *ComputeTaskService) createTask(input *asset.NewComputeTask, owner strin
 		return nil, err
 	}
 	// Make sure the organization exists
-	_, err = s.getCachedOrganization(worker)
+	_, err = s.GetAssetCache().GetOrganization(worker)
 	if err != nil {
 		return nil, err
 	}
@@ -428,6 +501,7 @@ func (s *ComputeTaskService) createTask(input *asset.NewComputeTask, owner strin
 		Outputs:        outputs,
 		Worker:         worker,
 		LogsPermission: logsPermissions,
+		Attempt:        1,
 	}
 
 	if err := s.validateInputs(task.Inputs, function.Inputs, task.Owner, task.Worker); err != nil {
@@ -462,7 +536,7 @@ func (s *ComputeTaskService) addComputeTaskOutputAsset(output *asset.ComputeTask
 // getCheckedFunction returns the Function identified by given key,
 // it will return an error if the function is not processable by the owner.
 func (s *ComputeTaskService) getCheckedFunction(functionKey string, owner string) (*asset.Function, error) {
-	function, err := s.getCachedFunction(functionKey)
+	function, err := s.GetAssetCache().GetFunction(functionKey)
 	if err != nil {
 		return nil, err
 	}
@@ -552,7 +626,7 @@ func (s *ComputeTaskService) validateDataManagerInput(dataManagerInput *asset.Co
 	}
 
 	// Check permissions + check datasamples are compatible with data manager
-	datamanager, err := s.getCachedDataManager(dmKey)
+	datamanager, err := s.GetAssetCache().GetDataManager(dmKey)
 	if err != nil {
 		return err
 	}
@@ -586,7 +660,7 @@ func (s *ComputeTaskService) validateInputRef(functionInput *asset.FunctionInput
 			return err
 		}
 
-		parentTaskFunction, err := s.getCachedFunction(parentTask.FunctionKey)
+		parentTaskFunction, err := s.GetAssetCache().GetFunction(parentTask.FunctionKey)
 		if err != nil {
 			return err
 		}
@@ -740,55 +814,6 @@ func (s *ComputeTaskService) allModelsAvailable(parents []*asset.ComputeTask) er
 	return nil
 }
 
-// getCachedFunction returns a cached version of an function
-// we cache the result to avoid multiple dbal queries on batch registration
-func (s *ComputeTaskService) getCachedFunction(functionKey string) (*asset.Function, error) {
-	if _, ok := s.functionStore[functionKey]; !ok {
-		function, err := s.GetFunctionService().GetFunction(functionKey)
-		if err != nil {
-			return nil, err
-		}
-		s.functionStore[functionKey] = function
-	}
-	function := s.functionStore[functionKey]
-	return function, nil
-}
-
-// getCachedCP returns a cached version of a compute plan
-// we cache the result to avoid multiple dbal queries on batch registration
-func (s *ComputeTaskService) getCachedCP(key string) (*asset.ComputePlan, error) {
-	if _, ok := s.planStore[key]; !ok {
-		plan, err := s.GetComputePlanService().GetPlan(key)
-		if err != nil {
-			return nil, err
-		}
-		s.planStore[key] = plan
-	}
-	return s.planStore[key], nil
-}
-
-func (s *ComputeTaskService) getCachedDataManager(key string) (*asset.DataManager, error) {
-	if _, ok := s.dataManagerStore[key]; !ok {
-		dm, err := s.GetDataManagerService().GetDataManager(key)
-		if err != nil {
-			return nil, err
-		}
-		s.dataManagerStore[key] = dm
-	}
-	return s.dataManagerStore[key], nil
-}
-
-func (s *ComputeTaskService) getCachedOrganization(key string) (*asset.Organization, error) {
-	if _, ok := s.orgStore[key]; !ok {
-		org, err := s.GetOrganizationService().GetOrganization(key)
-		if err != nil {
-			return nil, err
-		}
-		s.orgStore[key] = org
-	}
-	return s.orgStore[key], nil
-}
-
 // getInputAsset returns an input asset with the appropriate requested asset kind
 func (s *ComputeTaskService) getInputAsset(kind asset.AssetKind, key, identifier string) (*asset.ComputeTaskInputAsset, error) {
 	inputAsset := &asset.ComputeTaskInputAsset{
@@ -804,7 +829,7 @@ func (s *ComputeTaskService) getInputAsset(kind asset.AssetKind, key, identifier
 		inputAsset.Asset = &asset.ComputeTaskInputAsset_Model{Model: model}
 		return inputAsset, nil
 	case asset.AssetKind_ASSET_DATA_MANAGER:
-		manager, err := s.GetDataManagerService().GetDataManager(key)
+		manager, err := s.GetAssetCache().GetDataManager(key)
 		if err != nil {
 			return nil, err
 		}
@@ -835,7 +860,7 @@ func (s *ComputeTaskService) getTaskWorker(input *asset.NewComputeTask, function
 			continue
 		}
 
-		dm, err := s.getCachedDataManager(taskInput.GetAssetKey())
+		dm, err := s.GetAssetCache().GetDataManager(taskInput.GetAssetKey())
 		if err != nil {
 			return "", err
 		}
@@ -869,7 +894,7 @@ func (s *ComputeTaskService) getLogsPermission(owner string, parentTasks []*asse
 			if dmKey == "" {
 				return nil, orcerrors.NewInvalidAsset(fmt.Sprintf("invalid task input %q: openers must be referenced using an asset key", taskInput.Identifier))
 			}
-			datamanager, err := s.getCachedDataManager(dmKey)
+			datamanager, err := s.GetAssetCache().GetDataManager(dmKey)
 			if err != nil {
 				return nil, err
 			}
@@ -930,6 +955,35 @@ func GetParentTaskKeys(inputs []*asset.ComputeTaskInput) []string {
 	return parentKeys
 }
 
+// getTasks returns the tasks matching the keys, in the same order as the keys.
+func (s *ComputeTaskService) getTasks(keys []string) ([]*asset.ComputeTask, error) {
+	tasks, err := s.GetComputeTaskDBAL().GetComputeTasks(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*asset.ComputeTask, len(tasks))
+	for _, task := range tasks {
+		byKey[task.Key] = task
+	}
+
+	ordered := make([]*asset.ComputeTask, 0, len(keys))
+	for _, key := range keys {
+		task, ok := byKey[key]
+		if !ok {
+			return nil, orcerrors.NewNotFound(asset.ComputeTaskKind, key)
+		}
+		ordered = append(ordered, task)
+	}
+
+	return ordered, nil
+}
+
+// getPlanTasks returns all the tasks of a compute plan, with their inputs.
+func (s *ComputeTaskService) getPlanTasks(planKey string) ([]*asset.ComputeTask, error) {
+	return s.GetComputeTaskDBAL().GetComputePlanTasks(planKey)
+}
+
 func (s *ComputeTaskService) GetTasksByFunction(functionKey string, statuses []asset.ComputeTaskStatus) ([]*asset.ComputeTask, error) {
 	tasks, err := s.GetComputeTaskDBAL().GetFunctionFromTasksWithStatus(functionKey, statuses)
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/substra/orchestrator/lib/asset"
 	"github.com/substra/orchestrator/lib/common"
 	orcerrors "github.com/substra/orchestrator/lib/errors"
@@ -77,14 +78,39 @@ func TestQueryTasks(t *testing.T) {
 
 	returnedTasks := []*asset.ComputeTask{{}, {}}
 
-	dbal.On("QueryComputeTasks", pagination, filter).Once().Return(returnedTasks, "", nil)
+	dbal.On("QueryComputeTasks", pagination, filter, asset.TaskSortField_TASK_SORT_RANK, asset.SortOrder_DESCENDING).Once().Return(returnedTasks, "", nil)
 
-	tasks, _, err := service.QueryTasks(pagination, filter)
+	tasks, _, err := service.QueryTasks(pagination, filter, asset.TaskSortField_TASK_SORT_RANK, asset.SortOrder_DESCENDING)
 	assert.NoError(t, err)
 
 	assert.Len(t, tasks, 2)
 }
 
+func TestQueryTasksInvalidFilter(t *testing.T) {
+	rankMin := int32(3)
+	rankMax := int32(1)
+
+	cases := map[string]*asset.TaskQueryFilter{
+		"invalid key":         {Keys: []string{"not a uuid"}},
+		"inverted rank range": {RankMin: &rankMin, RankMax: &rankMax},
+		"inverted date range": {CreationDateStart: timestamppb.New(time.Unix(1338, 0)), CreationDateEnd: timestamppb.New(time.Unix(1337, 0))},
+	}
+
+	for name, filter := range cases {
+		t.Run(name, func(t *testing.T) {
+			provider := newMockedProvider()
+			service := NewComputeTaskService(provider)
+
+			_, _, err := service.QueryTasks(common.NewPagination("", 2), filter, asset.TaskSortField_TASK_SORT_CREATION_DATE, asset.SortOrder_ASCENDING)
+			orcError := new(orcerrors.OrcError)
+			assert.True(t, errors.As(err, &orcError))
+			assert.Equal(t, orcerrors.ErrInvalidAsset, orcError.Kind)
+
+			provider.AssertExpectations(t)
+		})
+	}
+}
+
 func TestRegisterMissingComputePlan(t *testing.T) {
 	dbal := new(persistence.MockDBAL)
 	cps := new(MockComputePlanAPI)
@@ -133,6 +159,58 @@ func TestRegisterTasksComputePlanOwnedBySomeoneElse(t *testing.T) {
 	provider.AssertExpectations(t)
 }
 
+func TestRegisterTasksCollectsErrors(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	cps := new(MockComputePlanAPI)
+	provider := newMockedProvider()
+
+	provider.On("GetComputeTaskDBAL").Return(dbal)
+	provider.On("GetComputePlanService").Return(cps)
+
+	service := NewComputeTaskService(provider)
+
+	parentRef := func(key string) []*asset.ComputeTaskInput {
+		return []*asset.ComputeTaskInput{
+			{Identifier: "model", Ref: &asset.ComputeTaskInput_ParentTaskOutput{
+				ParentTaskOutput: &asset.ParentTaskOutputRef{OutputIdentifier: "model", ParentTaskKey: key},
+			}},
+		}
+	}
+	child := &asset.NewComputeTask{
+		Key:            "aaaaaaaa-cccc-bbbb-eeee-111111111111",
+		FunctionKey:    newTrainTask.FunctionKey,
+		ComputePlanKey: newTrainTask.ComputePlanKey,
+		Inputs:         parentRef(newTrainTask.Key),
+	}
+	orphan := &asset.NewComputeTask{
+		Key:            "aaaaaaaa-cccc-bbbb-eeee-222222222222",
+		FunctionKey:    newTrainTask.FunctionKey,
+		ComputePlanKey: newTrainTask.ComputePlanKey,
+		Inputs:         parentRef("aaaaaaaa-cccc-bbbb-eeee-333333333333"),
+	}
+
+	dbal.On("GetExistingComputeTaskKeys", []string{orphan.Key, child.Key, newTrainTask.Key}).Once().Return([]string{}, nil)
+	dbal.On("GetExistingComputeTaskKeys", []string{"aaaaaaaa-cccc-bbbb-eeee-333333333333", newTrainTask.Key}).Once().Return([]string{}, nil)
+	cps.On("GetPlan", newTrainTask.ComputePlanKey).Once().Return(&asset.ComputePlan{Key: newTrainTask.ComputePlanKey, Owner: "not test"}, nil)
+
+	_, err := service.RegisterTasks([]*asset.NewComputeTask{orphan, child, newTrainTask}, "test")
+	orcError := new(orcerrors.OrcError)
+	require.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrInvalidAsset, orcError.Kind)
+
+	// Semantic errors are reported for every task, ordered by index
+	details := orcError.Details()
+	require.Len(t, details, 3)
+	assert.Equal(t, &orcerrors.FieldError{Index: 0, AssetKey: orphan.Key, Field: "inputs", Kind: orcerrors.ErrInvalidAsset, Msg: "cyclic dependency in compute plan graph or unknown task parent"}, details[0])
+	assert.Equal(t, &orcerrors.FieldError{Index: 1, AssetKey: child.Key, Field: "inputs", Kind: orcerrors.ErrInvalidAsset, Msg: fmt.Sprintf("parent task %q is not valid", newTrainTask.Key)}, details[1])
+	assert.Equal(t, 2, details[2].Index)
+	assert.Equal(t, orcerrors.ErrPermissionDenied, details[2].Kind)
+
+	dbal.AssertNotCalled(t, "AddComputeTasks", mock.Anything)
+	dbal.AssertExpectations(t)
+	cps.AssertExpectations(t)
+}
+
 func TestRegisterTaskConflict(t *testing.T) {
 	dbal := new(persistence.MockDBAL)
 	provider := newMockedProvider()
@@ -150,6 +228,31 @@ func TestRegisterTaskConflict(t *testing.T) {
 	provider.AssertExpectations(t)
 }
 
+func TestRegisterInvalidTasks(t *testing.T) {
+	provider := newMockedProvider()
+	service := NewComputeTaskService(provider)
+
+	tasks := []*asset.NewComputeTask{
+		{Key: "invalid", FunctionKey: newTrainTask.FunctionKey, ComputePlanKey: newTrainTask.ComputePlanKey},
+		newTrainTask,
+		{Key: "30bf4be4-d8b9-4e6a-9e58-07c23c6af06b", FunctionKey: newTrainTask.FunctionKey},
+	}
+
+	_, err := service.RegisterTasks(tasks, "test")
+	orcError := new(orcerrors.OrcError)
+	require.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrInvalidAsset, orcError.Kind)
+
+	// Every invalid task is reported, not only the first one
+	expected := []*orcerrors.FieldError{
+		{Index: 0, AssetKey: "invalid", Field: "key", Kind: orcerrors.ErrInvalidAsset, Msg: "must be a valid UUID"},
+		{Index: 2, AssetKey: "30bf4be4-d8b9-4e6a-9e58-07c23c6af06b", Field: "compute_plan_key", Kind: orcerrors.ErrInvalidAsset, Msg: "cannot be blank"},
+	}
+	assert.Equal(t, expected, orcError.Details())
+
+	provider.AssertExpectations(t)
+}
+
 func TestRegisterTrainTask(t *testing.T) {
 	dbal := new(persistence.MockDBAL)
 	es := new(MockEventAPI)
@@ -237,6 +340,7 @@ func TestRegisterTrainTask(t *testing.T) {
 		Outputs: map[string]*asset.ComputeTaskOutput{
 			"model": {Permissions: modelPerms},
 		},
+		Attempt: 1,
 	}
 
 	// finally store the created task
@@ -443,6 +547,7 @@ func TestRegisterCompositeTaskWithCompositeParents(t *testing.T) {
 			"shared": {Permissions: sharedPerms},
 			"local":  {Permissions: localPerms},
 		},
+		Attempt: 1,
 	}
 
 	// finally store the created task
@@ -1018,9 +1123,9 @@ func TestSortTasks(t *testing.T) {
 
 	provider := newMockedProvider()
 	service := NewComputeTaskService(provider)
-	result, err := service.sortTasks(nodes, existingKeys)
+	result, unsorted := service.sortTasks(nodes, existingKeys)
 
-	assert.NoError(t, err)
+	assert.Empty(t, unsorted)
 	assert.Equal(t, len(nodes), len(result))
 	assert.ElementsMatch(t, nodes, result)
 	assert.Equal(t, root, result[0])
@@ -1062,9 +1167,9 @@ func TestSortTasksWithCircularDependency(t *testing.T) {
 
 	provider := newMockedProvider()
 	service := NewComputeTaskService(provider)
-	_, err := service.sortTasks(nodes, existingKeys)
+	_, unsorted := service.sortTasks(nodes, existingKeys)
 
-	assert.Error(t, err)
+	assert.NotEmpty(t, unsorted)
 }
 
 func TestSortDependencyWithExistingTasks(t *testing.T) {
@@ -1113,9 +1218,9 @@ func TestSortDependencyWithExistingTasks(t *testing.T) {
 
 	provider := newMockedProvider()
 	service := NewComputeTaskService(provider)
-	result, err := service.sortTasks(nodes, existingKeys)
+	result, unsorted := service.sortTasks(nodes, existingKeys)
 
-	assert.NoError(t, err)
+	assert.Empty(t, unsorted)
 	assert.Equal(t, len(nodes), len(result))
 	assert.ElementsMatch(t, nodes, result)
 	assert.Equal(t, root, result[0])
@@ -1155,9 +1260,9 @@ func TestSortTasksUnknownRef(t *testing.T) {
 
 	provider := newMockedProvider()
 	service := NewComputeTaskService(provider)
-	_, err := service.sortTasks(nodes, existingKeys)
+	_, unsorted := service.sortTasks(nodes, existingKeys)
 
-	assert.Error(t, err)
+	assert.NotEmpty(t, unsorted)
 }
 
 func TestGetRank(t *testing.T) {
@@ -1452,30 +1557,6 @@ func TestGetRegisteredTask(t *testing.T) {
 	dbal.AssertExpectations(t)
 }
 
-func TestGetCachedPlan(t *testing.T) {
-	provider := newMockedProvider()
-	cps := new(MockComputePlanAPI)
-	provider.On("GetComputePlanService").Return(cps)
-
-	computePlan := &asset.ComputePlan{
-		Key: "uuid1",
-	}
-
-	cps.On("GetPlan", "uuid1").Return(computePlan, nil).Once()
-
-	service := NewComputeTaskService(provider)
-
-	cp, err := service.getCachedCP("uuid1")
-	assert.NoError(t, err)
-	assert.Equal(t, computePlan.Key, cp.Key)
-
-	cp, err = service.getCachedCP("uuid1")
-	assert.NoError(t, err)
-	assert.Equal(t, computePlan.Key, cp.Key)
-
-	cps.AssertExpectations(t)
-}
-
 func TestGetInputAssetsTaskUnready(t *testing.T) {
 	provider := newMockedProvider()
 	db := new(persistence.MockComputeTaskDBAL)
@@ -1769,3 +1850,27 @@ func TestGetLogsPermission(t *testing.T) {
 		)
 	}
 }
+
+func TestGetTasksKeepsOrder(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	provider := newMockedProvider()
+	provider.On("GetComputeTaskDBAL").Return(dbal)
+
+	dbal.On("GetComputeTasks", []string{"uuid1", "uuid2"}).Once().Return([]*asset.ComputeTask{{Key: "uuid2"}, {Key: "uuid1"}}, nil)
+	dbal.On("GetComputeTasks", []string{"unknown"}).Once().Return([]*asset.ComputeTask{}, nil)
+
+	service := NewComputeTaskService(provider)
+
+	tasks, err := service.getTasks([]string{"uuid1", "uuid2"})
+	assert.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "uuid1", tasks[0].Key)
+	assert.Equal(t, "uuid2", tasks[1].Key)
+
+	_, err = service.getTasks([]string{"unknown"})
+	orcError := new(orcerrors.OrcError)
+	assert.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrNotFound, orcError.Kind)
+
+	dbal.AssertExpectations(t)
+}
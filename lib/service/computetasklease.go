@@ -0,0 +1,112 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/substra/orchestrator/lib/asset"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+)
+
+// Heartbeat extends the execution lease of a task and returns the new expiration date.
+// Only the worker of an executing task can extend its lease.
+func (s *ComputeTaskService) Heartbeat(key string, requester string) (time.Time, error) {
+	s.GetLogger().Debug().Str("key", key).Str("requester", requester).Msg("Task heartbeat")
+
+	if s.GetTaskLeaseDuration() <= 0 {
+		return time.Time{}, orcerrors.NewBadRequest(fmt.Sprintf("task leases are disabled on channel %q", s.GetChannel()))
+	}
+
+	task, err := s.GetComputeTaskDBAL().GetComputeTask(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if task.Worker != requester {
+		return time.Time{}, orcerrors.NewPermissionDenied(fmt.Sprintf("only %q worker can send heartbeats for the task", task.Worker))
+	}
+
+	if task.Status != asset.ComputeTaskStatus_STATUS_EXECUTING {
+		return time.Time{}, orcerrors.NewError(orcerrors.ErrIncompatibleTaskStatus, fmt.Sprintf("cannot extend the lease of task with status %q", task.Status.String()))
+	}
+
+	return s.renewTaskLease(task)
+}
+
+// renewTaskLease sets the lease of the task to expire after the channel's lease duration.
+// It is a no-op when leases are disabled.
+func (s *ComputeTaskService) renewTaskLease(task *asset.ComputeTask) (time.Time, error) {
+	duration := s.GetTaskLeaseDuration()
+	if duration <= 0 {
+		return time.Time{}, nil
+	}
+
+	expiration := s.GetTimeService().GetTransactionTime().Add(duration)
+
+	err := s.GetComputeTaskDBAL().UpdateComputeTaskLease(task.Key, expiration)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return expiration, nil
+}
+
+// GetExpiredTaskKeys returns the keys of the executing tasks whose lease has expired.
+func (s *ComputeTaskService) GetExpiredTaskKeys() ([]string, error) {
+	if s.GetTaskLeaseDuration() <= 0 {
+		return []string{}, nil
+	}
+
+	tasks, err := s.GetComputeTaskDBAL().GetExpiredComputeTasks(s.GetTimeService().GetTransactionTime())
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		keys = append(keys, task.Key)
+	}
+
+	return keys, nil
+}
+
+// FailExpiredTask fails a task whose lease has expired, as if its worker had registered an internal failure report.
+// It is meant to be called in a dedicated transaction for each key returned by GetExpiredTaskKeys,
+// so that a task failing to be updated does not prevent the others from being failed.
+// It returns false if the task is not executing anymore, or if its lease has been renewed since it was listed.
+func (s *ComputeTaskService) FailExpiredTask(key string) (bool, error) {
+	task, err := s.GetComputeTaskDBAL().GetComputeTask(key)
+	if err != nil {
+		return false, err
+	}
+
+	if task.Status != asset.ComputeTaskStatus_STATUS_EXECUTING {
+		s.GetLogger().Debug().Str("taskKey", task.Key).Str("status", task.Status.String()).Msg("skipping task which is not executing anymore")
+		return false, nil
+	}
+
+	// The lease is checked again in this transaction: a heartbeat may have been sent since the task was listed
+	expired, err := s.GetComputeTaskDBAL().IsComputeTaskLeaseExpired(task.Key, s.GetTimeService().GetTransactionTime())
+	if err != nil {
+		return false, err
+	}
+	if !expired {
+		s.GetLogger().Debug().Str("taskKey", task.Key).Msg("skipping task whose lease has been renewed")
+		return false, nil
+	}
+
+	s.GetLogger().Info().Str("taskKey", task.Key).Str("worker", task.Worker).Msg("task lease expired")
+
+	failure := &asset.NewFailureReport{
+		AssetKey:  task.Key,
+		AssetType: asset.FailedAssetKind_FAILED_ASSET_COMPUTE_TASK,
+		ErrorType: asset.ErrorType_ERROR_TYPE_INTERNAL,
+	}
+
+	_, err = s.GetFailureReportService().RegisterFailureReport(failure, task.Worker)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
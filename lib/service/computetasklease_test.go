@@ -0,0 +1,252 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/substra/orchestrator/lib/asset"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"github.com/substra/orchestrator/lib/persistence"
+)
+
+// newMockedLeaseProvider returns a mocked provider with task leases enabled.
+func newMockedLeaseProvider(duration time.Duration) *MockDependenciesProvider {
+	provider := newMockedProvider()
+	provider.On("GetTaskLeaseDuration").Unset()
+	provider.On("GetTaskLeaseDuration").Return(duration)
+
+	return provider
+}
+
+func TestHeartbeat(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	ts := new(MockTimeAPI)
+	provider := newMockedLeaseProvider(time.Minute)
+
+	provider.On("GetComputeTaskDBAL").Return(dbal)
+	provider.On("GetTimeService").Return(ts)
+
+	dbal.On("GetComputeTask", "uuid").Return(&asset.ComputeTask{
+		Key:    "uuid",
+		Status: asset.ComputeTaskStatus_STATUS_EXECUTING,
+		Worker: "worker",
+	}, nil)
+	ts.On("GetTransactionTime").Once().Return(time.Unix(1337, 0))
+	dbal.On("UpdateComputeTaskLease", "uuid", time.Unix(1337, 0).Add(time.Minute)).Once().Return(nil)
+
+	service := NewComputeTaskService(provider)
+
+	expiration, err := service.Heartbeat("uuid", "worker")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Unix(1337, 0).Add(time.Minute), expiration)
+
+	dbal.AssertExpectations(t)
+	ts.AssertExpectations(t)
+}
+
+func TestHeartbeatRejected(t *testing.T) {
+	cases := map[string]struct {
+		duration  time.Duration
+		task      *asset.ComputeTask
+		requester string
+		errorKind string
+	}{
+		"leases disabled": {
+			duration:  0,
+			requester: "worker",
+			errorKind: orcerrors.ErrBadRequest,
+		},
+		"not the worker": {
+			duration:  time.Minute,
+			task:      &asset.ComputeTask{Key: "uuid", Status: asset.ComputeTaskStatus_STATUS_EXECUTING, Worker: "worker"},
+			requester: "other",
+			errorKind: orcerrors.ErrPermissionDenied,
+		},
+		"not executing": {
+			duration:  time.Minute,
+			task:      &asset.ComputeTask{Key: "uuid", Status: asset.ComputeTaskStatus_STATUS_DONE, Worker: "worker"},
+			requester: "worker",
+			errorKind: orcerrors.ErrIncompatibleTaskStatus,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			dbal := new(persistence.MockDBAL)
+			provider := newMockedLeaseProvider(c.duration)
+			provider.On("GetComputeTaskDBAL").Return(dbal)
+
+			if c.task != nil {
+				dbal.On("GetComputeTask", c.task.Key).Return(c.task, nil)
+			}
+
+			service := NewComputeTaskService(provider)
+
+			_, err := service.Heartbeat("uuid", c.requester)
+			orcError := new(orcerrors.OrcError)
+			assert.True(t, errors.As(err, &orcError))
+			assert.Equal(t, c.errorKind, orcError.Kind)
+
+			dbal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStartTaskRenewsLease(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	es := new(MockEventAPI)
+	cps := new(MockComputePlanAPI)
+	ts := new(MockTimeAPI)
+	provider := newMockedLeaseProvider(time.Minute)
+
+	provider.On("GetComputeTaskDBAL").Return(dbal)
+	provider.On("GetEventService").Return(es)
+	provider.On("GetComputePlanService").Return(cps)
+	provider.On("GetTimeService").Return(ts)
+
+	task := &asset.ComputeTask{
+		Key:            "uuid",
+		Status:         asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT,
+		Worker:         "worker",
+		ComputePlanKey: "cpKey",
+	}
+
+	cps.On("GetPlan", "cpKey").Return(&asset.ComputePlan{Key: "cpKey"}, nil)
+	ts.On("GetTransactionTime").Once().Return(time.Unix(1337, 0))
+	dbal.On("UpdateComputeTaskLease", "uuid", time.Unix(1337, 0).Add(time.Minute)).Once().Return(nil)
+	dbal.On("UpdateComputeTaskStatus", "uuid", asset.ComputeTaskStatus_STATUS_EXECUTING).Once().Return(nil)
+	es.On("RegisterEvents", mock.Anything).Once().Return(nil)
+
+	service := NewComputeTaskService(provider)
+
+	err := service.applyTaskAction(task, asset.ComputeTaskAction_TASK_ACTION_EXECUTING, "")
+	assert.NoError(t, err)
+
+	dbal.AssertExpectations(t)
+	es.AssertExpectations(t)
+	ts.AssertExpectations(t)
+}
+
+func TestGetExpiredTaskKeys(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	ts := new(MockTimeAPI)
+	provider := newMockedLeaseProvider(time.Minute)
+
+	provider.On("GetComputeTaskDBAL").Return(dbal)
+	provider.On("GetTimeService").Return(ts)
+
+	ts.On("GetTransactionTime").Once().Return(time.Unix(1337, 0))
+	dbal.On("GetExpiredComputeTasks", time.Unix(1337, 0)).Once().Return([]*asset.ComputeTask{
+		{Key: "task1", Worker: "worker1"},
+		{Key: "task2", Worker: "worker2"},
+	}, nil)
+
+	service := NewComputeTaskService(provider)
+
+	keys, err := service.GetExpiredTaskKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"task1", "task2"}, keys)
+
+	dbal.AssertExpectations(t)
+	ts.AssertExpectations(t)
+}
+
+func TestGetExpiredTaskKeysWithoutLease(t *testing.T) {
+	provider := newMockedProvider()
+	service := NewComputeTaskService(provider)
+
+	keys, err := service.GetExpiredTaskKeys()
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	provider.AssertExpectations(t)
+}
+
+func TestFailExpiredTask(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	frs := new(MockFailureReportAPI)
+	ts := new(MockTimeAPI)
+	provider := newMockedLeaseProvider(time.Minute)
+
+	provider.On("GetComputeTaskDBAL").Return(dbal)
+	provider.On("GetFailureReportService").Return(frs)
+	provider.On("GetTimeService").Return(ts)
+
+	dbal.On("GetComputeTask", "task1").Once().Return(&asset.ComputeTask{
+		Key:    "task1",
+		Worker: "worker1",
+		Status: asset.ComputeTaskStatus_STATUS_EXECUTING,
+	}, nil)
+	ts.On("GetTransactionTime").Once().Return(time.Unix(1337, 0))
+	dbal.On("IsComputeTaskLeaseExpired", "task1", time.Unix(1337, 0)).Once().Return(true, nil)
+
+	// Failures are reported on behalf of the task's worker
+	failure := &asset.NewFailureReport{
+		AssetKey:  "task1",
+		AssetType: asset.FailedAssetKind_FAILED_ASSET_COMPUTE_TASK,
+		ErrorType: asset.ErrorType_ERROR_TYPE_INTERNAL,
+	}
+	frs.On("RegisterFailureReport", failure, "worker1").Once().Return(&asset.FailureReport{}, nil)
+
+	service := NewComputeTaskService(provider)
+
+	failed, err := service.FailExpiredTask("task1")
+	assert.NoError(t, err)
+	assert.True(t, failed)
+
+	dbal.AssertExpectations(t)
+	frs.AssertExpectations(t)
+	ts.AssertExpectations(t)
+}
+
+func TestFailExpiredTaskRenewedLease(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	ts := new(MockTimeAPI)
+	provider := newMockedLeaseProvider(time.Minute)
+
+	provider.On("GetComputeTaskDBAL").Return(dbal)
+	provider.On("GetTimeService").Return(ts)
+
+	dbal.On("GetComputeTask", "task1").Once().Return(&asset.ComputeTask{
+		Key:    "task1",
+		Worker: "worker1",
+		Status: asset.ComputeTaskStatus_STATUS_EXECUTING,
+	}, nil)
+	// A heartbeat has been received since the lease was found expired
+	ts.On("GetTransactionTime").Once().Return(time.Unix(1337, 0))
+	dbal.On("IsComputeTaskLeaseExpired", "task1", time.Unix(1337, 0)).Once().Return(false, nil)
+
+	service := NewComputeTaskService(provider)
+
+	failed, err := service.FailExpiredTask("task1")
+	assert.NoError(t, err)
+	assert.False(t, failed)
+
+	dbal.AssertExpectations(t)
+	ts.AssertExpectations(t)
+}
+
+func TestFailExpiredTaskNotExecuting(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	provider := newMockedLeaseProvider(time.Minute)
+
+	provider.On("GetComputeTaskDBAL").Return(dbal)
+
+	// The task has been completed since its lease was found expired
+	dbal.On("GetComputeTask", "task1").Once().Return(&asset.ComputeTask{
+		Key:    "task1",
+		Worker: "worker1",
+		Status: asset.ComputeTaskStatus_STATUS_DONE,
+	}, nil)
+
+	service := NewComputeTaskService(provider)
+
+	failed, err := service.FailExpiredTask("task1")
+	assert.NoError(t, err)
+	assert.False(t, failed)
+
+	dbal.AssertExpectations(t)
+}
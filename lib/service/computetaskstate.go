@@ -7,6 +7,7 @@ import (
 
 	"github.com/looplab/fsm"
 	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
 	orcerrors "github.com/substra/orchestrator/lib/errors"
 	"github.com/substra/orchestrator/lib/metrics"
 )
@@ -21,6 +22,7 @@ const (
 	transitionCanceled            taskTransition = "transitionCanceled"
 	transitionFailed              taskTransition = "transitionFailed"
 	transitionExecuting           taskTransition = "transitionExecuting"
+	transitionRetried             taskTransition = "transitionRetried"
 )
 
 // taskStateEvents is the definition of the state machine representing task states
@@ -60,6 +62,11 @@ var taskStateEvents = fsm.Events{
 		Src:  []string{asset.ComputeTaskStatus_STATUS_WAITING_FOR_BUILDER_SLOT.String(), asset.ComputeTaskStatus_STATUS_BUILDING.String(), asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT.String(), asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS.String(), asset.ComputeTaskStatus_STATUS_EXECUTING.String()},
 		Dst:  asset.ComputeTaskStatus_STATUS_FAILED.String(),
 	},
+	{
+		Name: string(transitionRetried),
+		Src:  []string{asset.ComputeTaskStatus_STATUS_FAILED.String()},
+		Dst:  asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS.String(),
+	},
 }
 
 // taskStateUpdater defines a structure capable of handling task updates
@@ -75,6 +82,9 @@ type taskStateUpdater interface {
 	// Set the compute plan to failed when a task fails.
 	// Task is received as argument, any error should be registered as e.Err.
 	onFailure(e *fsm.Event)
+	// Restore the compute plan and start the task again when a failed task is retried.
+	// Task is received as argument, any error should be registered as e.Err.
+	onRetry(e *fsm.Event)
 }
 
 // dumbStateUpdater implements taskStateUpdater but does nothing,
@@ -84,6 +94,7 @@ type dumbStateUpdater struct{}
 func (d *dumbStateUpdater) onStateChange(e *fsm.Event) {}
 func (d *dumbStateUpdater) onDone(e *fsm.Event)        {}
 func (d *dumbStateUpdater) onFailure(e *fsm.Event)     {}
+func (d *dumbStateUpdater) onRetry(e *fsm.Event)       {}
 
 var dumbUpdater = dumbStateUpdater{}
 
@@ -92,9 +103,10 @@ func newState(updater taskStateUpdater, task *asset.ComputeTask) *fsm.FSM {
 		task.Status.String(),
 		taskStateEvents,
 		fsm.Callbacks{
-			"enter_state":            wrapFsmCallbackContext(updater.onStateChange),
-			"after_transitionDone":   wrapFsmCallbackContext(updater.onDone),
-			"after_transitionFailed": wrapFsmCallbackContext(updater.onFailure),
+			"enter_state":             wrapFsmCallbackContext(updater.onStateChange),
+			"after_transitionDone":    wrapFsmCallbackContext(updater.onDone),
+			"after_transitionFailed":  wrapFsmCallbackContext(updater.onFailure),
+			"after_transitionRetried": wrapFsmCallbackContext(updater.onRetry),
 		},
 	)
 }
@@ -105,6 +117,17 @@ func wrapFsmCallbackContext(f func(*fsm.Event)) func(context.Context, *fsm.Event
 	return func(_ context.Context, e *fsm.Event) { f(e) }
 }
 
+// isFinalState returns true if the task cannot evolve anymore.
+// A failed task is considered final even though it may be retried.
+func isFinalState(state *fsm.FSM) bool {
+	for _, transition := range state.AvailableTransitions() {
+		if transition != string(transitionRetried) {
+			return false
+		}
+	}
+	return true
+}
+
 // ApplyTaskAction apply an asset.ComputeTaskAction to the task.
 // It checks the permission and delegate to `applyTaskAction`
 // Depending on the current state and action, this may update children tasks
@@ -132,17 +155,41 @@ func (s *ComputeTaskService) applyTaskAction(task *asset.ComputeTask, action ass
 		transition = transitionCanceled
 	case asset.ComputeTaskAction_TASK_ACTION_EXECUTING:
 		transition = transitionExecuting
+		paused, err := s.isPlanPaused(task.ComputePlanKey)
+		if err != nil {
+			return err
+		}
+		if paused {
+			return orcerrors.NewPausedComputePlan(task.ComputePlanKey)
+		}
+		_, err = s.renewTaskLease(task)
+		if err != nil {
+			return err
+		}
 	case asset.ComputeTaskAction_TASK_ACTION_FAILED:
 		transition = transitionFailed
 	case asset.ComputeTaskAction_TASK_ACTION_DONE:
 		transition = transitionDone
 	case asset.ComputeTaskAction_TASK_ACTION_BUILD_STARTED:
 		transition = transitionBuilding
+		paused, err := s.isPlanPaused(task.ComputePlanKey)
+		if err != nil {
+			return err
+		}
+		if paused {
+			return orcerrors.NewPausedComputePlan(task.ComputePlanKey)
+		}
 	case asset.ComputeTaskAction_TASK_ACTION_BUILD_FINISHED:
 		transition, err = s.getTransitionBuildFinished(task.Key)
 		if err != nil {
 			return err
 		}
+	case asset.ComputeTaskAction_TASK_ACTION_RETRY:
+		transition = transitionRetried
+		err = s.prepareRetry(task)
+		if err != nil {
+			return err
+		}
 	default:
 		return orcerrors.NewBadRequest("unsupported action")
 	}
@@ -256,9 +303,27 @@ func (s *ComputeTaskService) StartDependentTask(child *asset.ComputeTask, reason
 	if err != nil {
 		return err
 	}
+
+	return s.startTaskIfReady(child, done, reason)
+}
+
+// startTaskIfReady moves the task to the executor slot queue if its parents are done,
+// or back to waiting for its parents otherwise.
+func (s *ComputeTaskService) startTaskIfReady(child *asset.ComputeTask, done bool, reason string) error {
+	if done {
+		// Tasks of a paused compute plan are held until the plan is resumed
+		paused, err := s.isPlanPaused(child.ComputePlanKey)
+		if err != nil {
+			return err
+		}
+		if paused {
+			s.GetLogger().Debug().Str("child", child.Key).Msg("StartDependentTask: holding task of paused compute plan")
+			done = false
+		}
+	}
 	if !done {
 		if child.Status != asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS {
-			err = s.applyTaskTransition(child, transitionWaitingParentTasks, reason)
+			err := s.applyTaskTransition(child, transitionWaitingParentTasks, reason)
 			if err != nil {
 				return err
 			}
@@ -278,12 +343,7 @@ func (s *ComputeTaskService) StartDependentTask(child *asset.ComputeTask, reason
 		return nil
 	}
 
-	err = s.applyTaskTransition(child, transitionWaitingExecutorSlot, reason)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return s.applyTaskTransition(child, transitionWaitingExecutorSlot, reason)
 }
 
 // onStateChange enqueue an orchestration event and saves the task
@@ -368,6 +428,106 @@ func (s *ComputeTaskService) onFailure(e *fsm.Event) {
 	}
 }
 
+// prepareRetry checks that a failed task can be executed again and increments its attempt counter.
+func (s *ComputeTaskService) prepareRetry(task *asset.ComputeTask) error {
+	if task.Status != asset.ComputeTaskStatus_STATUS_FAILED {
+		return orcerrors.NewError(orcerrors.ErrIncompatibleTaskStatus, fmt.Sprintf("cannot retry task with status %q", task.Status.String()))
+	}
+
+	plan, err := s.GetComputePlanService().GetPlan(task.ComputePlanKey)
+	if err != nil {
+		return err
+	}
+	if plan.CancelationDate != nil {
+		return orcerrors.NewTerminatedComputePlan(plan.Key)
+	}
+
+	function, err := s.GetAssetCache().GetFunction(task.FunctionKey)
+	if err != nil {
+		return err
+	}
+	if function.Status != asset.FunctionStatus_FUNCTION_STATUS_READY {
+		return orcerrors.NewBadRequest(fmt.Sprintf("cannot retry task: function %q has status %q", function.Key, function.Status.String()))
+	}
+
+	maxAttempts := getMaxTaskAttempts(function, plan)
+	if task.Attempt >= maxAttempts {
+		return orcerrors.NewBadRequest(fmt.Sprintf("cannot retry task: maximum number of attempts (%d) reached", maxAttempts))
+	}
+
+	parents, err := s.GetComputeTaskDBAL().GetComputeTaskParents(task.Key)
+	if err != nil {
+		return err
+	}
+	if _, err := countParentDone(parents); err != nil {
+		return err
+	}
+	if err := s.allModelsAvailable(parents); err != nil {
+		return err
+	}
+
+	task.Attempt++
+
+	return s.GetComputeTaskDBAL().UpdateComputeTaskAttempt(task.Key, task.Attempt)
+}
+
+// onRetry restores the compute plan if the task was its only failed one,
+// and starts the task if its parents are done.
+func (s *ComputeTaskService) onRetry(e *fsm.Event) {
+	if len(e.Args) != 2 {
+		e.Err = orcerrors.NewInternal(fmt.Sprintf("cannot handle state change with argument: %v", e.Args))
+		return
+	}
+	task, ok := e.Args[0].(*asset.ComputeTask)
+	if !ok {
+		e.Err = orcerrors.NewInternal("cannot cast argument into task")
+		return
+	}
+	reason, ok := e.Args[1].(string)
+	if !ok {
+		e.Err = orcerrors.NewInternal(fmt.Sprintf("cannot cast into string: %v", e.Args[1]))
+		return
+	}
+
+	failedTasks, _, err := s.GetComputeTaskDBAL().QueryComputeTasks(
+		common.NewPagination("", 1),
+		&asset.TaskQueryFilter{ComputePlanKey: task.ComputePlanKey, Status: asset.ComputeTaskStatus_STATUS_FAILED},
+		asset.TaskSortField_TASK_SORT_CREATION_DATE,
+		asset.SortOrder_UNSPECIFIED,
+	)
+	if err != nil {
+		e.Err = err
+		return
+	}
+
+	if len(failedTasks) == 0 {
+		err = s.GetComputePlanService().restorePlan(task.ComputePlanKey)
+		if err != nil {
+			e.Err = err
+			return
+		}
+	}
+
+	err = s.StartDependentTask(task, reason)
+	if err != nil {
+		e.Err = err
+		return
+	}
+}
+
+// getMaxTaskAttempts returns the number of times a task can be executed.
+// The function setting takes precedence over the compute plan one, a task is executed once by default.
+func getMaxTaskAttempts(function *asset.Function, plan *asset.ComputePlan) uint32 {
+	switch {
+	case function.MaxTaskAttempts > 0:
+		return function.MaxTaskAttempts
+	case plan.MaxTaskAttempts > 0:
+		return plan.MaxTaskAttempts
+	default:
+		return 1
+	}
+}
+
 func countParentDone(parents []*asset.ComputeTask) (int, error) {
 	var doneParents = 0
 
@@ -417,7 +577,7 @@ func getInitialStatus(parents []*asset.ComputeTask, function *asset.Function) as
 // This does not take into account the task status, only ownership.
 func updateAllowed(task *asset.ComputeTask, action asset.ComputeTaskAction, requester string) bool {
 	switch action {
-	case asset.ComputeTaskAction_TASK_ACTION_CANCELED, asset.ComputeTaskAction_TASK_ACTION_FAILED:
+	case asset.ComputeTaskAction_TASK_ACTION_CANCELED, asset.ComputeTaskAction_TASK_ACTION_FAILED, asset.ComputeTaskAction_TASK_ACTION_RETRY:
 		return requester == task.Owner || requester == task.Worker
 	case asset.ComputeTaskAction_TASK_ACTION_EXECUTING, asset.ComputeTaskAction_TASK_ACTION_DONE:
 		return requester == task.Worker
@@ -441,6 +601,17 @@ func (s *ComputeTaskService) PropagateActionFromFunction(functionKey string, act
 	}
 
 	for _, task := range tasks {
+		if action == asset.ComputeTaskAction_TASK_ACTION_BUILD_STARTED {
+			paused, err := s.isPlanPaused(task.ComputePlanKey)
+			if err != nil {
+				return err
+			}
+			if paused {
+				// The task will catch up with its function when the plan is resumed
+				continue
+			}
+		}
+
 		var err error
 		if action == asset.ComputeTaskAction_TASK_ACTION_BUILD_FINISHED {
 			// Bypass `ApplyTaskAction` as we don't want to run
@@ -482,3 +653,114 @@ func (s *ComputeTaskService) checkParentTasksDone(childKey string) (bool, error)
 
 	return true, nil
 }
+
+// isPlanPaused returns true if the given compute plan is paused.
+// The plan is cached, so that checking the children of a task queries it once.
+func (s *ComputeTaskService) isPlanPaused(planKey string) (bool, error) {
+	plan, err := s.GetAssetCache().GetPlan(planKey)
+	if err != nil {
+		return false, err
+	}
+
+	return plan.IsPaused(), nil
+}
+
+// resumePlanTasks updates the tasks of a resumed compute plan
+// which have been held while the plan was paused.
+func (s *ComputeTaskService) resumePlanTasks(planKey string) error {
+	tasks, err := s.GetComputeTaskDBAL().GetComputePlanTasks(planKey)
+	if err != nil {
+		return err
+	}
+
+	doneTasks, err := s.getDoneTasks(tasks)
+	if err != nil {
+		return err
+	}
+
+	reason := fmt.Sprintf("Compute plan %s resumed", planKey)
+
+	for _, task := range tasks {
+		parentsDone := true
+		for _, parentKey := range GetParentTaskKeys(task.Inputs) {
+			if _, ok := doneTasks[parentKey]; !ok {
+				parentsDone = false
+				break
+			}
+		}
+
+		switch task.Status {
+		case asset.ComputeTaskStatus_STATUS_WAITING_FOR_BUILDER_SLOT:
+			err = s.resumeTaskBuild(task, parentsDone, reason)
+		case asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS:
+			err = s.startTaskIfReady(task, parentsDone, reason)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getDoneTasks returns the keys of the given tasks and of their parents which are done.
+// Parents belonging to other compute plans are fetched in a single query.
+func (s *ComputeTaskService) getDoneTasks(tasks []*asset.ComputeTask) (map[string]struct{}, error) {
+	done := make(map[string]struct{})
+	known := make(map[string]struct{}, len(tasks))
+	for _, task := range tasks {
+		known[task.Key] = struct{}{}
+		if task.Status == asset.ComputeTaskStatus_STATUS_DONE {
+			done[task.Key] = struct{}{}
+		}
+	}
+
+	external := []string{}
+	for _, task := range tasks {
+		for _, parentKey := range GetParentTaskKeys(task.Inputs) {
+			if _, ok := known[parentKey]; !ok {
+				known[parentKey] = struct{}{}
+				external = append(external, parentKey)
+			}
+		}
+	}
+
+	if len(external) > 0 {
+		parents, err := s.GetComputeTaskDBAL().GetComputeTasks(external)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range parents {
+			if parent.Status == asset.ComputeTaskStatus_STATUS_DONE {
+				done[parent.Key] = struct{}{}
+			}
+		}
+	}
+
+	return done, nil
+}
+
+// resumeTaskBuild catches up a task with the build status of its function.
+func (s *ComputeTaskService) resumeTaskBuild(task *asset.ComputeTask, parentsDone bool, reason string) error {
+	function, err := s.GetAssetCache().GetFunction(task.FunctionKey)
+	if err != nil {
+		return err
+	}
+
+	switch function.Status {
+	case asset.FunctionStatus_FUNCTION_STATUS_BUILDING:
+		return s.applyTaskTransition(task, transitionBuilding, reason)
+	case asset.FunctionStatus_FUNCTION_STATUS_READY:
+		err = s.applyTaskTransition(task, transitionBuilding, reason)
+		if err != nil {
+			return err
+		}
+		return s.startTaskIfReady(task, parentsDone, reason)
+	case asset.FunctionStatus_FUNCTION_STATUS_FAILED:
+		return s.applyTaskTransition(task, transitionFailed, reason)
+	case asset.FunctionStatus_FUNCTION_STATUS_CANCELED:
+		return s.applyTaskTransition(task, transitionCanceled, reason)
+	default:
+		return nil
+	}
+}
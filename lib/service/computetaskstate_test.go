@@ -2,13 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/looplab/fsm"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/substra/orchestrator/lib/asset"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
 	"github.com/substra/orchestrator/lib/persistence"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestGetInitialStatus(t *testing.T) {
@@ -126,10 +129,14 @@ func TestFailedStateChange(t *testing.T) {
 func TestDispatchOnTransition(t *testing.T) {
 	dbal := new(persistence.MockDBAL)
 	es := new(MockEventAPI)
+	cps := new(MockComputePlanAPI)
 	provider := newMockedProvider()
 
 	provider.On("GetComputeTaskDBAL").Return(dbal)
 	provider.On("GetEventService").Return(es)
+	provider.On("GetComputePlanService").Return(cps)
+
+	cps.On("GetPlan", "uuidcp").Return(&asset.ComputePlan{Key: "uuidcp"}, nil)
 
 	service := NewComputeTaskService(provider)
 
@@ -231,11 +238,15 @@ func TestCascadeStatusDone(t *testing.T) {
 	dbal := new(persistence.MockDBAL)
 	es := new(MockEventAPI)
 	fs := new(MockFunctionAPI)
+	cps := new(MockComputePlanAPI)
 	provider := newMockedProvider()
 
 	provider.On("GetComputeTaskDBAL").Return(dbal)
 	provider.On("GetEventService").Return(es)
 	provider.On("GetFunctionService").Return(fs)
+	provider.On("GetComputePlanService").Return(cps)
+
+	cps.On("GetPlan", "cpKey").Return(&asset.ComputePlan{Key: "cpKey"}, nil)
 
 	task := &asset.ComputeTask{
 		Key:    "uuid",
@@ -248,7 +259,7 @@ func TestCascadeStatusDone(t *testing.T) {
 		{Key: "uuid", Status: asset.ComputeTaskStatus_STATUS_DONE},
 	}, nil)
 	dbal.On("GetComputeTaskChildren", "uuid").Return([]*asset.ComputeTask{
-		{Key: "child", Status: asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS},
+		{Key: "child", Status: asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS, ComputePlanKey: "cpKey"},
 	}, nil)
 
 	// There should be two updates: 1 for the parent, 1 for the child
@@ -256,7 +267,7 @@ func TestCascadeStatusDone(t *testing.T) {
 
 	// Updated task should be saved
 	updatedParent := &asset.ComputeTask{Key: "uuid", Status: asset.ComputeTaskStatus_STATUS_DONE, Owner: "owner", Worker: "worker"}
-	updatedChild := &asset.ComputeTask{Key: "child", Status: asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT}
+	updatedChild := &asset.ComputeTask{Key: "child", Status: asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT, ComputePlanKey: "cpKey"}
 	dbal.On("UpdateComputeTaskStatus", updatedParent.Key, updatedParent.Status).Return(nil)
 	dbal.On("UpdateComputeTaskStatus", updatedChild.Key, updatedChild.Status).Return(nil)
 
@@ -270,6 +281,334 @@ func TestCascadeStatusDone(t *testing.T) {
 	fs.AssertExpectations(t)
 }
 
+func TestRetryTask(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	es := new(MockEventAPI)
+	fs := new(MockFunctionAPI)
+	cps := new(MockComputePlanAPI)
+	provider := newMockedProvider()
+
+	provider.On("GetComputeTaskDBAL").Return(dbal)
+	provider.On("GetEventService").Return(es)
+	provider.On("GetFunctionService").Return(fs)
+	provider.On("GetComputePlanService").Return(cps)
+
+	dbal.On("GetComputeTask", "uuid").Return(&asset.ComputeTask{
+		Key:            "uuid",
+		Status:         asset.ComputeTaskStatus_STATUS_FAILED,
+		Owner:          "owner",
+		Worker:         "worker",
+		ComputePlanKey: "cpKey",
+		FunctionKey:    "functionKey",
+		Attempt:        1,
+	}, nil)
+
+	cps.On("GetPlan", "cpKey").Return(&asset.ComputePlan{Key: "cpKey", MaxTaskAttempts: 3, FailureDate: timestamppb.Now()}, nil)
+	fs.On("GetFunction", "functionKey").Return(&asset.Function{Key: "functionKey", Status: asset.FunctionStatus_FUNCTION_STATUS_READY}, nil)
+	dbal.On("GetComputeTaskParents", "uuid").Return([]*asset.ComputeTask{}, nil)
+	dbal.On("UpdateComputeTaskAttempt", "uuid", uint32(2)).Once().Return(nil)
+
+	// The task goes back to waiting for its parents then to waiting for an executor slot
+	dbal.On("UpdateComputeTaskStatus", "uuid", asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS).Once().Return(nil)
+	dbal.On("UpdateComputeTaskStatus", "uuid", asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT).Once().Return(nil)
+	es.On("RegisterEvents", mock.Anything).Times(2).Return(nil)
+
+	// No other failed task: the plan is restored
+	dbal.On("QueryComputeTasks", mock.Anything, &asset.TaskQueryFilter{ComputePlanKey: "cpKey", Status: asset.ComputeTaskStatus_STATUS_FAILED}, mock.Anything, mock.Anything).
+		Once().Return([]*asset.ComputeTask{}, "", nil)
+	cps.On("restorePlan", "cpKey").Once().Return(nil)
+
+	service := NewComputeTaskService(provider)
+
+	err := service.ApplyTaskAction("uuid", asset.ComputeTaskAction_TASK_ACTION_RETRY, "", "owner")
+	assert.NoError(t, err)
+
+	dbal.AssertExpectations(t)
+	es.AssertExpectations(t)
+	fs.AssertExpectations(t)
+	cps.AssertExpectations(t)
+}
+
+func TestRetryTaskKeepsPlanFailed(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	es := new(MockEventAPI)
+	cps := new(MockComputePlanAPI)
+	provider := newMockedProvider()
+
+	provider.On("GetComputeTaskDBAL").Return(dbal)
+	provider.On("GetEventService").Return(es)
+	provider.On("GetComputePlanService").Return(cps)
+
+	task := &asset.ComputeTask{
+		Key:            "uuid",
+		Status:         asset.ComputeTaskStatus_STATUS_FAILED,
+		ComputePlanKey: "cpKey",
+		Attempt:        2,
+	}
+
+	dbal.On("UpdateComputeTaskStatus", "uuid", asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS).Once().Return(nil)
+	es.On("RegisterEvents", mock.Anything).Once().Return(nil)
+
+	// Another task of the plan has failed: the plan stays failed
+	dbal.On("QueryComputeTasks", mock.Anything, &asset.TaskQueryFilter{ComputePlanKey: "cpKey", Status: asset.ComputeTaskStatus_STATUS_FAILED}, mock.Anything, mock.Anything).
+		Once().Return([]*asset.ComputeTask{{Key: "other"}}, "", nil)
+
+	// A parent is not done yet: the task waits
+	dbal.On("GetComputeTaskParents", "uuid").Return([]*asset.ComputeTask{{Key: "parent", Status: asset.ComputeTaskStatus_STATUS_EXECUTING}}, nil)
+
+	service := NewComputeTaskService(provider)
+
+	err := service.applyTaskTransition(task, transitionRetried, "reason")
+	assert.NoError(t, err)
+	assert.Equal(t, asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS, task.Status)
+
+	dbal.AssertExpectations(t)
+	es.AssertExpectations(t)
+	cps.AssertExpectations(t)
+}
+
+func TestRetryTaskRejected(t *testing.T) {
+	cases := map[string]struct {
+		task     *asset.ComputeTask
+		plan     *asset.ComputePlan
+		function *asset.Function
+		parents  []*asset.ComputeTask
+		errKind  string
+	}{
+		"not failed": {
+			task:    &asset.ComputeTask{Status: asset.ComputeTaskStatus_STATUS_DONE, Attempt: 1},
+			errKind: orcerrors.ErrIncompatibleTaskStatus,
+		},
+		"canceled plan": {
+			task:    &asset.ComputeTask{Status: asset.ComputeTaskStatus_STATUS_FAILED, Attempt: 1},
+			plan:    &asset.ComputePlan{MaxTaskAttempts: 3, CancelationDate: timestamppb.Now()},
+			errKind: orcerrors.ErrTerminatedComputePlan,
+		},
+		"function not ready": {
+			task:     &asset.ComputeTask{Status: asset.ComputeTaskStatus_STATUS_FAILED, Attempt: 1},
+			plan:     &asset.ComputePlan{MaxTaskAttempts: 3},
+			function: &asset.Function{Status: asset.FunctionStatus_FUNCTION_STATUS_FAILED},
+			errKind:  orcerrors.ErrBadRequest,
+		},
+		"no retry by default": {
+			task:     &asset.ComputeTask{Status: asset.ComputeTaskStatus_STATUS_FAILED, Attempt: 1},
+			plan:     &asset.ComputePlan{},
+			function: &asset.Function{Status: asset.FunctionStatus_FUNCTION_STATUS_READY},
+			errKind:  orcerrors.ErrBadRequest,
+		},
+		"max attempts reached": {
+			task:     &asset.ComputeTask{Status: asset.ComputeTaskStatus_STATUS_FAILED, Attempt: 2},
+			plan:     &asset.ComputePlan{MaxTaskAttempts: 3},
+			function: &asset.Function{Status: asset.FunctionStatus_FUNCTION_STATUS_READY, MaxTaskAttempts: 2},
+			errKind:  orcerrors.ErrBadRequest,
+		},
+		"failed parent": {
+			task:     &asset.ComputeTask{Status: asset.ComputeTaskStatus_STATUS_FAILED, Attempt: 1},
+			plan:     &asset.ComputePlan{MaxTaskAttempts: 3},
+			function: &asset.Function{Status: asset.FunctionStatus_FUNCTION_STATUS_READY},
+			parents:  []*asset.ComputeTask{{Key: "parent", Status: asset.ComputeTaskStatus_STATUS_FAILED}},
+			errKind:  orcerrors.ErrTerminatedComputeTask,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dbal := new(persistence.MockDBAL)
+			fs := new(MockFunctionAPI)
+			cps := new(MockComputePlanAPI)
+			provider := newMockedProvider()
+
+			provider.On("GetComputeTaskDBAL").Return(dbal).Maybe()
+			provider.On("GetFunctionService").Return(fs).Maybe()
+			provider.On("GetComputePlanService").Return(cps).Maybe()
+
+			tc.task.Key = "uuid"
+			tc.task.ComputePlanKey = "cpKey"
+			tc.task.FunctionKey = "functionKey"
+
+			if tc.plan != nil {
+				cps.On("GetPlan", "cpKey").Return(tc.plan, nil)
+			}
+			if tc.function != nil {
+				fs.On("GetFunction", "functionKey").Return(tc.function, nil)
+			}
+			if tc.parents != nil {
+				dbal.On("GetComputeTaskParents", "uuid").Return(tc.parents, nil)
+			}
+
+			service := NewComputeTaskService(provider)
+
+			err := service.applyTaskAction(tc.task, asset.ComputeTaskAction_TASK_ACTION_RETRY, "")
+			orcError := new(orcerrors.OrcError)
+			assert.True(t, errors.As(err, &orcError))
+			assert.Equal(t, tc.errKind, orcError.Kind)
+
+			dbal.AssertExpectations(t)
+			fs.AssertExpectations(t)
+			cps.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIsFinalState(t *testing.T) {
+	cases := map[asset.ComputeTaskStatus]bool{
+		asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS: false,
+		asset.ComputeTaskStatus_STATUS_EXECUTING:                false,
+		asset.ComputeTaskStatus_STATUS_DONE:                     true,
+		asset.ComputeTaskStatus_STATUS_CANCELED:                 true,
+		// a failed task may be retried but is still considered final
+		asset.ComputeTaskStatus_STATUS_FAILED: true,
+	}
+
+	for status, final := range cases {
+		t.Run(status.String(), func(t *testing.T) {
+			state := newState(&dumbUpdater, &asset.ComputeTask{Status: status})
+			assert.Equal(t, final, isFinalState(state))
+		})
+	}
+}
+
+func TestGetMaxTaskAttempts(t *testing.T) {
+	assert.Equal(t, uint32(1), getMaxTaskAttempts(&asset.Function{}, &asset.ComputePlan{}))
+	assert.Equal(t, uint32(3), getMaxTaskAttempts(&asset.Function{}, &asset.ComputePlan{MaxTaskAttempts: 3}))
+	assert.Equal(t, uint32(2), getMaxTaskAttempts(&asset.Function{MaxTaskAttempts: 2}, &asset.ComputePlan{MaxTaskAttempts: 3}))
+}
+
+func TestStartTaskOfPausedPlan(t *testing.T) {
+	cps := new(MockComputePlanAPI)
+	provider := newMockedProvider()
+	provider.On("GetComputePlanService").Return(cps)
+
+	cps.On("GetPlan", "cpKey").Return(&asset.ComputePlan{Key: "cpKey", PauseDate: timestamppb.Now()}, nil)
+
+	service := NewComputeTaskService(provider)
+
+	task := &asset.ComputeTask{Key: "uuid", Status: asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT, ComputePlanKey: "cpKey", Worker: "worker"}
+	err := service.applyTaskAction(task, asset.ComputeTaskAction_TASK_ACTION_EXECUTING, "")
+	orcError := new(orcerrors.OrcError)
+	assert.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrPausedComputePlan, orcError.Kind)
+
+	provider.AssertExpectations(t)
+	cps.AssertExpectations(t)
+}
+
+func TestBuildTaskOfPausedPlan(t *testing.T) {
+	cps := new(MockComputePlanAPI)
+	provider := newMockedProvider()
+	provider.On("GetComputePlanService").Return(cps)
+
+	cps.On("GetPlan", "cpKey").Return(&asset.ComputePlan{Key: "cpKey", PauseDate: timestamppb.Now()}, nil)
+
+	service := NewComputeTaskService(provider)
+
+	task := &asset.ComputeTask{Key: "uuid", Status: asset.ComputeTaskStatus_STATUS_WAITING_FOR_BUILDER_SLOT, ComputePlanKey: "cpKey"}
+	err := service.applyTaskAction(task, asset.ComputeTaskAction_TASK_ACTION_BUILD_STARTED, "")
+	orcError := new(orcerrors.OrcError)
+	assert.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrPausedComputePlan, orcError.Kind)
+
+	cps.AssertExpectations(t)
+}
+
+func TestCascadeStatusDonePausedPlan(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	es := new(MockEventAPI)
+	cps := new(MockComputePlanAPI)
+	provider := newMockedProvider()
+
+	provider.On("GetComputeTaskDBAL").Return(dbal)
+	provider.On("GetEventService").Return(es)
+	provider.On("GetComputePlanService").Return(cps)
+
+	cps.On("GetPlan", "cpKey").Return(&asset.ComputePlan{Key: "cpKey", PauseDate: timestamppb.Now()}, nil)
+
+	task := &asset.ComputeTask{
+		Key:            "uuid",
+		Status:         asset.ComputeTaskStatus_STATUS_EXECUTING,
+		ComputePlanKey: "cpKey",
+	}
+	dbal.On("GetComputeTaskParents", "child").Return([]*asset.ComputeTask{
+		{Key: "uuid", Status: asset.ComputeTaskStatus_STATUS_DONE},
+	}, nil)
+	dbal.On("GetComputeTaskChildren", "uuid").Return([]*asset.ComputeTask{
+		{Key: "child", Status: asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS, ComputePlanKey: "cpKey"},
+	}, nil)
+
+	// Only the parent is updated, the child is held until the plan is resumed
+	es.On("RegisterEvents", mock.Anything).Once().Return(nil)
+	dbal.On("UpdateComputeTaskStatus", "uuid", asset.ComputeTaskStatus_STATUS_DONE).Once().Return(nil)
+
+	service := NewComputeTaskService(provider)
+
+	err := service.applyTaskTransition(task, transitionDone, "reason")
+	assert.NoError(t, err)
+
+	dbal.AssertExpectations(t)
+	es.AssertExpectations(t)
+	cps.AssertExpectations(t)
+}
+
+func TestResumePlanTasks(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	es := new(MockEventAPI)
+	fs := new(MockFunctionAPI)
+	cps := new(MockComputePlanAPI)
+	provider := newMockedProvider()
+
+	provider.On("GetComputeTaskDBAL").Return(dbal)
+	provider.On("GetEventService").Return(es)
+	provider.On("GetFunctionService").Return(fs)
+	provider.On("GetComputePlanService").Return(cps)
+
+	// The plan is fetched once for every task started
+	cps.On("GetPlan", "cpKey").Once().Return(&asset.ComputePlan{Key: "cpKey"}, nil)
+
+	parentInput := func(key string) []*asset.ComputeTaskInput {
+		return []*asset.ComputeTaskInput{{
+			Identifier: "model",
+			Ref: &asset.ComputeTaskInput_ParentTaskOutput{
+				ParentTaskOutput: &asset.ParentTaskOutputRef{ParentTaskKey: key, OutputIdentifier: "model"},
+			},
+		}}
+	}
+
+	dbal.On("GetComputePlanTasks", "cpKey").Return([]*asset.ComputeTask{
+		// held task has its parent done and is started
+		{Key: "held", Status: asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS, ComputePlanKey: "cpKey", Inputs: parentInput("done")},
+		// waiting task still has a pending parent
+		{Key: "waiting", Status: asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS, ComputePlanKey: "cpKey", Inputs: parentInput("held")},
+		// task depending on a task of another plan which is done
+		{Key: "external", Status: asset.ComputeTaskStatus_STATUS_WAITING_FOR_PARENT_TASKS, ComputePlanKey: "cpKey", Inputs: parentInput("otherPlanTask")},
+		// task waiting for its function to be built catches up with the function
+		{Key: "build", Status: asset.ComputeTaskStatus_STATUS_WAITING_FOR_BUILDER_SLOT, ComputePlanKey: "cpKey", FunctionKey: "building"},
+		{Key: "done", Status: asset.ComputeTaskStatus_STATUS_DONE, ComputePlanKey: "cpKey"},
+	}, nil)
+
+	// Parents from other plans are fetched at once
+	dbal.On("GetComputeTasks", []string{"otherPlanTask"}).Once().Return([]*asset.ComputeTask{
+		{Key: "otherPlanTask", Status: asset.ComputeTaskStatus_STATUS_DONE, ComputePlanKey: "otherPlan"},
+	}, nil)
+
+	dbal.On("UpdateComputeTaskStatus", "held", asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT).Once().Return(nil)
+	dbal.On("UpdateComputeTaskStatus", "external", asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT).Once().Return(nil)
+	fs.On("GetFunction", "building").Return(&asset.Function{Key: "building", Status: asset.FunctionStatus_FUNCTION_STATUS_BUILDING}, nil)
+	dbal.On("UpdateComputeTaskStatus", "build", asset.ComputeTaskStatus_STATUS_BUILDING).Once().Return(nil)
+
+	es.On("RegisterEvents", mock.Anything).Times(3).Return(nil)
+
+	service := NewComputeTaskService(provider)
+
+	err := service.resumePlanTasks("cpKey")
+	assert.NoError(t, err)
+
+	dbal.AssertExpectations(t)
+	dbal.AssertNotCalled(t, "GetComputeTaskParents", mock.Anything)
+	es.AssertExpectations(t)
+	fs.AssertExpectations(t)
+	cps.AssertExpectations(t)
+}
+
 func TestUpdateAllowed(t *testing.T) {
 	task := &asset.ComputeTask{
 		Worker: "worker",
@@ -341,6 +680,21 @@ func TestUpdateAllowed(t *testing.T) {
 			action:    asset.ComputeTaskAction_TASK_ACTION_DONE,
 			outcome:   true,
 		},
+		"owner retry": {
+			requester: "owner",
+			action:    asset.ComputeTaskAction_TASK_ACTION_RETRY,
+			outcome:   true,
+		},
+		"worker retry": {
+			requester: "worker",
+			action:    asset.ComputeTaskAction_TASK_ACTION_RETRY,
+			outcome:   true,
+		},
+		"other retry": {
+			requester: "other",
+			action:    asset.ComputeTaskAction_TASK_ACTION_RETRY,
+			outcome:   false,
+		},
 	}
 
 	for name, tc := range cases {
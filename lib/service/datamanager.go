@@ -33,6 +33,7 @@ type DataManagerDependencyProvider interface {
 	EventServiceProvider
 	TimeServiceProvider
 	DataSampleServiceProvider
+	AssetCacheProvider
 }
 
 // DataManagerService is the DataManager manipulation entry point
@@ -183,5 +184,11 @@ func (s *DataManagerService) UpdateDataManager(a *asset.UpdateDataManagerParam,
 	if err != nil {
 		return err
 	}
-	return s.GetDataManagerDBAL().UpdateDataManager(dataManager)
+	err = s.GetDataManagerDBAL().UpdateDataManager(dataManager)
+	if err != nil {
+		return err
+	}
+	s.GetAssetCache().forgetDataManager(dataManagerKey)
+
+	return nil
 }
@@ -48,13 +48,29 @@ func NewDataSampleService(provider DataSampleDependencyProvider) *DataSampleServ
 func (s *DataSampleService) RegisterDataSamples(samples []*asset.NewDataSample, owner string) ([]*asset.DataSample, error) {
 	s.GetLogger().Debug().Str("owner", owner).Int("nbSamples", len(samples)).Msg("Registering data samples")
 
+	// Collect every invalid sample so that they can be fixed at once
+	invalidFields := []*orcerrors.FieldError{}
+	for i, newSample := range samples {
+		err := newSample.Validate()
+		if err != nil {
+			invalidFields = append(invalidFields, orcerrors.NewFieldErrors(i, newSample.Key, err)...)
+		}
+	}
+	if len(invalidFields) > 0 {
+		return nil, orcerrors.FromValidationErrors(asset.DataSampleKind, invalidFields)
+	}
+
 	registeredSamples := []*asset.DataSample{}
 	events := []*asset.Event{}
 
-	for _, newSample := range samples {
+	for i, newSample := range samples {
 		sample, err := s.createDataSample(newSample, owner)
 		if err != nil {
-			return nil, err
+			if !orcerrors.IsAssetError(err) {
+				return nil, err
+			}
+			invalidFields = append(invalidFields, orcerrors.NewFieldErrors(i, newSample.Key, err)...)
+			continue
 		}
 		registeredSamples = append(registeredSamples, sample)
 
@@ -67,6 +83,10 @@ func (s *DataSampleService) RegisterDataSamples(samples []*asset.NewDataSample,
 		events = append(events, event)
 	}
 
+	if len(invalidFields) > 0 {
+		return nil, orcerrors.FromValidationErrors(asset.DataSampleKind, invalidFields)
+	}
+
 	err := s.GetEventService().RegisterEvents(events...)
 	if err != nil {
 		return nil, err
@@ -80,15 +100,11 @@ func (s *DataSampleService) RegisterDataSamples(samples []*asset.NewDataSample,
 	return registeredSamples, nil
 }
 
-// createDataSample persist one datasample
+// createDataSample persist one datasample, it expects the sample to be validated
 func (s *DataSampleService) createDataSample(sample *asset.NewDataSample, owner string) (*asset.DataSample, error) {
 	s.GetLogger().Debug().Str("owner", owner).Interface("newDataSample", sample).Msg("Registering data sample")
-	err := sample.Validate()
-	if err != nil {
-		return nil, orcerrors.FromValidationError(asset.DataSampleKind, err)
-	}
 
-	err = s.GetDataManagerService().CheckOwner(sample.GetDataManagerKeys(), owner)
+	err := s.GetDataManagerService().CheckOwner(sample.GetDataManagerKeys(), owner)
 	if err != nil {
 		return nil, err
 	}
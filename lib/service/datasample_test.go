@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/substra/orchestrator/lib/asset"
 	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
 	"github.com/substra/orchestrator/lib/persistence"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -85,6 +86,88 @@ func TestRegisterSingleDataSampleUnknownDataManager(t *testing.T) {
 	dbal.AssertExpectations(t)
 }
 
+func TestRegisterInvalidDataSamples(t *testing.T) {
+	provider := newMockedProvider()
+	service := NewDataSampleService(provider)
+
+	samples := []*asset.NewDataSample{
+		{
+			Key:             "4c67ad88-309a-48b4-8bc4-c2e2c1a87a83",
+			DataManagerKeys: []string{"9eef1e88-951a-44fb-944a-c3dbd1d72d85"},
+		},
+		{
+			Key:             "invalid",
+			DataManagerKeys: []string{"9eef1e88-951a-44fb-944a-c3dbd1d72d85"},
+			Checksum:        "f2ca1bb6c7e907d06dafe4687e579fce76b37e4e93b7605022da52e6ccc26fd2",
+		},
+	}
+
+	_, err := service.RegisterDataSamples(samples, "owner")
+	orcError := new(orcerrors.OrcError)
+	require.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrInvalidAsset, orcError.Kind)
+
+	expected := []*orcerrors.FieldError{
+		{Index: 0, AssetKey: "4c67ad88-309a-48b4-8bc4-c2e2c1a87a83", Field: "checksum", Kind: orcerrors.ErrInvalidAsset, Msg: "cannot be blank"},
+		{Index: 1, AssetKey: "invalid", Field: "key", Kind: orcerrors.ErrInvalidAsset, Msg: "must be a valid UUID"},
+	}
+	assert.Equal(t, expected, orcError.Details())
+
+	provider.AssertExpectations(t)
+}
+
+func TestRegisterDataSamplesCollectsErrors(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	dm := new(MockDataManagerAPI)
+	provider := newMockedProvider()
+	ts := new(MockTimeAPI)
+	provider.On("GetDataSampleDBAL").Return(dbal)
+	provider.On("GetDataManagerService").Return(dm)
+	provider.On("GetTimeService").Return(ts)
+	service := NewDataSampleService(provider)
+
+	ts.On("GetTransactionTime").Return(time.Unix(1337, 0))
+
+	samples := []*asset.NewDataSample{
+		{
+			Key:             "4c67ad88-309a-48b4-8bc4-c2e2c1a87a83",
+			DataManagerKeys: []string{"9eef1e88-951a-44fb-944a-c3dbd1d72d85"},
+			Checksum:        "f2ca1bb6c7e907d06dafe4687e579fce76b37e4e93b7605022da52e6ccc26fd2",
+		},
+		{
+			Key:             "0b4b4466-9a81-4084-9bab-80939b78addd",
+			DataManagerKeys: []string{"0e07ff81-8f31-4d7f-a1b4-b0e3f5dd7bd5"},
+			Checksum:        "f2ca1bb6c7e907d06dafe4687e579fce76b37e4e93b7605022da52e6ccc26fd2",
+		},
+		{
+			Key:             "0b7c2ca6-4d53-4d36-92e8-2b0f0dbc8e26",
+			DataManagerKeys: []string{"9eef1e88-951a-44fb-944a-c3dbd1d72d85"},
+			Checksum:        "f2ca1bb6c7e907d06dafe4687e579fce76b37e4e93b7605022da52e6ccc26fd2",
+		},
+	}
+
+	dm.On("CheckOwner", []string{"9eef1e88-951a-44fb-944a-c3dbd1d72d85"}, "owner").Return(nil)
+	dm.On("CheckOwner", []string{"0e07ff81-8f31-4d7f-a1b4-b0e3f5dd7bd5"}, "owner").Return(orcerrors.NewPermissionDenied("not the owner")).Once()
+	dbal.On("DataSampleExists", "4c67ad88-309a-48b4-8bc4-c2e2c1a87a83").Return(false, nil).Once()
+	dbal.On("DataSampleExists", "0b7c2ca6-4d53-4d36-92e8-2b0f0dbc8e26").Return(true, nil).Once()
+
+	_, err := service.RegisterDataSamples(samples, "owner")
+	orcError := new(orcerrors.OrcError)
+	require.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrInvalidAsset, orcError.Kind)
+
+	details := orcError.Details()
+	require.Len(t, details, 2)
+	assert.Equal(t, 1, details[0].Index)
+	assert.Equal(t, orcerrors.ErrPermissionDenied, details[0].Kind)
+	assert.Equal(t, 2, details[1].Index)
+	assert.Equal(t, orcerrors.ErrConflict, details[1].Kind)
+
+	dbal.AssertNotCalled(t, "AddDataSamples", mock.Anything)
+	dbal.AssertExpectations(t)
+	dm.AssertExpectations(t)
+}
+
 func TestRegisterMultipleDataSamples(t *testing.T) {
 	dbal := new(persistence.MockDBAL)
 	dm := new(MockDataManagerAPI)
@@ -16,8 +16,8 @@ type DatasetServiceProvider interface {
 
 // DatasetDependencyProvider defines what the DatasetService needs to perform its duty
 type DatasetDependencyProvider interface {
-	DataManagerServiceProvider
 	DataSampleServiceProvider
+	AssetCacheProvider
 }
 
 // DatasetService is the Dataset manipulation entry point
@@ -33,7 +33,7 @@ func NewDatasetService(provider DatasetDependencyProvider) *DatasetService {
 
 // GetDataset retrieves a single Dataset by its ID
 func (s *DatasetService) GetDataset(id string) (*asset.Dataset, error) {
-	datamanager, err := s.GetDataManagerService().GetDataManager(id)
+	datamanager, err := s.GetAssetCache().GetDataManager(id)
 	if err != nil {
 		return nil, err
 	}
@@ -3,6 +3,7 @@ package service
 import (
 	"github.com/substra/orchestrator/lib/asset"
 	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
 	"github.com/substra/orchestrator/lib/persistence"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -11,6 +12,12 @@ type EventAPI interface {
 	// RegisterEvents allows registering multiple events at once.
 	RegisterEvents(...*asset.Event) error
 	QueryEvents(p *common.Pagination, filter *asset.EventQueryFilter, sortOrder asset.SortOrder) ([]*asset.Event, common.PaginationToken, error)
+	// VerifyEventChain checks that the events of the channel have not been altered since their creation.
+	VerifyEventChain() (*asset.EventChainStatus, error)
+	// AckEvents stores the last event processed by a durable consumer of the requester.
+	AckEvents(param *asset.AckEventsParam, requester string) error
+	// QueryEventConsumers returns the durable consumers of the requester.
+	QueryEventConsumers(p *common.Pagination, requester string) ([]*asset.EventConsumer, common.PaginationToken, error)
 }
 
 type EventServiceProvider interface {
@@ -43,3 +50,20 @@ func (s *EventService) RegisterEvents(events ...*asset.Event) error {
 func (s *EventService) QueryEvents(p *common.Pagination, filter *asset.EventQueryFilter, sortOrder asset.SortOrder) ([]*asset.Event, common.PaginationToken, error) {
 	return s.GetEventDBAL().QueryEvents(p, filter, sortOrder)
 }
+
+func (s *EventService) VerifyEventChain() (*asset.EventChainStatus, error) {
+	return s.GetEventDBAL().VerifyEventChain()
+}
+
+func (s *EventService) AckEvents(param *asset.AckEventsParam, requester string) error {
+	err := param.Validate()
+	if err != nil {
+		return orcerrors.FromValidationError(asset.EventConsumerKind, err)
+	}
+
+	return s.GetEventDBAL().AckEvents(requester, param.Consumer, param.EventId, s.GetTimeService().GetTransactionTime())
+}
+
+func (s *EventService) QueryEventConsumers(p *common.Pagination, requester string) ([]*asset.EventConsumer, common.PaginationToken, error) {
+	return s.GetEventDBAL().QueryEventConsumers(p, requester)
+}
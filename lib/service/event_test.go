@@ -1,11 +1,14 @@
 package service
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/substra/orchestrator/lib/asset"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
 	"github.com/substra/orchestrator/lib/persistence"
 )
 
@@ -36,3 +39,30 @@ func TestRegisterEvents(t *testing.T) {
 	provider.AssertExpectations(t)
 	dbal.AssertExpectations(t)
 }
+
+func TestAckEvents(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	ts := new(MockTimeAPI)
+	provider := newMockedProvider()
+
+	provider.On("GetEventDBAL").Return(dbal)
+	provider.On("GetTimeService").Return(ts)
+
+	service := NewEventService(provider)
+
+	param := &asset.AckEventsParam{Consumer: "backend", EventId: "c70d3e0e-7e0b-4638-b320-ee11f5c61055"}
+
+	ts.On("GetTransactionTime").Once().Return(time.Unix(1337, 0))
+	dbal.On("AckEvents", "owner", "backend", param.EventId, time.Unix(1337, 0)).Once().Return(nil)
+
+	err := service.AckEvents(param, "owner")
+	assert.NoError(t, err)
+
+	err = service.AckEvents(&asset.AckEventsParam{Consumer: "backend"}, "owner")
+	orcError := new(orcerrors.OrcError)
+	require.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrInvalidAsset, orcError.Kind)
+
+	dbal.AssertExpectations(t)
+	ts.AssertExpectations(t)
+}
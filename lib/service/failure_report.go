@@ -11,7 +11,7 @@ import (
 
 type FailureReportAPI interface {
 	RegisterFailureReport(failure *asset.NewFailureReport, owner string) (*asset.FailureReport, error)
-	GetFailureReport(assetKey string) (*asset.FailureReport, error)
+	GetFailureReport(assetKey string, attempt uint32) (*asset.FailureReport, error)
 }
 
 type FailureReportServiceProvider interface {
@@ -25,6 +25,7 @@ type FailureReportDependencyProvider interface {
 	FunctionServiceProvider
 	EventServiceProvider
 	TimeServiceProvider
+	AssetCacheProvider
 }
 
 type FailureReportService struct {
@@ -42,9 +43,11 @@ func (s *FailureReportService) RegisterFailureReport(newFailureReport *asset.New
 	if err != nil {
 		return nil, errors.FromValidationError(asset.FailureReportKind, err)
 	}
+	// Functions are built once, only compute tasks may have several attempts
+	attempt := uint32(1)
 	switch newFailureReport.AssetType {
 	case asset.FailedAssetKind_FAILED_ASSET_COMPUTE_TASK:
-		err = s.processTaskFailure(newFailureReport.AssetKey, requester)
+		attempt, err = s.processTaskFailure(newFailureReport.AssetKey, requester)
 	case asset.FailedAssetKind_FAILED_ASSET_FUNCTION:
 		err = s.processFunctionFailure(newFailureReport.AssetKey, requester)
 	default:
@@ -62,6 +65,7 @@ func (s *FailureReportService) RegisterFailureReport(newFailureReport *asset.New
 		LogsAddress:  newFailureReport.LogsAddress,
 		CreationDate: timestamppb.New(s.GetTimeService().GetTransactionTime()),
 		Owner:        requester,
+		Attempt:      attempt,
 	}
 
 	err = s.GetFailureReportDBAL().AddFailureReport(failureReport)
@@ -83,9 +87,10 @@ func (s *FailureReportService) RegisterFailureReport(newFailureReport *asset.New
 	return failureReport, nil
 }
 
-func (s *FailureReportService) GetFailureReport(assetKey string) (*asset.FailureReport, error) {
-	s.GetLogger().Debug().Str("assetKey", assetKey).Msg("Get failure report")
-	return s.GetFailureReportDBAL().GetFailureReport(assetKey)
+// GetFailureReport returns the failure report of the given attempt, or the latest one if attempt is 0.
+func (s *FailureReportService) GetFailureReport(assetKey string, attempt uint32) (*asset.FailureReport, error) {
+	s.GetLogger().Debug().Str("assetKey", assetKey).Uint32("attempt", attempt).Msg("Get failure report")
+	return s.GetFailureReportDBAL().GetFailureReport(assetKey, attempt)
 }
 
 func checkTaskPermissions(task *asset.ComputeTask, requester string) error {
@@ -100,18 +105,24 @@ func checkTaskPermissions(task *asset.ComputeTask, requester string) error {
 	return nil
 }
 
-func (s *FailureReportService) processTaskFailure(taskKey string, requester string) error {
+// processTaskFailure fails the task and returns its current attempt.
+func (s *FailureReportService) processTaskFailure(taskKey string, requester string) (uint32, error) {
 	task, err := s.GetComputeTaskService().GetTask(taskKey)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	err = checkTaskPermissions(task, requester)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	err = s.GetComputeTaskService().ApplyTaskAction(taskKey, asset.ComputeTaskAction_TASK_ACTION_FAILED, "failure report registered", requester)
+	if err != nil {
+		return 0, err
 	}
 
-	return s.GetComputeTaskService().ApplyTaskAction(taskKey, asset.ComputeTaskAction_TASK_ACTION_FAILED, "failure report registered", requester)
+	return task.Attempt, nil
 }
 
 func checkFunctionPermissions(function *asset.Function, requester string) error {
@@ -123,7 +134,7 @@ func checkFunctionPermissions(function *asset.Function, requester string) error
 }
 
 func (s *FailureReportService) processFunctionFailure(functionKey string, requester string) error {
-	function, err := s.GetFunctionService().GetFunction(functionKey)
+	function, err := s.GetAssetCache().GetFunction(functionKey)
 	if err != nil {
 		return err
 	}
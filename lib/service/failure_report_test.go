@@ -39,9 +39,10 @@ func TestRegisterComputeTaskFailureReport(t *testing.T) {
 	}
 
 	taskService.On("GetTask", newFailureReport.AssetKey).Once().Return(&asset.ComputeTask{
-		Key:    newFailureReport.AssetKey,
-		Status: asset.ComputeTaskStatus_STATUS_EXECUTING,
-		Worker: "test",
+		Key:     newFailureReport.AssetKey,
+		Status:  asset.ComputeTaskStatus_STATUS_EXECUTING,
+		Worker:  "test",
+		Attempt: 2,
 	}, nil)
 
 	taskService.On("ApplyTaskAction", newFailureReport.AssetKey, asset.ComputeTaskAction_TASK_ACTION_FAILED, "failure report registered", "test").Once().Return(nil)
@@ -53,6 +54,7 @@ func TestRegisterComputeTaskFailureReport(t *testing.T) {
 		LogsAddress:  newFailureReport.LogsAddress,
 		CreationDate: timestamppb.New(transactionTime),
 		Owner:        "test",
+		Attempt:      2,
 	}
 	failureReportDBAL.On("AddFailureReport", storedFailureReport).Once().Return(nil)
 
@@ -115,6 +117,7 @@ func TestRegisterFunctionFailureReport(t *testing.T) {
 		LogsAddress:  newFailureReport.LogsAddress,
 		CreationDate: timestamppb.New(transactionTime),
 		Owner:        "test",
+		Attempt:      1,
 	}
 	failureReportDBAL.On("AddFailureReport", storedFailureReport).Once().Return(nil)
 
@@ -180,9 +183,9 @@ func TestGetFailure(t *testing.T) {
 		AssetKey: "uuid",
 	}
 
-	dbal.On("GetFailureReport", failureReport.AssetKey).Once().Return(failureReport, nil)
+	dbal.On("GetFailureReport", failureReport.AssetKey, uint32(0)).Once().Return(failureReport, nil)
 
-	ret, err := service.GetFailureReport(failureReport.AssetKey)
+	ret, err := service.GetFailureReport(failureReport.AssetKey, 0)
 	assert.NoError(t, err)
 	assert.Equal(t, failureReport, ret)
 
@@ -36,6 +36,7 @@ type FunctionDependencyProvider interface {
 	ComputeTaskServiceProvider
 	PermissionServiceProvider
 	TimeServiceProvider
+	AssetCacheProvider
 }
 
 // FunctionService is the function manipulation entry point
@@ -66,17 +67,18 @@ func (s *FunctionService) RegisterFunction(a *asset.NewFunction, owner string) (
 	}
 
 	function := &asset.Function{
-		Key:          a.Key,
-		Name:         a.Name,
-		Description:  a.Description,
-		Archive:      a.Archive,
-		Metadata:     a.Metadata,
-		Owner:        owner,
-		CreationDate: timestamppb.New(s.GetTimeService().GetTransactionTime()),
-		Inputs:       a.Inputs,
-		Outputs:      a.Outputs,
-		Status:       asset.FunctionStatus_FUNCTION_STATUS_WAITING,
-		Image:        &asset.Addressable{StorageAddress: "", Checksum: ""},
+		Key:             a.Key,
+		Name:            a.Name,
+		Description:     a.Description,
+		Archive:         a.Archive,
+		Metadata:        a.Metadata,
+		Owner:           owner,
+		CreationDate:    timestamppb.New(s.GetTimeService().GetTransactionTime()),
+		Inputs:          a.Inputs,
+		Outputs:         a.Outputs,
+		Status:          asset.FunctionStatus_FUNCTION_STATUS_WAITING,
+		Image:           &asset.Addressable{StorageAddress: "", Checksum: ""},
+		MaxTaskAttempts: a.MaxTaskAttempts,
 	}
 
 	function.Permissions, err = s.GetPermissionService().CreatePermissions(owner, a.NewPermissions)
@@ -173,5 +175,11 @@ func (s *FunctionService) UpdateFunction(a *asset.UpdateFunctionParam, requester
 		return err
 	}
 
-	return s.GetFunctionDBAL().UpdateFunction(function)
+	err = s.GetFunctionDBAL().UpdateFunction(function)
+	if err != nil {
+		return err
+	}
+	s.GetAssetCache().forgetFunction(functionKey)
+
+	return nil
 }
@@ -146,6 +146,7 @@ func (s *FunctionService) onStateChange(e *fsm.Event) {
 		e.Err = err
 		return
 	}
+	s.GetAssetCache().forgetFunction(function.Key)
 
 	event := &asset.Event{
 		EventKind: asset.EventKind_EVENT_ASSET_UPDATED,
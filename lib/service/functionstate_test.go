@@ -145,6 +145,10 @@ func TestUpdateFunctionStateReady(t *testing.T) {
 			provider.On("GetComputeTaskService").Return(NewComputeTaskService(provider))
 			provider.On("GetEventService").Return(es)
 			provider.On("GetComputeTaskDBAL").Return(ctdbal)
+			// The compute plan is only checked when the task can be started
+			cps := new(MockComputePlanAPI)
+			provider.On("GetComputePlanService").Return(cps).Maybe()
+			cps.On("GetPlan", "uuidcp").Return(&asset.ComputePlan{Key: "uuidcp"}, nil).Maybe()
 
 			functionKey := "uuid"
 			task := &asset.ComputeTask{
@@ -28,9 +28,9 @@ type ModelDependencyProvider interface {
 	PermissionServiceProvider
 	ComputeTaskServiceProvider
 	ComputePlanServiceProvider
-	FunctionServiceProvider
 	EventServiceProvider
 	TimeServiceProvider
+	AssetCacheProvider
 }
 
 type ModelService struct {
@@ -83,7 +83,7 @@ func (s *ModelService) registerModel(newModel *asset.NewModel, requester string,
 	if !ok {
 		return nil, errors.NewMissingTaskOutput(task.Key, newModel.ComputeTaskOutputIdentifier)
 	}
-	function, err := s.GetFunctionService().GetFunction(task.FunctionKey)
+	function, err := s.GetAssetCache().GetFunction(task.FunctionKey)
 	if err != nil {
 		return nil, err
 	}
@@ -902,9 +902,10 @@ func TestRegisterHeadAndTrunkModel(t *testing.T) {
 			AssetKind:                   asset.AssetKind_ASSET_MODEL,
 			AssetKey:                    model.Key,
 		}
-		as.On("GetFunction", function.Key).Once().Return(function, nil)
 		cts.On("addComputeTaskOutputAsset", output).Once().Return(nil)
 	}
+	// The function is fetched once for both models
+	as.On("GetFunction", function.Key).Once().Return(function, nil)
 
 	event := &asset.Event{
 		AssetKind: asset.AssetKind_ASSET_MODEL,
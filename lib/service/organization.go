@@ -24,6 +24,7 @@ type OrganizationDependencyProvider interface {
 	persistence.OrganizationDBALProvider
 	EventServiceProvider
 	TimeServiceProvider
+	AssetCacheProvider
 }
 
 // OrganizationService is the organization manipulation entry point
@@ -61,6 +62,7 @@ func (s *OrganizationService) RegisterOrganization(id string, newOrganization *a
 	if err != nil {
 		return nil, err
 	}
+	s.GetAssetCache().forgetOrganizations()
 
 	event := &asset.Event{
 		EventKind: asset.EventKind_EVENT_ASSET_CREATED,
@@ -25,7 +25,7 @@ type PermissionServiceProvider interface {
 // PermissionDependencyProvider defines what the PermissionService needs to perform its duty
 type PermissionDependencyProvider interface {
 	LoggerProvider
-	OrganizationServiceProvider
+	AssetCacheProvider
 }
 
 // PermissionService is the entry point to manipulate permissions.
@@ -76,7 +76,7 @@ func (s *PermissionService) CreatePermissions(owner string, newPerms *asset.NewP
 // validateAuthorizedIds checks that given IDs are valid organizations in the network.
 // Returns nil if all IDs are valid, an Error otherwise
 func (s *PermissionService) validateAuthorizedIDs(ids []string) error {
-	organizations, err := s.GetOrganizationService().GetAllOrganizations()
+	organizations, err := s.GetAssetCache().GetAllOrganizations()
 	if err != nil {
 		return err
 	}
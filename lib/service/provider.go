@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -17,6 +18,12 @@ type ChannelProvider interface {
 	GetChannel() string
 }
 
+// TaskLeaseProvider describes a provider of the execution lease duration of compute tasks.
+type TaskLeaseProvider interface {
+	// GetTaskLeaseDuration returns the lease duration, leases are disabled when it is zero.
+	GetTaskLeaseDuration() time.Duration
+}
+
 // DependenciesProvider describes a Provider exposing all orchestration services.
 type DependenciesProvider interface {
 	persistence.DBALProvider
@@ -35,7 +42,10 @@ type DependenciesProvider interface {
 	LoggerProvider
 	TimeServiceProvider
 	FailureReportServiceProvider
+	WebhookServiceProvider
 	ChannelProvider
+	TaskLeaseProvider
+	AssetCacheProvider
 }
 
 // Provider is the central part of the dependency injection pattern.
@@ -44,23 +54,26 @@ type DependenciesProvider interface {
 // Each service should define a ServiceDependencyProvider interface which states what are its requirements.
 // Since the Provider implements every Provider interface, it can fit all service dependencies.
 type Provider struct {
-	logger        *zerolog.Logger
-	channel       string
-	dbal          persistence.DBAL
-	organization  OrganizationAPI
-	permission    PermissionAPI
-	datasample    DataSampleAPI
-	function      FunctionAPI
-	datamanager   DataManagerAPI
-	dataset       DatasetAPI
-	computeTask   ComputeTaskAPI
-	model         ModelAPI
-	computePlan   ComputePlanAPI
-	profiling     ProfilingAPI
-	performance   PerformanceAPI
-	event         EventAPI
-	time          TimeAPI
-	failureReport FailureReportAPI
+	logger            *zerolog.Logger
+	channel           string
+	taskLeaseDuration time.Duration
+	dbal              persistence.DBAL
+	organization      OrganizationAPI
+	permission        PermissionAPI
+	datasample        DataSampleAPI
+	function          FunctionAPI
+	datamanager       DataManagerAPI
+	dataset           DatasetAPI
+	computeTask       ComputeTaskAPI
+	model             ModelAPI
+	computePlan       ComputePlanAPI
+	profiling         ProfilingAPI
+	performance       PerformanceAPI
+	event             EventAPI
+	time              TimeAPI
+	failureReport     FailureReportAPI
+	webhook           WebhookAPI
+	assetCache        AssetCacheAPI
 }
 
 // GetLogger returns a logger instance.
@@ -76,13 +89,18 @@ func (sc *Provider) GetChannel() string {
 	return sc.channel
 }
 
+func (sc *Provider) GetTaskLeaseDuration() time.Duration {
+	return sc.taskLeaseDuration
+}
+
 // NewProvider return an instance of Provider based on given persistence layer.
-func NewProvider(ctx context.Context, dbal persistence.DBAL, time TimeAPI, channel string) *Provider {
+func NewProvider(ctx context.Context, dbal persistence.DBAL, time TimeAPI, channel string, taskLeaseDuration time.Duration) *Provider {
 	return &Provider{
-		logger:  log.Ctx(ctx),
-		dbal:    dbal,
-		time:    time,
-		channel: channel,
+		logger:            log.Ctx(ctx),
+		dbal:              dbal,
+		time:              time,
+		channel:           channel,
+		taskLeaseDuration: taskLeaseDuration,
 	}
 }
 
@@ -134,6 +152,11 @@ func (sc *Provider) GetFailureReportDBAL() persistence.FailureReportDBAL {
 	return sc.dbal
 }
 
+// GetWebhookDBAL returns the database abstraction layer for Webhooks
+func (sc *Provider) GetWebhookDBAL() persistence.WebhookDBAL {
+	return sc.dbal
+}
+
 // GetOrganizationService returns a OrganizationAPI instance.
 // The service will be instanciated if needed.
 func (sc *Provider) GetOrganizationService() OrganizationAPI {
@@ -250,3 +273,21 @@ func (sc *Provider) GetFailureReportService() FailureReportAPI {
 	}
 	return sc.failureReport
 }
+
+// GetWebhookService returns a WebhookAPI instance.
+// The service will be instantiated if needed.
+func (sc *Provider) GetWebhookService() WebhookAPI {
+	if sc.webhook == nil {
+		sc.webhook = NewWebhookService(sc)
+	}
+	return sc.webhook
+}
+
+// GetAssetCache returns the AssetCacheAPI instance shared by the services of the provider.
+// The cache will be instantiated if needed.
+func (sc *Provider) GetAssetCache() AssetCacheAPI {
+	if sc.assetCache == nil {
+		sc.assetCache = NewAssetCache(sc)
+	}
+	return sc.assetCache
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
@@ -16,6 +17,10 @@ func newMockedProvider() *MockDependenciesProvider {
 	provider.On("GetLogger").Maybe().Return(&logger)
 	// And channel
 	provider.On("GetChannel").Maybe().Return("testChannel")
+	// Leases are disabled by default
+	provider.On("GetTaskLeaseDuration").Maybe().Return(time.Duration(0))
+	// Assets are cached like in a real provider, fetching them from the mocked services
+	provider.On("GetAssetCache").Maybe().Return(NewAssetCache(provider))
 
 	return provider
 }
@@ -25,7 +30,7 @@ func TestServiceProviderInit(t *testing.T) {
 	time := new(MockTimeAPI)
 	ctx := context.Background()
 	ctx = log.With().Bool("test", true).Logger().WithContext(ctx)
-	provider := NewProvider(ctx, dbal, time, "testChannel")
+	provider := NewProvider(ctx, dbal, time, "testChannel", 0)
 
 	assert.Implements(t, (*OrganizationServiceProvider)(nil), provider, "service provider should provide OrganizationService")
 	assert.Implements(t, (*DataSampleServiceProvider)(nil), provider, "service provider should provide DataSampleService")
@@ -42,7 +47,7 @@ func TestLazyInstanciation(t *testing.T) {
 	time := new(MockTimeAPI)
 	ctx := context.Background()
 	ctx = log.With().Bool("test", true).Logger().WithContext(ctx)
-	provider := NewProvider(ctx, dbal, time, "testChannel")
+	provider := NewProvider(ctx, dbal, time, "testChannel", 0)
 
 	assert.Nil(t, provider.organization, "service should be instanciated when needed")
 
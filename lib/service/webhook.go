@@ -0,0 +1,370 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"github.com/substra/orchestrator/lib/persistence"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WebhookSender pushes an event to a webhook endpoint.
+// It returns the HTTP status code of the response, or 0 if no response was received.
+type WebhookSender func(webhook *asset.Webhook, secret string, delivery *asset.WebhookDelivery, event *asset.Event) (uint32, error)
+
+// WebhookRetryPolicy defines how failed deliveries are retried.
+type WebhookRetryPolicy struct {
+	// MaxAttempts is the number of attempts after which a delivery is dead-lettered
+	MaxAttempts uint32
+	// InitialBackoff is the delay before the second attempt, it doubles after each failure
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts
+	MaxBackoff time.Duration
+}
+
+// backoff returns the delay before retrying a delivery which failed the given number of times.
+func (p *WebhookRetryPolicy) backoff(attempts uint32) time.Duration {
+	delay := p.InitialBackoff
+	for i := uint32(1); i < attempts && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	return delay
+}
+
+// WebhookDispatch is a batch of events claimed for delivery to a webhook, see ClaimWebhookEvents.
+// Events are pushed outside of any transaction with Send, then the outcome is saved with RecordWebhookDispatch.
+type WebhookDispatch struct {
+	Webhook *asset.Webhook
+	// ClaimID identifies the dispatch, no other dispatch of the webhook can be claimed until ClaimedUntil
+	ClaimID      string
+	ClaimedUntil time.Time
+	secret       string
+	events       []*asset.Event
+	deliveries   []*asset.WebhookDelivery
+	isNew        []bool
+	// lastScannedEventID is the last event examined to build the batch, including the ones not matching the filter.
+	// It is empty when the batch stops before a delivery which is backing off.
+	lastScannedEventID string
+	attempts           []webhookAttempt
+}
+
+// webhookAttempt is the outcome of sending an event to a webhook.
+type webhookAttempt struct {
+	code uint32
+	err  error
+}
+
+// Send pushes the claimed events in order, it stops at the first failed delivery which is going to be retried.
+// No event is sent after the deadline, so that the outcome can be recorded before the claim expires.
+func (d *WebhookDispatch) Send(sender WebhookSender, policy *WebhookRetryPolicy, deadline time.Time) {
+	for i, event := range d.events {
+		if time.Now().After(deadline) {
+			return
+		}
+
+		code, err := sender(d.Webhook, d.secret, d.deliveries[i], event)
+		d.attempts = append(d.attempts, webhookAttempt{code: code, err: err})
+
+		if err != nil && d.deliveries[i].Attempts+1 < policy.MaxAttempts {
+			return
+		}
+	}
+}
+
+// WebhookAPI defines the methods to manage webhooks and push events to them
+type WebhookAPI interface {
+	RegisterWebhook(newWebhook *asset.NewWebhook, owner string) (*asset.Webhook, error)
+	GetWebhook(key string, requester string) (*asset.Webhook, error)
+	QueryWebhooks(p *common.Pagination, requester string) ([]*asset.Webhook, common.PaginationToken, error)
+	UpdateWebhook(param *asset.UpdateWebhookParam, requester string) (*asset.Webhook, error)
+	DeleteWebhook(key string, requester string) error
+	QueryWebhookDeliveries(p *common.Pagination, webhookKey string, status asset.WebhookDeliveryStatus, requester string) ([]*asset.WebhookDelivery, common.PaginationToken, error)
+	GetDueWebhookKeys() ([]string, error)
+	ClaimWebhookEvents(key string, claimDuration time.Duration, limit uint32) (*WebhookDispatch, error)
+	RecordWebhookDispatch(dispatch *WebhookDispatch, policy *WebhookRetryPolicy) ([]*asset.WebhookDelivery, error)
+}
+
+// WebhookServiceProvider defines an object able to provide a WebhookAPI instance
+type WebhookServiceProvider interface {
+	GetWebhookService() WebhookAPI
+}
+
+// WebhookDependencyProvider defines what the WebhookService needs to perform its duty
+type WebhookDependencyProvider interface {
+	LoggerProvider
+	persistence.WebhookDBALProvider
+	TimeServiceProvider
+}
+
+// WebhookService is the webhook manipulation entry point
+// it implements the API interface
+type WebhookService struct {
+	WebhookDependencyProvider
+}
+
+// NewWebhookService will create a new service with given dependency provider
+func NewWebhookService(provider WebhookDependencyProvider) *WebhookService {
+	return &WebhookService{provider}
+}
+
+// RegisterWebhook stores a new webhook owned by the requester.
+// Only the events emitted after the registration are pushed to the webhook.
+func (s *WebhookService) RegisterWebhook(newWebhook *asset.NewWebhook, owner string) (*asset.Webhook, error) {
+	s.GetLogger().Debug().Str("owner", owner).Str("key", newWebhook.Key).Str("url", newWebhook.Url).Msg("Registering webhook")
+
+	err := newWebhook.Validate()
+	if err != nil {
+		return nil, orcerrors.FromValidationError(asset.WebhookKind, err)
+	}
+
+	exists, err := s.GetWebhookDBAL().WebhookExists(newWebhook.Key)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, orcerrors.NewConflict(asset.WebhookKind, newWebhook.Key)
+	}
+
+	webhook := &asset.Webhook{
+		Key:          newWebhook.Key,
+		Owner:        owner,
+		Url:          newWebhook.Url,
+		Filter:       newWebhook.Filter,
+		CreationDate: timestamppb.New(s.GetTimeService().GetTransactionTime()),
+	}
+
+	err = s.GetWebhookDBAL().AddWebhook(webhook, newWebhook.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// GetWebhook returns a webhook owned by the requester.
+func (s *WebhookService) GetWebhook(key string, requester string) (*asset.Webhook, error) {
+	webhook, err := s.GetWebhookDBAL().GetWebhook(key)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.Owner != requester {
+		return nil, orcerrors.NewPermissionDenied("requester does not own the webhook")
+	}
+
+	return webhook, nil
+}
+
+// QueryWebhooks returns the webhooks owned by the requester.
+func (s *WebhookService) QueryWebhooks(p *common.Pagination, requester string) ([]*asset.Webhook, common.PaginationToken, error) {
+	return s.GetWebhookDBAL().QueryWebhooks(p, requester)
+}
+
+// UpdateWebhook updates the url, filter and secret of a webhook.
+// The secret is kept when the new one is empty.
+func (s *WebhookService) UpdateWebhook(param *asset.UpdateWebhookParam, requester string) (*asset.Webhook, error) {
+	s.GetLogger().Debug().Str("requester", requester).Str("key", param.Key).Str("url", param.Url).Msg("Updating webhook")
+
+	err := param.Validate()
+	if err != nil {
+		return nil, orcerrors.FromValidationError(asset.WebhookKind, err)
+	}
+
+	webhook, err := s.GetWebhook(param.Key, requester)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Url = param.Url
+	webhook.Filter = param.Filter
+
+	err = s.GetWebhookDBAL().UpdateWebhook(webhook, param.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook removes a webhook and its delivery log.
+func (s *WebhookService) DeleteWebhook(key string, requester string) error {
+	s.GetLogger().Debug().Str("requester", requester).Str("key", key).Msg("Deleting webhook")
+
+	_, err := s.GetWebhook(key, requester)
+	if err != nil {
+		return err
+	}
+
+	return s.GetWebhookDBAL().DeleteWebhook(key)
+}
+
+// QueryWebhookDeliveries returns the delivery log of a webhook, optionally filtered by status.
+func (s *WebhookService) QueryWebhookDeliveries(p *common.Pagination, webhookKey string, status asset.WebhookDeliveryStatus, requester string) ([]*asset.WebhookDelivery, common.PaginationToken, error) {
+	_, err := s.GetWebhook(webhookKey, requester)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.GetWebhookDBAL().QueryWebhookDeliveries(p, webhookKey, status)
+}
+
+// GetDueWebhookKeys returns the keys of the webhooks which have events to deliver now.
+func (s *WebhookService) GetDueWebhookKeys() ([]string, error) {
+	return s.GetWebhookDBAL().GetDueWebhookKeys(s.GetTimeService().GetTransactionTime())
+}
+
+// ClaimWebhookEvents claims a webhook for the given duration and returns at most limit events to push to it, in order.
+// The batch stops before a failed delivery which is not due for retry yet.
+// It returns nil if the webhook is already claimed by another dispatch.
+func (s *WebhookService) ClaimWebhookEvents(key string, claimDuration time.Duration, limit uint32) (*WebhookDispatch, error) {
+	now := s.GetTimeService().GetTransactionTime()
+	dispatch := &WebhookDispatch{
+		ClaimID:      s.GetWebhookDBAL().NewWebhookClaimID(),
+		ClaimedUntil: now.Add(claimDuration),
+	}
+
+	secret, claimed, err := s.GetWebhookDBAL().ClaimWebhook(key, dispatch.ClaimID, now, dispatch.ClaimedUntil)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, nil
+	}
+	dispatch.secret = secret
+
+	dispatch.Webhook, err = s.GetWebhookDBAL().GetWebhook(key)
+	if err != nil {
+		return nil, err
+	}
+
+	events, lastScanned, err := s.GetWebhookDBAL().GetWebhookEvents(dispatch.Webhook, limit)
+	if err != nil {
+		return nil, err
+	}
+	dispatch.lastScannedEventID = lastScanned
+
+	for _, event := range events {
+		delivery, isNew, err := s.getDelivery(dispatch.Webhook, event)
+		if err != nil {
+			return nil, err
+		}
+		if delivery.NextAttemptDate != nil && delivery.NextAttemptDate.AsTime().After(now) {
+			dispatch.lastScannedEventID = ""
+			break
+		}
+
+		dispatch.events = append(dispatch.events, event)
+		dispatch.deliveries = append(dispatch.deliveries, delivery)
+		dispatch.isNew = append(dispatch.isNew, isNew)
+	}
+
+	return dispatch, nil
+}
+
+// RecordWebhookDispatch saves the deliveries attempted by a dispatch, moves the cursor of the webhook
+// after the delivered events and releases the claim.
+// A delivery failing policy.MaxAttempts times is dead-lettered, other failures are retried after an exponential backoff.
+// Nothing is recorded if the claim has expired in between, the events are then pushed again by the next dispatch.
+// It returns the deliveries attempted.
+func (s *WebhookService) RecordWebhookDispatch(dispatch *WebhookDispatch, policy *WebhookRetryPolicy) ([]*asset.WebhookDelivery, error) {
+	key := dispatch.Webhook.Key
+
+	released, err := s.GetWebhookDBAL().ReleaseWebhook(key, dispatch.ClaimID)
+	if err != nil {
+		return nil, err
+	}
+	if !released {
+		s.GetLogger().Warn().Str("webhookKey", key).Msg("webhook claim expired before the deliveries were recorded")
+		return []*asset.WebhookDelivery{}, nil
+	}
+
+	now := s.GetTimeService().GetTransactionTime()
+	deliveries := make([]*asset.WebhookDelivery, 0, len(dispatch.attempts))
+	cursor := ""
+	pending := false
+
+	for i, attempt := range dispatch.attempts {
+		event := dispatch.events[i]
+		// The dispatch is left untouched so that the transaction can be retried
+		delivery := proto.Clone(dispatch.deliveries[i]).(*asset.WebhookDelivery)
+
+		delivery.Attempts++
+		delivery.LastAttemptDate = timestamppb.New(now)
+		delivery.NextAttemptDate = nil
+		delivery.Error = ""
+		delivery.ResponseCode = attempt.code
+
+		switch {
+		case attempt.err == nil:
+			delivery.Status = asset.WebhookDeliveryStatus_DELIVERY_SUCCEEDED
+		case delivery.Attempts >= policy.MaxAttempts:
+			delivery.Status = asset.WebhookDeliveryStatus_DELIVERY_DEAD_LETTER
+			delivery.Error = attempt.err.Error()
+		default:
+			delivery.Status = asset.WebhookDeliveryStatus_DELIVERY_PENDING
+			delivery.Error = attempt.err.Error()
+			delivery.NextAttemptDate = timestamppb.New(now.Add(policy.backoff(delivery.Attempts)))
+		}
+
+		if dispatch.isNew[i] {
+			err = s.GetWebhookDBAL().AddWebhookDelivery(delivery)
+		} else {
+			err = s.GetWebhookDBAL().UpdateWebhookDelivery(delivery)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+
+		if delivery.Status == asset.WebhookDeliveryStatus_DELIVERY_PENDING {
+			s.GetLogger().Info().Err(attempt.err).Str("webhookKey", key).Str("eventID", event.Id).Uint32("attempts", delivery.Attempts).Msg("webhook delivery failed, will retry")
+			pending = true
+			break
+		}
+
+		cursor = event.Id
+	}
+
+	// Once the whole batch is delivered, the cursor skips the events which do not match the filter of the webhook
+	if !pending && len(dispatch.attempts) == len(dispatch.events) && dispatch.lastScannedEventID != "" {
+		cursor = dispatch.lastScannedEventID
+	}
+
+	if cursor != "" {
+		err = s.GetWebhookDBAL().SetWebhookCursor(key, cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return deliveries, nil
+}
+
+// getDelivery returns the pending delivery of the event, or a new one if the event has not been delivered yet.
+func (s *WebhookService) getDelivery(webhook *asset.Webhook, event *asset.Event) (*asset.WebhookDelivery, bool, error) {
+	delivery, err := s.GetWebhookDBAL().GetWebhookDelivery(webhook.Key, event.Id)
+
+	orcErr := new(orcerrors.OrcError)
+	if errors.As(err, &orcErr) && orcErr.Kind == orcerrors.ErrNotFound {
+		delivery = &asset.WebhookDelivery{
+			Id:           s.GetWebhookDBAL().NewWebhookDeliveryID(),
+			WebhookKey:   webhook.Key,
+			EventId:      event.Id,
+			CreationDate: timestamppb.New(s.GetTimeService().GetTransactionTime()),
+		}
+		return delivery, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return delivery, false, nil
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"github.com/substra/orchestrator/lib/persistence"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRegisterWebhook(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	ts := new(MockTimeAPI)
+	provider := newMockedProvider()
+	provider.On("GetWebhookDBAL").Return(dbal)
+	provider.On("GetTimeService").Return(ts)
+
+	newWebhook := &asset.NewWebhook{
+		Key:    "08680966-97ae-4573-8b2d-6c4db2b3c532",
+		Url:    "https://example.com/hook",
+		Secret: "0123456789abcdef",
+		Filter: &asset.EventQueryFilter{AssetKind: asset.AssetKind_ASSET_COMPUTE_TASK},
+	}
+	expected := &asset.Webhook{
+		Key:          newWebhook.Key,
+		Owner:        "owner",
+		Url:          newWebhook.Url,
+		Filter:       newWebhook.Filter,
+		CreationDate: timestamppb.New(time.Unix(1337, 0)),
+	}
+
+	dbal.On("WebhookExists", newWebhook.Key).Once().Return(false, nil)
+	ts.On("GetTransactionTime").Once().Return(time.Unix(1337, 0))
+	dbal.On("AddWebhook", expected, newWebhook.Secret).Once().Return(nil)
+
+	service := NewWebhookService(provider)
+
+	webhook, err := service.RegisterWebhook(newWebhook, "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, webhook)
+
+	dbal.AssertExpectations(t)
+	ts.AssertExpectations(t)
+}
+
+func TestRegisterWebhookConflict(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	provider := newMockedProvider()
+	provider.On("GetWebhookDBAL").Return(dbal)
+
+	newWebhook := &asset.NewWebhook{
+		Key:    "08680966-97ae-4573-8b2d-6c4db2b3c532",
+		Url:    "https://example.com/hook",
+		Secret: "0123456789abcdef",
+	}
+
+	dbal.On("WebhookExists", newWebhook.Key).Once().Return(true, nil)
+
+	service := NewWebhookService(provider)
+
+	_, err := service.RegisterWebhook(newWebhook, "owner")
+	orcError := new(orcerrors.OrcError)
+	require.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrConflict, orcError.Kind)
+
+	dbal.AssertExpectations(t)
+}
+
+func TestUpdateWebhook(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	provider := newMockedProvider()
+	provider.On("GetWebhookDBAL").Return(dbal)
+
+	param := &asset.UpdateWebhookParam{
+		Key:    "08680966-97ae-4573-8b2d-6c4db2b3c532",
+		Url:    "https://example.com/other",
+		Filter: &asset.EventQueryFilter{EventKind: asset.EventKind_EVENT_ASSET_CREATED},
+	}
+	stored := &asset.Webhook{Key: param.Key, Owner: "owner", Url: "https://example.com/hook"}
+	expected := &asset.Webhook{Key: param.Key, Owner: "owner", Url: param.Url, Filter: param.Filter}
+
+	dbal.On("GetWebhook", param.Key).Twice().Return(stored, nil)
+	dbal.On("UpdateWebhook", expected, "").Once().Return(nil)
+
+	service := NewWebhookService(provider)
+
+	_, err := service.UpdateWebhook(param, "other")
+	orcError := new(orcerrors.OrcError)
+	require.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrPermissionDenied, orcError.Kind)
+
+	webhook, err := service.UpdateWebhook(param, "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, webhook)
+
+	dbal.AssertExpectations(t)
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	provider := newMockedProvider()
+	provider.On("GetWebhookDBAL").Return(dbal)
+
+	dbal.On("GetWebhook", "uuid").Once().Return(&asset.Webhook{Key: "uuid", Owner: "owner"}, nil)
+	dbal.On("DeleteWebhook", "uuid").Once().Return(nil)
+
+	service := NewWebhookService(provider)
+
+	err := service.DeleteWebhook("uuid", "owner")
+	assert.NoError(t, err)
+
+	dbal.AssertExpectations(t)
+}
+
+func TestWebhookRetryPolicyBackoff(t *testing.T) {
+	policy := &WebhookRetryPolicy{MaxAttempts: 10, InitialBackoff: time.Minute, MaxBackoff: 5 * time.Minute}
+
+	assert.Equal(t, time.Minute, policy.backoff(1))
+	assert.Equal(t, 2*time.Minute, policy.backoff(2))
+	assert.Equal(t, 4*time.Minute, policy.backoff(3))
+	assert.Equal(t, 5*time.Minute, policy.backoff(4))
+	assert.Equal(t, 5*time.Minute, policy.backoff(9))
+}
+
+func TestDispatchWebhookEvents(t *testing.T) {
+	policy := &WebhookRetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute, MaxBackoff: time.Hour}
+	now := time.Unix(1337, 0)
+	webhook := &asset.Webhook{Key: "hook", Owner: "owner", Url: "https://example.com/hook"}
+	events := []*asset.Event{{Id: "event1"}, {Id: "event2"}}
+
+	cases := map[string]struct {
+		// existing delivery of the first event, nil if it has not been attempted yet
+		existing *asset.WebhookDelivery
+		// sendErr is returned by the sender for the first event
+		sendErr  error
+		expected []*asset.WebhookDelivery
+		// cursor is the event after which the next dispatch starts, empty if it does not move
+		cursor string
+	}{
+		"success": {
+			expected: []*asset.WebhookDelivery{
+				{Id: "delivery1", WebhookKey: "hook", EventId: "event1", Status: asset.WebhookDeliveryStatus_DELIVERY_SUCCEEDED, Attempts: 1, CreationDate: timestamppb.New(now), LastAttemptDate: timestamppb.New(now), ResponseCode: 200},
+				{Id: "delivery2", WebhookKey: "hook", EventId: "event2", Status: asset.WebhookDeliveryStatus_DELIVERY_SUCCEEDED, Attempts: 1, CreationDate: timestamppb.New(now), LastAttemptDate: timestamppb.New(now), ResponseCode: 200},
+			},
+			cursor: "event3",
+		},
+		"retry": {
+			sendErr: fmt.Errorf("unexpected status code 500"),
+			expected: []*asset.WebhookDelivery{
+				{Id: "delivery1", WebhookKey: "hook", EventId: "event1", Status: asset.WebhookDeliveryStatus_DELIVERY_PENDING, Attempts: 1, CreationDate: timestamppb.New(now), LastAttemptDate: timestamppb.New(now), NextAttemptDate: timestamppb.New(now.Add(time.Minute)), ResponseCode: 500, Error: "unexpected status code 500"},
+			},
+		},
+		"dead letter": {
+			existing: &asset.WebhookDelivery{Id: "existing", WebhookKey: "hook", EventId: "event1", Status: asset.WebhookDeliveryStatus_DELIVERY_PENDING, Attempts: 2, CreationDate: timestamppb.New(time.Unix(1000, 0)), NextAttemptDate: timestamppb.New(now)},
+			sendErr:  fmt.Errorf("unexpected status code 500"),
+			expected: []*asset.WebhookDelivery{
+				{Id: "existing", WebhookKey: "hook", EventId: "event1", Status: asset.WebhookDeliveryStatus_DELIVERY_DEAD_LETTER, Attempts: 3, CreationDate: timestamppb.New(time.Unix(1000, 0)), LastAttemptDate: timestamppb.New(now), ResponseCode: 500, Error: "unexpected status code 500"},
+				{Id: "delivery2", WebhookKey: "hook", EventId: "event2", Status: asset.WebhookDeliveryStatus_DELIVERY_SUCCEEDED, Attempts: 1, CreationDate: timestamppb.New(now), LastAttemptDate: timestamppb.New(now), ResponseCode: 200},
+			},
+			cursor: "event3",
+		},
+		"backing off": {
+			existing: &asset.WebhookDelivery{Id: "existing", WebhookKey: "hook", EventId: "event1", Status: asset.WebhookDeliveryStatus_DELIVERY_PENDING, Attempts: 1, NextAttemptDate: timestamppb.New(now.Add(time.Minute))},
+			expected: []*asset.WebhookDelivery{},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			dbal := new(persistence.MockDBAL)
+			ts := new(MockTimeAPI)
+			provider := newMockedProvider()
+			provider.On("GetWebhookDBAL").Return(dbal)
+			provider.On("GetTimeService").Return(ts)
+
+			ts.On("GetTransactionTime").Return(now)
+			dbal.On("NewWebhookClaimID").Once().Return("claim")
+			dbal.On("ClaimWebhook", "hook", "claim", now, now.Add(time.Minute)).Once().Return("secret", true, nil)
+			dbal.On("GetWebhook", "hook").Once().Return(webhook, nil)
+			// event3 does not match the filter of the webhook
+			dbal.On("GetWebhookEvents", webhook, uint32(10)).Once().Return(events, "event3", nil)
+
+			if c.existing != nil {
+				dbal.On("GetWebhookDelivery", "hook", "event1").Once().Return(c.existing, nil)
+			} else {
+				dbal.On("GetWebhookDelivery", "hook", "event1").Once().Return(nil, orcerrors.NewNotFound("webhook delivery", "event1"))
+				dbal.On("NewWebhookDeliveryID").Once().Return("delivery1")
+			}
+			dbal.On("GetWebhookDelivery", "hook", "event2").Maybe().Return(nil, orcerrors.NewNotFound("webhook delivery", "event2"))
+			dbal.On("NewWebhookDeliveryID").Maybe().Return("delivery2")
+
+			dbal.On("ReleaseWebhook", "hook", "claim").Once().Return(true, nil)
+			for i, expected := range c.expected {
+				if i == 0 && c.existing != nil {
+					dbal.On("UpdateWebhookDelivery", expected).Once().Return(nil)
+				} else {
+					dbal.On("AddWebhookDelivery", expected).Once().Return(nil)
+				}
+			}
+			if c.cursor != "" {
+				dbal.On("SetWebhookCursor", "hook", c.cursor).Once().Return(nil)
+			}
+
+			sender := func(w *asset.Webhook, secret string, delivery *asset.WebhookDelivery, event *asset.Event) (uint32, error) {
+				assert.Equal(t, webhook, w)
+				assert.Equal(t, "secret", secret)
+				if event.Id == "event1" && c.sendErr != nil {
+					return 500, c.sendErr
+				}
+				return 200, nil
+			}
+
+			service := NewWebhookService(provider)
+
+			dispatch, err := service.ClaimWebhookEvents("hook", time.Minute, 10)
+			require.NoError(t, err)
+			require.NotNil(t, dispatch)
+
+			dispatch.Send(sender, policy, time.Now().Add(time.Minute))
+
+			deliveries, err := service.RecordWebhookDispatch(dispatch, policy)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, deliveries)
+
+			dbal.AssertExpectations(t)
+			dbal.AssertNotCalled(t, "SetWebhookCursor", "hook", "event1")
+		})
+	}
+}
+
+func TestClaimWebhookEventsAlreadyClaimed(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	ts := new(MockTimeAPI)
+	provider := newMockedProvider()
+	provider.On("GetWebhookDBAL").Return(dbal)
+	provider.On("GetTimeService").Return(ts)
+
+	now := time.Unix(1337, 0)
+	ts.On("GetTransactionTime").Return(now)
+	dbal.On("NewWebhookClaimID").Once().Return("claim")
+	dbal.On("ClaimWebhook", "hook", "claim", now, now.Add(time.Minute)).Once().Return("", false, nil)
+
+	service := NewWebhookService(provider)
+
+	dispatch, err := service.ClaimWebhookEvents("hook", time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Nil(t, dispatch)
+
+	dbal.AssertExpectations(t)
+}
+
+func TestSendWebhookEventsDeadline(t *testing.T) {
+	dispatch := &WebhookDispatch{
+		Webhook:    &asset.Webhook{Key: "hook"},
+		events:     []*asset.Event{{Id: "event1"}},
+		deliveries: []*asset.WebhookDelivery{{Id: "delivery1"}},
+		isNew:      []bool{true},
+	}
+
+	sender := func(*asset.Webhook, string, *asset.WebhookDelivery, *asset.Event) (uint32, error) {
+		t.Error("no event should be sent after the deadline")
+		return 200, nil
+	}
+
+	dispatch.Send(sender, &WebhookRetryPolicy{MaxAttempts: 1}, time.Now().Add(-time.Second))
+	assert.Empty(t, dispatch.attempts)
+}
+
+func TestRecordWebhookDispatchExpiredClaim(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	provider := newMockedProvider()
+	provider.On("GetWebhookDBAL").Return(dbal)
+
+	dbal.On("ReleaseWebhook", "hook", "claim").Once().Return(false, nil)
+
+	dispatch := &WebhookDispatch{
+		Webhook:    &asset.Webhook{Key: "hook"},
+		ClaimID:    "claim",
+		events:     []*asset.Event{{Id: "event1"}},
+		deliveries: []*asset.WebhookDelivery{{Id: "delivery1"}},
+		isNew:      []bool{true},
+		attempts:   []webhookAttempt{{code: 200}},
+	}
+
+	service := NewWebhookService(provider)
+
+	deliveries, err := service.RecordWebhookDispatch(dispatch, &WebhookRetryPolicy{MaxAttempts: 1})
+	assert.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	dbal.AssertExpectations(t)
+}
+
+func TestRecordWebhookDispatchSaveError(t *testing.T) {
+	dbal := new(persistence.MockDBAL)
+	ts := new(MockTimeAPI)
+	provider := newMockedProvider()
+	provider.On("GetWebhookDBAL").Return(dbal)
+	provider.On("GetTimeService").Return(ts)
+
+	ts.On("GetTransactionTime").Return(time.Unix(1337, 0))
+	dbal.On("ReleaseWebhook", "hook", "claim").Once().Return(true, nil)
+	dbal.On("AddWebhookDelivery", mock.Anything).Once().Return(fmt.Errorf("db error"))
+
+	dispatch := &WebhookDispatch{
+		Webhook:    &asset.Webhook{Key: "hook"},
+		ClaimID:    "claim",
+		events:     []*asset.Event{{Id: "event1"}},
+		deliveries: []*asset.WebhookDelivery{{Id: "delivery1"}},
+		isNew:      []bool{true},
+		attempts:   []webhookAttempt{{code: 200}},
+	}
+
+	service := NewWebhookService(provider)
+
+	_, err := service.RecordWebhookDispatch(dispatch, &WebhookRetryPolicy{MaxAttempts: 1})
+	assert.Error(t, err)
+	assert.Equal(t, uint32(0), dispatch.deliveries[0].Attempts, "the dispatch should be left untouched for a retry")
+
+	dbal.AssertExpectations(t)
+}
@@ -17,4 +17,37 @@ type AppParameters struct {
 	GrpcOptions []grpc.ServerOption
 	Config      *OrchestratorConfiguration
 	RetryBudget time.Duration
+	// DatabaseReplicaURL is the url of a PostgreSQL read replica serving read-only requests, unused when empty
+	DatabaseReplicaURL string
+	// DBALInstrumentation enables the recording of metrics about each DBAL call
+	DBALInstrumentation bool
+	// DBALSlowCallThreshold is the duration above which DBAL calls are logged, zero disables the logging
+	DBALSlowCallThreshold time.Duration
+	// MaxReplicaLag is the replication lag above which read-only requests are served by the primary database
+	MaxReplicaLag time.Duration
+	// TaskLeaseReaperInterval is the delay between two checks of expired task leases
+	TaskLeaseReaperInterval time.Duration
+	// PlanPurgeInterval is the delay between two purges of expired compute plans
+	PlanPurgeInterval time.Duration
+	// PlanArchiveDir is the directory where compute plans are archived before being purged,
+	// plans are not archived when empty
+	PlanArchiveDir string
+	// WebhookDispatchInterval is the delay between two checks of events to push to webhooks
+	WebhookDispatchInterval time.Duration
+	// WebhookTimeout is the maximum duration of a request to a webhook endpoint
+	WebhookTimeout time.Duration
+	// WebhookMaxAttempts is the number of failed attempts after which a delivery is dead-lettered
+	WebhookMaxAttempts uint32
+	// EventRelayInterval is the delay between two checks of events to deliver to the event sinks
+	EventRelayInterval time.Duration
+	// EventSinkTimeout is the maximum duration of a delivery to an event sink
+	EventSinkTimeout time.Duration
+	// EventSubscriberBufferSize is the maximum number of event notifications pending for a subscriber
+	EventSubscriberBufferSize int
+	// EventSubscriptionMode determines how subscribers are notified of new events: "listen" or "poll"
+	EventSubscriptionMode string
+	// EventPollMinInterval is the delay between two polls of the events table after finding new events
+	EventPollMinInterval time.Duration
+	// EventPollMaxInterval is the maximum delay between two polls of the events table
+	EventPollMaxInterval time.Duration
 }
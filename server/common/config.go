@@ -2,6 +2,7 @@ package common
 
 import (
 	"os"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v2"
@@ -13,6 +14,38 @@ import (
 type OrchestratorConfiguration struct {
 	// map of channels -> organizations
 	Channels map[string][]string `yaml:"channels"`
+	// map of channels -> execution lease duration of compute tasks,
+	// leases are disabled on channels without duration.
+	TaskLeaseDurations map[string]time.Duration `yaml:"task_lease_durations"`
+	// map of channels -> number of days after which terminated compute plans are purged,
+	// plans are kept forever on channels without retention.
+	PlanRetentionDays map[string]uint32 `yaml:"plan_retention_days"`
+	// external sinks receiving the events of every channel
+	EventSinks []EventSinkConfiguration `yaml:"event_sinks"`
+}
+
+// EventSinkConfiguration describes an external destination of the events.
+type EventSinkConfiguration struct {
+	// Name identifies the sink, the delivery progress is recorded under this name
+	Name string `yaml:"name"`
+	// Type is either "file" or "http"
+	Type string `yaml:"type"`
+	// Path is the file to which a file sink appends the events
+	Path string `yaml:"path"`
+	// URL is the endpoint to which an http sink posts the events
+	URL string `yaml:"url"`
+}
+
+// GetTaskLeaseDuration returns the lease duration of executing tasks on the given channel.
+// A zero duration means that leases are disabled.
+func (c *OrchestratorConfiguration) GetTaskLeaseDuration(channel string) time.Duration {
+	return c.TaskLeaseDurations[channel]
+}
+
+// GetPlanRetention returns the duration during which terminated compute plans are kept on the given channel.
+// A zero duration means that plans are never purged automatically.
+func (c *OrchestratorConfiguration) GetPlanRetention(channel string) time.Duration {
+	return time.Duration(c.PlanRetentionDays[channel]) * 24 * time.Hour
 }
 
 // Version represents the version of the server, the value is changed at build time
@@ -0,0 +1,40 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+channels:
+  mychannel:
+    - MyOrg1MSP
+  yourchannel:
+    - MyOrg1MSP
+task_lease_durations:
+  mychannel: 5m
+plan_retention_days:
+  yourchannel: 30
+event_sinks:
+  - name: lake
+    type: http
+    url: https://lake.example.com/events
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	conf := NewConfig(path)
+
+	assert.Equal(t, []string{"MyOrg1MSP"}, conf.Channels["mychannel"])
+	assert.Equal(t, 5*time.Minute, conf.GetTaskLeaseDuration("mychannel"))
+	assert.Equal(t, time.Duration(0), conf.GetTaskLeaseDuration("yourchannel"), "leases should be disabled by default")
+	assert.Equal(t, 30*24*time.Hour, conf.GetPlanRetention("yourchannel"))
+	assert.Equal(t, time.Duration(0), conf.GetPlanRetention("mychannel"), "plans should be kept by default")
+	assert.Equal(t, []EventSinkConfiguration{{Name: "lake", Type: "http", URL: "https://lake.example.com/events"}}, conf.EventSinks)
+}
@@ -12,16 +12,18 @@ var ReadOnlyMethods = map[string][]string{
 	"Metric":        {"GetMetric", "QueryMetrics"},
 	"Organization":  {"GetAllOrganizations"},
 	"Function":      {"GetFunction", "QueryFunctions"},
-	"Event":         {"QueryEvents"},
+	"Event":         {"QueryEvents", "VerifyEventChain", "QueryEventConsumers"},
 	"Model":         {"GetComputeTaskOutputModels", "CanDisableModel", "GetModel"},
 	"Dataset":       {"GetDataset"},
 	"DataSample":    {"GetDataSample", "QueryDataSamples"},
 	"DataManager":   {"GetDataManager", "QueryDataManagers"},
 	"ComputeTask":   {"QueryTasks", "GetTask", "GetTaskInputAssets"},
-	"ComputePlan":   {"GetPlan", "QueryPlans", "IsPlanRunning"},
+	"ComputePlan":   {"GetPlan", "QueryPlans", "IsPlanRunning", "GetPlanStatistics", "GetPlanGraph"},
 	"Performance":   {"QueryPerformances"},
 	"Info":          {"QueryVersion"},
 	"FailureReport": {"GetFailureReport"},
+	"Archive":       {"ArchivePlan"},
+	"Webhook":       {"GetWebhook", "QueryWebhooks", "QueryWebhookDeliveries"},
 }
 
 // TransactionChecker is able to characterize a transaction based on the gRPC method.
@@ -44,7 +44,7 @@ func (i *ChannelInterceptor) UnaryServerInterceptor(ctx context.Context, req int
 		}
 	}
 
-	newCtx, err := i.extractFromContext(ctx)
+	newCtx, err := i.extractFromContext(ctx, info.FullMethod)
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +58,7 @@ func (i *ChannelInterceptor) StreamServerInterceptor(
 	info *grpc.StreamServerInfo,
 	handler grpc.StreamHandler,
 ) error {
-	newCtx, err := i.extractFromContext(stream.Context())
+	newCtx, err := i.extractFromContext(stream.Context(), info.FullMethod)
 	if err != nil {
 		return err
 	}
@@ -67,7 +67,7 @@ func (i *ChannelInterceptor) StreamServerInterceptor(
 	return handler(srv, streamWithContext)
 }
 
-func (i *ChannelInterceptor) extractFromContext(ctx context.Context) (context.Context, error) {
+func (i *ChannelInterceptor) extractFromContext(ctx context.Context, method string) (context.Context, error) {
 	org, err := ExtractMSPID(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract organization: %w", err)
@@ -78,6 +78,12 @@ func (i *ChannelInterceptor) extractFromContext(ctx context.Context) (context.Co
 		return nil, errors.New("could not extract metadata")
 	}
 
+	for _, m := range MultiChannelMethods {
+		if strings.Contains(method, m) {
+			return i.extractChannels(ctx, org, md.Get(headerChannel))
+		}
+	}
+
 	if len(md.Get(headerChannel)) != 1 {
 		return nil, fmt.Errorf("missing or invalid header '%s'", headerChannel)
 	}
@@ -91,6 +97,31 @@ func (i *ChannelInterceptor) extractFromContext(ctx context.Context) (context.Co
 	return WithChannel(ctx, channel), nil
 }
 
+// extractChannels checks that the organization belongs to every requested channel
+// and makes the list of channels available from the request context, see ExtractChannels.
+func (i *ChannelInterceptor) extractChannels(ctx context.Context, org string, headers []string) (context.Context, error) {
+	if len(headers) == 0 {
+		return nil, fmt.Errorf("missing header '%s'", headerChannel)
+	}
+
+	channels := make([]string, 0, len(headers))
+	seen := make(map[string]bool, len(headers))
+
+	for _, channel := range headers {
+		if seen[channel] {
+			continue
+		}
+		seen[channel] = true
+
+		if err := i.checkOrgBelongsToChannel(org, channel); err != nil {
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+
+	return WithChannels(ctx, channels), nil
+}
+
 func (i *ChannelInterceptor) checkOrgBelongsToChannel(org, channel string) error {
 	channels, ok := i.orgChannels[org]
 	if !ok {
@@ -124,3 +155,22 @@ func ExtractChannel(ctx context.Context) (string, error) {
 	}
 	return channel, nil
 }
+
+type ctxChannelsMarker struct{}
+
+var ctxChannelsKey = &ctxChannelsMarker{}
+
+// WithChannels add the channels of a multi-channel request to a context
+func WithChannels(ctx context.Context, channels []string) context.Context {
+	return context.WithValue(ctx, ctxChannelsKey, channels)
+}
+
+// ExtractChannels retrieves the channels of a multi-channel request from its context,
+// channels are expected to be set by ChannelInterceptor for the methods listed in MultiChannelMethods.
+func ExtractChannels(ctx context.Context) ([]string, error) {
+	channels, ok := ctx.Value(ctxChannelsKey).([]string)
+	if !ok {
+		return nil, errors.New("channels not found in context")
+	}
+	return channels, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/substra/orchestrator/server/common"
+	"google.golang.org/grpc/metadata"
 )
 
 func TestNewChannelInterceptor(t *testing.T) {
@@ -83,3 +84,61 @@ func TestExtractChannel(t *testing.T) {
 	_, err = ExtractChannel(ctx)
 	assert.Error(t, err, "Extraction should fail on empty context")
 }
+
+func TestExtractMultipleChannels(t *testing.T) {
+	config := &common.OrchestratorConfiguration{
+		Channels: map[string][]string{
+			"mychannel":    {"org1", "org2"},
+			"yourchannel":  {"org1", "org2"},
+			"theirchannel": {"org2", "org3"},
+		},
+	}
+	interceptor := NewChannelInterceptor(config)
+
+	cases := map[string]struct {
+		method   string
+		channels []string
+		valid    bool
+		expected []string
+	}{
+		"several channels": {
+			method:   "/orchestrator.EventService/SubscribeToChannels",
+			channels: []string{"mychannel", "yourchannel", "mychannel"},
+			valid:    true,
+			expected: []string{"mychannel", "yourchannel"},
+		},
+		"not in channel": {
+			method:   "/orchestrator.EventService/SubscribeToChannels",
+			channels: []string{"mychannel", "theirchannel"},
+			valid:    false,
+		},
+		"missing channel": {
+			method: "/orchestrator.EventService/SubscribeToChannels",
+			valid:  false,
+		},
+		"single channel method": {
+			method:   "/orchestrator.EventService/SubscribeToEvents",
+			channels: []string{"mychannel", "yourchannel"},
+			valid:    false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			md := metadata.MD{}
+			md.Append(headerChannel, tc.channels...)
+			ctx := metadata.NewIncomingContext(context.WithValue(context.Background(), CtxMSPIDKey, "org1"), md)
+
+			newCtx, err := interceptor.extractFromContext(ctx, tc.method)
+			if !tc.valid {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			channels, err := ExtractChannels(newCtx)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, channels)
+		})
+	}
+}
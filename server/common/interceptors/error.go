@@ -6,10 +6,12 @@ import (
 	"strings"
 
 	"github.com/rs/zerolog/log"
+	"github.com/substra/orchestrator/lib/asset"
 	orcerrors "github.com/substra/orchestrator/lib/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
 )
 
 // Although we are in common module, this file contains two separate implementations for error interception.
@@ -66,6 +68,8 @@ func fromMessage(msg string) error {
 		return status.Error(codes.InvalidArgument, msg)
 	case strings.Contains(msg, orcerrors.ErrIncompatibleKind):
 		return status.Error(codes.InvalidArgument, msg)
+	case strings.Contains(msg, orcerrors.ErrPausedComputePlan):
+		return status.Error(codes.FailedPrecondition, msg)
 	case strings.Contains(msg, orcerrors.ErrInternal):
 		return status.Error(codes.Internal, msg)
 	default:
@@ -73,7 +77,8 @@ func fromMessage(msg string) error {
 	}
 }
 
-// fromError converts an error to a gRPC status by matching its error type
+// fromError converts an error to a gRPC status by matching its error type.
+// Per-field validation errors are attached to the status as ValidationErrorDetail.
 func fromError(err error) error {
 	if err == nil {
 		return nil
@@ -84,30 +89,60 @@ func fromError(err error) error {
 		return status.Error(codes.Unknown, err.Error())
 	}
 
-	switch {
-	case orcError.Kind == orcerrors.ErrInvalidAsset:
-		return status.Error(codes.InvalidArgument, err.Error())
-	case orcError.Kind == orcerrors.ErrConflict:
-		return status.Error(codes.AlreadyExists, err.Error())
-	case orcError.Kind == orcerrors.ErrPermissionDenied:
-		return status.Error(codes.PermissionDenied, err.Error())
-	case orcError.Kind == orcerrors.ErrNotFound:
-		return status.Error(codes.NotFound, err.Error())
-	case orcError.Kind == orcerrors.ErrBadRequest:
-		return status.Error(codes.FailedPrecondition, err.Error())
-	case orcError.Kind == orcerrors.ErrIncompatibleTaskStatus:
-		return status.Error(codes.InvalidArgument, err.Error())
-	case orcError.Kind == orcerrors.ErrUnimplemented:
-		return status.Error(codes.Unimplemented, err.Error())
-	case orcError.Kind == orcerrors.ErrCannotDisableModel:
-		return status.Error(codes.InvalidArgument, err.Error())
-	case orcError.Kind == orcerrors.ErrMissingTaskOutput:
-		return status.Error(codes.InvalidArgument, err.Error())
-	case orcError.Kind == orcerrors.ErrIncompatibleKind:
-		return status.Error(codes.InvalidArgument, err.Error())
-	case orcError.Kind == orcerrors.ErrInternal:
-		return status.Error(codes.Internal, err.Error())
+	st := status.New(codeFromKind(orcError.Kind), err.Error())
+
+	if len(orcError.Details()) > 0 {
+		details := make([]protoadapt.MessageV1, 0, len(orcError.Details()))
+		for _, d := range orcError.Details() {
+			details = append(details, &asset.ValidationErrorDetail{
+				Index:    uint32(d.Index),
+				AssetKey: d.AssetKey,
+				Field:    d.Field,
+				Code:     d.Kind,
+				Message:  d.Msg,
+			})
+		}
+
+		withDetails, detailsErr := st.WithDetails(details...)
+		if detailsErr != nil {
+			// Details are a convenience, the status itself is still meaningful
+			log.Error().Err(detailsErr).Msg("failed to attach error details")
+		} else {
+			st = withDetails
+		}
+	}
+
+	return st.Err()
+}
+
+// codeFromKind returns the gRPC status code matching an orchestration error kind
+func codeFromKind(kind orcerrors.ErrorKind) codes.Code {
+	switch kind {
+	case orcerrors.ErrInvalidAsset:
+		return codes.InvalidArgument
+	case orcerrors.ErrConflict:
+		return codes.AlreadyExists
+	case orcerrors.ErrPermissionDenied:
+		return codes.PermissionDenied
+	case orcerrors.ErrNotFound:
+		return codes.NotFound
+	case orcerrors.ErrBadRequest:
+		return codes.FailedPrecondition
+	case orcerrors.ErrIncompatibleTaskStatus:
+		return codes.InvalidArgument
+	case orcerrors.ErrUnimplemented:
+		return codes.Unimplemented
+	case orcerrors.ErrCannotDisableModel:
+		return codes.InvalidArgument
+	case orcerrors.ErrMissingTaskOutput:
+		return codes.InvalidArgument
+	case orcerrors.ErrIncompatibleKind:
+		return codes.InvalidArgument
+	case orcerrors.ErrPausedComputePlan:
+		return codes.FailedPrecondition
+	case orcerrors.ErrInternal:
+		return codes.Internal
 	default:
-		return status.Error(codes.Unknown, err.Error())
+		return codes.Unknown
 	}
 }
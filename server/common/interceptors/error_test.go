@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/substra/orchestrator/lib/asset"
 	"github.com/substra/orchestrator/lib/errors"
 	"google.golang.org/grpc/codes"
@@ -28,6 +29,9 @@ func TestStatusConversion(t *testing.T) {
 		"internal":                 {err: errors.NewInternal("test"), code: codes.Internal},
 		"missing task output":      {err: errors.NewMissingTaskOutput("test", "output"), code: codes.InvalidArgument},
 		"incompatible task output": {err: errors.NewIncompatibleTaskOutput("test", "output", asset.AssetKind_ASSET_MODEL.String(), asset.AssetKind_ASSET_PERFORMANCE.String()), code: codes.InvalidArgument},
+		"terminated plan":          {err: errors.NewTerminatedComputePlan("test"), code: codes.Unknown},
+		"terminated task":          {err: errors.NewTerminatedComputeTask("test"), code: codes.Unknown},
+		"paused plan":              {err: errors.NewPausedComputePlan("test"), code: codes.FailedPrecondition},
 	}
 
 	for name, tc := range cases {
@@ -42,3 +46,24 @@ func TestStatusConversion(t *testing.T) {
 
 	assert.Nil(t, fromError(nil), "nil should not be mapped")
 }
+
+func TestStatusDetails(t *testing.T) {
+	err := errors.FromValidationErrors(asset.ComputeTaskKind, []*errors.FieldError{
+		{Index: 0, AssetKey: "uuid1", Field: "key", Kind: errors.ErrInvalidAsset, Msg: "must be a valid UUID"},
+		{Index: 2, AssetKey: "uuid2", Field: "inputs.identifier", Kind: errors.ErrInvalidAsset, Msg: "cannot be blank"},
+	})
+
+	st := status.Convert(fromError(err))
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+
+	details := st.Details()
+	require.Len(t, details, 2)
+
+	detail, ok := details[1].(*asset.ValidationErrorDetail)
+	require.True(t, ok)
+	assert.Equal(t, uint32(2), detail.Index)
+	assert.Equal(t, "uuid2", detail.AssetKey)
+	assert.Equal(t, "inputs.identifier", detail.Field)
+	assert.Equal(t, errors.ErrInvalidAsset, detail.Code)
+	assert.Equal(t, "cannot be blank", detail.Message)
+}
@@ -3,3 +3,9 @@ package interceptors
 var IgnoredMethods = [...]string{
 	"grpc.health",
 }
+
+// MultiChannelMethods are the methods which can be called on several channels at once,
+// by repeating the channel header.
+var MultiChannelMethods = [...]string{
+	"SubscribeToChannels",
+}
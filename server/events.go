@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/substra/orchestrator/server/common"
+	"github.com/substra/orchestrator/server/standalone"
+	"github.com/substra/orchestrator/server/standalone/dbal"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const eventsUsage = `usage: orchestrator events <command> [flags]
+
+Commands:
+  export  write the events of a channel to a newline delimited JSON file
+  import  load events written by export into a channel
+  verify  check that the events of a channel have not been altered
+
+Run "orchestrator events <command> -h" for the flags of a command.
+`
+
+// runEventsCommand runs the "events" subcommand with the given arguments and returns the process exit code.
+// It operates on the database referenced by the DATABASE_URL environment variable.
+func runEventsCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, eventsUsage)
+		return 2
+	}
+
+	var err error
+
+	switch args[0] {
+	case "export":
+		err = exportEvents(args[1:])
+	case "import":
+		err = importEvents(args[1:])
+	case "verify":
+		err = verifyEvents(args[1:])
+	default:
+		fmt.Fprint(os.Stderr, eventsUsage)
+		return 2
+	}
+
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		log.Error().Err(err).Str("command", args[0]).Msg("events command failed")
+		return 1
+	}
+
+	return 0
+}
+
+func exportEvents(args []string) error {
+	flags := flag.NewFlagSet("events export", flag.ContinueOnError)
+	channel := flags.String("channel", "", "channel of the events to export (required)")
+	fromPosition := flags.Uint64("from-position", 0, "position of the first event to export")
+	toPosition := flags.Uint64("to-position", 0, "position of the last event to export, 0 to export up to the last event")
+	since := flags.String("since", "", "only export the events emitted at or after this RFC 3339 timestamp")
+	until := flags.String("until", "", "only export the events emitted at or before this RFC 3339 timestamp")
+	output := flags.String("output", "-", "file to write the events to, - for the standard output")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *channel == "" {
+		return fmt.Errorf("missing -channel flag")
+	}
+
+	r := &standalone.EventRange{FromPosition: *fromPosition, ToPosition: *toPosition}
+	if r.Start, err = parseOptionalTime(*since); err != nil {
+		return err
+	}
+	if r.End, err = parseOptionalTime(*until); err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if *output != "-" {
+		w, err = os.Create(*output)
+		if err != nil {
+			return err
+		}
+		// Only closes the file on failure, it is closed explicitly once the events are written
+		defer w.Close() //nolint:errcheck
+	}
+
+	db, err := dbal.InitDatabase(common.MustGetEnv("DATABASE_URL"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	count, err := standalone.ExportEvents(context.Background(), db, *channel, r, w)
+	if err != nil {
+		return err
+	}
+
+	if w != os.Stdout {
+		err = closeOutput(w)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("channel", *channel).Int("count", count).Msg("events exported")
+
+	return nil
+}
+
+// closeOutput flushes the file to disk and closes it.
+// Write errors may only be reported at this point, eg: on a full disk or a network file system.
+func closeOutput(f *os.File) error {
+	err := f.Sync()
+	if err != nil {
+		f.Close() //nolint:errcheck
+		return fmt.Errorf("failed to write %s: %w", f.Name(), err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.Name(), err)
+	}
+
+	return nil
+}
+
+func importEvents(args []string) error {
+	flags := flag.NewFlagSet("events import", flag.ContinueOnError)
+	channel := flags.String("channel", "", "channel to import the events into (required)")
+	input := flags.String("input", "-", "file to read the events from, - for the standard input")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *channel == "" {
+		return fmt.Errorf("missing -channel flag")
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	db, err := dbal.InitDatabase(common.MustGetEnv("DATABASE_URL"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	count, err := standalone.ImportEvents(context.Background(), db, *channel, r)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Str("channel", *channel).Int("count", count).Msg("events imported")
+
+	return nil
+}
+
+// verifyEvents prints the status of the hash chain of a channel and fails if the chain is broken.
+func verifyEvents(args []string) error {
+	flags := flag.NewFlagSet("events verify", flag.ContinueOnError)
+	channel := flags.String("channel", "", "channel of the events to verify (required)")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *channel == "" {
+		return fmt.Errorf("missing -channel flag")
+	}
+
+	db, err := dbal.InitDatabase(common.MustGetEnv("DATABASE_URL"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	status, err := standalone.VerifyEventChain(context.Background(), db, *channel)
+	if err != nil {
+		return err
+	}
+
+	output, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(status)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(output))
+
+	if !status.Valid {
+		return fmt.Errorf("event chain is broken at position %d", status.FirstBrokenLink.Position)
+	}
+
+	return nil
+}
+
+// parseOptionalTime parses an RFC 3339 timestamp, an empty string is the zero time.
+func parseOptionalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
@@ -29,6 +29,19 @@ import (
 
 const httpPort = "8484"
 const grpcPort = "9000"
+const defaultTaskLeaseReaperInterval = "30s"
+const defaultPlanPurgeInterval = "1h"
+const defaultWebhookDispatchInterval = "5s"
+const defaultWebhookTimeout = "10s"
+const defaultWebhookMaxAttempts = "8"
+const defaultEventRelayInterval = "5s"
+const defaultEventSinkTimeout = "30s"
+const defaultEventSubscriberBufferSize = "100"
+const defaultEventSubscriptionMode = "listen"
+const defaultEventPollMinInterval = "100ms"
+const defaultEventPollMaxInterval = "2s"
+const defaultMaxReplicaLag = "5s"
+const defaultDBALSlowCallThreshold = "100ms"
 
 func getStandaloneServer(params common.AppParameters, healthcheck *health.Server) common.Runnable {
 	dbURL := common.MustGetEnv("DATABASE_URL")
@@ -47,6 +60,10 @@ func main() {
 
 	utils.InitLogging()
 
+	if len(os.Args) > 1 && os.Args[1] == "events" {
+		os.Exit(runEventsCommand(os.Args[2:]))
+	}
+
 	serverOptions := []grpc.ServerOption{}
 	if tlsOptions := common.GetTLSOptions(); tlsOptions != nil {
 		serverOptions = append(serverOptions, tlsOptions)
@@ -57,10 +74,40 @@ func main() {
 
 	retryBudget := common.MustParseDuration(common.MustGetEnv("TX_RETRY_BUDGET"))
 
+	reaperInterval := common.MustParseDuration(common.GetEnvOrFallback("TASK_LEASE_REAPER_INTERVAL", defaultTaskLeaseReaperInterval))
+	purgeInterval := common.MustParseDuration(common.GetEnvOrFallback("PLAN_PURGE_INTERVAL", defaultPlanPurgeInterval))
+	webhookInterval := common.MustParseDuration(common.GetEnvOrFallback("WEBHOOK_DISPATCH_INTERVAL", defaultWebhookDispatchInterval))
+	webhookTimeout := common.MustParseDuration(common.GetEnvOrFallback("WEBHOOK_TIMEOUT", defaultWebhookTimeout))
+	webhookMaxAttempts := common.MustParseInt(common.GetEnvOrFallback("WEBHOOK_MAX_ATTEMPTS", defaultWebhookMaxAttempts))
+	relayInterval := common.MustParseDuration(common.GetEnvOrFallback("EVENT_RELAY_INTERVAL", defaultEventRelayInterval))
+	sinkTimeout := common.MustParseDuration(common.GetEnvOrFallback("EVENT_SINK_TIMEOUT", defaultEventSinkTimeout))
+	subscriberBufferSize := common.MustParseInt(common.GetEnvOrFallback("EVENT_SUBSCRIBER_BUFFER_SIZE", defaultEventSubscriberBufferSize))
+	pollMinInterval := common.MustParseDuration(common.GetEnvOrFallback("EVENT_POLL_MIN_INTERVAL", defaultEventPollMinInterval))
+	pollMaxInterval := common.MustParseDuration(common.GetEnvOrFallback("EVENT_POLL_MAX_INTERVAL", defaultEventPollMaxInterval))
+	maxReplicaLag := common.MustParseDuration(common.GetEnvOrFallback("MAX_REPLICA_LAG", defaultMaxReplicaLag))
+	dbalInstrumentation := common.MustParseBool(common.GetEnvOrFallback("DBAL_INSTRUMENTATION_ENABLED", "false"))
+	dbalSlowCallThreshold := common.MustParseDuration(common.GetEnvOrFallback("DBAL_SLOW_CALL_THRESHOLD", defaultDBALSlowCallThreshold))
+
 	params := common.AppParameters{
-		GrpcOptions: serverOptions,
-		Config:      orchestrationConfig,
-		RetryBudget: retryBudget,
+		GrpcOptions:               serverOptions,
+		Config:                    orchestrationConfig,
+		RetryBudget:               retryBudget,
+		DatabaseReplicaURL:        common.GetEnvOrFallback("DATABASE_REPLICA_URL", ""),
+		MaxReplicaLag:             maxReplicaLag,
+		DBALInstrumentation:       dbalInstrumentation,
+		DBALSlowCallThreshold:     dbalSlowCallThreshold,
+		TaskLeaseReaperInterval:   reaperInterval,
+		PlanPurgeInterval:         purgeInterval,
+		PlanArchiveDir:            common.GetEnvOrFallback("PLAN_ARCHIVE_DIR", ""),
+		WebhookDispatchInterval:   webhookInterval,
+		WebhookTimeout:            webhookTimeout,
+		WebhookMaxAttempts:        uint32(webhookMaxAttempts),
+		EventRelayInterval:        relayInterval,
+		EventSinkTimeout:          sinkTimeout,
+		EventSubscriberBufferSize: subscriberBufferSize,
+		EventSubscriptionMode:     common.GetEnvOrFallback("EVENT_SUBSCRIPTION_MODE", defaultEventSubscriptionMode),
+		EventPollMinInterval:      pollMinInterval,
+		EventPollMaxInterval:      pollMaxInterval,
 	}
 
 	ctx := context.Background()
@@ -3,7 +3,6 @@ package dbal
 import (
 	"database/sql"
 	"errors"
-	"strconv"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -20,20 +19,26 @@ type sqlComputePlan struct {
 	CreationDate    time.Time
 	CancelationDate sql.NullTime
 	FailureDate     sql.NullTime
+	PauseDate       sql.NullTime
+	ResumeDate      sql.NullTime
+	PurgeDate       sql.NullTime
+	PurgeSummary    *asset.ComputePlanPurgeSummary
 	Tag             string
 	Name            string
 	Metadata        map[string]string
+	MaxTaskAttempts uint32
 }
 
 // toComputePlan returns a compute plan.
 func (cp *sqlComputePlan) toComputePlan() *asset.ComputePlan {
 	res := &asset.ComputePlan{
-		Key:          cp.Key,
-		Owner:        cp.Owner,
-		CreationDate: timestamppb.New(cp.CreationDate),
-		Tag:          cp.Tag,
-		Name:         cp.Name,
-		Metadata:     cp.Metadata,
+		Key:             cp.Key,
+		Owner:           cp.Owner,
+		CreationDate:    timestamppb.New(cp.CreationDate),
+		Tag:             cp.Tag,
+		Name:            cp.Name,
+		Metadata:        cp.Metadata,
+		MaxTaskAttempts: cp.MaxTaskAttempts,
 	}
 
 	if cp.CancelationDate.Valid {
@@ -41,6 +46,16 @@ func (cp *sqlComputePlan) toComputePlan() *asset.ComputePlan {
 	} else if cp.FailureDate.Valid {
 		res.FailureDate = timestamppb.New(cp.FailureDate.Time)
 	}
+	if cp.PauseDate.Valid {
+		res.PauseDate = timestamppb.New(cp.PauseDate.Time)
+	}
+	if cp.ResumeDate.Valid {
+		res.ResumeDate = timestamppb.New(cp.ResumeDate.Time)
+	}
+	if cp.PurgeDate.Valid {
+		res.PurgeDate = timestamppb.New(cp.PurgeDate.Time)
+		res.PurgeSummary = cp.PurgeSummary
+	}
 	return res
 }
 
@@ -66,8 +81,8 @@ func (d *DBAL) ComputePlanExists(key string) (bool, error) {
 func (d *DBAL) AddComputePlan(plan *asset.ComputePlan) error {
 	stmt := getStatementBuilder().
 		Insert("compute_plans").
-		Columns("key", "channel", "owner", "creation_date", "tag", "name", "metadata").
-		Values(plan.Key, d.channel, plan.Owner, plan.CreationDate.AsTime(), plan.Tag, plan.Name, plan.Metadata)
+		Columns("key", "channel", "owner", "creation_date", "tag", "name", "metadata", "max_task_attempts").
+		Values(plan.Key, d.channel, plan.Owner, plan.CreationDate.AsTime(), plan.Tag, plan.Name, plan.Metadata, plan.MaxTaskAttempts)
 
 	return d.exec(stmt)
 }
@@ -75,7 +90,7 @@ func (d *DBAL) AddComputePlan(plan *asset.ComputePlan) error {
 // GetComputePlan fetches a given compute plan
 func (d *DBAL) GetComputePlan(key string) (*asset.ComputePlan, error) {
 	stmt := getStatementBuilder().
-		Select("key", "owner", "creation_date", "cancelation_date", "failure_date", "tag", "name", "metadata").
+		Select("key", "owner", "creation_date", "cancelation_date", "failure_date", "tag", "name", "metadata", "max_task_attempts", "pause_date", "resume_date", "purge_date", "purge_summary").
 		From("compute_plans").
 		Where(sq.Eq{"key": key, "channel": d.channel})
 
@@ -85,7 +100,7 @@ func (d *DBAL) GetComputePlan(key string) (*asset.ComputePlan, error) {
 	}
 
 	pl := new(sqlComputePlan)
-	err = row.Scan(&pl.Key, &pl.Owner, &pl.CreationDate, &pl.CancelationDate, &pl.FailureDate, &pl.Tag, &pl.Name, &pl.Metadata)
+	err = row.Scan(&pl.Key, &pl.Owner, &pl.CreationDate, &pl.CancelationDate, &pl.FailureDate, &pl.Tag, &pl.Name, &pl.Metadata, &pl.MaxTaskAttempts, &pl.PauseDate, &pl.ResumeDate, &pl.PurgeDate, &pl.PurgeSummary)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, orcerrors.NewNotFound("computeplan", key)
@@ -97,24 +112,22 @@ func (d *DBAL) GetComputePlan(key string) (*asset.ComputePlan, error) {
 }
 
 func (d *DBAL) QueryComputePlans(p *common.Pagination, filter *asset.PlanQueryFilter) ([]*asset.ComputePlan, common.PaginationToken, error) {
-	offset, err := getOffset(p.Token)
+	pg, err := newPage(p, newKeyset(false, "creation_date", "key"))
 	if err != nil {
 		return nil, "", err
 	}
 
 	stmt := getStatementBuilder().
-		Select("key", "owner", "creation_date", "cancelation_date", "failure_date", "tag", "name", "metadata").
+		Select("key", "owner", "creation_date", "cancelation_date", "failure_date", "tag", "name", "metadata", "max_task_attempts", "pause_date", "resume_date", "purge_date", "purge_summary").
 		From("compute_plans").
-		Where(sq.Eq{"channel": d.channel}).
-		OrderBy("creation_date ASC, key ASC").
-		Offset(uint64(offset)).
-		// Fetch page size + 1 elements to determine whether there is a next page
-		Limit(uint64(p.Size + 1))
+		Where(sq.Eq{"channel": d.channel})
 
 	if filter != nil && filter.Owner != "" {
 		stmt = stmt.Where(sq.Eq{"owner": filter.Owner})
 	}
 
+	stmt = pg.apply(stmt)
+
 	rows, err := d.query(stmt)
 	if err != nil {
 		return nil, "", err
@@ -123,16 +136,18 @@ func (d *DBAL) QueryComputePlans(p *common.Pagination, filter *asset.PlanQueryFi
 
 	var plans []*asset.ComputePlan
 	var count int
+	var last *sqlComputePlan
 
 	for rows.Next() {
 		pl := new(sqlComputePlan)
 
-		err = rows.Scan(&pl.Key, &pl.Owner, &pl.CreationDate, &pl.CancelationDate, &pl.FailureDate, &pl.Tag, &pl.Name, &pl.Metadata)
+		err = rows.Scan(&pl.Key, &pl.Owner, &pl.CreationDate, &pl.CancelationDate, &pl.FailureDate, &pl.Tag, &pl.Name, &pl.Metadata, &pl.MaxTaskAttempts, &pl.PauseDate, &pl.ResumeDate, &pl.PurgeDate, &pl.PurgeSummary)
 		if err != nil {
 			return nil, "", err
 		}
 
 		plans = append(plans, pl.toComputePlan())
+		last = pl
 		count++
 
 		if count == int(p.Size) {
@@ -146,7 +161,10 @@ func (d *DBAL) QueryComputePlans(p *common.Pagination, filter *asset.PlanQueryFi
 	bookmark := ""
 	if count == int(p.Size) && rows.Next() {
 		// there is more to fetch
-		bookmark = strconv.Itoa(offset + count)
+		bookmark, err = pg.nextToken(cursorTime(last.CreationDate), last.Key)
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
 	return plans, bookmark, nil
@@ -173,6 +191,18 @@ func (d *DBAL) FailComputePlan(plan *asset.ComputePlan, failureDate time.Time) e
 	return d.updateComputePlan(plan.Key, "failure_date", failureDate)
 }
 
+func (d *DBAL) RestoreComputePlan(plan *asset.ComputePlan) error {
+	return d.updateComputePlan(plan.Key, "failure_date", nil)
+}
+
+func (d *DBAL) PauseComputePlan(plan *asset.ComputePlan, pauseDate time.Time) error {
+	return d.updateComputePlan(plan.Key, "pause_date", pauseDate)
+}
+
+func (d *DBAL) ResumeComputePlan(plan *asset.ComputePlan, resumeDate time.Time) error {
+	return d.updateComputePlan(plan.Key, "resume_date", resumeDate)
+}
+
 func (d *DBAL) ArePlanTasksRunning(key string) (bool, error) {
 	stmt := getStatementBuilder().
 		Select("status",
@@ -207,3 +237,74 @@ func (d *DBAL) ArePlanTasksRunning(key string) (bool, error) {
 	// Reaching the end of the loop means all tasks are Done. The CP is terminated.
 	return false, err
 }
+
+// GetComputePlanStatistics counts the tasks of a compute plan by status and fetches the first task start
+// and the last task completion from the task status update events.
+func (d *DBAL) GetComputePlanStatistics(key string) (*asset.ComputePlanStatistics, error) {
+	stats := &asset.ComputePlanStatistics{
+		ComputePlanKey: key,
+		TaskCounts:     []*asset.TaskStatusCount{},
+	}
+
+	stmt := getStatementBuilder().
+		Select("status", "COUNT(1)").
+		From("compute_tasks").
+		Where(sq.Eq{"channel": d.channel, "compute_plan_key": key}).
+		GroupBy("status").
+		OrderBy("status")
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status string
+		var count uint32
+
+		err = rows.Scan(&status, &count)
+		if err != nil {
+			return nil, err
+		}
+
+		stats.TaskCounts = append(stats.TaskCounts, &asset.TaskStatusCount{
+			Status: asset.ComputeTaskStatus(asset.ComputeTaskStatus_value[status]),
+			Count:  count,
+		})
+		stats.TaskCount += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stmt = getStatementBuilder().
+		Select().
+		Column(sq.Expr("MIN(timestamp) FILTER (WHERE asset->>'status' = ?)", asset.ComputeTaskStatus_STATUS_EXECUTING.String())).
+		Column(sq.Expr("MAX(timestamp) FILTER (WHERE asset->>'status' = ?)", asset.ComputeTaskStatus_STATUS_DONE.String())).
+		From("events").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Eq{"asset_kind": asset.AssetKind_ASSET_COMPUTE_TASK.String()}).
+		Where(sq.Eq{"event_kind": asset.EventKind_EVENT_ASSET_UPDATED.String()}).
+		Where(sq.Expr("asset->>'computePlanKey' = ?", key))
+
+	row, err := d.queryRow(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	var firstStart, lastCompletion sql.NullTime
+	err = row.Scan(&firstStart, &lastCompletion)
+	if err != nil {
+		return nil, err
+	}
+
+	if firstStart.Valid {
+		stats.FirstTaskStartDate = timestamppb.New(firstStart.Time)
+	}
+	if lastCompletion.Valid {
+		stats.LastTaskCompletionDate = timestamppb.New(lastCompletion.Time)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,415 @@
+package dbal
+
+import (
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// planTaskKeys returns a subquery selecting the keys of the tasks of a compute plan.
+// Keys are converted to text so that they can be compared to event asset keys.
+// Like other subqueries, it keeps the default placeholders which are numbered by the enclosing statement.
+func (d *DBAL) planTaskKeys(key string, asText bool) sq.SelectBuilder {
+	column := "key"
+	if asText {
+		column = "key::text"
+	}
+
+	return sq.
+		Select(column).
+		From("compute_tasks").
+		Where(sq.Eq{"channel": d.channel, "compute_plan_key": key})
+}
+
+// planEventsCondition selects the events related to the tasks of a compute plan and to their outputs.
+// Events of the compute plan itself are not selected.
+func (d *DBAL) planEventsCondition(key string) sq.Sqlizer {
+	return sq.Or{
+		sq.Expr("asset_key IN (?)", d.planTaskKeys(key, true)),
+		sq.Expr("asset->>'computeTaskKey' IN (?)", d.planTaskKeys(key, true)),
+	}
+}
+
+// GetComputePlanArchive returns the compute plan along with its tasks and every related asset.
+func (d *DBAL) GetComputePlanArchive(key string) (*asset.ComputePlanArchive, error) {
+	plan, err := d.GetComputePlan(key)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := d.GetComputePlanTasks(key)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &asset.ComputePlanArchive{
+		ComputePlan: plan,
+		Tasks:       tasks,
+	}
+
+	archive.Models, err = d.getPlanModels(key)
+	if err != nil {
+		return nil, err
+	}
+
+	archive.Performances, err = d.getPlanPerformances(key)
+	if err != nil {
+		return nil, err
+	}
+
+	archive.FailureReports, err = d.getPlanFailureReports(key)
+	if err != nil {
+		return nil, err
+	}
+
+	archive.OutputAssets, err = d.getPlanOutputAssets(key)
+	if err != nil {
+		return nil, err
+	}
+
+	archive.Events, err = d.getPlanEvents(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+func (d *DBAL) getPlanModels(key string) ([]*asset.Model, error) {
+	stmt := getStatementBuilder().
+		Select("key", "compute_task_key", "address", "checksum", "permissions", "owner", "creation_date").
+		From("expanded_models").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Expr("compute_task_key IN (?)", d.planTaskKeys(key, false))).
+		OrderBy("creation_date", "key")
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	models := []*asset.Model{}
+	for rows.Next() {
+		m := new(sqlModel)
+
+		err = rows.Scan(&m.Key, &m.ComputeTaskKey, &m.Address, &m.Checksum, &m.Permissions, &m.Owner, &m.CreationDate)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, m.toModel())
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return models, nil
+}
+
+func (d *DBAL) getPlanPerformances(key string) ([]*asset.Performance, error) {
+	stmt := getStatementBuilder().
+		Select("compute_task_key", "compute_task_output_identifier", "performance_value", "creation_date").
+		From("performances").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Expr("compute_task_key IN (?)", d.planTaskKeys(key, false))).
+		OrderBy("creation_date", "compute_task_key", "compute_task_output_identifier")
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	performances := []*asset.Performance{}
+	for rows.Next() {
+		perf := new(sqlPerformance)
+
+		err = rows.Scan(&perf.ComputeTaskKey, &perf.ComputeTaskOutputIdentifier, &perf.PerformanceValue, &perf.CreationDate)
+		if err != nil {
+			return nil, err
+		}
+		performances = append(performances, perf.toPerformance())
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return performances, nil
+}
+
+func (d *DBAL) getPlanFailureReports(key string) ([]*asset.FailureReport, error) {
+	stmt := getStatementBuilder().
+		Select("asset_key", "asset_type", "error_type", "creation_date", "owner", "logs_address", "logs_checksum", "attempt").
+		From("expanded_failure_reports").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Expr("asset_key IN (?)", d.planTaskKeys(key, false))).
+		OrderBy("asset_key", "attempt")
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []*asset.FailureReport{}
+	for rows.Next() {
+		r := new(sqlFailureReport)
+
+		err = rows.Scan(&r.AssetKey, &r.AssetType, &r.ErrorType, &r.CreationDate, &r.Owner, &r.LogsAddress, &r.LogsChecksum, &r.Attempt)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, r.toFailureReport())
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func (d *DBAL) getPlanOutputAssets(key string) ([]*asset.ComputeTaskOutputAsset, error) {
+	stmt := getStatementBuilder().
+		Select("compute_task_key", "compute_task_output_identifier", "asset_kind", "asset_key").
+		From(computeTaskOutputAssetsTable).
+		Where(sq.Expr("compute_task_key IN (?)", d.planTaskKeys(key, false))).
+		OrderBy("compute_task_key", "compute_task_output_identifier", "position")
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outputAssets := []*asset.ComputeTaskOutputAsset{}
+	for rows.Next() {
+		out := new(asset.ComputeTaskOutputAsset)
+
+		err = rows.Scan(&out.ComputeTaskKey, &out.ComputeTaskOutputIdentifier, &out.AssetKind, &out.AssetKey)
+		if err != nil {
+			return nil, err
+		}
+		outputAssets = append(outputAssets, out)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return outputAssets, nil
+}
+
+// getPlanEvents returns the events of the compute plan and of its tasks and their outputs, in emission order.
+func (d *DBAL) getPlanEvents(key string) ([]*asset.Event, error) {
+	stmt := getStatementBuilder().
+		Select("id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata").
+		From("events").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Or{
+			sq.Eq{"asset_key": key},
+			d.planEventsCondition(key),
+		}).
+		OrderBy("position")
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*asset.Event{}
+	for rows.Next() {
+		ev := sqlEvent{Channel: d.channel}
+
+		err = rows.Scan(&ev.ID, &ev.AssetKey, &ev.AssetKind, &ev.EventKind, &ev.Timestamp, &ev.Asset, &ev.Metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		event, err := ev.toEvent()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// IsComputePlanReferenced returns true if a task of another compute plan
+// depends on a task of the given compute plan or on one of its models.
+func (d *DBAL) IsComputePlanReferenced(key string) (bool, error) {
+	planModels := sq.
+		Select("key").
+		From("models").
+		Where(sq.Expr("compute_task_key IN (?)", d.planTaskKeys(key, false)))
+
+	stmt := getStatementBuilder().
+		Select("COUNT(1)").
+		From("compute_task_inputs i").
+		Join("compute_tasks t ON t.key = i.compute_task_key").
+		Where(sq.Eq{"t.channel": d.channel}).
+		Where(sq.NotEq{"t.compute_plan_key": key}).
+		Where(sq.Or{
+			sq.Expr("i.parent_task_key IN (?)", d.planTaskKeys(key, false)),
+			sq.Expr("i.asset_key IN (?)", planModels),
+		})
+
+	row, err := d.queryRow(stmt)
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	err = row.Scan(&count)
+
+	return count > 0, err
+}
+
+// PurgeComputePlan deletes the tasks of a compute plan and every related row.
+// The compute plan is kept as a tombstone recording the purge date and what has been removed.
+// Callers are expected to check beforehand that no other plan depends on the purged tasks.
+func (d *DBAL) PurgeComputePlan(key string, purgeDate time.Time) (*asset.ComputePlanPurgeSummary, error) {
+	summary := new(asset.ComputePlanPurgeSummary)
+	taskKeys := d.planTaskKeys(key, false)
+
+	// Rows are deleted in an order satisfying the foreign key constraints
+	deletions := []struct {
+		stmt    sq.DeleteBuilder
+		counter *uint32
+	}{
+		{
+			stmt: getStatementBuilder().Delete("events").
+				Where(sq.Eq{"channel": d.channel}).
+				Where(d.planEventsCondition(key)),
+			counter: &summary.EventCount,
+		},
+		{
+			stmt: getStatementBuilder().Delete("failure_reports").
+				Where(sq.Eq{"channel": d.channel}).
+				Where(sq.Expr("asset_key IN (?)", taskKeys)),
+			counter: &summary.FailureReportCount,
+		},
+		{
+			stmt: getStatementBuilder().Delete("performances").
+				Where(sq.Eq{"channel": d.channel}).
+				Where(sq.Expr("compute_task_key IN (?)", taskKeys)),
+			counter: &summary.PerformanceCount,
+		},
+		{
+			stmt: getStatementBuilder().Delete(computeTaskOutputAssetsTable).
+				Where(sq.Expr("compute_task_key IN (?)", taskKeys)),
+			counter: &summary.OutputAssetCount,
+		},
+		{
+			stmt: getStatementBuilder().Delete("models").
+				Where(sq.Eq{"channel": d.channel}).
+				Where(sq.Expr("compute_task_key IN (?)", taskKeys)),
+			counter: &summary.ModelCount,
+		},
+		{
+			stmt: getStatementBuilder().Delete("compute_task_inputs").
+				Where(sq.Expr("compute_task_key IN (?)", taskKeys)),
+		},
+		{
+			stmt: getStatementBuilder().Delete("compute_task_parents").
+				Where(sq.Expr("child_task_key IN (?)", taskKeys)),
+		},
+		{
+			stmt: getStatementBuilder().Delete("compute_task_outputs").
+				Where(sq.Expr("compute_task_key IN (?)", taskKeys)),
+		},
+		{
+			stmt: getStatementBuilder().Delete("compute_tasks").
+				Where(sq.Eq{"channel": d.channel, "compute_plan_key": key}),
+			counter: &summary.TaskCount,
+		},
+	}
+
+	// Purged events are replaced by their hash, so that the remaining events stay chained
+	tombstones := getStatementBuilder().
+		Insert("event_tombstones").
+		Columns("position", "channel", "id", "hash", "previous_hash").
+		Select(getStatementBuilder().
+			Select("position", "channel", "id", "hash", "previous_hash").
+			From("events").
+			Where(sq.Eq{"channel": d.channel}).
+			Where(d.planEventsCondition(key)))
+	if err := d.exec(tombstones); err != nil {
+		return nil, err
+	}
+
+	for _, deletion := range deletions {
+		count, err := d.execCount(deletion.stmt)
+		if err != nil {
+			return nil, err
+		}
+		if deletion.counter != nil {
+			*deletion.counter = count
+		}
+	}
+
+	stmt := getStatementBuilder().
+		Update("compute_plans").
+		Set("purge_date", purgeDate).
+		Set("purge_summary", summary).
+		Where(sq.Eq{"channel": d.channel, "key": key})
+
+	err := d.exec(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// GetPurgeableComputePlanKeys returns the keys of the compute plans terminated before the given date
+// which have not been purged yet.
+// A plan is terminated when it has been canceled or has failed, or when all its tasks are done.
+func (d *DBAL) GetPurgeableComputePlanKeys(terminatedBefore time.Time) ([]string, error) {
+	lastTaskUpdate := sq.
+		Select("MAX(timestamp)").
+		From("events").
+		Where(sq.Eq{"channel": d.channel, "asset_kind": asset.AssetKind_ASSET_COMPUTE_TASK.String()}).
+		Where("asset->>'computePlanKey' = cp.key::text")
+
+	stmt := getStatementBuilder().
+		Select("key").
+		From("compute_plans cp").
+		Where(sq.Eq{"channel": d.channel, "purge_date": nil}).
+		Where(sq.Or{
+			sq.Lt{"cancelation_date": terminatedBefore},
+			sq.Lt{"failure_date": terminatedBefore},
+			sq.And{
+				sq.Expr("EXISTS (SELECT 1 FROM compute_tasks WHERE compute_plan_key = cp.key)"),
+				sq.Expr("NOT EXISTS (SELECT 1 FROM compute_tasks WHERE compute_plan_key = cp.key AND status <> ?)", asset.ComputeTaskStatus_STATUS_DONE.String()),
+				sq.Expr("(?) < ?", lastTaskUpdate, terminatedBefore),
+			},
+		}).
+		OrderBy("creation_date", "key")
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+
+		err = rows.Scan(&key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,140 @@
+package dbal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+func TestPurgeComputePlan(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	cpKey := "abc"
+	purgeDate := time.Unix(1337, 0).UTC()
+	planTasks := "SELECT key FROM compute_tasks WHERE channel = $%d AND compute_plan_key = $%d"
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectExec(`INSERT INTO event_tombstones (position,channel,id,hash,previous_hash) SELECT position, channel, id, hash, previous_hash FROM events WHERE channel = $1 AND (asset_key IN (SELECT key::text FROM compute_tasks WHERE channel = $2 AND compute_plan_key = $3) OR asset->>'computeTaskKey' IN (SELECT key::text FROM compute_tasks WHERE channel = $4 AND compute_plan_key = $5))`).
+		WithArgs(testChannel, testChannel, cpKey, testChannel, cpKey).
+		WillReturnResult(pgxmock.NewResult("INSERT", 12))
+	mock.
+		ExpectExec(`DELETE FROM events WHERE channel = $1 AND (asset_key IN (SELECT key::text FROM compute_tasks WHERE channel = $2 AND compute_plan_key = $3) OR asset->>'computeTaskKey' IN (SELECT key::text FROM compute_tasks WHERE channel = $4 AND compute_plan_key = $5))`).
+		WithArgs(testChannel, testChannel, cpKey, testChannel, cpKey).
+		WillReturnResult(pgxmock.NewResult("DELETE", 12))
+	mock.
+		ExpectExec(`DELETE FROM failure_reports WHERE channel = $1 AND asset_key IN (`+fmt.Sprintf(planTasks, 2, 3)+`)`).
+		WithArgs(testChannel, testChannel, cpKey).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.
+		ExpectExec(`DELETE FROM performances WHERE channel = $1 AND compute_task_key IN (`+fmt.Sprintf(planTasks, 2, 3)+`)`).
+		WithArgs(testChannel, testChannel, cpKey).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	mock.
+		ExpectExec(`DELETE FROM compute_task_output_assets WHERE compute_task_key IN (`+fmt.Sprintf(planTasks, 1, 2)+`)`).
+		WithArgs(testChannel, cpKey).
+		WillReturnResult(pgxmock.NewResult("DELETE", 4))
+	mock.
+		ExpectExec(`DELETE FROM models WHERE channel = $1 AND compute_task_key IN (`+fmt.Sprintf(planTasks, 2, 3)+`)`).
+		WithArgs(testChannel, testChannel, cpKey).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	mock.
+		ExpectExec(`DELETE FROM compute_task_inputs WHERE compute_task_key IN (`+fmt.Sprintf(planTasks, 1, 2)+`)`).
+		WithArgs(testChannel, cpKey).
+		WillReturnResult(pgxmock.NewResult("DELETE", 5))
+	mock.
+		ExpectExec(`DELETE FROM compute_task_parents WHERE child_task_key IN (`+fmt.Sprintf(planTasks, 1, 2)+`)`).
+		WithArgs(testChannel, cpKey).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	mock.
+		ExpectExec(`DELETE FROM compute_task_outputs WHERE compute_task_key IN (`+fmt.Sprintf(planTasks, 1, 2)+`)`).
+		WithArgs(testChannel, cpKey).
+		WillReturnResult(pgxmock.NewResult("DELETE", 4))
+	mock.
+		ExpectExec(`DELETE FROM compute_tasks WHERE channel = $1 AND compute_plan_key = $2`).
+		WithArgs(testChannel, cpKey).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+
+	expectedSummary := &asset.ComputePlanPurgeSummary{
+		TaskCount:          3,
+		ModelCount:         2,
+		PerformanceCount:   2,
+		FailureReportCount: 1,
+		OutputAssetCount:   4,
+		EventCount:         12,
+	}
+
+	mock.
+		ExpectExec(`UPDATE compute_plans SET purge_date = $1, purge_summary = $2 WHERE channel = $3 AND key = $4`).
+		WithArgs(purgeDate, expectedSummary, testChannel, cpKey).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	summary, err := dbal.PurgeComputePlan(cpKey, purgeDate)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSummary, summary)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIsComputePlanReferenced(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	cpKey := "abc"
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(`SELECT COUNT(1) FROM compute_task_inputs i JOIN compute_tasks t ON t.key = i.compute_task_key WHERE t.channel = $1 AND t.compute_plan_key <> $2 AND (i.parent_task_key IN (SELECT key FROM compute_tasks WHERE channel = $3 AND compute_plan_key = $4) OR i.asset_key IN (SELECT key FROM models WHERE compute_task_key IN (SELECT key FROM compute_tasks WHERE channel = $5 AND compute_plan_key = $6)))`).
+		WithArgs(testChannel, cpKey, testChannel, cpKey, testChannel, cpKey).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	referenced, err := dbal.IsComputePlanReferenced(cpKey)
+	assert.NoError(t, err)
+	assert.True(t, referenced)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPurgeableComputePlanKeys(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	before := time.Unix(1337, 0).UTC()
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(`SELECT key FROM compute_plans cp WHERE channel = $1 AND purge_date IS NULL AND (cancelation_date < $2 OR failure_date < $3 OR (EXISTS (SELECT 1 FROM compute_tasks WHERE compute_plan_key = cp.key) AND NOT EXISTS (SELECT 1 FROM compute_tasks WHERE compute_plan_key = cp.key AND status <> $4) AND (SELECT MAX(timestamp) FROM events WHERE asset_kind = $5 AND channel = $6 AND asset->>'computePlanKey' = cp.key::text) < $7)) ORDER BY creation_date, key`).
+		WithArgs(testChannel, before, before, asset.ComputeTaskStatus_STATUS_DONE.String(), asset.AssetKind_ASSET_COMPUTE_TASK.String(), testChannel, before).
+		WillReturnRows(pgxmock.NewRows([]string{"key"}).AddRow("cp1").AddRow("cp2"))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	keys, err := dbal.GetPurgeableComputePlanKeys(before)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cp1", "cp2"}, keys)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
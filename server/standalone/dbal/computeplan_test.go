@@ -2,6 +2,7 @@ package dbal
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -18,10 +19,10 @@ func TestGetComputePlan(t *testing.T) {
 
 	mock.ExpectBegin()
 
-	rows := pgxmock.NewRows([]string{"key", "owner", "creation_date", "cancelation_date", "failure_date", "tag", "name", "metadata"}).
-		AddRow("uuid", "owner", time.Now(), nil, nil, "", "My compute plan", map[string]string{})
+	rows := pgxmock.NewRows([]string{"key", "owner", "creation_date", "cancelation_date", "failure_date", "tag", "name", "metadata", "max_task_attempts", "pause_date", "resume_date", "purge_date", "purge_summary"}).
+		AddRow("uuid", "owner", time.Now(), nil, nil, "", "My compute plan", map[string]string{}, uint32(0), nil, nil, nil, nil)
 
-	mock.ExpectQuery(`SELECT key, owner, creation_date, cancelation_date, failure_date, tag, name, metadata`).
+	mock.ExpectQuery(`SELECT key, owner, creation_date, cancelation_date, failure_date, tag, name, metadata, max_task_attempts, pause_date, resume_date, purge_date, purge_summary`).
 		WithArgs(testChannel, "uuid").
 		WillReturnRows(rows)
 
@@ -42,8 +43,8 @@ func TestQueryComputePlans(t *testing.T) {
 
 	mock.ExpectBegin()
 
-	rows := pgxmock.NewRows([]string{"key", "owner", "creation_date", "cancelation_date", "failure_date", "tag", "name", "metadata"}).
-		AddRow("uuid", "owner", time.Now(), nil, nil, "", "My compute plan", map[string]string{})
+	rows := pgxmock.NewRows([]string{"key", "owner", "creation_date", "cancelation_date", "failure_date", "tag", "name", "metadata", "max_task_attempts", "pause_date", "resume_date", "purge_date", "purge_summary"}).
+		AddRow("uuid", "owner", time.Now(), nil, nil, "", "My compute plan", map[string]string{}, uint32(0), nil, nil, nil, nil)
 
 	mock.ExpectQuery(`SELECT key,.* FROM compute_plans .* ORDER BY creation_date ASC, key ASC`).
 		WithArgs(testChannel, "owner").
@@ -70,7 +71,7 @@ func TestQueryComputePlansNilFilter(t *testing.T) {
 
 	mock.ExpectBegin()
 
-	rows := pgxmock.NewRows([]string{"key", "owner", "creation_date", "cancelation_date", "failure_date", "tag", "name", "metadata"})
+	rows := pgxmock.NewRows([]string{"key", "owner", "creation_date", "cancelation_date", "failure_date", "tag", "name", "metadata", "max_task_attempts", "pause_date", "resume_date", "purge_date", "purge_summary"})
 
 	mock.ExpectQuery(`SELECT key,.* FROM compute_plans .* ORDER BY creation_date ASC, key`).
 		WithArgs(testChannel).
@@ -115,6 +116,60 @@ func TestCancelComputePlan(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestRestoreComputePlan(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+
+	cpKey := "abc"
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectExec(`UPDATE compute_plans SET failure_date = $1 WHERE channel = $2 AND key = $3`).
+		WithArgs(nil, testChannel, cpKey).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	err = dbal.RestoreComputePlan(&asset.ComputePlan{Key: cpKey})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPauseComputePlan(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+
+	cpKey := "abc"
+	pauseDate := time.Unix(1337, 0)
+	resumeDate := time.Unix(1338, 0)
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectExec(`UPDATE compute_plans SET pause_date = $1 WHERE channel = $2 AND key = $3`).
+		WithArgs(pauseDate, testChannel, cpKey).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.
+		ExpectExec(`UPDATE compute_plans SET resume_date = $1 WHERE channel = $2 AND key = $3`).
+		WithArgs(resumeDate, testChannel, cpKey).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	err = dbal.PauseComputePlan(&asset.ComputePlan{Key: cpKey}, pauseDate)
+	assert.NoError(t, err)
+	err = dbal.ResumeComputePlan(&asset.ComputePlan{Key: cpKey}, resumeDate)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUpdateComputePlan(t *testing.T) {
 	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
 	assert.NoError(t, err)
@@ -173,3 +228,49 @@ func TestIsPlanRunning(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestGetComputePlanStatistics(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	cpKey := "abc"
+	firstStart := time.Unix(1337, 0).UTC()
+
+	mock.ExpectBegin()
+
+	countRows := pgxmock.NewRows([]string{"status", "count"}).
+		AddRow(asset.ComputeTaskStatus_STATUS_DONE.String(), uint32(2)).
+		AddRow(asset.ComputeTaskStatus_STATUS_EXECUTING.String(), uint32(1))
+	mock.
+		ExpectQuery(`SELECT status, COUNT(1) FROM compute_tasks WHERE channel = $1 AND compute_plan_key = $2 GROUP BY status ORDER BY status`).
+		WithArgs(testChannel, cpKey).
+		WillReturnRows(countRows)
+
+	timingRows := pgxmock.NewRows([]string{"min", "max"}).
+		AddRow(sql.NullTime{Time: firstStart, Valid: true}, nil)
+	mock.
+		ExpectQuery(`SELECT MIN(timestamp) FILTER (WHERE asset->>'status' = $1), MAX(timestamp) FILTER (WHERE asset->>'status' = $2) FROM events WHERE channel = $3 AND asset_kind = $4 AND event_kind = $5 AND asset->>'computePlanKey' = $6`).
+		WithArgs(
+			asset.ComputeTaskStatus_STATUS_EXECUTING.String(),
+			asset.ComputeTaskStatus_STATUS_DONE.String(),
+			testChannel,
+			asset.AssetKind_ASSET_COMPUTE_TASK.String(),
+			asset.EventKind_EVENT_ASSET_UPDATED.String(),
+			cpKey,
+		).
+		WillReturnRows(timingRows)
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	stats, err := dbal.GetComputePlanStatistics(cpKey)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), stats.TaskCount)
+	assert.Len(t, stats.TaskCounts, 2)
+	assert.Equal(t, firstStart, stats.FirstTaskStartDate.AsTime())
+	assert.Nil(t, stats.LastTaskCompletionDate)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
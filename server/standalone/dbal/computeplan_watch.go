@@ -0,0 +1,92 @@
+package dbal
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// WatchComputePlan sends a snapshot of the compute plan, then streams the events related to the plan
+// which are positioned after the snapshot.
+// The listener of the DBAL must be subscribed before calling this method, so that no event is missed nor sent twice.
+func (d *DBAL) WatchComputePlan(key string, stream asset.EventService_WatchComputePlanServer) error {
+	snapshot, err := d.getComputePlanSnapshot(key)
+	if err != nil {
+		return err
+	}
+
+	err = stream.Send(&asset.ComputePlanUpdate{Update: &asset.ComputePlanUpdate_Snapshot{Snapshot: snapshot}})
+	if err != nil {
+		return err
+	}
+
+	filter := &asset.EventQueryFilter{ComputePlanKey: key}
+
+	return d.streamEvents(int64(snapshot.Position), filter, func(event *asset.Event) error {
+		return stream.Send(&asset.ComputePlanUpdate{Update: &asset.ComputePlanUpdate_Event{Event: event}})
+	})
+}
+
+// getComputePlanSnapshot reads the compute plan and its related assets in a repeatable read transaction,
+// along with the position of the last event of the channel.
+// Assets and events are written in the same transaction, so the snapshot reflects every event up to this position.
+func (d *DBAL) getComputePlanSnapshot(key string) (*asset.ComputePlanSnapshot, error) {
+	tx, err := d.conn.BeginTx(d.ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	// Nothing to commit in a read-only transaction
+	defer tx.Rollback(d.ctx) //nolint:errcheck
+
+	snapshotDBAL := New(d.ctx, tx, d.conn, d.channel)
+
+	stmt := getStatementBuilder().
+		Select("COALESCE(MAX(position), 0)").
+		From("events").
+		Where(sq.Eq{"channel": d.channel})
+
+	row, err := snapshotDBAL.queryRow(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	var position int64
+	err = row.Scan(&position)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &asset.ComputePlanSnapshot{Position: uint64(position)}
+
+	snapshot.ComputePlan, err = snapshotDBAL.GetComputePlan(key)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Tasks, err = snapshotDBAL.GetComputePlanTasks(key)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.OutputAssets, err = snapshotDBAL.getPlanOutputAssets(key)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Models, err = snapshotDBAL.getPlanModels(key)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Performances, err = snapshotDBAL.getPlanPerformances(key)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.FailureReports, err = snapshotDBAL.getPlanFailureReports(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,37 @@
+package dbal
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pashagolub/pgxmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"github.com/substra/orchestrator/utils"
+)
+
+func TestGetComputePlanSnapshotNotFound(t *testing.T) {
+	conn, err := utils.NewMockConn()
+	require.NoError(t, err)
+
+	conn.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	conn.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(position), 0) FROM events WHERE channel = $1`)).
+		WithArgs(testChannel).
+		WillReturnRows(pgxmock.NewRows([]string{"position"}).AddRow(int64(42)))
+	conn.ExpectQuery(`SELECT key, owner, creation_date, .* FROM compute_plans`).
+		WillReturnError(pgx.ErrNoRows)
+	conn.ExpectRollback()
+
+	dbal := &DBAL{ctx: context.TODO(), conn: conn, channel: testChannel}
+
+	_, err = dbal.getComputePlanSnapshot("unknown")
+	orcError := new(orcerrors.OrcError)
+	require.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrNotFound, orcError.Kind)
+
+	assert.NoError(t, conn.ExpectationsWereMet())
+}
@@ -21,6 +21,9 @@ import (
 
 const computeTaskOutputAssetsTable = "compute_task_output_assets"
 
+// defaultTaskKeyset sorts tasks by creation date, it matches the default of QueryComputeTasks.
+var defaultTaskKeyset = newKeyset(false, "creation_date", "key")
+
 type sqlComputeTask struct {
 	Key            string
 	FunctionKey    string
@@ -32,6 +35,7 @@ type sqlComputeTask struct {
 	CreationDate   time.Time
 	LogsPermission asset.Permission
 	Metadata       map[string]string
+	Attempt        uint32
 }
 
 func (t *sqlComputeTask) toComputeTask() (*asset.ComputeTask, error) {
@@ -47,6 +51,7 @@ func (t *sqlComputeTask) toComputeTask() (*asset.ComputeTask, error) {
 	task.CreationDate = timestamppb.New(t.CreationDate)
 	task.LogsPermission = &t.LogsPermission
 	task.Metadata = t.Metadata
+	task.Attempt = t.Attempt
 
 	return task, nil
 }
@@ -78,7 +83,7 @@ func (d *DBAL) insertTasks(tasks []*asset.ComputeTask) error {
 	_, err := d.tx.CopyFrom(
 		d.ctx,
 		pgx.Identifier{"compute_tasks"},
-		[]string{"key", "channel", "function_key", "owner", "compute_plan_key", "rank", "status", "worker", "creation_date", "logs_permission", "metadata"},
+		[]string{"key", "channel", "function_key", "owner", "compute_plan_key", "rank", "status", "worker", "creation_date", "logs_permission", "metadata", "attempt"},
 		pgx.CopyFromSlice(len(tasks), func(i int) ([]interface{}, error) {
 			return getCopyableComputeTaskValues(d.channel, tasks[i])
 		}),
@@ -120,6 +125,7 @@ func getCopyableComputeTaskValues(channel string, task *asset.ComputeTask) ([]in
 		task.CreationDate.AsTime(),
 		logsPermission,
 		task.Metadata,
+		task.Attempt,
 	}, nil
 }
 
@@ -164,6 +170,25 @@ func (d *DBAL) UpdateComputeTaskStatus(taskKey string, taskStatus asset.ComputeT
 	return d.exec(stmt)
 }
 
+func (d *DBAL) UpdateComputeTaskAttempt(taskKey string, attempt uint32) error {
+	stmt := getStatementBuilder().
+		Update("compute_tasks").
+		Set("attempt", attempt).
+		Where(sq.Eq{"channel": d.channel, "key": taskKey})
+
+	return d.exec(stmt)
+}
+
+// UpdateComputeTaskLease sets the date until which an executing task is considered alive.
+func (d *DBAL) UpdateComputeTaskLease(taskKey string, expiration time.Time) error {
+	stmt := getStatementBuilder().
+		Update("compute_tasks").
+		Set("lease_expiration_date", expiration).
+		Where(sq.Eq{"channel": d.channel, "key": taskKey})
+
+	return d.exec(stmt)
+}
+
 // GetExistingComputeTaskKeys returns the keys of tasks already in storage among those given as input.
 func (d *DBAL) GetExistingComputeTaskKeys(keys []string) ([]string, error) {
 	if len(keys) == 0 {
@@ -204,7 +229,7 @@ func (d *DBAL) GetExistingComputeTaskKeys(keys []string) ([]string, error) {
 func (d *DBAL) GetComputeTask(key string) (*asset.ComputeTask, error) {
 	stmt := getStatementBuilder().
 		Select("key", "compute_plan_key", "status", "worker", "owner", "rank", "creation_date",
-			"logs_permission", "metadata", "function_key").
+			"logs_permission", "metadata", "function_key", "attempt").
 		From("compute_tasks").
 		Where(sq.Eq{"channel": d.channel, "key": key})
 
@@ -215,7 +240,7 @@ func (d *DBAL) GetComputeTask(key string) (*asset.ComputeTask, error) {
 
 	ct := new(sqlComputeTask)
 	err = row.Scan(&ct.Key, &ct.ComputePlanKey, &ct.Status, &ct.Worker, &ct.Owner, &ct.Rank, &ct.CreationDate,
-		&ct.LogsPermission, &ct.Metadata, &ct.FunctionKey)
+		&ct.LogsPermission, &ct.Metadata, &ct.FunctionKey, &ct.Attempt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, orcerrors.NewNotFound("computetask", key)
@@ -241,7 +266,7 @@ func (d *DBAL) GetComputeTask(key string) (*asset.ComputeTask, error) {
 func (d *DBAL) GetComputeTaskChildren(key string) ([]*asset.ComputeTask, error) {
 	stmt := getStatementBuilder().
 		Select("key", "compute_plan_key", "status", "worker", "owner", "rank", "creation_date",
-			"logs_permission", "metadata", "function_key").
+			"logs_permission", "metadata", "function_key", "attempt").
 		From("compute_tasks t").
 		Join("compute_task_parents p ON t.key = p.child_task_key").
 		Where(sq.Eq{"t.channel": d.channel, "p.parent_task_key": key}).
@@ -259,7 +284,7 @@ func (d *DBAL) GetComputeTaskChildren(key string) ([]*asset.ComputeTask, error)
 
 		err = rows.Scan(
 			&ct.Key, &ct.ComputePlanKey, &ct.Status, &ct.Worker, &ct.Owner, &ct.Rank, &ct.CreationDate,
-			&ct.LogsPermission, &ct.Metadata, &ct.FunctionKey)
+			&ct.LogsPermission, &ct.Metadata, &ct.FunctionKey, &ct.Attempt)
 		if err != nil {
 			return nil, err
 		}
@@ -281,7 +306,7 @@ func (d *DBAL) GetComputeTaskChildren(key string) ([]*asset.ComputeTask, error)
 func (d *DBAL) GetComputeTaskParents(key string) ([]*asset.ComputeTask, error) {
 	stmt := getStatementBuilder().
 		Select("key", "compute_plan_key", "status", "worker", "owner", "rank", "creation_date",
-			"logs_permission", "metadata", "function_key").
+			"logs_permission", "metadata", "function_key", "attempt").
 		From("compute_tasks t").
 		Join("compute_task_parents p ON t.key = p.parent_task_key").
 		Where(sq.Eq{"t.channel": d.channel, "p.child_task_key": key}).
@@ -299,7 +324,7 @@ func (d *DBAL) GetComputeTaskParents(key string) ([]*asset.ComputeTask, error) {
 
 		err = rows.Scan(
 			&ct.Key, &ct.ComputePlanKey, &ct.Status, &ct.Worker, &ct.Owner, &ct.Rank, &ct.CreationDate,
-			&ct.LogsPermission, &ct.Metadata, &ct.FunctionKey)
+			&ct.LogsPermission, &ct.Metadata, &ct.FunctionKey, &ct.Attempt)
 		if err != nil {
 			return nil, err
 		}
@@ -351,7 +376,7 @@ func (d *DBAL) GetComputePlanTasksKeys(key string) ([]string, error) {
 func (d *DBAL) GetFunctionFromTasksWithStatus(key string, statuses []asset.ComputeTaskStatus) ([]*asset.ComputeTask, error) {
 	stmt := getStatementBuilder().
 		Select("key", "compute_plan_key", "status", "worker", "owner", "rank", "creation_date",
-			"logs_permission", "metadata", "function_key").
+			"logs_permission", "metadata", "function_key", "attempt").
 		From("compute_tasks").
 		Where(sq.Eq{
 			"function_key": key,
@@ -370,7 +395,7 @@ func (d *DBAL) GetFunctionFromTasksWithStatus(key string, statuses []asset.Compu
 
 		err = rows.Scan(
 			&ct.Key, &ct.ComputePlanKey, &ct.Status, &ct.Worker, &ct.Owner, &ct.Rank, &ct.CreationDate,
-			&ct.LogsPermission, &ct.Metadata, &ct.FunctionKey)
+			&ct.LogsPermission, &ct.Metadata, &ct.FunctionKey, &ct.Attempt)
 		if err != nil {
 			return nil, err
 		}
@@ -417,37 +442,35 @@ func (d *DBAL) CountComputeTaskRegisteredOutputs(key string) (persistence.Comput
 }
 
 // queryBaseComputeTasks will return tasks without inputs/outputs, their keys and pagination token
-func (d *DBAL) queryBaseComputeTasks(pagination *common.Pagination, filterer func(sq.SelectBuilder) sq.SelectBuilder) ([]*asset.ComputeTask, common.PaginationToken, error) {
+func (d *DBAL) queryBaseComputeTasks(pagination *common.Pagination, ks keyset, filterer func(sq.SelectBuilder) sq.SelectBuilder) ([]*asset.ComputeTask, common.PaginationToken, error) {
 	stmt := getStatementBuilder().
 		Select("key", "compute_plan_key", "status", "worker", "owner", "rank", "creation_date",
-			"logs_permission", "metadata", "function_key").
+			"logs_permission", "metadata", "function_key", "attempt").
 		From("compute_tasks").
-		Where(sq.Eq{"channel": d.channel}).
-		OrderByClause("creation_date ASC, key")
+		Where(sq.Eq{"channel": d.channel})
+
+	stmt = filterer(stmt)
 
 	var (
-		offset int
-		err    error
-		tasks  []*asset.ComputeTask
+		pg    *page
+		err   error
+		tasks []*asset.ComputeTask
 	)
 
 	if pagination != nil {
-		offset, err = getOffset(pagination.Token)
+		pg, err = newPage(pagination, ks)
 		if err != nil {
 			return nil, "", err
 		}
 
-		stmt = stmt.Offset(uint64(offset)).
-			// Fetch page size + 1 elements to determine whether there is a next page
-			Limit(uint64(pagination.Size + 1))
+		stmt = pg.apply(stmt)
 
 		tasks = make([]*asset.ComputeTask, 0, pagination.Size)
 	} else {
+		stmt = stmt.OrderByClause(ks.orderBy())
 		tasks = make([]*asset.ComputeTask, 0)
 	}
 
-	stmt = filterer(stmt)
-
 	rows, err := d.query(stmt)
 	if err != nil {
 		return nil, "", err
@@ -455,13 +478,14 @@ func (d *DBAL) queryBaseComputeTasks(pagination *common.Pagination, filterer fun
 	defer rows.Close()
 
 	var count int
+	var last *sqlComputeTask
 
 	for rows.Next() {
 		ct := new(sqlComputeTask)
 
 		err = rows.Scan(
 			&ct.Key, &ct.ComputePlanKey, &ct.Status, &ct.Worker, &ct.Owner, &ct.Rank, &ct.CreationDate,
-			&ct.LogsPermission, &ct.Metadata, &ct.FunctionKey)
+			&ct.LogsPermission, &ct.Metadata, &ct.FunctionKey, &ct.Attempt)
 		if err != nil {
 			return nil, "", err
 		}
@@ -472,6 +496,7 @@ func (d *DBAL) queryBaseComputeTasks(pagination *common.Pagination, filterer fun
 		}
 
 		tasks = append(tasks, task)
+		last = ct
 		count++
 
 		if pagination != nil && count == int(pagination.Size) {
@@ -485,14 +510,37 @@ func (d *DBAL) queryBaseComputeTasks(pagination *common.Pagination, filterer fun
 	bookmark := ""
 	if pagination != nil && count == int(pagination.Size) && rows.Next() {
 		// there is more to fetch
-		bookmark = strconv.Itoa(offset + count)
+		bookmark, err = pg.nextToken(taskCursorValues(ks, last)...)
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
 	return tasks, bookmark, nil
 }
 
+// taskCursorValues returns the values of the task for the columns of the keyset.
+func taskCursorValues(ks keyset, ct *sqlComputeTask) []string {
+	values := make([]string, 0, len(ks))
+	for _, c := range ks {
+		switch c.name {
+		case "creation_date":
+			values = append(values, cursorTime(ct.CreationDate))
+		case "rank":
+			values = append(values, strconv.Itoa(int(ct.Rank)))
+		case "key":
+			values = append(values, ct.Key)
+		}
+	}
+	return values
+}
+
 func (d *DBAL) queryComputeTasks(pagination *common.Pagination, filterer func(sq.SelectBuilder) sq.SelectBuilder) ([]*asset.ComputeTask, common.PaginationToken, error) {
-	tasks, bookmark, err := d.queryBaseComputeTasks(pagination, filterer)
+	return d.querySortedComputeTasks(pagination, defaultTaskKeyset, filterer)
+}
+
+func (d *DBAL) querySortedComputeTasks(pagination *common.Pagination, ks keyset, filterer func(sq.SelectBuilder) sq.SelectBuilder) ([]*asset.ComputeTask, common.PaginationToken, error) {
+	tasks, bookmark, err := d.queryBaseComputeTasks(pagination, ks, filterer)
 	if err != nil {
 		return nil, "", err
 	}
@@ -514,22 +562,69 @@ func taskFilterToQuery(filter *asset.TaskQueryFilter, builder sq.SelectBuilder)
 	if filter.Worker != "" {
 		builder = builder.Where(sq.Eq{"worker": filter.Worker})
 	}
+
+	statuses := make([]string, 0, len(filter.Statuses)+1)
 	if filter.Status != 0 {
-		builder = builder.Where(sq.Eq{"status": filter.Status.String()})
+		statuses = append(statuses, filter.Status.String())
+	}
+	for _, status := range filter.Statuses {
+		statuses = append(statuses, status.String())
 	}
+	if len(statuses) == 1 {
+		builder = builder.Where(sq.Eq{"status": statuses[0]})
+	} else if len(statuses) > 1 {
+		builder = builder.Where(sq.Eq{"status": statuses})
+	}
+
 	if filter.ComputePlanKey != "" {
 		builder = builder.Where(sq.Eq{"compute_plan_key": filter.ComputePlanKey})
 	}
 	if filter.FunctionKey != "" {
 		builder = builder.Where(sq.Eq{"function_key": filter.FunctionKey})
 	}
+	if filter.Owner != "" {
+		builder = builder.Where(sq.Eq{"owner": filter.Owner})
+	}
+	if len(filter.Keys) > 0 {
+		builder = builder.Where(sq.Eq{"key": filter.Keys})
+	}
+	if filter.RankMin != nil {
+		builder = builder.Where(sq.GtOrEq{"rank": filter.GetRankMin()})
+	}
+	if filter.RankMax != nil {
+		builder = builder.Where(sq.LtOrEq{"rank": filter.GetRankMax()})
+	}
+	if filter.CreationDateStart != nil {
+		builder = builder.Where(sq.GtOrEq{"creation_date": filter.CreationDateStart.AsTime()})
+	}
+	if filter.CreationDateEnd != nil {
+		builder = builder.Where(sq.LtOrEq{"creation_date": filter.CreationDateEnd.AsTime()})
+	}
+	if len(filter.Metadata) > 0 {
+		builder = builder.Where(sq.Expr("metadata @> ?", filter.Metadata))
+	}
 
 	return builder
 }
 
-// QueryComputeTasks returns a paginated and filtered list of tasks.
-func (d *DBAL) QueryComputeTasks(pagination *common.Pagination, filter *asset.TaskQueryFilter) ([]*asset.ComputeTask, common.PaginationToken, error) {
-	return d.queryComputeTasks(pagination, func(builder sq.SelectBuilder) sq.SelectBuilder {
+// taskSortToKeyset returns the keyset sorting tasks by the given field.
+// The key is always the last criterion so that pagination is stable.
+func taskSortToKeyset(sortBy asset.TaskSortField, sortOrder asset.SortOrder) keyset {
+	desc := sortOrder == asset.SortOrder_DESCENDING
+
+	switch sortBy {
+	case asset.TaskSortField_TASK_SORT_RANK:
+		return newKeyset(desc, "rank", "key")
+	case asset.TaskSortField_TASK_SORT_KEY:
+		return newKeyset(desc, "key")
+	default:
+		return newKeyset(desc, "creation_date", "key")
+	}
+}
+
+// QueryComputeTasks returns a paginated, filtered and sorted list of tasks.
+func (d *DBAL) QueryComputeTasks(pagination *common.Pagination, filter *asset.TaskQueryFilter, sortBy asset.TaskSortField, sortOrder asset.SortOrder) ([]*asset.ComputeTask, common.PaginationToken, error) {
+	return d.querySortedComputeTasks(pagination, taskSortToKeyset(sortBy, sortOrder), func(builder sq.SelectBuilder) sq.SelectBuilder {
 		return taskFilterToQuery(filter, builder)
 	})
 }
@@ -542,6 +637,36 @@ func (d *DBAL) GetComputePlanTasks(key string) ([]*asset.ComputeTask, error) {
 	return tasks, err
 }
 
+// GetExpiredComputeTasks returns the executing tasks whose lease expired before the given date.
+// Tasks without lease are never returned.
+func (d *DBAL) GetExpiredComputeTasks(expiredBefore time.Time) ([]*asset.ComputeTask, error) {
+	tasks, _, err := d.queryComputeTasks(nil, func(builder sq.SelectBuilder) sq.SelectBuilder {
+		return builder.
+			Where(sq.Eq{"status": asset.ComputeTaskStatus_STATUS_EXECUTING.String()}).
+			Where(sq.Lt{"lease_expiration_date": expiredBefore})
+	})
+	return tasks, err
+}
+
+// IsComputeTaskLeaseExpired returns true if the lease of the task expired before the given date.
+func (d *DBAL) IsComputeTaskLeaseExpired(taskKey string, expiredBefore time.Time) (bool, error) {
+	stmt := getStatementBuilder().
+		Select("COUNT(key)").
+		From("compute_tasks").
+		Where(sq.Eq{"channel": d.channel, "key": taskKey}).
+		Where(sq.Lt{"lease_expiration_date": expiredBefore})
+
+	row, err := d.queryRow(stmt)
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	err = row.Scan(&count)
+
+	return count == 1, err
+}
+
 // GetComputeTasks returns the list of unique compute tasks identified by the provided keys.
 // It should not be used where pagination is expected!
 func (d *DBAL) GetComputeTasks(keys []string) ([]*asset.ComputeTask, error) {
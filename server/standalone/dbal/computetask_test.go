@@ -14,6 +14,7 @@ import (
 	"github.com/substra/orchestrator/lib/asset"
 	"github.com/substra/orchestrator/lib/common"
 	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestToComputeTask(t *testing.T) {
@@ -44,6 +45,7 @@ func TestToComputeTask(t *testing.T) {
 			AuthorizedIds: []string{},
 		},
 		Metadata: map[string]string{},
+		Attempt:  2,
 	}
 
 	res, err := ct.toComputeTask()
@@ -58,15 +60,16 @@ func TestToComputeTask(t *testing.T) {
 	assert.Equal(t, ct.CreationDate, res.CreationDate.AsTime())
 	assert.Equal(t, &ct.LogsPermission, res.LogsPermission)
 	assert.Equal(t, ct.Metadata, res.Metadata)
+	assert.Equal(t, ct.Attempt, res.Attempt)
 }
 
 func makeTaskRows(taskKeys ...string) *pgxmock.Rows {
 	res := pgxmock.NewRows([]string{"key", "compute_plan_key", "status", "worker", "owner", "rank", "creation_date",
-		"logs_permission", "metadata", "function_key"})
+		"logs_permission", "metadata", "function_key", "attempt"})
 
 	for _, key := range taskKeys {
 		res = res.AddRow(key, "cp_key", "STATUS_WAITING_FOR_PARENT_TASKS", "worker", "owner", int32(0), time.Unix(0, 100),
-			[]byte("{}"), map[string]string{}, "function_key")
+			[]byte("{}"), map[string]string{}, "function_key", uint32(1))
 	}
 
 	return res
@@ -96,6 +99,9 @@ func makeTaskOutputRows(taskKeys ...string) *pgxmock.Rows {
 }
 
 func TestTaskFilterToQuery(t *testing.T) {
+	rankMin := int32(0)
+	rankMax := int32(4)
+
 	cases := map[string]struct {
 		filter        *asset.TaskQueryFilter
 		queryContains string
@@ -105,6 +111,21 @@ func TestTaskFilterToQuery(t *testing.T) {
 		"single filter": {&asset.TaskQueryFilter{Worker: "myorganization"}, "worker = $1", []interface{}{"myorganization"}},
 		"two filter":    {&asset.TaskQueryFilter{Worker: "myorganization", Status: asset.ComputeTaskStatus_STATUS_DONE}, "worker = $1 AND status = $2", []interface{}{"myorganization", asset.ComputeTaskStatus_STATUS_DONE.String()}},
 		"three filter":  {&asset.TaskQueryFilter{Worker: "myorganization", Status: asset.ComputeTaskStatus_STATUS_DONE, FunctionKey: "test-key"}, "worker = $1 AND status = $2 AND function_key = $3", []interface{}{"myorganization", asset.ComputeTaskStatus_STATUS_DONE.String(), "test-key"}},
+		"statuses": {
+			&asset.TaskQueryFilter{Status: asset.ComputeTaskStatus_STATUS_DONE, Statuses: []asset.ComputeTaskStatus{asset.ComputeTaskStatus_STATUS_FAILED}},
+			"status IN ($1,$2)",
+			[]interface{}{asset.ComputeTaskStatus_STATUS_DONE.String(), asset.ComputeTaskStatus_STATUS_FAILED.String()},
+		},
+		"ranges": {
+			&asset.TaskQueryFilter{RankMin: &rankMin, RankMax: &rankMax, CreationDateStart: timestamppb.New(time.Unix(1337, 0)), CreationDateEnd: timestamppb.New(time.Unix(1338, 0))},
+			"rank >= $1 AND rank <= $2 AND creation_date >= $3 AND creation_date <= $4",
+			[]interface{}{int32(0), int32(4), time.Unix(1337, 0).UTC(), time.Unix(1338, 0).UTC()},
+		},
+		"owner, keys and metadata": {
+			&asset.TaskQueryFilter{Owner: "myorganization", Keys: []string{"uuid1", "uuid2"}, Metadata: map[string]string{"foo": "bar"}},
+			"owner = $1 AND key IN ($2,$3) AND metadata @> $4",
+			[]interface{}{"myorganization", "uuid1", "uuid2", map[string]string{"foo": "bar"}},
+		},
 	}
 
 	pgDialect := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
@@ -205,6 +226,8 @@ func TestQueryComputeTasks(t *testing.T) {
 	res, _, err := dbal.QueryComputeTasks(
 		common.NewPagination("", 1),
 		&asset.TaskQueryFilter{Worker: "testWorker", Status: asset.ComputeTaskStatus_STATUS_DONE},
+		asset.TaskSortField_TASK_SORT_CREATION_DATE,
+		asset.SortOrder_ASCENDING,
 	)
 	assert.NoError(t, err)
 	assert.Len(t, res, 1)
@@ -212,6 +235,24 @@ func TestQueryComputeTasks(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestTaskSortToKeyset(t *testing.T) {
+	cases := map[string]struct {
+		sortBy    asset.TaskSortField
+		sortOrder asset.SortOrder
+		expected  string
+	}{
+		"default":         {asset.TaskSortField_TASK_SORT_CREATION_DATE, asset.SortOrder_UNSPECIFIED, "creation_date ASC, key ASC"},
+		"rank descending": {asset.TaskSortField_TASK_SORT_RANK, asset.SortOrder_DESCENDING, "rank DESC, key DESC"},
+		"key":             {asset.TaskSortField_TASK_SORT_KEY, asset.SortOrder_ASCENDING, "key ASC"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.expected, taskSortToKeyset(c.sortBy, c.sortOrder).orderBy())
+		})
+	}
+}
+
 func TestAddComputeTask(t *testing.T) {
 	newTask := &asset.ComputeTask{
 		Key:            "8d9fc421-15a6-4c3d-9082-3337a5436e83",
@@ -246,7 +287,7 @@ func TestAddComputeTask(t *testing.T) {
 
 	// Insert task
 	mock.ExpectCopyFrom(`"compute_tasks"`,
-		[]string{"key", "channel", "function_key", "owner", "compute_plan_key", "rank", "status", "worker", "creation_date", "logs_permission", "metadata"}).
+		[]string{"key", "channel", "function_key", "owner", "compute_plan_key", "rank", "status", "worker", "creation_date", "logs_permission", "metadata", "attempt"}).
 		WillReturnResult(1)
 	// Insert parents relationships
 	mock.ExpectCopyFrom(`"compute_task_parents"`, []string{"parent_task_key", "child_task_key", "position"}).WillReturnResult(2)
@@ -324,7 +365,7 @@ func TestAddComputeTasks(t *testing.T) {
 
 	// Insert task
 	mock.ExpectCopyFrom(`"compute_tasks"`,
-		[]string{"key", "channel", "function_key", "owner", "compute_plan_key", "rank", "status", "worker", "creation_date", "logs_permission", "metadata"}).
+		[]string{"key", "channel", "function_key", "owner", "compute_plan_key", "rank", "status", "worker", "creation_date", "logs_permission", "metadata", "attempt"}).
 		WillReturnResult(2)
 	// Insert parents relationships
 	mock.ExpectCopyFrom(`"compute_task_parents"`, []string{"parent_task_key", "child_task_key", "position"}).WillReturnResult(3)
@@ -372,12 +413,110 @@ func TestQueryComputeTasksNilFilter(t *testing.T) {
 	_, _, err = dbal.QueryComputeTasks(
 		common.NewPagination("", 1),
 		nil,
+		asset.TaskSortField_TASK_SORT_CREATION_DATE,
+		asset.SortOrder_ASCENDING,
 	)
 	assert.NoError(t, err)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUpdateComputeTaskAttempt(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`UPDATE compute_tasks SET attempt = $1 WHERE channel = $2 AND key = $3`).
+		WithArgs(uint32(2), testChannel, "uuid").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	err = dbal.UpdateComputeTaskAttempt("uuid", 2)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateComputeTaskLease(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+
+	expiration := time.Unix(1337, 0)
+	mock.ExpectExec(`UPDATE compute_tasks SET lease_expiration_date = $1 WHERE channel = $2 AND key = $3`).
+		WithArgs(expiration, testChannel, "uuid").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	err = dbal.UpdateComputeTaskLease("uuid", expiration)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetExpiredComputeTasks(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	assert.NoError(t, err)
+
+	mock.ExpectBegin()
+
+	key := "93733214-02b6-4d69-90a8-4e3518a63470"
+	expiredBefore := time.Unix(1337, 0)
+
+	mock.ExpectQuery(`SELECT .* FROM compute_tasks WHERE channel = \$1 AND status = \$2 AND lease_expiration_date < \$3`).
+		WithArgs(testChannel, asset.ComputeTaskStatus_STATUS_EXECUTING.String(), expiredBefore).
+		WillReturnRows(makeTaskRows(key))
+
+	mock.ExpectQuery(`SELECT .* FROM compute_task_inputs`).
+		WithArgs(key).
+		WillReturnRows(makeTaskInputRows(key))
+
+	mock.ExpectQuery(`SELECT .* FROM compute_task_outputs`).
+		WithArgs(key).
+		WillReturnRows(makeTaskOutputRows(key))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	res, err := dbal.GetExpiredComputeTasks(expiredBefore)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIsComputeTaskLeaseExpired(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+
+	expiredBefore := time.Unix(1337, 0)
+	mock.ExpectQuery(`SELECT COUNT(key) FROM compute_tasks WHERE channel = $1 AND key = $2 AND lease_expiration_date < $3`).
+		WithArgs(testChannel, "uuid", expiredBefore).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	expired, err := dbal.IsComputeTaskLeaseExpired("uuid", expiredBefore)
+	assert.NoError(t, err)
+	assert.True(t, expired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestAddComputeTaskOutputAsset(t *testing.T) {
 	mock, err := pgxmock.NewConn()
 	assert.NoError(t, err)
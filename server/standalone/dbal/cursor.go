@@ -0,0 +1,171 @@
+package dbal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+)
+
+// cursorVersion is the version of the pagination tokens issued by the DBAL.
+// It should be increased whenever the token content changes in an incompatible way.
+const cursorVersion = 1
+
+// sortColumn is a column of the ORDER BY clause of a paginated query.
+type sortColumn struct {
+	name string
+	desc bool
+}
+
+// keyset is the list of columns sorting a paginated query.
+// The combination of columns must be unique so that a row can be used as a cursor.
+type keyset []sortColumn
+
+// newKeyset returns a keyset sorting every column in the same order.
+func newKeyset(desc bool, columns ...string) keyset {
+	ks := make(keyset, 0, len(columns))
+	for _, c := range columns {
+		ks = append(ks, sortColumn{name: c, desc: desc})
+	}
+	return ks
+}
+
+// orderBy returns the ORDER BY clause matching the keyset.
+func (ks keyset) orderBy() string {
+	clauses := make([]string, 0, len(ks))
+	for _, c := range ks {
+		order := PgSortAsc
+		if c.desc {
+			order = PgSortDesc
+		}
+		clauses = append(clauses, c.name+" "+order)
+	}
+	return strings.Join(clauses, ", ")
+}
+
+// after returns the condition selecting rows located after the given values.
+func (ks keyset) after(values []string) sq.Sqlizer {
+	sameOrder := true
+	for _, c := range ks[1:] {
+		sameOrder = sameOrder && c.desc == ks[0].desc
+	}
+
+	if sameOrder {
+		// A row comparison can make use of multicolumn indexes
+		names := make([]string, 0, len(ks))
+		args := make([]interface{}, 0, len(ks))
+		for i, c := range ks {
+			names = append(names, c.name)
+			args = append(args, values[i])
+		}
+		operator := ">"
+		if ks[0].desc {
+			operator = "<"
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ks)), ", ")
+		return sq.Expr(fmt.Sprintf("(%s) %s (%s)", strings.Join(names, ", "), operator, placeholders), args...)
+	}
+
+	// (a, b) after (x, y) is expanded to: a after x OR (a = x AND b after y)
+	or := sq.Or{}
+	for i, c := range ks {
+		and := sq.And{}
+		for j := 0; j < i; j++ {
+			and = append(and, sq.Eq{ks[j].name: values[j]})
+		}
+		if c.desc {
+			and = append(and, sq.Lt{c.name: values[i]})
+		} else {
+			and = append(and, sq.Gt{c.name: values[i]})
+		}
+		or = append(or, and)
+	}
+	return or
+}
+
+// cursor is the content of a pagination token: the sort values of the last row of the previous page.
+type cursor struct {
+	Version int      `json:"v"`
+	OrderBy string   `json:"o"`
+	Values  []string `json:"k"`
+}
+
+// page describes the rows to fetch for a paginated query.
+// Pages start after a cursor, or at an offset for legacy numeric tokens.
+type page struct {
+	keyset keyset
+	size   uint32
+	offset int
+	after  []string
+}
+
+// newPage parses the pagination token of a query sorted by the given keyset.
+func newPage(p *common.Pagination, ks keyset) (*page, error) {
+	pg := &page{keyset: ks, size: p.Size}
+
+	if p.Token == "" {
+		return pg, nil
+	}
+
+	// Numeric tokens were issued by previous versions, they are still accepted during the transition
+	if _, err := strconv.Atoi(p.Token); err == nil {
+		pg.offset, err = getOffset(p.Token)
+		return pg, err
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(p.Token)
+	if err != nil {
+		return nil, orcerrors.NewBadRequest("invalid page token").Wrap(err)
+	}
+
+	c := new(cursor)
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, orcerrors.NewBadRequest("invalid page token").Wrap(err)
+	}
+	if c.Version != cursorVersion {
+		return nil, orcerrors.NewBadRequest(fmt.Sprintf("unsupported page token version %d", c.Version))
+	}
+	if c.OrderBy != ks.orderBy() || len(c.Values) != len(ks) {
+		return nil, orcerrors.NewBadRequest("page token does not match the query sort order")
+	}
+
+	pg.after = c.Values
+
+	return pg, nil
+}
+
+// apply sorts the statement and restricts it to the page.
+// It fetches one more row than the page size to determine whether there is a next page.
+func (pg *page) apply(stmt sq.SelectBuilder) sq.SelectBuilder {
+	stmt = stmt.OrderByClause(pg.keyset.orderBy())
+
+	if pg.after != nil {
+		stmt = stmt.Where(pg.keyset.after(pg.after))
+	}
+	if pg.offset > 0 {
+		stmt = stmt.Offset(uint64(pg.offset))
+	}
+
+	return stmt.Limit(uint64(pg.size + 1))
+}
+
+// nextToken returns the token of the page starting after the row with given sort values.
+func (pg *page) nextToken(values ...string) (common.PaginationToken, error) {
+	data, err := json.Marshal(cursor{Version: cursorVersion, OrderBy: pg.keyset.orderBy(), Values: values})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// cursorTime formats a timestamp as a cursor value without losing precision.
+func cursorTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
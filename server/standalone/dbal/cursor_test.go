@@ -0,0 +1,89 @@
+package dbal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+)
+
+func TestPageApply(t *testing.T) {
+	ks := newKeyset(false, "creation_date", "key")
+
+	cases := map[string]struct {
+		token         func(*testing.T) string
+		expectedQuery string
+		expectedArgs  []interface{}
+	}{
+		"first page": {
+			token:         func(*testing.T) string { return "" },
+			expectedQuery: "SELECT key FROM compute_plans WHERE channel = $1 ORDER BY creation_date ASC, key ASC LIMIT 11",
+			expectedArgs:  []interface{}{testChannel},
+		},
+		"legacy offset": {
+			token:         func(*testing.T) string { return "20" },
+			expectedQuery: "SELECT key FROM compute_plans WHERE channel = $1 ORDER BY creation_date ASC, key ASC LIMIT 11 OFFSET 20",
+			expectedArgs:  []interface{}{testChannel},
+		},
+		"cursor": {
+			token: func(t *testing.T) string {
+				token, err := (&page{keyset: ks}).nextToken("2022-01-01T00:00:00Z", "uuid")
+				require.NoError(t, err)
+				return token
+			},
+			expectedQuery: "SELECT key FROM compute_plans WHERE channel = $1 AND (creation_date, key) > ($2, $3) ORDER BY creation_date ASC, key ASC LIMIT 11",
+			expectedArgs:  []interface{}{testChannel, "2022-01-01T00:00:00Z", "uuid"},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pg, err := newPage(common.NewPagination(c.token(t), 10), ks)
+			require.NoError(t, err)
+
+			stmt := getStatementBuilder().Select("key").From("compute_plans").Where("channel = ?", testChannel)
+			query, args, err := pg.apply(stmt).ToSql()
+			require.NoError(t, err)
+
+			assert.Equal(t, c.expectedQuery, query)
+			assert.Equal(t, c.expectedArgs, args)
+		})
+	}
+}
+
+func TestKeysetAfterMixedOrder(t *testing.T) {
+	ks := keyset{{name: "creation_date"}, {name: "compute_task_key", desc: true}}
+
+	query, args, err := ks.after([]string{"2022-01-01T00:00:00Z", "uuid"}).ToSql()
+	require.NoError(t, err)
+
+	assert.Equal(t, "((creation_date > ?) OR (creation_date = ? AND compute_task_key < ?))", query)
+	assert.Equal(t, []interface{}{"2022-01-01T00:00:00Z", "2022-01-01T00:00:00Z", "uuid"}, args)
+}
+
+func TestNewPageInvalidToken(t *testing.T) {
+	ks := newKeyset(false, "creation_date", "key")
+	otherKs := newKeyset(true, "creation_date", "key")
+
+	otherToken, err := (&page{keyset: otherKs}).nextToken("2022-01-01T00:00:00Z", "uuid")
+	require.NoError(t, err)
+
+	cases := map[string]string{
+		"garbage":         "not a token",
+		"not json":        "bm90IGpzb24",
+		"unknown version": "eyJ2Ijo0MiwibyI6IiIsImsiOltdfQ",
+		"other keyset":    otherToken,
+	}
+
+	for name, token := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := newPage(common.NewPagination(token, 10), ks)
+			orcError := new(orcerrors.OrcError)
+			require.True(t, errors.As(err, &orcError))
+			assert.Equal(t, orcerrors.ErrBadRequest, orcError.Kind)
+		})
+	}
+}
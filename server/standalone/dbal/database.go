@@ -2,23 +2,44 @@ package dbal
 
 import (
 	"context"
+	"time"
 
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/substra/orchestrator/lib/persistence"
+	"github.com/substra/orchestrator/server/standalone/metrics"
 	"github.com/substra/orchestrator/utils"
 )
 
 type PgPool interface {
 	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
 	Close()
 }
 
+// Transaction is a DBAL bound to a transaction: its changes are persisted on Commit.
+type Transaction interface {
+	persistence.DBAL
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// TransactionFactory begins the transactions on which service providers are built.
+type TransactionFactory interface {
+	BeginDBAL(ctx context.Context, channel string, readOnly bool) (Transaction, error)
+}
+
 // Database is a thin wrapper around PgPool.
 // It handles the orchestrator specifics, such as DBAL creation.
+// When a read replica is configured, read-only transactions are routed to it, see BeginTransaction.
 type Database struct {
-	Pool PgPool
+	Pool    PgPool
+	replica *replica
 }
 
 type SQLLogger struct {
@@ -73,38 +94,80 @@ func (l *SQLLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, dat
 
 // InitDatabase opens a database connexion from given url.
 func InitDatabase(databaseURL string) (*Database, error) {
-	config, err := pgxpool.ParseConfig(databaseURL)
+	pool, err := connectPool(databaseURL)
 	if err != nil {
 		return nil, err
 	}
 
-	verbose, _ := utils.GetenvBool("LOG_SQL_VERBOSE")
-	config.ConnConfig.Logger = &SQLLogger{verbose: verbose}
+	return &Database{Pool: pool}, nil
+}
 
-	pool, err := pgxpool.ConnectConfig(context.Background(), config)
+// InitReplica opens a connexion to the read replica at replicaURL.
+// Read-only transactions are then routed to the replica as long as its replication lag does not exceed maxLag.
+func (d *Database) InitReplica(replicaURL string, maxLag time.Duration) error {
+	pool, err := connectPool(replicaURL)
+	if err != nil {
+		return err
+	}
+
+	d.replica = newReplica(pool, maxLag)
+
+	return nil
+}
+
+func connectPool(databaseURL string) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Database{pool}, nil
+	verbose, _ := utils.GetenvBool("LOG_SQL_VERBOSE")
+	config.ConnConfig.Logger = &SQLLogger{verbose: verbose}
+
+	return pgxpool.ConnectConfig(context.Background(), config)
 }
 
 // Close the connexion
 func (d *Database) Close() {
 	d.Pool.Close()
+	if d.replica != nil {
+		d.replica.pool.Close()
+	}
 }
 
 // BeginTransaction returns a new transaction.
 // When readOnly is false the transaction is configured with SERIALIZABLE isolation level to protect against potential
 // inconsistencies with concurrent requests.
+// Read-only transactions are begun on the read replica if there is one and it is up to date, on the primary otherwise.
 func (d *Database) BeginTransaction(ctx context.Context, readOnly bool) (pgx.Tx, error) {
-	log.Ctx(ctx).Debug().Bool("ReadOnly", readOnly).Msg("new DB transaction")
 	txOpts := pgx.TxOptions{
 		IsoLevel: pgx.Serializable, // This level of isolation is the guarantee to always return consistent data
 	}
+	accessMode := "read_write"
+	pool, poolName := d.Pool, primaryPoolName
+
 	if readOnly {
 		txOpts.AccessMode = pgx.ReadOnly
 		txOpts.IsoLevel = pgx.ReadCommitted
+		accessMode = "read_only"
+
+		if d.replica != nil && d.replica.isUpToDate(ctx) {
+			pool, poolName = d.replica.pool, replicaPoolName
+		}
+	}
+
+	log.Ctx(ctx).Debug().Bool("ReadOnly", readOnly).Str("pool", poolName).Msg("new DB transaction")
+	metrics.DBPoolTransactionTotal.WithLabelValues(poolName, accessMode).Inc()
+
+	return pool.BeginTx(ctx, txOpts)
+}
+
+// BeginDBAL returns a DBAL bound to a new transaction on the given channel, see BeginTransaction.
+func (d *Database) BeginDBAL(ctx context.Context, channel string, readOnly bool) (Transaction, error) {
+	tx, err := d.BeginTransaction(ctx, readOnly)
+	if err != nil {
+		return nil, err
 	}
-	return d.Pool.BeginTx(ctx, txOpts)
+
+	return New(ctx, tx, tx.Conn(), channel), nil
 }
@@ -2,10 +2,13 @@ package dbal
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/substra/orchestrator/utils"
 )
 
@@ -44,3 +47,83 @@ func TestTransactionIsolation(t *testing.T) {
 		})
 	}
 }
+
+// lagRow is the result of the replication lag query.
+type lagRow struct {
+	streaming bool
+	seconds   float64
+	err       error
+}
+
+func (r *lagRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*bool) = r.streaming
+	*dest[1].(*float64) = r.seconds
+	return nil
+}
+
+func TestReadOnlyTransactionRouting(t *testing.T) {
+	cases := map[string]struct {
+		lag       *lagRow
+		readOnly  bool
+		onReplica bool
+	}{
+		"read-only transaction on up to date replica":   {&lagRow{streaming: true, seconds: 1}, true, true},
+		"read-only transaction on late replica":         {&lagRow{streaming: true, seconds: 10}, true, false},
+		"read-only transaction on disconnected replica": {&lagRow{streaming: false, seconds: 0}, true, false},
+		"read-only transaction on unreachable replica":  {&lagRow{err: errors.New("unreachable")}, true, false},
+		"read-write transaction":                        {nil, false, false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			primary := new(MockPgPool)
+			replicaPool := new(MockPgPool)
+			db := Database{Pool: primary, replica: newReplica(replicaPool, 5*time.Second)}
+
+			if tc.lag != nil {
+				replicaPool.On("QueryRow", utils.AnyContext, replicaLagQuery).Once().Return(tc.lag)
+				db.replica.measure(context.Background())
+			}
+
+			tx := new(utils.MockTx)
+			if tc.onReplica {
+				replicaPool.On("BeginTx", utils.AnyContext, mock.Anything).Return(tx, nil)
+			} else {
+				primary.On("BeginTx", utils.AnyContext, mock.Anything).Return(tx, nil)
+			}
+
+			_, err := db.BeginTransaction(context.Background(), tc.readOnly)
+			assert.NoError(t, err)
+			primary.AssertExpectations(t)
+			replicaPool.AssertExpectations(t)
+		})
+	}
+}
+
+func TestReplicaLagIsMeasuredInBackground(t *testing.T) {
+	pool := new(MockPgPool)
+	r := newReplica(pool, 5*time.Second)
+	now := time.Unix(1000, 0)
+	r.now = func() time.Time { return now }
+
+	measured := make(chan time.Time)
+	pool.On("QueryRow", utils.AnyContext, replicaLagQuery).Once().WaitUntil(measured).Return(&lagRow{streaming: true, seconds: 0})
+	assert.False(t, r.isUpToDate(context.Background()), "the replica should be late until measured")
+	assert.False(t, r.isUpToDate(context.Background()), "a single measure should run at a time")
+
+	close(measured)
+	assert.Eventually(t, func() bool { return r.isUpToDate(context.Background()) }, 5*time.Second, time.Millisecond)
+
+	now = now.Add(replicaLagCheckInterval / 2)
+	assert.True(t, r.isUpToDate(context.Background()), "the previous measure should be reused")
+
+	pool.On("QueryRow", utils.AnyContext, replicaLagQuery).Once().Return(&lagRow{streaming: true, seconds: 10})
+	now = now.Add(replicaLagCheckInterval)
+	assert.Eventually(t, func() bool { return !r.isUpToDate(context.Background()) }, 5*time.Second, time.Millisecond)
+
+	assert.Eventually(t, func() bool { return !r.measuring.Load() }, 5*time.Second, time.Millisecond)
+	pool.AssertExpectations(t)
+}
@@ -2,7 +2,6 @@ package dbal
 
 import (
 	"errors"
-	"strconv"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -104,7 +103,7 @@ func (d *DBAL) GetDataManager(key string) (*asset.DataManager, error) {
 
 // QueryDataManagers implements persistence.DataManagerDBAL
 func (d *DBAL) QueryDataManagers(p *common.Pagination) ([]*asset.DataManager, common.PaginationToken, error) {
-	offset, err := getOffset(p.Token)
+	pg, err := newPage(p, newKeyset(false, "creation_date", "key"))
 	if err != nil {
 		return nil, "", err
 	}
@@ -112,11 +111,9 @@ func (d *DBAL) QueryDataManagers(p *common.Pagination) ([]*asset.DataManager, co
 	stmt := getStatementBuilder().
 		Select("key", "name", "owner", "permissions", "description_address", "description_checksum", "opener_address", "opener_checksum", "creation_date", "logs_permission", "metadata").
 		From("expanded_datamanagers").
-		Where(sq.Eq{"channel": d.channel}).
-		OrderByClause("creation_date ASC, key").
-		Offset(uint64(offset)).
-		// Fetch page size + 1 elements to determine whether there is a next page
-		Limit(uint64(p.Size + 1))
+		Where(sq.Eq{"channel": d.channel})
+
+	stmt = pg.apply(stmt)
 
 	rows, err := d.query(stmt)
 	if err != nil {
@@ -126,6 +123,7 @@ func (d *DBAL) QueryDataManagers(p *common.Pagination) ([]*asset.DataManager, co
 
 	var datamanagers []*asset.DataManager
 	var count int
+	var last *sqlDataManager
 
 	for rows.Next() {
 		dm := new(sqlDataManager)
@@ -136,6 +134,7 @@ func (d *DBAL) QueryDataManagers(p *common.Pagination) ([]*asset.DataManager, co
 		}
 
 		datamanagers = append(datamanagers, dm.toDataManager())
+		last = dm
 		count++
 
 		if count == int(p.Size) {
@@ -148,7 +147,10 @@ func (d *DBAL) QueryDataManagers(p *common.Pagination) ([]*asset.DataManager, co
 
 	bookmark := ""
 	if count == int(p.Size) && rows.Next() {
-		bookmark = strconv.Itoa(offset + count)
+		bookmark, err = pg.nextToken(cursorTime(last.CreationDate), last.Key)
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
 	return datamanagers, bookmark, nil
@@ -47,6 +47,10 @@ func TestPaginatedQueryDataManagers(t *testing.T) {
 	mock.ExpectQuery(`SELECT .* FROM expanded_datamanagers`).
 		WithArgs(testChannel).
 		WillReturnRows(makeDataManagerRows())
+	// Next page starts after the last returned data manager
+	mock.ExpectQuery(`SELECT .* FROM expanded_datamanagers WHERE channel = \$1 AND \(creation_date, key\) > \(\$2, \$3\)`).
+		WithArgs(testChannel, "1970-01-01T00:00:12Z", "key1").
+		WillReturnRows(makeDataManagerRows())
 
 	tx, err := mock.Begin(context.Background())
 	require.NoError(t, err)
@@ -56,7 +60,10 @@ func TestPaginatedQueryDataManagers(t *testing.T) {
 	res, bookmark, err := dbal.QueryDataManagers(common.NewPagination("", 1))
 	assert.NoError(t, err)
 	assert.Len(t, res, 1)
-	assert.Equal(t, "1", bookmark, "There should be another page")
+	assert.NotEmpty(t, bookmark, "There should be another page")
+
+	_, _, err = dbal.QueryDataManagers(common.NewPagination(bookmark, 1))
+	assert.NoError(t, err)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
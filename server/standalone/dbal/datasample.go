@@ -2,7 +2,6 @@ package dbal
 
 import (
 	"errors"
-	"strconv"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -171,7 +170,7 @@ func (d *DBAL) GetDataSample(key string) (*asset.DataSample, error) {
 
 // QueryDataSamples implements persistence.DataSample
 func (d *DBAL) QueryDataSamples(p *common.Pagination, filter *asset.DataSampleQueryFilter) ([]*asset.DataSample, common.PaginationToken, error) {
-	offset, err := getOffset(p.Token)
+	pg, err := newPage(p, newKeyset(false, "creation_date", "key"))
 	if err != nil {
 		return nil, "", err
 	}
@@ -179,16 +178,14 @@ func (d *DBAL) QueryDataSamples(p *common.Pagination, filter *asset.DataSampleQu
 	stmt := getStatementBuilder().
 		Select("key", "owner", "checksum", "creation_date", "datamanager_keys").
 		From("expanded_datasamples").
-		Where(sq.Eq{"channel": d.channel}).
-		OrderByClause("creation_date ASC, key").
-		Offset(uint64(offset)).
-		// Fetch page size + 1 elements to determine whether there is a next page
-		Limit(uint64(p.Size + 1))
+		Where(sq.Eq{"channel": d.channel})
 
 	if filter != nil && len(filter.Keys) > 0 {
 		stmt = stmt.Where(sq.Eq{"key": filter.Keys})
 	}
 
+	stmt = pg.apply(stmt)
+
 	rows, err := d.query(stmt)
 	if err != nil {
 		return nil, "", err
@@ -197,6 +194,7 @@ func (d *DBAL) QueryDataSamples(p *common.Pagination, filter *asset.DataSampleQu
 
 	var datasamples []*asset.DataSample
 	var count int
+	var last *sqlDataSample
 
 	for rows.Next() {
 		ds := new(sqlDataSample)
@@ -207,6 +205,7 @@ func (d *DBAL) QueryDataSamples(p *common.Pagination, filter *asset.DataSampleQu
 		}
 
 		datasamples = append(datasamples, ds.toDataSample())
+		last = ds
 		count++
 
 		if count == int(p.Size) {
@@ -220,7 +219,10 @@ func (d *DBAL) QueryDataSamples(p *common.Pagination, filter *asset.DataSampleQu
 	bookmark := ""
 	if count == int(p.Size) && rows.Next() {
 		// there is more to fetch
-		bookmark = strconv.Itoa(offset + count)
+		bookmark, err = pg.nextToken(cursorTime(last.CreationDate), last.Key)
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
 	return datasamples, bookmark, nil
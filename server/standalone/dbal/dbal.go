@@ -13,26 +13,48 @@ import (
 const PgSortAsc = "ASC"
 const PgSortDesc = "DESC"
 
-// Conn is the database connection used by the DBAL.
+// Conn is the database connection used by the DBAL outside of its transaction.
 type Conn interface {
 	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
 	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
 	QueryRow(context.Context, string, ...interface{}) pgx.Row
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// Listener receives the notifications sent when events are inserted, see EventNotifier.
+type Listener interface {
 	WaitForNotification(ctx context.Context) (*pgconn.Notification, error)
 }
 
 // DBAL is the Database Abstraction Layer around asset storage
 type DBAL struct {
-	ctx     context.Context
-	tx      pgx.Tx
-	conn    Conn
-	channel string
+	ctx      context.Context
+	tx       pgx.Tx
+	conn     Conn
+	listener Listener
+	channel  string
 }
 
 func New(ctx context.Context, tx pgx.Tx, conn Conn, channel string) *DBAL {
 	return &DBAL{ctx: ctx, tx: tx, conn: conn, channel: channel}
 }
 
+// NewWithListener returns a DBAL streaming events, it reads from conn and waits for new events with listener.
+// The listener must be subscribed to the channel before any event is read, so that no event is missed.
+func NewWithListener(ctx context.Context, conn Conn, listener Listener, channel string) *DBAL {
+	return &DBAL{ctx: ctx, conn: conn, listener: listener, channel: channel}
+}
+
+// Commit persists the changes made through the DBAL.
+func (d *DBAL) Commit(ctx context.Context) error {
+	return d.tx.Commit(ctx)
+}
+
+// Rollback discards the changes made through the DBAL.
+func (d *DBAL) Rollback(ctx context.Context) error {
+	return d.tx.Rollback(ctx)
+}
+
 func getStatementBuilder() squirrel.StatementBuilderType {
 	return squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 }
@@ -62,6 +84,19 @@ func (d *DBAL) exec(builder squirrel.Sqlizer) error {
 	return err
 }
 
+// execCount executes the statement and returns the number of affected rows.
+func (d *DBAL) execCount(builder squirrel.Sqlizer) (uint32, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+	tag, err := d.tx.Exec(d.ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(tag.RowsAffected()), nil
+}
+
 func getOffset(token string) (int, error) {
 	if token == "" {
 		token = "0"
@@ -3,7 +3,6 @@ package dbal
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"strconv"
 	"time"
 
@@ -116,27 +115,21 @@ func (d *DBAL) AddEvents(events ...*asset.Event) error {
 }
 
 func (d *DBAL) QueryEvents(p *common.Pagination, filter *asset.EventQueryFilter, sortOrder asset.SortOrder) ([]*asset.Event, common.PaginationToken, error) {
-	offset, err := getOffset(p.Token)
+	// Events are paginated by position rather than timestamp: the timestamp is taken when the transaction starts,
+	// an event committed after a page was read could otherwise be sorted before its cursor and never be returned.
+	// Positions are assigned under the events table lock, in commit order.
+	pg, err := newPage(p, newKeyset(sortOrder == asset.SortOrder_DESCENDING, "position"))
 	if err != nil {
 		return nil, "", err
 	}
 
-	order := PgSortAsc
-	if sortOrder == asset.SortOrder_DESCENDING {
-		order = PgSortDesc
-	}
-	orderBy := fmt.Sprintf("timestamp %s, id %s", order, order)
-
 	stmt := getStatementBuilder().
-		Select("id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata").
+		Select("id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata", "position").
 		From("events").
-		Where(sq.Eq{"channel": d.channel}).
-		OrderByClause(orderBy).
-		Offset(uint64(offset)).
-		// Fetch page size + 1 elements to determine whether there is a next page
-		Limit(uint64(p.Size + 1))
+		Where(sq.Eq{"channel": d.channel})
 
-	stmt = eventFilterToQuery(filter, stmt)
+	stmt = d.eventFilterToQuery(filter, stmt)
+	stmt = pg.apply(stmt)
 
 	rows, err := d.query(stmt)
 	if err != nil {
@@ -146,11 +139,12 @@ func (d *DBAL) QueryEvents(p *common.Pagination, filter *asset.EventQueryFilter,
 
 	var events []*asset.Event
 	var count int
+	var lastPosition int64
 
 	for rows.Next() {
 		ev := sqlEvent{Channel: d.channel}
 
-		err = rows.Scan(&ev.ID, &ev.AssetKey, &ev.AssetKind, &ev.EventKind, &ev.Timestamp, &ev.Asset, &ev.Metadata)
+		err = rows.Scan(&ev.ID, &ev.AssetKey, &ev.AssetKind, &ev.EventKind, &ev.Timestamp, &ev.Asset, &ev.Metadata, &lastPosition)
 		if err != nil {
 			return nil, "", err
 		}
@@ -174,78 +168,143 @@ func (d *DBAL) QueryEvents(p *common.Pagination, filter *asset.EventQueryFilter,
 	bookmark := ""
 	if count == int(p.Size) && rows.Next() {
 		// there is more to fetch
-		bookmark = strconv.Itoa(offset + count)
+		bookmark, err = pg.nextToken(strconv.FormatInt(lastPosition, 10))
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
 	return events, bookmark, nil
 }
 
 // eventFilterToQuery convert as filter into query string and param list
-func eventFilterToQuery(filter *asset.EventQueryFilter, builder sq.SelectBuilder) sq.SelectBuilder {
+func (d *DBAL) eventFilterToQuery(filter *asset.EventQueryFilter, builder sq.SelectBuilder) sq.SelectBuilder {
+	for _, condition := range d.eventFilterConditions(filter) {
+		builder = builder.Where(condition)
+	}
+
+	return builder
+}
+
+// eventFilterConditions returns the conditions selecting the events of the channel matching the filter.
+func (d *DBAL) eventFilterConditions(filter *asset.EventQueryFilter) []sq.Sqlizer {
 	if filter == nil {
-		return builder
+		return nil
 	}
 
+	conditions := []sq.Sqlizer{}
+
 	if filter.AssetKey != "" {
-		builder = builder.Where(sq.Eq{"asset_key": filter.AssetKey})
+		conditions = append(conditions, sq.Eq{"asset_key": filter.AssetKey})
 	}
+
+	assetKinds := make([]string, 0, len(filter.AssetKinds)+1)
 	if filter.AssetKind != asset.AssetKind_ASSET_UNKNOWN {
-		builder = builder.Where(sq.Eq{"asset_kind": filter.AssetKind.String()})
+		assetKinds = append(assetKinds, filter.AssetKind.String())
 	}
+	for _, kind := range filter.AssetKinds {
+		assetKinds = append(assetKinds, kind.String())
+	}
+	if len(assetKinds) == 1 {
+		conditions = append(conditions, sq.Eq{"asset_kind": assetKinds[0]})
+	} else if len(assetKinds) > 1 {
+		conditions = append(conditions, sq.Eq{"asset_kind": assetKinds})
+	}
+
+	eventKinds := make([]string, 0, len(filter.EventKinds)+1)
 	if filter.EventKind != asset.EventKind_EVENT_UNKNOWN {
-		builder = builder.Where(sq.Eq{"event_kind": filter.EventKind.String()})
+		eventKinds = append(eventKinds, filter.EventKind.String())
+	}
+	for _, kind := range filter.EventKinds {
+		eventKinds = append(eventKinds, kind.String())
+	}
+	if len(eventKinds) == 1 {
+		conditions = append(conditions, sq.Eq{"event_kind": eventKinds[0]})
+	} else if len(eventKinds) > 1 {
+		conditions = append(conditions, sq.Eq{"event_kind": eventKinds})
+	}
+
+	if filter.ComputePlanKey != "" {
+		conditions = append(conditions, sq.Or{
+			sq.Eq{"asset_key": filter.ComputePlanKey},
+			d.planEventsCondition(filter.ComputePlanKey),
+		})
 	}
 	if filter.Metadata != nil {
-		builder = builder.Where(sq.Expr("metadata @> ?", filter.Metadata))
+		conditions = append(conditions, sq.Expr("metadata @> ?", filter.Metadata))
 	}
 	if filter.Start != nil {
-		builder = builder.Where(sq.GtOrEq{"timestamp": filter.Start.AsTime()})
+		conditions = append(conditions, sq.GtOrEq{"timestamp": filter.Start.AsTime()})
 	}
 	if filter.End != nil {
-		builder = builder.Where(sq.LtOrEq{"timestamp": filter.End.AsTime()})
+		conditions = append(conditions, sq.LtOrEq{"timestamp": filter.End.AsTime()})
 	}
 
-	return builder
+	return conditions
 }
 
-// SubscribeToEvents replays already existing events starting from startEventID (excluded),
+// SubscribeToEvents replays already existing events starting from param.StartEventId (excluded),
 // then it waits and forward newly created events.
-func (d *DBAL) SubscribeToEvents(startEventID string, stream asset.EventService_SubscribeToEventsServer) error {
-	// Start listening to event notifications before fetching already existing events
-	// from the database to prevent missing any event.
-	err := d.startListeningToEventNotifications()
+// When no start event is given, events are replayed from the last event acknowledged by the durable consumer
+// param.Consumer of the owner, or from the beginning.
+// Only the events matching param.Filter are sent, a nil filter matches every event.
+// The listener of the DBAL must be subscribed before calling this method, so that no event is missed.
+func (d *DBAL) SubscribeToEvents(param *asset.SubscribeToEventsParam, owner string, stream asset.EventService_SubscribeToEventsServer) error {
+	filter := param.Filter
+
+	var err error
+	// startAfterPosition is the position of the last event already processed by the subscriber
+	startAfterPosition := int64(0)
+
+	switch {
+	case param.StartEventId != "":
+		startAfterPosition, err = d.getEventPosition(param.StartEventId)
+	case param.Consumer != "":
+		startAfterPosition, err = d.getEventConsumerPosition(owner, param.Consumer)
+	}
 	if err != nil {
 		return err
 	}
 
-	// lastProcessedPos stores the position of the last processed event
-	lastProcessedPos := int64(0)
+	return d.streamEvents(startAfterPosition, filter, stream.Send)
+}
 
-	if startEventID != "" {
-		lastProcessedPos, err = d.getEventPosition(startEventID)
-		if err != nil {
+// streamEvents replays the existing events positioned after startAfterPosition,
+// then it forwards newly created events until the context is done.
+// The listener of the DBAL must be subscribed before determining startAfterPosition.
+func (d *DBAL) streamEvents(startAfterPosition int64, filter *asset.EventQueryFilter, send func(*asset.Event) error) error {
+	lastProcessedPos, err := d.replayEvents(startAfterPosition, filter, send)
+	if err != nil {
+		return err
+	}
+
+	for {
+		if err = d.ctx.Err(); err != nil {
 			return err
 		}
-	}
 
-	hasNextBatch := true
-	for hasNextBatch {
-		lastProcessedPos, hasNextBatch, err = d.replayBatchOfEvents(lastProcessedPos, stream)
+		lastProcessedPos, err = d.forwardEventNotification(lastProcessedPos, filter, send)
 		if err != nil {
 			return err
 		}
 	}
+}
 
-	for {
-		if err = d.ctx.Err(); err != nil {
-			return err
-		}
+// replayEvents sends every existing event positioned after startAfterPosition and matching the filter,
+// it returns the position of the last processed event.
+func (d *DBAL) replayEvents(startAfterPosition int64, filter *asset.EventQueryFilter, send func(*asset.Event) error) (int64, error) {
+	lastProcessedPos := startAfterPosition
 
-		lastProcessedPos, err = d.forwardEventNotification(lastProcessedPos, stream)
+	var err error
+	hasNextBatch := true
+	for hasNextBatch {
+		lastProcessedPos, hasNextBatch, err = d.replayBatchOfEvents(lastProcessedPos, filter, send)
 		if err != nil {
-			return err
+			return 0, err
 		}
 	}
+
+	return lastProcessedPos, nil
 }
 
 func (d *DBAL) getEventPosition(eventID string) (int64, error) {
@@ -267,14 +326,19 @@ func (d *DBAL) getEventPosition(eventID string) (int64, error) {
 	return position, err
 }
 
-// replayBatchOfEvents fetches a batch of already existing events from the database and send them in the provided stream.
+// replayBatchOfEvents fetches a batch of already existing events from the database and sends them with the provided function.
 // Events are replayed based on position order, starting right after startAfterPosition.
-func (d *DBAL) replayBatchOfEvents(startAfterPosition int64, stream asset.EventService_SubscribeToEventsServer) (lastProcessedPos int64, hasNextBatch bool, err error) {
+// Events not matching the filter are skipped.
+func (d *DBAL) replayBatchOfEvents(startAfterPosition int64, filter *asset.EventQueryFilter, send func(*asset.Event) error) (lastProcessedPos int64, hasNextBatch bool, err error) {
+	lastProcessedPos = startAfterPosition
+
 	stmt := getStatementBuilder().
 		Select("position", "id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata").
 		From("events").
 		Where(sq.Eq{"channel": d.channel}).
-		Where(sq.Gt{"position": startAfterPosition}).
+		Where(sq.Gt{"position": startAfterPosition})
+
+	stmt = d.eventFilterToQuery(filter, stmt).
 		OrderBy("position").
 		// Fetch replayEventsBatchSize size + 1 elements to determine whether there is a next batch to fetch
 		Limit(uint64(replayEventsBatchSize + 1))
@@ -306,7 +370,7 @@ func (d *DBAL) replayBatchOfEvents(startAfterPosition int64, stream asset.EventS
 			return 0, false, err
 		}
 
-		err = stream.Send(event)
+		err = send(event)
 		if err != nil {
 			return 0, false, err
 		}
@@ -327,15 +391,11 @@ func (d *DBAL) replayBatchOfEvents(startAfterPosition int64, stream asset.EventS
 	return lastProcessedPos, hasNextBatch, err
 }
 
-// startListeningToEventNotifications starts listening to PostgreSQL notifications
-func (d *DBAL) startListeningToEventNotifications() error {
-	_, err := d.conn.Exec(context.Background(), "LISTEN events")
-	return err
-}
-
-// forwardEventNotification waits for the reception of a notification indicating a new event,
-// and then sends the corresponding event into the provided stream.
-func (d *DBAL) forwardEventNotification(lastProcessedPos int64, stream asset.EventService_SubscribeToEventsServer) (int64, error) {
+// forwardEventNotification waits for the reception of a notification indicating a new event of the channel,
+// and then sends the events matching the filter created since the last processed one.
+// Since events are committed in position order, a notification guarantees that every previous event is visible:
+// a notification covers the ones which may have been dropped before it.
+func (d *DBAL) forwardEventNotification(lastProcessedPos int64, filter *asset.EventQueryFilter, send func(*asset.Event) error) (int64, error) {
 	notif, err := d.waitForEventNotification()
 	if err != nil {
 		return lastProcessedPos, err
@@ -351,19 +411,17 @@ func (d *DBAL) forwardEventNotification(lastProcessedPos int64, stream asset.Eve
 		return lastProcessedPos, nil
 	}
 
-	event, err := d.getEventByPosition(notif.EventPosition)
+	replayedPos, err := d.replayEvents(lastProcessedPos, filter, send)
 	if err != nil {
 		return lastProcessedPos, err
 	}
 
-	err = stream.Send(event)
-	if err != nil {
-		return lastProcessedPos, err
+	// events up to the notified one which have not been replayed do not match the filter
+	if replayedPos < notif.EventPosition {
+		replayedPos = notif.EventPosition
 	}
 
-	lastProcessedPos = notif.EventPosition
-
-	return lastProcessedPos, nil
+	return replayedPos, nil
 }
 
 // eventNotification is sent with PostgreSQL NOTIFY when an event is inserted in the events table
@@ -375,7 +433,7 @@ type eventNotification struct {
 // waitForEventNotification returns an *eventNotification upon reception
 // of a PostgreSQL notification.
 func (d *DBAL) waitForEventNotification() (*eventNotification, error) {
-	notif, err := d.conn.WaitForNotification(d.ctx)
+	notif, err := d.listener.WaitForNotification(d.ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -384,25 +442,3 @@ func (d *DBAL) waitForEventNotification() (*eventNotification, error) {
 	err = json.Unmarshal([]byte(notif.Payload), eventNotif)
 	return eventNotif, err
 }
-
-func (d *DBAL) getEventByPosition(position int64) (*asset.Event, error) {
-	stmt := getStatementBuilder().
-		Select("id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata").
-		From("events").
-		Where(sq.Eq{"position": position, "channel": d.channel})
-
-	query, args, err := stmt.ToSql()
-	if err != nil {
-		return nil, err
-	}
-
-	row := d.conn.QueryRow(context.Background(), query, args...)
-
-	ev := sqlEvent{Channel: d.channel}
-	err = row.Scan(&ev.ID, &ev.AssetKey, &ev.AssetKind, &ev.EventKind, &ev.Timestamp, &ev.Asset, &ev.Metadata)
-	if err != nil {
-		return nil, err
-	}
-
-	return ev.toEvent()
-}
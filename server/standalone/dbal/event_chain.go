@@ -0,0 +1,140 @@
+package dbal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// chainedEvent holds the stored representation of an event used to compute its hash.
+type chainedEvent struct {
+	Position  int64
+	ID        string
+	AssetKey  string
+	AssetKind string
+	EventKind string
+	Timestamp time.Time
+	// Asset and Metadata are the text representations of the JSONB columns
+	Asset    string
+	Metadata string
+	Hash     []byte
+	// PreviousHash is the hash the event has been chained to when it was inserted
+	PreviousHash []byte
+	// Purged events are only known by their hash, which links the remaining events of the chain
+	Purged bool
+}
+
+// canonicalForm returns the serialized form of the event which is hashed.
+// It must match the event_canonical_form SQL function, which computes the hash when events are inserted.
+func (e *chainedEvent) canonicalForm(channel string) []byte {
+	return []byte(strings.Join([]string{
+		channel,
+		strconv.FormatInt(e.Position, 10),
+		e.ID,
+		e.AssetKey,
+		e.AssetKind,
+		e.EventKind,
+		e.Timestamp.UTC().Format("2006-01-02T15:04:05.000000Z"),
+		e.Asset,
+		e.Metadata,
+	}, "\n"))
+}
+
+// chainEventHash returns the hash of an event chained to the hash of the previous event.
+func chainEventHash(previous []byte, canonicalForm []byte) []byte {
+	h := sha256.New()
+	h.Write(previous)
+	h.Write(canonicalForm)
+	return h.Sum(nil)
+}
+
+// chainVerifier checks the events of a channel in position order.
+type chainVerifier struct {
+	channel  string
+	status   *asset.EventChainStatus
+	previous []byte
+}
+
+func newChainVerifier(channel string) *chainVerifier {
+	return &chainVerifier{
+		channel: channel,
+		status:  &asset.EventChainStatus{Valid: true},
+	}
+}
+
+// verify returns false and records the broken link if the hash of the event does not match.
+// The hash of a purged event cannot be recomputed: only its link to the previous event is checked.
+func (v *chainVerifier) verify(e *chainedEvent) bool {
+	if e.Purged {
+		if !bytes.Equal(v.previous, e.PreviousHash) {
+			v.broken(e, v.previous, e.PreviousHash)
+			return false
+		}
+		v.status.PurgedEvents++
+		v.previous = e.Hash
+		return true
+	}
+
+	expected := chainEventHash(v.previous, e.canonicalForm(v.channel))
+	if !bytes.Equal(expected, e.Hash) {
+		v.broken(e, expected, e.Hash)
+		return false
+	}
+
+	v.status.VerifiedEvents++
+	v.status.LastEventId = e.ID
+	v.status.LastHash = hex.EncodeToString(e.Hash)
+	v.previous = e.Hash
+	return true
+}
+
+func (v *chainVerifier) broken(e *chainedEvent, expected, actual []byte) {
+	v.status.Valid = false
+	v.status.FirstBrokenLink = &asset.BrokenEventLink{
+		EventId:      e.ID,
+		Position:     uint64(e.Position),
+		ExpectedHash: hex.EncodeToString(expected),
+		ActualHash:   hex.EncodeToString(actual),
+	}
+}
+
+// VerifyEventChain recomputes the hash of every event of the channel in position order,
+// and reports the first event whose stored hash does not match.
+// Purged events are replaced by their tombstone.
+func (d *DBAL) VerifyEventChain() (*asset.EventChainStatus, error) {
+	stmt := getStatementBuilder().
+		Select("position", "id", "asset_key", "asset_kind", "event_kind", "timestamp", "COALESCE(asset::text, '')", "metadata::text", "hash", "previous_hash", "false").
+		From("events").
+		Where(sq.Eq{"channel": d.channel}).
+		Suffix("UNION ALL SELECT position, id, '', '', '', TO_TIMESTAMP(0), '', '', hash, previous_hash, true FROM event_tombstones WHERE channel = ? "+
+			"ORDER BY position", d.channel)
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	verifier := newChainVerifier(d.channel)
+
+	for rows.Next() {
+		e := new(chainedEvent)
+
+		err = rows.Scan(&e.Position, &e.ID, &e.AssetKey, &e.AssetKind, &e.EventKind, &e.Timestamp, &e.Asset, &e.Metadata, &e.Hash, &e.PreviousHash, &e.Purged)
+		if err != nil {
+			return nil, err
+		}
+
+		if !verifier.verify(e) {
+			return verifier.status, nil
+		}
+	}
+
+	return verifier.status, rows.Err()
+}
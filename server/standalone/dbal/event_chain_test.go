@@ -0,0 +1,133 @@
+package dbal
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainEventHash(t *testing.T) {
+	e := &chainedEvent{
+		Position:  1,
+		ID:        "id1",
+		AssetKey:  "key1",
+		AssetKind: "ASSET_FUNCTION",
+		EventKind: "EVENT_ASSET_CREATED",
+		Timestamp: time.Unix(1, 2000),
+		Asset:     `{"key": "key1"}`,
+		Metadata:  `{}`,
+	}
+
+	hash := chainEventHash(nil, e.canonicalForm("mychannel"))
+	assert.Equal(t, "0ca939fd61f181aec183d0acf0903636a30e084c42a06e88159002cdd57da116", hex.EncodeToString(hash))
+}
+
+func TestVerifyEventChain(t *testing.T) {
+	events := []*chainedEvent{
+		{Position: 1, ID: "id1", AssetKey: "key1", AssetKind: "ASSET_FUNCTION", EventKind: "EVENT_ASSET_CREATED", Timestamp: time.Unix(1, 0).UTC(), Asset: `{"key": "key1"}`, Metadata: `{}`},
+		{Position: 3, ID: "id2", AssetKey: "key2", AssetKind: "ASSET_MODEL", EventKind: "EVENT_ASSET_CREATED", Timestamp: time.Unix(2, 0).UTC(), Asset: `{"key": "key2"}`, Metadata: `{}`},
+		{Position: 4, ID: "id3", AssetKey: "key2", AssetKind: "ASSET_MODEL", EventKind: "EVENT_ASSET_DISABLED", Timestamp: time.Unix(3, 0).UTC(), Asset: `{"key": "key2"}`, Metadata: `{}`},
+	}
+
+	var previous []byte
+	for _, e := range events {
+		e.PreviousHash = previous
+		e.Hash = chainEventHash(previous, e.canonicalForm(testChannel))
+		previous = e.Hash
+	}
+
+	cases := map[string]struct {
+		// tamper alters the stored events before the verification
+		tamper         func([]*chainedEvent) []*chainedEvent
+		valid          bool
+		verifiedEvents uint64
+		purgedEvents   uint64
+		brokenEventID  string
+	}{
+		"valid": {
+			tamper:         func(e []*chainedEvent) []*chainedEvent { return e },
+			valid:          true,
+			verifiedEvents: 3,
+		},
+		"purged event": {
+			tamper: func(e []*chainedEvent) []*chainedEvent {
+				purged := &chainedEvent{Position: e[1].Position, ID: e[1].ID, Hash: e[1].Hash, PreviousHash: e[1].PreviousHash, Purged: true}
+				return []*chainedEvent{e[0], purged, e[2]}
+			},
+			valid:          true,
+			verifiedEvents: 2,
+			purgedEvents:   1,
+		},
+		"deleted event before a purged one": {
+			tamper: func(e []*chainedEvent) []*chainedEvent {
+				purged := &chainedEvent{Position: e[2].Position, ID: e[2].ID, Hash: e[2].Hash, PreviousHash: e[2].PreviousHash, Purged: true}
+				return []*chainedEvent{e[0], purged}
+			},
+			verifiedEvents: 1,
+			brokenEventID:  "id3",
+		},
+		"altered asset": {
+			tamper: func(e []*chainedEvent) []*chainedEvent {
+				altered := *e[1]
+				altered.Asset = `{"key": "other"}`
+				return []*chainedEvent{e[0], &altered, e[2]}
+			},
+			verifiedEvents: 1,
+			brokenEventID:  "id2",
+		},
+		"deleted event": {
+			tamper: func(e []*chainedEvent) []*chainedEvent {
+				return []*chainedEvent{e[0], e[2]}
+			},
+			verifiedEvents: 1,
+			brokenEventID:  "id3",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+			require.NoError(t, err)
+
+			rows := pgxmock.NewRows([]string{"position", "id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata", "hash", "previous_hash", "purged"})
+			for _, e := range c.tamper(events) {
+				rows.AddRow(e.Position, e.ID, e.AssetKey, e.AssetKind, e.EventKind, e.Timestamp, e.Asset, e.Metadata, e.Hash, e.PreviousHash, e.Purged)
+			}
+
+			mock.ExpectBegin()
+			mock.
+				ExpectQuery(`SELECT position, id, asset_key, asset_kind, event_kind, timestamp, COALESCE(asset::text, ''), metadata::text, hash, previous_hash, false FROM events WHERE channel = $1 `+
+					`UNION ALL SELECT position, id, '', '', '', TO_TIMESTAMP(0), '', '', hash, previous_hash, true FROM event_tombstones WHERE channel = $2 ORDER BY position`).
+				WithArgs(testChannel, testChannel).
+				WillReturnRows(rows)
+
+			tx, err := mock.Begin(context.Background())
+			require.NoError(t, err)
+
+			dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+			status, err := dbal.VerifyEventChain()
+			require.NoError(t, err)
+
+			assert.Equal(t, c.valid, status.Valid)
+			assert.Equal(t, c.verifiedEvents, status.VerifiedEvents)
+			assert.Equal(t, c.purgedEvents, status.PurgedEvents)
+			if c.valid {
+				assert.Nil(t, status.FirstBrokenLink)
+				assert.Equal(t, "id3", status.LastEventId)
+				assert.Equal(t, hex.EncodeToString(events[2].Hash), status.LastHash)
+			} else {
+				require.NotNil(t, status.FirstBrokenLink)
+				assert.Equal(t, c.brokenEventID, status.FirstBrokenLink.EventId)
+				assert.Equal(t, "id1", status.LastEventId)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
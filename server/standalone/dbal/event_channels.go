@@ -0,0 +1,190 @@
+package dbal
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// channelSubscription streams the events of several channels over a single connection.
+// Since positions are shared by every channel, events are merged in position order.
+type channelSubscription struct {
+	conn     Conn
+	listener Listener
+	channels []string
+	// dbals holds a DBAL bound to each subscribed channel
+	dbals map[string]*DBAL
+	// positions holds the position of the last processed event of each channel
+	positions map[string]int64
+	// notifiedPos is the position of the last notified event whose previous events have all been replayed
+	notifiedPos int64
+	filter      *asset.EventQueryFilter
+}
+
+// SubscribeToChannels replays the existing events of the given channels, then it waits and forwards newly created events.
+// The events of each channel are replayed after the start event of its cursor (excluded), or from the beginning.
+// Only the events matching param.Filter are sent, a nil filter matches every event.
+// The listener must be subscribed to every channel before calling this function, so that no event is missed.
+func SubscribeToChannels(ctx context.Context, conn Conn, listener Listener, channels []string, param *asset.SubscribeToChannelsParam, stream asset.EventService_SubscribeToChannelsServer) error {
+	s := &channelSubscription{
+		conn:      conn,
+		listener:  listener,
+		channels:  channels,
+		dbals:     make(map[string]*DBAL, len(channels)),
+		positions: make(map[string]int64, len(channels)),
+		filter:    param.Filter,
+	}
+	for _, channel := range channels {
+		s.dbals[channel] = NewWithListener(ctx, conn, listener, channel)
+	}
+
+	var err error
+	for _, cursor := range param.Cursors {
+		if cursor.StartEventId == "" {
+			continue
+		}
+		s.positions[cursor.Channel], err = s.dbals[cursor.Channel].getEventPosition(cursor.StartEventId)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.replayEvents(stream.Send)
+	if err != nil {
+		return err
+	}
+
+	for {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		err = s.forwardEventNotification(stream.Send)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// replayEvents sends every existing event of the subscribed channels positioned after their cursor.
+func (s *channelSubscription) replayEvents(send func(*asset.Event) error) error {
+	hasNextBatch := true
+	for hasNextBatch {
+		var err error
+		hasNextBatch, err = s.replayBatchOfEvents(send)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replayBatchOfEvents fetches a batch of already existing events of every channel and sends them with the provided function.
+func (s *channelSubscription) replayBatchOfEvents(send func(*asset.Event) error) (hasNextBatch bool, err error) {
+	conditions := make(sq.Or, 0, len(s.channels))
+	for _, channel := range s.channels {
+		condition := sq.And{
+			sq.Eq{"channel": channel},
+			sq.Gt{"position": s.positions[channel]},
+		}
+		conditions = append(conditions, append(condition, s.dbals[channel].eventFilterConditions(s.filter)...))
+	}
+
+	stmt := getStatementBuilder().
+		Select("position", "channel", "id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata").
+		From("events").
+		Where(conditions).
+		OrderBy("position").
+		// Fetch replayEventsBatchSize size + 1 elements to determine whether there is a next batch to fetch
+		Limit(uint64(replayEventsBatchSize + 1))
+
+	query, args, err := stmt.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := s.conn.Query(context.Background(), query, args...)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	count := 0
+	lastPosition := int64(0)
+
+	for rows.Next() {
+		var position int64
+		ev := sqlEvent{}
+
+		err = rows.Scan(&position, &ev.Channel, &ev.ID, &ev.AssetKey, &ev.AssetKind, &ev.EventKind, &ev.Timestamp, &ev.Asset, &ev.Metadata)
+		if err != nil {
+			return false, err
+		}
+
+		event, err := ev.toEvent()
+		if err != nil {
+			return false, err
+		}
+
+		err = send(event)
+		if err != nil {
+			return false, err
+		}
+
+		lastPosition = position
+		count++
+
+		if count == replayEventsBatchSize {
+			break
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	// Every matching event up to lastPosition has been sent, whatever its channel
+	for _, channel := range s.channels {
+		if s.positions[channel] < lastPosition {
+			s.positions[channel] = lastPosition
+		}
+	}
+
+	return count == replayEventsBatchSize && rows.Next(), nil
+}
+
+// forwardEventNotification waits for the reception of a notification indicating a new event of a subscribed channel,
+// and then sends the events of every subscribed channel created since the last processed ones, see DBAL.forwardEventNotification.
+func (s *channelSubscription) forwardEventNotification(send func(*asset.Event) error) error {
+	notif, err := s.dbals[s.channels[0]].waitForEventNotification()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := s.dbals[notif.Channel]; !ok {
+		return nil
+	}
+
+	// since events are inserted with a strictly increasing position value,
+	// this ensures that an already forwarded event cannot be sent again
+	if notif.EventPosition <= s.notifiedPos || notif.EventPosition <= s.positions[notif.Channel] {
+		return nil
+	}
+
+	err = s.replayEvents(send)
+	if err != nil {
+		return err
+	}
+
+	// events up to the notified one which have not been replayed do not match the filter
+	s.notifiedPos = notif.EventPosition
+	for _, channel := range s.channels {
+		if s.positions[channel] < notif.EventPosition {
+			s.positions[channel] = notif.EventPosition
+		}
+	}
+
+	return nil
+}
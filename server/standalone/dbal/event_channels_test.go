@@ -0,0 +1,136 @@
+package dbal
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/utils"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newTestChannelSubscription(conn *utils.MockConn, filter *asset.EventQueryFilter, channels ...string) *channelSubscription {
+	s := &channelSubscription{
+		conn:      conn,
+		listener:  conn,
+		channels:  channels,
+		dbals:     make(map[string]*DBAL),
+		positions: make(map[string]int64),
+		filter:    filter,
+	}
+	for _, channel := range channels {
+		s.dbals[channel] = &DBAL{ctx: context.TODO(), conn: conn, listener: conn, channel: channel}
+	}
+
+	return s
+}
+
+func TestReplayBatchOfChannelEvents(t *testing.T) {
+	conn, err := utils.NewMockConn()
+	require.NoError(t, err)
+
+	rows := pgxmock.NewRows([]string{"position", "channel", "id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata"}).
+		AddRow(int64(12), "yourchannel", "id1", "13e88e4f-a287-4e8f-a96e-ea0c03f91e86", "ASSET_FUNCTION", "EVENT_ASSET_CREATED", time.Unix(1, 0).UTC(), []byte(`{}`), map[string]string{}).
+		AddRow(int64(15), testChannel, "id2", "7623fc2d-33fd-4b00-a6a0-65f5ec2eee20", "ASSET_FUNCTION", "EVENT_ASSET_CREATED", time.Unix(2, 0).UTC(), []byte(`{}`), map[string]string{})
+
+	query := "SELECT position, channel, id, asset_key, asset_kind, event_kind, timestamp, asset, metadata FROM events " +
+		"WHERE ((channel = $1 AND position > $2 AND asset_kind = $3) OR (channel = $4 AND position > $5 AND asset_kind = $6)) " +
+		"ORDER BY position " +
+		fmt.Sprintf("LIMIT %d", replayEventsBatchSize+1)
+	conn.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(testChannel, int64(10), "ASSET_FUNCTION", "yourchannel", int64(0), "ASSET_FUNCTION").
+		WillReturnRows(rows)
+
+	s := newTestChannelSubscription(conn, &asset.EventQueryFilter{AssetKind: asset.AssetKind_ASSET_FUNCTION}, testChannel, "yourchannel")
+	s.positions[testChannel] = 10
+
+	stream := new(asset.MockEventService_SubscribeToChannelsServer)
+	stream.On("Send", mock.MatchedBy(func(e *asset.Event) bool { return e.Id == "id1" && e.Channel == "yourchannel" })).Once().Return(nil)
+	stream.On("Send", mock.MatchedBy(func(e *asset.Event) bool { return e.Id == "id2" && e.Channel == testChannel })).Once().Return(nil)
+
+	hasNextBatch, err := s.replayBatchOfEvents(stream.Send)
+	assert.NoError(t, err)
+	assert.False(t, hasNextBatch)
+	assert.Equal(t, int64(15), s.positions[testChannel])
+	assert.Equal(t, int64(15), s.positions["yourchannel"])
+
+	stream.AssertExpectations(t)
+	assert.NoError(t, conn.ExpectationsWereMet())
+}
+
+func TestForwardChannelEventNotification(t *testing.T) {
+	ctx := context.TODO()
+
+	event := &asset.Event{
+		Id:        "b2b30b36-b7f3-4839-9c6f-36ddafcf19fc",
+		AssetKey:  "56a3dc56-f493-47e5-8a61-46a120e5403c",
+		AssetKind: asset.AssetKind_ASSET_FUNCTION,
+		EventKind: asset.EventKind_EVENT_ASSET_CREATED,
+		Channel:   "yourchannel",
+		Timestamp: timestamppb.New(time.Unix(15000, 0)),
+		Asset:     &asset.Event_Function{Function: &asset.Function{}},
+		Metadata:  map[string]string{},
+	}
+
+	cases := map[string]struct {
+		notif *eventNotification
+		sent  bool
+	}{
+		"subscribed channel":      {&eventNotification{EventPosition: 50, Channel: "yourchannel"}, true},
+		"unsubscribed channel":    {&eventNotification{EventPosition: 50, Channel: "theirchannel"}, false},
+		"event already processed": {&eventNotification{EventPosition: 20, Channel: "yourchannel"}, false},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			conn, err := utils.NewMockConn()
+			require.NoError(t, err)
+
+			pgNotif, err := getPgNotificationFrom(c.notif)
+			require.NoError(t, err)
+			conn.On("WaitForNotification", ctx).Return(pgNotif, nil)
+
+			stream := new(asset.MockEventService_SubscribeToChannelsServer)
+
+			if c.sent {
+				marshalledAsset, err := asset.MarshalEventAsset(event)
+				require.NoError(t, err)
+				rows := pgxmock.NewRows([]string{"position", "channel", "id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata"}).
+					AddRow(int64(45), event.Channel, event.Id, event.AssetKey, event.AssetKind, event.EventKind, event.Timestamp.AsTime(), marshalledAsset, event.Metadata)
+				query := "SELECT position, channel, id, asset_key, asset_kind, event_kind, timestamp, asset, metadata FROM events " +
+					"WHERE ((channel = $1 AND position > $2) OR (channel = $3 AND position > $4)) " +
+					"ORDER BY position " +
+					fmt.Sprintf("LIMIT %d", replayEventsBatchSize+1)
+				conn.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(testChannel, int64(10), "yourchannel", int64(30)).
+					WillReturnRows(rows)
+				stream.On("Send", mock.MatchedBy(func(e *asset.Event) bool { return e.Id == event.Id })).Return(nil)
+			}
+
+			s := newTestChannelSubscription(conn, nil, testChannel, "yourchannel")
+			s.positions[testChannel] = 10
+			s.positions["yourchannel"] = 30
+
+			err = s.forwardEventNotification(stream.Send)
+			assert.NoError(t, err)
+			if c.sent {
+				// every channel is caught up with the notified event
+				assert.Equal(t, c.notif.EventPosition, s.positions[testChannel])
+				assert.Equal(t, c.notif.EventPosition, s.positions["yourchannel"])
+			} else {
+				assert.Equal(t, int64(30), s.positions["yourchannel"])
+			}
+
+			stream.AssertExpectations(t)
+			conn.AssertExpectations(t)
+			assert.NoError(t, conn.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package dbal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type sqlEventConsumer struct {
+	Name    string
+	Owner   string
+	EventID string
+	AckDate time.Time
+	Lag     int64
+}
+
+func (c *sqlEventConsumer) toEventConsumer() *asset.EventConsumer {
+	return &asset.EventConsumer{
+		Name:             c.Name,
+		Owner:            c.Owner,
+		LastAckedEventId: c.EventID,
+		LastAckDate:      timestamppb.New(c.AckDate),
+		Lag:              uint64(c.Lag),
+	}
+}
+
+// AckEvents stores the given event as the last one processed by the consumer.
+// The acknowledged position never moves backward: acknowledging an older event has no effect.
+func (d *DBAL) AckEvents(owner string, consumer string, eventID string, ackDate time.Time) error {
+	stmt := getStatementBuilder().
+		Select("position").
+		From("events").
+		Where(sq.Eq{"channel": d.channel, "id": eventID})
+
+	row, err := d.queryRow(stmt)
+	if err != nil {
+		return err
+	}
+
+	var position int64
+	err = row.Scan(&position)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return orcerrors.NewNotFound("event", eventID)
+	}
+	if err != nil {
+		return err
+	}
+
+	upsert := getStatementBuilder().
+		Insert("event_consumers").
+		Columns("channel", "owner", "name", "event_id", "position", "ack_date").
+		Values(d.channel, owner, consumer, eventID, position, ackDate).
+		Suffix("ON CONFLICT (channel, owner, name) DO UPDATE SET " +
+			"event_id = CASE WHEN EXCLUDED.position > event_consumers.position THEN EXCLUDED.event_id ELSE event_consumers.event_id END, " +
+			"position = GREATEST(EXCLUDED.position, event_consumers.position), " +
+			"ack_date = EXCLUDED.ack_date")
+
+	return d.exec(upsert)
+}
+
+// QueryEventConsumers returns the durable consumers of an organization along with their lag,
+// that is the number of events of the channel emitted after their last acknowledged event.
+// Consumers don't store their subscription filter, so the lag counts every event regardless of it.
+func (d *DBAL) QueryEventConsumers(p *common.Pagination, owner string) ([]*asset.EventConsumer, common.PaginationToken, error) {
+	pg, err := newPage(p, newKeyset(false, "owner", "name"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	stmt := getStatementBuilder().
+		Select("name", "owner", "event_id", "ack_date").
+		Column("(SELECT COUNT(*) FROM events e WHERE e.channel = c.channel AND e.position > c.position) AS lag").
+		From("event_consumers c").
+		Where(sq.Eq{"channel": d.channel, "owner": owner})
+
+	stmt = pg.apply(stmt)
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var consumers []*asset.EventConsumer
+	var count int
+	var last *sqlEventConsumer
+
+	for rows.Next() {
+		c := new(sqlEventConsumer)
+
+		err = rows.Scan(&c.Name, &c.Owner, &c.EventID, &c.AckDate, &c.Lag)
+		if err != nil {
+			return nil, "", err
+		}
+
+		consumers = append(consumers, c.toEventConsumer())
+		last = c
+		count++
+
+		if count == int(p.Size) {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	bookmark := ""
+	if count == int(p.Size) && rows.Next() {
+		// there is more to fetch
+		bookmark, err = pg.nextToken(last.Owner, last.Name)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return consumers, bookmark, nil
+}
+
+// getEventConsumerPosition returns the position of the last event acknowledged by a consumer,
+// or 0 if the consumer has not acknowledged any event yet.
+func (d *DBAL) getEventConsumerPosition(owner string, consumer string) (int64, error) {
+	stmt := getStatementBuilder().
+		Select("position").
+		From("event_consumers").
+		Where(sq.Eq{"channel": d.channel, "owner": owner, "name": consumer})
+
+	query, args, err := stmt.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var position int64
+	err = d.conn.QueryRow(context.Background(), query, args...).Scan(&position)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+
+	return position, err
+}
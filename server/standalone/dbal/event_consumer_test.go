@@ -0,0 +1,134 @@
+package dbal
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pashagolub/pgxmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"github.com/substra/orchestrator/utils"
+)
+
+func TestAckEvents(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	eventID := "912aa0f8-ad56-4446-ac25-fe9b924561aa"
+	ackDate := time.Unix(1337, 0).UTC()
+
+	mock.ExpectBegin()
+	mock.
+		ExpectQuery(`SELECT position FROM events WHERE channel = $1 AND id = $2`).
+		WithArgs(testChannel, eventID).
+		WillReturnRows(pgxmock.NewRows([]string{"position"}).AddRow(int64(42)))
+	mock.
+		ExpectExec(`INSERT INTO event_consumers (channel,owner,name,event_id,position,ack_date) VALUES ($1,$2,$3,$4,$5,$6) `+
+			`ON CONFLICT (channel, owner, name) DO UPDATE SET `+
+			`event_id = CASE WHEN EXCLUDED.position > event_consumers.position THEN EXCLUDED.event_id ELSE event_consumers.event_id END, `+
+			`position = GREATEST(EXCLUDED.position, event_consumers.position), `+
+			`ack_date = EXCLUDED.ack_date`).
+		WithArgs(testChannel, "owner", "backend", eventID, int64(42), ackDate).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	err = dbal.AckEvents("owner", "backend", eventID, ackDate)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAckEventsNotFound(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.
+		ExpectQuery(`SELECT position FROM events WHERE channel = $1 AND id = $2`).
+		WithArgs(testChannel, "unknown").
+		WillReturnError(pgx.ErrNoRows)
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	err = dbal.AckEvents("owner", "backend", "unknown", time.Unix(1337, 0))
+	orcError := new(orcerrors.OrcError)
+	require.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrNotFound, orcError.Kind)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryEventConsumers(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	rows := pgxmock.NewRows([]string{"name", "owner", "event_id", "ack_date", "lag"}).
+		AddRow("backend", "org1", "id1", time.Unix(10, 0).UTC(), int64(3)).
+		AddRow("frontend", "org1", "id2", time.Unix(20, 0).UTC(), int64(0))
+
+	mock.ExpectBegin()
+	mock.
+		ExpectQuery(`SELECT name, owner, event_id, ack_date, (SELECT COUNT(*) FROM events e WHERE e.channel = c.channel AND e.position > c.position) AS lag FROM event_consumers c WHERE channel = $1 AND owner = $2 ORDER BY owner ASC, name ASC LIMIT 2`).
+		WithArgs(testChannel, "org1").
+		WillReturnRows(rows)
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	consumers, token, err := dbal.QueryEventConsumers(common.NewPagination("", 1), "org1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	require.Len(t, consumers, 1)
+	assert.Equal(t, "org1", consumers[0].Owner)
+	assert.Equal(t, "id1", consumers[0].LastAckedEventId)
+	assert.Equal(t, uint64(3), consumers[0].Lag)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetEventConsumerPosition(t *testing.T) {
+	query := regexp.QuoteMeta(`SELECT position FROM event_consumers WHERE channel = $1 AND name = $2 AND owner = $3`)
+
+	t.Run("acknowledged", func(t *testing.T) {
+		conn, err := utils.NewMockConn()
+		require.NoError(t, err)
+
+		conn.ExpectQuery(query).
+			WithArgs(testChannel, "backend", "owner").
+			WillReturnRows(pgxmock.NewRows([]string{"position"}).AddRow(int64(42)))
+
+		dbal := &DBAL{ctx: context.TODO(), channel: testChannel, conn: conn}
+		position, err := dbal.getEventConsumerPosition("owner", "backend")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), position)
+	})
+
+	t.Run("unknown consumer", func(t *testing.T) {
+		conn, err := utils.NewMockConn()
+		require.NoError(t, err)
+
+		conn.ExpectQuery(query).
+			WithArgs(testChannel, "backend", "owner").
+			WillReturnError(pgx.ErrNoRows)
+
+		dbal := &DBAL{ctx: context.TODO(), channel: testChannel, conn: conn}
+		position, err := dbal.getEventConsumerPosition("owner", "backend")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), position)
+	})
+}
@@ -0,0 +1,173 @@
+package dbal
+
+import (
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/rs/zerolog/log"
+	"github.com/substra/orchestrator/lib/asset"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+)
+
+// ExportEvents calls fn for each event of the channel in position order.
+// Only the events whose position is in [fromPosition, toPosition] and matching the filter are exported,
+// a zero toPosition means no upper bound.
+// Each event is given with the position of the previous event of the channel, regardless of the bounds and filter,
+// so that the continuity of the exported events can be checked on import.
+func (d *DBAL) ExportEvents(fromPosition uint64, toPosition uint64, filter *asset.EventQueryFilter, fn func(*asset.ExportedEvent) error) error {
+	// The window only spans the exported range, the previous event of the first one is looked up separately
+	// so that exporting recent events does not scan the whole channel.
+	previous := sq.
+		Select("position").
+		From("events").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Lt{"position": fromPosition}).
+		OrderBy("position DESC").
+		Limit(1)
+
+	channelEvents := sq.
+		Select("*").
+		Column(sq.Expr("COALESCE(LAG(position) OVER (ORDER BY position), (?), 0) AS previous_position", previous)).
+		From("events").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.GtOrEq{"position": fromPosition})
+
+	if toPosition > 0 {
+		channelEvents = channelEvents.Where(sq.LtOrEq{"position": toPosition})
+	}
+
+	stmt := getStatementBuilder().
+		Select("position", "previous_position", "id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata").
+		FromSelect(channelEvents, "e")
+
+	stmt = d.eventFilterToQuery(filter, stmt).OrderBy("position")
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var position, previousPosition int64
+		ev := sqlEvent{Channel: d.channel}
+
+		err = rows.Scan(&position, &previousPosition, &ev.ID, &ev.AssetKey, &ev.AssetKind, &ev.EventKind, &ev.Timestamp, &ev.Asset, &ev.Metadata)
+		if err != nil {
+			return err
+		}
+
+		event, err := ev.toEvent()
+		if err != nil {
+			return err
+		}
+
+		err = fn(&asset.ExportedEvent{
+			Position:         uint64(position),
+			PreviousPosition: uint64(previousPosition),
+			Event:            event,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ImportEvents appends previously exported events to the channel, keeping their IDs and positions.
+// The events must be contiguous: each one should reference the previous one as its previous position,
+// and the first one should reference the last event of the channel, unless the channel has no events.
+// Their positions must also be greater than the position of any existing event.
+func (d *DBAL) ImportEvents(events []*asset.ExportedEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	log.Ctx(d.ctx).Debug().Int("numEvents", len(events)).Msg("dbal: importing events")
+
+	_, err := d.tx.Exec(d.ctx, "LOCK TABLE events IN SHARE ROW EXCLUSIVE MODE")
+	if err != nil {
+		return err
+	}
+
+	stmt := getStatementBuilder().
+		Select("COALESCE(MAX(position), 0)").
+		Column(sq.Expr("COALESCE(MAX(position) FILTER (WHERE channel = ?), 0)", d.channel)).
+		From("events")
+
+	row, err := d.queryRow(stmt)
+	if err != nil {
+		return err
+	}
+
+	var lastPosition, lastChannelPosition int64
+	err = row.Scan(&lastPosition, &lastChannelPosition)
+	if err != nil {
+		return err
+	}
+
+	err = checkEventsContinuity(events, uint64(lastPosition), uint64(lastChannelPosition))
+	if err != nil {
+		return err
+	}
+
+	_, err = d.tx.CopyFrom(
+		d.ctx,
+		pgx.Identifier{"events"},
+		[]string{"id", "asset_key", "asset_kind", "event_kind", "channel", "timestamp", "asset", "metadata", "position"},
+		pgx.CopyFromSlice(len(events), func(i int) ([]interface{}, error) {
+			event := events[i].Event
+
+			id, err := uuid.Parse(event.Id)
+			if err != nil {
+				return nil, err
+			}
+
+			eventAsset, err := asset.MarshalEventAsset(event)
+			if err != nil {
+				return nil, err
+			}
+
+			return []interface{}{
+				id,
+				event.AssetKey,
+				event.AssetKind.String(),
+				event.EventKind.String(),
+				d.channel,
+				event.Timestamp.AsTime(),
+				eventAsset,
+				event.Metadata,
+				events[i].Position,
+			}, nil
+		}),
+	)
+
+	return err
+}
+
+// checkEventsContinuity makes sure that events can be appended to an event log
+// whose last position is lastPosition, and lastChannelPosition for the channel of the events.
+func checkEventsContinuity(events []*asset.ExportedEvent, lastPosition uint64, lastChannelPosition uint64) error {
+	previous := lastChannelPosition
+
+	for i, event := range events {
+		if event.GetEvent() == nil {
+			return orcerrors.NewBadRequest(fmt.Sprintf("missing event at position %d", event.Position))
+		}
+		if event.Position <= lastPosition {
+			return orcerrors.NewBadRequest(fmt.Sprintf("event %s at position %d conflicts with existing events up to position %d", event.Event.Id, event.Position, lastPosition))
+		}
+		// The first event of a partial history can be imported in an empty channel
+		if (i > 0 || lastChannelPosition > 0) && event.PreviousPosition != previous {
+			return orcerrors.NewBadRequest(fmt.Sprintf("event %s at position %d follows position %d instead of %d", event.Event.Id, event.Position, event.PreviousPosition, previous))
+		}
+
+		previous = event.Position
+		lastPosition = event.Position
+	}
+
+	return nil
+}
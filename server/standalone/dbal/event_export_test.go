@@ -0,0 +1,163 @@
+package dbal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestExportEvents(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	rows := pgxmock.NewRows([]string{"position", "previous_position", "id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata"}).
+		AddRow(int64(12), int64(10), "id1", "13e88e4f-a287-4e8f-a96e-ea0c03f91e86", "ASSET_FUNCTION", "EVENT_ASSET_CREATED", time.Unix(1, 0).UTC(), []byte(`{}`), map[string]string{}).
+		AddRow(int64(13), int64(12), "id2", "7623fc2d-33fd-4b00-a6a0-65f5ec2eee20", "ASSET_MODEL", "EVENT_ASSET_UPDATED", time.Unix(2, 0).UTC(), []byte(`{}`), map[string]string{})
+
+	mock.ExpectBegin()
+	mock.
+		ExpectQuery(`SELECT position, previous_position, id, asset_key, asset_kind, event_kind, timestamp, asset, metadata FROM (SELECT *, COALESCE(LAG(position) OVER (ORDER BY position), (SELECT position FROM events WHERE channel = $1 AND position < $2 ORDER BY position DESC LIMIT 1), 0) AS previous_position FROM events WHERE channel = $3 AND position >= $4 AND position <= $5) AS e WHERE timestamp >= $6 ORDER BY position`).
+		WithArgs(testChannel, uint64(12), testChannel, uint64(12), uint64(20), time.Unix(1, 0).UTC()).
+		WillReturnRows(rows)
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	exported := make([]*asset.ExportedEvent, 0)
+	err = dbal.ExportEvents(12, 20, &asset.EventQueryFilter{Start: timestamppb.New(time.Unix(1, 0))}, func(e *asset.ExportedEvent) error {
+		exported = append(exported, e)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	require.Len(t, exported, 2)
+	assert.Equal(t, uint64(12), exported[0].Position)
+	assert.Equal(t, uint64(10), exported[0].PreviousPosition)
+	assert.Equal(t, "id1", exported[0].Event.Id)
+	assert.Equal(t, testChannel, exported[0].Event.Channel)
+	assert.Equal(t, uint64(13), exported[1].Position)
+	assert.Equal(t, uint64(12), exported[1].PreviousPosition)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportEvents(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	events := []*asset.ExportedEvent{
+		{
+			Position:         12,
+			PreviousPosition: 10,
+			Event: &asset.Event{
+				Id:        "b2b30b36-b7f3-4839-9c6f-36ddafcf19fc",
+				AssetKey:  "56a3dc56-f493-47e5-8a61-46a120e5403c",
+				AssetKind: asset.AssetKind_ASSET_FUNCTION,
+				EventKind: asset.EventKind_EVENT_ASSET_CREATED,
+				Timestamp: timestamppb.New(time.Unix(1, 0)),
+				Asset:     &asset.Event_Function{Function: &asset.Function{Key: "56a3dc56-f493-47e5-8a61-46a120e5403c"}},
+			},
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`LOCK TABLE events IN SHARE ROW EXCLUSIVE MODE`).WillReturnResult(pgxmock.NewResult("LOCK", 0))
+	mock.
+		ExpectQuery(`SELECT COALESCE(MAX(position), 0), COALESCE(MAX(position) FILTER (WHERE channel = $1), 0) FROM events`).
+		WithArgs(testChannel).
+		WillReturnRows(pgxmock.NewRows([]string{"max", "channel_max"}).AddRow(int64(11), int64(10)))
+	mock.ExpectCopyFrom(`"events"`, []string{"id", "asset_key", "asset_kind", "event_kind", "channel", "timestamp", "asset", "metadata", "position"}).
+		WillReturnResult(1)
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	err = dbal.ImportEvents(events)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckEventsContinuity(t *testing.T) {
+	event := &asset.Event{Id: "id"}
+
+	cases := map[string]struct {
+		events              []*asset.ExportedEvent
+		lastPosition        uint64
+		lastChannelPosition uint64
+		valid               bool
+	}{
+		"contiguous": {
+			events: []*asset.ExportedEvent{
+				{Position: 5, PreviousPosition: 3, Event: event},
+				{Position: 8, PreviousPosition: 5, Event: event},
+			},
+			lastPosition:        4,
+			lastChannelPosition: 3,
+			valid:               true,
+		},
+		"partial history in empty channel": {
+			events: []*asset.ExportedEvent{
+				{Position: 5, PreviousPosition: 3, Event: event},
+			},
+			lastPosition: 4,
+			valid:        true,
+		},
+		"not following the channel": {
+			events: []*asset.ExportedEvent{
+				{Position: 5, PreviousPosition: 2, Event: event},
+			},
+			lastPosition:        4,
+			lastChannelPosition: 3,
+		},
+		"missing event": {
+			events: []*asset.ExportedEvent{
+				{Position: 5, PreviousPosition: 3, Event: event},
+				{Position: 8, PreviousPosition: 6, Event: event},
+			},
+			lastPosition:        4,
+			lastChannelPosition: 3,
+		},
+		"conflicting position": {
+			events: []*asset.ExportedEvent{
+				{Position: 4, PreviousPosition: 3, Event: event},
+			},
+			lastPosition:        4,
+			lastChannelPosition: 3,
+		},
+		"unordered": {
+			events: []*asset.ExportedEvent{
+				{Position: 8, Event: event},
+				{Position: 6, PreviousPosition: 8, Event: event},
+			},
+		},
+		"no event": {
+			events: []*asset.ExportedEvent{{Position: 1}},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := checkEventsContinuity(c.events, c.lastPosition, c.lastChannelPosition)
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				orcError := new(orcerrors.OrcError)
+				require.True(t, errors.As(err, &orcError))
+				assert.Equal(t, orcerrors.ErrBadRequest, orcError.Kind)
+			}
+		})
+	}
+}
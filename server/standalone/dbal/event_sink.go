@@ -0,0 +1,126 @@
+package dbal
+
+import (
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// ClaimEventSinkPosition claims the progress of the sink on the channel until the given date,
+// unless another claim is still running, and returns the position of the last event delivered to the sink.
+// A sink which has not received any event yet starts at the beginning of the event log.
+func (d *DBAL) ClaimEventSinkPosition(sink string, claimID string, now time.Time, until time.Time) (int64, bool, error) {
+	insert := getStatementBuilder().
+		Insert("event_sink_positions").
+		Columns("sink", "channel", "position", "update_date").
+		Values(sink, d.channel, 0, now).
+		Suffix("ON CONFLICT DO NOTHING")
+
+	err := d.exec(insert)
+	if err != nil {
+		return 0, false, err
+	}
+
+	stmt := getStatementBuilder().
+		Select("position", "claimed_until").
+		From("event_sink_positions").
+		Where(sq.Eq{"sink": sink, "channel": d.channel}).
+		Suffix("FOR UPDATE")
+
+	row, err := d.queryRow(stmt)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var position int64
+	var claimedUntil *time.Time
+	err = row.Scan(&position, &claimedUntil)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if claimedUntil != nil && claimedUntil.After(now) {
+		return 0, false, nil
+	}
+
+	update := getStatementBuilder().
+		Update("event_sink_positions").
+		Set("claim_id", claimID).
+		Set("claimed_until", until).
+		Where(sq.Eq{"sink": sink, "channel": d.channel})
+
+	err = d.exec(update)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return position, true, nil
+}
+
+// ReleaseEventSinkPosition clears the claim on the progress of the sink, provided it is still held by claimID.
+// The position is moved to the given one when it is not nil.
+// It returns false if the claim has expired and was taken over in between.
+func (d *DBAL) ReleaseEventSinkPosition(sink string, claimID string, position *int64, date time.Time) (bool, error) {
+	stmt := getStatementBuilder().
+		Update("event_sink_positions").
+		Set("claim_id", nil).
+		Set("claimed_until", nil)
+
+	if position != nil {
+		stmt = stmt.
+			Set("position", *position).
+			Set("update_date", date)
+	}
+
+	stmt = stmt.Where(sq.Eq{"sink": sink, "channel": d.channel, "claim_id": claimID})
+
+	count, err := d.execCount(stmt)
+
+	return count > 0, err
+}
+
+// GetEventsAfter returns at most limit events of the channel whose position is greater than the given one,
+// in position order.
+func (d *DBAL) GetEventsAfter(position int64, limit uint64) ([]*asset.ExportedEvent, error) {
+	stmt := getStatementBuilder().
+		Select("e.position").
+		Column("(SELECT COALESCE(MAX(p.position), 0) FROM events p WHERE p.channel = e.channel AND p.position < e.position)").
+		Columns("e.id", "e.asset_key", "e.asset_kind", "e.event_kind", "e.timestamp", "e.asset", "e.metadata").
+		From("events e").
+		Where(sq.Eq{"e.channel": d.channel}).
+		Where(sq.Gt{"e.position": position}).
+		OrderBy("e.position").
+		Limit(limit)
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*asset.ExportedEvent{}
+
+	for rows.Next() {
+		var position, previousPosition int64
+		ev := sqlEvent{Channel: d.channel}
+
+		err = rows.Scan(&position, &previousPosition, &ev.ID, &ev.AssetKey, &ev.AssetKind, &ev.EventKind, &ev.Timestamp, &ev.Asset, &ev.Metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		event, err := ev.toEvent()
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, &asset.ExportedEvent{
+			Position:         uint64(position),
+			PreviousPosition: uint64(previousPosition),
+			Event:            event,
+		})
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,139 @@
+package dbal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimEventSinkPosition(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	now := time.Unix(1337, 0).UTC()
+	until := now.Add(time.Minute)
+
+	mock.ExpectBegin()
+	mock.
+		ExpectExec(`INSERT INTO event_sink_positions (sink,channel,position,update_date) VALUES ($1,$2,$3,$4) ON CONFLICT DO NOTHING`).
+		WithArgs("archive", testChannel, 0, now).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	mock.
+		ExpectQuery(`SELECT position, claimed_until FROM event_sink_positions WHERE channel = $1 AND sink = $2 FOR UPDATE`).
+		WithArgs(testChannel, "archive").
+		WillReturnRows(pgxmock.NewRows([]string{"position", "claimed_until"}).AddRow(int64(42), nil))
+	mock.
+		ExpectExec(`UPDATE event_sink_positions SET claim_id = $1, claimed_until = $2 WHERE channel = $3 AND sink = $4`).
+		WithArgs("claim", until, testChannel, "archive").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	position, claimed, err := dbal.ClaimEventSinkPosition("archive", "claim", now, until)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Equal(t, int64(42), position)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimEventSinkPositionAlreadyClaimed(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	now := time.Unix(1337, 0).UTC()
+	claimedUntil := now.Add(time.Second)
+
+	mock.ExpectBegin()
+	mock.
+		ExpectExec(`INSERT INTO event_sink_positions (sink,channel,position,update_date) VALUES ($1,$2,$3,$4) ON CONFLICT DO NOTHING`).
+		WithArgs("archive", testChannel, 0, now).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	mock.
+		ExpectQuery(`SELECT position, claimed_until FROM event_sink_positions WHERE channel = $1 AND sink = $2 FOR UPDATE`).
+		WithArgs(testChannel, "archive").
+		WillReturnRows(pgxmock.NewRows([]string{"position", "claimed_until"}).AddRow(int64(42), &claimedUntil))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	_, claimed, err := dbal.ClaimEventSinkPosition("archive", "claim", now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReleaseEventSinkPosition(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	date := time.Unix(1337, 0).UTC()
+	position := int64(43)
+
+	mock.ExpectBegin()
+	mock.
+		ExpectExec(`UPDATE event_sink_positions SET claim_id = $1, claimed_until = $2, position = $3, update_date = $4 WHERE channel = $5 AND claim_id = $6 AND sink = $7`).
+		WithArgs(nil, nil, position, date, testChannel, "claim", "archive").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.
+		ExpectExec(`UPDATE event_sink_positions SET claim_id = $1, claimed_until = $2 WHERE channel = $3 AND claim_id = $4 AND sink = $5`).
+		WithArgs(nil, nil, testChannel, "expired", "archive").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	released, err := dbal.ReleaseEventSinkPosition("archive", "claim", &position, date)
+	assert.NoError(t, err)
+	assert.True(t, released)
+
+	released, err = dbal.ReleaseEventSinkPosition("archive", "expired", nil, date)
+	assert.NoError(t, err)
+	assert.False(t, released)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetEventsAfter(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	rows := pgxmock.NewRows([]string{"position", "previous_position", "id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata"}).
+		AddRow(int64(12), int64(10), "id1", "13e88e4f-a287-4e8f-a96e-ea0c03f91e86", "ASSET_FUNCTION", "EVENT_ASSET_CREATED", time.Unix(1, 0).UTC(), []byte(`{}`), map[string]string{}).
+		AddRow(int64(13), int64(12), "id2", "7623fc2d-33fd-4b00-a6a0-65f5ec2eee20", "ASSET_MODEL", "EVENT_ASSET_UPDATED", time.Unix(2, 0).UTC(), []byte(`{}`), map[string]string{})
+
+	mock.ExpectBegin()
+	mock.
+		ExpectQuery(`SELECT e.position, (SELECT COALESCE(MAX(p.position), 0) FROM events p WHERE p.channel = e.channel AND p.position < e.position), `+
+			`e.id, e.asset_key, e.asset_kind, e.event_kind, e.timestamp, e.asset, e.metadata `+
+			`FROM events e WHERE e.channel = $1 AND e.position > $2 ORDER BY e.position LIMIT 2`).
+		WithArgs(testChannel, int64(10)).
+		WillReturnRows(rows)
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	events, err := dbal.GetEventsAfter(10, 2)
+	assert.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, uint64(12), events[0].Position)
+	assert.Equal(t, uint64(10), events[0].PreviousPosition)
+	assert.Equal(t, "id2", events[1].Event.Id)
+	assert.Equal(t, testChannel, events[1].Event.Channel)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
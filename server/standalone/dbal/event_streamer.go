@@ -0,0 +1,51 @@
+package dbal
+
+import (
+	"context"
+
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// EventStreamer serves the streaming requests of the EventService.
+// Each stream subscribes to the notifier of new events before reading existing ones, so that no event is missed.
+type EventStreamer interface {
+	SubscribeToEvents(ctx context.Context, channel string, owner string, param *asset.SubscribeToEventsParam, stream asset.EventService_SubscribeToEventsServer) error
+	SubscribeToChannels(ctx context.Context, channels []string, param *asset.SubscribeToChannelsParam, stream asset.EventService_SubscribeToChannelsServer) error
+	WatchComputePlan(ctx context.Context, channel string, key string, stream asset.EventService_WatchComputePlanServer) error
+}
+
+// pgEventStreamer streams the events of a PostgreSQL database.
+type pgEventStreamer struct {
+	conn     Conn
+	notifier *EventNotifier
+}
+
+// NewEventStreamer returns an EventStreamer reading events with conn.
+// The notifier is shared by every stream, it either listens to event notifications or polls the events table.
+func NewEventStreamer(conn Conn, notifier *EventNotifier) EventStreamer {
+	return &pgEventStreamer{conn: conn, notifier: notifier}
+}
+
+// SubscribeToEvents implements EventStreamer, see DBAL.SubscribeToEvents.
+func (s *pgEventStreamer) SubscribeToEvents(ctx context.Context, channel string, owner string, param *asset.SubscribeToEventsParam, stream asset.EventService_SubscribeToEventsServer) error {
+	sub := s.notifier.Subscribe(channel)
+	defer sub.Close()
+
+	return NewWithListener(ctx, s.conn, sub, channel).SubscribeToEvents(param, owner, stream)
+}
+
+// SubscribeToChannels implements EventStreamer, see SubscribeToChannels.
+func (s *pgEventStreamer) SubscribeToChannels(ctx context.Context, channels []string, param *asset.SubscribeToChannelsParam, stream asset.EventService_SubscribeToChannelsServer) error {
+	sub := s.notifier.Subscribe(channels...)
+	defer sub.Close()
+
+	return SubscribeToChannels(ctx, s.conn, sub, channels, param, stream)
+}
+
+// WatchComputePlan implements EventStreamer, see DBAL.WatchComputePlan.
+func (s *pgEventStreamer) WatchComputePlan(ctx context.Context, channel string, key string, stream asset.EventService_WatchComputePlanServer) error {
+	sub := s.notifier.Subscribe(channel)
+	defer sub.Close()
+
+	return NewWithListener(ctx, s.conn, sub, channel).WatchComputePlan(key, stream)
+}
@@ -42,12 +42,33 @@ func TestEventFilterToQuery(t *testing.T) {
 			"timestamp >= $1 AND timestamp <= $2",
 			[]interface{}{time.Unix(1337, 0).UTC(), time.Unix(7331, 0).UTC()},
 		},
+		"multiple kinds": {
+			&asset.EventQueryFilter{
+				AssetKind:  asset.AssetKind_ASSET_COMPUTE_TASK,
+				AssetKinds: []asset.AssetKind{asset.AssetKind_ASSET_MODEL},
+				EventKinds: []asset.EventKind{asset.EventKind_EVENT_ASSET_CREATED, asset.EventKind_EVENT_ASSET_UPDATED},
+			},
+			"asset_kind IN ($1,$2) AND event_kind IN ($3,$4)",
+			[]interface{}{
+				asset.AssetKind_ASSET_COMPUTE_TASK.String(),
+				asset.AssetKind_ASSET_MODEL.String(),
+				asset.EventKind_EVENT_ASSET_CREATED.String(),
+				asset.EventKind_EVENT_ASSET_UPDATED.String(),
+			},
+		},
+		"compute plan": {
+			&asset.EventQueryFilter{ComputePlanKey: "uuid"},
+			"(asset_key = $1 OR (asset_key IN (SELECT key::text FROM compute_tasks WHERE channel = $2 AND compute_plan_key = $3) " +
+				"OR asset->>'computeTaskKey' IN (SELECT key::text FROM compute_tasks WHERE channel = $4 AND compute_plan_key = $5)))",
+			[]interface{}{"uuid", testChannel, "uuid", testChannel, "uuid"},
+		},
 	}
 
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
 			builder := getStatementBuilder().Select("id").From("events")
-			builder = eventFilterToQuery(c.filter, builder)
+			dbal := &DBAL{channel: testChannel}
+			builder = dbal.eventFilterToQuery(c.filter, builder)
 			query, params, err := builder.ToSql()
 			assert.NoError(t, err)
 			assert.Contains(t, query, c.queryContains)
@@ -62,14 +83,21 @@ func makeEventRows() *pgxmock.Rows {
 		AddRow("id2", "7623fc2d-33fd-4b00-a6a0-65f5ec2eee20", "ASSET_MODEL", "EVENT_ASSET_UPDATED", time.Unix(2, 0).UTC(), []byte(`{}`), map[string]string{})
 }
 
+// makeQueriedEventRows returns the rows of events queried along with their position.
+func makeQueriedEventRows() *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata", "position"}).
+		AddRow("id1", "13e88e4f-a287-4e8f-a96e-ea0c03f91e86", "ASSET_FUNCTION", "EVENT_ASSET_CREATED", time.Unix(1, 0).UTC(), []byte(`{}`), map[string]string{}, int64(2)).
+		AddRow("id2", "7623fc2d-33fd-4b00-a6a0-65f5ec2eee20", "ASSET_MODEL", "EVENT_ASSET_UPDATED", time.Unix(2, 0).UTC(), []byte(`{}`), map[string]string{}, int64(1))
+}
+
 func TestEventQuery(t *testing.T) {
 	mock, err := pgxmock.NewConn()
 	assert.NoError(t, err)
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`SELECT id, asset_key, asset_kind, event_kind, timestamp, asset, metadata FROM events .* ORDER BY timestamp ASC, id ASC`).
+	mock.ExpectQuery(`SELECT id, asset_key, asset_kind, event_kind, timestamp, asset, metadata, position FROM events .* ORDER BY position ASC`).
 		WithArgs(testChannel).
-		WillReturnRows(makeEventRows())
+		WillReturnRows(makeQueriedEventRows())
 
 	tx, err := mock.Begin(context.Background())
 	require.NoError(t, err)
@@ -90,9 +118,9 @@ func TestQueryEventsNilFilter(t *testing.T) {
 	assert.NoError(t, err)
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`SELECT id, asset_key, asset_kind, event_kind, timestamp, asset, metadata FROM events`).
+	mock.ExpectQuery(`SELECT id, asset_key, asset_kind, event_kind, timestamp, asset, metadata, position FROM events`).
 		WithArgs(testChannel).
-		WillReturnRows(makeEventRows())
+		WillReturnRows(makeQueriedEventRows())
 
 	tx, err := mock.Begin(context.Background())
 	require.NoError(t, err)
@@ -103,6 +131,36 @@ func TestQueryEventsNilFilter(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestQueryEventsCursor(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, asset_key, asset_kind, event_kind, timestamp, asset, metadata, position FROM events WHERE channel = $1 ORDER BY position DESC LIMIT 2`).
+		WithArgs(testChannel).
+		WillReturnRows(makeQueriedEventRows())
+	// The next page starts before the position of the last event, whatever the timestamps
+	mock.ExpectQuery(`SELECT id, asset_key, asset_kind, event_kind, timestamp, asset, metadata, position FROM events WHERE channel = $1 AND (position) < ($2) ORDER BY position DESC LIMIT 2`).
+		WithArgs(testChannel, "2").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata", "position"}))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	events, token, err := dbal.QueryEvents(common.NewPagination("", 1), nil, asset.SortOrder_DESCENDING)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.NotEmpty(t, token)
+
+	events, token, err = dbal.QueryEvents(common.NewPagination(token, 1), nil, asset.SortOrder_DESCENDING)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Empty(t, token)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestGetEventPosition(t *testing.T) {
 	eventID := "912aa0f8-ad56-4446-ac25-fe9b924561aa"
 	eventPosition := int64(1234)
@@ -163,7 +221,7 @@ func TestReplayBatchOfEvents(t *testing.T) {
 	stream.On("Send", matchEventID).Return(nil)
 
 	dbal := &DBAL{ctx: context.TODO(), conn: conn, channel: testChannel}
-	lastProcessedPos, hasNextBatch, err := dbal.replayBatchOfEvents(startAfterPosition, stream)
+	lastProcessedPos, hasNextBatch, err := dbal.replayBatchOfEvents(startAfterPosition, nil, stream.Send)
 	assert.NoError(t, err)
 	assert.False(t, hasNextBatch)
 	assert.Equal(t, eventPosition, lastProcessedPos)
@@ -172,6 +230,35 @@ func TestReplayBatchOfEvents(t *testing.T) {
 	conn.AssertExpectations(t)
 }
 
+func TestReplayBatchOfEventsWithFilter(t *testing.T) {
+	conn, err := utils.NewMockConn()
+	require.NoError(t, err)
+
+	startAfterPosition := int64(80)
+	filter := &asset.EventQueryFilter{AssetKind: asset.AssetKind_ASSET_COMPUTE_TASK}
+
+	rows := pgxmock.NewRows([]string{"position", "id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata"})
+
+	query := "SELECT position, id, asset_key, asset_kind, event_kind, timestamp, asset, metadata " +
+		"FROM events " +
+		"WHERE channel = $1 AND position > $2 AND asset_kind = $3 " +
+		"ORDER BY position " +
+		fmt.Sprintf("LIMIT %d", replayEventsBatchSize+1)
+	conn.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(testChannel, startAfterPosition, asset.AssetKind_ASSET_COMPUTE_TASK.String()).
+		WillReturnRows(rows)
+
+	stream := new(asset.MockEventService_SubscribeToEventsServer)
+
+	dbal := &DBAL{ctx: context.TODO(), conn: conn, channel: testChannel}
+	_, hasNextBatch, err := dbal.replayBatchOfEvents(startAfterPosition, filter, stream.Send)
+	assert.NoError(t, err)
+	assert.False(t, hasNextBatch)
+
+	stream.AssertExpectations(t)
+	conn.AssertExpectations(t)
+}
+
 func getPgNotificationFrom(n *eventNotification) (*pgconn.Notification, error) {
 	marshalledPayload, err := json.Marshal(n)
 	if err != nil {
@@ -185,14 +272,14 @@ func getPgNotificationFrom(n *eventNotification) (*pgconn.Notification, error) {
 	}, nil
 }
 
-func makeEventRowsFrom(e *asset.Event) (*pgxmock.Rows, error) {
+func makeEventRowsFrom(position int64, e *asset.Event) (*pgxmock.Rows, error) {
 	marshalledAsset, err := asset.MarshalEventAsset(e)
 	if err != nil {
 		return nil, err
 	}
 
-	rows := pgxmock.NewRows([]string{"id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata"}).
-		AddRow(e.Id, e.AssetKey, e.AssetKind, e.EventKind, e.Timestamp.AsTime(), marshalledAsset, e.Metadata)
+	rows := pgxmock.NewRows([]string{"position", "id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata"}).
+		AddRow(position, e.Id, e.AssetKey, e.AssetKind, e.EventKind, e.Timestamp.AsTime(), marshalledAsset, e.Metadata)
 	return rows, nil
 }
 
@@ -222,11 +309,11 @@ func TestForwardEventNotification(t *testing.T) {
 
 	conn.On("WaitForNotification", ctx).Return(pgNotif, nil)
 
-	rows, err := makeEventRowsFrom(event)
+	rows, err := makeEventRowsFrom(notif.EventPosition, event)
 	require.NoError(t, err)
 
 	conn.ExpectQuery("SELECT .* FROM events").
-		WithArgs(testChannel, notif.EventPosition).
+		WithArgs(testChannel, int64(1)).
 		WillReturnRows(rows)
 
 	stream := new(asset.MockEventService_SubscribeToEventsServer)
@@ -235,8 +322,8 @@ func TestForwardEventNotification(t *testing.T) {
 	})
 	stream.On("Send", matchEvent).Return(nil)
 
-	dbal := &DBAL{ctx: ctx, conn: conn, channel: testChannel}
-	lastProcessedPos, err := dbal.forwardEventNotification(1, stream)
+	dbal := &DBAL{ctx: ctx, conn: conn, listener: conn, channel: testChannel}
+	lastProcessedPos, err := dbal.forwardEventNotification(1, nil, stream.Send)
 	assert.NoError(t, err)
 	assert.Equal(t, notif.EventPosition, lastProcessedPos)
 
@@ -269,8 +356,8 @@ func TestForwardEventNotificationIgnoreEvent(t *testing.T) {
 			require.NoError(t, err)
 			conn.On("WaitForNotification", ctx).Return(pgNotif, nil)
 
-			dbal := &DBAL{ctx: ctx, conn: conn, channel: testChannel}
-			lastProcessedPos, err := dbal.forwardEventNotification(c.initialLastProcessedPos, nil)
+			dbal := &DBAL{ctx: ctx, conn: conn, listener: conn, channel: testChannel}
+			lastProcessedPos, err := dbal.forwardEventNotification(c.initialLastProcessedPos, nil, nil)
 			assert.NoError(t, err)
 			assert.Equal(t, c.initialLastProcessedPos, lastProcessedPos)
 
@@ -279,55 +366,61 @@ func TestForwardEventNotificationIgnoreEvent(t *testing.T) {
 	}
 }
 
-func TestWaitForEventNotification(t *testing.T) {
+func TestForwardEventNotificationFilteredOut(t *testing.T) {
 	ctx := context.TODO()
 
 	conn, err := utils.NewMockConn()
 	require.NoError(t, err)
 
 	notif := &eventNotification{
-		EventPosition: 5,
+		EventPosition: 50,
 		Channel:       testChannel,
 	}
+	filter := &asset.EventQueryFilter{EventKind: asset.EventKind_EVENT_ASSET_DISABLED}
 
 	pgNotif, err := getPgNotificationFrom(notif)
 	require.NoError(t, err)
 	conn.On("WaitForNotification", ctx).Return(pgNotif, nil)
 
-	dbal := &DBAL{ctx: ctx, conn: conn, channel: testChannel}
-	received, err := dbal.waitForEventNotification()
+	query := "SELECT position, id, asset_key, asset_kind, event_kind, timestamp, asset, metadata " +
+		"FROM events " +
+		"WHERE channel = $1 AND position > $2 AND event_kind = $3 " +
+		"ORDER BY position " +
+		fmt.Sprintf("LIMIT %d", replayEventsBatchSize+1)
+	conn.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(testChannel, int64(1), asset.EventKind_EVENT_ASSET_DISABLED.String()).
+		WillReturnRows(pgxmock.NewRows([]string{"position", "id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata"}))
+
+	stream := new(asset.MockEventService_SubscribeToEventsServer)
+
+	dbal := &DBAL{ctx: ctx, conn: conn, listener: conn, channel: testChannel}
+	lastProcessedPos, err := dbal.forwardEventNotification(1, filter, stream.Send)
 	assert.NoError(t, err)
-	assert.Equal(t, received, notif)
+	assert.Equal(t, notif.EventPosition, lastProcessedPos)
 
 	conn.AssertExpectations(t)
+	stream.AssertExpectations(t)
 }
 
-func TestGetEventByPosition(t *testing.T) {
+func TestWaitForEventNotification(t *testing.T) {
+	ctx := context.TODO()
+
 	conn, err := utils.NewMockConn()
 	require.NoError(t, err)
 
-	position := int64(9)
-	event := &asset.Event{
-		Id:        "b2b30b36-b7f3-4839-9c6f-36ddafcf19fc",
-		AssetKey:  "56a3dc56-f493-47e5-8a61-46a120e5403c",
-		AssetKind: asset.AssetKind_ASSET_FUNCTION,
-		EventKind: asset.EventKind_EVENT_ASSET_CREATED,
-		Channel:   testChannel,
-		Timestamp: timestamppb.New(time.Unix(15000, 0)),
-		Asset:     &asset.Event_Function{Function: &asset.Function{}},
-		Metadata:  map[string]string{},
+	notif := &eventNotification{
+		EventPosition: 5,
+		Channel:       testChannel,
 	}
 
-	query := `SELECT id, asset_key, asset_kind, event_kind, timestamp, asset, metadata FROM events WHERE channel = $1 AND position = $2`
-	rows, err := makeEventRowsFrom(event)
+	pgNotif, err := getPgNotificationFrom(notif)
 	require.NoError(t, err)
+	conn.On("WaitForNotification", ctx).Return(pgNotif, nil)
 
-	conn.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(testChannel, position).WillReturnRows(rows)
-
-	dbal := &DBAL{ctx: context.TODO(), channel: testChannel, conn: conn}
-	retrieved, err := dbal.getEventByPosition(position)
+	dbal := &DBAL{ctx: ctx, conn: conn, listener: conn, channel: testChannel}
+	received, err := dbal.waitForEventNotification()
 	assert.NoError(t, err)
-	assert.True(t, proto.Equal(event, retrieved))
+	assert.Equal(t, received, notif)
 
 	conn.AssertExpectations(t)
 }
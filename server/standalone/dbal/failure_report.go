@@ -20,6 +20,7 @@ type sqlFailureReport struct {
 	Owner        string
 	LogsChecksum pgtype.Text
 	LogsAddress  pgtype.Text
+	Attempt      uint32
 }
 
 func (r sqlFailureReport) toFailureReport() *asset.FailureReport {
@@ -29,6 +30,7 @@ func (r sqlFailureReport) toFailureReport() *asset.FailureReport {
 		ErrorType:    r.ErrorType,
 		CreationDate: timestamppb.New(r.CreationDate),
 		Owner:        r.Owner,
+		Attempt:      r.Attempt,
 	}
 
 	if r.LogsAddress.Status == pgtype.Present {
@@ -41,11 +43,18 @@ func (r sqlFailureReport) toFailureReport() *asset.FailureReport {
 	return failureReport
 }
 
-func (d *DBAL) GetFailureReport(assetKey string) (*asset.FailureReport, error) {
+// GetFailureReport returns the failure report of the given attempt, or the latest one if attempt is 0.
+func (d *DBAL) GetFailureReport(assetKey string, attempt uint32) (*asset.FailureReport, error) {
 	stmt := getStatementBuilder().
-		Select("asset_key", "asset_type", "error_type", "creation_date", "owner", "logs_address", "logs_checksum").
+		Select("asset_key", "asset_type", "error_type", "creation_date", "owner", "logs_address", "logs_checksum", "attempt").
 		From("expanded_failure_reports").
-		Where(sq.Eq{"channel": d.channel, "asset_key": assetKey})
+		Where(sq.Eq{"channel": d.channel, "asset_key": assetKey}).
+		OrderBy("attempt DESC").
+		Limit(1)
+
+	if attempt > 0 {
+		stmt = stmt.Where(sq.Eq{"attempt": attempt})
+	}
 
 	row, err := d.queryRow(stmt)
 	if err != nil {
@@ -53,7 +62,7 @@ func (d *DBAL) GetFailureReport(assetKey string) (*asset.FailureReport, error) {
 	}
 
 	r := new(sqlFailureReport)
-	err = row.Scan(&r.AssetKey, &r.AssetType, &r.ErrorType, &r.CreationDate, &r.Owner, &r.LogsAddress, &r.LogsChecksum)
+	err = row.Scan(&r.AssetKey, &r.AssetType, &r.ErrorType, &r.CreationDate, &r.Owner, &r.LogsAddress, &r.LogsChecksum, &r.Attempt)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -83,8 +92,8 @@ func (d *DBAL) AddFailureReport(failureReport *asset.FailureReport) error {
 
 	stmt := getStatementBuilder().
 		Insert("failure_reports").
-		Columns("asset_key", "asset_type", "channel", "error_type", "creation_date", "owner", "logs_address").
-		Values(failureReport.AssetKey, failureReport.AssetType.String(), d.channel, failureReport.ErrorType, failureReport.CreationDate.AsTime(), failureReport.Owner, logsAddress)
+		Columns("asset_key", "asset_type", "channel", "error_type", "creation_date", "owner", "logs_address", "attempt").
+		Values(failureReport.AssetKey, failureReport.AssetType.String(), d.channel, failureReport.ErrorType, failureReport.CreationDate.AsTime(), failureReport.Owner, logsAddress, failureReport.Attempt)
 
 	return d.exec(stmt)
 }
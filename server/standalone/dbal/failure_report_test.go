@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
 	"github.com/pashagolub/pgxmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
 	orcerrors "github.com/substra/orchestrator/lib/errors"
 )
 
@@ -28,7 +31,7 @@ func TestGetFailureReportNotFound(t *testing.T) {
 
 	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
 
-	_, err = dbal.GetFailureReport(assetKey)
+	_, err = dbal.GetFailureReport(assetKey, 0)
 
 	assert.Error(t, err)
 	orcError := new(orcerrors.OrcError)
@@ -36,3 +39,27 @@ func TestGetFailureReportNotFound(t *testing.T) {
 	assert.Equal(t, orcerrors.ErrNotFound, orcError.Kind)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestGetFailureReportAttempt(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+
+	assetKey := "4c67ad88-309a-48b4-8bc4-c2e2c1a87a83"
+	rows := pgxmock.NewRows([]string{"asset_key", "asset_type", "error_type", "creation_date", "owner", "logs_address", "logs_checksum", "attempt"}).
+		AddRow(assetKey, asset.FailedAssetKind_FAILED_ASSET_COMPUTE_TASK, asset.ErrorType_ERROR_TYPE_EXECUTION, time.Unix(1337, 0), "owner", pgtype.Text{Status: pgtype.Null}, pgtype.Text{Status: pgtype.Null}, uint32(2))
+	mock.ExpectQuery(`SELECT asset_key, asset_type, error_type, creation_date, owner, logs_address, logs_checksum, attempt FROM expanded_failure_reports WHERE asset_key = $1 AND channel = $2 AND attempt = $3 ORDER BY attempt DESC LIMIT 1`).
+		WithArgs(assetKey, testChannel, uint32(2)).
+		WillReturnRows(rows)
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	failureReport, err := dbal.GetFailureReport(assetKey, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), failureReport.Attempt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
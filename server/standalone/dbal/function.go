@@ -2,7 +2,6 @@ package dbal
 
 import (
 	"errors"
-	"strconv"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -14,30 +13,32 @@ import (
 )
 
 type sqlFunction struct {
-	Key          string
-	Name         string
-	Description  asset.Addressable
-	Archive      asset.Addressable
-	Permissions  asset.Permissions
-	Owner        string
-	CreationDate time.Time
-	Metadata     map[string]string
-	Status       asset.FunctionStatus
-	Image        asset.Addressable
+	Key             string
+	Name            string
+	Description     asset.Addressable
+	Archive         asset.Addressable
+	Permissions     asset.Permissions
+	Owner           string
+	CreationDate    time.Time
+	Metadata        map[string]string
+	Status          asset.FunctionStatus
+	Image           asset.Addressable
+	MaxTaskAttempts uint32
 }
 
 func (a *sqlFunction) toFunction() *asset.Function {
 	return &asset.Function{
-		Key:          a.Key,
-		Name:         a.Name,
-		Description:  &a.Description,
-		Archive:      &a.Archive,
-		Permissions:  &a.Permissions,
-		Owner:        a.Owner,
-		CreationDate: timestamppb.New(a.CreationDate),
-		Metadata:     a.Metadata,
-		Status:       a.Status,
-		Image:        &a.Image,
+		Key:             a.Key,
+		Name:            a.Name,
+		Description:     &a.Description,
+		Archive:         &a.Archive,
+		Permissions:     &a.Permissions,
+		Owner:           a.Owner,
+		CreationDate:    timestamppb.New(a.CreationDate),
+		Metadata:        a.Metadata,
+		Status:          a.Status,
+		Image:           &a.Image,
+		MaxTaskAttempts: a.MaxTaskAttempts,
 	}
 }
 
@@ -62,8 +63,8 @@ func (d *DBAL) AddFunction(function *asset.Function) error {
 
 	stmt := getStatementBuilder().
 		Insert("functions").
-		Columns("key", "channel", "name", "description", "archive_address", "permissions", "owner", "creation_date", "metadata", "status", "image_address").
-		Values(function.Key, d.channel, function.Name, function.Description.StorageAddress, function.Archive.StorageAddress, function.Permissions, function.Owner, function.CreationDate.AsTime(), function.Metadata, function.Status.String(), function.Image.StorageAddress)
+		Columns("key", "channel", "name", "description", "archive_address", "permissions", "owner", "creation_date", "metadata", "status", "image_address", "max_task_attempts").
+		Values(function.Key, d.channel, function.Name, function.Description.StorageAddress, function.Archive.StorageAddress, function.Permissions, function.Owner, function.CreationDate.AsTime(), function.Metadata, function.Status.String(), function.Image.StorageAddress, function.MaxTaskAttempts)
 
 	err = d.exec(stmt)
 	if err != nil {
@@ -86,7 +87,7 @@ func (d *DBAL) AddFunction(function *asset.Function) error {
 // GetFunction implements persistence.FunctionDBAL
 func (d *DBAL) GetFunction(key string) (*asset.Function, error) {
 	stmt := getStatementBuilder().
-		Select("key", "name", "description_address", "description_checksum", "archive_address", "archive_checksum", "permissions", "owner", "creation_date", "metadata", "status", "image_address", "image_checksum").
+		Select("key", "name", "description_address", "description_checksum", "archive_address", "archive_checksum", "permissions", "owner", "creation_date", "metadata", "status", "image_address", "image_checksum", "max_task_attempts").
 		From("expanded_functions").
 		Where(sq.Eq{"key": key, "channel": d.channel})
 
@@ -97,7 +98,7 @@ func (d *DBAL) GetFunction(key string) (*asset.Function, error) {
 
 	al := sqlFunction{}
 
-	err = row.Scan(&al.Key, &al.Name, &al.Description.StorageAddress, &al.Description.Checksum, &al.Archive.StorageAddress, &al.Archive.Checksum, &al.Permissions, &al.Owner, &al.CreationDate, &al.Metadata, &al.Status, &al.Image.StorageAddress, &al.Image.Checksum)
+	err = row.Scan(&al.Key, &al.Name, &al.Description.StorageAddress, &al.Description.Checksum, &al.Archive.StorageAddress, &al.Archive.Checksum, &al.Permissions, &al.Owner, &al.CreationDate, &al.Metadata, &al.Status, &al.Image.StorageAddress, &al.Image.Checksum, &al.MaxTaskAttempts)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -150,19 +151,15 @@ func (d *DBAL) FunctionExists(key string) (bool, error) {
 }
 
 func (d *DBAL) queryFunctions(p *common.Pagination, filter *asset.FunctionQueryFilter) ([]*asset.Function, common.PaginationToken, error) {
-	offset, err := getOffset(p.Token)
+	pg, err := newPage(p, newKeyset(false, "creation_date", "key"))
 	if err != nil {
 		return nil, "", err
 	}
 
 	stmt := getStatementBuilder().
-		Select("key", "name", "description_address", "description_checksum", "archive_address", "archive_checksum", "permissions", "owner", "creation_date", "metadata", "status", "image_address", "image_checksum").
+		Select("key", "name", "description_address", "description_checksum", "archive_address", "archive_checksum", "permissions", "owner", "creation_date", "metadata", "status", "image_address", "image_checksum", "max_task_attempts").
 		From("expanded_functions").
-		Where(sq.Eq{"channel": d.channel}).
-		OrderByClause("creation_date ASC, key").
-		Offset(uint64(offset)).
-		// Fetch page size + 1 elements to determine whether there is a next page
-		Limit(uint64(p.Size + 1))
+		Where(sq.Eq{"channel": d.channel})
 
 	if filter != nil {
 		if filter.ComputePlanKey != "" {
@@ -173,6 +170,8 @@ func (d *DBAL) queryFunctions(p *common.Pagination, filter *asset.FunctionQueryF
 		}
 	}
 
+	stmt = pg.apply(stmt)
+
 	rows, err := d.query(stmt)
 	if err != nil {
 		return nil, "", err
@@ -181,16 +180,18 @@ func (d *DBAL) queryFunctions(p *common.Pagination, filter *asset.FunctionQueryF
 
 	functions := make([]*asset.Function, 0, p.Size)
 	var count int
+	var last *sqlFunction
 
 	for rows.Next() {
 		al := sqlFunction{}
 
-		err = rows.Scan(&al.Key, &al.Name, &al.Description.StorageAddress, &al.Description.Checksum, &al.Archive.StorageAddress, &al.Archive.Checksum, &al.Permissions, &al.Owner, &al.CreationDate, &al.Metadata, &al.Status, &al.Image.StorageAddress, &al.Image.Checksum)
+		err = rows.Scan(&al.Key, &al.Name, &al.Description.StorageAddress, &al.Description.Checksum, &al.Archive.StorageAddress, &al.Archive.Checksum, &al.Permissions, &al.Owner, &al.CreationDate, &al.Metadata, &al.Status, &al.Image.StorageAddress, &al.Image.Checksum, &al.MaxTaskAttempts)
 		if err != nil {
 			return nil, "", err
 		}
 
 		functions = append(functions, al.toFunction())
+		last = &al
 		count++
 
 		if count == int(p.Size) {
@@ -204,7 +205,10 @@ func (d *DBAL) queryFunctions(p *common.Pagination, filter *asset.FunctionQueryF
 	bookmark := ""
 	if count == int(p.Size) && rows.Next() {
 		// there is more to fetch
-		bookmark = strconv.Itoa(offset + count)
+		bookmark, err = pg.nextToken(cursorTime(last.CreationDate), last.Key)
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
 	return functions, bookmark, nil
@@ -0,0 +1,717 @@
+package dbal
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	"github.com/substra/orchestrator/lib/persistence"
+	"github.com/substra/orchestrator/server/common/interceptors"
+	"github.com/substra/orchestrator/server/standalone/metrics"
+)
+
+// noRows is passed to InstrumentedDBAL.observe by methods which don't return a list of assets.
+const noRows = -1
+
+// InstrumentedTransactionFactory begins instrumented transactions, see InstrumentedDBAL.
+type InstrumentedTransactionFactory struct {
+	db                TransactionFactory
+	slowCallThreshold time.Duration
+}
+
+// NewInstrumentedTransactionFactory returns a factory instrumenting the transactions begun by db.
+// Calls lasting at least slowCallThreshold are logged, a zero threshold disables the logging of slow calls.
+func NewInstrumentedTransactionFactory(db TransactionFactory, slowCallThreshold time.Duration) *InstrumentedTransactionFactory {
+	return &InstrumentedTransactionFactory{db: db, slowCallThreshold: slowCallThreshold}
+}
+
+// BeginDBAL implements TransactionFactory
+func (f *InstrumentedTransactionFactory) BeginDBAL(ctx context.Context, channel string, readOnly bool) (Transaction, error) {
+	tx, err := f.db.BeginDBAL(ctx, channel, readOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewInstrumentedDBAL(ctx, tx, channel, f.slowCallThreshold), nil
+}
+
+// InstrumentedDBAL decorates a Transaction to record the latency, the errors
+// and the number of returned assets of each call in prometheus metrics, per method and channel.
+// Slow calls are logged along with the ID of the request.
+type InstrumentedDBAL struct {
+	ctx               context.Context
+	dbal              Transaction
+	channel           string
+	slowCallThreshold time.Duration
+}
+
+var _ Transaction = (*InstrumentedDBAL)(nil)
+
+// NewInstrumentedDBAL returns a DBAL instrumenting the calls to dbal, see NewInstrumentedTransactionFactory.
+func NewInstrumentedDBAL(ctx context.Context, dbal Transaction, channel string, slowCallThreshold time.Duration) *InstrumentedDBAL {
+	return &InstrumentedDBAL{ctx: ctx, dbal: dbal, channel: channel, slowCallThreshold: slowCallThreshold}
+}
+
+// observe records a call to method which started at start.
+// rows is the number of assets returned by the call, or noRows if the method doesn't return a list.
+func (d *InstrumentedDBAL) observe(method string, start time.Time, rows int, err error) {
+	elapsed := time.Since(start)
+
+	metrics.DBALCallDuration.WithLabelValues(method, d.channel).Observe(elapsed.Seconds())
+	if err != nil {
+		metrics.DBALCallErrorTotal.WithLabelValues(method, d.channel).Inc()
+	}
+	if rows != noRows {
+		metrics.DBALRowsReturned.WithLabelValues(method, d.channel).Observe(float64(rows))
+	}
+
+	if d.slowCallThreshold > 0 && elapsed >= d.slowCallThreshold {
+		log.Ctx(d.ctx).Warn().
+			Str("requestID", interceptors.GetRequestID(d.ctx)).
+			Str("method", method).
+			Str("channel", d.channel).
+			Dur("duration", elapsed).
+			Msg("slow DBAL call")
+	}
+}
+
+func (d *InstrumentedDBAL) Commit(ctx context.Context) error {
+	start := time.Now()
+	err := d.dbal.Commit(ctx)
+	d.observe("Commit", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) Rollback(ctx context.Context) error {
+	start := time.Now()
+	err := d.dbal.Rollback(ctx)
+	d.observe("Rollback", start, noRows, err)
+	return err
+}
+
+// persistence.OrganizationDBAL
+
+func (d *InstrumentedDBAL) AddOrganization(organization *asset.Organization) error {
+	start := time.Now()
+	err := d.dbal.AddOrganization(organization)
+	d.observe("AddOrganization", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) OrganizationExists(id string) (bool, error) {
+	start := time.Now()
+	res, err := d.dbal.OrganizationExists(id)
+	d.observe("OrganizationExists", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetAllOrganizations() ([]*asset.Organization, error) {
+	start := time.Now()
+	res, err := d.dbal.GetAllOrganizations()
+	d.observe("GetAllOrganizations", start, len(res), err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetOrganization(id string) (*asset.Organization, error) {
+	start := time.Now()
+	res, err := d.dbal.GetOrganization(id)
+	d.observe("GetOrganization", start, noRows, err)
+	return res, err
+}
+
+// persistence.DataSampleDBAL
+
+func (d *InstrumentedDBAL) AddDataSamples(dataSamples ...*asset.DataSample) error {
+	start := time.Now()
+	err := d.dbal.AddDataSamples(dataSamples...)
+	d.observe("AddDataSamples", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) UpdateDataSample(dataSample *asset.DataSample) error {
+	start := time.Now()
+	err := d.dbal.UpdateDataSample(dataSample)
+	d.observe("UpdateDataSample", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) GetDataSample(key string) (*asset.DataSample, error) {
+	start := time.Now()
+	res, err := d.dbal.GetDataSample(key)
+	d.observe("GetDataSample", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) QueryDataSamples(p *common.Pagination, filter *asset.DataSampleQueryFilter) ([]*asset.DataSample, common.PaginationToken, error) {
+	start := time.Now()
+	res, token, err := d.dbal.QueryDataSamples(p, filter)
+	d.observe("QueryDataSamples", start, len(res), err)
+	return res, token, err
+}
+
+func (d *InstrumentedDBAL) DataSampleExists(key string) (bool, error) {
+	start := time.Now()
+	res, err := d.dbal.DataSampleExists(key)
+	d.observe("DataSampleExists", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetDataSampleKeysByManager(managerKey string) ([]string, error) {
+	start := time.Now()
+	res, err := d.dbal.GetDataSampleKeysByManager(managerKey)
+	d.observe("GetDataSampleKeysByManager", start, len(res), err)
+	return res, err
+}
+
+// persistence.FunctionDBAL
+
+func (d *InstrumentedDBAL) AddFunction(obj *asset.Function) error {
+	start := time.Now()
+	err := d.dbal.AddFunction(obj)
+	d.observe("AddFunction", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) GetFunction(key string) (*asset.Function, error) {
+	start := time.Now()
+	res, err := d.dbal.GetFunction(key)
+	d.observe("GetFunction", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) QueryFunctions(p *common.Pagination, filter *asset.FunctionQueryFilter) ([]*asset.Function, common.PaginationToken, error) {
+	start := time.Now()
+	res, token, err := d.dbal.QueryFunctions(p, filter)
+	d.observe("QueryFunctions", start, len(res), err)
+	return res, token, err
+}
+
+func (d *InstrumentedDBAL) FunctionExists(key string) (bool, error) {
+	start := time.Now()
+	res, err := d.dbal.FunctionExists(key)
+	d.observe("FunctionExists", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) UpdateFunction(function *asset.Function) error {
+	start := time.Now()
+	err := d.dbal.UpdateFunction(function)
+	d.observe("UpdateFunction", start, noRows, err)
+	return err
+}
+
+// persistence.DataManagerDBAL
+
+func (d *InstrumentedDBAL) AddDataManager(datamanager *asset.DataManager) error {
+	start := time.Now()
+	err := d.dbal.AddDataManager(datamanager)
+	d.observe("AddDataManager", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) GetDataManager(key string) (*asset.DataManager, error) {
+	start := time.Now()
+	res, err := d.dbal.GetDataManager(key)
+	d.observe("GetDataManager", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) QueryDataManagers(p *common.Pagination) ([]*asset.DataManager, common.PaginationToken, error) {
+	start := time.Now()
+	res, token, err := d.dbal.QueryDataManagers(p)
+	d.observe("QueryDataManagers", start, len(res), err)
+	return res, token, err
+}
+
+func (d *InstrumentedDBAL) DataManagerExists(key string) (bool, error) {
+	start := time.Now()
+	res, err := d.dbal.DataManagerExists(key)
+	d.observe("DataManagerExists", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) UpdateDataManager(dm *asset.DataManager) error {
+	start := time.Now()
+	err := d.dbal.UpdateDataManager(dm)
+	d.observe("UpdateDataManager", start, noRows, err)
+	return err
+}
+
+// persistence.ComputeTaskDBAL
+
+func (d *InstrumentedDBAL) GetExistingComputeTaskKeys(keys []string) ([]string, error) {
+	start := time.Now()
+	res, err := d.dbal.GetExistingComputeTaskKeys(keys)
+	d.observe("GetExistingComputeTaskKeys", start, len(res), err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetComputeTask(key string) (*asset.ComputeTask, error) {
+	start := time.Now()
+	res, err := d.dbal.GetComputeTask(key)
+	d.observe("GetComputeTask", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetComputeTasks(keys []string) ([]*asset.ComputeTask, error) {
+	start := time.Now()
+	res, err := d.dbal.GetComputeTasks(keys)
+	d.observe("GetComputeTasks", start, len(res), err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) AddComputeTasks(tasks ...*asset.ComputeTask) error {
+	start := time.Now()
+	err := d.dbal.AddComputeTasks(tasks...)
+	d.observe("AddComputeTasks", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) UpdateComputeTaskStatus(taskKey string, taskStatus asset.ComputeTaskStatus) error {
+	start := time.Now()
+	err := d.dbal.UpdateComputeTaskStatus(taskKey, taskStatus)
+	d.observe("UpdateComputeTaskStatus", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) UpdateComputeTaskAttempt(taskKey string, attempt uint32) error {
+	start := time.Now()
+	err := d.dbal.UpdateComputeTaskAttempt(taskKey, attempt)
+	d.observe("UpdateComputeTaskAttempt", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) UpdateComputeTaskLease(taskKey string, expiration time.Time) error {
+	start := time.Now()
+	err := d.dbal.UpdateComputeTaskLease(taskKey, expiration)
+	d.observe("UpdateComputeTaskLease", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) IsComputeTaskLeaseExpired(taskKey string, expiredBefore time.Time) (bool, error) {
+	start := time.Now()
+	res, err := d.dbal.IsComputeTaskLeaseExpired(taskKey, expiredBefore)
+	d.observe("IsComputeTaskLeaseExpired", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetExpiredComputeTasks(expiredBefore time.Time) ([]*asset.ComputeTask, error) {
+	start := time.Now()
+	res, err := d.dbal.GetExpiredComputeTasks(expiredBefore)
+	d.observe("GetExpiredComputeTasks", start, len(res), err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) QueryComputeTasks(p *common.Pagination, filter *asset.TaskQueryFilter, sortBy asset.TaskSortField, sortOrder asset.SortOrder) ([]*asset.ComputeTask, common.PaginationToken, error) {
+	start := time.Now()
+	res, token, err := d.dbal.QueryComputeTasks(p, filter, sortBy, sortOrder)
+	d.observe("QueryComputeTasks", start, len(res), err)
+	return res, token, err
+}
+
+func (d *InstrumentedDBAL) GetComputeTaskChildren(key string) ([]*asset.ComputeTask, error) {
+	start := time.Now()
+	res, err := d.dbal.GetComputeTaskChildren(key)
+	d.observe("GetComputeTaskChildren", start, len(res), err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetComputeTaskParents(key string) ([]*asset.ComputeTask, error) {
+	start := time.Now()
+	res, err := d.dbal.GetComputeTaskParents(key)
+	d.observe("GetComputeTaskParents", start, len(res), err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetComputePlanTasks(key string) ([]*asset.ComputeTask, error) {
+	start := time.Now()
+	res, err := d.dbal.GetComputePlanTasks(key)
+	d.observe("GetComputePlanTasks", start, len(res), err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetComputePlanTasksKeys(key string) ([]string, error) {
+	start := time.Now()
+	res, err := d.dbal.GetComputePlanTasksKeys(key)
+	d.observe("GetComputePlanTasksKeys", start, len(res), err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetFunctionFromTasksWithStatus(key string, statuses []asset.ComputeTaskStatus) ([]*asset.ComputeTask, error) {
+	start := time.Now()
+	res, err := d.dbal.GetFunctionFromTasksWithStatus(key, statuses)
+	d.observe("GetFunctionFromTasksWithStatus", start, len(res), err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) AddComputeTaskOutputAsset(output *asset.ComputeTaskOutputAsset) error {
+	start := time.Now()
+	err := d.dbal.AddComputeTaskOutputAsset(output)
+	d.observe("AddComputeTaskOutputAsset", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) CountComputeTaskRegisteredOutputs(key string) (persistence.ComputeTaskOutputCounter, error) {
+	start := time.Now()
+	res, err := d.dbal.CountComputeTaskRegisteredOutputs(key)
+	d.observe("CountComputeTaskRegisteredOutputs", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetComputeTaskOutputAssets(taskKey, identifier string) ([]*asset.ComputeTaskOutputAsset, error) {
+	start := time.Now()
+	res, err := d.dbal.GetComputeTaskOutputAssets(taskKey, identifier)
+	d.observe("GetComputeTaskOutputAssets", start, len(res), err)
+	return res, err
+}
+
+// persistence.ModelDBAL
+
+func (d *InstrumentedDBAL) ModelExists(key string) (bool, error) {
+	start := time.Now()
+	res, err := d.dbal.ModelExists(key)
+	d.observe("ModelExists", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetModel(key string) (*asset.Model, error) {
+	start := time.Now()
+	res, err := d.dbal.GetModel(key)
+	d.observe("GetModel", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetComputeTaskOutputModels(key string) ([]*asset.Model, error) {
+	start := time.Now()
+	res, err := d.dbal.GetComputeTaskOutputModels(key)
+	d.observe("GetComputeTaskOutputModels", start, len(res), err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) AddModel(m *asset.Model, identifier string) error {
+	start := time.Now()
+	err := d.dbal.AddModel(m, identifier)
+	d.observe("AddModel", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) UpdateModel(m *asset.Model) error {
+	start := time.Now()
+	err := d.dbal.UpdateModel(m)
+	d.observe("UpdateModel", start, noRows, err)
+	return err
+}
+
+// persistence.ComputePlanDBAL
+
+func (d *InstrumentedDBAL) ComputePlanExists(key string) (bool, error) {
+	start := time.Now()
+	res, err := d.dbal.ComputePlanExists(key)
+	d.observe("ComputePlanExists", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetComputePlan(key string) (*asset.ComputePlan, error) {
+	start := time.Now()
+	res, err := d.dbal.GetComputePlan(key)
+	d.observe("GetComputePlan", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) AddComputePlan(plan *asset.ComputePlan) error {
+	start := time.Now()
+	err := d.dbal.AddComputePlan(plan)
+	d.observe("AddComputePlan", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) QueryComputePlans(p *common.Pagination, filter *asset.PlanQueryFilter) ([]*asset.ComputePlan, common.PaginationToken, error) {
+	start := time.Now()
+	res, token, err := d.dbal.QueryComputePlans(p, filter)
+	d.observe("QueryComputePlans", start, len(res), err)
+	return res, token, err
+}
+
+func (d *InstrumentedDBAL) SetComputePlanName(plan *asset.ComputePlan, name string) error {
+	start := time.Now()
+	err := d.dbal.SetComputePlanName(plan, name)
+	d.observe("SetComputePlanName", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) CancelComputePlan(plan *asset.ComputePlan, cancelationDate time.Time) error {
+	start := time.Now()
+	err := d.dbal.CancelComputePlan(plan, cancelationDate)
+	d.observe("CancelComputePlan", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) FailComputePlan(plan *asset.ComputePlan, failureDate time.Time) error {
+	start := time.Now()
+	err := d.dbal.FailComputePlan(plan, failureDate)
+	d.observe("FailComputePlan", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) RestoreComputePlan(plan *asset.ComputePlan) error {
+	start := time.Now()
+	err := d.dbal.RestoreComputePlan(plan)
+	d.observe("RestoreComputePlan", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) PauseComputePlan(plan *asset.ComputePlan, pauseDate time.Time) error {
+	start := time.Now()
+	err := d.dbal.PauseComputePlan(plan, pauseDate)
+	d.observe("PauseComputePlan", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) ResumeComputePlan(plan *asset.ComputePlan, resumeDate time.Time) error {
+	start := time.Now()
+	err := d.dbal.ResumeComputePlan(plan, resumeDate)
+	d.observe("ResumeComputePlan", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) ArePlanTasksRunning(key string) (bool, error) {
+	start := time.Now()
+	res, err := d.dbal.ArePlanTasksRunning(key)
+	d.observe("ArePlanTasksRunning", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetComputePlanStatistics(key string) (*asset.ComputePlanStatistics, error) {
+	start := time.Now()
+	res, err := d.dbal.GetComputePlanStatistics(key)
+	d.observe("GetComputePlanStatistics", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetComputePlanArchive(key string) (*asset.ComputePlanArchive, error) {
+	start := time.Now()
+	res, err := d.dbal.GetComputePlanArchive(key)
+	d.observe("GetComputePlanArchive", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) IsComputePlanReferenced(key string) (bool, error) {
+	start := time.Now()
+	res, err := d.dbal.IsComputePlanReferenced(key)
+	d.observe("IsComputePlanReferenced", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) PurgeComputePlan(key string, purgeDate time.Time) (*asset.ComputePlanPurgeSummary, error) {
+	start := time.Now()
+	res, err := d.dbal.PurgeComputePlan(key, purgeDate)
+	d.observe("PurgeComputePlan", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetPurgeableComputePlanKeys(terminatedBefore time.Time) ([]string, error) {
+	start := time.Now()
+	res, err := d.dbal.GetPurgeableComputePlanKeys(terminatedBefore)
+	d.observe("GetPurgeableComputePlanKeys", start, len(res), err)
+	return res, err
+}
+
+// persistence.PerformanceDBAL
+
+func (d *InstrumentedDBAL) AddPerformance(perf *asset.Performance, identifier string) error {
+	start := time.Now()
+	err := d.dbal.AddPerformance(perf, identifier)
+	d.observe("AddPerformance", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) QueryPerformances(p *common.Pagination, filter *asset.PerformanceQueryFilter) ([]*asset.Performance, common.PaginationToken, error) {
+	start := time.Now()
+	res, token, err := d.dbal.QueryPerformances(p, filter)
+	d.observe("QueryPerformances", start, len(res), err)
+	return res, token, err
+}
+
+func (d *InstrumentedDBAL) PerformanceExists(perf *asset.Performance) (bool, error) {
+	start := time.Now()
+	res, err := d.dbal.PerformanceExists(perf)
+	d.observe("PerformanceExists", start, noRows, err)
+	return res, err
+}
+
+// persistence.EventDBAL
+
+// NewEventID does not query the database, it is not instrumented
+func (d *InstrumentedDBAL) NewEventID() string {
+	return d.dbal.NewEventID()
+}
+
+func (d *InstrumentedDBAL) AddEvents(events ...*asset.Event) error {
+	start := time.Now()
+	err := d.dbal.AddEvents(events...)
+	d.observe("AddEvents", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) QueryEvents(p *common.Pagination, filter *asset.EventQueryFilter, sortOrder asset.SortOrder) ([]*asset.Event, common.PaginationToken, error) {
+	start := time.Now()
+	res, token, err := d.dbal.QueryEvents(p, filter, sortOrder)
+	d.observe("QueryEvents", start, len(res), err)
+	return res, token, err
+}
+
+func (d *InstrumentedDBAL) VerifyEventChain() (*asset.EventChainStatus, error) {
+	start := time.Now()
+	res, err := d.dbal.VerifyEventChain()
+	d.observe("VerifyEventChain", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) AckEvents(owner string, consumer string, eventID string, ackDate time.Time) error {
+	start := time.Now()
+	err := d.dbal.AckEvents(owner, consumer, eventID, ackDate)
+	d.observe("AckEvents", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) QueryEventConsumers(p *common.Pagination, owner string) ([]*asset.EventConsumer, common.PaginationToken, error) {
+	start := time.Now()
+	res, token, err := d.dbal.QueryEventConsumers(p, owner)
+	d.observe("QueryEventConsumers", start, len(res), err)
+	return res, token, err
+}
+
+// persistence.FailureReportDBAL
+
+func (d *InstrumentedDBAL) GetFailureReport(assetKey string, attempt uint32) (*asset.FailureReport, error) {
+	start := time.Now()
+	res, err := d.dbal.GetFailureReport(assetKey, attempt)
+	d.observe("GetFailureReport", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) AddFailureReport(f *asset.FailureReport) error {
+	start := time.Now()
+	err := d.dbal.AddFailureReport(f)
+	d.observe("AddFailureReport", start, noRows, err)
+	return err
+}
+
+// persistence.WebhookDBAL
+
+func (d *InstrumentedDBAL) AddWebhook(webhook *asset.Webhook, secret string) error {
+	start := time.Now()
+	err := d.dbal.AddWebhook(webhook, secret)
+	d.observe("AddWebhook", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) UpdateWebhook(webhook *asset.Webhook, secret string) error {
+	start := time.Now()
+	err := d.dbal.UpdateWebhook(webhook, secret)
+	d.observe("UpdateWebhook", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) DeleteWebhook(key string) error {
+	start := time.Now()
+	err := d.dbal.DeleteWebhook(key)
+	d.observe("DeleteWebhook", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) GetWebhook(key string) (*asset.Webhook, error) {
+	start := time.Now()
+	res, err := d.dbal.GetWebhook(key)
+	d.observe("GetWebhook", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) WebhookExists(key string) (bool, error) {
+	start := time.Now()
+	res, err := d.dbal.WebhookExists(key)
+	d.observe("WebhookExists", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) QueryWebhooks(p *common.Pagination, owner string) ([]*asset.Webhook, common.PaginationToken, error) {
+	start := time.Now()
+	res, token, err := d.dbal.QueryWebhooks(p, owner)
+	d.observe("QueryWebhooks", start, len(res), err)
+	return res, token, err
+}
+
+func (d *InstrumentedDBAL) ClaimWebhook(key string, claimID string, now time.Time, until time.Time) (string, bool, error) {
+	start := time.Now()
+	secret, claimed, err := d.dbal.ClaimWebhook(key, claimID, now, until)
+	d.observe("ClaimWebhook", start, noRows, err)
+	return secret, claimed, err
+}
+
+func (d *InstrumentedDBAL) ReleaseWebhook(key string, claimID string) (bool, error) {
+	start := time.Now()
+	res, err := d.dbal.ReleaseWebhook(key, claimID)
+	d.observe("ReleaseWebhook", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetDueWebhookKeys(now time.Time) ([]string, error) {
+	start := time.Now()
+	res, err := d.dbal.GetDueWebhookKeys(now)
+	d.observe("GetDueWebhookKeys", start, len(res), err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) GetWebhookEvents(webhook *asset.Webhook, limit uint32) ([]*asset.Event, string, error) {
+	start := time.Now()
+	res, lastScanned, err := d.dbal.GetWebhookEvents(webhook, limit)
+	d.observe("GetWebhookEvents", start, len(res), err)
+	return res, lastScanned, err
+}
+
+func (d *InstrumentedDBAL) SetWebhookCursor(webhookKey string, eventID string) error {
+	start := time.Now()
+	err := d.dbal.SetWebhookCursor(webhookKey, eventID)
+	d.observe("SetWebhookCursor", start, noRows, err)
+	return err
+}
+
+// NewWebhookClaimID does not query the database, it is not instrumented
+func (d *InstrumentedDBAL) NewWebhookClaimID() string {
+	return d.dbal.NewWebhookClaimID()
+}
+
+// NewWebhookDeliveryID does not query the database, it is not instrumented
+func (d *InstrumentedDBAL) NewWebhookDeliveryID() string {
+	return d.dbal.NewWebhookDeliveryID()
+}
+
+func (d *InstrumentedDBAL) GetWebhookDelivery(webhookKey string, eventID string) (*asset.WebhookDelivery, error) {
+	start := time.Now()
+	res, err := d.dbal.GetWebhookDelivery(webhookKey, eventID)
+	d.observe("GetWebhookDelivery", start, noRows, err)
+	return res, err
+}
+
+func (d *InstrumentedDBAL) AddWebhookDelivery(delivery *asset.WebhookDelivery) error {
+	start := time.Now()
+	err := d.dbal.AddWebhookDelivery(delivery)
+	d.observe("AddWebhookDelivery", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) UpdateWebhookDelivery(delivery *asset.WebhookDelivery) error {
+	start := time.Now()
+	err := d.dbal.UpdateWebhookDelivery(delivery)
+	d.observe("UpdateWebhookDelivery", start, noRows, err)
+	return err
+}
+
+func (d *InstrumentedDBAL) QueryWebhookDeliveries(p *common.Pagination, webhookKey string, status asset.WebhookDeliveryStatus) ([]*asset.WebhookDelivery, common.PaginationToken, error) {
+	start := time.Now()
+	res, token, err := d.dbal.QueryWebhookDeliveries(p, webhookKey, status)
+	d.observe("QueryWebhookDeliveries", start, len(res), err)
+	return res, token, err
+}
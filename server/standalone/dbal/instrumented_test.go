@@ -0,0 +1,89 @@
+package dbal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/server/common/interceptors"
+	"github.com/substra/orchestrator/server/standalone/metrics"
+)
+
+func TestInstrumentedDBALForwardsCalls(t *testing.T) {
+	tx := new(MockTransaction)
+	tx.On("GetComputePlanTasks", "cp").Once().Return([]*asset.ComputeTask{{Key: "task1"}, {Key: "task2"}}, nil)
+	tx.On("GetComputeTask", "unknown").Once().Return(nil, errors.New("not found"))
+	tx.On("NewEventID").Once().Return("id")
+
+	d := NewInstrumentedDBAL(context.Background(), tx, "instrumentedchannel", 0)
+
+	errorsBefore := testutil.ToFloat64(metrics.DBALCallErrorTotal.WithLabelValues("GetComputeTask", "instrumentedchannel"))
+	rowsBefore := observedRows(t, "GetComputePlanTasks", "instrumentedchannel")
+
+	tasks, err := d.GetComputePlanTasks("cp")
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+
+	_, err = d.GetComputeTask("unknown")
+	assert.Error(t, err)
+
+	assert.Equal(t, "id", d.NewEventID())
+
+	assert.Equal(t, errorsBefore+1, testutil.ToFloat64(metrics.DBALCallErrorTotal.WithLabelValues("GetComputeTask", "instrumentedchannel")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.DBALCallErrorTotal.WithLabelValues("GetComputePlanTasks", "instrumentedchannel")))
+	rowsAfter := observedRows(t, "GetComputePlanTasks", "instrumentedchannel")
+	assert.Equal(t, rowsBefore.GetSampleCount()+1, rowsAfter.GetSampleCount())
+	assert.Equal(t, rowsBefore.GetSampleSum()+2, rowsAfter.GetSampleSum())
+
+	tx.AssertExpectations(t)
+}
+
+// observedRows returns the histogram of the rows returned by a method on a channel.
+func observedRows(t *testing.T, method string, channel string) *dto.Histogram {
+	m := new(dto.Metric)
+	require.NoError(t, metrics.DBALRowsReturned.WithLabelValues(method, channel).(prometheus.Histogram).Write(m))
+
+	return m.GetHistogram()
+}
+
+func TestInstrumentedDBALLogsSlowCalls(t *testing.T) {
+	tx := new(MockTransaction)
+	tx.On("ComputePlanExists", "cp").Return(true, nil)
+
+	buf := new(bytes.Buffer)
+	ctx := zerolog.New(buf).WithContext(context.Background())
+	ctx = context.WithValue(ctx, interceptors.RequestIDMarker, "abcd1234")
+
+	_, err := NewInstrumentedDBAL(ctx, tx, testChannel, time.Hour).ComputePlanExists("cp")
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String(), "fast calls should not be logged")
+
+	_, err = NewInstrumentedDBAL(ctx, tx, testChannel, time.Nanosecond).ComputePlanExists("cp")
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `"requestID":"abcd1234"`)
+	assert.Contains(t, buf.String(), `"method":"ComputePlanExists"`)
+}
+
+func TestInstrumentedTransactionFactory(t *testing.T) {
+	tx := new(MockTransaction)
+	db := new(MockTransactionFactory)
+	db.On("BeginDBAL", context.Background(), testChannel, true).Once().Return(tx, nil)
+	tx.On("Commit", context.Background()).Once().Return(nil)
+
+	instrumented, err := NewInstrumentedTransactionFactory(db, 0).BeginDBAL(context.Background(), testChannel, true)
+	require.NoError(t, err)
+	assert.IsType(t, &InstrumentedDBAL{}, instrumented)
+	assert.NoError(t, instrumented.Commit(context.Background()))
+
+	db.AssertExpectations(t)
+	tx.AssertExpectations(t)
+}
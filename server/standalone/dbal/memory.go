@@ -0,0 +1,49 @@
+package dbal
+
+import (
+	"context"
+	"strings"
+
+	"github.com/substra/orchestrator/lib/persistence/memory"
+)
+
+// MemoryDatabaseURL is the database url selecting the in-memory storage.
+const MemoryDatabaseURL = "memory://"
+
+// IsMemoryDatabaseURL returns true if the url designates the in-memory storage.
+func IsMemoryDatabaseURL(databaseURL string) bool {
+	return strings.HasPrefix(databaseURL, MemoryDatabaseURL)
+}
+
+// MemoryDatabase keeps assets in memory, they are lost when the server stops.
+// It is meant for tests and for embedding the orchestrator in another process.
+type MemoryDatabase struct {
+	store *memory.Store
+}
+
+// NewMemoryDatabase returns an empty in-memory database.
+func NewMemoryDatabase() *MemoryDatabase {
+	return &MemoryDatabase{store: memory.NewStore()}
+}
+
+// memoryTransaction binds an in-memory DBAL to its transaction.
+type memoryTransaction struct {
+	*memory.DBAL
+	tx *memory.Tx
+}
+
+// BeginDBAL returns a DBAL bound to a new transaction on the given channel.
+// Write transactions are serialized: this blocks until ongoing write transactions are done.
+func (d *MemoryDatabase) BeginDBAL(_ context.Context, channel string, readOnly bool) (Transaction, error) {
+	tx := d.store.Begin(readOnly)
+
+	return &memoryTransaction{DBAL: memory.New(tx, channel), tx: tx}, nil
+}
+
+func (t *memoryTransaction) Commit(_ context.Context) error {
+	return t.tx.Commit()
+}
+
+func (t *memoryTransaction) Rollback(_ context.Context) error {
+	return t.tx.Rollback()
+}
@@ -0,0 +1,175 @@
+package dbal
+
+import (
+	"context"
+	"sort"
+
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/persistence/memory"
+)
+
+// lastEventPosition implements eventPositionReader
+func (d *MemoryDatabase) lastEventPosition(_ context.Context) (int64, error) {
+	return d.store.LastEventPosition(), nil
+}
+
+// lastChannelEvents implements eventPositionReader
+func (d *MemoryDatabase) lastChannelEvents(_ context.Context, afterPosition int64) ([]*eventNotification, error) {
+	notifs := []*eventNotification{}
+	for channel, position := range d.store.LastChannelEvents(afterPosition) {
+		notifs = append(notifs, &eventNotification{Channel: channel, EventPosition: position})
+	}
+
+	return notifs, nil
+}
+
+// read calls f with a DBAL of the channel bound to a read-only transaction.
+func (d *MemoryDatabase) read(channel string, f func(*memory.DBAL) error) error {
+	tx := d.store.Begin(true)
+	// Nothing to commit in a read-only transaction
+	defer tx.Rollback() //nolint:errcheck
+
+	return f(memory.New(tx, channel))
+}
+
+// memoryEventStreamer streams the events of an in-memory database, it is notified of new events by a polling notifier.
+type memoryEventStreamer struct {
+	db       *MemoryDatabase
+	notifier *EventNotifier
+}
+
+// NewMemoryEventStreamer returns an EventStreamer reading the events of db.
+// The notifier should poll the same database, see NewMemoryEventNotifier.
+func NewMemoryEventStreamer(db *MemoryDatabase, notifier *EventNotifier) EventStreamer {
+	return &memoryEventStreamer{db: db, notifier: notifier}
+}
+
+// SubscribeToEvents implements EventStreamer, see DBAL.SubscribeToEvents.
+func (s *memoryEventStreamer) SubscribeToEvents(ctx context.Context, channel string, owner string, param *asset.SubscribeToEventsParam, stream asset.EventService_SubscribeToEventsServer) error {
+	// Subscribe before replaying existing events to prevent missing any event
+	sub := s.notifier.Subscribe(channel)
+	defer sub.Close()
+
+	startAfterPosition := int64(0)
+	err := s.db.read(channel, func(d *memory.DBAL) error {
+		var err error
+		switch {
+		case param.StartEventId != "":
+			startAfterPosition, err = d.GetEventPosition(param.StartEventId)
+		case param.Consumer != "":
+			startAfterPosition, err = d.GetEventConsumerPosition(owner, param.Consumer)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	es := newPolledEventStream(ctx, sub, &memoryEventLog{db: s.db}, []string{channel}, param.Filter)
+	es.positions[channel] = startAfterPosition
+
+	return es.run(stream.Send)
+}
+
+// SubscribeToChannels implements EventStreamer, see SubscribeToChannels.
+func (s *memoryEventStreamer) SubscribeToChannels(ctx context.Context, channels []string, param *asset.SubscribeToChannelsParam, stream asset.EventService_SubscribeToChannelsServer) error {
+	// Subscribe before replaying existing events to prevent missing any event
+	sub := s.notifier.Subscribe(channels...)
+	defer sub.Close()
+
+	es := newPolledEventStream(ctx, sub, &memoryEventLog{db: s.db}, channels, param.Filter)
+
+	for _, cursor := range param.Cursors {
+		if cursor.StartEventId == "" {
+			continue
+		}
+		err := s.db.read(cursor.Channel, func(d *memory.DBAL) error {
+			position, err := d.GetEventPosition(cursor.StartEventId)
+			es.positions[cursor.Channel] = position
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return es.run(stream.Send)
+}
+
+// WatchComputePlan implements EventStreamer, see DBAL.WatchComputePlan.
+func (s *memoryEventStreamer) WatchComputePlan(ctx context.Context, channel string, key string, stream asset.EventService_WatchComputePlanServer) error {
+	// Subscribe before taking the snapshot to prevent missing any event
+	sub := s.notifier.Subscribe(channel)
+	defer sub.Close()
+
+	snapshot := new(asset.ComputePlanSnapshot)
+	err := s.db.read(channel, func(d *memory.DBAL) error {
+		position, err := d.GetLastEventPosition()
+		if err != nil {
+			return err
+		}
+		assets, err := d.GetComputePlanAssets(key)
+		if err != nil {
+			return err
+		}
+
+		snapshot = &asset.ComputePlanSnapshot{
+			Position:       uint64(position),
+			ComputePlan:    assets.ComputePlan,
+			Tasks:          assets.Tasks,
+			OutputAssets:   assets.OutputAssets,
+			Models:         assets.Models,
+			Performances:   assets.Performances,
+			FailureReports: assets.FailureReports,
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = stream.Send(&asset.ComputePlanUpdate{Update: &asset.ComputePlanUpdate_Snapshot{Snapshot: snapshot}})
+	if err != nil {
+		return err
+	}
+
+	es := newPolledEventStream(ctx, sub, &memoryEventLog{db: s.db}, []string{channel}, &asset.EventQueryFilter{ComputePlanKey: key})
+	es.positions[channel] = int64(snapshot.Position)
+
+	return es.run(func(event *asset.Event) error {
+		return stream.Send(&asset.ComputePlanUpdate{Update: &asset.ComputePlanUpdate_Event{Event: event}})
+	})
+}
+
+// memoryEventLog reads the events of an in-memory database.
+type memoryEventLog struct {
+	db *MemoryDatabase
+}
+
+// eventsAfter implements eventLog
+// Every channel is read in the same transaction, so that the events of a channel can't be missed
+// while reading the other ones.
+func (l *memoryEventLog) eventsAfter(channels []string, positions map[string]int64, filter *asset.EventQueryFilter, limit int) ([]positionedEvent, error) {
+	tx := l.db.store.Begin(true)
+	defer tx.Rollback() //nolint:errcheck
+
+	events := []positionedEvent{}
+	for _, channel := range channels {
+		channelEvents, err := memory.New(tx, channel).GetEventsAfter(positions[channel], filter, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range channelEvents {
+			events = append(events, positionedEvent{position: e.Position, event: e.Event})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].position < events[j].position
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	return events, nil
+}
@@ -0,0 +1,108 @@
+package dbal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+func addMemoryTestEvents(t *testing.T, db *MemoryDatabase, channel string, events ...*asset.Event) {
+	tx, err := db.BeginDBAL(context.Background(), channel, false)
+	require.NoError(t, err)
+	require.NoError(t, tx.AddEvents(events...))
+	require.NoError(t, tx.Commit(context.Background()))
+}
+
+func TestMemorySubscribeToChannels(t *testing.T) {
+	db := NewMemoryDatabase()
+	task := &asset.ComputeTask{Key: "task"}
+	addMemoryTestEvents(t, db, testChannel, newSQLiteTestEvent("e1", 1, task), newSQLiteTestEvent("e2", 2, task))
+	addMemoryTestEvents(t, db, "otherchannel", newSQLiteTestEvent("other1", 3, task))
+
+	notifier := NewMemoryEventNotifier(db, 10, time.Millisecond, 10*time.Millisecond)
+	notifier.Start(context.Background())
+	defer notifier.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan *asset.Event, 10)
+	stream := new(asset.MockEventService_SubscribeToChannelsServer)
+	stream.On("Send", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		received <- args.Get(0).(*asset.Event)
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		channels := []string{testChannel, "otherchannel"}
+		param := &asset.SubscribeToChannelsParam{Cursors: []*asset.ChannelCursor{{Channel: testChannel, StartEventId: "e1"}}}
+		done <- NewMemoryEventStreamer(db, notifier).SubscribeToChannels(ctx, channels, param, stream)
+	}()
+
+	assert.Equal(t, "e2", receiveEvent(t, received).Id, "events should be replayed after the start event")
+	assert.Equal(t, "other1", receiveEvent(t, received).Id, "channels without cursor should be replayed from the start")
+
+	addMemoryTestEvents(t, db, "unwatched", newSQLiteTestEvent("unwatched", 4, task))
+	addMemoryTestEvents(t, db, "otherchannel", newSQLiteTestEvent("other2", 5, task))
+	addMemoryTestEvents(t, db, testChannel, newSQLiteTestEvent("e3", 6, task))
+	assert.Equal(t, "other2", receiveEvent(t, received).Id, "new events should be forwarded")
+	assert.Equal(t, "e3", receiveEvent(t, received).Id, "new events should be forwarded")
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Empty(t, received)
+}
+
+func TestMemoryWatchComputePlan(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	tx, err := db.BeginDBAL(context.Background(), testChannel, false)
+	require.NoError(t, err)
+	require.NoError(t, tx.AddComputePlan(&asset.ComputePlan{Key: "cp"}))
+	require.NoError(t, tx.AddComputeTasks(newSQLiteTestTask("task", 0)))
+	require.NoError(t, tx.AddEvents(newSQLiteTestEvent("e1", 1, newSQLiteTestTask("task", 0))))
+	require.NoError(t, tx.Commit(context.Background()))
+
+	notifier := NewMemoryEventNotifier(db, 10, time.Millisecond, 10*time.Millisecond)
+	notifier.Start(context.Background())
+	defer notifier.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := make(chan *asset.ComputePlanUpdate, 10)
+	stream := new(asset.MockEventService_WatchComputePlanServer)
+	stream.On("Send", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		updates <- args.Get(0).(*asset.ComputePlanUpdate)
+	})
+
+	go NewMemoryEventStreamer(db, notifier).WatchComputePlan(ctx, testChannel, "cp", stream) //nolint:errcheck
+
+	var snapshot *asset.ComputePlanSnapshot
+	select {
+	case update := <-updates:
+		snapshot = update.GetSnapshot()
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no snapshot received")
+	}
+	require.NotNil(t, snapshot)
+	assert.Equal(t, uint64(1), snapshot.Position)
+	assert.Len(t, snapshot.Tasks, 1)
+
+	addMemoryTestEvents(t, db, testChannel,
+		newSQLiteTestEvent("unrelated", 2, &asset.ComputeTask{Key: "other"}),
+		newSQLiteTestEvent("e2", 3, newSQLiteTestTask("task", 0)),
+	)
+
+	select {
+	case update := <-updates:
+		assert.Equal(t, "e2", update.GetEvent().Id)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event received")
+	}
+}
@@ -0,0 +1,457 @@
+package dbal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/rs/zerolog/log"
+	"github.com/substra/orchestrator/server/standalone/metrics"
+)
+
+// notifierReconnectDelay is the delay before reconnecting after the loss of the listening connection,
+// or before polling again after a failure.
+const notifierReconnectDelay = 5 * time.Second
+
+// ErrNotifierStopped is returned to the subscribers once the notifier is stopped.
+var ErrNotifierStopped = errors.New("event notifier stopped")
+
+// EventNotifier holds the single database connection of the process listening to event notifications,
+// and fans them out to every subscriber.
+// Where LISTEN is not available, such as behind a connection pooler in transaction mode or with SQLite,
+// the notifier polls the events table instead.
+//
+// A notification of an event is a high-water mark: since events are inserted under a table lock,
+// every event positioned before the notified one is already committed.
+// This allows subscribers to skip intermediate notifications and only act on the latest one.
+type EventNotifier struct {
+	dbURL      string
+	events     eventPositionReader
+	bufferSize int
+	// minPollInterval and maxPollInterval bound the delay between two polls, they are only used when polling
+	minPollInterval time.Duration
+	maxPollInterval time.Duration
+	// watch sends notifications to the subscribers until an error occurs
+	watch       func(context.Context) error
+	mu          sync.Mutex
+	subscribers map[*EventSubscription]struct{}
+	// latest is the position of the last event notified by channel, and lastPosition the greatest of them
+	latest       map[string]int64
+	lastPosition int64
+	done         chan struct{}
+	stopOnce     sync.Once
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+}
+
+// NewEventNotifier returns a notifier listening to event notifications on a dedicated connection to dbURL.
+// Its subscribers can hold up to bufferSize pending notifications.
+func NewEventNotifier(dbURL string, bufferSize int) *EventNotifier {
+	n := newEventNotifier(bufferSize)
+	n.dbURL = dbURL
+	n.watch = n.listen
+
+	return n
+}
+
+// NewPollingEventNotifier returns a notifier polling the events table with conn, it doesn't rely on LISTEN.
+// The delay between two polls doubles from minInterval up to maxInterval as long as there is no new event.
+// Its subscribers can hold up to bufferSize pending notifications.
+func NewPollingEventNotifier(conn Conn, bufferSize int, minInterval, maxInterval time.Duration) *EventNotifier {
+	return newPollingEventNotifier(&pgEventPositionReader{conn: conn}, bufferSize, minInterval, maxInterval)
+}
+
+// NewSQLiteEventNotifier returns a notifier polling the events table of a SQLite database,
+// see NewPollingEventNotifier.
+func NewSQLiteEventNotifier(db *SQLiteDatabase, bufferSize int, minInterval, maxInterval time.Duration) *EventNotifier {
+	return newPollingEventNotifier(db, bufferSize, minInterval, maxInterval)
+}
+
+// NewMemoryEventNotifier returns a notifier polling the events of an in-memory database,
+// see NewPollingEventNotifier.
+func NewMemoryEventNotifier(db *MemoryDatabase, bufferSize int, minInterval, maxInterval time.Duration) *EventNotifier {
+	return newPollingEventNotifier(db, bufferSize, minInterval, maxInterval)
+}
+
+func newPollingEventNotifier(events eventPositionReader, bufferSize int, minInterval, maxInterval time.Duration) *EventNotifier {
+	n := newEventNotifier(bufferSize)
+	n.events = events
+	n.minPollInterval = minInterval
+	n.maxPollInterval = maxInterval
+	if n.maxPollInterval < n.minPollInterval {
+		n.maxPollInterval = n.minPollInterval
+	}
+	n.watch = n.poll
+
+	return n
+}
+
+func newEventNotifier(bufferSize int) *EventNotifier {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+
+	return &EventNotifier{
+		bufferSize:  bufferSize,
+		subscribers: make(map[*EventSubscription]struct{}),
+		latest:      make(map[string]int64),
+		done:        make(chan struct{}),
+	}
+}
+
+// Start watches new events in background until Stop is called.
+// The connection is reopened whenever it is lost.
+func (n *EventNotifier) Start(ctx context.Context) {
+	ctx, n.cancel = context.WithCancel(ctx)
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+
+		for {
+			err := n.watch(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Error().Err(err).Msg("lost event notifications, retrying")
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(notifierReconnectDelay):
+			}
+		}
+	}()
+}
+
+// Stop closes the listening connection, subscribers waiting for a notification receive ErrNotifierStopped.
+func (n *EventNotifier) Stop() {
+	if n.cancel != nil {
+		n.cancel()
+	}
+	n.wg.Wait()
+	n.stopOnce.Do(func() { close(n.done) })
+}
+
+// listen opens a connection, listens to event notifications and dispatches them until an error occurs.
+func (n *EventNotifier) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, n.dbURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, `LISTEN events`)
+	if err != nil {
+		return err
+	}
+
+	// Notifications sent while not listening are lost:
+	// catch every subscriber up with the last existing event.
+	var position int64
+	err = conn.QueryRow(ctx, `SELECT COALESCE(MAX(position), 0) FROM events`).Scan(&position)
+	if err != nil {
+		return err
+	}
+	n.resync(position)
+
+	for {
+		notif, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		n.dispatch(notif)
+	}
+}
+
+// poll looks for new events at an adaptive interval and notifies the subscribers until an error occurs.
+func (n *EventNotifier) poll(ctx context.Context) error {
+	position, err := n.events.lastEventPosition(ctx)
+	if err != nil {
+		return err
+	}
+	// Events may have been created while not polling
+	n.resync(position)
+
+	interval := n.minPollInterval
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		latest, err := n.pollEvents(ctx, position)
+		if err != nil {
+			return err
+		}
+
+		interval = nextPollInterval(interval, latest > position, n.minPollInterval, n.maxPollInterval)
+		if latest > position {
+			position = latest
+		}
+	}
+}
+
+// pollEvents notifies the subscribers of the last event of each channel positioned after the given position,
+// and returns the position of the last event.
+func (n *EventNotifier) pollEvents(ctx context.Context, afterPosition int64) (int64, error) {
+	if !n.hasSubscribers() {
+		return afterPosition, nil
+	}
+
+	notifs, err := n.events.lastChannelEvents(ctx, afterPosition)
+	if err != nil {
+		return 0, err
+	}
+
+	latest := afterPosition
+	for _, notif := range notifs {
+		pgNotif, err := newPgNotification(notif)
+		if err != nil {
+			return 0, err
+		}
+		n.dispatch(pgNotif)
+
+		if notif.EventPosition > latest {
+			latest = notif.EventPosition
+		}
+	}
+
+	return latest, nil
+}
+
+// eventPositionReader reads the positions of the events polled by the notifier.
+type eventPositionReader interface {
+	// lastEventPosition returns the position of the last event of any channel, or 0 if there is none.
+	lastEventPosition(ctx context.Context) (int64, error)
+	// lastChannelEvents returns the position of the last event of each channel positioned after the given position.
+	lastChannelEvents(ctx context.Context, afterPosition int64) ([]*eventNotification, error)
+}
+
+// pgEventPositionReader reads event positions from PostgreSQL.
+type pgEventPositionReader struct {
+	conn Conn
+}
+
+func (r *pgEventPositionReader) lastEventPosition(ctx context.Context) (int64, error) {
+	var position int64
+	err := r.conn.QueryRow(ctx, `SELECT COALESCE(MAX(position), 0) FROM events`).Scan(&position)
+
+	return position, err
+}
+
+func (r *pgEventPositionReader) lastChannelEvents(ctx context.Context, afterPosition int64) ([]*eventNotification, error) {
+	rows, err := r.conn.Query(ctx, `SELECT channel, MAX(position) FROM events WHERE position > $1 GROUP BY channel`, afterPosition)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifs := []*eventNotification{}
+	for rows.Next() {
+		notif := new(eventNotification)
+		if err := rows.Scan(&notif.Channel, &notif.EventPosition); err != nil {
+			return nil, err
+		}
+		notifs = append(notifs, notif)
+	}
+
+	return notifs, rows.Err()
+}
+
+// nextPollInterval resets the interval when new events were found, otherwise it doubles it up to maxInterval.
+func nextPollInterval(current time.Duration, found bool, minInterval, maxInterval time.Duration) time.Duration {
+	if found {
+		return minInterval
+	}
+
+	next := current * 2
+	if next > maxInterval {
+		return maxInterval
+	}
+
+	return next
+}
+
+func (n *EventNotifier) hasSubscribers() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return len(n.subscribers) > 0
+}
+
+// Subscribe returns a subscription receiving the notifications of events of the given channels.
+// The subscription must be closed once done.
+func (n *EventNotifier) Subscribe(channels ...string) *EventSubscription {
+	s := &EventSubscription{
+		notifier:      n,
+		channels:      make(map[string]struct{}, len(channels)),
+		notifications: make(chan subscriptionNotification, n.bufferSize),
+	}
+	for _, channel := range channels {
+		s.channels[channel] = struct{}{}
+	}
+
+	n.mu.Lock()
+	// Events notified before the subscription are not expected to be processed by the subscriber
+	s.processed.Store(n.lastPosition)
+	n.subscribers[s] = struct{}{}
+	n.mu.Unlock()
+	metrics.EventSubscribers.Inc()
+
+	return s
+}
+
+// dispatch forwards a notification to the subscribers of its channel.
+func (n *EventNotifier) dispatch(notif *pgconn.Notification) {
+	payload := new(eventNotification)
+	err := json.Unmarshal([]byte(notif.Payload), payload)
+	if err != nil {
+		log.Error().Err(err).Str("payload", notif.Payload).Msg("invalid event notification")
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.notified(payload.Channel, payload.EventPosition)
+
+	for s := range n.subscribers {
+		if _, ok := s.channels[payload.Channel]; ok {
+			s.push(notif, payload.EventPosition, n.latest[payload.Channel])
+		}
+	}
+}
+
+// notified records the position of the last event notified on the channel.
+// It must be called with the lock held.
+func (n *EventNotifier) notified(channel string, position int64) {
+	if position > n.latest[channel] {
+		n.latest[channel] = position
+	}
+	if position > n.lastPosition {
+		n.lastPosition = position
+	}
+}
+
+// resync notifies every subscriber of the given position on each of its channels.
+func (n *EventNotifier) resync(position int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if position > n.lastPosition {
+		n.lastPosition = position
+	}
+
+	for s := range n.subscribers {
+		for channel := range s.channels {
+			notif, err := newPgNotification(&eventNotification{EventPosition: position, Channel: channel})
+			if err != nil {
+				log.Error().Err(err).Msg("failed to build event notification")
+				continue
+			}
+			n.notified(channel, position)
+			s.push(notif, position, n.latest[channel])
+		}
+	}
+}
+
+// newPgNotification returns a notification identical to the ones sent on event insertion.
+func newPgNotification(notif *eventNotification) (*pgconn.Notification, error) {
+	payload, err := json.Marshal(notif)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pgconn.Notification{Channel: "events", Payload: string(payload)}, nil
+}
+
+func (n *EventNotifier) unsubscribe(s *EventSubscription) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.subscribers[s]; ok {
+		delete(n.subscribers, s)
+		metrics.EventSubscribers.Dec()
+	}
+}
+
+// EventSubscription receives the notifications of an EventNotifier, it implements Listener.
+type EventSubscription struct {
+	notifier      *EventNotifier
+	channels      map[string]struct{}
+	notifications chan subscriptionNotification
+	// processed is the position of the last notification processed by the subscriber,
+	// it is written by the subscriber and read by the dispatching goroutine
+	processed atomic.Int64
+	// received is the position of the last notification returned to the subscriber, see WaitForNotification
+	received int64
+}
+
+// subscriptionNotification is a notification pending in the buffer of a subscription, along with its event position.
+type subscriptionNotification struct {
+	notif    *pgconn.Notification
+	position int64
+}
+
+// push queues a notification of the given event position, dropping the oldest pending one if the buffer is full.
+// Since notifications are high-water marks, dropping older ones doesn't lose any event.
+// latest is the position of the last event notified on the channel, it is used to measure the lag of the subscriber.
+// It must only be called by the dispatching goroutine.
+func (s *EventSubscription) push(notif *pgconn.Notification, position int64, latest int64) {
+	metrics.EventSubscriberBufferedNotifications.Observe(float64(len(s.notifications)))
+	metrics.EventSubscriberLag.Observe(float64(s.lag(latest)))
+
+	for {
+		select {
+		case s.notifications <- subscriptionNotification{notif: notif, position: position}:
+			return
+		default:
+		}
+
+		select {
+		case <-s.notifications:
+			metrics.EventNotificationDroppedTotal.Inc()
+		default:
+		}
+	}
+}
+
+// lag returns the number of event positions between the given one and the last one processed by the subscriber.
+func (s *EventSubscription) lag(latest int64) int64 {
+	lag := latest - s.processed.Load()
+	if lag < 0 {
+		return 0
+	}
+
+	return lag
+}
+
+// WaitForNotification blocks until a notification is received, the context is done or the notifier is stopped.
+// Waiting for a notification means that the previously returned one has been processed.
+func (s *EventSubscription) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	if s.received > s.processed.Load() {
+		s.processed.Store(s.received)
+	}
+
+	select {
+	case pending := <-s.notifications:
+		s.received = pending.position
+		return pending.notif, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-s.notifier.done:
+		return nil, ErrNotifierStopped
+	}
+}
+
+// Close stops the delivery of notifications to the subscription.
+func (s *EventSubscription) Close() {
+	s.notifier.unsubscribe(s)
+}
@@ -0,0 +1,169 @@
+package dbal
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/utils"
+)
+
+func receiveNotification(t *testing.T, s *EventSubscription) *eventNotification {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	d := &DBAL{ctx: ctx, listener: s, channel: testChannel}
+	notif, err := d.waitForEventNotification()
+	require.NoError(t, err)
+
+	return notif
+}
+
+func TestEventNotifierDispatch(t *testing.T) {
+	notifier := NewEventNotifier("", 10)
+
+	sub := notifier.Subscribe(testChannel)
+	defer sub.Close()
+	other := notifier.Subscribe("otherchannel")
+	defer other.Close()
+
+	pgNotif, err := getPgNotificationFrom(&eventNotification{EventPosition: 12, Channel: testChannel})
+	require.NoError(t, err)
+	notifier.dispatch(pgNotif)
+
+	assert.Equal(t, &eventNotification{EventPosition: 12, Channel: testChannel}, receiveNotification(t, sub))
+	assert.Len(t, other.notifications, 0, "notification of another channel should not be dispatched")
+}
+
+func TestEventNotifierDropOldest(t *testing.T) {
+	notifier := NewEventNotifier("", 2)
+
+	sub := notifier.Subscribe(testChannel)
+	defer sub.Close()
+
+	for _, position := range []int64{1, 2, 3} {
+		pgNotif, err := getPgNotificationFrom(&eventNotification{EventPosition: position, Channel: testChannel})
+		require.NoError(t, err)
+		notifier.dispatch(pgNotif)
+	}
+
+	assert.Equal(t, int64(2), receiveNotification(t, sub).EventPosition)
+	assert.Equal(t, int64(3), receiveNotification(t, sub).EventPosition)
+}
+
+func TestEventNotifierResync(t *testing.T) {
+	notifier := NewEventNotifier("", 10)
+
+	sub := notifier.Subscribe(testChannel, "otherchannel")
+	defer sub.Close()
+
+	notifier.resync(42)
+
+	received := map[string]int64{}
+	for i := 0; i < 2; i++ {
+		notif := receiveNotification(t, sub)
+		received[notif.Channel] = notif.EventPosition
+	}
+	assert.Equal(t, map[string]int64{testChannel: 42, "otherchannel": 42}, received)
+}
+
+func TestEventSubscriptionLag(t *testing.T) {
+	notifier := NewEventNotifier("", 10)
+
+	pgNotif, err := getPgNotificationFrom(&eventNotification{EventPosition: 5, Channel: "otherchannel"})
+	require.NoError(t, err)
+	notifier.dispatch(pgNotif)
+
+	sub := notifier.Subscribe(testChannel)
+	defer sub.Close()
+	assert.Equal(t, int64(0), sub.lag(5), "events notified before the subscription should not be lagging")
+
+	for _, position := range []int64{12, 15} {
+		pgNotif, err := getPgNotificationFrom(&eventNotification{EventPosition: position, Channel: testChannel})
+		require.NoError(t, err)
+		notifier.dispatch(pgNotif)
+	}
+	assert.Equal(t, int64(15), notifier.latest[testChannel])
+	assert.Equal(t, int64(10), sub.lag(notifier.latest[testChannel]))
+
+	// The first notification is processed once the subscriber waits for the next one
+	assert.Equal(t, int64(12), receiveNotification(t, sub).EventPosition)
+	assert.Equal(t, int64(10), sub.lag(notifier.latest[testChannel]))
+	assert.Equal(t, int64(15), receiveNotification(t, sub).EventPosition)
+	assert.Equal(t, int64(3), sub.lag(notifier.latest[testChannel]))
+}
+
+func TestEventSubscriptionClose(t *testing.T) {
+	notifier := NewEventNotifier("", 10)
+
+	sub := notifier.Subscribe(testChannel)
+	sub.Close()
+
+	pgNotif, err := getPgNotificationFrom(&eventNotification{EventPosition: 12, Channel: testChannel})
+	require.NoError(t, err)
+	notifier.dispatch(pgNotif)
+
+	assert.Len(t, sub.notifications, 0)
+	assert.Empty(t, notifier.subscribers)
+}
+
+func TestEventSubscriptionNotifierStopped(t *testing.T) {
+	notifier := NewEventNotifier("", 10)
+
+	sub := notifier.Subscribe(testChannel)
+	defer sub.Close()
+
+	notifier.Stop()
+
+	_, err := sub.WaitForNotification(context.Background())
+	assert.ErrorIs(t, err, ErrNotifierStopped)
+}
+
+func TestPollEvents(t *testing.T) {
+	conn, err := utils.NewMockConn()
+	require.NoError(t, err)
+
+	conn.ExpectQuery(regexp.QuoteMeta(`SELECT channel, MAX(position) FROM events WHERE position > $1 GROUP BY channel`)).
+		WithArgs(int64(10)).
+		WillReturnRows(pgxmock.NewRows([]string{"channel", "max"}).
+			AddRow(testChannel, int64(14)).
+			AddRow("otherchannel", int64(17)))
+
+	notifier := NewPollingEventNotifier(conn, 10, time.Millisecond, time.Second)
+
+	sub := notifier.Subscribe(testChannel)
+	defer sub.Close()
+
+	latest, err := notifier.pollEvents(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(17), latest)
+
+	assert.Equal(t, &eventNotification{EventPosition: 14, Channel: testChannel}, receiveNotification(t, sub))
+	assert.Len(t, sub.notifications, 0)
+	assert.NoError(t, conn.ExpectationsWereMet())
+}
+
+func TestPollEventsWithoutSubscriber(t *testing.T) {
+	conn, err := utils.NewMockConn()
+	require.NoError(t, err)
+
+	notifier := NewPollingEventNotifier(conn, 10, time.Millisecond, time.Second)
+
+	latest, err := notifier.pollEvents(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), latest)
+	assert.NoError(t, conn.ExpectationsWereMet())
+}
+
+func TestNextPollInterval(t *testing.T) {
+	minInterval := 100 * time.Millisecond
+	maxInterval := time.Second
+
+	assert.Equal(t, minInterval, nextPollInterval(800*time.Millisecond, true, minInterval, maxInterval))
+	assert.Equal(t, 200*time.Millisecond, nextPollInterval(minInterval, false, minInterval, maxInterval))
+	assert.Equal(t, maxInterval, nextPollInterval(800*time.Millisecond, false, minInterval, maxInterval))
+}
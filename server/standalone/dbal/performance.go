@@ -1,7 +1,6 @@
 package dbal
 
 import (
-	"strconv"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -54,7 +53,12 @@ func (d *DBAL) PerformanceExists(perf *asset.Performance) (bool, error) {
 }
 
 func (d *DBAL) QueryPerformances(p *common.Pagination, filter *asset.PerformanceQueryFilter) ([]*asset.Performance, common.PaginationToken, error) {
-	offset, err := getOffset(p.Token)
+	// The output identifier makes the sort unique, so that performances can be used as cursors
+	pg, err := newPage(p, keyset{
+		{name: "creation_date"},
+		{name: "compute_task_key", desc: true},
+		{name: "compute_task_output_identifier"},
+	})
 	if err != nil {
 		return nil, "", err
 	}
@@ -62,11 +66,7 @@ func (d *DBAL) QueryPerformances(p *common.Pagination, filter *asset.Performance
 	stmt := getStatementBuilder().
 		Select("compute_task_key", "compute_task_output_identifier", "performance_value", "creation_date").
 		From("performances").
-		Where(sq.Eq{"channel": d.channel}).
-		OrderByClause("creation_date ASC, compute_task_key DESC").
-		Offset(uint64(offset)).
-		// Fetch page size + 1 elements to determine whether there is a next page
-		Limit(uint64(p.Size + 1))
+		Where(sq.Eq{"channel": d.channel})
 
 	if filter != nil {
 		if filter.ComputeTaskKey != "" {
@@ -78,6 +78,8 @@ func (d *DBAL) QueryPerformances(p *common.Pagination, filter *asset.Performance
 		}
 	}
 
+	stmt = pg.apply(stmt)
+
 	rows, err := d.query(stmt)
 	if err != nil {
 		return nil, "", err
@@ -86,6 +88,7 @@ func (d *DBAL) QueryPerformances(p *common.Pagination, filter *asset.Performance
 
 	var performances []*asset.Performance
 	var count int
+	var last *sqlPerformance
 
 	for rows.Next() {
 		perf := new(sqlPerformance)
@@ -96,6 +99,7 @@ func (d *DBAL) QueryPerformances(p *common.Pagination, filter *asset.Performance
 		}
 
 		performances = append(performances, perf.toPerformance())
+		last = perf
 		count++
 
 		if count == int(p.Size) {
@@ -109,7 +113,10 @@ func (d *DBAL) QueryPerformances(p *common.Pagination, filter *asset.Performance
 	bookmark := ""
 	if count == int(p.Size) && rows.Next() {
 		// there is more to fetch
-		bookmark = strconv.Itoa(offset + count)
+		bookmark, err = pg.nextToken(cursorTime(last.CreationDate), last.ComputeTaskKey, last.ComputeTaskOutputIdentifier)
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
 	return performances, bookmark, nil
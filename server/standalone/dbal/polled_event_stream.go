@@ -0,0 +1,149 @@
+package dbal
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// eventLog reads the events streamed by a polledEventStream.
+type eventLog interface {
+	// eventsAfter returns at most limit events of the given channels matching the filter,
+	// each one positioned after the position of its channel, in position order.
+	eventsAfter(channels []string, positions map[string]int64, filter *asset.EventQueryFilter, limit int) ([]positionedEvent, error)
+}
+
+// polledEventStream sends the events of several channels in position order, see channelSubscription.
+// It serves databases without notifications, whose new events are detected by a polling notifier.
+type polledEventStream struct {
+	ctx      context.Context
+	sub      *EventSubscription
+	events   eventLog
+	channels []string
+	// positions holds the position of the last processed event of each channel
+	positions map[string]int64
+	// notifiedPos is the position of the last notified event whose previous events have all been replayed
+	notifiedPos int64
+	filter      *asset.EventQueryFilter
+}
+
+func newPolledEventStream(ctx context.Context, sub *EventSubscription, events eventLog, channels []string, filter *asset.EventQueryFilter) *polledEventStream {
+	es := &polledEventStream{
+		ctx:       ctx,
+		sub:       sub,
+		events:    events,
+		channels:  channels,
+		positions: make(map[string]int64, len(channels)),
+		filter:    filter,
+	}
+	for _, channel := range channels {
+		es.positions[channel] = 0
+	}
+
+	return es
+}
+
+// run replays the existing events positioned after the cursor of each channel,
+// then it forwards newly created events until the context is done.
+func (s *polledEventStream) run(send func(*asset.Event) error) error {
+	err := s.replayEvents(send)
+	if err != nil {
+		return err
+	}
+
+	for {
+		if err = s.ctx.Err(); err != nil {
+			return err
+		}
+
+		err = s.forwardEventNotification(send)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// replayEvents sends every existing event of the subscribed channels positioned after their cursor.
+func (s *polledEventStream) replayEvents(send func(*asset.Event) error) error {
+	hasNextBatch := true
+	for hasNextBatch {
+		var err error
+		hasNextBatch, err = s.replayBatchOfEvents(send)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replayBatchOfEvents fetches a batch of already existing events of every channel and sends them with the provided function.
+func (s *polledEventStream) replayBatchOfEvents(send func(*asset.Event) error) (bool, error) {
+	// Fetch replayEventsBatchSize size + 1 elements to determine whether there is a next batch to fetch
+	events, err := s.events.eventsAfter(s.channels, s.positions, s.filter, replayEventsBatchSize+1)
+	if err != nil {
+		return false, err
+	}
+
+	events, hasNextBatch := splitPage(events, uint32(replayEventsBatchSize))
+	if len(events) == 0 {
+		return false, nil
+	}
+
+	for _, e := range events {
+		if err := send(e.event); err != nil {
+			return false, err
+		}
+	}
+
+	// Every matching event up to the last one has been sent, whatever its channel
+	lastPosition := events[len(events)-1].position
+	for _, channel := range s.channels {
+		if s.positions[channel] < lastPosition {
+			s.positions[channel] = lastPosition
+		}
+	}
+
+	return hasNextBatch, nil
+}
+
+// forwardEventNotification waits for the notification of a new event of a subscribed channel,
+// and then sends the events of every subscribed channel created since the last processed ones.
+// Write transactions being serialized, a notification guarantees that every previous event is visible.
+func (s *polledEventStream) forwardEventNotification(send func(*asset.Event) error) error {
+	pgNotif, err := s.sub.WaitForNotification(s.ctx)
+	if err != nil {
+		return err
+	}
+
+	notif := new(eventNotification)
+	if err := json.Unmarshal([]byte(pgNotif.Payload), notif); err != nil {
+		return err
+	}
+
+	if _, ok := s.positions[notif.Channel]; !ok {
+		return nil
+	}
+
+	// since events are inserted with a strictly increasing position value,
+	// this ensures that an already forwarded event cannot be sent again
+	if notif.EventPosition <= s.notifiedPos || notif.EventPosition <= s.positions[notif.Channel] {
+		return nil
+	}
+
+	err = s.replayEvents(send)
+	if err != nil {
+		return err
+	}
+
+	// events up to the notified one which have not been replayed do not match the filter
+	s.notifiedPos = notif.EventPosition
+	for _, channel := range s.channels {
+		if s.positions[channel] < notif.EventPosition {
+			s.positions[channel] = notif.EventPosition
+		}
+	}
+
+	return nil
+}
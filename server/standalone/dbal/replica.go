@@ -0,0 +1,93 @@
+package dbal
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/substra/orchestrator/server/standalone/metrics"
+)
+
+// replicaLagCheckInterval is how long a measure of the replication lag is reused before measuring it again.
+const replicaLagCheckInterval = time.Second
+
+// replicaLagTimeout bounds the measure of the replication lag, a replica which does not answer in time is considered late.
+const replicaLagTimeout = time.Second
+
+// replicaLagQuery returns whether the replica is streaming WAL from the primary, and the replication lag in seconds.
+// A replica which is not streaming is late, whatever its lag: it may have replayed every WAL record it received
+// while being disconnected from the primary.
+// A streaming replica which has replayed every received WAL record is up to date, whatever the age of the last replayed transaction:
+// otherwise an idle primary would make the replica look late.
+// Reading the status of the WAL receiver requires the pg_read_all_stats role.
+const replicaLagQuery = `SELECT EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming'),
+CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END`
+
+// Names of the pools in metrics
+const (
+	primaryPoolName = "primary"
+	replicaPoolName = "replica"
+)
+
+// replica is a PostgreSQL read replica serving read-only transactions as long as its replication lag is below maxLag.
+// The lag is measured in the background: transactions rely on the last measure and never wait for the replica to answer.
+type replica struct {
+	pool   PgPool
+	maxLag time.Duration
+	// now is overridden by tests
+	now func() time.Time
+
+	// checkedAt is the time of the last measure, in nanoseconds since the epoch
+	checkedAt atomic.Int64
+	measuring atomic.Bool
+	upToDate  atomic.Bool
+}
+
+func newReplica(pool PgPool, maxLag time.Duration) *replica {
+	return &replica{pool: pool, maxLag: maxLag, now: time.Now}
+}
+
+// isUpToDate returns whether the replication lag was acceptable when last measured.
+// The lag is measured again in the background when the last measure is older than replicaLagCheckInterval.
+// Until a first measure completes, the replica is considered late so that reads go to the primary.
+func (r *replica) isUpToDate(ctx context.Context) bool {
+	if r.now().Sub(time.Unix(0, r.checkedAt.Load())) >= replicaLagCheckInterval && r.measuring.CompareAndSwap(false, true) {
+		// The measure outlives the request: only its logger is kept
+		measureCtx := log.Ctx(ctx).WithContext(context.Background())
+		go func() {
+			defer r.measuring.Store(false)
+			r.measure(measureCtx)
+		}()
+	}
+
+	return r.upToDate.Load()
+}
+
+// measure queries the replication lag and records whether it is acceptable.
+// An unreachable replica is considered late, so that reads fall back to the primary.
+func (r *replica) measure(ctx context.Context) {
+	r.checkedAt.Store(r.now().UnixNano())
+
+	ctx, cancel := context.WithTimeout(ctx, replicaLagTimeout)
+	defer cancel()
+
+	var streaming bool
+	var seconds float64
+	err := r.pool.QueryRow(ctx, replicaLagQuery).Scan(&streaming, &seconds)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("failed to measure the replication lag, reading from the primary")
+		r.upToDate.Store(false)
+		return
+	}
+
+	metrics.DBReplicaLag.Set(seconds)
+	lag := time.Duration(seconds * float64(time.Second))
+	upToDate := streaming && lag <= r.maxLag
+
+	if !upToDate && r.upToDate.Load() {
+		log.Ctx(ctx).Warn().Bool("streaming", streaming).Dur("lag", lag).Dur("maxLag", r.maxLag).Msg("replica is late, reading from the primary")
+	}
+	r.upToDate.Store(upToDate)
+}
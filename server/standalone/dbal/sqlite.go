@@ -0,0 +1,348 @@
+package dbal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog/log"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"github.com/substra/orchestrator/lib/persistence"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLiteDatabaseScheme prefixes the urls of SQLite databases, followed by the path of the database file,
+// e.g. sqlite:///var/lib/orchestrator/orchestrator.db
+const SQLiteDatabaseScheme = "sqlite://"
+
+// sqliteBusyTimeout is how long a transaction waits for the lock held by another write transaction.
+// Once expired, the transaction fails with SQLITE_BUSY and is retried, see IsSQLiteBusy.
+const sqliteBusyTimeout = 5 * time.Second
+
+// sqliteTimeFormat is the format of the timestamps stored in SQLite text columns.
+// Its fixed width makes the lexicographic order of the columns match the chronological order.
+const sqliteTimeFormat = "2006-01-02T15:04:05.000000Z"
+
+var _ persistence.DBAL = (*SQLiteDBAL)(nil)
+
+// IsSQLiteDatabaseURL returns true if the url designates a SQLite database.
+func IsSQLiteDatabaseURL(databaseURL string) bool {
+	return strings.HasPrefix(databaseURL, SQLiteDatabaseScheme)
+}
+
+// SQLiteDatabase stores assets in a SQLite database file.
+// It is meant for single-node deployments such as demos, CI, or labs with a single organization.
+type SQLiteDatabase struct {
+	db *sql.DB
+}
+
+// InitSQLiteDatabase opens the SQLite database designated by the url, creating the file if needed,
+// and applies the migrations which have not been applied yet.
+func InitSQLiteDatabase(databaseURL string) (*SQLiteDatabase, error) {
+	path := strings.TrimPrefix(databaseURL, SQLiteDatabaseScheme)
+	if path == "" || strings.Contains(path, "?") {
+		return nil, orcerrors.NewBadRequest("invalid SQLite database url, expected sqlite://<path>")
+	}
+
+	// Write transactions take the database lock when they begin rather than on their first write:
+	// this way concurrent write transactions wait for each other instead of failing on commit.
+	params := url.Values{}
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", sqliteBusyTimeout.Milliseconds()))
+	params.Add("_txlock", "immediate")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrateSQLite(context.Background(), db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteDatabase{db: db}, nil
+}
+
+// Close the database
+func (d *SQLiteDatabase) Close() {
+	d.db.Close()
+}
+
+// BeginDBAL returns a DBAL bound to a new transaction on the given channel.
+// SQLite transactions are serializable: write transactions are executed one at a time.
+func (d *SQLiteDatabase) BeginDBAL(ctx context.Context, channel string, readOnly bool) (Transaction, error) {
+	log.Ctx(ctx).Debug().Bool("ReadOnly", readOnly).Msg("new SQLite transaction")
+
+	tx, err := d.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: readOnly})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSQLite(ctx, tx, channel), nil
+}
+
+// IsSQLiteBusy returns true if the error is caused by another transaction holding the database lock.
+// Like serialization failures in PostgreSQL, the failed transaction can be retried.
+func IsSQLiteBusy(err error) bool {
+	sqliteErr := new(sqlite.Error)
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	// Extended result codes hold the primary result code in their least significant byte
+	code := sqliteErr.Code() & 0xff
+
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
+
+// sqliteQuerier is implemented by both sql.DB and sql.Tx.
+type sqliteQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// SQLiteDBAL is the Database Abstraction Layer around asset storage in SQLite.
+// Assets are stored in their protojson representation,
+// along with copies of the fields used to filter and sort them.
+type SQLiteDBAL struct {
+	ctx     context.Context
+	db      sqliteQuerier
+	tx      *sql.Tx
+	channel string
+}
+
+// NewSQLite returns a DBAL of the given channel operating in the transaction tx.
+func NewSQLite(ctx context.Context, tx *sql.Tx, channel string) *SQLiteDBAL {
+	return &SQLiteDBAL{ctx: ctx, db: tx, tx: tx, channel: channel}
+}
+
+// Commit persists the changes made through the DBAL.
+func (d *SQLiteDBAL) Commit(_ context.Context) error {
+	return d.tx.Commit()
+}
+
+// Rollback discards the changes made through the DBAL.
+func (d *SQLiteDBAL) Rollback(_ context.Context) error {
+	return d.tx.Rollback()
+}
+
+func getSQLiteStatementBuilder() sq.StatementBuilderType {
+	return sq.StatementBuilder.PlaceholderFormat(sq.Question)
+}
+
+func (d *SQLiteDBAL) query(builder sq.Sqlizer) (*sql.Rows, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return d.db.QueryContext(d.ctx, query, args...)
+}
+
+func (d *SQLiteDBAL) queryRow(builder sq.Sqlizer) (*sql.Row, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return d.db.QueryRowContext(d.ctx, query, args...), nil
+}
+
+func (d *SQLiteDBAL) exec(builder sq.Sqlizer) error {
+	_, err := d.execCount(builder)
+	return err
+}
+
+// execCount executes the statement and returns the number of affected rows.
+func (d *SQLiteDBAL) execCount(builder sq.Sqlizer) (uint32, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+	res, err := d.db.ExecContext(d.ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	count, err := res.RowsAffected()
+	return uint32(count), err
+}
+
+// exists returns whether the statement selects at least one row.
+func (d *SQLiteDBAL) exists(stmt sq.SelectBuilder) (bool, error) {
+	row, err := d.queryRow(getSQLiteStatementBuilder().Select().Column(sq.Expr("EXISTS(?)", stmt)))
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err = row.Scan(&exists)
+
+	return exists, err
+}
+
+// sqliteTime formats a timestamp to be stored in a text column, see sqliteTimeFormat.
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeFormat)
+}
+
+// nullSQLiteTime returns a nullable text column value of the timestamp.
+func nullSQLiteTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: sqliteTime(*t), Valid: true}
+}
+
+// nullSQLiteTimestamp returns a nullable text column value of the timestamp.
+func nullSQLiteTimestamp(ts *timestamppb.Timestamp) sql.NullString {
+	if ts == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: sqliteTime(ts.AsTime()), Valid: true}
+}
+
+func parseSQLiteTime(s string) (time.Time, error) {
+	return time.Parse(sqliteTimeFormat, s)
+}
+
+// jsonTime formats a timestamp to be set in the protojson representation of an asset.
+func jsonTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// protoMessage is the type of pointers to proto messages, it allows to allocate messages in generic functions.
+type protoMessage[T any] interface {
+	*T
+	proto.Message
+}
+
+// marshalAsset returns the protojson representation of the asset stored in the asset column.
+func marshalAsset(m proto.Message) (string, error) {
+	data, err := protojson.Marshal(m)
+	return string(data), err
+}
+
+func unmarshalAsset[T any, PT protoMessage[T]](data string) (PT, error) {
+	m := PT(new(T))
+	if err := protojson.Unmarshal([]byte(data), m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// getAsset returns the asset selected by stmt, or a not found error if there is none.
+// The statement should select the asset column only.
+func getAsset[T any, PT protoMessage[T]](d *SQLiteDBAL, stmt sq.SelectBuilder, kind string, key string) (PT, error) {
+	row, err := d.queryRow(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	var data string
+	err = row.Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, orcerrors.NewNotFound(kind, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalAsset[T, PT](data)
+}
+
+// queryAssets returns the assets selected by stmt, the statement should select the asset column only.
+func queryAssets[T any, PT protoMessage[T]](d *SQLiteDBAL, stmt sq.SelectBuilder) ([]PT, error) {
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assets := []PT{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		m, err := unmarshalAsset[T, PT](data)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, m)
+	}
+
+	return assets, rows.Err()
+}
+
+// queryAssetPage returns a page of the assets selected by stmt, sorted by the keyset.
+// cursorValues returns the values of the keyset columns of an asset, they are used to build the next page token.
+func queryAssetPage[T any, PT protoMessage[T]](
+	d *SQLiteDBAL,
+	stmt sq.SelectBuilder,
+	p *common.Pagination,
+	ks keyset,
+	cursorValues func(PT) []string,
+) ([]PT, common.PaginationToken, error) {
+	pg, err := newPage(p, ks)
+	if err != nil {
+		return nil, "", err
+	}
+
+	assets, err := queryAssets[T, PT](d, pg.apply(stmt))
+	if err != nil {
+		return nil, "", err
+	}
+
+	assets, hasNext := splitPage(assets, p.Size)
+	if !hasNext {
+		return assets, "", nil
+	}
+
+	token, err := pg.nextToken(cursorValues(assets[len(assets)-1])...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return assets, token, nil
+}
+
+// splitPage trims the rows fetched for a page of the given size, see page.apply,
+// and returns whether there is a next page.
+func splitPage[T any](rows []T, size uint32) ([]T, bool) {
+	if len(rows) <= int(size) || size == 0 {
+		return rows[:min(len(rows), int(size))], false
+	}
+
+	return rows[:size], true
+}
+
+// queryStrings returns the values of the single text column selected by stmt.
+func (d *SQLiteDBAL) queryStrings(stmt sq.SelectBuilder) ([]string, error) {
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
@@ -0,0 +1,254 @@
+package dbal
+
+import (
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toSQLiteComputePlan returns a stored compute plan as returned by the DBAL:
+// like with PostgreSQL, the failure date of a canceled plan is not returned.
+func toSQLiteComputePlan(plan *asset.ComputePlan) *asset.ComputePlan {
+	if plan.CancelationDate != nil {
+		plan.FailureDate = nil
+	}
+	return plan
+}
+
+// ComputePlanExists implements persistence.ComputePlanDBAL
+func (d *SQLiteDBAL) ComputePlanExists(key string) (bool, error) {
+	return d.exists(getSQLiteStatementBuilder().
+		Select("key").
+		From("compute_plans").
+		Where(sq.Eq{"channel": d.channel, "key": key}))
+}
+
+// AddComputePlan implements persistence.ComputePlanDBAL
+// Only the immutable fields are stored, dates are set by the update methods.
+func (d *SQLiteDBAL) AddComputePlan(plan *asset.ComputePlan) error {
+	data, err := marshalAsset(&asset.ComputePlan{
+		Key:             plan.Key,
+		Owner:           plan.Owner,
+		CreationDate:    plan.CreationDate,
+		Tag:             plan.Tag,
+		Name:            plan.Name,
+		Metadata:        plan.Metadata,
+		MaxTaskAttempts: plan.MaxTaskAttempts,
+	})
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Insert("compute_plans").
+		Columns("key", "channel", "owner", "creation_date", "asset").
+		Values(plan.Key, d.channel, plan.Owner, sqliteTime(plan.CreationDate.AsTime()), data)
+
+	return d.exec(stmt)
+}
+
+// GetComputePlan implements persistence.ComputePlanDBAL
+func (d *SQLiteDBAL) GetComputePlan(key string) (*asset.ComputePlan, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("compute_plans").
+		Where(sq.Eq{"channel": d.channel, "key": key})
+
+	plan, err := getAsset[asset.ComputePlan](d, stmt, "computeplan", key)
+	if err != nil {
+		return nil, err
+	}
+
+	return toSQLiteComputePlan(plan), nil
+}
+
+// QueryComputePlans implements persistence.ComputePlanDBAL
+func (d *SQLiteDBAL) QueryComputePlans(p *common.Pagination, filter *asset.PlanQueryFilter) ([]*asset.ComputePlan, common.PaginationToken, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("compute_plans").
+		Where(sq.Eq{"channel": d.channel})
+
+	if filter != nil && filter.Owner != "" {
+		stmt = stmt.Where(sq.Eq{"owner": filter.Owner})
+	}
+
+	plans, token, err := queryAssetPage(d, stmt, p, newKeyset(false, "creation_date", "key"), func(plan *asset.ComputePlan) []string {
+		return []string{sqliteTime(plan.CreationDate.AsTime()), plan.Key}
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, plan := range plans {
+		toSQLiteComputePlan(plan)
+	}
+
+	return plans, token, nil
+}
+
+// setComputePlanDate sets a date of the plan, both in its asset and in the column of the same name if any.
+func (d *SQLiteDBAL) setComputePlanDate(key string, field string, column string, date *time.Time) error {
+	stmt := getSQLiteStatementBuilder().
+		Update("compute_plans").
+		Where(sq.Eq{"channel": d.channel, "key": key})
+
+	if date == nil {
+		stmt = stmt.Set("asset", sq.Expr("json_remove(asset, '$."+field+"')"))
+	} else {
+		stmt = stmt.Set("asset", sq.Expr("json_set(asset, '$."+field+"', ?)", jsonTime(*date)))
+	}
+	if column != "" {
+		stmt = stmt.Set(column, nullSQLiteTime(date))
+	}
+
+	return d.exec(stmt)
+}
+
+// SetComputePlanName implements persistence.ComputePlanDBAL
+func (d *SQLiteDBAL) SetComputePlanName(plan *asset.ComputePlan, name string) error {
+	stmt := getSQLiteStatementBuilder().
+		Update("compute_plans").
+		Set("asset", sq.Expr("json_set(asset, '$.name', ?)", name)).
+		Where(sq.Eq{"channel": d.channel, "key": plan.Key})
+
+	return d.exec(stmt)
+}
+
+// CancelComputePlan implements persistence.ComputePlanDBAL
+func (d *SQLiteDBAL) CancelComputePlan(plan *asset.ComputePlan, cancelationDate time.Time) error {
+	return d.setComputePlanDate(plan.Key, "cancelationDate", "cancelation_date", &cancelationDate)
+}
+
+// FailComputePlan implements persistence.ComputePlanDBAL
+func (d *SQLiteDBAL) FailComputePlan(plan *asset.ComputePlan, failureDate time.Time) error {
+	return d.setComputePlanDate(plan.Key, "failureDate", "failure_date", &failureDate)
+}
+
+// RestoreComputePlan implements persistence.ComputePlanDBAL
+func (d *SQLiteDBAL) RestoreComputePlan(plan *asset.ComputePlan) error {
+	return d.setComputePlanDate(plan.Key, "failureDate", "failure_date", nil)
+}
+
+// PauseComputePlan implements persistence.ComputePlanDBAL
+func (d *SQLiteDBAL) PauseComputePlan(plan *asset.ComputePlan, pauseDate time.Time) error {
+	return d.setComputePlanDate(plan.Key, "pauseDate", "", &pauseDate)
+}
+
+// ResumeComputePlan implements persistence.ComputePlanDBAL
+func (d *SQLiteDBAL) ResumeComputePlan(plan *asset.ComputePlan, resumeDate time.Time) error {
+	return d.setComputePlanDate(plan.Key, "resumeDate", "", &resumeDate)
+}
+
+// ArePlanTasksRunning implements persistence.ComputePlanDBAL
+// A plan having a canceled or failed task is not running.
+func (d *SQLiteDBAL) ArePlanTasksRunning(key string) (bool, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select().
+		Column(sq.Expr("COUNT(*) FILTER (WHERE status IN (?, ?))",
+			asset.ComputeTaskStatus_STATUS_CANCELED.String(), asset.ComputeTaskStatus_STATUS_FAILED.String())).
+		Column(sq.Expr("COUNT(*) FILTER (WHERE status != ?)", asset.ComputeTaskStatus_STATUS_DONE.String())).
+		From("compute_tasks").
+		Where(sq.Eq{"channel": d.channel, "compute_plan_key": key})
+
+	row, err := d.queryRow(stmt)
+	if err != nil {
+		return false, err
+	}
+
+	var stopped, notDone int
+	if err := row.Scan(&stopped, &notDone); err != nil {
+		return false, err
+	}
+
+	return stopped == 0 && notDone > 0, nil
+}
+
+// GetComputePlanStatistics implements persistence.ComputePlanDBAL
+// The first task start and the last task completion come from the task status update events.
+func (d *SQLiteDBAL) GetComputePlanStatistics(key string) (*asset.ComputePlanStatistics, error) {
+	stats := &asset.ComputePlanStatistics{
+		ComputePlanKey: key,
+		TaskCounts:     []*asset.TaskStatusCount{},
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Select("status", "COUNT(1)").
+		From("compute_tasks").
+		Where(sq.Eq{"channel": d.channel, "compute_plan_key": key}).
+		GroupBy("status").
+		OrderBy("status")
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status string
+		var count uint32
+
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+
+		stats.TaskCounts = append(stats.TaskCounts, &asset.TaskStatusCount{
+			Status: asset.ComputeTaskStatus(asset.ComputeTaskStatus_value[status]),
+			Count:  count,
+		})
+		stats.TaskCount += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	eventsStmt := getSQLiteStatementBuilder().
+		Select().
+		Column(sq.Expr("MIN(timestamp) FILTER (WHERE json_extract(asset, '$.status') = ?)", asset.ComputeTaskStatus_STATUS_EXECUTING.String())).
+		Column(sq.Expr("MAX(timestamp) FILTER (WHERE json_extract(asset, '$.status') = ?)", asset.ComputeTaskStatus_STATUS_DONE.String())).
+		From("events").
+		Where(sq.Eq{
+			"channel":    d.channel,
+			"asset_kind": asset.AssetKind_ASSET_COMPUTE_TASK.String(),
+			"event_kind": asset.EventKind_EVENT_ASSET_UPDATED.String(),
+		}).
+		Where(sq.Expr("json_extract(asset, '$.computePlanKey') = ?", key))
+
+	row, err := d.queryRow(eventsStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	var firstStart, lastCompletion sql.NullString
+	if err := row.Scan(&firstStart, &lastCompletion); err != nil {
+		return nil, err
+	}
+
+	if stats.FirstTaskStartDate, err = nullTimestamp(firstStart); err != nil {
+		return nil, err
+	}
+	if stats.LastTaskCompletionDate, err = nullTimestamp(lastCompletion); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// nullTimestamp parses a nullable timestamp column.
+func nullTimestamp(s sql.NullString) (*timestamppb.Timestamp, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+
+	t, err := parseSQLiteTime(s.String)
+	if err != nil {
+		return nil, err
+	}
+
+	return timestamppb.New(t), nil
+}
@@ -0,0 +1,256 @@
+package dbal
+
+import (
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// planTaskKeys returns a subquery selecting the keys of the tasks of a compute plan.
+func (d *SQLiteDBAL) planTaskKeys(key string) sq.SelectBuilder {
+	return sq.
+		Select("key").
+		From("compute_tasks").
+		Where(sq.Eq{"channel": d.channel, "compute_plan_key": key})
+}
+
+// planEventsCondition selects the events related to the tasks of a compute plan and to their outputs.
+// Events of the compute plan itself are not selected.
+func (d *SQLiteDBAL) planEventsCondition(key string) sq.Sqlizer {
+	return sq.Or{
+		sq.Expr("asset_key IN (?)", d.planTaskKeys(key)),
+		sq.Expr("json_extract(asset, '$.computeTaskKey') IN (?)", d.planTaskKeys(key)),
+	}
+}
+
+// GetComputePlanArchive implements persistence.ComputePlanDBAL
+func (d *SQLiteDBAL) GetComputePlanArchive(key string) (*asset.ComputePlanArchive, error) {
+	archive, err := d.getComputePlanAssets(key)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := d.queryEvents(getSQLiteStatementBuilder().
+		Select(sqliteEventColumns...).
+		From("events").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Or{
+			sq.Eq{"asset_key": key},
+			d.planEventsCondition(key),
+		}).
+		OrderBy("position"))
+	if err != nil {
+		return nil, err
+	}
+	archive.Events = make([]*asset.Event, 0, len(events))
+	for _, e := range events {
+		archive.Events = append(archive.Events, e.event)
+	}
+
+	return archive, nil
+}
+
+// getComputePlanAssets returns the compute plan along with its tasks and their outputs, without the events.
+func (d *SQLiteDBAL) getComputePlanAssets(key string) (*asset.ComputePlanArchive, error) {
+	plan, err := d.GetComputePlan(key)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := d.GetComputePlanTasks(key)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &asset.ComputePlanArchive{
+		ComputePlan: plan,
+		Tasks:       tasks,
+	}
+
+	archive.Models, err = queryAssets[asset.Model](d, getSQLiteStatementBuilder().
+		Select("asset").
+		From("models").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Expr("compute_task_key IN (?)", d.planTaskKeys(key))).
+		OrderBy("creation_date", "key"))
+	if err != nil {
+		return nil, err
+	}
+
+	archive.Performances, err = queryAssets[asset.Performance](d, getSQLiteStatementBuilder().
+		Select("asset").
+		From("performances").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Expr("compute_task_key IN (?)", d.planTaskKeys(key))).
+		OrderBy("creation_date", "compute_task_key", "compute_task_output_identifier"))
+	if err != nil {
+		return nil, err
+	}
+
+	archive.FailureReports, err = queryAssets[asset.FailureReport](d, getSQLiteStatementBuilder().
+		Select("asset").
+		From("failure_reports").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Expr("asset_key IN (?)", d.planTaskKeys(key))).
+		OrderBy("asset_key", "attempt"))
+	if err != nil {
+		return nil, err
+	}
+
+	archive.OutputAssets, err = queryAssets[asset.ComputeTaskOutputAsset](d, getSQLiteStatementBuilder().
+		Select("asset").
+		From("compute_task_output_assets").
+		Where(sq.Expr("compute_task_key IN (?)", d.planTaskKeys(key))).
+		OrderBy("compute_task_key", "compute_task_output_identifier", "id"))
+	if err != nil {
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// IsComputePlanReferenced implements persistence.ComputePlanDBAL
+// A plan is referenced when a task of another compute plan depends on one of its tasks or on one of its models.
+func (d *SQLiteDBAL) IsComputePlanReferenced(key string) (bool, error) {
+	planModels := sq.
+		Select("key").
+		From("models").
+		Where(sq.Expr("compute_task_key IN (?)", d.planTaskKeys(key)))
+
+	stmt := getSQLiteStatementBuilder().
+		Select("key").
+		From("compute_tasks t").
+		Where(sq.Eq{"t.channel": d.channel}).
+		Where(sq.NotEq{"t.compute_plan_key": key}).
+		Where(sq.Or{
+			sq.Expr("EXISTS (SELECT 1 FROM compute_task_parents p WHERE p.child_task_key = t.key AND p.parent_task_key IN (?))", d.planTaskKeys(key)),
+			sq.Expr("EXISTS (SELECT 1 FROM json_each(t.asset, '$.inputs') i WHERE json_extract(i.value, '$.assetKey') IN (?))", planModels),
+		})
+
+	return d.exists(stmt)
+}
+
+// PurgeComputePlan implements persistence.ComputePlanDBAL
+// The compute plan is kept as a tombstone recording the purge date and what has been removed.
+func (d *SQLiteDBAL) PurgeComputePlan(key string, purgeDate time.Time) (*asset.ComputePlanPurgeSummary, error) {
+	summary := new(asset.ComputePlanPurgeSummary)
+	taskKeys := d.planTaskKeys(key)
+
+	// Tasks are deleted last since the other deletions select rows by task
+	deletions := []struct {
+		stmt    sq.DeleteBuilder
+		counter *uint32
+	}{
+		{
+			stmt: getSQLiteStatementBuilder().Delete("events").
+				Where(sq.Eq{"channel": d.channel}).
+				Where(d.planEventsCondition(key)),
+			counter: &summary.EventCount,
+		},
+		{
+			stmt: getSQLiteStatementBuilder().Delete("failure_reports").
+				Where(sq.Eq{"channel": d.channel}).
+				Where(sq.Expr("asset_key IN (?)", taskKeys)),
+			counter: &summary.FailureReportCount,
+		},
+		{
+			stmt: getSQLiteStatementBuilder().Delete("performances").
+				Where(sq.Eq{"channel": d.channel}).
+				Where(sq.Expr("compute_task_key IN (?)", taskKeys)),
+			counter: &summary.PerformanceCount,
+		},
+		{
+			stmt: getSQLiteStatementBuilder().Delete("compute_task_output_assets").
+				Where(sq.Expr("compute_task_key IN (?)", taskKeys)),
+			counter: &summary.OutputAssetCount,
+		},
+		{
+			stmt: getSQLiteStatementBuilder().Delete("models").
+				Where(sq.Eq{"channel": d.channel}).
+				Where(sq.Expr("compute_task_key IN (?)", taskKeys)),
+			counter: &summary.ModelCount,
+		},
+		{
+			stmt: getSQLiteStatementBuilder().Delete("compute_task_parents").
+				Where(sq.Expr("child_task_key IN (?)", taskKeys)),
+		},
+		{
+			stmt: getSQLiteStatementBuilder().Delete("compute_tasks").
+				Where(sq.Eq{"channel": d.channel, "compute_plan_key": key}),
+			counter: &summary.TaskCount,
+		},
+	}
+
+	// Purged events are replaced by their hash, so that the remaining events stay chained
+	tombstones := getSQLiteStatementBuilder().
+		Insert("event_tombstones").
+		Columns("position", "channel", "id", "hash", "previous_hash").
+		Select(getSQLiteStatementBuilder().
+			Select("position", "channel", "id", "hash", "previous_hash").
+			From("events").
+			Where(sq.Eq{"channel": d.channel}).
+			Where(d.planEventsCondition(key)))
+	if err := d.exec(tombstones); err != nil {
+		return nil, err
+	}
+
+	for _, deletion := range deletions {
+		count, err := d.execCount(deletion.stmt)
+		if err != nil {
+			return nil, err
+		}
+		if deletion.counter != nil {
+			*deletion.counter = count
+		}
+	}
+
+	purgeSummary, err := marshalAsset(summary)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Update("compute_plans").
+		Set("purge_date", sqliteTime(purgeDate)).
+		Set("asset", sq.Expr(
+			"json_set(asset, '$.purgeDate', ?, '$.purgeSummary', json(?))",
+			jsonTime(purgeDate), purgeSummary,
+		)).
+		Where(sq.Eq{"channel": d.channel, "key": key})
+
+	if err := d.exec(stmt); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// GetPurgeableComputePlanKeys implements persistence.ComputePlanDBAL
+// A plan is terminated when it has been canceled or has failed, or when all its tasks are done.
+func (d *SQLiteDBAL) GetPurgeableComputePlanKeys(terminatedBefore time.Time) ([]string, error) {
+	before := sqliteTime(terminatedBefore)
+
+	lastTaskUpdate := sq.
+		Select("MAX(timestamp)").
+		From("events").
+		Where(sq.Eq{"channel": d.channel, "asset_kind": asset.AssetKind_ASSET_COMPUTE_TASK.String()}).
+		Where("json_extract(asset, '$.computePlanKey') = cp.key")
+
+	stmt := getSQLiteStatementBuilder().
+		Select("key").
+		From("compute_plans cp").
+		Where(sq.Eq{"channel": d.channel, "purge_date": nil}).
+		Where(sq.Or{
+			sq.Lt{"cancelation_date": before},
+			sq.Lt{"failure_date": before},
+			sq.And{
+				sq.Expr("EXISTS (SELECT 1 FROM compute_tasks WHERE compute_plan_key = cp.key)"),
+				sq.Expr("NOT EXISTS (SELECT 1 FROM compute_tasks WHERE compute_plan_key = cp.key AND status <> ?)", asset.ComputeTaskStatus_STATUS_DONE.String()),
+				sq.Expr("(?) < ?", lastTaskUpdate, before),
+			},
+		}).
+		OrderBy("creation_date", "key")
+
+	return d.queryStrings(stmt)
+}
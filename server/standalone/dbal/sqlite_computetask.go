@@ -0,0 +1,322 @@
+package dbal
+
+import (
+	"strconv"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	"github.com/substra/orchestrator/lib/persistence"
+	"github.com/substra/orchestrator/lib/service"
+	"github.com/substra/orchestrator/utils"
+)
+
+// sqliteMetadataConditions returns the conditions selecting rows whose JSON object at path contains every entry of metadata.
+func sqliteMetadataConditions(column string, path string, metadata map[string]string) sq.And {
+	conditions := sq.And{}
+	for key, value := range metadata {
+		conditions = append(conditions, sq.Expr(
+			"EXISTS (SELECT 1 FROM json_each("+column+", '"+path+"') WHERE key = ? AND value = ?)",
+			key, value,
+		))
+	}
+	return conditions
+}
+
+// AddComputeTasks implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) AddComputeTasks(tasks ...*asset.ComputeTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Insert("compute_tasks").
+		Columns("key", "channel", "compute_plan_key", "function_key", "owner", "worker", "status", "rank", "creation_date", "asset")
+	parentsStmt := getSQLiteStatementBuilder().
+		Insert("compute_task_parents").
+		Columns("child_task_key", "parent_task_key", "position")
+	hasParents := false
+
+	for _, task := range tasks {
+		data, err := marshalAsset(task)
+		if err != nil {
+			return err
+		}
+		stmt = stmt.Values(
+			task.Key, d.channel, task.ComputePlanKey, task.FunctionKey, task.Owner, task.Worker,
+			task.Status.String(), task.Rank, sqliteTime(task.CreationDate.AsTime()), data,
+		)
+
+		for idx, parentKey := range service.GetParentTaskKeys(task.Inputs) {
+			parentsStmt = parentsStmt.Values(task.Key, parentKey, idx+1)
+			hasParents = true
+		}
+	}
+
+	if err := d.exec(stmt); err != nil {
+		return err
+	}
+	if !hasParents {
+		return nil
+	}
+
+	return d.exec(parentsStmt)
+}
+
+// UpdateComputeTaskStatus implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) UpdateComputeTaskStatus(taskKey string, taskStatus asset.ComputeTaskStatus) error {
+	stmt := getSQLiteStatementBuilder().
+		Update("compute_tasks").
+		Set("status", taskStatus.String()).
+		Set("asset", sq.Expr("json_set(asset, '$.status', ?)", taskStatus.String())).
+		Where(sq.Eq{"channel": d.channel, "key": taskKey})
+
+	return d.exec(stmt)
+}
+
+// UpdateComputeTaskAttempt implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) UpdateComputeTaskAttempt(taskKey string, attempt uint32) error {
+	stmt := getSQLiteStatementBuilder().
+		Update("compute_tasks").
+		Set("asset", sq.Expr("json_set(asset, '$.attempt', ?)", attempt)).
+		Where(sq.Eq{"channel": d.channel, "key": taskKey})
+
+	return d.exec(stmt)
+}
+
+// UpdateComputeTaskLease implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) UpdateComputeTaskLease(taskKey string, expiration time.Time) error {
+	stmt := getSQLiteStatementBuilder().
+		Update("compute_tasks").
+		Set("lease_expiration", sqliteTime(expiration)).
+		Where(sq.Eq{"channel": d.channel, "key": taskKey})
+
+	return d.exec(stmt)
+}
+
+// GetExistingComputeTaskKeys implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) GetExistingComputeTaskKeys(keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return []string{}, nil
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Select("key").
+		From("compute_tasks").
+		Where(sq.Eq{"channel": d.channel, "key": utils.Unique(keys)})
+
+	return d.queryStrings(stmt)
+}
+
+// selectComputeTasks returns the statement selecting the tasks of the channel.
+func (d *SQLiteDBAL) selectComputeTasks() sq.SelectBuilder {
+	return getSQLiteStatementBuilder().
+		Select("asset").
+		From("compute_tasks").
+		Where(sq.Eq{"channel": d.channel})
+}
+
+// getComputeTasks returns the tasks selected by the condition, sorted by creation date.
+func (d *SQLiteDBAL) getComputeTasks(condition sq.Sqlizer) ([]*asset.ComputeTask, error) {
+	return queryAssets[asset.ComputeTask](d, d.selectComputeTasks().Where(condition).OrderBy("creation_date", "key"))
+}
+
+// GetComputeTask implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) GetComputeTask(key string) (*asset.ComputeTask, error) {
+	return getAsset[asset.ComputeTask](d, d.selectComputeTasks().Where(sq.Eq{"key": key}), "computetask", key)
+}
+
+// GetComputeTasks implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) GetComputeTasks(keys []string) ([]*asset.ComputeTask, error) {
+	return d.getComputeTasks(sq.Eq{"key": keys})
+}
+
+// GetComputeTaskChildren implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) GetComputeTaskChildren(key string) ([]*asset.ComputeTask, error) {
+	return d.getComputeTasks(sq.Expr("key IN (SELECT child_task_key FROM compute_task_parents WHERE parent_task_key = ?)", key))
+}
+
+// GetComputeTaskParents implements persistence.ComputeTaskDBAL
+// Parents are returned in the order of the task inputs.
+func (d *SQLiteDBAL) GetComputeTaskParents(key string) ([]*asset.ComputeTask, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("t.asset").
+		From("compute_tasks t").
+		Join("compute_task_parents p ON t.key = p.parent_task_key").
+		Where(sq.Eq{"t.channel": d.channel, "p.child_task_key": key}).
+		OrderBy("p.position")
+
+	return queryAssets[asset.ComputeTask](d, stmt)
+}
+
+// GetComputePlanTasks implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) GetComputePlanTasks(key string) ([]*asset.ComputeTask, error) {
+	return d.getComputeTasks(sq.Eq{"compute_plan_key": key})
+}
+
+// GetComputePlanTasksKeys implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) GetComputePlanTasksKeys(key string) ([]string, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("key").
+		From("compute_tasks").
+		Where(sq.Eq{"channel": d.channel, "compute_plan_key": key}).
+		OrderBy("creation_date", "key")
+
+	return d.queryStrings(stmt)
+}
+
+// GetFunctionFromTasksWithStatus implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) GetFunctionFromTasksWithStatus(key string, statuses []asset.ComputeTaskStatus) ([]*asset.ComputeTask, error) {
+	names := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		names = append(names, status.String())
+	}
+
+	return d.getComputeTasks(sq.Eq{"function_key": key, "status": names})
+}
+
+// GetExpiredComputeTasks implements persistence.ComputeTaskDBAL
+// Tasks without lease are never returned.
+func (d *SQLiteDBAL) GetExpiredComputeTasks(expiredBefore time.Time) ([]*asset.ComputeTask, error) {
+	return d.getComputeTasks(sq.And{
+		sq.Eq{"status": asset.ComputeTaskStatus_STATUS_EXECUTING.String()},
+		sq.Lt{"lease_expiration": sqliteTime(expiredBefore)},
+	})
+}
+
+// IsComputeTaskLeaseExpired implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) IsComputeTaskLeaseExpired(taskKey string, expiredBefore time.Time) (bool, error) {
+	return d.exists(getSQLiteStatementBuilder().
+		Select("key").
+		From("compute_tasks").
+		Where(sq.Eq{"channel": d.channel, "key": taskKey}).
+		Where(sq.Lt{"lease_expiration": sqliteTime(expiredBefore)}))
+}
+
+// QueryComputeTasks implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) QueryComputeTasks(p *common.Pagination, filter *asset.TaskQueryFilter, sortBy asset.TaskSortField, sortOrder asset.SortOrder) ([]*asset.ComputeTask, common.PaginationToken, error) {
+	ks := taskSortToKeyset(sortBy, sortOrder)
+	stmt := sqliteTaskFilterToQuery(filter, d.selectComputeTasks())
+
+	return queryAssetPage(d, stmt, p, ks, func(task *asset.ComputeTask) []string {
+		values := make([]string, 0, len(ks))
+		for _, c := range ks {
+			switch c.name {
+			case "creation_date":
+				values = append(values, sqliteTime(task.CreationDate.AsTime()))
+			case "rank":
+				values = append(values, strconv.Itoa(int(task.Rank)))
+			case "key":
+				values = append(values, task.Key)
+			}
+		}
+		return values
+	})
+}
+
+func sqliteTaskFilterToQuery(filter *asset.TaskQueryFilter, builder sq.SelectBuilder) sq.SelectBuilder {
+	if filter == nil {
+		return builder
+	}
+
+	if filter.Worker != "" {
+		builder = builder.Where(sq.Eq{"worker": filter.Worker})
+	}
+
+	statuses := make([]string, 0, len(filter.Statuses)+1)
+	if filter.Status != 0 {
+		statuses = append(statuses, filter.Status.String())
+	}
+	for _, status := range filter.Statuses {
+		statuses = append(statuses, status.String())
+	}
+	if len(statuses) > 0 {
+		builder = builder.Where(sq.Eq{"status": statuses})
+	}
+
+	if filter.ComputePlanKey != "" {
+		builder = builder.Where(sq.Eq{"compute_plan_key": filter.ComputePlanKey})
+	}
+	if filter.FunctionKey != "" {
+		builder = builder.Where(sq.Eq{"function_key": filter.FunctionKey})
+	}
+	if filter.Owner != "" {
+		builder = builder.Where(sq.Eq{"owner": filter.Owner})
+	}
+	if len(filter.Keys) > 0 {
+		builder = builder.Where(sq.Eq{"key": filter.Keys})
+	}
+	if filter.RankMin != nil {
+		builder = builder.Where(sq.GtOrEq{"rank": filter.GetRankMin()})
+	}
+	if filter.RankMax != nil {
+		builder = builder.Where(sq.LtOrEq{"rank": filter.GetRankMax()})
+	}
+	if filter.CreationDateStart != nil {
+		builder = builder.Where(sq.GtOrEq{"creation_date": sqliteTime(filter.CreationDateStart.AsTime())})
+	}
+	if filter.CreationDateEnd != nil {
+		builder = builder.Where(sq.LtOrEq{"creation_date": sqliteTime(filter.CreationDateEnd.AsTime())})
+	}
+	if len(filter.Metadata) > 0 {
+		builder = builder.Where(sqliteMetadataConditions("asset", "$.metadata", filter.Metadata))
+	}
+
+	return builder
+}
+
+// AddComputeTaskOutputAsset implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) AddComputeTaskOutputAsset(output *asset.ComputeTaskOutputAsset) error {
+	data, err := marshalAsset(output)
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Insert("compute_task_output_assets").
+		Columns("compute_task_key", "compute_task_output_identifier", "asset").
+		Values(output.ComputeTaskKey, output.ComputeTaskOutputIdentifier, data)
+
+	return d.exec(stmt)
+}
+
+// CountComputeTaskRegisteredOutputs implements persistence.ComputeTaskDBAL
+func (d *SQLiteDBAL) CountComputeTaskRegisteredOutputs(key string) (persistence.ComputeTaskOutputCounter, error) {
+	counter := make(persistence.ComputeTaskOutputCounter)
+
+	stmt := getSQLiteStatementBuilder().
+		Select("compute_task_output_identifier", "COUNT(1)").
+		From("compute_task_output_assets").
+		Where(sq.Eq{"compute_task_key": key}).
+		GroupBy("compute_task_output_identifier")
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return counter, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var identifier string
+		var count int
+		if err := rows.Scan(&identifier, &count); err != nil {
+			return counter, err
+		}
+		counter[identifier] = count
+	}
+
+	return counter, rows.Err()
+}
+
+// GetComputeTaskOutputAssets implements persistence.ComputeTaskDBAL
+// Outputs are returned in the order they were added.
+func (d *SQLiteDBAL) GetComputeTaskOutputAssets(taskKey, identifier string) ([]*asset.ComputeTaskOutputAsset, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("compute_task_output_assets").
+		Where(sq.Eq{"compute_task_key": taskKey, "compute_task_output_identifier": identifier}).
+		OrderBy("id")
+
+	return queryAssets[asset.ComputeTaskOutputAsset](d, stmt)
+}
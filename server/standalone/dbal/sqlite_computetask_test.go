@@ -0,0 +1,166 @@
+package dbal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newSQLiteTestTask(key string, rank int32, parents ...string) *asset.ComputeTask {
+	task := &asset.ComputeTask{
+		Key:            key,
+		ComputePlanKey: "cp",
+		Rank:           rank,
+		Status:         asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT,
+		CreationDate:   timestamppb.New(sqliteTestTime(int64(rank))),
+	}
+	for _, parent := range parents {
+		task.Inputs = append(task.Inputs, &asset.ComputeTaskInput{
+			Identifier: "model",
+			Ref: &asset.ComputeTaskInput_ParentTaskOutput{
+				ParentTaskOutput: &asset.ParentTaskOutputRef{ParentTaskKey: parent, OutputIdentifier: "model"},
+			},
+		})
+	}
+
+	return task
+}
+
+func TestSQLiteComputeTaskRelatives(t *testing.T) {
+	dbal := beginSQLite(t, newTestSQLiteDatabase(t), testChannel)
+
+	require.NoError(t, dbal.AddComputeTasks(
+		newSQLiteTestTask("parent1", 0),
+		newSQLiteTestTask("parent2", 0),
+		newSQLiteTestTask("child", 1, "parent2", "parent1", "parent2"),
+	))
+
+	parents, err := dbal.GetComputeTaskParents("child")
+	assert.NoError(t, err)
+	require.Len(t, parents, 2)
+	assert.Equal(t, "parent2", parents[0].Key)
+	assert.Equal(t, "parent1", parents[1].Key)
+
+	children, err := dbal.GetComputeTaskChildren("parent1")
+	assert.NoError(t, err)
+	require.Len(t, children, 1)
+	assert.Equal(t, "child", children[0].Key)
+
+	task, err := dbal.GetComputeTask("child")
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(newSQLiteTestTask("child", 1, "parent2", "parent1", "parent2"), task))
+}
+
+func TestSQLiteQueryComputeTasks(t *testing.T) {
+	dbal := beginSQLite(t, newTestSQLiteDatabase(t), testChannel)
+
+	require.NoError(t, dbal.AddComputeTasks(newSQLiteTestTask("a", 2), newSQLiteTestTask("b", 0), newSQLiteTestTask("c", 1)))
+	require.NoError(t, dbal.UpdateComputeTaskStatus("c", asset.ComputeTaskStatus_STATUS_DONE))
+
+	tasks, token, err := dbal.QueryComputeTasks(common.NewPagination("", 2), nil, asset.TaskSortField_TASK_SORT_RANK, asset.SortOrder_DESCENDING)
+	assert.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, []string{"a", "c"}, []string{tasks[0].Key, tasks[1].Key})
+	assert.Equal(t, asset.ComputeTaskStatus_STATUS_DONE, tasks[1].Status)
+
+	tasks, token, err = dbal.QueryComputeTasks(common.NewPagination(token, 2), nil, asset.TaskSortField_TASK_SORT_RANK, asset.SortOrder_DESCENDING)
+	assert.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "b", tasks[0].Key)
+	assert.Equal(t, "", token)
+
+	rankMin := int32(1)
+	tasks, _, err = dbal.QueryComputeTasks(
+		common.NewPagination("", 10),
+		&asset.TaskQueryFilter{RankMin: &rankMin, Statuses: []asset.ComputeTaskStatus{asset.ComputeTaskStatus_STATUS_WAITING_FOR_EXECUTOR_SLOT}},
+		asset.TaskSortField_TASK_SORT_CREATION_DATE,
+		asset.SortOrder_ASCENDING,
+	)
+	assert.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "a", tasks[0].Key)
+}
+
+func TestSQLiteGetExpiredComputeTasks(t *testing.T) {
+	dbal := beginSQLite(t, newTestSQLiteDatabase(t), testChannel)
+
+	require.NoError(t, dbal.AddComputeTasks(newSQLiteTestTask("expired", 0), newSQLiteTestTask("alive", 0), newSQLiteTestTask("noLease", 0)))
+	for _, key := range []string{"expired", "alive", "noLease"} {
+		require.NoError(t, dbal.UpdateComputeTaskStatus(key, asset.ComputeTaskStatus_STATUS_EXECUTING))
+	}
+	require.NoError(t, dbal.UpdateComputeTaskLease("expired", sqliteTestTime(1)))
+	require.NoError(t, dbal.UpdateComputeTaskLease("alive", sqliteTestTime(10)))
+
+	tasks, err := dbal.GetExpiredComputeTasks(sqliteTestTime(5))
+	assert.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "expired", tasks[0].Key)
+}
+
+func TestSQLiteIsComputeTaskLeaseExpired(t *testing.T) {
+	dbal := beginSQLite(t, newTestSQLiteDatabase(t), testChannel)
+
+	require.NoError(t, dbal.AddComputeTasks(newSQLiteTestTask("task", 0), newSQLiteTestTask("noLease", 0)))
+	require.NoError(t, dbal.UpdateComputeTaskLease("task", sqliteTestTime(5)))
+
+	expired, err := dbal.IsComputeTaskLeaseExpired("task", sqliteTestTime(10))
+	assert.NoError(t, err)
+	assert.True(t, expired)
+
+	expired, err = dbal.IsComputeTaskLeaseExpired("task", sqliteTestTime(1))
+	assert.NoError(t, err)
+	assert.False(t, expired)
+
+	expired, err = dbal.IsComputeTaskLeaseExpired("noLease", sqliteTestTime(10))
+	assert.NoError(t, err)
+	assert.False(t, expired)
+}
+
+func TestSQLitePurgeComputePlan(t *testing.T) {
+	db := newTestSQLiteDatabase(t)
+	dbal := beginSQLite(t, db, testChannel)
+
+	require.NoError(t, dbal.AddComputePlan(&asset.ComputePlan{Key: "cp", CreationDate: timestamppb.New(sqliteTestTime(0))}))
+	require.NoError(t, dbal.AddComputeTasks(newSQLiteTestTask("task", 0)))
+	require.NoError(t, dbal.AddModel(&asset.Model{Key: "model", ComputeTaskKey: "task", CreationDate: timestamppb.New(sqliteTestTime(1))}, "model"))
+	require.NoError(t, dbal.AddComputeTaskOutputAsset(&asset.ComputeTaskOutputAsset{ComputeTaskKey: "task", ComputeTaskOutputIdentifier: "model", AssetKey: "model"}))
+	require.NoError(t, dbal.AddEvents(
+		&asset.Event{Id: "e1", AssetKey: "cp", AssetKind: asset.AssetKind_ASSET_COMPUTE_PLAN, Timestamp: timestamppb.New(sqliteTestTime(0)), Asset: &asset.Event_ComputePlan{ComputePlan: &asset.ComputePlan{Key: "cp"}}},
+		newSQLiteTestEvent("e2", 1, newSQLiteTestTask("task", 0)),
+	))
+	require.NoError(t, dbal.Commit(context.Background()))
+
+	dbal = beginSQLite(t, db, testChannel)
+	referenced, err := dbal.IsComputePlanReferenced("cp")
+	assert.NoError(t, err)
+	assert.False(t, referenced)
+
+	summary, err := dbal.PurgeComputePlan("cp", sqliteTestTime(2))
+	require.NoError(t, err)
+	expected := &asset.ComputePlanPurgeSummary{TaskCount: 1, ModelCount: 1, OutputAssetCount: 1, EventCount: 1}
+	assert.True(t, proto.Equal(expected, summary))
+
+	plan, err := dbal.GetComputePlan("cp")
+	assert.NoError(t, err)
+	assert.Equal(t, sqliteTestTime(2), plan.PurgeDate.AsTime())
+
+	archive, err := dbal.GetComputePlanArchive("cp")
+	assert.NoError(t, err)
+	assert.Empty(t, archive.Tasks)
+	require.Len(t, archive.Events, 1, "events of the plan itself should be kept")
+	require.NoError(t, dbal.Rollback(context.Background()))
+
+	archive, err = beginSQLite(t, db, testChannel).GetComputePlanArchive("cp")
+	assert.NoError(t, err)
+	assert.Nil(t, archive.ComputePlan.PurgeDate)
+	assert.Len(t, archive.Tasks, 1)
+	assert.Len(t, archive.Models, 1)
+	assert.Len(t, archive.OutputAssets, 1)
+	assert.Len(t, archive.Events, 2)
+}
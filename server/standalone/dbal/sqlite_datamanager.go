@@ -0,0 +1,63 @@
+package dbal
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+)
+
+// AddDataManager implements persistence.DataManagerDBAL
+func (d *SQLiteDBAL) AddDataManager(dataManager *asset.DataManager) error {
+	data, err := marshalAsset(dataManager)
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Insert("datamanagers").
+		Columns("key", "channel", "creation_date", "asset").
+		Values(dataManager.Key, d.channel, sqliteTime(dataManager.CreationDate.AsTime()), data)
+
+	return d.exec(stmt)
+}
+
+// DataManagerExists implements persistence.DataManagerDBAL
+func (d *SQLiteDBAL) DataManagerExists(key string) (bool, error) {
+	return d.exists(getSQLiteStatementBuilder().
+		Select("key").
+		From("datamanagers").
+		Where(sq.Eq{"channel": d.channel, "key": key}))
+}
+
+// GetDataManager implements persistence.DataManagerDBAL
+func (d *SQLiteDBAL) GetDataManager(key string) (*asset.DataManager, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("datamanagers").
+		Where(sq.Eq{"channel": d.channel, "key": key})
+
+	return getAsset[asset.DataManager](d, stmt, "datamanager", key)
+}
+
+// QueryDataManagers implements persistence.DataManagerDBAL
+func (d *SQLiteDBAL) QueryDataManagers(p *common.Pagination) ([]*asset.DataManager, common.PaginationToken, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("datamanagers").
+		Where(sq.Eq{"channel": d.channel})
+
+	return queryAssetPage(d, stmt, p, newKeyset(false, "creation_date", "key"), func(dm *asset.DataManager) []string {
+		return []string{sqliteTime(dm.CreationDate.AsTime()), dm.Key}
+	})
+}
+
+// UpdateDataManager implements persistence.DataManagerDBAL
+// Only the name of the data manager is updated.
+func (d *SQLiteDBAL) UpdateDataManager(dataManager *asset.DataManager) error {
+	stmt := getSQLiteStatementBuilder().
+		Update("datamanagers").
+		Set("asset", sq.Expr("json_set(asset, '$.name', ?)", dataManager.Name)).
+		Where(sq.Eq{"channel": d.channel, "key": dataManager.Key})
+
+	return d.exec(stmt)
+}
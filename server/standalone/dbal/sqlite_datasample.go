@@ -0,0 +1,95 @@
+package dbal
+
+import (
+	"encoding/json"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+)
+
+// DataSampleExists implements persistence.DataSampleDBAL
+func (d *SQLiteDBAL) DataSampleExists(key string) (bool, error) {
+	return d.exists(getSQLiteStatementBuilder().
+		Select("key").
+		From("datasamples").
+		Where(sq.Eq{"channel": d.channel, "key": key}))
+}
+
+// AddDataSamples implements persistence.DataSampleDBAL
+func (d *SQLiteDBAL) AddDataSamples(dataSamples ...*asset.DataSample) error {
+	if len(dataSamples) == 0 {
+		return nil
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Insert("datasamples").
+		Columns("key", "channel", "creation_date", "asset")
+
+	for _, ds := range dataSamples {
+		data, err := marshalAsset(ds)
+		if err != nil {
+			return err
+		}
+		stmt = stmt.Values(ds.Key, d.channel, sqliteTime(ds.CreationDate.AsTime()), data)
+	}
+
+	return d.exec(stmt)
+}
+
+// UpdateDataSample implements persistence.DataSampleDBAL
+// Only the owner, the checksum and the data managers of the sample are updated.
+func (d *SQLiteDBAL) UpdateDataSample(dataSample *asset.DataSample) error {
+	managerKeys, err := json.Marshal(append([]string{}, dataSample.DataManagerKeys...))
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Update("datasamples").
+		Set("asset", sq.Expr(
+			"json_set(asset, '$.owner', ?, '$.checksum', ?, '$.dataManagerKeys', json(?))",
+			dataSample.Owner, dataSample.Checksum, string(managerKeys),
+		)).
+		Where(sq.Eq{"channel": d.channel, "key": dataSample.Key})
+
+	return d.exec(stmt)
+}
+
+// GetDataSample implements persistence.DataSampleDBAL
+func (d *SQLiteDBAL) GetDataSample(key string) (*asset.DataSample, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("datasamples").
+		Where(sq.Eq{"channel": d.channel, "key": key})
+
+	return getAsset[asset.DataSample](d, stmt, "datasample", key)
+}
+
+// QueryDataSamples implements persistence.DataSampleDBAL
+func (d *SQLiteDBAL) QueryDataSamples(p *common.Pagination, filter *asset.DataSampleQueryFilter) ([]*asset.DataSample, common.PaginationToken, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("datasamples").
+		Where(sq.Eq{"channel": d.channel})
+
+	if filter != nil && len(filter.Keys) > 0 {
+		stmt = stmt.Where(sq.Eq{"key": filter.Keys})
+	}
+
+	return queryAssetPage(d, stmt, p, newKeyset(false, "creation_date", "key"), func(ds *asset.DataSample) []string {
+		return []string{sqliteTime(ds.CreationDate.AsTime()), ds.Key}
+	})
+}
+
+// GetDataSampleKeysByManager implements persistence.DataSampleDBAL
+func (d *SQLiteDBAL) GetDataSampleKeysByManager(dataManagerKey string) ([]string, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("key").
+		From("datasamples").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Expr("EXISTS (SELECT 1 FROM json_each(asset, '$.dataManagerKeys') WHERE value = ?)", dataManagerKey)).
+		OrderBy("creation_date", "key")
+
+	return d.queryStrings(stmt)
+}
@@ -0,0 +1,402 @@
+package dbal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// sqliteEventColumns are the columns scanned by scanSQLiteEvent.
+var sqliteEventColumns = []string{"channel", "position", "id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata"}
+
+// positionedEvent is an event along with its position.
+type positionedEvent struct {
+	position int64
+	event    *asset.Event
+}
+
+// scanSQLiteEvents returns the events of rows selecting sqliteEventColumns.
+func scanSQLiteEvents(rows *sql.Rows) ([]positionedEvent, error) {
+	defer rows.Close()
+
+	events := []positionedEvent{}
+	for rows.Next() {
+		var position int64
+		var timestamp, metadata, eventAsset string
+		ev := sqlEvent{}
+
+		err := rows.Scan(&ev.Channel, &position, &ev.ID, &ev.AssetKey, &ev.AssetKind, &ev.EventKind, &timestamp, &eventAsset, &metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		ev.Timestamp, err = parseSQLiteTime(timestamp)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(metadata), &ev.Metadata); err != nil {
+			return nil, err
+		}
+		ev.Asset = []byte(eventAsset)
+
+		event, err := ev.toEvent()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, positionedEvent{position: position, event: event})
+	}
+
+	return events, rows.Err()
+}
+
+// queryEvents returns the events selected by stmt, the statement should select sqliteEventColumns.
+func (d *SQLiteDBAL) queryEvents(stmt sq.SelectBuilder) ([]positionedEvent, error) {
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSQLiteEvents(rows)
+}
+
+// NewEventID implements persistence.EventDBAL
+func (d *SQLiteDBAL) NewEventID() string {
+	return uuid.NewString()
+}
+
+// AddEvents implements persistence.EventDBAL
+// Events are positioned after every event ever inserted in any channel, and chained to the last event of the channel.
+// Write transactions being serialized, positions are allocated in commit order.
+func (d *SQLiteDBAL) AddEvents(events ...*asset.Event) error {
+	log.Ctx(d.ctx).Debug().Int("numEvents", len(events)).Msg("dbal: adding multiple events")
+
+	// Unlike MAX(position), the sequence of the AUTOINCREMENT column is not decreased when the last events are purged
+	row, err := d.queryRow(getSQLiteStatementBuilder().
+		Select("COALESCE(MAX(seq), 0)").
+		From("sqlite_sequence").
+		Where(sq.Eq{"name": "events"}))
+	if err != nil {
+		return err
+	}
+	var position int64
+	if err := row.Scan(&position); err != nil {
+		return err
+	}
+
+	// The last event of the channel may have been purged, its tombstone keeps the chain
+	chain := getSQLiteStatementBuilder().
+		Select("position", "hash").
+		From("events").
+		Where(sq.Eq{"channel": d.channel}).
+		Suffix("UNION ALL SELECT position, hash FROM event_tombstones WHERE channel = ?", d.channel)
+	row, err = d.queryRow(getSQLiteStatementBuilder().
+		Select("hash").
+		FromSelect(chain, "chain").
+		OrderBy("position DESC").
+		Limit(1))
+	if err != nil {
+		return err
+	}
+	var previous []byte
+	if err := row.Scan(&previous); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	for _, event := range events {
+		eventAsset, err := asset.MarshalEventAsset(event)
+		if err != nil {
+			return err
+		}
+		metadata := event.Metadata
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+		eventMetadata, err := json.Marshal(metadata)
+		if err != nil {
+			return err
+		}
+
+		position++
+		e := &chainedEvent{
+			Position:  position,
+			ID:        event.Id,
+			AssetKey:  event.AssetKey,
+			AssetKind: event.AssetKind.String(),
+			EventKind: event.EventKind.String(),
+			Timestamp: event.Timestamp.AsTime(),
+			Asset:     string(eventAsset),
+			Metadata:  string(eventMetadata),
+		}
+		e.PreviousHash = previous
+		e.Hash = chainEventHash(previous, e.canonicalForm(d.channel))
+		previous = e.Hash
+
+		stmt := getSQLiteStatementBuilder().
+			Insert("events").
+			Columns("position", "id", "channel", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata", "hash", "previous_hash").
+			Values(e.Position, e.ID, d.channel, e.AssetKey, e.AssetKind, e.EventKind, sqliteTime(e.Timestamp), e.Asset, e.Metadata, e.Hash, e.PreviousHash)
+
+		if err := d.exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// QueryEvents implements persistence.EventDBAL
+func (d *SQLiteDBAL) QueryEvents(p *common.Pagination, filter *asset.EventQueryFilter, sortOrder asset.SortOrder) ([]*asset.Event, common.PaginationToken, error) {
+	// Events are paginated by position, which follows the commit order, see DBAL.QueryEvents
+	pg, err := newPage(p, newKeyset(sortOrder == asset.SortOrder_DESCENDING, "position"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Select(sqliteEventColumns...).
+		From("events").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(d.eventFilterConditions(filter))
+
+	positioned, err := d.queryEvents(pg.apply(stmt))
+	if err != nil {
+		return nil, "", err
+	}
+
+	positioned, hasNext := splitPage(positioned, p.Size)
+
+	events := make([]*asset.Event, 0, len(positioned))
+	for _, e := range positioned {
+		events = append(events, e.event)
+	}
+
+	if !hasNext {
+		return events, "", nil
+	}
+
+	bookmark, err := pg.nextToken(strconv.FormatInt(positioned[len(positioned)-1].position, 10))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return events, bookmark, nil
+}
+
+// eventFilterConditions returns the conditions selecting the events of the channel matching the filter.
+func (d *SQLiteDBAL) eventFilterConditions(filter *asset.EventQueryFilter) sq.And {
+	conditions := sq.And{}
+	if filter == nil {
+		return conditions
+	}
+
+	if filter.AssetKey != "" {
+		conditions = append(conditions, sq.Eq{"asset_key": filter.AssetKey})
+	}
+
+	assetKinds := make([]string, 0, len(filter.AssetKinds)+1)
+	if filter.AssetKind != asset.AssetKind_ASSET_UNKNOWN {
+		assetKinds = append(assetKinds, filter.AssetKind.String())
+	}
+	for _, kind := range filter.AssetKinds {
+		assetKinds = append(assetKinds, kind.String())
+	}
+	if len(assetKinds) > 0 {
+		conditions = append(conditions, sq.Eq{"asset_kind": assetKinds})
+	}
+
+	eventKinds := make([]string, 0, len(filter.EventKinds)+1)
+	if filter.EventKind != asset.EventKind_EVENT_UNKNOWN {
+		eventKinds = append(eventKinds, filter.EventKind.String())
+	}
+	for _, kind := range filter.EventKinds {
+		eventKinds = append(eventKinds, kind.String())
+	}
+	if len(eventKinds) > 0 {
+		conditions = append(conditions, sq.Eq{"event_kind": eventKinds})
+	}
+
+	if filter.ComputePlanKey != "" {
+		conditions = append(conditions, sq.Or{
+			sq.Eq{"asset_key": filter.ComputePlanKey},
+			d.planEventsCondition(filter.ComputePlanKey),
+		})
+	}
+	if len(filter.Metadata) > 0 {
+		conditions = append(conditions, sqliteMetadataConditions("metadata", "$", filter.Metadata))
+	}
+	if filter.Start != nil {
+		conditions = append(conditions, sq.GtOrEq{"timestamp": sqliteTime(filter.Start.AsTime())})
+	}
+	if filter.End != nil {
+		conditions = append(conditions, sq.LtOrEq{"timestamp": sqliteTime(filter.End.AsTime())})
+	}
+
+	return conditions
+}
+
+// VerifyEventChain implements persistence.EventDBAL
+// It recomputes the hash of every event of the channel in position order,
+// and reports the first event whose stored hash does not match.
+// Purged events are replaced by their tombstone.
+func (d *SQLiteDBAL) VerifyEventChain() (*asset.EventChainStatus, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("position", "id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata", "hash", "previous_hash", "FALSE").
+		From("events").
+		Where(sq.Eq{"channel": d.channel}).
+		Suffix("UNION ALL SELECT position, id, '', '', '', '', '', '', hash, previous_hash, TRUE FROM event_tombstones WHERE channel = ? "+
+			"ORDER BY position", d.channel)
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	verifier := newChainVerifier(d.channel)
+
+	for rows.Next() {
+		e := new(chainedEvent)
+		var timestamp string
+
+		err = rows.Scan(&e.Position, &e.ID, &e.AssetKey, &e.AssetKind, &e.EventKind, &timestamp, &e.Asset, &e.Metadata, &e.Hash, &e.PreviousHash, &e.Purged)
+		if err != nil {
+			return nil, err
+		}
+		if !e.Purged {
+			e.Timestamp, err = parseSQLiteTime(timestamp)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if !verifier.verify(e) {
+			return verifier.status, nil
+		}
+	}
+
+	return verifier.status, rows.Err()
+}
+
+// getEventPosition returns the position of an event of the channel, or a not found error.
+func (d *SQLiteDBAL) getEventPosition(eventID string) (int64, error) {
+	row, err := d.queryRow(getSQLiteStatementBuilder().
+		Select("position").
+		From("events").
+		Where(sq.Eq{"channel": d.channel, "id": eventID}))
+	if err != nil {
+		return 0, err
+	}
+
+	var position int64
+	err = row.Scan(&position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, orcerrors.NewNotFound("event", eventID)
+	}
+
+	return position, err
+}
+
+// AckEvents implements persistence.EventDBAL
+// The acknowledged position never moves backward: acknowledging an older event has no effect.
+func (d *SQLiteDBAL) AckEvents(owner string, consumer string, eventID string, ackDate time.Time) error {
+	position, err := d.getEventPosition(eventID)
+	if err != nil {
+		return err
+	}
+
+	upsert := getSQLiteStatementBuilder().
+		Insert("event_consumers").
+		Columns("channel", "owner", "name", "event_id", "position", "ack_date").
+		Values(d.channel, owner, consumer, eventID, position, sqliteTime(ackDate)).
+		Suffix("ON CONFLICT (channel, owner, name) DO UPDATE SET " +
+			"event_id = CASE WHEN excluded.position > event_consumers.position THEN excluded.event_id ELSE event_consumers.event_id END, " +
+			"position = MAX(excluded.position, event_consumers.position), " +
+			"ack_date = excluded.ack_date")
+
+	return d.exec(upsert)
+}
+
+// QueryEventConsumers implements persistence.EventDBAL
+// The lag of a consumer is the number of events of the channel emitted after its last acknowledged event,
+// regardless of its subscription filter.
+func (d *SQLiteDBAL) QueryEventConsumers(p *common.Pagination, owner string) ([]*asset.EventConsumer, common.PaginationToken, error) {
+	pg, err := newPage(p, newKeyset(false, "owner", "name"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Select("name", "owner", "event_id", "ack_date").
+		Column("(SELECT COUNT(*) FROM events e WHERE e.channel = c.channel AND e.position > c.position) AS lag").
+		From("event_consumers c").
+		Where(sq.Eq{"channel": d.channel, "owner": owner})
+
+	rows, err := d.query(pg.apply(stmt))
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	consumers := []*asset.EventConsumer{}
+	for rows.Next() {
+		c := new(asset.EventConsumer)
+		var ackDate string
+
+		if err := rows.Scan(&c.Name, &c.Owner, &c.LastAckedEventId, &ackDate, &c.Lag); err != nil {
+			return nil, "", err
+		}
+		date, err := parseSQLiteTime(ackDate)
+		if err != nil {
+			return nil, "", err
+		}
+		c.LastAckDate = timestamppb.New(date)
+
+		consumers = append(consumers, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	consumers, hasNext := splitPage(consumers, p.Size)
+	if !hasNext {
+		return consumers, "", nil
+	}
+
+	last := consumers[len(consumers)-1]
+	bookmark, err := pg.nextToken(last.Owner, last.Name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return consumers, bookmark, nil
+}
+
+// getEventConsumerPosition returns the position of the last event acknowledged by a consumer,
+// or 0 if the consumer has not acknowledged any event yet.
+func (d *SQLiteDBAL) getEventConsumerPosition(owner string, consumer string) (int64, error) {
+	row, err := d.queryRow(getSQLiteStatementBuilder().
+		Select("position").
+		From("event_consumers").
+		Where(sq.Eq{"channel": d.channel, "owner": owner, "name": consumer}))
+	if err != nil {
+		return 0, err
+	}
+
+	var position int64
+	err = row.Scan(&position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	return position, err
+}
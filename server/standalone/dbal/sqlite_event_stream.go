@@ -0,0 +1,203 @@
+package dbal
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// lastEventPosition implements eventPositionReader
+func (d *SQLiteDatabase) lastEventPosition(ctx context.Context) (int64, error) {
+	var position int64
+	err := d.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(position), 0) FROM events`).Scan(&position)
+
+	return position, err
+}
+
+// lastChannelEvents implements eventPositionReader
+func (d *SQLiteDatabase) lastChannelEvents(ctx context.Context, afterPosition int64) ([]*eventNotification, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT channel, MAX(position) FROM events WHERE position > ? GROUP BY channel`, afterPosition)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifs := []*eventNotification{}
+	for rows.Next() {
+		notif := new(eventNotification)
+		if err := rows.Scan(&notif.Channel, &notif.EventPosition); err != nil {
+			return nil, err
+		}
+		notifs = append(notifs, notif)
+	}
+
+	return notifs, rows.Err()
+}
+
+// reader returns a DBAL of the channel reading the database outside of any transaction.
+// Each statement sees the changes committed before it, the DBAL must not be committed nor rolled back.
+func (d *SQLiteDatabase) reader(ctx context.Context, channel string) *SQLiteDBAL {
+	return &SQLiteDBAL{ctx: ctx, db: d.db, channel: channel}
+}
+
+// sqliteEventStreamer streams the events of a SQLite database, it is notified of new events by a polling notifier.
+type sqliteEventStreamer struct {
+	db       *SQLiteDatabase
+	notifier *EventNotifier
+}
+
+// NewSQLiteEventStreamer returns an EventStreamer reading the events of db.
+// The notifier should poll the same database, see NewSQLiteEventNotifier.
+func NewSQLiteEventStreamer(db *SQLiteDatabase, notifier *EventNotifier) EventStreamer {
+	return &sqliteEventStreamer{db: db, notifier: notifier}
+}
+
+// SubscribeToEvents implements EventStreamer, see DBAL.SubscribeToEvents.
+func (s *sqliteEventStreamer) SubscribeToEvents(ctx context.Context, channel string, owner string, param *asset.SubscribeToEventsParam, stream asset.EventService_SubscribeToEventsServer) error {
+	// Subscribe before replaying existing events to prevent missing any event
+	sub := s.notifier.Subscribe(channel)
+	defer sub.Close()
+
+	reader := s.db.reader(ctx, channel)
+
+	var err error
+	startAfterPosition := int64(0)
+
+	switch {
+	case param.StartEventId != "":
+		startAfterPosition, err = reader.getEventPosition(param.StartEventId)
+	case param.Consumer != "":
+		startAfterPosition, err = reader.getEventConsumerPosition(owner, param.Consumer)
+	}
+	if err != nil {
+		return err
+	}
+
+	es := s.newStream(ctx, sub, []string{channel}, param.Filter)
+	es.positions[channel] = startAfterPosition
+
+	return es.run(stream.Send)
+}
+
+// SubscribeToChannels implements EventStreamer, see SubscribeToChannels.
+func (s *sqliteEventStreamer) SubscribeToChannels(ctx context.Context, channels []string, param *asset.SubscribeToChannelsParam, stream asset.EventService_SubscribeToChannelsServer) error {
+	// Subscribe before replaying existing events to prevent missing any event
+	sub := s.notifier.Subscribe(channels...)
+	defer sub.Close()
+
+	es := s.newStream(ctx, sub, channels, param.Filter)
+
+	for _, cursor := range param.Cursors {
+		if cursor.StartEventId == "" {
+			continue
+		}
+		position, err := s.db.reader(ctx, cursor.Channel).getEventPosition(cursor.StartEventId)
+		if err != nil {
+			return err
+		}
+		es.positions[cursor.Channel] = position
+	}
+
+	return es.run(stream.Send)
+}
+
+// WatchComputePlan implements EventStreamer, see DBAL.WatchComputePlan.
+func (s *sqliteEventStreamer) WatchComputePlan(ctx context.Context, channel string, key string, stream asset.EventService_WatchComputePlanServer) error {
+	// Subscribe before taking the snapshot to prevent missing any event
+	sub := s.notifier.Subscribe(channel)
+	defer sub.Close()
+
+	snapshot, err := s.getComputePlanSnapshot(ctx, channel, key)
+	if err != nil {
+		return err
+	}
+
+	err = stream.Send(&asset.ComputePlanUpdate{Update: &asset.ComputePlanUpdate_Snapshot{Snapshot: snapshot}})
+	if err != nil {
+		return err
+	}
+
+	es := s.newStream(ctx, sub, []string{channel}, &asset.EventQueryFilter{ComputePlanKey: key})
+	es.positions[channel] = int64(snapshot.Position)
+
+	return es.run(func(event *asset.Event) error {
+		return stream.Send(&asset.ComputePlanUpdate{Update: &asset.ComputePlanUpdate_Event{Event: event}})
+	})
+}
+
+// getComputePlanSnapshot reads the compute plan and its related assets in a read transaction,
+// along with the position of the last event of the channel.
+func (s *sqliteEventStreamer) getComputePlanSnapshot(ctx context.Context, channel string, key string) (*asset.ComputePlanSnapshot, error) {
+	tx, err := s.db.BeginDBAL(ctx, channel, true)
+	if err != nil {
+		return nil, err
+	}
+	// Nothing to commit in a read-only transaction
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	d := tx.(*SQLiteDBAL)
+
+	row, err := d.queryRow(getSQLiteStatementBuilder().
+		Select("COALESCE(MAX(position), 0)").
+		From("events").
+		Where(sq.Eq{"channel": channel}))
+	if err != nil {
+		return nil, err
+	}
+
+	var position int64
+	if err := row.Scan(&position); err != nil {
+		return nil, err
+	}
+
+	assets, err := d.getComputePlanAssets(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &asset.ComputePlanSnapshot{
+		Position:       uint64(position),
+		ComputePlan:    assets.ComputePlan,
+		Tasks:          assets.Tasks,
+		OutputAssets:   assets.OutputAssets,
+		Models:         assets.Models,
+		Performances:   assets.Performances,
+		FailureReports: assets.FailureReports,
+	}, nil
+}
+
+func (s *sqliteEventStreamer) newStream(ctx context.Context, sub *EventSubscription, channels []string, filter *asset.EventQueryFilter) *polledEventStream {
+	events := &sqliteEventLog{readers: make(map[string]*SQLiteDBAL, len(channels))}
+	for _, channel := range channels {
+		events.readers[channel] = s.db.reader(ctx, channel)
+	}
+
+	return newPolledEventStream(ctx, sub, events, channels, filter)
+}
+
+// sqliteEventLog reads the events of a SQLite database, readers holds a DBAL reading each subscribed channel.
+type sqliteEventLog struct {
+	readers map[string]*SQLiteDBAL
+}
+
+// eventsAfter implements eventLog
+func (l *sqliteEventLog) eventsAfter(channels []string, positions map[string]int64, filter *asset.EventQueryFilter, limit int) ([]positionedEvent, error) {
+	conditions := make(sq.Or, 0, len(channels))
+	for _, channel := range channels {
+		condition := sq.And{
+			sq.Eq{"channel": channel},
+			sq.Gt{"position": positions[channel]},
+		}
+		conditions = append(conditions, append(condition, l.readers[channel].eventFilterConditions(filter)...))
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Select(sqliteEventColumns...).
+		From("events").
+		Where(conditions).
+		OrderBy("position").
+		Limit(uint64(limit))
+
+	return l.readers[channels[0]].queryEvents(stmt)
+}
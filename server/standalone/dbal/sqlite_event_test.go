@@ -0,0 +1,251 @@
+package dbal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newSQLiteTestEvent(id string, seconds int64, task *asset.ComputeTask) *asset.Event {
+	return &asset.Event{
+		Id:        id,
+		AssetKey:  task.Key,
+		AssetKind: asset.AssetKind_ASSET_COMPUTE_TASK,
+		EventKind: asset.EventKind_EVENT_ASSET_UPDATED,
+		Timestamp: timestamppb.New(sqliteTestTime(seconds)),
+		Asset:     &asset.Event_ComputeTask{ComputeTask: task},
+	}
+}
+
+func TestSQLiteQueryEvents(t *testing.T) {
+	dbal := beginSQLite(t, newTestSQLiteDatabase(t), testChannel)
+
+	require.NoError(t, dbal.AddComputeTasks(newSQLiteTestTask("task", 0)))
+	require.NoError(t, dbal.AddEvents(
+		newSQLiteTestEvent("e1", 3, &asset.ComputeTask{Key: "task", ComputePlanKey: "cp"}),
+		newSQLiteTestEvent("e2", 1, &asset.ComputeTask{Key: "other"}),
+		&asset.Event{
+			Id:        "e3",
+			AssetKey:  "model",
+			AssetKind: asset.AssetKind_ASSET_MODEL,
+			EventKind: asset.EventKind_EVENT_ASSET_CREATED,
+			Timestamp: timestamppb.New(sqliteTestTime(2)),
+			Asset:     &asset.Event_Model{Model: &asset.Model{Key: "model", ComputeTaskKey: "task"}},
+		},
+	))
+
+	// Events are sorted by position, whatever their timestamp
+	events, token, err := dbal.QueryEvents(common.NewPagination("", 2), nil, asset.SortOrder_DESCENDING)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	require.Len(t, events, 2)
+	assert.Equal(t, "e3", events[0].Id)
+	assert.Equal(t, "e2", events[1].Id)
+	assert.Equal(t, testChannel, events[0].Channel)
+
+	events, token, err = dbal.QueryEvents(common.NewPagination(token, 2), nil, asset.SortOrder_DESCENDING)
+	assert.NoError(t, err)
+	assert.Empty(t, token)
+	require.Len(t, events, 1)
+	assert.Equal(t, "e1", events[0].Id)
+
+	events, _, err = dbal.QueryEvents(common.NewPagination("", 10), &asset.EventQueryFilter{ComputePlanKey: "cp"}, asset.SortOrder_ASCENDING)
+	assert.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "e1", events[0].Id)
+	assert.Equal(t, "e3", events[1].Id)
+
+	events, _, err = dbal.QueryEvents(common.NewPagination("", 10), &asset.EventQueryFilter{
+		AssetKinds: []asset.AssetKind{asset.AssetKind_ASSET_COMPUTE_TASK},
+		End:        timestamppb.New(sqliteTestTime(1)),
+	}, asset.SortOrder_ASCENDING)
+	assert.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "e2", events[0].Id)
+}
+
+func TestSQLiteQueryEventsInsertedBehindCursor(t *testing.T) {
+	db := newTestSQLiteDatabase(t)
+	task := &asset.ComputeTask{Key: "task"}
+	addSQLiteTestEvents(t, db, testChannel, newSQLiteTestEvent("e1", 2, task), newSQLiteTestEvent("e2", 3, task))
+
+	tx, err := db.BeginDBAL(context.Background(), testChannel, true)
+	require.NoError(t, err)
+	events, token, err := tx.QueryEvents(common.NewPagination("", 1), nil, asset.SortOrder_ASCENDING)
+	require.NoError(t, tx.Rollback(context.Background()))
+	assert.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "e1", events[0].Id)
+
+	// A transaction which started before the first page was read commits an older timestamp
+	addSQLiteTestEvents(t, db, testChannel, newSQLiteTestEvent("e3", 1, task))
+
+	tx, err = db.BeginDBAL(context.Background(), testChannel, true)
+	require.NoError(t, err)
+	defer tx.Rollback(context.Background()) //nolint:errcheck
+	events, _, err = tx.QueryEvents(common.NewPagination(token, 10), nil, asset.SortOrder_ASCENDING)
+	assert.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "e2", events[0].Id)
+	assert.Equal(t, "e3", events[1].Id, "an event stored after the cursor should be returned")
+}
+
+func TestSQLiteAckEvents(t *testing.T) {
+	dbal := beginSQLite(t, newTestSQLiteDatabase(t), testChannel)
+
+	task := &asset.ComputeTask{Key: "task"}
+	require.NoError(t, dbal.AddEvents(newSQLiteTestEvent("e1", 1, task), newSQLiteTestEvent("e2", 2, task), newSQLiteTestEvent("e3", 3, task)))
+
+	require.NoError(t, dbal.AckEvents("owner", "consumer", "e2", sqliteTestTime(10)))
+	// Acknowledging an older event doesn't move the position backward
+	require.NoError(t, dbal.AckEvents("owner", "consumer", "e1", sqliteTestTime(11)))
+	require.NoError(t, dbal.AckEvents("other", "consumer", "e3", sqliteTestTime(12)))
+
+	consumers, _, err := dbal.QueryEventConsumers(common.NewPagination("", 10), "owner")
+	assert.NoError(t, err)
+	require.Len(t, consumers, 1)
+	assert.Equal(t, "e2", consumers[0].LastAckedEventId)
+	assert.Equal(t, uint64(1), consumers[0].Lag)
+	assert.Equal(t, sqliteTestTime(11), consumers[0].LastAckDate.AsTime())
+
+	assert.Error(t, dbal.AckEvents("owner", "consumer", "unknown", sqliteTestTime(12)))
+}
+
+func TestSQLiteVerifyEventChain(t *testing.T) {
+	dbal := beginSQLite(t, newTestSQLiteDatabase(t), testChannel)
+
+	task := &asset.ComputeTask{Key: "task"}
+	require.NoError(t, dbal.AddEvents(newSQLiteTestEvent("e1", 1, task)))
+	require.NoError(t, dbal.AddEvents(newSQLiteTestEvent("e2", 2, task)))
+
+	status, err := dbal.VerifyEventChain()
+	assert.NoError(t, err)
+	assert.True(t, status.Valid)
+	assert.Equal(t, uint64(2), status.VerifiedEvents)
+	assert.Equal(t, "e2", status.LastEventId)
+
+	_, err = dbal.tx.Exec("UPDATE events SET asset_key = 'tampered' WHERE id = 'e1'")
+	require.NoError(t, err)
+
+	status, err = dbal.VerifyEventChain()
+	assert.NoError(t, err)
+	assert.False(t, status.Valid)
+	assert.Equal(t, "e1", status.FirstBrokenLink.EventId)
+}
+
+func TestSQLiteVerifyEventChainAfterPurge(t *testing.T) {
+	dbal := beginSQLite(t, newTestSQLiteDatabase(t), testChannel)
+
+	task := newSQLiteTestTask("task", 0)
+	other := &asset.ComputeTask{Key: "other"}
+	require.NoError(t, dbal.AddComputePlan(&asset.ComputePlan{Key: "cp", CreationDate: timestamppb.New(sqliteTestTime(0))}))
+	require.NoError(t, dbal.AddComputeTasks(task))
+	require.NoError(t, dbal.AddEvents(newSQLiteTestEvent("e1", 1, task), newSQLiteTestEvent("e2", 2, other), newSQLiteTestEvent("e3", 3, task)))
+
+	_, err := dbal.PurgeComputePlan("cp", sqliteTestTime(4))
+	require.NoError(t, err)
+	// The new event is chained to the last event of the channel, which has been purged
+	require.NoError(t, dbal.AddEvents(newSQLiteTestEvent("e4", 5, other)))
+
+	status, err := dbal.VerifyEventChain()
+	assert.NoError(t, err)
+	assert.True(t, status.Valid)
+	assert.Equal(t, uint64(2), status.VerifiedEvents)
+	assert.Equal(t, uint64(2), status.PurgedEvents)
+	assert.Equal(t, "e4", status.LastEventId)
+
+	// Deleting an event without keeping its hash still breaks the chain
+	_, err = dbal.tx.Exec("DELETE FROM events WHERE id = 'e2'")
+	require.NoError(t, err)
+
+	status, err = dbal.VerifyEventChain()
+	assert.NoError(t, err)
+	assert.False(t, status.Valid)
+	assert.Equal(t, "e3", status.FirstBrokenLink.EventId, "the link of the purged event should be checked")
+}
+
+func TestSQLiteWebhookEvents(t *testing.T) {
+	dbal := beginSQLite(t, newTestSQLiteDatabase(t), testChannel)
+
+	task := &asset.ComputeTask{Key: "task"}
+	require.NoError(t, dbal.AddEvents(newSQLiteTestEvent("e1", 1, task)))
+
+	webhook := &asset.Webhook{Key: "webhook", Owner: "owner", CreationDate: timestamppb.New(sqliteTestTime(1))}
+	require.NoError(t, dbal.AddWebhook(webhook, "secret"))
+
+	keys, err := dbal.GetDueWebhookKeys(sqliteTestTime(10))
+	assert.NoError(t, err)
+	assert.Empty(t, keys, "events emitted before the registration should not be pushed")
+
+	require.NoError(t, dbal.AddEvents(newSQLiteTestEvent("e2", 2, task), newSQLiteTestEvent("e3", 3, task)))
+
+	keys, err = dbal.GetDueWebhookKeys(sqliteTestTime(10))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"webhook"}, keys)
+
+	events, lastScanned, err := dbal.GetWebhookEvents(webhook, 1)
+	assert.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "e2", events[0].Id)
+	assert.Equal(t, "e2", lastScanned)
+
+	// Events not matching the filter are scanned as well
+	filtered := &asset.Webhook{Key: "webhook", Filter: &asset.EventQueryFilter{AssetKind: asset.AssetKind_ASSET_COMPUTE_PLAN}}
+	events, lastScanned, err = dbal.GetWebhookEvents(filtered, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Equal(t, "e3", lastScanned)
+
+	require.NoError(t, dbal.SetWebhookCursor("webhook", "e3"))
+	keys, err = dbal.GetDueWebhookKeys(sqliteTestTime(10))
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, lastScanned, err = dbal.GetWebhookEvents(webhook, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, lastScanned)
+}
+
+func TestSQLiteWebhookClaims(t *testing.T) {
+	dbal := beginSQLite(t, newTestSQLiteDatabase(t), testChannel)
+
+	task := &asset.ComputeTask{Key: "task"}
+	webhook := &asset.Webhook{Key: "webhook", Owner: "owner", CreationDate: timestamppb.New(sqliteTestTime(1))}
+	require.NoError(t, dbal.AddWebhook(webhook, "secret"))
+	require.NoError(t, dbal.AddEvents(newSQLiteTestEvent("e1", 1, task)))
+
+	secret, claimed, err := dbal.ClaimWebhook("webhook", "claim1", sqliteTestTime(10), sqliteTestTime(20))
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Equal(t, "secret", secret)
+
+	keys, err := dbal.GetDueWebhookKeys(sqliteTestTime(10))
+	assert.NoError(t, err)
+	assert.Empty(t, keys, "claimed webhooks should not be due")
+
+	_, claimed, err = dbal.ClaimWebhook("webhook", "claim2", sqliteTestTime(15), sqliteTestTime(25))
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	// The claim expired, another dispatch takes over
+	_, claimed, err = dbal.ClaimWebhook("webhook", "claim2", sqliteTestTime(21), sqliteTestTime(30))
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	released, err := dbal.ReleaseWebhook("webhook", "claim1")
+	assert.NoError(t, err)
+	assert.False(t, released)
+
+	released, err = dbal.ReleaseWebhook("webhook", "claim2")
+	assert.NoError(t, err)
+	assert.True(t, released)
+
+	keys, err = dbal.GetDueWebhookKeys(sqliteTestTime(22))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"webhook"}, keys)
+}
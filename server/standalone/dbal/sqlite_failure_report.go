@@ -0,0 +1,37 @@
+package dbal
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// GetFailureReport returns the failure report of the given attempt, or the latest one if attempt is 0.
+func (d *SQLiteDBAL) GetFailureReport(assetKey string, attempt uint32) (*asset.FailureReport, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("failure_reports").
+		Where(sq.Eq{"channel": d.channel, "asset_key": assetKey}).
+		OrderBy("attempt DESC").
+		Limit(1)
+
+	if attempt > 0 {
+		stmt = stmt.Where(sq.Eq{"attempt": attempt})
+	}
+
+	return getAsset[asset.FailureReport](d, stmt, "failure report", assetKey)
+}
+
+// AddFailureReport implements persistence.FailureReportDBAL
+func (d *SQLiteDBAL) AddFailureReport(failureReport *asset.FailureReport) error {
+	data, err := marshalAsset(failureReport)
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Insert("failure_reports").
+		Columns("channel", "asset_key", "attempt", "creation_date", "asset").
+		Values(d.channel, failureReport.AssetKey, failureReport.Attempt, sqliteTime(failureReport.CreationDate.AsTime()), data)
+
+	return d.exec(stmt)
+}
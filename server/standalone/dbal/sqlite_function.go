@@ -0,0 +1,83 @@
+package dbal
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+)
+
+// AddFunction implements persistence.FunctionDBAL
+func (d *SQLiteDBAL) AddFunction(function *asset.Function) error {
+	data, err := marshalAsset(function)
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Insert("functions").
+		Columns("key", "channel", "creation_date", "asset").
+		Values(function.Key, d.channel, sqliteTime(function.CreationDate.AsTime()), data)
+
+	return d.exec(stmt)
+}
+
+// GetFunction implements persistence.FunctionDBAL
+func (d *SQLiteDBAL) GetFunction(key string) (*asset.Function, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("functions").
+		Where(sq.Eq{"channel": d.channel, "key": key})
+
+	return getAsset[asset.Function](d, stmt, asset.FunctionKind, key)
+}
+
+// QueryFunctions implements persistence.FunctionDBAL
+func (d *SQLiteDBAL) QueryFunctions(p *common.Pagination, filter *asset.FunctionQueryFilter) ([]*asset.Function, common.PaginationToken, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("functions").
+		Where(sq.Eq{"channel": d.channel})
+
+	if filter != nil && filter.ComputePlanKey != "" {
+		stmt = stmt.Where(sq.Expr(
+			"key IN (SELECT function_key FROM compute_tasks WHERE channel = ? AND compute_plan_key = ?)",
+			d.channel, filter.ComputePlanKey,
+		))
+	}
+
+	return queryAssetPage(d, stmt, p, newKeyset(false, "creation_date", "key"), func(f *asset.Function) []string {
+		return []string{sqliteTime(f.CreationDate.AsTime()), f.Key}
+	})
+}
+
+// FunctionExists implements persistence.FunctionDBAL
+func (d *SQLiteDBAL) FunctionExists(key string) (bool, error) {
+	return d.exists(getSQLiteStatementBuilder().
+		Select("key").
+		From("functions").
+		Where(sq.Eq{"channel": d.channel, "key": key}))
+}
+
+// UpdateFunction implements persistence.FunctionDBAL
+// Only the name, the status and the image of the function are updated.
+func (d *SQLiteDBAL) UpdateFunction(function *asset.Function) error {
+	update := sq.Expr("json_set(asset, '$.name', ?, '$.status', ?)", function.Name, function.Status.String())
+
+	if function.GetImage().GetStorageAddress() != "" {
+		image, err := marshalAsset(function.Image)
+		if err != nil {
+			return err
+		}
+		update = sq.Expr(
+			"json_set(asset, '$.name', ?, '$.status', ?, '$.image', json(?))",
+			function.Name, function.Status.String(), image,
+		)
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Update("functions").
+		Set("asset", update).
+		Where(sq.Eq{"channel": d.channel, "key": function.Key})
+
+	return d.exec(stmt)
+}
@@ -0,0 +1,107 @@
+package dbal
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sqliteMigrations holds the schema migrations of SQLite databases.
+// Like PostgreSQL migrations, they are named <version>_<description>.up.sql.
+//
+//go:embed sqlite_migration/*.up.sql
+var sqliteMigrations embed.FS
+
+// sqliteMigration is a migration file along with its version.
+type sqliteMigration struct {
+	version int
+	name    string
+}
+
+// listSQLiteMigrations returns the embedded migrations sorted by version.
+func listSQLiteMigrations() ([]sqliteMigration, error) {
+	names, err := fs.Glob(sqliteMigrations, "sqlite_migration/*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]sqliteMigration, 0, len(names))
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(strings.TrimPrefix(name, "sqlite_migration/"), "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration name %s: %w", name, err)
+		}
+		migrations = append(migrations, sqliteMigration{version: version, name: name})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
+
+// migrateSQLite applies the migrations which have not been applied to the database yet.
+// The schema version is recorded in the schema_migrations table, like golang-migrate does for PostgreSQL.
+// Each migration is applied in its own transaction.
+func migrateSQLite(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL PRIMARY KEY, dirty INTEGER NOT NULL)")
+	if err != nil {
+		return err
+	}
+
+	migrations, err := listSQLiteMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		if err := applySQLiteMigration(ctx, db, migration); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", migration.name, err)
+		}
+	}
+
+	return nil
+}
+
+// applySQLiteMigration applies a migration unless the database schema is already at the same version or above.
+func applySQLiteMigration(ctx context.Context, db *sql.DB, migration sqliteMigration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var current int
+	err = tx.QueryRowContext(ctx, "SELECT version FROM schema_migrations").Scan(&current)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if current >= migration.version {
+		return nil
+	}
+
+	content, err := sqliteMigrations.ReadFile(migration.name)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, string(content)); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES (?, 0)", migration.version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
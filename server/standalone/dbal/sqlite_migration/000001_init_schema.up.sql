@@ -0,0 +1,165 @@
+-- Schema equivalent to the PostgreSQL migrations up to 000074_add_event_sink_positions.
+-- Assets are stored in their protojson representation in the asset column,
+-- the other columns are copies of the fields used to filter, sort and join assets.
+-- Timestamps are stored as text in the fixed width format 2006-01-02T15:04:05.000000Z,
+-- so that they sort chronologically.
+-- Event sinks are not supported by the SQLite backend, hence there is no event_sink_positions table.
+
+CREATE TABLE organizations (
+    id TEXT NOT NULL,
+    channel TEXT NOT NULL,
+    creation_date TEXT NOT NULL,
+    asset TEXT NOT NULL,
+    PRIMARY KEY (channel, id)
+);
+
+CREATE TABLE datamanagers (
+    key TEXT PRIMARY KEY,
+    channel TEXT NOT NULL,
+    creation_date TEXT NOT NULL,
+    asset TEXT NOT NULL
+);
+CREATE INDEX ix_datamanagers_channel_creation ON datamanagers (channel, creation_date, key);
+
+CREATE TABLE datasamples (
+    key TEXT PRIMARY KEY,
+    channel TEXT NOT NULL,
+    creation_date TEXT NOT NULL,
+    asset TEXT NOT NULL
+);
+CREATE INDEX ix_datasamples_channel_creation ON datasamples (channel, creation_date, key);
+
+CREATE TABLE functions (
+    key TEXT PRIMARY KEY,
+    channel TEXT NOT NULL,
+    creation_date TEXT NOT NULL,
+    asset TEXT NOT NULL
+);
+CREATE INDEX ix_functions_channel_creation ON functions (channel, creation_date, key);
+
+CREATE TABLE compute_plans (
+    key TEXT PRIMARY KEY,
+    channel TEXT NOT NULL,
+    owner TEXT NOT NULL,
+    creation_date TEXT NOT NULL,
+    cancelation_date TEXT,
+    failure_date TEXT,
+    purge_date TEXT,
+    asset TEXT NOT NULL
+);
+CREATE INDEX ix_compute_plans_channel_creation ON compute_plans (channel, creation_date, key);
+
+CREATE TABLE compute_tasks (
+    key TEXT PRIMARY KEY,
+    channel TEXT NOT NULL,
+    compute_plan_key TEXT NOT NULL,
+    function_key TEXT NOT NULL,
+    owner TEXT NOT NULL,
+    worker TEXT NOT NULL,
+    status TEXT NOT NULL,
+    rank INTEGER NOT NULL,
+    creation_date TEXT NOT NULL,
+    lease_expiration TEXT,
+    asset TEXT NOT NULL
+);
+CREATE INDEX ix_compute_tasks_compute_plan_key ON compute_tasks (compute_plan_key);
+CREATE INDEX ix_compute_tasks_function_key ON compute_tasks (function_key);
+CREATE INDEX ix_compute_tasks_channel_creation ON compute_tasks (channel, creation_date, key);
+CREATE INDEX ix_compute_tasks_lease_expiration ON compute_tasks (lease_expiration) WHERE lease_expiration IS NOT NULL;
+
+-- Parents are stored in the order of the task inputs
+CREATE TABLE compute_task_parents (
+    child_task_key TEXT NOT NULL,
+    parent_task_key TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (child_task_key, parent_task_key)
+);
+CREATE INDEX ix_compute_task_parents_parent_task_key ON compute_task_parents (parent_task_key);
+
+CREATE TABLE compute_task_output_assets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    compute_task_key TEXT NOT NULL,
+    compute_task_output_identifier TEXT NOT NULL,
+    asset TEXT NOT NULL
+);
+CREATE INDEX ix_compute_task_output_assets_compute_task ON compute_task_output_assets (compute_task_key, compute_task_output_identifier);
+
+CREATE TABLE models (
+    key TEXT PRIMARY KEY,
+    channel TEXT NOT NULL,
+    compute_task_key TEXT NOT NULL,
+    creation_date TEXT NOT NULL,
+    asset TEXT NOT NULL
+);
+CREATE INDEX ix_models_compute_task_key ON models (compute_task_key);
+
+CREATE TABLE performances (
+    channel TEXT NOT NULL,
+    compute_task_key TEXT NOT NULL,
+    compute_task_output_identifier TEXT NOT NULL,
+    creation_date TEXT NOT NULL,
+    asset TEXT NOT NULL,
+    PRIMARY KEY (compute_task_key, compute_task_output_identifier)
+);
+CREATE INDEX ix_performances_channel_creation ON performances (channel, creation_date);
+
+CREATE TABLE failure_reports (
+    channel TEXT NOT NULL,
+    asset_key TEXT NOT NULL,
+    attempt INTEGER NOT NULL,
+    creation_date TEXT NOT NULL,
+    asset TEXT NOT NULL,
+    PRIMARY KEY (asset_key, attempt)
+);
+
+-- Positions are shared by every channel, they order events by insertion.
+-- The hash of an event chains it to the previous event of the channel.
+CREATE TABLE events (
+    position INTEGER PRIMARY KEY AUTOINCREMENT,
+    id TEXT NOT NULL UNIQUE,
+    channel TEXT NOT NULL,
+    asset_key TEXT NOT NULL,
+    asset_kind TEXT NOT NULL,
+    event_kind TEXT NOT NULL,
+    timestamp TEXT NOT NULL,
+    asset TEXT NOT NULL,
+    metadata TEXT NOT NULL,
+    hash BLOB NOT NULL
+);
+CREATE INDEX ix_events_channel_position ON events (channel, position);
+CREATE INDEX ix_events_channel_timestamp ON events (channel, timestamp, id);
+CREATE INDEX ix_events_asset_key ON events (asset_key);
+
+CREATE TABLE event_consumers (
+    channel TEXT NOT NULL,
+    owner TEXT NOT NULL,
+    name TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    ack_date TEXT NOT NULL,
+    PRIMARY KEY (channel, owner, name)
+);
+
+-- The position of a webhook is the position of the last event pushed to it
+CREATE TABLE webhooks (
+    key TEXT PRIMARY KEY,
+    channel TEXT NOT NULL,
+    owner TEXT NOT NULL,
+    creation_date TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    asset TEXT NOT NULL
+);
+CREATE INDEX ix_webhooks_channel_owner ON webhooks (channel, owner, creation_date, key);
+
+CREATE TABLE webhook_deliveries (
+    id TEXT PRIMARY KEY,
+    webhook_key TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    status TEXT NOT NULL,
+    next_attempt_date TEXT,
+    creation_date TEXT NOT NULL,
+    asset TEXT NOT NULL,
+    UNIQUE (webhook_key, event_id)
+);
+CREATE INDEX ix_webhook_deliveries_webhook_creation ON webhook_deliveries (webhook_key, creation_date, id);
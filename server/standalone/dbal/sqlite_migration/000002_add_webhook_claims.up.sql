@@ -0,0 +1,3 @@
+-- The claim of a webhook identifies the dispatcher pushing events to it, until the claim expires
+ALTER TABLE webhooks ADD COLUMN claim_id TEXT;
+ALTER TABLE webhooks ADD COLUMN claimed_until TEXT;
@@ -0,0 +1,17 @@
+ALTER TABLE events ADD COLUMN previous_hash BLOB;
+UPDATE events SET previous_hash = (
+    SELECT e.hash FROM events e
+    WHERE e.channel = events.channel AND e.position < events.position
+    ORDER BY e.position DESC
+    LIMIT 1
+);
+
+-- Hashes of the events removed by a purge, linking the remaining events of the chain
+CREATE TABLE event_tombstones (
+    position INTEGER PRIMARY KEY,
+    channel TEXT NOT NULL,
+    id TEXT NOT NULL,
+    hash BLOB NOT NULL,
+    previous_hash BLOB
+);
+CREATE INDEX ix_event_tombstones_channel_position ON event_tombstones (channel, position);
@@ -0,0 +1,77 @@
+package dbal
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// GetModel implements persistence.ModelDBAL
+func (d *SQLiteDBAL) GetModel(key string) (*asset.Model, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("models").
+		Where(sq.Eq{"channel": d.channel, "key": key})
+
+	return getAsset[asset.Model](d, stmt, "model", key)
+}
+
+// ModelExists implements persistence.ModelDBAL
+func (d *SQLiteDBAL) ModelExists(key string) (bool, error) {
+	return d.exists(getSQLiteStatementBuilder().
+		Select("key").
+		From("models").
+		Where(sq.Eq{"channel": d.channel, "key": key}))
+}
+
+// GetComputeTaskOutputModels implements persistence.ModelDBAL
+func (d *SQLiteDBAL) GetComputeTaskOutputModels(key string) ([]*asset.Model, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("models").
+		Where(sq.Eq{"channel": d.channel, "compute_task_key": key}).
+		OrderBy("creation_date", "key")
+
+	return queryAssets[asset.Model](d, stmt)
+}
+
+// AddModel implements persistence.ModelDBAL
+func (d *SQLiteDBAL) AddModel(model *asset.Model, identifier string) error {
+	data, err := marshalAsset(model)
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Insert("models").
+		Columns("key", "channel", "compute_task_key", "creation_date", "asset").
+		Values(model.Key, d.channel, model.ComputeTaskKey, sqliteTime(model.CreationDate.AsTime()), data)
+
+	return d.exec(stmt)
+}
+
+// UpdateModel implements persistence.ModelDBAL
+// The task, permissions, owner and address of the model are updated, a nil address disables the model.
+func (d *SQLiteDBAL) UpdateModel(model *asset.Model) error {
+	updated, err := d.GetModel(model.Key)
+	if err != nil {
+		return err
+	}
+
+	updated.ComputeTaskKey = model.ComputeTaskKey
+	updated.Permissions = model.Permissions
+	updated.Owner = model.Owner
+	updated.Address = model.Address
+
+	data, err := marshalAsset(updated)
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Update("models").
+		Set("compute_task_key", updated.ComputeTaskKey).
+		Set("asset", data).
+		Where(sq.Eq{"channel": d.channel, "key": model.Key})
+
+	return d.exec(stmt)
+}
@@ -0,0 +1,50 @@
+package dbal
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+)
+
+// AddOrganization implements persistence.OrganizationDBAL
+func (d *SQLiteDBAL) AddOrganization(organization *asset.Organization) error {
+	data, err := marshalAsset(organization)
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Insert("organizations").
+		Columns("id", "channel", "creation_date", "asset").
+		Values(organization.Id, d.channel, sqliteTime(organization.CreationDate.AsTime()), data)
+
+	return d.exec(stmt)
+}
+
+// OrganizationExists implements persistence.OrganizationDBAL
+func (d *SQLiteDBAL) OrganizationExists(id string) (bool, error) {
+	return d.exists(getSQLiteStatementBuilder().
+		Select("id").
+		From("organizations").
+		Where(sq.Eq{"channel": d.channel, "id": id}))
+}
+
+// GetAllOrganizations implements persistence.OrganizationDBAL
+func (d *SQLiteDBAL) GetAllOrganizations() ([]*asset.Organization, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("organizations").
+		Where(sq.Eq{"channel": d.channel}).
+		OrderBy("creation_date", "id")
+
+	return queryAssets[asset.Organization](d, stmt)
+}
+
+// GetOrganization implements persistence.OrganizationDBAL
+func (d *SQLiteDBAL) GetOrganization(id string) (*asset.Organization, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("organizations").
+		Where(sq.Eq{"channel": d.channel, "id": id})
+
+	return getAsset[asset.Organization](d, stmt, "organization", id)
+}
@@ -0,0 +1,62 @@
+package dbal
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+)
+
+// AddPerformance implements persistence.PerformanceDBAL
+func (d *SQLiteDBAL) AddPerformance(perf *asset.Performance, identifier string) error {
+	data, err := marshalAsset(perf)
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Insert("performances").
+		Columns("channel", "compute_task_key", "compute_task_output_identifier", "creation_date", "asset").
+		Values(d.channel, perf.ComputeTaskKey, perf.ComputeTaskOutputIdentifier, sqliteTime(perf.CreationDate.AsTime()), data)
+
+	return d.exec(stmt)
+}
+
+// PerformanceExists implements persistence.PerformanceDBAL
+func (d *SQLiteDBAL) PerformanceExists(perf *asset.Performance) (bool, error) {
+	return d.exists(getSQLiteStatementBuilder().
+		Select("compute_task_key").
+		From("performances").
+		Where(sq.Eq{
+			"channel":                        d.channel,
+			"compute_task_key":               perf.ComputeTaskKey,
+			"compute_task_output_identifier": perf.ComputeTaskOutputIdentifier,
+		}))
+}
+
+// QueryPerformances implements persistence.PerformanceDBAL
+func (d *SQLiteDBAL) QueryPerformances(p *common.Pagination, filter *asset.PerformanceQueryFilter) ([]*asset.Performance, common.PaginationToken, error) {
+	// The output identifier makes the sort unique, so that performances can be used as cursors
+	ks := keyset{
+		{name: "creation_date"},
+		{name: "compute_task_key", desc: true},
+		{name: "compute_task_output_identifier"},
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("performances").
+		Where(sq.Eq{"channel": d.channel})
+
+	if filter != nil {
+		if filter.ComputeTaskKey != "" {
+			stmt = stmt.Where(sq.Eq{"compute_task_key": filter.ComputeTaskKey})
+		}
+		if filter.ComputeTaskOutputIdentifier != "" {
+			stmt = stmt.Where(sq.Eq{"compute_task_output_identifier": filter.ComputeTaskOutputIdentifier})
+		}
+	}
+
+	return queryAssetPage(d, stmt, p, ks, func(perf *asset.Performance) []string {
+		return []string{sqliteTime(perf.CreationDate.AsTime()), perf.ComputeTaskKey, perf.ComputeTaskOutputIdentifier}
+	})
+}
@@ -0,0 +1,208 @@
+package dbal
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newTestSQLiteDatabase(t *testing.T) *SQLiteDatabase {
+	db, err := InitSQLiteDatabase(SQLiteDatabaseScheme + filepath.Join(t.TempDir(), "orchestrator.db"))
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+// beginSQLite returns a write transaction which is rolled back at the end of the test.
+func beginSQLite(t *testing.T, db *SQLiteDatabase, channel string) *SQLiteDBAL {
+	tx, err := db.BeginDBAL(context.Background(), channel, false)
+	require.NoError(t, err)
+	t.Cleanup(func() { tx.Rollback(context.Background()) }) //nolint:errcheck
+
+	return tx.(*SQLiteDBAL)
+}
+
+func sqliteTestTime(seconds int64) time.Time {
+	return time.Unix(1700000000+seconds, 0).UTC()
+}
+
+func TestIsSQLiteDatabaseURL(t *testing.T) {
+	assert.True(t, IsSQLiteDatabaseURL("sqlite:///var/lib/orchestrator.db"))
+	assert.False(t, IsSQLiteDatabaseURL("postgresql://localhost/orchestrator"))
+	assert.False(t, IsSQLiteDatabaseURL(MemoryDatabaseURL))
+
+	_, err := InitSQLiteDatabase("sqlite://")
+	assert.Error(t, err)
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	path := SQLiteDatabaseScheme + filepath.Join(t.TempDir(), "orchestrator.db")
+
+	db, err := InitSQLiteDatabase(path)
+	require.NoError(t, err)
+
+	dbal := beginSQLite(t, db, testChannel)
+	require.NoError(t, dbal.AddOrganization(&asset.Organization{Id: "org1", CreationDate: timestamppb.New(sqliteTestTime(0))}))
+	require.NoError(t, dbal.Commit(context.Background()))
+	db.Close()
+
+	// Opening the database again should not apply the migrations twice
+	db, err = InitSQLiteDatabase(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var version int
+	require.NoError(t, db.db.QueryRow("SELECT version FROM schema_migrations").Scan(&version))
+	migrations, err := listSQLiteMigrations()
+	require.NoError(t, err)
+	assert.Equal(t, migrations[len(migrations)-1].version, version)
+
+	exists, err := beginSQLite(t, db, testChannel).OrganizationExists("org1")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestSQLiteRollback(t *testing.T) {
+	db := newTestSQLiteDatabase(t)
+
+	dbal := beginSQLite(t, db, testChannel)
+	require.NoError(t, dbal.AddComputePlan(&asset.ComputePlan{Key: "cp", CreationDate: timestamppb.New(sqliteTestTime(0))}))
+	require.NoError(t, dbal.Rollback(context.Background()))
+
+	exists, err := beginSQLite(t, db, testChannel).ComputePlanExists("cp")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestSQLiteChannelIsolation(t *testing.T) {
+	db := newTestSQLiteDatabase(t)
+	dbal := beginSQLite(t, db, testChannel)
+
+	require.NoError(t, dbal.AddOrganization(&asset.Organization{Id: "org1", CreationDate: timestamppb.New(sqliteTestTime(0))}))
+
+	exists, err := dbal.OrganizationExists("org1")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	other := &SQLiteDBAL{ctx: dbal.ctx, db: dbal.db, tx: dbal.tx, channel: "otherchannel"}
+	exists, err = other.OrganizationExists("org1")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = other.GetOrganization("org1")
+	orcErr := new(orcerrors.OrcError)
+	assert.ErrorAs(t, err, &orcErr)
+	assert.Equal(t, orcerrors.ErrNotFound, orcErr.Kind)
+}
+
+func TestSQLiteBusy(t *testing.T) {
+	db := newTestSQLiteDatabase(t)
+	ctx := context.Background()
+
+	tx, err := db.BeginDBAL(ctx, testChannel, false)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	// Write transactions lock the database as soon as they begin
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	conn, err := db.db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, "PRAGMA busy_timeout = 0")
+	require.NoError(t, err)
+
+	_, err = conn.ExecContext(ctx, "BEGIN IMMEDIATE")
+	assert.True(t, IsSQLiteBusy(err), "unexpected error %v", err)
+
+	assert.False(t, IsSQLiteBusy(nil))
+	assert.False(t, IsSQLiteBusy(orcerrors.NewInternal("not busy")))
+}
+
+func TestSQLiteReadOnlyTransactionsDoNotLock(t *testing.T) {
+	db := newTestSQLiteDatabase(t)
+	ctx := context.Background()
+
+	writer, err := db.BeginDBAL(ctx, testChannel, false)
+	require.NoError(t, err)
+	defer writer.Rollback(ctx) //nolint:errcheck
+
+	reader, err := db.BeginDBAL(ctx, testChannel, true)
+	require.NoError(t, err)
+	defer reader.Rollback(ctx) //nolint:errcheck
+
+	_, err = reader.GetAllOrganizations()
+	assert.NoError(t, err)
+}
+
+func TestSQLitePagination(t *testing.T) {
+	db := newTestSQLiteDatabase(t)
+	dbal := beginSQLite(t, db, testChannel)
+
+	require.NoError(t, dbal.AddDataSamples(
+		&asset.DataSample{Key: "b", CreationDate: timestamppb.New(sqliteTestTime(2))},
+		&asset.DataSample{Key: "a", CreationDate: timestamppb.New(sqliteTestTime(2))},
+		&asset.DataSample{Key: "c", CreationDate: timestamppb.New(sqliteTestTime(1))},
+	))
+
+	samples, token, err := dbal.QueryDataSamples(common.NewPagination("", 2), nil)
+	assert.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, []string{"c", "a"}, []string{samples[0].Key, samples[1].Key})
+	require.NotEmpty(t, token)
+
+	samples, token, err = dbal.QueryDataSamples(common.NewPagination(token, 2), nil)
+	assert.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, "b", samples[0].Key)
+	assert.Equal(t, "", token)
+
+	samples, _, err = dbal.QueryDataSamples(common.NewPagination("", 10), &asset.DataSampleQueryFilter{Keys: []string{"a", "b"}})
+	assert.NoError(t, err)
+	assert.Len(t, samples, 2)
+}
+
+func TestSQLiteUpdates(t *testing.T) {
+	db := newTestSQLiteDatabase(t)
+	dbal := beginSQLite(t, db, testChannel)
+
+	require.NoError(t, dbal.AddDataSamples(&asset.DataSample{Key: "ds", DataManagerKeys: []string{"dm1"}, CreationDate: timestamppb.New(sqliteTestTime(0))}))
+	require.NoError(t, dbal.UpdateDataSample(&asset.DataSample{Key: "ds", Owner: "org", DataManagerKeys: []string{"dm1", "dm2"}}))
+
+	sample, err := dbal.GetDataSample("ds")
+	require.NoError(t, err)
+	assert.Equal(t, "org", sample.Owner)
+	assert.Equal(t, []string{"dm1", "dm2"}, sample.DataManagerKeys)
+
+	keys, err := dbal.GetDataSampleKeysByManager("dm2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ds"}, keys)
+
+	require.NoError(t, dbal.AddComputePlan(&asset.ComputePlan{Key: "cp", Name: "before", CreationDate: timestamppb.New(sqliteTestTime(0))}))
+	require.NoError(t, dbal.SetComputePlanName(&asset.ComputePlan{Key: "cp"}, "after"))
+	require.NoError(t, dbal.FailComputePlan(&asset.ComputePlan{Key: "cp"}, sqliteTestTime(1)))
+
+	plan, err := dbal.GetComputePlan("cp")
+	require.NoError(t, err)
+	assert.Equal(t, "after", plan.Name)
+	assert.Equal(t, sqliteTestTime(1), plan.FailureDate.AsTime())
+
+	require.NoError(t, dbal.CancelComputePlan(&asset.ComputePlan{Key: "cp"}, sqliteTestTime(2)))
+	plan, err = dbal.GetComputePlan("cp")
+	require.NoError(t, err)
+	assert.Nil(t, plan.FailureDate, "the failure date of a canceled plan should not be returned")
+
+	require.NoError(t, dbal.RestoreComputePlan(&asset.ComputePlan{Key: "cp"}))
+	keys, err = dbal.GetPurgeableComputePlanKeys(sqliteTestTime(3))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cp"}, keys)
+}
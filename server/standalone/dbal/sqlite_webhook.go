@@ -0,0 +1,345 @@
+package dbal
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// AddWebhook implements persistence.WebhookDBAL
+// Its cursor is set to the last event so that only new events are pushed.
+func (d *SQLiteDBAL) AddWebhook(webhook *asset.Webhook, secret string) error {
+	stored := proto.Clone(webhook).(*asset.Webhook)
+	stored.Filter = webhookFilter(webhook)
+
+	data, err := marshalAsset(stored)
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Insert("webhooks").
+		Columns("key", "channel", "owner", "creation_date", "secret", "position", "asset").
+		Values(
+			webhook.Key, d.channel, webhook.Owner, sqliteTime(webhook.CreationDate.AsTime()), secret,
+			sq.Expr("(SELECT COALESCE(MAX(position), 0) FROM events)"), data,
+		)
+
+	return d.exec(stmt)
+}
+
+// UpdateWebhook implements persistence.WebhookDBAL
+// The url and filter of the webhook are updated, the secret is only updated when not empty.
+func (d *SQLiteDBAL) UpdateWebhook(webhook *asset.Webhook, secret string) error {
+	filter, err := marshalAsset(webhookFilter(webhook))
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Update("webhooks").
+		Set("asset", sq.Expr("json_set(asset, '$.url', ?, '$.filter', json(?))", webhook.Url, filter)).
+		Where(sq.Eq{"channel": d.channel, "key": webhook.Key})
+
+	if secret != "" {
+		stmt = stmt.Set("secret", secret)
+	}
+
+	return d.exec(stmt)
+}
+
+// DeleteWebhook implements persistence.WebhookDBAL
+func (d *SQLiteDBAL) DeleteWebhook(key string) error {
+	deliveries := getSQLiteStatementBuilder().
+		Delete("webhook_deliveries").
+		Where(sq.Eq{"webhook_key": key}).
+		Where(sq.Expr("EXISTS (SELECT 1 FROM webhooks WHERE channel = ? AND key = ?)", d.channel, key))
+	if err := d.exec(deliveries); err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Delete("webhooks").
+		Where(sq.Eq{"channel": d.channel, "key": key})
+
+	return d.exec(stmt)
+}
+
+// GetWebhook implements persistence.WebhookDBAL
+func (d *SQLiteDBAL) GetWebhook(key string) (*asset.Webhook, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("webhooks").
+		Where(sq.Eq{"channel": d.channel, "key": key})
+
+	return getAsset[asset.Webhook](d, stmt, asset.WebhookKind, key)
+}
+
+// WebhookExists implements persistence.WebhookDBAL
+func (d *SQLiteDBAL) WebhookExists(key string) (bool, error) {
+	return d.exists(getSQLiteStatementBuilder().
+		Select("key").
+		From("webhooks").
+		Where(sq.Eq{"channel": d.channel, "key": key}))
+}
+
+// QueryWebhooks implements persistence.WebhookDBAL
+func (d *SQLiteDBAL) QueryWebhooks(p *common.Pagination, owner string) ([]*asset.Webhook, common.PaginationToken, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("webhooks").
+		Where(sq.Eq{"channel": d.channel, "owner": owner})
+
+	return queryAssetPage(d, stmt, p, newKeyset(false, "creation_date", "key"), func(w *asset.Webhook) []string {
+		return []string{sqliteTime(w.CreationDate.AsTime()), w.Key}
+	})
+}
+
+// ClaimWebhook implements persistence.WebhookDBAL
+// Write transactions being serialized, the webhook is locked by the transaction itself.
+func (d *SQLiteDBAL) ClaimWebhook(key string, claimID string, now time.Time, until time.Time) (string, bool, error) {
+	row, err := d.queryRow(getSQLiteStatementBuilder().
+		Select("secret", "claimed_until").
+		From("webhooks").
+		Where(sq.Eq{"channel": d.channel, "key": key}))
+	if err != nil {
+		return "", false, err
+	}
+
+	var secret string
+	var claimedUntil sql.NullString
+	err = row.Scan(&secret, &claimedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, orcerrors.NewNotFound(asset.WebhookKind, key)
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	if claimedUntil.Valid && claimedUntil.String > sqliteTime(now) {
+		return "", false, nil
+	}
+
+	err = d.exec(getSQLiteStatementBuilder().
+		Update("webhooks").
+		Set("claim_id", claimID).
+		Set("claimed_until", sqliteTime(until)).
+		Where(sq.Eq{"channel": d.channel, "key": key}))
+	if err != nil {
+		return "", false, err
+	}
+
+	return secret, true, nil
+}
+
+// ReleaseWebhook implements persistence.WebhookDBAL
+func (d *SQLiteDBAL) ReleaseWebhook(key string, claimID string) (bool, error) {
+	count, err := d.execCount(getSQLiteStatementBuilder().
+		Update("webhooks").
+		Set("claim_id", nil).
+		Set("claimed_until", nil).
+		Where(sq.Eq{"channel": d.channel, "key": key, "claim_id": claimID}))
+
+	return count > 0, err
+}
+
+// GetDueWebhookKeys implements persistence.WebhookDBAL
+// Webhooks waiting for the next attempt of a pending delivery or claimed by a running dispatch are skipped.
+func (d *SQLiteDBAL) GetDueWebhookKeys(now time.Time) ([]string, error) {
+	backingOff := sq.
+		Select("1").
+		From("webhook_deliveries dl").
+		Where("dl.webhook_key = w.key").
+		Where(sq.Eq{"dl.status": asset.WebhookDeliveryStatus_DELIVERY_PENDING.String()}).
+		Where(sq.Gt{"dl.next_attempt_date": sqliteTime(now)})
+
+	newEvents := sq.
+		Select("1").
+		From("events e").
+		Where("e.channel = w.channel").
+		Where("e.position > w.position")
+
+	stmt := getSQLiteStatementBuilder().
+		Select("w.key").
+		From("webhooks w").
+		Where(sq.Eq{"w.channel": d.channel}).
+		Where(sq.Expr("EXISTS (?)", newEvents)).
+		Where(sq.Expr("NOT EXISTS (?)", backingOff)).
+		Where(sq.Or{sq.Eq{"w.claimed_until": nil}, sq.LtOrEq{"w.claimed_until": sqliteTime(now)}}).
+		OrderBy("w.creation_date", "w.key")
+
+	return d.queryStrings(stmt)
+}
+
+// GetWebhookEvents implements persistence.WebhookDBAL
+// Events after the cursor of the webhook matching its filter are returned by position.
+// Unless the limit is reached, every event of the channel has been scanned and the last one is returned as such.
+func (d *SQLiteDBAL) GetWebhookEvents(webhook *asset.Webhook, limit uint32) ([]*asset.Event, string, error) {
+	cursor := sq.
+		Select("position").
+		From("webhooks").
+		Where(sq.Eq{"channel": d.channel, "key": webhook.Key})
+
+	stmt := getSQLiteStatementBuilder().
+		Select(sqliteEventColumns...).
+		From("events").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Expr("position > (?)", cursor)).
+		Where(d.eventFilterConditions(webhook.Filter)).
+		OrderBy("position").
+		Limit(uint64(limit))
+
+	positioned, err := d.queryEvents(stmt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	events := make([]*asset.Event, 0, len(positioned))
+	for _, e := range positioned {
+		events = append(events, e.event)
+	}
+
+	if len(events) == int(limit) {
+		return events, events[len(events)-1].Id, nil
+	}
+
+	lastScanned, err := d.queryStrings(getSQLiteStatementBuilder().
+		Select("id").
+		From("events").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Expr("position > (?)", cursor)).
+		OrderBy("position DESC").
+		Limit(1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(lastScanned) == 0 {
+		return events, "", nil
+	}
+
+	return events, lastScanned[0], nil
+}
+
+// SetWebhookCursor implements persistence.WebhookDBAL
+func (d *SQLiteDBAL) SetWebhookCursor(webhookKey string, eventID string) error {
+	position, err := d.getEventPosition(eventID)
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Update("webhooks").
+		Set("position", position).
+		Where(sq.Eq{"channel": d.channel, "key": webhookKey})
+
+	return d.exec(stmt)
+}
+
+// NewWebhookClaimID implements persistence.WebhookDBAL
+func (d *SQLiteDBAL) NewWebhookClaimID() string {
+	return uuid.NewString()
+}
+
+// NewWebhookDeliveryID implements persistence.WebhookDBAL
+func (d *SQLiteDBAL) NewWebhookDeliveryID() string {
+	return uuid.NewString()
+}
+
+// GetWebhookDelivery implements persistence.WebhookDBAL
+func (d *SQLiteDBAL) GetWebhookDelivery(webhookKey string, eventID string) (*asset.WebhookDelivery, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("webhook_deliveries").
+		Where(sq.Eq{"webhook_key": webhookKey, "event_id": eventID})
+
+	return getAsset[asset.WebhookDelivery](d, stmt, "webhook delivery", eventID)
+}
+
+// AddWebhookDelivery implements persistence.WebhookDBAL
+func (d *SQLiteDBAL) AddWebhookDelivery(delivery *asset.WebhookDelivery) error {
+	data, err := marshalAsset(delivery)
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Insert("webhook_deliveries").
+		Columns("id", "webhook_key", "event_id", "status", "next_attempt_date", "creation_date", "asset").
+		Values(
+			delivery.Id, delivery.WebhookKey, delivery.EventId, delivery.Status.String(),
+			nullSQLiteTimestamp(delivery.NextAttemptDate), sqliteTime(delivery.CreationDate.AsTime()), data,
+		)
+
+	return d.exec(stmt)
+}
+
+// UpdateWebhookDelivery implements persistence.WebhookDBAL
+// The outcome of the delivery attempts is updated.
+func (d *SQLiteDBAL) UpdateWebhookDelivery(delivery *asset.WebhookDelivery) error {
+	row, err := d.queryRow(getSQLiteStatementBuilder().
+		Select("asset").
+		From("webhook_deliveries").
+		Where(sq.Eq{"id": delivery.Id}))
+	if err != nil {
+		return err
+	}
+
+	var data string
+	err = row.Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	stored, err := unmarshalAsset[asset.WebhookDelivery](data)
+	if err != nil {
+		return err
+	}
+
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.LastAttemptDate = delivery.LastAttemptDate
+	stored.NextAttemptDate = delivery.NextAttemptDate
+	stored.ResponseCode = delivery.ResponseCode
+	stored.Error = delivery.Error
+
+	data, err = marshalAsset(stored)
+	if err != nil {
+		return err
+	}
+
+	stmt := getSQLiteStatementBuilder().
+		Update("webhook_deliveries").
+		Set("status", stored.Status.String()).
+		Set("next_attempt_date", nullSQLiteTimestamp(stored.NextAttemptDate)).
+		Set("asset", data).
+		Where(sq.Eq{"id": delivery.Id})
+
+	return d.exec(stmt)
+}
+
+// QueryWebhookDeliveries implements persistence.WebhookDBAL
+// Deliveries are returned most recent first.
+func (d *SQLiteDBAL) QueryWebhookDeliveries(p *common.Pagination, webhookKey string, status asset.WebhookDeliveryStatus) ([]*asset.WebhookDelivery, common.PaginationToken, error) {
+	stmt := getSQLiteStatementBuilder().
+		Select("asset").
+		From("webhook_deliveries").
+		Where(sq.Eq{"webhook_key": webhookKey})
+
+	if status != asset.WebhookDeliveryStatus_DELIVERY_UNKNOWN {
+		stmt = stmt.Where(sq.Eq{"status": status.String()})
+	}
+
+	return queryAssetPage(d, stmt, p, newKeyset(true, "creation_date", "id"), func(delivery *asset.WebhookDelivery) []string {
+		return []string{sqliteTime(delivery.CreationDate.AsTime()), delivery.Id}
+	})
+}
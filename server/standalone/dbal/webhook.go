@@ -0,0 +1,528 @@
+package dbal
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type sqlWebhook struct {
+	Key          string
+	Owner        string
+	URL          string
+	Filter       asset.EventQueryFilter
+	CreationDate time.Time
+}
+
+func (w *sqlWebhook) toWebhook() *asset.Webhook {
+	return &asset.Webhook{
+		Key:          w.Key,
+		Owner:        w.Owner,
+		Url:          w.URL,
+		Filter:       &w.Filter,
+		CreationDate: timestamppb.New(w.CreationDate),
+	}
+}
+
+type sqlWebhookDelivery struct {
+	ID              string
+	WebhookKey      string
+	EventID         string
+	Status          asset.WebhookDeliveryStatus
+	Attempts        uint32
+	CreationDate    time.Time
+	LastAttemptDate sql.NullTime
+	NextAttemptDate sql.NullTime
+	ResponseCode    uint32
+	Error           string
+}
+
+func (d *sqlWebhookDelivery) toWebhookDelivery() *asset.WebhookDelivery {
+	delivery := &asset.WebhookDelivery{
+		Id:           d.ID,
+		WebhookKey:   d.WebhookKey,
+		EventId:      d.EventID,
+		Status:       d.Status,
+		Attempts:     d.Attempts,
+		CreationDate: timestamppb.New(d.CreationDate),
+		ResponseCode: d.ResponseCode,
+		Error:        d.Error,
+	}
+
+	if d.LastAttemptDate.Valid {
+		delivery.LastAttemptDate = timestamppb.New(d.LastAttemptDate.Time)
+	}
+	if d.NextAttemptDate.Valid {
+		delivery.NextAttemptDate = timestamppb.New(d.NextAttemptDate.Time)
+	}
+
+	return delivery
+}
+
+var webhookDeliveryColumns = []string{"id", "webhook_key", "event_id", "status", "attempts", "creation_date", "last_attempt_date", "next_attempt_date", "response_code", "error"}
+
+func (d *sqlWebhookDelivery) scanArgs() []interface{} {
+	return []interface{}{&d.ID, &d.WebhookKey, &d.EventID, &d.Status, &d.Attempts, &d.CreationDate, &d.LastAttemptDate, &d.NextAttemptDate, &d.ResponseCode, &d.Error}
+}
+
+// nullTime converts an optional timestamp to a nullable column value.
+func nullTime(ts *timestamppb.Timestamp) sql.NullTime {
+	if ts == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: ts.AsTime(), Valid: true}
+}
+
+// webhookFilter returns the filter to store, an empty filter matches every event.
+func webhookFilter(webhook *asset.Webhook) *asset.EventQueryFilter {
+	if webhook.Filter == nil {
+		return &asset.EventQueryFilter{}
+	}
+	return webhook.Filter
+}
+
+// AddWebhook stores a new webhook, its cursor is set to the last event so that only new events are pushed.
+func (d *DBAL) AddWebhook(webhook *asset.Webhook, secret string) error {
+	stmt := getStatementBuilder().
+		Insert("webhooks").
+		Columns("key", "channel", "owner", "url", "secret", "filter", "creation_date", "position").
+		Values(webhook.Key, d.channel, webhook.Owner, webhook.Url, secret, webhookFilter(webhook), webhook.CreationDate.AsTime(), sq.Expr("(SELECT COALESCE(MAX(position), 0) FROM events)"))
+
+	return d.exec(stmt)
+}
+
+// UpdateWebhook updates the url and filter of a webhook, the secret is only updated when not empty.
+func (d *DBAL) UpdateWebhook(webhook *asset.Webhook, secret string) error {
+	stmt := getStatementBuilder().
+		Update("webhooks").
+		Set("url", webhook.Url).
+		Set("filter", webhookFilter(webhook)).
+		Where(sq.Eq{"channel": d.channel, "key": webhook.Key})
+
+	if secret != "" {
+		stmt = stmt.Set("secret", secret)
+	}
+
+	return d.exec(stmt)
+}
+
+// DeleteWebhook removes a webhook, its deliveries are removed by cascade.
+func (d *DBAL) DeleteWebhook(key string) error {
+	stmt := getStatementBuilder().
+		Delete("webhooks").
+		Where(sq.Eq{"channel": d.channel, "key": key})
+
+	return d.exec(stmt)
+}
+
+func (d *DBAL) GetWebhook(key string) (*asset.Webhook, error) {
+	stmt := getStatementBuilder().
+		Select("key", "owner", "url", "filter", "creation_date").
+		From("webhooks").
+		Where(sq.Eq{"channel": d.channel, "key": key})
+
+	row, err := d.queryRow(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	w := new(sqlWebhook)
+	err = row.Scan(&w.Key, &w.Owner, &w.URL, &w.Filter, &w.CreationDate)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, orcerrors.NewNotFound(asset.WebhookKind, key)
+		}
+		return nil, err
+	}
+
+	return w.toWebhook(), nil
+}
+
+func (d *DBAL) WebhookExists(key string) (bool, error) {
+	stmt := getStatementBuilder().
+		Select("COUNT(key)").
+		From("webhooks").
+		Where(sq.Eq{"channel": d.channel, "key": key})
+
+	row, err := d.queryRow(stmt)
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	err = row.Scan(&count)
+
+	return count == 1, err
+}
+
+func (d *DBAL) QueryWebhooks(p *common.Pagination, owner string) ([]*asset.Webhook, common.PaginationToken, error) {
+	pg, err := newPage(p, newKeyset(false, "creation_date", "key"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	stmt := getStatementBuilder().
+		Select("key", "owner", "url", "filter", "creation_date").
+		From("webhooks").
+		Where(sq.Eq{"channel": d.channel, "owner": owner})
+
+	stmt = pg.apply(stmt)
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var webhooks []*asset.Webhook
+	var count int
+	var last *sqlWebhook
+
+	for rows.Next() {
+		w := new(sqlWebhook)
+
+		err = rows.Scan(&w.Key, &w.Owner, &w.URL, &w.Filter, &w.CreationDate)
+		if err != nil {
+			return nil, "", err
+		}
+
+		webhooks = append(webhooks, w.toWebhook())
+		last = w
+		count++
+
+		if count == int(p.Size) {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	bookmark := ""
+	if count == int(p.Size) && rows.Next() {
+		// there is more to fetch
+		bookmark, err = pg.nextToken(cursorTime(last.CreationDate), last.Key)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return webhooks, bookmark, nil
+}
+
+// ClaimWebhook locks the row of the webhook and claims it until the given date, unless another claim is still running.
+func (d *DBAL) ClaimWebhook(key string, claimID string, now time.Time, until time.Time) (string, bool, error) {
+	stmt := getStatementBuilder().
+		Select("secret", "claimed_until").
+		From("webhooks").
+		Where(sq.Eq{"channel": d.channel, "key": key}).
+		Suffix("FOR UPDATE")
+
+	row, err := d.queryRow(stmt)
+	if err != nil {
+		return "", false, err
+	}
+
+	var secret string
+	var claimedUntil *time.Time
+	err = row.Scan(&secret, &claimedUntil)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", false, orcerrors.NewNotFound(asset.WebhookKind, key)
+		}
+		return "", false, err
+	}
+
+	if claimedUntil != nil && claimedUntil.After(now) {
+		return "", false, nil
+	}
+
+	update := getStatementBuilder().
+		Update("webhooks").
+		Set("claim_id", claimID).
+		Set("claimed_until", until).
+		Where(sq.Eq{"channel": d.channel, "key": key})
+
+	err = d.exec(update)
+	if err != nil {
+		return "", false, err
+	}
+
+	return secret, true, nil
+}
+
+// ReleaseWebhook clears the claim of the webhook, provided it is still held by claimID.
+func (d *DBAL) ReleaseWebhook(key string, claimID string) (bool, error) {
+	stmt := getStatementBuilder().
+		Update("webhooks").
+		Set("claim_id", nil).
+		Set("claimed_until", nil).
+		Where(sq.Eq{"channel": d.channel, "key": key, "claim_id": claimID})
+
+	count, err := d.execCount(stmt)
+
+	return count > 0, err
+}
+
+// GetDueWebhookKeys returns the keys of the webhooks having events after their cursor,
+// skipping webhooks waiting for the next attempt of a pending delivery or claimed by a running dispatch.
+func (d *DBAL) GetDueWebhookKeys(now time.Time) ([]string, error) {
+	backingOff := sq.
+		Select("1").
+		From("webhook_deliveries dl").
+		Where("dl.webhook_key = w.key").
+		Where(sq.Eq{"dl.status": asset.WebhookDeliveryStatus_DELIVERY_PENDING.String()}).
+		Where(sq.Gt{"dl.next_attempt_date": now})
+
+	newEvents := sq.
+		Select("1").
+		From("events e").
+		Where("e.channel = w.channel").
+		Where("e.position > w.position")
+
+	stmt := getStatementBuilder().
+		Select("w.key").
+		From("webhooks w").
+		Where(sq.Eq{"w.channel": d.channel}).
+		Where(sq.Expr("EXISTS (?)", newEvents)).
+		Where(sq.Expr("NOT EXISTS (?)", backingOff)).
+		Where(sq.Or{sq.Eq{"w.claimed_until": nil}, sq.LtOrEq{"w.claimed_until": now}}).
+		OrderBy("w.creation_date", "w.key")
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		err = rows.Scan(&key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// GetWebhookEvents returns the events after the cursor of the webhook matching its filter, ordered by position.
+// Unless the limit is reached, every event of the channel has been scanned and the last one is returned as such.
+func (d *DBAL) GetWebhookEvents(webhook *asset.Webhook, limit uint32) ([]*asset.Event, string, error) {
+	cursor := sq.
+		Select("position").
+		From("webhooks").
+		Where(sq.Eq{"channel": d.channel, "key": webhook.Key})
+
+	stmt := getStatementBuilder().
+		Select("id", "asset_key", "asset_kind", "event_kind", "timestamp", "asset", "metadata").
+		From("events").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Expr("position > (?)", cursor))
+
+	stmt = d.eventFilterToQuery(webhook.Filter, stmt).
+		OrderBy("position").
+		Limit(uint64(limit))
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	events := []*asset.Event{}
+	for rows.Next() {
+		ev := sqlEvent{Channel: d.channel}
+
+		err = rows.Scan(&ev.ID, &ev.AssetKey, &ev.AssetKind, &ev.EventKind, &ev.Timestamp, &ev.Asset, &ev.Metadata)
+		if err != nil {
+			return nil, "", err
+		}
+
+		event, err := ev.toEvent()
+		if err != nil {
+			return nil, "", err
+		}
+
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if len(events) == int(limit) {
+		return events, events[len(events)-1].Id, nil
+	}
+
+	last := getStatementBuilder().
+		Select("id").
+		From("events").
+		Where(sq.Eq{"channel": d.channel}).
+		Where(sq.Expr("position > (?)", cursor)).
+		OrderBy("position DESC").
+		Limit(1)
+
+	row, err := d.queryRow(last)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var lastScanned string
+	err = row.Scan(&lastScanned)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, "", err
+	}
+
+	return events, lastScanned, nil
+}
+
+func (d *DBAL) NewWebhookClaimID() string {
+	return uuid.NewString()
+}
+
+// SetWebhookCursor moves the cursor of the webhook to the position of the given event.
+func (d *DBAL) SetWebhookCursor(webhookKey string, eventID string) error {
+	position := sq.
+		Select("position").
+		From("events").
+		Where(sq.Eq{"channel": d.channel, "id": eventID})
+
+	stmt := getStatementBuilder().
+		Update("webhooks").
+		Set("position", sq.Expr("(?)", position)).
+		Where(sq.Eq{"channel": d.channel, "key": webhookKey})
+
+	return d.exec(stmt)
+}
+
+func (d *DBAL) NewWebhookDeliveryID() string {
+	return uuid.NewString()
+}
+
+// GetWebhookDelivery returns the delivery of an event to a webhook.
+func (d *DBAL) GetWebhookDelivery(webhookKey string, eventID string) (*asset.WebhookDelivery, error) {
+	stmt := getStatementBuilder().
+		Select(webhookDeliveryColumns...).
+		From("webhook_deliveries").
+		Where(sq.Eq{"webhook_key": webhookKey, "event_id": eventID})
+
+	row, err := d.queryRow(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	dl := new(sqlWebhookDelivery)
+	err = row.Scan(dl.scanArgs()...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, orcerrors.NewNotFound("webhook delivery", eventID)
+		}
+		return nil, err
+	}
+
+	return dl.toWebhookDelivery(), nil
+}
+
+func (d *DBAL) AddWebhookDelivery(delivery *asset.WebhookDelivery) error {
+	stmt := getStatementBuilder().
+		Insert("webhook_deliveries").
+		Columns(webhookDeliveryColumns...).
+		Values(
+			delivery.Id,
+			delivery.WebhookKey,
+			delivery.EventId,
+			delivery.Status.String(),
+			delivery.Attempts,
+			delivery.CreationDate.AsTime(),
+			nullTime(delivery.LastAttemptDate),
+			nullTime(delivery.NextAttemptDate),
+			delivery.ResponseCode,
+			delivery.Error,
+		)
+
+	return d.exec(stmt)
+}
+
+func (d *DBAL) UpdateWebhookDelivery(delivery *asset.WebhookDelivery) error {
+	stmt := getStatementBuilder().
+		Update("webhook_deliveries").
+		Set("status", delivery.Status.String()).
+		Set("attempts", delivery.Attempts).
+		Set("last_attempt_date", nullTime(delivery.LastAttemptDate)).
+		Set("next_attempt_date", nullTime(delivery.NextAttemptDate)).
+		Set("response_code", delivery.ResponseCode).
+		Set("error", delivery.Error).
+		Where(sq.Eq{"id": delivery.Id})
+
+	return d.exec(stmt)
+}
+
+// QueryWebhookDeliveries returns the deliveries of a webhook, most recent first.
+func (d *DBAL) QueryWebhookDeliveries(p *common.Pagination, webhookKey string, status asset.WebhookDeliveryStatus) ([]*asset.WebhookDelivery, common.PaginationToken, error) {
+	pg, err := newPage(p, newKeyset(true, "creation_date", "id"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	stmt := getStatementBuilder().
+		Select(webhookDeliveryColumns...).
+		From("webhook_deliveries").
+		Where(sq.Eq{"webhook_key": webhookKey})
+
+	if status != asset.WebhookDeliveryStatus_DELIVERY_UNKNOWN {
+		stmt = stmt.Where(sq.Eq{"status": status.String()})
+	}
+
+	stmt = pg.apply(stmt)
+
+	rows, err := d.query(stmt)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var deliveries []*asset.WebhookDelivery
+	var count int
+	var last *sqlWebhookDelivery
+
+	for rows.Next() {
+		dl := new(sqlWebhookDelivery)
+
+		err = rows.Scan(dl.scanArgs()...)
+		if err != nil {
+			return nil, "", err
+		}
+
+		deliveries = append(deliveries, dl.toWebhookDelivery())
+		last = dl
+		count++
+
+		if count == int(p.Size) {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	bookmark := ""
+	if count == int(p.Size) && rows.Next() {
+		// there is more to fetch
+		bookmark, err = pg.nextToken(cursorTime(last.CreationDate), last.ID)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return deliveries, bookmark, nil
+}
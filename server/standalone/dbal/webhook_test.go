@@ -0,0 +1,264 @@
+package dbal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pashagolub/pgxmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestAddWebhook(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	webhook := &asset.Webhook{
+		Key:          "08680966-97ae-4573-8b2d-6c4db2b3c532",
+		Owner:        "owner",
+		Url:          "https://example.com/hook",
+		CreationDate: timestamppb.New(time.Unix(1337, 0)),
+	}
+
+	mock.ExpectBegin()
+	mock.
+		ExpectExec(`INSERT INTO webhooks (key,channel,owner,url,secret,filter,creation_date,position) VALUES ($1,$2,$3,$4,$5,$6,$7,(SELECT COALESCE(MAX(position), 0) FROM events))`).
+		WithArgs(webhook.Key, testChannel, "owner", webhook.Url, "secret", &asset.EventQueryFilter{}, time.Unix(1337, 0).UTC()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	err = dbal.AddWebhook(webhook, "secret")
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateWebhookKeepSecret(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	filter := &asset.EventQueryFilter{AssetKind: asset.AssetKind_ASSET_MODEL}
+	webhook := &asset.Webhook{Key: "uuid", Url: "https://example.com/hook", Filter: filter}
+
+	mock.ExpectBegin()
+	mock.
+		ExpectExec(`UPDATE webhooks SET url = $1, filter = $2 WHERE channel = $3 AND key = $4`).
+		WithArgs(webhook.Url, filter, testChannel, "uuid").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	err = dbal.UpdateWebhook(webhook, "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDueWebhookKeys(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	now := time.Unix(1337, 0).UTC()
+
+	mock.ExpectBegin()
+	mock.
+		ExpectQuery(`SELECT w.key FROM webhooks w WHERE w.channel = $1 AND EXISTS (SELECT 1 FROM events e WHERE e.channel = w.channel AND e.position > w.position) AND NOT EXISTS (SELECT 1 FROM webhook_deliveries dl WHERE dl.webhook_key = w.key AND dl.status = $2 AND dl.next_attempt_date > $3) AND (w.claimed_until IS NULL OR w.claimed_until <= $4) ORDER BY w.creation_date, w.key`).
+		WithArgs(testChannel, asset.WebhookDeliveryStatus_DELIVERY_PENDING.String(), now, now).
+		WillReturnRows(pgxmock.NewRows([]string{"key"}).AddRow("hook1").AddRow("hook2"))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	keys, err := dbal.GetDueWebhookKeys(now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hook1", "hook2"}, keys)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetWebhookEvents(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	webhook := &asset.Webhook{Key: "hook", Filter: &asset.EventQueryFilter{EventKind: asset.EventKind_EVENT_ASSET_CREATED}}
+
+	mock.ExpectBegin()
+	mock.
+		ExpectQuery(`SELECT id, asset_key, asset_kind, event_kind, timestamp, asset, metadata FROM events WHERE channel = $1 AND position > (SELECT position FROM webhooks WHERE channel = $2 AND key = $3) AND event_kind = $4 ORDER BY position LIMIT 10`).
+		WithArgs(testChannel, testChannel, "hook", asset.EventKind_EVENT_ASSET_CREATED.String()).
+		WillReturnRows(makeEventRows())
+	// Less events than the limit match the filter: the last event of the channel has been scanned
+	mock.
+		ExpectQuery(`SELECT id FROM events WHERE channel = $1 AND position > (SELECT position FROM webhooks WHERE channel = $2 AND key = $3) ORDER BY position DESC LIMIT 1`).
+		WithArgs(testChannel, testChannel, "hook").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow("id3"))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	events, lastScanned, err := dbal.GetWebhookEvents(webhook, 10)
+	assert.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "id1", events[0].Id)
+	assert.Equal(t, "id2", events[1].Id)
+	assert.Equal(t, "id3", lastScanned)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimWebhook(t *testing.T) {
+	now := time.Unix(1337, 0).UTC()
+	until := now.Add(time.Minute)
+
+	cases := map[string]struct {
+		claimedUntil *time.Time
+		claimed      bool
+	}{
+		"not claimed":   {nil, true},
+		"claim expired": {&now, true},
+		"claim running": {&until, false},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+			require.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.
+				ExpectQuery(`SELECT secret, claimed_until FROM webhooks WHERE channel = $1 AND key = $2 FOR UPDATE`).
+				WithArgs(testChannel, "hook").
+				WillReturnRows(pgxmock.NewRows([]string{"secret", "claimed_until"}).AddRow("secret", c.claimedUntil))
+			if c.claimed {
+				mock.
+					ExpectExec(`UPDATE webhooks SET claim_id = $1, claimed_until = $2 WHERE channel = $3 AND key = $4`).
+					WithArgs("claim", until, testChannel, "hook").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			}
+
+			tx, err := mock.Begin(context.Background())
+			require.NoError(t, err)
+
+			dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+			_, claimed, err := dbal.ClaimWebhook("hook", "claim", now, until)
+			assert.NoError(t, err)
+			assert.Equal(t, c.claimed, claimed)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestReleaseWebhook(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.
+		ExpectExec(`UPDATE webhooks SET claim_id = $1, claimed_until = $2 WHERE channel = $3 AND claim_id = $4 AND key = $5`).
+		WithArgs(nil, nil, testChannel, "claim", "hook").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	released, err := dbal.ReleaseWebhook("hook", "claim")
+	assert.NoError(t, err)
+	assert.False(t, released, "the claim is held by another dispatch")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetWebhookCursor(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.
+		ExpectExec(`UPDATE webhooks SET position = (SELECT position FROM events WHERE channel = $1 AND id = $2) WHERE channel = $3 AND key = $4`).
+		WithArgs(testChannel, "event", testChannel, "hook").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	err = dbal.SetWebhookCursor("hook", "event")
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetWebhookDeliveryNotFound(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.
+		ExpectQuery(`SELECT id, webhook_key, event_id, status, attempts, creation_date, last_attempt_date, next_attempt_date, response_code, error FROM webhook_deliveries WHERE event_id = $1 AND webhook_key = $2`).
+		WithArgs("event", "hook").
+		WillReturnError(pgx.ErrNoRows)
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	_, err = dbal.GetWebhookDelivery("hook", "event")
+	orcError := new(orcerrors.OrcError)
+	require.True(t, errors.As(err, &orcError))
+	assert.Equal(t, orcerrors.ErrNotFound, orcError.Kind)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryWebhookDeliveries(t *testing.T) {
+	mock, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	rows := pgxmock.NewRows(webhookDeliveryColumns).
+		AddRow("delivery1", "hook", "event1", "DELIVERY_PENDING", uint32(2), time.Unix(10, 0).UTC(), sql.NullTime{Time: time.Unix(20, 0).UTC(), Valid: true}, sql.NullTime{Time: time.Unix(30, 0).UTC(), Valid: true}, uint32(500), "unexpected status code 500")
+
+	mock.ExpectBegin()
+	mock.
+		ExpectQuery(`SELECT id, webhook_key, event_id, status, attempts, creation_date, last_attempt_date, next_attempt_date, response_code, error FROM webhook_deliveries WHERE webhook_key = $1 AND status = $2 ORDER BY creation_date DESC, id DESC LIMIT 11`).
+		WithArgs("hook", asset.WebhookDeliveryStatus_DELIVERY_PENDING.String()).
+		WillReturnRows(rows)
+
+	tx, err := mock.Begin(context.Background())
+	require.NoError(t, err)
+
+	dbal := &DBAL{ctx: context.TODO(), tx: tx, channel: testChannel}
+
+	deliveries, token, err := dbal.QueryWebhookDeliveries(common.NewPagination("", 10), "hook", asset.WebhookDeliveryStatus_DELIVERY_PENDING)
+	assert.NoError(t, err)
+	assert.Equal(t, "", token)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, asset.WebhookDeliveryStatus_DELIVERY_PENDING, deliveries[0].Status)
+	assert.Equal(t, int64(30), deliveries[0].NextAttemptDate.GetSeconds())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,155 @@
+package standalone
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/server/standalone/dbal"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// importBatchSize is the number of events inserted at once when importing events.
+const importBatchSize = 1000
+
+// EventRange selects the events to export, zero values are unbounded.
+type EventRange struct {
+	// FromPosition is the inclusive lower bound of the event position
+	FromPosition uint64
+	// ToPosition is the inclusive upper bound of the event position
+	ToPosition uint64
+	// Start is the inclusive lower bound of the event timestamp
+	Start time.Time
+	// End is the inclusive upper bound of the event timestamp
+	End time.Time
+}
+
+func (r *EventRange) filter() *asset.EventQueryFilter {
+	filter := &asset.EventQueryFilter{}
+	if !r.Start.IsZero() {
+		filter.Start = timestamppb.New(r.Start)
+	}
+	if !r.End.IsZero() {
+		filter.End = timestamppb.New(r.End)
+	}
+
+	return filter
+}
+
+// ExportEvents writes the events of a channel to w in position order, as newline delimited JSON.
+// Each line is an asset.ExportedEvent serialized with protojson.
+// It returns the number of exported events.
+func ExportEvents(ctx context.Context, db *dbal.Database, channel string, r *EventRange, w io.Writer) (int, error) {
+	tx, err := db.BeginTransaction(ctx, true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Nothing to commit in a read-only transaction
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	buf := bufio.NewWriter(w)
+	count := 0
+
+	err = dbal.New(ctx, tx, tx.Conn(), channel).ExportEvents(r.FromPosition, r.ToPosition, r.filter(), func(event *asset.ExportedEvent) error {
+		count++
+		return writeExportedEvent(buf, event)
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, buf.Flush()
+}
+
+// ImportEvents loads events written by ExportEvents into a channel.
+// Events are imported in a single transaction: either all of them are imported or none.
+// It returns the number of imported events.
+func ImportEvents(ctx context.Context, db *dbal.Database, channel string, r io.Reader) (int, error) {
+	tx, err := db.BeginTransaction(ctx, false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	transactionalDBAL := dbal.New(ctx, tx, tx.Conn(), channel)
+	count := 0
+
+	err = readExportedEvents(r, importBatchSize, func(events []*asset.ExportedEvent) error {
+		count += len(events)
+		return transactionalDBAL.ImportEvents(events)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return count, nil
+}
+
+// VerifyEventChain checks the hash chain of the events of a channel, see dbal.DBAL.VerifyEventChain.
+func VerifyEventChain(ctx context.Context, db *dbal.Database, channel string) (*asset.EventChainStatus, error) {
+	tx, err := db.BeginTransaction(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	return dbal.New(ctx, tx, tx.Conn(), channel).VerifyEventChain()
+}
+
+// writeExportedEvent writes an event as a single line of JSON.
+func writeExportedEvent(w io.Writer, event *asset.ExportedEvent) error {
+	line, err := protojson.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
+
+// readExportedEvents reads newline delimited events from r and calls fn with batches of at most batchSize events.
+// Empty lines are ignored.
+func readExportedEvents(r io.Reader, batchSize int, fn func([]*asset.ExportedEvent) error) error {
+	reader := bufio.NewReader(r)
+	batch := make([]*asset.ExportedEvent, 0, batchSize)
+	lineNumber := 0
+
+	for {
+		// Unlike bufio.Scanner, ReadBytes does not limit the length of a line
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		lineNumber++
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			event := new(asset.ExportedEvent)
+			if unmarshalErr := protojson.Unmarshal(line, event); unmarshalErr != nil {
+				return fmt.Errorf("invalid event on line %d: %w", lineNumber, unmarshalErr)
+			}
+			batch = append(batch, event)
+		}
+
+		if len(batch) == batchSize || (errors.Is(err, io.EOF) && len(batch) > 0) {
+			if fnErr := fn(batch); fnErr != nil {
+				return fnErr
+			}
+			batch = make([]*asset.ExportedEvent, 0, batchSize)
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package standalone
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestEventRangeFilter(t *testing.T) {
+	assert.Equal(t, &asset.EventQueryFilter{}, (&EventRange{FromPosition: 12}).filter())
+
+	r := &EventRange{Start: time.Unix(10, 0), End: time.Unix(20, 0)}
+	expected := &asset.EventQueryFilter{Start: timestamppb.New(time.Unix(10, 0)), End: timestamppb.New(time.Unix(20, 0))}
+	assert.True(t, proto.Equal(expected, r.filter()))
+}
+
+func TestExportedEventsRoundTrip(t *testing.T) {
+	events := []*asset.ExportedEvent{
+		{
+			Position: 1,
+			Event: &asset.Event{
+				Id:        "b2b30b36-b7f3-4839-9c6f-36ddafcf19fc",
+				AssetKind: asset.AssetKind_ASSET_FUNCTION,
+				EventKind: asset.EventKind_EVENT_ASSET_CREATED,
+				Timestamp: timestamppb.New(time.Unix(1, 0)),
+				Asset:     &asset.Event_Function{Function: &asset.Function{Key: "56a3dc56-f493-47e5-8a61-46a120e5403c"}},
+			},
+		},
+		{Position: 3, PreviousPosition: 1, Event: &asset.Event{Id: "id2"}},
+		{Position: 4, PreviousPosition: 3, Event: &asset.Event{Id: "id3"}},
+	}
+
+	buf := new(bytes.Buffer)
+	for _, event := range events {
+		require.NoError(t, writeExportedEvent(buf, event))
+	}
+	assert.Equal(t, 3, strings.Count(buf.String(), "\n"))
+
+	batches := [][]*asset.ExportedEvent{}
+	err := readExportedEvents(buf, 2, func(batch []*asset.ExportedEvent) error {
+		batches = append(batches, batch)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, batches, 2)
+	assert.Len(t, batches[0], 2)
+	assert.Len(t, batches[1], 1)
+	assert.True(t, proto.Equal(events[0], batches[0][0]))
+	assert.True(t, proto.Equal(events[2], batches[1][0]))
+}
+
+func TestReadExportedEventsWithoutTrailingNewline(t *testing.T) {
+	input := "{\"position\":\"1\"}\n\n{\"position\":\"2\",\"previousPosition\":\"1\"}"
+
+	var read []*asset.ExportedEvent
+	err := readExportedEvents(strings.NewReader(input), 10, func(batch []*asset.ExportedEvent) error {
+		read = append(read, batch...)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, read, 2)
+	assert.Equal(t, uint64(2), read[1].Position)
+	assert.Equal(t, uint64(1), read[1].PreviousPosition)
+}
+
+func TestReadExportedEventsInvalidLine(t *testing.T) {
+	input := "{\"position\":\"1\"}\nnot json\n"
+
+	called := false
+	err := readExportedEvents(strings.NewReader(input), 10, func([]*asset.ExportedEvent) error {
+		called = true
+		return nil
+	})
+	assert.ErrorContains(t, err, "line 2")
+	assert.False(t, called)
+}
+
+func TestReadExportedEventsBatchError(t *testing.T) {
+	input := "{\"position\":\"1\"}\n"
+
+	err := readExportedEvents(strings.NewReader(input), 10, func([]*asset.ExportedEvent) error {
+		return errors.New("import failed")
+	})
+	assert.EqualError(t, err, "import failed")
+}
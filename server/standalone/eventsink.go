@@ -0,0 +1,154 @@
+package standalone
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/server/common"
+)
+
+// EventSink is an external destination of the events.
+// Delivery is at least once: a batch may be sent again if the relay fails to record its progress,
+// sinks should rely on the event position to discard duplicates.
+type EventSink interface {
+	// Name identifies the sink, the delivery progress is recorded under this name.
+	Name() string
+	// Send delivers a batch of events of a single channel, in position order.
+	// The batch is considered delivered if no error is returned.
+	Send(ctx context.Context, events []*asset.ExportedEvent) error
+}
+
+// NewEventSinks returns the sinks described by the configuration.
+func NewEventSinks(configs []common.EventSinkConfiguration, timeout time.Duration) ([]EventSink, error) {
+	sinks := make([]EventSink, 0, len(configs))
+	names := make(map[string]bool, len(configs))
+
+	for _, config := range configs {
+		if config.Name == "" {
+			return nil, fmt.Errorf("missing name of event sink")
+		}
+		if names[config.Name] {
+			return nil, fmt.Errorf("duplicate event sink %q", config.Name)
+		}
+		names[config.Name] = true
+
+		switch config.Type {
+		case "file":
+			if config.Path == "" {
+				return nil, fmt.Errorf("missing path of event sink %q", config.Name)
+			}
+			sinks = append(sinks, NewFileEventSink(config.Name, config.Path))
+		case "http":
+			if config.URL == "" {
+				return nil, fmt.Errorf("missing url of event sink %q", config.Name)
+			}
+			sinks = append(sinks, NewHTTPEventSink(config.Name, config.URL, timeout))
+		default:
+			return nil, fmt.Errorf("unknown type %q of event sink %q", config.Type, config.Name)
+		}
+	}
+
+	return sinks, nil
+}
+
+// FileEventSink appends events to a file as newline delimited JSON,
+// in the same format as ExportEvents.
+type FileEventSink struct {
+	name string
+	path string
+}
+
+func NewFileEventSink(name string, path string) *FileEventSink {
+	return &FileEventSink{name: name, path: path}
+}
+
+func (s *FileEventSink) Name() string {
+	return s.name
+}
+
+// Send appends the events to the file and syncs it to disk.
+func (s *FileEventSink) Send(_ context.Context, events []*asset.ExportedEvent) error {
+	err := os.MkdirAll(filepath.Dir(s.path), 0o750)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, event := range events {
+		if err := writeExportedEvent(&buf, event); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(buf.Bytes())
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// HTTPEventSink posts batches of events to an endpoint as newline delimited JSON,
+// in the same format as ExportEvents.
+// Any response status other than 2xx is a failed delivery.
+type HTTPEventSink struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+func NewHTTPEventSink(name string, url string, timeout time.Duration) *HTTPEventSink {
+	return &HTTPEventSink{
+		name:   name,
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *HTTPEventSink) Name() string {
+	return s.name
+}
+
+func (s *HTTPEventSink) Send(ctx context.Context, events []*asset.ExportedEvent) error {
+	var body bytes.Buffer
+	for _, event := range events {
+		if err := writeExportedEvent(&body, event); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain the response so that the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
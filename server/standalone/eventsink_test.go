@@ -0,0 +1,106 @@
+package standalone
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/server/common"
+)
+
+func TestNewEventSinks(t *testing.T) {
+	sinks, err := NewEventSinks([]common.EventSinkConfiguration{
+		{Name: "archive", Type: "file", Path: "/tmp/events.ndjson"},
+		{Name: "lake", Type: "http", URL: "https://lake.example.com/events"},
+	}, time.Second)
+	require.NoError(t, err)
+	require.Len(t, sinks, 2)
+	assert.IsType(t, &FileEventSink{}, sinks[0])
+	assert.Equal(t, "lake", sinks[1].Name())
+
+	invalid := map[string][]common.EventSinkConfiguration{
+		"missing name":   {{Type: "file", Path: "/tmp/events.ndjson"}},
+		"unknown type":   {{Name: "queue", Type: "kafka"}},
+		"missing path":   {{Name: "archive", Type: "file"}},
+		"missing url":    {{Name: "lake", Type: "http"}},
+		"duplicate sink": {{Name: "lake", Type: "http", URL: "http://a"}, {Name: "lake", Type: "http", URL: "http://b"}},
+	}
+	for name, configs := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewEventSinks(configs, time.Second)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFileEventSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sink", "events.ndjson")
+	sink := NewFileEventSink("archive", path)
+
+	require.NoError(t, sink.Send(context.Background(), []*asset.ExportedEvent{{Position: 1, Event: &asset.Event{Id: "id1"}}}))
+	require.NoError(t, sink.Send(context.Background(), []*asset.ExportedEvent{{Position: 3, PreviousPosition: 1, Event: &asset.Event{Id: "id2"}}}))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var events []*asset.ExportedEvent
+	err = readExportedEvents(f, 10, func(batch []*asset.ExportedEvent) error {
+		events = append(events, batch...)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "id1", events[0].Event.Id)
+	assert.Equal(t, uint64(1), events[1].PreviousPosition)
+}
+
+func TestHTTPEventSink(t *testing.T) {
+	events := []*asset.ExportedEvent{
+		{Position: 1, Event: &asset.Event{Id: "id1"}},
+		{Position: 2, PreviousPosition: 1, Event: &asset.Event{Id: "id2"}},
+	}
+
+	cases := map[string]struct {
+		status  int
+		success bool
+	}{
+		"success": {http.StatusAccepted, true},
+		"failure": {http.StatusServiceUnavailable, false},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+
+				count := 0
+				err := readExportedEvents(r.Body, 10, func(batch []*asset.ExportedEvent) error {
+					count += len(batch)
+					return nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, len(events), count)
+
+				w.WriteHeader(c.status)
+			}))
+			defer endpoint.Close()
+
+			sink := NewHTTPEventSink("lake", endpoint.URL, time.Second)
+			err := sink.Send(context.Background(), events)
+			if c.success {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
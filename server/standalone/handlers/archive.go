@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/substra/orchestrator/lib/asset"
+	commonInterceptors "github.com/substra/orchestrator/server/common/interceptors"
+	"github.com/substra/orchestrator/server/standalone/interceptors"
+)
+
+// ArchiveServer is the gRPC facade to compute plan archival and purge
+type ArchiveServer struct {
+	asset.UnimplementedArchiveServiceServer
+}
+
+// NewArchiveServer creates a grpc server
+func NewArchiveServer() *ArchiveServer {
+	return &ArchiveServer{}
+}
+
+func (s *ArchiveServer) ArchivePlan(ctx context.Context, param *asset.ArchivePlanParam) (*asset.ComputePlanArchive, error) {
+	requester, err := commonInterceptors.ExtractMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := interceptors.ExtractProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetComputePlanService().ArchivePlan(param.Key, requester)
+}
+
+func (s *ArchiveServer) PurgePlan(ctx context.Context, param *asset.PurgePlanParam) (*asset.PurgePlanResponse, error) {
+	requester, err := commonInterceptors.ExtractMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := interceptors.ExtractProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := provider.GetComputePlanService().PurgePlan(param.Key, requester)
+	if err != nil {
+		return nil, err
+	}
+
+	return &asset.PurgePlanResponse{ComputePlan: plan}, nil
+}
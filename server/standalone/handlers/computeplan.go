@@ -121,3 +121,21 @@ func (s *ComputePlanServer) IsPlanRunning(ctx context.Context, param *asset.IsPl
 
 	return &asset.IsPlanRunningResponse{IsRunning: isRunning}, nil
 }
+
+func (s *ComputePlanServer) GetPlanStatistics(ctx context.Context, param *asset.GetPlanStatisticsParam) (*asset.ComputePlanStatistics, error) {
+	provider, err := interceptors.ExtractProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetComputePlanService().GetPlanStatistics(param.Key)
+}
+
+func (s *ComputePlanServer) GetPlanGraph(ctx context.Context, param *asset.GetPlanGraphParam) (*asset.ComputePlanGraph, error) {
+	provider, err := interceptors.ExtractProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetComputePlanService().GetPlanGraph(param.Key)
+}
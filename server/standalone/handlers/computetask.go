@@ -7,6 +7,7 @@ import (
 	libCommon "github.com/substra/orchestrator/lib/common"
 	commonInterceptors "github.com/substra/orchestrator/server/common/interceptors"
 	"github.com/substra/orchestrator/server/standalone/interceptors"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // ComputeTaskServer is the gRPC server exposing ComputeTask actions
@@ -29,6 +30,13 @@ func (s *ComputeTaskServer) RegisterTasks(ctx context.Context, input *asset.Regi
 		return nil, err
 	}
 
+	if input.ValidateOnly {
+		err = interceptors.EnableDryRun(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	tasks, err := provider.GetComputeTaskService().RegisterTasks(input.GetTasks(), owner)
 
 	if err != nil {
@@ -46,7 +54,7 @@ func (s *ComputeTaskServer) QueryTasks(ctx context.Context, in *asset.QueryTasks
 
 	pagination := libCommon.NewPagination(in.PageToken, in.PageSize)
 
-	tasks, paginationToken, err := provider.GetComputeTaskService().QueryTasks(pagination, in.Filter)
+	tasks, paginationToken, err := provider.GetComputeTaskService().QueryTasks(pagination, in.Filter, in.SortBy, in.Sort)
 	if err != nil {
 		return nil, err
 	}
@@ -114,3 +122,21 @@ func (s *ComputeTaskServer) DisableOutput(ctx context.Context, param *asset.Disa
 
 	return &asset.DisableOutputResponse{}, nil
 }
+
+func (s *ComputeTaskServer) Heartbeat(ctx context.Context, param *asset.HeartbeatParam) (*asset.HeartbeatResponse, error) {
+	requester, err := commonInterceptors.ExtractMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := interceptors.ExtractProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expiration, err := provider.GetComputeTaskService().Heartbeat(param.ComputeTaskKey, requester)
+	if err != nil {
+		return nil, err
+	}
+
+	return &asset.HeartbeatResponse{LeaseExpirationDate: timestamppb.New(expiration)}, nil
+}
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/substra/orchestrator/lib/asset"
 	"github.com/substra/orchestrator/lib/service"
+	"github.com/substra/orchestrator/server/standalone/interceptors"
 )
 
 func TestComputeTaskServerImplementServer(t *testing.T) {
@@ -33,3 +35,44 @@ func TestGetTaskInputAssets(t *testing.T) {
 	p.AssertExpectations(t)
 	cts.AssertExpectations(t)
 }
+
+func TestHeartbeat(t *testing.T) {
+	ctx, p := getContext()
+	cts := new(service.MockComputeTaskAPI)
+
+	server := NewComputeTaskServer()
+
+	expiration := time.Unix(1337, 0).UTC()
+
+	p.On("GetComputeTaskService").Return(cts)
+	cts.On("Heartbeat", "uuid", "requester").Once().Return(expiration, nil)
+
+	resp, err := server.Heartbeat(ctx, &asset.HeartbeatParam{ComputeTaskKey: "uuid"})
+	assert.NoError(t, err)
+	assert.Equal(t, expiration, resp.LeaseExpirationDate.AsTime())
+
+	p.AssertExpectations(t)
+	cts.AssertExpectations(t)
+}
+
+func TestRegisterTasksValidateOnly(t *testing.T) {
+	ctx, p := getContext()
+	ctx = interceptors.WithDryRunSupport(ctx)
+	cts := new(service.MockComputeTaskAPI)
+
+	server := NewComputeTaskServer()
+
+	newTasks := []*asset.NewComputeTask{{Key: "uuid"}}
+	tasks := []*asset.ComputeTask{{Key: "uuid", Rank: 1}}
+
+	p.On("GetComputeTaskService").Return(cts)
+	cts.On("RegisterTasks", newTasks, "requester").Once().Return(tasks, nil)
+
+	resp, err := server.RegisterTasks(ctx, &asset.RegisterTasksParam{Tasks: newTasks, ValidateOnly: true})
+	assert.NoError(t, err)
+	assert.Equal(t, tasks, resp.Tasks)
+	assert.True(t, interceptors.IsDryRun(ctx), "transaction should be rolled back")
+
+	p.AssertExpectations(t)
+	cts.AssertExpectations(t)
+}
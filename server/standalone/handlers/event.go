@@ -2,13 +2,15 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/rs/zerolog/log"
 	"github.com/substra/orchestrator/lib/asset"
 	"github.com/substra/orchestrator/lib/common"
+	orcerrors "github.com/substra/orchestrator/lib/errors"
 	commonInterceptors "github.com/substra/orchestrator/server/common/interceptors"
-	"github.com/substra/orchestrator/server/standalone/dbal"
 	"github.com/substra/orchestrator/server/standalone/interceptors"
+	"github.com/substra/orchestrator/utils"
 )
 
 // EventServer is the gRPC facade to Model manipulation
@@ -55,19 +57,133 @@ func (s *EventServer) SubscribeToEvents(param *asset.SubscribeToEventsParam, str
 		return err
 	}
 
+	err = param.Validate()
+	if err != nil {
+		return orcerrors.FromValidationError(asset.EventConsumerKind, err)
+	}
+
 	log.Ctx(ctx).Info().
 		Str("mspid", mspid).
 		Str("channel", channel).
 		Str("startEventId", param.StartEventId).
+		Str("consumer", param.Consumer).
 		Msg("Subscribing to events")
 
-	// Use a dedicated database connection per SubscribeToEvents request
-	// to prevent connection starvation in the pool.
-	conn, err := interceptors.ExtractDatabaseConn(ctx)
+	streamer, err := interceptors.ExtractEventStreamer(ctx)
+	if err != nil {
+		return err
+	}
+
+	return streamer.SubscribeToEvents(ctx, channel, mspid, param, stream)
+}
+
+func (s *EventServer) SubscribeToChannels(param *asset.SubscribeToChannelsParam, stream asset.EventService_SubscribeToChannelsServer) error {
+	ctx := stream.Context()
+
+	mspid, err := commonInterceptors.ExtractMSPID(ctx)
+	if err != nil {
+		return err
+	}
+
+	channels, err := commonInterceptors.ExtractChannels(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = param.Validate()
+	if err != nil {
+		return orcerrors.FromValidationError(asset.EventConsumerKind, err)
+	}
+
+	for _, cursor := range param.Cursors {
+		if !utils.SliceContains(channels, cursor.Channel) {
+			return orcerrors.NewBadRequest(fmt.Sprintf("cursor for channel %q which is not subscribed", cursor.Channel))
+		}
+	}
+
+	log.Ctx(ctx).Info().
+		Str("mspid", mspid).
+		Strs("channels", channels).
+		Msg("Subscribing to events of several channels")
+
+	streamer, err := interceptors.ExtractEventStreamer(ctx)
+	if err != nil {
+		return err
+	}
+
+	return streamer.SubscribeToChannels(ctx, channels, param, stream)
+}
+
+func (s *EventServer) VerifyEventChain(ctx context.Context, params *asset.VerifyEventChainParam) (*asset.EventChainStatus, error) {
+	services, err := interceptors.ExtractProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return services.GetEventService().VerifyEventChain()
+}
+
+func (s *EventServer) AckEvents(ctx context.Context, params *asset.AckEventsParam) (*asset.AckEventsResponse, error) {
+	mspid, err := commonInterceptors.ExtractMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	services, err := interceptors.ExtractProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = services.GetEventService().AckEvents(params, mspid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &asset.AckEventsResponse{}, nil
+}
+
+func (s *EventServer) QueryEventConsumers(ctx context.Context, params *asset.QueryEventConsumersParam) (*asset.QueryEventConsumersResponse, error) {
+	mspid, err := commonInterceptors.ExtractMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	services, err := interceptors.ExtractProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	consumers, paginationToken, err := services.GetEventService().QueryEventConsumers(common.NewPagination(params.PageToken, params.PageSize), mspid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &asset.QueryEventConsumersResponse{
+		Consumers:     consumers,
+		NextPageToken: paginationToken,
+	}, nil
+}
+
+func (s *EventServer) WatchComputePlan(param *asset.WatchComputePlanParam, stream asset.EventService_WatchComputePlanServer) error {
+	ctx := stream.Context()
+
+	channel, err := commonInterceptors.ExtractChannel(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = param.Validate()
+	if err != nil {
+		return orcerrors.FromValidationError(asset.ComputePlanKind, err)
+	}
+
+	log.Ctx(ctx).Info().
+		Str("channel", channel).
+		Str("computePlanKey", param.Key).
+		Msg("Watching compute plan")
+
+	streamer, err := interceptors.ExtractEventStreamer(ctx)
 	if err != nil {
 		return err
 	}
 
-	d := dbal.New(ctx, nil, conn, channel)
-	return d.SubscribeToEvents(param.StartEventId, stream)
+	return streamer.WatchComputePlan(ctx, channel, param.Key, stream)
 }
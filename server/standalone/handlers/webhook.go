@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/substra/orchestrator/lib/asset"
+	libCommon "github.com/substra/orchestrator/lib/common"
+	commonInterceptors "github.com/substra/orchestrator/server/common/interceptors"
+	"github.com/substra/orchestrator/server/standalone/interceptors"
+)
+
+// WebhookServer is the gRPC facade to Webhook manipulation
+type WebhookServer struct {
+	asset.UnimplementedWebhookServiceServer
+}
+
+// NewWebhookServer creates a gRPC server
+func NewWebhookServer() *WebhookServer {
+	return &WebhookServer{}
+}
+
+func (s *WebhookServer) RegisterWebhook(ctx context.Context, newWebhook *asset.NewWebhook) (*asset.Webhook, error) {
+	mspid, err := commonInterceptors.ExtractMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := interceptors.ExtractProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetWebhookService().RegisterWebhook(newWebhook, mspid)
+}
+
+func (s *WebhookServer) GetWebhook(ctx context.Context, param *asset.GetWebhookParam) (*asset.Webhook, error) {
+	mspid, err := commonInterceptors.ExtractMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := interceptors.ExtractProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetWebhookService().GetWebhook(param.Key, mspid)
+}
+
+func (s *WebhookServer) QueryWebhooks(ctx context.Context, param *asset.QueryWebhooksParam) (*asset.QueryWebhooksResponse, error) {
+	mspid, err := commonInterceptors.ExtractMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := interceptors.ExtractProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, nextPage, err := provider.GetWebhookService().QueryWebhooks(libCommon.NewPagination(param.PageToken, param.PageSize), mspid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &asset.QueryWebhooksResponse{
+		Webhooks:      webhooks,
+		NextPageToken: nextPage,
+	}, nil
+}
+
+func (s *WebhookServer) UpdateWebhook(ctx context.Context, param *asset.UpdateWebhookParam) (*asset.Webhook, error) {
+	mspid, err := commonInterceptors.ExtractMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := interceptors.ExtractProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetWebhookService().UpdateWebhook(param, mspid)
+}
+
+func (s *WebhookServer) DeleteWebhook(ctx context.Context, param *asset.DeleteWebhookParam) (*asset.DeleteWebhookResponse, error) {
+	mspid, err := commonInterceptors.ExtractMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := interceptors.ExtractProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = provider.GetWebhookService().DeleteWebhook(param.Key, mspid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &asset.DeleteWebhookResponse{}, nil
+}
+
+func (s *WebhookServer) QueryWebhookDeliveries(ctx context.Context, param *asset.QueryWebhookDeliveriesParam) (*asset.QueryWebhookDeliveriesResponse, error) {
+	mspid, err := commonInterceptors.ExtractMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := interceptors.ExtractProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, nextPage, err := provider.GetWebhookService().QueryWebhookDeliveries(
+		libCommon.NewPagination(param.PageToken, param.PageSize),
+		param.WebhookKey,
+		param.Status,
+		mspid,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &asset.QueryWebhookDeliveriesResponse{
+		Deliveries:    deliveries,
+		NextPageToken: nextPage,
+	}, nil
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/common"
+	"github.com/substra/orchestrator/lib/service"
+)
+
+func TestWebhookServiceServer(t *testing.T) {
+	server := NewWebhookServer()
+	assert.Implements(t, (*asset.WebhookServiceServer)(nil), server)
+}
+
+func TestRegisterWebhook(t *testing.T) {
+	ctx, p := getContext()
+	ws := new(service.MockWebhookAPI)
+
+	server := NewWebhookServer()
+
+	newWebhook := &asset.NewWebhook{
+		Key:    "08680966-97ae-4573-8b2d-6c4db2b3c532",
+		Url:    "https://example.com/hook",
+		Secret: "0123456789abcdef",
+	}
+	webhook := &asset.Webhook{Key: newWebhook.Key, Owner: "requester", Url: newWebhook.Url}
+
+	p.On("GetWebhookService").Return(ws)
+	ws.On("RegisterWebhook", newWebhook, "requester").Once().Return(webhook, nil)
+
+	res, err := server.RegisterWebhook(ctx, newWebhook)
+	assert.NoError(t, err)
+	assert.Equal(t, webhook, res)
+
+	p.AssertExpectations(t)
+	ws.AssertExpectations(t)
+}
+
+func TestQueryWebhookDeliveries(t *testing.T) {
+	ctx, p := getContext()
+	ws := new(service.MockWebhookAPI)
+
+	server := NewWebhookServer()
+
+	param := &asset.QueryWebhookDeliveriesParam{
+		WebhookKey: "hook",
+		Status:     asset.WebhookDeliveryStatus_DELIVERY_DEAD_LETTER,
+		PageToken:  "",
+		PageSize:   10,
+	}
+	deliveries := []*asset.WebhookDelivery{{Id: "delivery", WebhookKey: "hook", Status: asset.WebhookDeliveryStatus_DELIVERY_DEAD_LETTER}}
+
+	p.On("GetWebhookService").Return(ws)
+	ws.On("QueryWebhookDeliveries", common.NewPagination("", 10), "hook", asset.WebhookDeliveryStatus_DELIVERY_DEAD_LETTER, "requester").
+		Once().
+		Return(deliveries, "nextPage", nil)
+
+	res, err := server.QueryWebhookDeliveries(ctx, param)
+	assert.NoError(t, err)
+	assert.Equal(t, deliveries, res.Deliveries)
+	assert.Equal(t, "nextPage", res.NextPageToken)
+
+	p.AssertExpectations(t)
+	ws.AssertExpectations(t)
+}
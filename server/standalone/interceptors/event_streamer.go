@@ -0,0 +1,48 @@
+package interceptors
+
+import (
+	"context"
+	"errors"
+
+	"github.com/substra/orchestrator/server/common"
+	"github.com/substra/orchestrator/server/standalone/dbal"
+	"google.golang.org/grpc"
+)
+
+// EventStreamerInterceptor makes the EventStreamer serving event streams available to streaming requests.
+type EventStreamerInterceptor struct {
+	streamer dbal.EventStreamer
+}
+
+func NewEventStreamerInterceptor(streamer dbal.EventStreamer) *EventStreamerInterceptor {
+	return &EventStreamerInterceptor{streamer: streamer}
+}
+
+// StreamServerInterceptor will make the event streamer available from the context of each request
+func (i *EventStreamerInterceptor) StreamServerInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	newCtx := WithEventStreamer(stream.Context(), i.streamer)
+	streamWithContext := common.BindStreamToContext(newCtx, stream)
+	return handler(srv, streamWithContext)
+}
+
+type ctxEventStreamerMarker struct{}
+
+var ctxEventStreamerKey = &ctxEventStreamerMarker{}
+
+func WithEventStreamer(ctx context.Context, streamer dbal.EventStreamer) context.Context {
+	return context.WithValue(ctx, ctxEventStreamerKey, streamer)
+}
+
+// ExtractEventStreamer will return the dbal.EventStreamer injected in context
+func ExtractEventStreamer(ctx context.Context) (dbal.EventStreamer, error) {
+	streamer, ok := ctx.Value(ctxEventStreamerKey).(dbal.EventStreamer)
+	if !ok {
+		return nil, errors.New("event streamer not found in context")
+	}
+	return streamer, nil
+}
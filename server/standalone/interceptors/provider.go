@@ -23,7 +23,8 @@ type HealthReporter interface {
 // ProviderInterceptor intercepts gRPC requests and assign a request-scoped orchestration.Provider
 // to the request context.
 type ProviderInterceptor struct {
-	db             *dbal.Database
+	db             dbal.TransactionFactory
+	config         *common.OrchestratorConfiguration
 	txChecker      common.TransactionChecker
 	statusReporter HealthReporter
 }
@@ -32,10 +33,42 @@ type ctxProviderInterceptorMarker struct{}
 
 var ctxProviderKey = &ctxProviderInterceptorMarker{}
 
+type ctxDryRunMarker struct{}
+
+var ctxDryRunKey = &ctxDryRunMarker{}
+
+// dryRun holds whether the request transaction should be rolled back even if the request succeeds.
+type dryRun struct {
+	enabled bool
+}
+
+// WithDryRunSupport returns a context in which the request can be switched to dry run with EnableDryRun.
+func WithDryRunSupport(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxDryRunKey, new(dryRun))
+}
+
+// EnableDryRun marks the request transaction to be rolled back once the request is processed.
+// It fails if the context does not support dry run, so that changes are never committed by mistake.
+func EnableDryRun(ctx context.Context) error {
+	d, ok := ctx.Value(ctxDryRunKey).(*dryRun)
+	if !ok {
+		return errors.New("dry run is not supported in this context")
+	}
+	d.enabled = true
+	return nil
+}
+
+// IsDryRun returns true if the request transaction should be rolled back.
+func IsDryRun(ctx context.Context) bool {
+	d, ok := ctx.Value(ctxDryRunKey).(*dryRun)
+	return ok && d.enabled
+}
+
 // NewProviderInterceptor returns an instance of ProviderInterceptor
-func NewProviderInterceptor(db *dbal.Database, statusReporter HealthReporter) *ProviderInterceptor {
+func NewProviderInterceptor(db dbal.TransactionFactory, config *common.OrchestratorConfiguration, statusReporter HealthReporter) *ProviderInterceptor {
 	return &ProviderInterceptor{
 		db:             db,
+		config:         config,
 		txChecker:      new(common.GrpcMethodChecker),
 		statusReporter: statusReporter,
 	}
@@ -62,22 +95,22 @@ func (pi *ProviderInterceptor) UnaryServerInterceptor(ctx context.Context, req i
 
 	readOnly := pi.txChecker.IsEvaluateMethod(info.FullMethod)
 
-	tx, err := pi.db.BeginTransaction(ctx, readOnly)
+	tx, err := pi.db.BeginDBAL(ctx, channel, readOnly)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	transactionalDBAL := dbal.New(ctx, tx, tx.Conn(), channel)
 
 	// Truncate time to microsecond resolution to match PostgreSQL timestamp resolution.
 	// https://www.postgresql.org/docs/current/datatype-datetime.html
 	ts := service.NewTimeService(time.Now().Truncate(time.Microsecond))
 
-	provider := service.NewProvider(ctx, transactionalDBAL, ts, channel)
+	provider := service.NewProvider(ctx, tx, ts, channel, pi.config.GetTaskLeaseDuration(channel))
 
 	ctx = WithProvider(ctx, provider)
+	ctx = WithDryRunSupport(ctx)
 	res, err := handler(ctx, req)
 
-	if err != nil {
+	if err != nil || IsDryRun(ctx) {
 		metrics.DBTransactionTotal.WithLabelValues(info.FullMethod, "rollback").Inc()
 		rollbackErr := tx.Rollback(ctx)
 		if rollbackErr != nil {
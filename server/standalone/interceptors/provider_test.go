@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
 	"github.com/substra/orchestrator/lib/service"
+	"github.com/substra/orchestrator/server/common"
 	"github.com/substra/orchestrator/server/common/interceptors"
 	"github.com/substra/orchestrator/server/standalone/dbal"
 	"github.com/substra/orchestrator/utils"
@@ -43,14 +46,19 @@ func TestInjectProvider(t *testing.T) {
 
 	healthcheck := new(MockHealthReporter)
 
-	interceptor := NewProviderInterceptor(db, healthcheck)
+	config := &common.OrchestratorConfiguration{
+		TaskLeaseDurations: map[string]time.Duration{"testChannel": time.Minute},
+	}
+
+	interceptor := NewProviderInterceptor(db, config, healthcheck)
 
 	unaryInfo := &grpc.UnaryServerInfo{
 		FullMethod: "TestService.UnaryMethod",
 	}
 	unaryHandler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		_, err := ExtractProvider(ctx)
+		provider, err := ExtractProvider(ctx)
 		assert.NoError(t, err, "Provider extraction should not fail")
+		assert.Equal(t, time.Minute, provider.GetTaskLeaseDuration(), "lease duration should match the channel configuration")
 		return "test", nil
 	}
 
@@ -74,7 +82,7 @@ func TestOnSuccess(t *testing.T) {
 
 	healthcheck := new(MockHealthReporter)
 
-	interceptor := NewProviderInterceptor(db, healthcheck)
+	interceptor := NewProviderInterceptor(db, &common.OrchestratorConfiguration{}, healthcheck)
 
 	unaryInfo := &grpc.UnaryServerInfo{
 		FullMethod: "TestService.UnaryMethod",
@@ -107,7 +115,7 @@ func TestOnError(t *testing.T) {
 
 	healthcheck := new(MockHealthReporter)
 
-	interceptor := NewProviderInterceptor(db, healthcheck)
+	interceptor := NewProviderInterceptor(db, &common.OrchestratorConfiguration{}, healthcheck)
 
 	unaryInfo := &grpc.UnaryServerInfo{
 		FullMethod: "TestService.UnaryMethod",
@@ -126,3 +134,80 @@ func TestOnError(t *testing.T) {
 	pool.AssertExpectations(t)
 	healthcheck.AssertExpectations(t)
 }
+
+func TestOnDryRun(t *testing.T) {
+	ctx := interceptors.WithChannel(context.TODO(), "testChannel")
+
+	tx := new(utils.MockTx)
+	tx.On("Conn").Return(nil)
+	tx.On("Rollback", utils.AnyContext).Return(nil)
+
+	pool := new(dbal.MockPgPool)
+	pool.On("BeginTx", ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}).Return(tx, nil)
+	db := &dbal.Database{Pool: pool}
+
+	healthcheck := new(MockHealthReporter)
+
+	interceptor := NewProviderInterceptor(db, &common.OrchestratorConfiguration{}, healthcheck)
+
+	unaryInfo := &grpc.UnaryServerInfo{
+		FullMethod: "TestService.UnaryMethod",
+	}
+	unaryHandler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		err := EnableDryRun(ctx)
+		require.NoError(t, err)
+		return "test", nil
+	}
+
+	res, err := interceptor.UnaryServerInterceptor(ctx, "test", unaryInfo, unaryHandler)
+	assert.NoError(t, err)
+	assert.Equal(t, "test", res, "response should be returned despite the rollback")
+
+	tx.AssertExpectations(t)
+	tx.AssertNotCalled(t, "Commit", utils.AnyContext)
+	pool.AssertExpectations(t)
+}
+
+func TestEnableDryRun(t *testing.T) {
+	assert.Error(t, EnableDryRun(context.TODO()), "dry run should not be enabled outside of a supporting context")
+
+	ctx := WithDryRunSupport(context.TODO())
+	assert.False(t, IsDryRun(ctx))
+	assert.NoError(t, EnableDryRun(ctx))
+	assert.True(t, IsDryRun(ctx))
+}
+
+func TestInMemoryTransactions(t *testing.T) {
+	ctx := interceptors.WithChannel(context.TODO(), "testChannel")
+	interceptor := NewProviderInterceptor(dbal.NewMemoryDatabase(), &common.OrchestratorConfiguration{}, new(MockHealthReporter))
+
+	register := func(id string, fail bool) {
+		unaryInfo := &grpc.UnaryServerInfo{FullMethod: "TestService.UnaryMethod"}
+		_, err := interceptor.UnaryServerInterceptor(ctx, "test", unaryInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+			provider, err := ExtractProvider(ctx)
+			require.NoError(t, err)
+			_, err = provider.GetOrganizationService().RegisterOrganization(id, &asset.RegisterOrganizationParam{})
+			require.NoError(t, err)
+			if fail {
+				return nil, errors.New("test error")
+			}
+			return "test", nil
+		})
+		assert.Equal(t, fail, err != nil)
+	}
+
+	register("org1", false)
+	register("org2", true)
+
+	unaryInfo := &grpc.UnaryServerInfo{FullMethod: "orchestrator.OrganizationService/GetAllOrganizations"}
+	_, err := interceptor.UnaryServerInterceptor(ctx, "test", unaryInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+		provider, err := ExtractProvider(ctx)
+		require.NoError(t, err)
+		organizations, err := provider.GetOrganizationService().GetAllOrganizations()
+		require.NoError(t, err)
+		require.Len(t, organizations, 1, "changes of the failed request should be rolled back")
+		assert.Equal(t, "org1", organizations[0].Id)
+		return "test", nil
+	})
+	assert.NoError(t, err)
+}
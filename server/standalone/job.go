@@ -0,0 +1,114 @@
+package standalone
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/substra/orchestrator/lib/service"
+	"github.com/substra/orchestrator/server/common"
+	"github.com/substra/orchestrator/server/standalone/dbal"
+)
+
+// periodicJob runs a function in background at a regular interval.
+type periodicJob struct {
+	name     string
+	interval time.Duration
+	run      func(context.Context)
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// Start runs the job in background until Stop is called.
+// The job is not started if its interval is not positive.
+func (j *periodicJob) Start(ctx context.Context) {
+	if j.interval <= 0 {
+		log.Warn().Msgf("%s is disabled", j.name)
+		return
+	}
+
+	ctx, j.cancel = context.WithCancel(ctx)
+
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.run(ctx)
+			}
+		}
+	}()
+}
+
+// Stop interrupts the job and waits for the ongoing iteration to complete.
+func (j *periodicJob) Stop() {
+	if j.cancel != nil {
+		j.cancel()
+	}
+	j.wg.Wait()
+}
+
+// jobTransactionAttempts bounds the number of times a background job transaction is attempted,
+// when it keeps failing with an error which can be retried.
+const jobTransactionAttempts = 3
+
+// inChannelTransaction calls fn with a service provider bound to a dedicated transaction on the given channel.
+// The transaction is committed if fn succeeds, and rolled back otherwise.
+func inChannelTransaction(ctx context.Context, db dbal.TransactionFactory, config *common.OrchestratorConfiguration, channel string, fn func(service.DependenciesProvider) error) error {
+	tx, err := db.BeginDBAL(ctx, channel, false)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	ts := service.NewTimeService(time.Now().Truncate(time.Microsecond))
+	provider := service.NewProvider(ctx, tx, ts, channel, config.GetTaskLeaseDuration(channel))
+
+	err = fn(provider)
+	if err != nil {
+		rollbackErr := tx.Rollback(ctx)
+		if rollbackErr != nil {
+			return fmt.Errorf("failed to rollback transaction: %w", rollbackErr)
+		}
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// inRetriedChannelTransaction is like inChannelTransaction,
+// but the transaction is attempted again when it fails with an error which can be retried, see retryTransaction.
+// fn may therefore be called several times.
+func inRetriedChannelTransaction(ctx context.Context, db dbal.TransactionFactory, config *common.OrchestratorConfiguration, channel string, fn func(service.DependenciesProvider) error) error {
+	return retryTransaction(ctx, func() error {
+		return inChannelTransaction(ctx, db, config, channel, fn)
+	})
+}
+
+// retryTransaction calls fn, which runs a whole transaction, again as long as it fails with an error
+// which can be retried, see shouldRetry, up to jobTransactionAttempts times.
+func retryTransaction(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= jobTransactionAttempts; attempt++ {
+		err = fn()
+		if err == nil || !shouldRetry(err) {
+			return err
+		}
+		log.Ctx(ctx).Info().Err(err).Int("attempt", attempt).Msg("retrying failed transaction")
+	}
+
+	return err
+}
@@ -0,0 +1,63 @@
+package standalone
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/substra/orchestrator/lib/service"
+	"github.com/substra/orchestrator/server/common"
+	"github.com/substra/orchestrator/server/standalone/dbal"
+	"github.com/substra/orchestrator/utils"
+)
+
+func TestInRetriedChannelTransaction(t *testing.T) {
+	tx := new(dbal.MockTransaction)
+	db := new(dbal.MockTransactionFactory)
+	db.On("BeginDBAL", utils.AnyContext, "mychannel", false).Return(tx, nil)
+
+	tx.On("Rollback", mock.Anything).Twice().Return(nil)
+	tx.On("Commit", mock.Anything).Once().Return(nil)
+
+	calls := 0
+	err := inRetriedChannelTransaction(context.Background(), db, &common.OrchestratorConfiguration{}, "mychannel", func(_ service.DependenciesProvider) error {
+		calls++
+		if calls < 3 {
+			return &pgconn.PgError{Code: "40001"}
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	tx.AssertExpectations(t)
+}
+
+func TestInRetriedChannelTransactionGivesUp(t *testing.T) {
+	tx := new(dbal.MockTransaction)
+	db := new(dbal.MockTransactionFactory)
+	db.On("BeginDBAL", utils.AnyContext, "mychannel", false).Return(tx, nil)
+	tx.On("Rollback", mock.Anything).Return(nil)
+
+	calls := 0
+	err := inRetriedChannelTransaction(context.Background(), db, &common.OrchestratorConfiguration{}, "mychannel", func(_ service.DependenciesProvider) error {
+		calls++
+		return &pgconn.PgError{Code: "40001"}
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, jobTransactionAttempts, calls)
+
+	// Errors which cannot be retried are returned right away
+	calls = 0
+	err = inRetriedChannelTransaction(context.Background(), db, &common.OrchestratorConfiguration{}, "mychannel", func(_ service.DependenciesProvider) error {
+		calls++
+		return errors.New("failure")
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
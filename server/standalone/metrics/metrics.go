@@ -14,6 +14,52 @@ var (
 		[]string{"method", "outcome"},
 	)
 
+	// DBPoolTransactionTotal keeps track of the number of transactions begun on each database pool
+	DBPoolTransactionTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "orc_db_pool_transaction_total",
+			Help: "Number of database transactions, partitioned by pool (primary/replica) and access mode (read_write/read_only)",
+		},
+		[]string{"pool", "access_mode"},
+	)
+
+	// DBReplicaLag keeps track of the replication lag of the read replica
+	DBReplicaLag = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "orc_db_replica_lag_seconds",
+			Help: "Replication lag of the read replica, as last measured",
+		},
+	)
+
+	// DBALCallDuration keeps track of the latency of DBAL calls
+	DBALCallDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "orc_dbal_call_duration_seconds",
+			Help:    "Duration of DBAL calls, partitioned by method and channel",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+		},
+		[]string{"method", "channel"},
+	)
+
+	// DBALCallErrorTotal keeps track of the number of failed DBAL calls
+	DBALCallErrorTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "orc_dbal_call_error_total",
+			Help: "Number of DBAL calls returning an error, partitioned by method and channel",
+		},
+		[]string{"method", "channel"},
+	)
+
+	// DBALRowsReturned keeps track of the number of assets returned by DBAL calls
+	DBALRowsReturned = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "orc_dbal_rows_returned",
+			Help:    "Number of assets returned by DBAL calls returning a list, partitioned by method and channel",
+			Buckets: []float64{0, 1, 5, 10, 50, 100, 500, 1000, 5000},
+		},
+		[]string{"method", "channel"},
+	)
+
 	// EventDispatchedTotal keeps track of the number of dispatched events
 	EventDispatchedTotal = prometheus.NewCounter(
 		prometheus.CounterOpts{
@@ -21,9 +67,52 @@ var (
 			Help: "Number of events dispatched",
 		},
 	)
+
+	// EventSubscribers keeps track of the number of event subscribers
+	EventSubscribers = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "orc_event_subscribers",
+			Help: "Number of subscribers to event notifications",
+		},
+	)
+
+	// EventSubscriberLag keeps track of the events notified but not yet processed by subscribers
+	EventSubscriberLag = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "orc_event_subscriber_lag_events",
+			Help:    "Number of event positions between the last event notified on a channel and the last one processed by a subscriber, when a new one is received",
+			Buckets: []float64{0, 1, 10, 100, 1000, 10000, 100000},
+		},
+	)
+
+	// EventSubscriberBufferedNotifications keeps track of the fill level of subscriber buffers
+	EventSubscriberBufferedNotifications = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "orc_event_subscriber_buffered_notifications",
+			Help:    "Number of notifications pending in the buffer of a subscriber when a new one is received",
+			Buckets: []float64{0, 1, 5, 10, 25, 50, 100, 250, 500},
+		},
+	)
+
+	// EventNotificationDroppedTotal keeps track of the notifications dropped because of a full subscriber buffer
+	EventNotificationDroppedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "orc_event_notification_dropped_total",
+			Help: "Number of notifications dropped from the full buffer of a subscriber",
+		},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(DBTransactionTotal)
+	prometheus.MustRegister(DBPoolTransactionTotal)
+	prometheus.MustRegister(DBReplicaLag)
+	prometheus.MustRegister(DBALCallDuration)
+	prometheus.MustRegister(DBALCallErrorTotal)
+	prometheus.MustRegister(DBALRowsReturned)
 	prometheus.MustRegister(EventDispatchedTotal)
+	prometheus.MustRegister(EventSubscribers)
+	prometheus.MustRegister(EventSubscriberLag)
+	prometheus.MustRegister(EventSubscriberBufferedNotifications)
+	prometheus.MustRegister(EventNotificationDroppedTotal)
 }
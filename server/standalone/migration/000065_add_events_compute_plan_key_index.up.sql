@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS ix_events_compute_plan_key ON events ((asset->>'computePlanKey'));
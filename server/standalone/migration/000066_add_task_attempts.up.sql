@@ -0,0 +1,69 @@
+SELECT execute($$
+    ALTER TABLE compute_tasks
+    ADD COLUMN attempt INTEGER DEFAULT 1 NOT NULL;
+
+    UPDATE events
+    SET asset = jsonb_set(asset, '{attempt}', to_jsonb(1))
+    WHERE asset_kind = 'ASSET_COMPUTE_TASK' AND NOT(asset ? 'attempt');
+$$) WHERE NOT column_exists('public', 'compute_tasks', 'attempt');
+
+SELECT execute($$
+    ALTER TABLE compute_plans
+    ADD COLUMN max_task_attempts INTEGER DEFAULT 0 NOT NULL;
+$$) WHERE NOT column_exists('public', 'compute_plans', 'max_task_attempts');
+
+SELECT execute($$
+    ALTER TABLE functions
+    ADD COLUMN max_task_attempts INTEGER DEFAULT 0 NOT NULL;
+
+    DROP VIEW IF EXISTS expanded_functions;
+    CREATE VIEW expanded_functions AS
+        SELECT 	key,
+                name,
+                description             AS description_address,
+                desc_add.checksum       AS description_checksum,
+                archive_address,
+                archive_add.checksum   AS archive_checksum,
+                permissions,
+                owner,
+                creation_date,
+                metadata,
+                channel,
+                status,
+                image_address,
+                image_add.checksum   AS image_checksum,
+                max_task_attempts
+        FROM functions
+        JOIN addressables desc_add ON functions.description = desc_add.storage_address
+        JOIN addressables archive_add ON functions.archive_address = archive_add.storage_address
+        JOIN addressables image_add ON functions.image_address = image_add.storage_address;
+$$) WHERE NOT column_exists('public', 'functions', 'max_task_attempts');
+
+SELECT execute($$
+    ALTER TABLE failure_reports
+    ADD COLUMN attempt INTEGER DEFAULT 1 NOT NULL;
+
+    -- each execution attempt of a task has its own failure report
+    ALTER TABLE failure_reports
+    DROP CONSTRAINT failure_reports_pkey;
+    ALTER TABLE failure_reports
+    ADD CONSTRAINT failure_reports_pkey PRIMARY KEY (asset_key, attempt);
+
+    DROP VIEW IF EXISTS expanded_failure_reports;
+    CREATE VIEW expanded_failure_reports AS
+    SELECT asset_key,
+           asset_type,
+           error_type,
+           logs_address,
+           a.checksum AS logs_checksum,
+           creation_date,
+           owner,
+           channel,
+           attempt
+    FROM failure_reports
+    LEFT JOIN addressables a ON failure_reports.logs_address = a.storage_address;
+
+    UPDATE events
+    SET asset = jsonb_set(asset, '{attempt}', to_jsonb(1))
+    WHERE asset_kind = 'ASSET_FAILURE_REPORT' AND NOT(asset ? 'attempt');
+$$) WHERE NOT column_exists('public', 'failure_reports', 'attempt');
@@ -0,0 +1,5 @@
+SELECT execute($$
+    ALTER TABLE compute_plans
+    ADD COLUMN pause_date timestamptz,
+    ADD COLUMN resume_date timestamptz;
+$$) WHERE NOT column_exists('public', 'compute_plans', 'pause_date');
@@ -0,0 +1,7 @@
+SELECT execute($$
+    ALTER TABLE compute_tasks
+    ADD COLUMN lease_expiration_date timestamptz;
+$$) WHERE NOT column_exists('public', 'compute_tasks', 'lease_expiration_date');
+
+CREATE INDEX IF NOT EXISTS ix_compute_tasks_lease_expiration_date ON compute_tasks (lease_expiration_date)
+WHERE status = 'STATUS_EXECUTING';
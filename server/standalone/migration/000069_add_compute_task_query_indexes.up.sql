@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS ix_compute_tasks_channel_worker_status_rank ON compute_tasks (channel, worker, status, rank, key);
+CREATE INDEX IF NOT EXISTS ix_compute_tasks_channel_creation_date ON compute_tasks (channel, creation_date, key);
+CREATE INDEX IF NOT EXISTS ix_compute_tasks_channel_owner ON compute_tasks (channel, owner);
+CREATE INDEX IF NOT EXISTS ix_compute_tasks_metadata ON compute_tasks USING GIN (metadata jsonb_path_ops);
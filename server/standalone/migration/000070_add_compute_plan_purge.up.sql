@@ -0,0 +1,7 @@
+SELECT execute($$
+    ALTER TABLE compute_plans
+    ADD COLUMN purge_date timestamptz,
+    ADD COLUMN purge_summary jsonb;
+$$) WHERE NOT column_exists('public', 'compute_plans', 'purge_date');
+
+CREATE INDEX IF NOT EXISTS ix_events_compute_task_key ON events ((asset->>'computeTaskKey'));
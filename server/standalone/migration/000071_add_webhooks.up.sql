@@ -0,0 +1,38 @@
+SELECT execute($$
+
+    CREATE TABLE webhooks (
+        key uuid PRIMARY KEY,
+        channel varchar(100) NOT NULL,
+        owner varchar(100) NOT NULL,
+        url text NOT NULL,
+        secret text NOT NULL,
+        filter jsonb NOT NULL,
+        creation_date timestamptz NOT NULL,
+        /* position of the last event processed by the webhook */
+        position bigint NOT NULL
+    );
+
+    CREATE INDEX ix_webhooks_channel_owner ON webhooks (channel, owner, creation_date, key);
+
+$$) WHERE NOT table_exists('public', 'webhooks');
+
+SELECT execute($$
+
+    CREATE TABLE webhook_deliveries (
+        id uuid PRIMARY KEY,
+        webhook_key uuid NOT NULL REFERENCES webhooks (key) ON DELETE CASCADE,
+        /* no foreign key since events may be purged along with their compute plan */
+        event_id uuid NOT NULL,
+        status varchar(100) NOT NULL,
+        attempts integer NOT NULL,
+        creation_date timestamptz NOT NULL,
+        last_attempt_date timestamptz,
+        next_attempt_date timestamptz,
+        response_code integer NOT NULL,
+        error text NOT NULL,
+        UNIQUE (webhook_key, event_id)
+    );
+
+    CREATE INDEX ix_webhook_deliveries_webhook_key_creation_date ON webhook_deliveries (webhook_key, creation_date DESC, id DESC);
+
+$$) WHERE NOT table_exists('public', 'webhook_deliveries');
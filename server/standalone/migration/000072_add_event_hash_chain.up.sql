@@ -0,0 +1,75 @@
+-- Canonical serialized form of an event, which is hashed to build the event chain.
+-- It must stay consistent with the canonical form computed by the DBAL to verify the chain.
+CREATE OR REPLACE FUNCTION event_canonical_form(e events) RETURNS text AS
+$$
+BEGIN
+    RETURN e.channel || E'\n' ||
+           e.position::text || E'\n' ||
+           e.id::text || E'\n' ||
+           e.asset_key || E'\n' ||
+           e.asset_kind || E'\n' ||
+           e.event_kind || E'\n' ||
+           TO_CHAR(e.timestamp AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"') || E'\n' ||
+           COALESCE(e.asset::text, '') || E'\n' ||
+           e.metadata::text;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Hash of an event chained to the hash of the previous event of the same channel.
+CREATE OR REPLACE FUNCTION event_hash(previous bytea, e events) RETURNS bytea AS
+$$
+BEGIN
+    RETURN SHA256(COALESCE(previous, ''::bytea) || CONVERT_TO(event_canonical_form(e), 'UTF8'));
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION chain_event()
+    RETURNS trigger AS
+$$
+DECLARE
+    previous bytea;
+BEGIN
+    SELECT hash INTO previous
+    FROM events
+    WHERE channel = NEW.channel AND position < NEW.position
+    ORDER BY position DESC
+    LIMIT 1;
+
+    NEW.hash := event_hash(previous, NEW);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT execute($$
+    ALTER TABLE events ADD COLUMN hash bytea;
+
+    CREATE INDEX ix_events_channel_position ON events(channel, position);
+
+    DO $backfill$
+    DECLARE
+        e events;
+        previous bytea;
+        current_channel text;
+    BEGIN
+        FOR e IN SELECT * FROM events ORDER BY channel, position LOOP
+            IF current_channel IS DISTINCT FROM e.channel THEN
+                previous := NULL;
+                current_channel := e.channel;
+            END IF;
+
+            previous := event_hash(previous, e);
+            UPDATE events SET hash = previous WHERE id = e.id;
+        END LOOP;
+    END
+    $backfill$;
+
+    ALTER TABLE events ALTER COLUMN hash SET NOT NULL;
+$$) WHERE NOT column_exists('public', 'events', 'hash');
+
+DROP TRIGGER IF EXISTS chain_event ON events;
+
+CREATE TRIGGER chain_event
+    BEFORE INSERT
+    ON events
+    FOR EACH ROW
+EXECUTE PROCEDURE chain_event();
@@ -0,0 +1,14 @@
+SELECT execute($$
+
+    CREATE TABLE event_consumers (
+        channel varchar(100) NOT NULL,
+        owner varchar(100) NOT NULL,
+        name varchar(100) NOT NULL,
+        /* last event acknowledged by the consumer and its position */
+        event_id uuid NOT NULL,
+        position bigint NOT NULL,
+        ack_date timestamptz NOT NULL,
+        PRIMARY KEY (channel, owner, name)
+    );
+
+$$) WHERE NOT table_exists('public', 'event_consumers');
@@ -0,0 +1,15 @@
+SELECT execute($$
+
+    CREATE TABLE event_sink_positions (
+        sink varchar(100) NOT NULL,
+        channel varchar(100) NOT NULL,
+        /* position of the last event delivered to the sink */
+        position bigint NOT NULL,
+        update_date timestamptz NOT NULL,
+        /* relay currently sending events to the sink, until the claim expires */
+        claim_id uuid,
+        claimed_until timestamptz,
+        PRIMARY KEY (sink, channel)
+    );
+
+$$) WHERE NOT table_exists('public', 'event_sink_positions');
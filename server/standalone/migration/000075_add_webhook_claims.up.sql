@@ -0,0 +1,6 @@
+SELECT execute($$
+    ALTER TABLE webhooks
+    /* dispatcher currently pushing events to the webhook, until the claim expires */
+    ADD COLUMN claim_id uuid,
+    ADD COLUMN claimed_until timestamptz;
+$$) WHERE NOT column_exists('public', 'webhooks', 'claim_id');
@@ -0,0 +1,51 @@
+SELECT execute($$
+
+    ALTER TABLE events ADD COLUMN previous_hash bytea;
+
+    UPDATE events e SET previous_hash = (
+        SELECT hash FROM events
+        WHERE channel = e.channel AND position < e.position
+        ORDER BY position DESC
+        LIMIT 1
+    );
+
+$$) WHERE NOT column_exists('public', 'events', 'previous_hash');
+
+SELECT execute($$
+
+    /* Hashes of the events removed by a purge, linking the remaining events of the chain */
+    CREATE TABLE event_tombstones (
+        position bigint PRIMARY KEY,
+        channel varchar(100) NOT NULL,
+        id uuid NOT NULL,
+        hash bytea NOT NULL,
+        previous_hash bytea
+    );
+
+    CREATE INDEX ix_event_tombstones_channel_position ON event_tombstones(channel, position);
+
+$$) WHERE NOT table_exists('public', 'event_tombstones');
+
+-- Events are chained to the previous event of the channel, even if it has been purged.
+CREATE OR REPLACE FUNCTION chain_event()
+    RETURNS trigger AS
+$$
+DECLARE
+    previous bytea;
+BEGIN
+    SELECT hash INTO previous
+    FROM (
+        SELECT position, hash FROM events
+        WHERE channel = NEW.channel AND position < NEW.position
+        UNION ALL
+        SELECT position, hash FROM event_tombstones
+        WHERE channel = NEW.channel AND position < NEW.position
+    ) chain
+    ORDER BY position DESC
+    LIMIT 1;
+
+    NEW.previous_hash := previous;
+    NEW.hash := event_hash(previous, NEW);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,174 @@
+package standalone
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/service"
+	"github.com/substra/orchestrator/server/common"
+	"github.com/substra/orchestrator/server/standalone/dbal"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// PlanPurger periodically purges the compute plans terminated for longer than the retention of their channel.
+// It only processes channels on which a retention is set.
+type PlanPurger struct {
+	periodicJob
+	db         dbal.TransactionFactory
+	config     *common.OrchestratorConfiguration
+	archiveDir string
+}
+
+// NewPlanPurger returns a purger looking for expired plans at the given interval.
+// When archiveDir is not empty, plans are archived in this directory before being purged.
+func NewPlanPurger(db dbal.TransactionFactory, config *common.OrchestratorConfiguration, interval time.Duration, archiveDir string) *PlanPurger {
+	p := &PlanPurger{
+		db:         db,
+		config:     config,
+		archiveDir: archiveDir,
+	}
+	p.periodicJob = periodicJob{
+		name:     "compute plan purger",
+		interval: interval,
+		run:      p.purgeAll,
+	}
+
+	return p
+}
+
+func (p *PlanPurger) purgeAll(ctx context.Context) {
+	for channel := range p.config.PlanRetentionDays {
+		retention := p.config.GetPlanRetention(channel)
+		if retention <= 0 {
+			continue
+		}
+
+		logger := log.With().Str("channel", channel).Logger()
+
+		keys, err := p.purge(logger.WithContext(ctx), channel, retention)
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to purge expired compute plans")
+			continue
+		}
+		if len(keys) > 0 {
+			logger.Info().Strs("computePlanKeys", keys).Msg("purged expired compute plans")
+		}
+	}
+}
+
+// purge lists the expired plans of a single channel, then purges each of them in a dedicated transaction.
+// A plan which cannot be purged is logged and checked again on the next run.
+func (p *PlanPurger) purge(ctx context.Context, channel string, retention time.Duration) ([]string, error) {
+	var keys []string
+
+	err := inChannelTransaction(ctx, p.db, p.config, channel, func(provider service.DependenciesProvider) error {
+		var err error
+		keys, err = provider.GetComputePlanService().GetExpiredPlanKeys(retention)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	purged := make([]string, 0, len(keys))
+	for _, key := range keys {
+		ok, err := p.purgePlan(ctx, channel, key)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("computePlanKey", key).Msg("failed to purge expired compute plan")
+			continue
+		}
+		if ok {
+			purged = append(purged, key)
+		}
+	}
+
+	return purged, nil
+}
+
+// purgePlan purges a single plan in its own transaction.
+// Its archive is written to a temporary file within the transaction: the purge is rolled back if the archive can't be written.
+// The file is only moved in place once the transaction is committed,
+// so that no archive is left behind for a plan whose purge has been rolled back.
+func (p *PlanPurger) purgePlan(ctx context.Context, channel string, key string) (bool, error) {
+	dir := filepath.Join(p.archiveDir, channel)
+	var tempPath string
+	var archiver service.PlanArchiver
+	if p.archiveDir != "" {
+		archiver = func(a *asset.ComputePlanArchive) error {
+			var err error
+			tempPath, err = writeTempArchive(dir, a)
+			return err
+		}
+	}
+
+	var purged bool
+	err := inRetriedChannelTransaction(ctx, p.db, p.config, channel, func(provider service.DependenciesProvider) error {
+		// The archive of a previous attempt is outdated
+		removeTempArchive(ctx, tempPath)
+		tempPath = ""
+		var err error
+		purged, err = provider.GetComputePlanService().PurgeExpiredPlan(key, archiver)
+		return err
+	})
+	if err != nil {
+		removeTempArchive(ctx, tempPath)
+		return false, err
+	}
+
+	if tempPath != "" {
+		err = os.Rename(tempPath, filepath.Join(dir, key+".json"))
+		if err != nil {
+			// The purge is committed and cannot be rolled back anymore, the archive is kept under its temporary name
+			log.Ctx(ctx).Error().Err(err).Str("computePlanKey", key).Str("path", tempPath).Msg("failed to move the archive of a purged compute plan in place")
+		}
+	}
+
+	return purged, nil
+}
+
+// writeTempArchive stores the archive as a JSON file in dir, under a temporary name which is returned.
+// The file is synced to disk before returning, so that the archive outlives a crash following the purge.
+func writeTempArchive(dir string, archive *asset.ComputePlanArchive) (string, error) {
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := protojson.Marshal(archive)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp(dir, archive.ComputePlan.Key+".json.*.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = f.Write(content)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name()) //nolint:errcheck
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// removeTempArchive deletes the temporary archive of a plan whose purge has not been committed.
+func removeTempArchive(ctx context.Context, path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Ctx(ctx).Warn().Err(err).Str("path", path).Msg("failed to remove a temporary compute plan archive")
+	}
+}
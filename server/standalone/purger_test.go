@@ -0,0 +1,102 @@
+package standalone
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/server/common"
+	"github.com/substra/orchestrator/server/standalone/dbal"
+	"github.com/substra/orchestrator/utils"
+)
+
+// newArchivableTransaction returns a transaction in which the plan can be purged, up to its archive.
+func newArchivableTransaction(key string) *dbal.MockTransaction {
+	tx := new(dbal.MockTransaction)
+	tx.On("GetComputePlan", key).Return(&asset.ComputePlan{Key: key}, nil)
+	tx.On("GetComputePlanStatistics", key).Return(&asset.ComputePlanStatistics{
+		TaskCount:  1,
+		TaskCounts: []*asset.TaskStatusCount{{Status: asset.ComputeTaskStatus_STATUS_DONE, Count: 1}},
+	}, nil)
+	tx.On("IsComputePlanReferenced", key).Return(false, nil)
+	tx.On("GetComputePlanArchive", key).Return(&asset.ComputePlanArchive{ComputePlan: &asset.ComputePlan{Key: key}}, nil)
+
+	return tx
+}
+
+func newPurgeableTransaction(key string) *dbal.MockTransaction {
+	tx := newArchivableTransaction(key)
+	tx.On("PurgeComputePlan", key, mock.Anything).Return(&asset.ComputePlanPurgeSummary{TaskCount: 1}, nil)
+	tx.On("NewEventID").Return("event")
+	tx.On("AddEvents", mock.Anything).Return(nil)
+
+	return tx
+}
+
+func TestPurgePlanWritesArchiveAfterCommit(t *testing.T) {
+	tx := newPurgeableTransaction("cp1")
+	tx.On("Commit", mock.Anything).Once().Return(nil)
+	db := new(dbal.MockTransactionFactory)
+	db.On("BeginDBAL", utils.AnyContext, "mychannel", false).Return(tx, nil)
+
+	dir := t.TempDir()
+	purger := NewPlanPurger(db, &common.OrchestratorConfiguration{}, 0, dir)
+
+	purged, err := purger.purgePlan(context.Background(), "mychannel", "cp1")
+	assert.NoError(t, err)
+	assert.True(t, purged)
+	assert.FileExists(t, filepath.Join(dir, "mychannel", "cp1.json"))
+	assertNoTempArchive(t, filepath.Join(dir, "mychannel"))
+
+	tx.AssertExpectations(t)
+}
+
+func TestPurgePlanSkipsArchiveOnFailedCommit(t *testing.T) {
+	tx := newPurgeableTransaction("cp1")
+	tx.On("Commit", mock.Anything).Once().Return(errors.New("commit failure"))
+	db := new(dbal.MockTransactionFactory)
+	db.On("BeginDBAL", utils.AnyContext, "mychannel", false).Return(tx, nil)
+
+	dir := t.TempDir()
+	purger := NewPlanPurger(db, &common.OrchestratorConfiguration{}, 0, dir)
+
+	purged, err := purger.purgePlan(context.Background(), "mychannel", "cp1")
+	assert.Error(t, err)
+	assert.False(t, purged)
+	assert.NoFileExists(t, filepath.Join(dir, "mychannel", "cp1.json"))
+	assertNoTempArchive(t, filepath.Join(dir, "mychannel"))
+
+	tx.AssertExpectations(t)
+}
+
+func TestPurgePlanRollsBackOnFailedArchive(t *testing.T) {
+	tx := newArchivableTransaction("cp1")
+	tx.On("Rollback", mock.Anything).Once().Return(nil)
+	db := new(dbal.MockTransactionFactory)
+	db.On("BeginDBAL", utils.AnyContext, "mychannel", false).Return(tx, nil)
+
+	// The channel directory can't be created where a file already exists
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mychannel"), nil, 0o600))
+	purger := NewPlanPurger(db, &common.OrchestratorConfiguration{}, 0, dir)
+
+	purged, err := purger.purgePlan(context.Background(), "mychannel", "cp1")
+	assert.Error(t, err)
+	assert.False(t, purged)
+
+	tx.AssertNotCalled(t, "PurgeComputePlan", mock.Anything, mock.Anything)
+	tx.AssertNotCalled(t, "Commit", mock.Anything)
+	tx.AssertExpectations(t)
+}
+
+func assertNoTempArchive(t *testing.T, dir string) {
+	temp, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, temp, "temporary archives should be removed")
+}
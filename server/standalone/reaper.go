@@ -0,0 +1,87 @@
+package standalone
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/substra/orchestrator/lib/service"
+	"github.com/substra/orchestrator/server/common"
+	"github.com/substra/orchestrator/server/standalone/dbal"
+)
+
+// TaskLeaseReaper periodically fails the executing tasks whose lease has expired.
+// It only processes channels on which task leases are enabled.
+type TaskLeaseReaper struct {
+	periodicJob
+	db     dbal.TransactionFactory
+	config *common.OrchestratorConfiguration
+}
+
+// NewTaskLeaseReaper returns a reaper checking leases at the given interval.
+func NewTaskLeaseReaper(db dbal.TransactionFactory, config *common.OrchestratorConfiguration, interval time.Duration) *TaskLeaseReaper {
+	r := &TaskLeaseReaper{
+		db:     db,
+		config: config,
+	}
+	r.periodicJob = periodicJob{
+		name:     "task lease reaper",
+		interval: interval,
+		run:      r.reapAll,
+	}
+
+	return r
+}
+
+func (r *TaskLeaseReaper) reapAll(ctx context.Context) {
+	for channel, duration := range r.config.TaskLeaseDurations {
+		if duration <= 0 {
+			continue
+		}
+
+		logger := log.With().Str("channel", channel).Logger()
+
+		keys, err := r.reap(logger.WithContext(ctx), channel)
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to reap expired task leases")
+			continue
+		}
+		if len(keys) > 0 {
+			logger.Info().Strs("taskKeys", keys).Msg("failed tasks with expired lease")
+		}
+	}
+}
+
+// reap fails expired tasks of a single channel.
+// Each task is failed in a dedicated transaction: a task which cannot be failed is logged and skipped.
+func (r *TaskLeaseReaper) reap(ctx context.Context, channel string) ([]string, error) {
+	var keys []string
+
+	err := inChannelTransaction(ctx, r.db, r.config, channel, func(provider service.DependenciesProvider) error {
+		var err error
+		keys, err = provider.GetComputeTaskService().GetExpiredTaskKeys()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	failed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		var ok bool
+		err := inRetriedChannelTransaction(ctx, r.db, r.config, channel, func(provider service.DependenciesProvider) error {
+			var err error
+			ok, err = provider.GetComputeTaskService().FailExpiredTask(key)
+			return err
+		})
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("taskKey", key).Msg("failed to fail task with expired lease")
+			continue
+		}
+		if ok {
+			failed = append(failed, key)
+		}
+	}
+
+	return failed, nil
+}
@@ -0,0 +1,178 @@
+package standalone
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/server/common"
+	"github.com/substra/orchestrator/server/standalone/dbal"
+)
+
+// relayBatchSize is the maximum number of events sent to a sink at once.
+const relayBatchSize = 500
+
+// relayClaimDuration is the time given to a relay to send a batch of events to a sink and record its progress.
+// A sink claimed by a relay which did not complete, eg: because the orchestrator stopped,
+// can be relayed again once this delay has elapsed.
+const relayClaimDuration = 5 * time.Minute
+
+// relayRecordDelay is the part of the claim kept to record the progress of the sink once the batch is sent.
+const relayRecordDelay = 30 * time.Second
+
+// EventRelay periodically delivers the new events of every channel to the event sinks.
+// The position of the last event delivered to a sink is recorded by channel, once the batch is sent.
+// Since events are inserted under a table lock, they are committed in position order:
+// an event can't become visible after the relay moved past its position.
+type EventRelay struct {
+	periodicJob
+	db     *dbal.Database
+	config *common.OrchestratorConfiguration
+	sinks  []EventSink
+}
+
+// NewEventRelay returns a relay looking for events to deliver at the given interval.
+func NewEventRelay(db *dbal.Database, config *common.OrchestratorConfiguration, sinks []EventSink, interval time.Duration) *EventRelay {
+	r := &EventRelay{
+		db:     db,
+		config: config,
+		sinks:  sinks,
+	}
+	r.periodicJob = periodicJob{
+		name:     "event relay",
+		interval: interval,
+		run:      r.relayAll,
+	}
+
+	return r
+}
+
+// Start runs the relay in background, it does nothing when no sink is configured.
+func (r *EventRelay) Start(ctx context.Context) {
+	if len(r.sinks) == 0 {
+		return
+	}
+	r.periodicJob.Start(ctx)
+}
+
+func (r *EventRelay) relayAll(ctx context.Context) {
+	for channel := range r.config.Channels {
+		for _, sink := range r.sinks {
+			logger := log.With().Str("channel", channel).Str("sink", sink.Name()).Logger()
+
+			for ctx.Err() == nil {
+				count, err := r.relay(logger.WithContext(ctx), channel, sink)
+				if err != nil {
+					logger.Error().Err(err).Msg("failed to relay events")
+					break
+				}
+				if count > 0 {
+					logger.Debug().Int("count", count).Msg("relayed events")
+				}
+				if count < relayBatchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// relay delivers a batch of events to a sink and returns the number of delivered events.
+// The progress of the sink is claimed and the events are read in a first transaction,
+// the events are sent outside of any transaction, then the progress is recorded in a second transaction.
+// The claim prevents concurrent relays from sending the same batch.
+func (r *EventRelay) relay(ctx context.Context, channel string, sink EventSink) (int, error) {
+	claimID := uuid.NewString()
+	now := time.Now()
+	claimedUntil := now.Add(relayClaimDuration)
+
+	var claimed bool
+	var events []*asset.ExportedEvent
+
+	err := r.inTransaction(ctx, channel, func(d *dbal.DBAL) error {
+		var position int64
+		var err error
+
+		position, claimed, err = d.ClaimEventSinkPosition(sink.Name(), claimID, now, claimedUntil)
+		if err != nil || !claimed {
+			return err
+		}
+
+		events, err = d.GetEventsAfter(position, relayBatchSize)
+		if err != nil || len(events) > 0 {
+			return err
+		}
+
+		// Nothing to send, there is no need to hold the claim
+		_, err = d.ReleaseEventSinkPosition(sink.Name(), claimID, nil, now)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !claimed {
+		log.Ctx(ctx).Debug().Msg("sink is already being relayed")
+		return 0, nil
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	// Leave enough time to record the progress before the claim expires
+	sendCtx, cancel := context.WithDeadline(ctx, claimedUntil.Add(-relayRecordDelay))
+	sendErr := sink.Send(sendCtx, events)
+	cancel()
+
+	// The position is left untouched when the batch was not sent, so that it is sent again on the next check
+	var position *int64
+	if sendErr == nil {
+		last := int64(events[len(events)-1].Position)
+		position = &last
+	}
+
+	var released bool
+	err = retryTransaction(ctx, func() error {
+		return r.inTransaction(ctx, channel, func(d *dbal.DBAL) error {
+			var err error
+			released, err = d.ReleaseEventSinkPosition(sink.Name(), claimID, position, time.Now())
+			return err
+		})
+	})
+	if sendErr != nil {
+		return 0, sendErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !released {
+		log.Ctx(ctx).Warn().Msg("sink claim expired before the progress was recorded")
+		return 0, nil
+	}
+
+	return len(events), nil
+}
+
+// inTransaction calls fn with a DBAL bound to a dedicated transaction on the given channel.
+// The transaction is committed if fn succeeds, and rolled back otherwise.
+func (r *EventRelay) inTransaction(ctx context.Context, channel string, fn func(*dbal.DBAL) error) error {
+	tx, err := r.db.BeginTransaction(ctx, false)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	err = fn(dbal.New(ctx, tx, tx.Conn(), channel))
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
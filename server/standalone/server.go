@@ -1,7 +1,9 @@
 package standalone
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/jackc/pgconn"
@@ -16,17 +18,90 @@ import (
 	"google.golang.org/grpc/health"
 )
 
+const (
+	// EventSubscriptionModeListen relies on PostgreSQL LISTEN/NOTIFY to be notified of new events
+	EventSubscriptionModeListen = "listen"
+	// EventSubscriptionModePoll polls the events table, for databases behind a pooler in transaction mode
+	EventSubscriptionModePoll = "poll"
+)
+
 type AppServer struct {
-	grpc *grpc.Server
-	db   *dbal.Database
+	grpc     *grpc.Server
+	db       *dbal.Database
+	sqliteDB *dbal.SQLiteDatabase
+	notifier *dbal.EventNotifier
+	reaper   *TaskLeaseReaper
+	purger   *PlanPurger
+	hooks    *WebhookDispatcher
+	relay    *EventRelay
 }
 
+// GetServer returns a server storing assets in the database referenced by dbURL.
+// Assets are kept in memory when dbURL is dbal.MemoryDatabaseURL:
+// event streams then poll the stored events, and event sinks are unavailable.
+// Assets are stored in a SQLite database file when dbURL starts with dbal.SQLiteDatabaseScheme:
+// event streams then poll the events table, and event sinks are unavailable.
 func GetServer(dbURL string, params common.AppParameters, healthcheck *health.Server) (*AppServer, error) {
-	pgDB, err := dbal.InitDatabase(dbURL)
+	sinks, err := NewEventSinks(params.Config.EventSinks, params.EventSinkTimeout)
 	if err != nil {
 		return nil, err
 	}
 
+	var db dbal.TransactionFactory
+	var pgDB *dbal.Database
+	var sqliteDB *dbal.SQLiteDatabase
+	var notifier *dbal.EventNotifier
+	var streamResources grpc.StreamServerInterceptor
+
+	if params.DatabaseReplicaURL != "" && (dbal.IsMemoryDatabaseURL(dbURL) || dbal.IsSQLiteDatabaseURL(dbURL)) {
+		return nil, errors.New("read replicas are only supported with PostgreSQL")
+	}
+
+	switch {
+	case dbal.IsMemoryDatabaseURL(dbURL):
+		if len(sinks) > 0 {
+			return nil, errors.New("event sinks are not supported by the in-memory database")
+		}
+		memoryDB := dbal.NewMemoryDatabase()
+		// The in-memory database has no LISTEN: new events are always polled
+		notifier = dbal.NewMemoryEventNotifier(memoryDB, params.EventSubscriberBufferSize, params.EventPollMinInterval, params.EventPollMaxInterval)
+		db = memoryDB
+		streamResources = interceptors.NewEventStreamerInterceptor(dbal.NewMemoryEventStreamer(memoryDB, notifier)).StreamServerInterceptor
+	case dbal.IsSQLiteDatabaseURL(dbURL):
+		if len(sinks) > 0 {
+			return nil, errors.New("event sinks are not supported by the SQLite database")
+		}
+		sqliteDB, err = dbal.InitSQLiteDatabase(dbURL)
+		if err != nil {
+			return nil, err
+		}
+		// SQLite has no LISTEN: new events are always polled
+		notifier = dbal.NewSQLiteEventNotifier(sqliteDB, params.EventSubscriberBufferSize, params.EventPollMinInterval, params.EventPollMaxInterval)
+		db = sqliteDB
+		streamResources = interceptors.NewEventStreamerInterceptor(dbal.NewSQLiteEventStreamer(sqliteDB, notifier)).StreamServerInterceptor
+	default:
+		pgDB, err = dbal.InitDatabase(dbURL)
+		if err != nil {
+			return nil, err
+		}
+		if params.DatabaseReplicaURL != "" {
+			err = pgDB.InitReplica(params.DatabaseReplicaURL, params.MaxReplicaLag)
+			if err != nil {
+				return nil, err
+			}
+		}
+		notifier, err = newEventNotifier(dbURL, pgDB, params)
+		if err != nil {
+			return nil, err
+		}
+		db = pgDB
+		streamResources = interceptors.NewEventStreamerInterceptor(dbal.NewEventStreamer(pgDB.Pool, notifier)).StreamServerInterceptor
+	}
+
+	if params.DBALInstrumentation {
+		db = dbal.NewInstrumentedTransactionFactory(db, params.DBALSlowCallThreshold)
+	}
+
 	channelInterceptor := commonInterceptors.NewChannelInterceptor(params.Config)
 
 	MSPIDInterceptor, err := commonInterceptors.NewMSPIDInterceptor()
@@ -35,7 +110,7 @@ func GetServer(dbURL string, params common.AppParameters, healthcheck *health.Se
 	}
 
 	// providerInterceptor will wrap gRPC requests and inject a ServiceProvider in request's context
-	providerInterceptor := interceptors.NewProviderInterceptor(pgDB, healthcheck)
+	providerInterceptor := interceptors.NewProviderInterceptor(db, params.Config, healthcheck)
 
 	retryInterceptor := commonInterceptors.NewRetryInterceptor(params.RetryBudget, shouldRetry)
 
@@ -51,14 +126,13 @@ func GetServer(dbURL string, params common.AppParameters, healthcheck *health.Se
 		providerInterceptor.UnaryServerInterceptor,
 	)
 
-	dbConnInterceptor := interceptors.NewDatabaseConnInterceptor(dbURL)
 	streamInterceptor := grpc.ChainStreamInterceptor(
 		grpc_prometheus.StreamServerInterceptor,
 		commonInterceptors.StreamServerLoggerInterceptor,
 		commonInterceptors.StreamServerRequestLogger,
 		MSPIDInterceptor.StreamServerInterceptor,
 		channelInterceptor.StreamServerInterceptor,
-		dbConnInterceptor.StreamServerInterceptor,
+		streamResources,
 	)
 	serverOptions := append(params.GrpcOptions, unaryInterceptor, streamInterceptor) //nolint:gocritic
 
@@ -75,29 +149,78 @@ func GetServer(dbURL string, params common.AppParameters, healthcheck *health.Se
 	asset.RegisterComputePlanServiceServer(server, handlers.NewComputePlanServer())
 	asset.RegisterPerformanceServiceServer(server, handlers.NewPerformanceServer())
 	asset.RegisterProfilingServiceServer(server, handlers.NewProfilingServer())
+	asset.RegisterArchiveServiceServer(server, handlers.NewArchiveServer())
 	asset.RegisterEventServiceServer(server, handlers.NewEventServer())
 	asset.RegisterInfoServiceServer(server, handlers.NewInfoServer())
 	asset.RegisterFailureReportServiceServer(server, handlers.NewFailureReportServer())
+	asset.RegisterWebhookServiceServer(server, handlers.NewWebhookServer())
+
+	if notifier != nil {
+		notifier.Start(context.Background())
+	}
+
+	reaper := NewTaskLeaseReaper(db, params.Config, params.TaskLeaseReaperInterval)
+	reaper.Start(context.Background())
+
+	purger := NewPlanPurger(db, params.Config, params.PlanPurgeInterval, params.PlanArchiveDir)
+	purger.Start(context.Background())
+
+	hooks := NewWebhookDispatcher(db, params.Config, params.WebhookDispatchInterval, params.WebhookTimeout, params.WebhookMaxAttempts)
+	hooks.Start(context.Background())
+
+	relay := NewEventRelay(pgDB, params.Config, sinks, params.EventRelayInterval)
+	relay.Start(context.Background())
 
 	return &AppServer{
-		grpc: server,
-		db:   pgDB,
+		grpc:     server,
+		db:       pgDB,
+		sqliteDB: sqliteDB,
+		notifier: notifier,
+		reaper:   reaper,
+		purger:   purger,
+		hooks:    hooks,
+		relay:    relay,
 	}, nil
 }
 
+// newEventNotifier returns the notifier shared by every event stream, according to the subscription mode.
+func newEventNotifier(dbURL string, db *dbal.Database, params common.AppParameters) (*dbal.EventNotifier, error) {
+	switch params.EventSubscriptionMode {
+	case EventSubscriptionModeListen, "":
+		return dbal.NewEventNotifier(dbURL, params.EventSubscriberBufferSize), nil
+	case EventSubscriptionModePoll:
+		return dbal.NewPollingEventNotifier(db.Pool, params.EventSubscriberBufferSize, params.EventPollMinInterval, params.EventPollMaxInterval), nil
+	default:
+		return nil, fmt.Errorf("unknown event subscription mode %q", params.EventSubscriptionMode)
+	}
+}
+
 func (a *AppServer) GetGrpcServer() *grpc.Server {
 	return a.grpc
 }
 
 func (a *AppServer) Stop() {
 	a.grpc.Stop()
-	a.db.Close()
+	a.reaper.Stop()
+	a.purger.Stop()
+	a.hooks.Stop()
+	a.relay.Stop()
+	if a.notifier != nil {
+		a.notifier.Stop()
+	}
+	if a.db != nil {
+		a.db.Close()
+	}
+	if a.sqliteDB != nil {
+		a.sqliteDB.Close()
+	}
 }
 
 // shouldRetry is used as RetryInterceptor's checker function
 // and allow a retry on transaction serialization failure.
+// SQLite serializes write transactions: a transaction failing to acquire the database lock is retried as well.
 func shouldRetry(err error) bool {
 	var pgErr *pgconn.PgError
 
-	return errors.As(err, &pgErr) && pgErr.Code == pgerrcode.SerializationFailure
+	return (errors.As(err, &pgErr) && pgErr.Code == pgerrcode.SerializationFailure) || dbal.IsSQLiteBusy(err)
 }
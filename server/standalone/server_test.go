@@ -2,11 +2,16 @@ package standalone
 
 import (
 	"fmt"
+	"path/filepath"
 	"testing"
 
 	"github.com/jackc/pgconn"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/substra/orchestrator/lib/errors"
+	"github.com/substra/orchestrator/server/common"
+	"github.com/substra/orchestrator/server/standalone/dbal"
+	"google.golang.org/grpc/health"
 )
 
 func TestRetryOnUnserializableTransaction(t *testing.T) {
@@ -15,3 +20,49 @@ func TestRetryOnUnserializableTransaction(t *testing.T) {
 	assert.False(t, shouldRetry(&pgconn.PgError{Code: "1234"}))
 	assert.False(t, shouldRetry(fmt.Errorf("not a pgconn error")))
 }
+
+func TestNewEventNotifier(t *testing.T) {
+	db := &dbal.Database{}
+
+	for _, mode := range []string{"", EventSubscriptionModeListen, EventSubscriptionModePoll} {
+		notifier, err := newEventNotifier("postgresql://localhost", db, common.AppParameters{EventSubscriptionMode: mode})
+		assert.NoError(t, err, mode)
+		assert.NotNil(t, notifier, mode)
+	}
+
+	_, err := newEventNotifier("postgresql://localhost", db, common.AppParameters{EventSubscriptionMode: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestGetServerInMemory(t *testing.T) {
+	t.Setenv("ORCHESTRATOR_VERIFY_CLIENT_MSP_ID", "false")
+
+	server, err := GetServer(dbal.MemoryDatabaseURL, common.AppParameters{Config: &common.OrchestratorConfiguration{}}, health.NewServer())
+	require.NoError(t, err)
+	server.Stop()
+
+	params := common.AppParameters{Config: &common.OrchestratorConfiguration{
+		EventSinks: []common.EventSinkConfiguration{{Name: "archive", Type: "file", Path: "/tmp/events.ndjson"}},
+	}}
+	_, err = GetServer(dbal.MemoryDatabaseURL, params, health.NewServer())
+	assert.Error(t, err, "event sinks should not be supported")
+
+	params = common.AppParameters{Config: &common.OrchestratorConfiguration{}, DatabaseReplicaURL: "postgresql://replica/orchestrator"}
+	_, err = GetServer(dbal.MemoryDatabaseURL, params, health.NewServer())
+	assert.Error(t, err, "read replicas should not be supported")
+}
+
+func TestGetServerSQLite(t *testing.T) {
+	t.Setenv("ORCHESTRATOR_VERIFY_CLIENT_MSP_ID", "false")
+	dbURL := dbal.SQLiteDatabaseScheme + filepath.Join(t.TempDir(), "orchestrator.db")
+
+	server, err := GetServer(dbURL, common.AppParameters{Config: &common.OrchestratorConfiguration{}}, health.NewServer())
+	require.NoError(t, err)
+	server.Stop()
+
+	params := common.AppParameters{Config: &common.OrchestratorConfiguration{
+		EventSinks: []common.EventSinkConfiguration{{Name: "archive", Type: "file", Path: "/tmp/events.ndjson"}},
+	}}
+	_, err = GetServer(dbURL, params, health.NewServer())
+	assert.Error(t, err, "event sinks should not be supported")
+}
@@ -0,0 +1,184 @@
+package standalone
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/lib/service"
+	"github.com/substra/orchestrator/server/common"
+	"github.com/substra/orchestrator/server/standalone/dbal"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	webhookEventHeader     = "X-Orchestrator-Event-Id"
+	webhookDeliveryHeader  = "X-Orchestrator-Delivery-Id"
+	webhookTimestampHeader = "X-Orchestrator-Timestamp"
+	webhookSignatureHeader = "X-Orchestrator-Signature"
+)
+
+// webhookBatchSize is the maximum number of events pushed to a webhook in a single dispatch.
+const webhookBatchSize = 100
+
+// webhookClaimDuration is the time given to a dispatch to send its events.
+// A webhook claimed by a dispatch which did not complete, eg: because the orchestrator stopped,
+// can be dispatched again once this delay and the time to record the deliveries have elapsed.
+const webhookClaimDuration = 5 * time.Minute
+
+const (
+	webhookInitialBackoff = 30 * time.Second
+	webhookMaxBackoff     = time.Hour
+)
+
+// WebhookDispatcher periodically pushes the new events of every channel to the registered webhooks.
+type WebhookDispatcher struct {
+	periodicJob
+	db     dbal.TransactionFactory
+	config *common.OrchestratorConfiguration
+	client *http.Client
+	policy *service.WebhookRetryPolicy
+}
+
+// NewWebhookDispatcher returns a dispatcher looking for events to push at the given interval.
+// Failed deliveries are dead-lettered after maxAttempts attempts.
+func NewWebhookDispatcher(db dbal.TransactionFactory, config *common.OrchestratorConfiguration, interval time.Duration, timeout time.Duration, maxAttempts uint32) *WebhookDispatcher {
+	w := &WebhookDispatcher{
+		db:     db,
+		config: config,
+		client: &http.Client{Timeout: timeout},
+		policy: &service.WebhookRetryPolicy{
+			MaxAttempts:    maxAttempts,
+			InitialBackoff: webhookInitialBackoff,
+			MaxBackoff:     webhookMaxBackoff,
+		},
+	}
+	w.periodicJob = periodicJob{
+		name:     "webhook dispatcher",
+		interval: interval,
+		run:      w.dispatchAll,
+	}
+
+	return w
+}
+
+func (w *WebhookDispatcher) dispatchAll(ctx context.Context) {
+	for channel := range w.config.Channels {
+		logger := log.With().Str("channel", channel).Logger()
+		ctx := logger.WithContext(ctx)
+
+		var keys []string
+		err := inChannelTransaction(ctx, w.db, w.config, channel, func(provider service.DependenciesProvider) error {
+			var err error
+			keys, err = provider.GetWebhookService().GetDueWebhookKeys()
+			return err
+		})
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to list webhooks")
+			continue
+		}
+
+		for _, key := range keys {
+			if ctx.Err() != nil {
+				return
+			}
+
+			err := w.dispatch(ctx, channel, key)
+			if err != nil {
+				logger.Error().Err(err).Str("webhookKey", key).Msg("failed to dispatch webhook events")
+			}
+		}
+	}
+}
+
+// dispatch pushes the pending events of a single webhook.
+// The events are claimed in a first transaction, sent outside of any transaction,
+// then the deliveries are recorded in a second transaction.
+func (w *WebhookDispatcher) dispatch(ctx context.Context, channel string, key string) error {
+	var dispatch *service.WebhookDispatch
+
+	err := inChannelTransaction(ctx, w.db, w.config, channel, func(provider service.DependenciesProvider) error {
+		var err error
+		dispatch, err = provider.GetWebhookService().ClaimWebhookEvents(key, webhookClaimDuration+2*w.client.Timeout, webhookBatchSize)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if dispatch == nil {
+		log.Ctx(ctx).Debug().Str("webhookKey", key).Msg("webhook is already being dispatched")
+		return nil
+	}
+
+	// Leave enough time to record the deliveries before the claim expires
+	dispatch.Send(w.sender(ctx), w.policy, dispatch.ClaimedUntil.Add(-2*w.client.Timeout))
+
+	var deliveries []*asset.WebhookDelivery
+	err = inRetriedChannelTransaction(ctx, w.db, w.config, channel, func(provider service.DependenciesProvider) error {
+		var err error
+		deliveries, err = provider.GetWebhookService().RecordWebhookDispatch(dispatch, w.policy)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Ctx(ctx).Debug().Str("webhookKey", key).Int("deliveries", len(deliveries)).Msg("dispatched webhook events")
+
+	return nil
+}
+
+// sender returns a function posting events to webhook endpoints.
+// Each request is signed with the secret of the webhook, see signWebhookPayload.
+func (w *WebhookDispatcher) sender(ctx context.Context) service.WebhookSender {
+	return func(webhook *asset.Webhook, secret string, delivery *asset.WebhookDelivery, event *asset.Event) (uint32, error) {
+		body, err := protojson.Marshal(event)
+		if err != nil {
+			return 0, err
+		}
+
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(webhookEventHeader, event.Id)
+		req.Header.Set(webhookDeliveryHeader, delivery.Id)
+		req.Header.Set(webhookTimestampHeader, timestamp)
+		req.Header.Set(webhookSignatureHeader, "sha256="+signWebhookPayload(secret, timestamp, body))
+
+		resp, err := w.client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		// Drain the response so that the connection can be reused
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return uint32(resp.StatusCode), fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+
+		return uint32(resp.StatusCode), nil
+	}
+}
+
+// signWebhookPayload returns the hex encoded HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+// Including the timestamp allows receivers to reject replayed requests.
+func signWebhookPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
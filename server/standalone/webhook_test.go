@@ -0,0 +1,74 @@
+package standalone
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/substra/orchestrator/lib/asset"
+	"github.com/substra/orchestrator/server/common"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	signature := signWebhookPayload("secret", "1337", []byte(`{"id":"uuid"}`))
+	assert.Equal(t, "bf388f455fb4ea17b64a23cf5450866a83d2c5175e2ea3d2de7e33056a29100a", signature)
+}
+
+func TestWebhookSender(t *testing.T) {
+	event := &asset.Event{Id: "2f9a6ed1-a54c-4cde-9a8b-c0ff2b2c5e9b", AssetKey: "uuid", AssetKind: asset.AssetKind_ASSET_COMPUTE_PLAN}
+	delivery := &asset.WebhookDelivery{Id: "delivery"}
+
+	cases := map[string]struct {
+		status  int
+		success bool
+	}{
+		"success": {http.StatusNoContent, true},
+		"failure": {http.StatusInternalServerError, false},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, event.Id, r.Header.Get(webhookEventHeader))
+				assert.Equal(t, delivery.Id, r.Header.Get(webhookDeliveryHeader))
+				timestamp := r.Header.Get(webhookTimestampHeader)
+				assert.Equal(t, "sha256="+signWebhookPayload("0123456789abcdef", timestamp, body), r.Header.Get(webhookSignatureHeader))
+
+				w.WriteHeader(c.status)
+			}))
+			defer endpoint.Close()
+
+			dispatcher := NewWebhookDispatcher(nil, &common.OrchestratorConfiguration{}, 0, time.Second, 3)
+			send := dispatcher.sender(context.Background())
+
+			code, err := send(&asset.Webhook{Url: endpoint.URL}, "0123456789abcdef", delivery, event)
+			assert.Equal(t, uint32(c.status), code)
+			if c.success {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestWebhookSenderUnreachable(t *testing.T) {
+	endpoint := httptest.NewServer(http.NotFoundHandler())
+	endpoint.Close()
+
+	dispatcher := NewWebhookDispatcher(nil, &common.OrchestratorConfiguration{}, 0, time.Second, 3)
+	send := dispatcher.sender(context.Background())
+
+	code, err := send(&asset.Webhook{Url: endpoint.URL}, "secret", &asset.WebhookDelivery{}, &asset.Event{})
+	assert.Error(t, err)
+	assert.Equal(t, uint32(0), code)
+}
@@ -35,7 +35,7 @@ type Tx interface {
 }
 
 // MockConn augments pgxmock.PgxConnIface with a WaitForNotification method so that
-// it implements dbal.Conn interface.
+// it implements both dbal.Conn and dbal.Listener interfaces.
 type MockConn struct {
 	pgxmock.PgxConnIface
 	mock.Mock